@@ -0,0 +1,175 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package bigquery is a read only adapter for Google BigQuery, it runs the
+// SELECT statements of the builder as query jobs so reporting code written
+// against sqlbuilder.Selector and sqlbuilder.Iterator can target BigQuery:
+//
+//	sess, err := bigquery.Open(bigquery.ConnectionURL{
+//		Project: "my-project",
+//		Dataset: "analytics",
+//		Options: map[string]string{
+//			"max_bytes_billed": "1073741824",
+//		},
+//	})
+//	...
+//	var totals []Total
+//	err = sess.Select("country", db.Raw("COUNT(*) AS visits")).
+//		From("events").
+//		Where("day >= ?", since).
+//		GroupBy("country").
+//		All(&totals)
+//
+// Statements are rendered in standard SQL and their arguments are sent as
+// query parameters. Statements that modify data and transactions return
+// db.ErrUnsupported.
+package bigquery // import "upper.io/db.v3/bigquery"
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/sqladapter"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// Adapter is the public name of the adapter.
+const Adapter = `bigquery`
+
+func init() {
+	sql.Register(driverName, &sqlDriver{})
+
+	sqladapter.Register(Adapter, sqladapter.AdapterFuncs{
+		Driver:      driverName,
+		Template:    template,
+		Collections: collections,
+		TableExists: tableExists,
+		LookupName:  lookupName,
+	})
+}
+
+// Open creates a session that runs queries on the project and dataset of the
+// given settings.
+func Open(settings db.ConnectionURL) (sqlbuilder.Database, error) {
+	return sqlbuilder.Open(Adapter, settings)
+}
+
+type maxBytesBilledKey struct{}
+
+// WithMaxBytesBilled returns a copy of ctx that limits the number of bytes
+// the queries that run on it can bill, overriding the max_bytes_billed
+// option of the session. Queries that would go over the limit fail without
+// being charged:
+//
+//	capped := sess.WithContext(bigquery.WithMaxBytesBilled(ctx, 1<<30))
+//	err := capped.SelectFrom("events").All(&events)
+func WithMaxBytesBilled(ctx context.Context, n int64) context.Context {
+	return context.WithValue(ctx, maxBytesBilledKey{}, n)
+}
+
+// tablesView returns the INFORMATION_SCHEMA view that lists the tables of the
+// dataset of the session, or of the one name is qualified with.
+func tablesView(sess sqlbuilder.Database, name string) (string, string, error) {
+	connURL, err := sessionURL(sess)
+	if err != nil {
+		return "", "", err
+	}
+
+	dataset := connURL.Dataset
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		dataset, name = name[:i], name[i+1:]
+	}
+	if dataset == "" {
+		return "", "", db.ErrMissingDatabaseName
+	}
+
+	return fmt.Sprintf("`%s`.`%s`.INFORMATION_SCHEMA.TABLES", connURL.Project, dataset), name, nil
+}
+
+// collections returns the names of the tables of the session's dataset.
+func collections(sess sqlbuilder.Database) ([]string, error) {
+	view, _, err := tablesView(sess, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := sess.Query("SELECT table_name FROM " + view + " ORDER BY table_name")
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []struct {
+		Name string `db:"table_name"`
+	}
+	if err := sqlbuilder.NewIterator(rows).All(&tables); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(tables))
+	for i := range tables {
+		names[i] = tables[i].Name
+	}
+	return names, nil
+}
+
+// tableExists returns db.ErrCollectionDoesNotExist if there's no table with
+// the given name.
+func tableExists(sess sqlbuilder.Database, name string) error {
+	view, table, err := tablesView(sess, name)
+	if err != nil {
+		return err
+	}
+
+	row, err := sess.QueryRow("SELECT table_name FROM "+view+" WHERE table_name = ?", table)
+	if err != nil {
+		return err
+	}
+	if err := row.Scan(&table); err != nil {
+		if err == sql.ErrNoRows {
+			return db.ErrCollectionDoesNotExist
+		}
+		return err
+	}
+	return nil
+}
+
+// lookupName returns the name of the dataset of the session.
+func lookupName(sess sqlbuilder.Database) (string, error) {
+	if sess.ConnectionURL() == nil {
+		return "", nil
+	}
+	connURL, err := sessionURL(sess)
+	if err != nil {
+		return "", err
+	}
+	return connURL.Dataset, nil
+}
+
+// sessionURL returns the settings the session was opened with.
+func sessionURL(sess sqlbuilder.Database) (ConnectionURL, error) {
+	if sess.ConnectionURL() == nil {
+		return ConnectionURL{}, db.ErrMissingConnURL
+	}
+	return ParseURL(sess.ConnectionURL().String())
+}
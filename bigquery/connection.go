@@ -0,0 +1,100 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bigquery
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const connectionScheme = `bigquery`
+
+// ConnectionURL implements a BigQuery connection struct.
+//
+// Project is the project jobs are run (and billed) in and Dataset the one
+// unqualified table names belong to. The following options are recognized:
+//
+//	location            location jobs are run in, like "US" or "EU".
+//	credentials         path of a service account key file, the application
+//	                    default credentials are used if not given.
+//	endpoint            base URL of the API, like the one of an emulator.
+//	max_bytes_billed    default limit of bytes billed per query, queries
+//	                    that would go over it fail without being charged.
+//	poll_interval       interval job statuses are polled at, like "500ms".
+type ConnectionURL struct {
+	Project string
+	Dataset string
+	Options map[string]string
+}
+
+func (c ConnectionURL) String() (s string) {
+	if c.Project == "" {
+		return ""
+	}
+
+	vv := url.Values{}
+
+	// Converting options into URL values.
+	for k, v := range c.Options {
+		vv.Set(k, v)
+	}
+
+	// Building URL.
+	u := url.URL{
+		Scheme:   connectionScheme,
+		Host:     c.Project,
+		Path:     "/" + c.Dataset,
+		RawQuery: vv.Encode(),
+	}
+
+	return u.String()
+}
+
+// ParseURL parses s into a ConnectionURL struct.
+func ParseURL(s string) (conn ConnectionURL, err error) {
+	var u *url.URL
+
+	if !strings.HasPrefix(s, connectionScheme+"://") {
+		return conn, fmt.Errorf(`Expecting bigquery:// connection scheme.`)
+	}
+
+	if u, err = url.Parse(s); err != nil {
+		return conn, err
+	}
+
+	conn.Project = u.Host
+	conn.Dataset = strings.Trim(u.Path, "/")
+	conn.Options = map[string]string{}
+
+	var vv url.Values
+
+	if vv, err = url.ParseQuery(u.RawQuery); err != nil {
+		return conn, err
+	}
+
+	for k := range vv {
+		conn.Options[k] = vv.Get(k)
+	}
+
+	return conn, err
+}
@@ -0,0 +1,53 @@
+package bigquery
+
+import (
+	"testing"
+)
+
+func TestConnectionURL(t *testing.T) {
+	c := ConnectionURL{}
+
+	// Default connection string is empty.
+	if c.String() != "" {
+		t.Fatal(`Expecting default connection string to be empty, got:`, c.String())
+	}
+
+	c.Project = "my-project"
+	c.Dataset = "analytics"
+
+	if c.String() != "bigquery://my-project/analytics" {
+		t.Fatal(`Test failed, got:`, c.String())
+	}
+
+	c.Options = map[string]string{
+		"location":         "EU",
+		"max_bytes_billed": "1000",
+	}
+
+	if c.String() != "bigquery://my-project/analytics?location=EU&max_bytes_billed=1000" {
+		t.Fatal(`Test failed, got:`, c.String())
+	}
+}
+
+func TestParseConnectionURL(t *testing.T) {
+	u, err := ParseURL("bigquery://my-project/analytics?location=EU&poll_interval=1s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Project != "my-project" {
+		t.Fatal("Failed to parse project.")
+	}
+
+	if u.Dataset != "analytics" {
+		t.Fatal("Failed to parse dataset.")
+	}
+
+	if u.Options["location"] != "EU" || u.Options["poll_interval"] != "1s" {
+		t.Fatal("Expecting options.")
+	}
+
+	if _, err = ParseURL("http://example.org"); err == nil {
+		t.Fatal("Expecting error.")
+	}
+}
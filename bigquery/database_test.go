@@ -0,0 +1,120 @@
+package bigquery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer answers the requests the client sends to run a query job that
+// is reported as running the first time its status is polled.
+type fakeServer struct {
+	mu    sync.Mutex
+	jobs  []map[string]interface{}
+	polls int
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := map[string]interface{}{
+		"jobReference":  map[string]interface{}{"projectId": "my-project", "jobId": "job-1", "location": "US"},
+		"configuration": map[string]interface{}{"query": map[string]interface{}{}},
+		"status":        map[string]interface{}{"state": "RUNNING"},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/jobs"):
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		s.jobs = append(s.jobs, body)
+		_ = json.NewEncoder(w).Encode(job)
+	case strings.HasSuffix(r.URL.Path, "/jobs/job-1"):
+		if s.polls++; s.polls > 1 {
+			job["status"] = map[string]interface{}{"state": "DONE"}
+		}
+		_ = json.NewEncoder(w).Encode(job)
+	case strings.HasSuffix(r.URL.Path, "/queries/job-1"):
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"jobComplete": true,
+			"totalRows":   "2",
+			"schema": map[string]interface{}{
+				"fields": []interface{}{
+					map[string]interface{}{"name": "country", "type": "STRING"},
+					map[string]interface{}{"name": "visits", "type": "INTEGER"},
+				},
+			},
+			"rows": []interface{}{
+				map[string]interface{}{"f": []interface{}{map[string]interface{}{"v": "PE"}, map[string]interface{}{"v": "12"}}},
+				map[string]interface{}{"f": []interface{}{map[string]interface{}{"v": "UY"}, map[string]interface{}{"v": "7"}}},
+			},
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	srv := &fakeServer{}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	sess, err := Open(ConnectionURL{
+		Project: "my-project",
+		Dataset: "analytics",
+		Options: map[string]string{
+			"credentials":      "none",
+			"endpoint":         ts.URL,
+			"max_bytes_billed": "1000",
+			"poll_interval":    "1ms",
+		},
+	})
+	require.NoError(t, err)
+	defer sess.Close()
+
+	var totals []struct {
+		Country string `db:"country"`
+		Visits  int    `db:"visits"`
+	}
+	err = sess.Select("country", "visits").From("totals").Where("visits >", 5).All(&totals)
+	require.NoError(t, err)
+
+	require.Len(t, totals, 2)
+	assert.Equal(t, "PE", totals[0].Country)
+	assert.Equal(t, 12, totals[0].Visits)
+	assert.Equal(t, "UY", totals[1].Country)
+
+	// The status of the job was polled until it was done.
+	assert.Equal(t, 2, srv.polls)
+
+	require.Len(t, srv.jobs, 1)
+	query := srv.jobs[0]["configuration"].(map[string]interface{})["query"].(map[string]interface{})
+	assert.Contains(t, query["query"], "FROM `totals`")
+	assert.Contains(t, query["query"], "WHERE (`visits` > ?)")
+	assert.Equal(t, false, query["useLegacySql"])
+	assert.Equal(t, "1000", query["maximumBytesBilled"])
+	assert.Equal(t, "analytics", query["defaultDataset"].(map[string]interface{})["datasetId"])
+
+	params := query["queryParameters"].([]interface{})
+	require.Len(t, params, 1)
+	assert.Equal(t, "5", params[0].(map[string]interface{})["parameterValue"].(map[string]interface{})["value"])
+
+	// The limit can be set per query.
+	capped := sess.WithContext(WithMaxBytesBilled(context.Background(), 10))
+	err = capped.SelectFrom("totals").All(&totals)
+	require.NoError(t, err)
+
+	require.Len(t, srv.jobs, 2)
+	query = srv.jobs[1]["configuration"].(map[string]interface{})["query"].(map[string]interface{})
+	assert.Equal(t, "10", query["maximumBytesBilled"])
+}
@@ -0,0 +1,343 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bigquery
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	bq "cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"upper.io/db.v3"
+)
+
+// driverName is the name the database/sql driver that runs queries as
+// BigQuery jobs is registered with.
+const driverName = `upper.io/db.v3/bigquery`
+
+const defaultPollInterval = 500 * time.Millisecond
+
+// sqlDriver is a read only database/sql driver on top of the BigQuery client,
+// it lets the builder and sqladapter run statements on BigQuery as they do
+// on any other database. Statements that write and transactions are
+// rejected with db.ErrUnsupported.
+type sqlDriver struct{}
+
+func (d *sqlDriver) Open(dsn string) (driver.Conn, error) {
+	connURL, err := ParseURL(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(context.Background(), connURL)
+}
+
+type conn struct {
+	client  *bq.Client
+	project string
+	dataset string
+
+	maxBytesBilled int64
+	pollInterval   time.Duration
+}
+
+var (
+	_ = driver.QueryerContext(&conn{})
+	_ = driver.ExecerContext(&conn{})
+	_ = driver.NamedValueChecker(&conn{})
+)
+
+func newConn(ctx context.Context, connURL ConnectionURL) (*conn, error) {
+	c := &conn{
+		project:      connURL.Project,
+		dataset:      connURL.Dataset,
+		pollInterval: defaultPollInterval,
+	}
+
+	var err error
+	if v := connURL.Options["max_bytes_billed"]; v != "" {
+		if c.maxBytesBilled, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid max_bytes_billed %q: %v", v, err)
+		}
+	}
+	if v := connURL.Options["poll_interval"]; v != "" {
+		if c.pollInterval, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("invalid poll_interval %q: %v", v, err)
+		}
+	}
+
+	var opts []option.ClientOption
+	switch v := connURL.Options["credentials"]; v {
+	case "":
+	case "none":
+		opts = append(opts, option.WithoutAuthentication())
+	default:
+		opts = append(opts, option.WithAuthCredentialsFile(option.ServiceAccount, v))
+	}
+	if v := connURL.Options["endpoint"]; v != "" {
+		opts = append(opts, option.WithEndpoint(v))
+	}
+
+	if c.client, err = bq.NewClient(ctx, connURL.Project, opts...); err != nil {
+		return nil, err
+	}
+	c.client.Location = connURL.Options["location"]
+
+	return c, nil
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return c.client.Close()
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, db.ErrUnsupported
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return nil, db.ErrUnsupported
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.query(ctx, query, args)
+}
+
+// CheckNamedValue passes arguments to the client as they are, it knows how
+// to send values like civil.Date or *big.Rat the default converter rejects.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.(driver.Valuer); ok {
+		return driver.ErrSkip
+	}
+	return nil
+}
+
+// query runs query as a job and waits for it to finish, "?" placeholders
+// are sent as positional parameters and named arguments as named ones.
+func (c *conn) query(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q := c.client.Query(query)
+	if c.dataset != "" {
+		q.DefaultProjectID = c.project
+		q.DefaultDatasetID = c.dataset
+	}
+
+	q.MaxBytesBilled = c.maxBytesBilled
+	if n, ok := ctx.Value(maxBytesBilledKey{}).(int64); ok {
+		q.MaxBytesBilled = n
+	}
+
+	for _, arg := range args {
+		q.Parameters = append(q.Parameters, bq.QueryParameter{
+			Name:  arg.Name,
+			Value: arg.Value,
+		})
+	}
+
+	job, err := q.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.wait(ctx, job); err != nil {
+		return nil, err
+	}
+
+	it, err := job.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(it)
+}
+
+// wait polls the status of job until it's done, the job is cancelled if ctx
+// is done first.
+func (c *conn) wait(ctx context.Context, job *bq.Job) error {
+	for {
+		status, err := job.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if status.Done() {
+			return status.Err()
+		}
+
+		select {
+		case <-time.After(c.pollInterval):
+		case <-ctx.Done():
+			_ = job.Cancel(context.Background())
+			return ctx.Err()
+		}
+	}
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+var (
+	_ = driver.StmtQueryContext(&stmt{})
+)
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, db.ErrUnsupported
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	named := make([]driver.NamedValue, len(args))
+	for i := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: args[i]}
+	}
+	return s.conn.query(context.Background(), s.query, named)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.query(ctx, s.query, args)
+}
+
+// rows reads the results of a job, the first row is read in advance because
+// the schema of the results is not known before.
+type rows struct {
+	it   *bq.RowIterator
+	next []bq.Value
+	done bool
+}
+
+func newRows(it *bq.RowIterator) (*rows, error) {
+	r := &rows{it: it}
+	if err := it.Next(&r.next); err != nil {
+		if err != iterator.Done {
+			return nil, err
+		}
+		r.done = true
+	}
+	return r, nil
+}
+
+func (r *rows) Columns() []string {
+	columns := make([]string, len(r.it.Schema))
+	for i := range r.it.Schema {
+		columns[i] = r.it.Schema[i].Name
+	}
+	return columns
+}
+
+func (r *rows) Close() error {
+	r.done = true
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+
+	values := r.next
+	if values != nil {
+		r.next = nil
+	} else if err := r.it.Next(&values); err != nil {
+		if err == iterator.Done {
+			r.done = true
+			return io.EOF
+		}
+		return err
+	}
+
+	for i := range dest {
+		v, err := driverValue(r.it.Schema[i], values[i])
+		if err != nil {
+			return err
+		}
+		dest[i] = v
+	}
+	return nil
+}
+
+// driverValue converts a value read from BigQuery into one database/sql can
+// scan. Dates and datetimes become UTC times, NUMERIC and BIGNUMERIC values
+// become strings, and arrays and records are encoded as JSON.
+func driverValue(field *bq.FieldSchema, v bq.Value) (driver.Value, error) {
+	switch v := v.(type) {
+	case nil, int64, float64, bool, string, []byte, time.Time:
+		return v, nil
+	case civil.Date:
+		return v.In(time.UTC), nil
+	case civil.DateTime:
+		return v.In(time.UTC), nil
+	case civil.Time:
+		return v.String(), nil
+	case *big.Rat:
+		if field.Type == bq.BigNumericFieldType {
+			return bq.BigNumericString(v), nil
+		}
+		return bq.NumericString(v), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+	return json.Marshal(jsonValue(field, v, field.Repeated))
+}
+
+// jsonValue turns records into maps keyed by field name so they're encoded as
+// JSON objects instead of arrays.
+func jsonValue(field *bq.FieldSchema, v bq.Value, repeated bool) interface{} {
+	values, ok := v.([]bq.Value)
+	if !ok {
+		return v
+	}
+
+	if repeated {
+		list := make([]interface{}, len(values))
+		for i := range values {
+			list[i] = jsonValue(field, values[i], false)
+		}
+		return list
+	}
+
+	if field.Type == bq.RecordFieldType {
+		record := make(map[string]interface{}, len(values))
+		for i := range field.Schema {
+			if i < len(values) {
+				record[field.Schema[i].Name] = jsonValue(field.Schema[i], values[i], field.Schema[i].Repeated)
+			}
+		}
+		return record
+	}
+
+	return v
+}
@@ -0,0 +1,69 @@
+package bigquery
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	bq "cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"upper.io/db.v3"
+)
+
+func TestDriverValue(t *testing.T) {
+	assert := assert.New(t)
+
+	v, err := driverValue(&bq.FieldSchema{Type: bq.DateFieldType}, civil.Date{Year: 2024, Month: 2, Day: 29})
+	require.NoError(t, err)
+	assert.Equal(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), v)
+
+	v, err = driverValue(&bq.FieldSchema{Type: bq.NumericFieldType}, big.NewRat(1, 4))
+	require.NoError(t, err)
+	assert.Equal("0.250000000", v)
+
+	v, err = driverValue(&bq.FieldSchema{Type: bq.StringFieldType}, nil)
+	require.NoError(t, err)
+	assert.Nil(v)
+
+	v, err = driverValue(&bq.FieldSchema{Type: bq.IntegerFieldType, Repeated: true}, []bq.Value{int64(1), int64(2)})
+	require.NoError(t, err)
+	assert.Equal([]byte(`[1,2]`), v)
+
+	record := &bq.FieldSchema{
+		Type: bq.RecordFieldType,
+		Schema: bq.Schema{
+			{Name: "city", Type: bq.StringFieldType},
+			{Name: "tags", Type: bq.StringFieldType, Repeated: true},
+		},
+	}
+	v, err = driverValue(record, []bq.Value{"Lima", []bq.Value{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal([]byte(`{"city":"Lima","tags":["a","b"]}`), v)
+}
+
+func TestReadOnly(t *testing.T) {
+	sess, err := Open(ConnectionURL{
+		Project: "my-project",
+		Dataset: "analytics",
+		Options: map[string]string{
+			"credentials": "none",
+			"endpoint":    "http://127.0.0.1:1",
+		},
+	})
+	require.NoError(t, err)
+	defer sess.Close()
+
+	assert.Equal(t, "analytics", sess.Name())
+
+	_, err = sess.InsertInto("events").Values(map[string]interface{}{"id": 1}).Exec()
+	assert.Equal(t, db.ErrUnsupported, err)
+
+	_, err = sess.Update("events").Set("id", 2).Exec()
+	assert.Equal(t, db.ErrUnsupported, err)
+
+	_, err = sess.NewTx(context.Background())
+	assert.Equal(t, db.ErrUnsupported, err)
+}
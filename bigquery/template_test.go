@@ -0,0 +1,54 @@
+package bigquery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+func TestTemplateSelect(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		"SELECT * FROM `events`",
+		b.SelectFrom("events").String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM `analytics`.`events`",
+		b.SelectFrom("analytics.events").String(),
+	)
+
+	assert.Equal(
+		"SELECT `country`, COUNT(*) AS visits FROM `events` WHERE (`day` >= $1) GROUP BY `country` ORDER BY `country` ASC",
+		b.Select("country", db.Raw("COUNT(*) AS visits")).From("events").Where("day >=", "2024-01-01").GroupBy("country").OrderBy("country").String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM `events` LIMIT 10 OFFSET 20",
+		b.SelectFrom("events").Limit(10).Offset(20).String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM `events` LIMIT 9223372036854775807 OFFSET 20",
+		b.SelectFrom("events").Offset(20).String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM `events` WHERE (LOWER(`name`) LIKE LOWER($1))",
+		b.SelectFrom("events").Where(db.ILike("name", "%a%")).String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM `events` AS `e` JOIN `users` AS `u` ON (e.user_id = u.id)",
+		b.SelectFrom("events AS e").Join("users AS u").On("e.user_id = u.id").String(),
+	)
+
+	assert.Equal(
+		"SELECT COUNT(*) FROM `events` GROUP BY ROLLUP(`country`, `city`)",
+		b.Select(db.Raw("COUNT(*)")).From("events").GroupBy(db.Rollup("country", "city")).String(),
+	)
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+// Codec transforms the values of struct fields tagged with the codec option
+// before they're written and after they're read, like a codec that encrypts
+// columns with personal data at rest:
+//
+//	type Person struct {
+//		ID  int64  `db:"id,omitempty"`
+//		SSN string `db:"ssn,codec=aes"`
+//	}
+//
+//	sess.SetCodec("aes", aesCodec)
+//
+// Codecs are registered by name on the session, see Settings.SetCodec. Fields
+// of type string or []byte are passed to the codec as is, fields with the json
+// option are passed as their JSON encoding. NULL values are not passed to the
+// codec. Encoded values are sent as []byte, so they're meant to be stored in
+// binary columns, like bytea or BLOB.
+//
+// Conditions are compared against the encoded values of columns, so encoded
+// columns can only be looked up by value if the codec is deterministic. Items
+// kept by the record cache of a session are stored decoded.
+type Codec interface {
+	// Encode transforms the value of a field into the value that is written
+	// to its column.
+	Encode(value []byte) ([]byte, error)
+
+	// Decode transforms the value read from a column back into the value of
+	// its field.
+	Decode(value []byte) ([]byte, error)
+}
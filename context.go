@@ -0,0 +1,63 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+)
+
+type txContextKey struct{}
+
+// ContextWithTx returns a copy of ctx that carries the given transaction.
+// Sessions that run statements with a context carrying a transaction run them
+// within the transaction instead, so functions that only take a context take
+// part in the transaction of their caller:
+//
+//	func (r *Repo) Deposit(ctx context.Context, account int64, amount int) error {
+//		balance := db.Raw("balance + ?", amount)
+//		return r.sess.WithContext(ctx).Collection("accounts").Find(account).
+//			Update(map[string]interface{}{"balance": balance})
+//	}
+//
+//	sess.Tx(ctx, func(tx sqlbuilder.Tx) error {
+//		ctx := db.ContextWithTx(ctx, tx)
+//		if err := repo.Withdraw(ctx, from, amount); err != nil {
+//			return err
+//		}
+//		return repo.Deposit(ctx, to, amount)
+//	})
+//
+// The transaction is only used by sessions of the same database it was
+// started on, other sessions ignore it.
+func ContextWithTx(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext returns the transaction ctx carries, or nil if there is none,
+// see ContextWithTx.
+func TxFromContext(ctx context.Context) Tx {
+	if ctx == nil {
+		return nil
+	}
+	tx, _ := ctx.Value(txContextKey{}).(Tx)
+	return tx
+}
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+type fakeTx struct{}
+
+func (fakeTx) Rollback() error { return nil }
+
+func (fakeTx) Commit() error { return nil }
+
+func TestContextWithTx(t *testing.T) {
+	if tx := TxFromContext(context.Background()); tx != nil {
+		t.Fatalf("Expecting no transaction, got: %v", tx)
+	}
+
+	ctx := ContextWithTx(context.Background(), fakeTx{})
+	if tx := TxFromContext(ctx); tx != (fakeTx{}) {
+		t.Fatalf("Expecting the transaction, got: %v", tx)
+	}
+
+	if tx := TxFromContext(nil); tx != nil {
+		t.Fatalf("Expecting no transaction, got: %v", tx)
+	}
+}
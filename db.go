@@ -77,9 +77,11 @@
 package db // import "upper.io/db.v3"
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 
 	"upper.io/db.v3/internal/immutable"
 )
@@ -157,6 +159,79 @@ type Function interface {
 	Arguments() []interface{}
 }
 
+// Grouping interface defines methods for representing grouping expressions
+// on GROUP BY clauses, like ROLLUP(...), CUBE(...) or GROUPING SETS (...).
+type Grouping interface {
+	// Type returns the grouping type: "ROLLUP", "CUBE" or "GROUPING SETS".
+	Type() string
+
+	// Sets returns the grouping elements, each one of them is a list of
+	// columns.
+	Sets() [][]interface{}
+}
+
+// CaseExpression interface defines methods for building CASE expressions.
+// CaseExpression values are immutable, each method returns a copy.
+type CaseExpression interface {
+	// When adds a "WHEN cond THEN value" branch. The condition accepts the same
+	// values as Where() does, like db.Cond{} or db.Raw().
+	When(cond interface{}, value interface{}) CaseExpression
+
+	// Else sets the value returned when no branch matches.
+	Else(value interface{}) CaseExpression
+
+	// As sets an alias for the expression, the alias is only used when the
+	// expression is given as a column.
+	As(alias string) CaseExpression
+
+	// Branches returns the WHEN branches in the order they were added.
+	Branches() []CaseBranch
+
+	// ElseValue returns the value given to Else and true, or nil and false
+	// if Else was not called.
+	ElseValue() (interface{}, bool)
+
+	// Alias returns the alias given to As.
+	Alias() string
+}
+
+// SearchRank interface defines methods for expressions that score how well a
+// column matches a full-text query, see Rank.
+type SearchRank interface {
+	// Column returns the searched column.
+	Column() string
+
+	// Query returns the full-text query.
+	Query() string
+
+	// As sets an alias for the expression, the alias is only used when the
+	// expression is given as a column.
+	As(alias string) SearchRank
+
+	// Alias returns the alias given to As.
+	Alias() string
+}
+
+// ValuesList interface defines methods for VALUES lists that can be used as
+// tables on FROM and JOIN clauses.
+type ValuesList interface {
+	// As sets an alias for the list, the alias may include column names, like
+	// "v(id, name)".
+	As(alias string) ValuesList
+
+	// Rows returns the rows of the list.
+	Rows() [][]interface{}
+
+	// Alias returns the alias given to As.
+	Alias() string
+}
+
+// CaseBranch represents a "WHEN cond THEN value" branch of a CASE expression.
+type CaseBranch struct {
+	Cond  interface{}
+	Value interface{}
+}
+
 // Marshaler is the interface implemented by struct fields that can transform
 // themselves into values that can be stored on a database.
 type Marshaler interface {
@@ -196,6 +271,10 @@ type Unmarshaler interface {
 //
 //  // Where age > 32 and age < 35
 //  db.Cond{"age >": 32, "age <": 35}
+//
+//  // Where the "status" key of a JSON column equals "active" (on SQL
+//  // databases that support JSON path operators).
+//  db.Cond{"data->>'status'": "active"}
 type Cond map[interface{}]interface{}
 
 // Constraints returns each one of the Cond map records as a constraint.
@@ -244,6 +323,14 @@ func (c Cond) Empty() bool {
 type rawValue struct {
 	v string
 	a *[]interface{} // This may look ugly but allows us to use db.Raw() as keys for db.Cond{}.
+
+	err error
+}
+
+// Err returns the error found while binding the named parameters of the raw
+// value, if any.
+func (r rawValue) Err() error {
+	return r.err
 }
 
 func (r rawValue) Arguments() []interface{} {
@@ -466,6 +553,324 @@ func (f *dbFunc) Name() string {
 	return f.name
 }
 
+// Rollup represents a ROLLUP grouping expression, which produces subtotals for
+// each prefix of the given columns plus a grand total.
+//
+// Example:
+//
+//	// GROUP BY ROLLUP(year, month)
+//	sel.GroupBy(db.Rollup("year", "month"))
+//
+// MySQL only supports ROLLUP as the last GROUP BY expression, it's compiled
+// into "GROUP BY year, month WITH ROLLUP".
+func Rollup(columns ...interface{}) Grouping {
+	return &grouping{typ: "ROLLUP", sets: groupingElements(columns)}
+}
+
+// Cube represents a CUBE grouping expression, which produces subtotals for
+// every combination of the given columns.
+//
+// Example:
+//
+//	// GROUP BY CUBE(country, product)
+//	sel.GroupBy(db.Cube("country", "product"))
+func Cube(columns ...interface{}) Grouping {
+	return &grouping{typ: "CUBE", sets: groupingElements(columns)}
+}
+
+// GroupingSets represents a GROUPING SETS expression, each set is a list of
+// columns to group by, an empty set represents the grand total.
+//
+// Example:
+//
+//	// GROUP BY GROUPING SETS ((country, product), (country), ())
+//	sel.GroupBy(db.GroupingSets(
+//		[]interface{}{"country", "product"},
+//		[]interface{}{"country"},
+//		[]interface{}{},
+//	))
+func GroupingSets(sets ...[]interface{}) Grouping {
+	return &grouping{typ: "GROUPING SETS", sets: sets}
+}
+
+type grouping struct {
+	typ  string
+	sets [][]interface{}
+}
+
+func (g *grouping) Type() string {
+	return g.typ
+}
+
+func (g *grouping) Sets() [][]interface{} {
+	return g.sets
+}
+
+func groupingElements(columns []interface{}) [][]interface{} {
+	sets := make([][]interface{}, len(columns))
+	for i := range columns {
+		sets[i] = []interface{}{columns[i]}
+	}
+	return sets
+}
+
+// In creates a row value IN condition that matches rows where the given
+// columns are equal to any of the given tuples. Adapters that don't support
+// row values get an equivalent condition joined with OR and AND operators.
+//
+// Example:
+//
+//	// (author_id, title) IN ((1, 'Hamlet'), (2, 'Ulysses'))
+//	db.In([]string{"author_id", "title"}, [][]interface{}{
+//		{1, "Hamlet"},
+//		{2, "Ulysses"},
+//	})
+//
+// The same condition can be written with db.Cond:
+//
+//	db.Cond{"(author_id, title) IN": [][]interface{}{{1, "Hamlet"}, {2, "Ulysses"}}}
+func In(columns []string, tuples [][]interface{}) Cond {
+	return Cond{"(" + strings.Join(columns, ", ") + ") IN": tuples}
+}
+
+// Exists creates an EXISTS condition with the given subquery, which is usually
+// a sqlbuilder.Selector. The subquery is compiled along with the outer query
+// and its arguments are merged into it.
+//
+// Example:
+//
+//	// EXISTS (SELECT 1 FROM "publication" WHERE (author_id = artist.id))
+//	db.Exists(sess.Select(db.Raw("1")).From("publication").Where("author_id = artist.id"))
+func Exists(subquery interface{}) RawValue {
+	return Raw("EXISTS ?", subquery)
+}
+
+// NotExists creates a NOT EXISTS condition with the given subquery, see
+// Exists.
+func NotExists(subquery interface{}) RawValue {
+	return Raw("NOT EXISTS ?", subquery)
+}
+
+// Comparison is a Cond key that holds a column name and an operator
+// separately. Unlike string keys, it's not split on spaces to find the
+// operator, so the whole name is taken as the column. It's created by IsNull,
+// NotNull, ILike, Between and NotIn.
+type Comparison struct {
+	Column   string
+	Operator string
+}
+
+// String returns the comparison as a string key, like "name IS NOT".
+func (c Comparison) String() string {
+	return c.Column + " " + c.Operator
+}
+
+// IsNull creates a condition that matches rows where the given column is
+// NULL.
+//
+// Example:
+//
+//	// "deleted_at" IS NULL
+//	db.IsNull("deleted_at")
+func IsNull(column string) Cond {
+	return Cond{Comparison{column, "IS"}: nil}
+}
+
+// NotNull creates a condition that matches rows where the given column is not
+// NULL.
+//
+// Example:
+//
+//	// "deleted_at" IS NOT NULL
+//	db.NotNull("deleted_at")
+func NotNull(column string) Cond {
+	return Cond{Comparison{column, "IS NOT"}: nil}
+}
+
+// ILike creates a case-insensitive LIKE condition. Adapters that don't
+// support the ILIKE operator compare the lowercased column and pattern
+// instead.
+//
+// Example:
+//
+//	// "name" ILIKE '%smith%'
+//	// LOWER("name") LIKE LOWER('%smith%')
+//	db.ILike("name", "%smith%")
+func ILike(column string, pattern interface{}) Cond {
+	return Cond{Comparison{column, "ILIKE"}: pattern}
+}
+
+// Between creates a condition that matches rows where the given column is
+// between a and b, both included.
+//
+// Example:
+//
+//	// "price" BETWEEN 10 AND 20
+//	db.Between("price", 10, 20)
+func Between(column string, a interface{}, b interface{}) Cond {
+	return Cond{Comparison{column, "BETWEEN"}: Raw("? AND ?", a, b)}
+}
+
+// NotIn creates a condition that matches rows where the given column is not
+// equal to any of the given values, values must be a slice. An empty slice
+// excludes nothing and matches all rows.
+//
+// Example:
+//
+//	// "id" NOT IN (1, 2, 3)
+//	db.NotIn("id", []int{1, 2, 3})
+func NotIn(column string, values interface{}) Cond {
+	return Cond{Comparison{column, "NOT IN"}: values}
+}
+
+// Match creates a full-text search condition that matches rows where the
+// given column matches query, which is plain text in natural language. It's
+// compiled into the native full-text search of each adapter:
+//
+//	// PostgreSQL
+//	to_tsvector("body") @@ plainto_tsquery($1)
+//
+//	// MySQL, the column requires a FULLTEXT index
+//	MATCH (`body`) AGAINST (? IN NATURAL LANGUAGE MODE)
+//
+//	// SQLite, the column must belong to a FTS5 table
+//	"body" MATCH ?
+//
+// SQLite interprets query with the FTS5 query syntax, use the name of the
+// table as column to search all of its columns.
+func Match(column string, query string) Cond {
+	return Cond{Comparison{column, "MATCH"}: query}
+}
+
+// Rank creates an expression that scores how well the given column matches
+// a full-text query, see Match. Higher values are better matches, so ordering
+// by a rank puts the best matches first. It can also be given as a column.
+//
+// Example:
+//
+//	sess.SelectFrom("docs").
+//		Where(db.Match("body", "full text search")).
+//		OrderBy(db.Rank("body", "full text search"))
+//
+// On SQLite the rank is the one of the FTS5 MATCH condition of the query, so
+// query is not used.
+func Rank(column string, query string) SearchRank {
+	return &searchRank{column: column, query: query}
+}
+
+type searchRank struct {
+	column string
+	query  string
+	alias  string
+}
+
+func (r *searchRank) Column() string {
+	return r.column
+}
+
+func (r *searchRank) Query() string {
+	return r.query
+}
+
+func (r *searchRank) As(alias string) SearchRank {
+	nr := *r
+	nr.alias = alias
+	return &nr
+}
+
+func (r *searchRank) Alias() string {
+	return r.alias
+}
+
+// Values creates a VALUES list that can be used as a table on FROM and JOIN
+// clauses, all rows must have the same number of values. Values are passed as
+// arguments, use db.Raw to add casts, like db.Raw("?::int", id) on
+// PostgreSQL.
+//
+// Example:
+//
+//	// SELECT * FROM (VALUES ($1, $2), ($3, $4)) AS "v" ("id", "name")
+//	sess.SelectFrom(db.Values([][]interface{}{
+//		{1, "Hamlet"},
+//		{2, "Ulysses"},
+//	}).As("v(id, name)"))
+func Values(rows [][]interface{}) ValuesList {
+	return &valuesList{rows: rows}
+}
+
+type valuesList struct {
+	rows  [][]interface{}
+	alias string
+}
+
+func (v *valuesList) As(alias string) ValuesList {
+	nv := *v
+	nv.alias = alias
+	return &nv
+}
+
+func (v *valuesList) Rows() [][]interface{} {
+	return v.rows
+}
+
+func (v *valuesList) Alias() string {
+	return v.alias
+}
+
+// Case creates a CASE expression that can be used as a column, on ORDER BY
+// clauses or as a value on UPDATE statements.
+//
+// Example:
+//
+//	// CASE WHEN ("stock" = 0) THEN 'sold out' WHEN ("stock" < 10) THEN 'few left' ELSE 'available' END AS "availability"
+//	db.Case().
+//		When(db.Cond{"stock": 0}, "sold out").
+//		When(db.Cond{"stock <": 10}, "few left").
+//		Else("available").
+//		As("availability")
+func Case() CaseExpression {
+	return &caseExpr{}
+}
+
+type caseExpr struct {
+	branches  []CaseBranch
+	elseValue interface{}
+	hasElse   bool
+	alias     string
+}
+
+func (c *caseExpr) When(cond interface{}, value interface{}) CaseExpression {
+	nc := *c
+	nc.branches = make([]CaseBranch, len(c.branches), len(c.branches)+1)
+	copy(nc.branches, c.branches)
+	nc.branches = append(nc.branches, CaseBranch{Cond: cond, Value: value})
+	return &nc
+}
+
+func (c *caseExpr) Else(value interface{}) CaseExpression {
+	nc := *c
+	nc.elseValue, nc.hasElse = value, true
+	return &nc
+}
+
+func (c *caseExpr) As(alias string) CaseExpression {
+	nc := *c
+	nc.alias = alias
+	return &nc
+}
+
+func (c *caseExpr) Branches() []CaseBranch {
+	return c.branches
+}
+
+func (c *caseExpr) ElseValue() (interface{}, bool) {
+	return c.elseValue, c.hasElse
+}
+
+func (c *caseExpr) Alias() string {
+	return c.alias
+}
+
 // And joins conditions under logical conjunction. Conditions can be
 // represented by db.Cond{}, db.Or() or db.And().
 //
@@ -511,8 +916,21 @@ func Or(conds ...Compound) *Union {
 //	// SOUNDEX('Hello')
 //	Raw("SOUNDEX('Hello')")
 //
+// Named parameters can be bound from a single map or struct, see BindNamed:
+//
+//	// name = ? AND age > ?
+//	Raw("name = :name AND age > :age", map[string]interface{}{"name": "Hayao", "age": 30})
+//
+// If a named parameter has no value the returned value carries the error, see
+// Err, and the query that uses it fails with it.
+//
 // Raw returns a value that satifies the db.RawValue interface.
 func Raw(value string, args ...interface{}) RawValue {
+	q, a, err := BindNamed(value, args...)
+	if err != nil {
+		return rawValue{v: value, err: err}
+	}
+	value, args = q, a
 	r := rawValue{v: value, a: nil}
 	if len(args) > 0 {
 		r.a = &args
@@ -585,22 +1003,42 @@ type Collection interface {
 
 	// InsertReturning is like Insert() but it updates the passed pointer to map
 	// or struct with the newly inserted element (and with automatic fields, like
-	// IDs, timestamps, etc). This is all done atomically within a transaction.
-	// If the database does not support transactions this method returns
-	// db.ErrUnsupported.
+	// IDs, timestamps, etc). On PostgreSQL and MSSQL the element is read with a
+	// RETURNING (or OUTPUT) clause of the INSERT statement, other databases
+	// query it by the ID that was returned by the database (like
+	// LAST_INSERT_ID() on MySQL or last_insert_rowid() on SQLite) atomically
+	// within a transaction. If the database does not support transactions this
+	// method returns db.ErrUnsupported.
 	InsertReturning(interface{}) error
 
 	// UpdateReturning takes a pointer to map or struct and tries to update the
 	// given item on the collection based on the item's primary keys. Once the
-	// element is updated, UpdateReturning will query the element that was just
-	// updated. If the database does not support transactions this method returns
-	// db.ErrUnsupported
+	// element is updated, the pointer is updated with the values that are
+	// stored in the database, like the ones set by triggers or defaults. Like
+	// InsertReturning, UpdateReturning uses a RETURNING (or OUTPUT) clause when
+	// the database supports it, or queries the element within a transaction
+	// otherwise.
 	UpdateReturning(interface{}) error
 
 	// Exists returns true if the collection exists, false otherwise.
 	Exists() bool
 
-	// Find defines a new result set with elements from the collection.
+	// Find defines a new result set with elements from the collection. If a
+	// single struct (or pointer to struct) is given, the result set matches it
+	// by its primary keys. Struct fields tagged with the pk option are used as
+	// primary keys instead of the ones of the table, several fields may be
+	// tagged to match a compound key:
+	//
+	//	type Membership struct {
+	//		GroupID int64  `db:"group_id,pk"`
+	//		UserID  int64  `db:"user_id,pk"`
+	//		Role    string `db:"role"`
+	//	}
+	//
+	//	err = col.Find(&membership).Update(&membership)
+	//
+	// The ID returned by Insert on tables with compound keys is a Cond that
+	// holds all the key parts.
 	Find(...interface{}) Result
 
 	// Truncate removes all elements on the collection and resets the
@@ -609,6 +1047,47 @@ type Collection interface {
 
 	// Name returns the name of the collection.
 	Name() string
+
+	// BulkInsert returns a BulkInserter that can be used to load large amounts
+	// of items into the collection.
+	BulkInsert() BulkInserter
+}
+
+// BulkInserter loads large amounts of items into a collection in batches,
+// using the fastest method the adapter supports (like COPY FROM on
+// PostgreSQL).
+//
+//   err := sess.Collection("people").BulkInsert().BatchSize(5000).Insert(people)
+type BulkInserter interface {
+	// BatchSize sets the maximum number of items that are sent to the database
+	// at once, defaults to 1000.
+	BatchSize(int) BulkInserter
+
+	// Insert loads the given items, which can be either a slice or a channel of
+	// structs or maps. When a channel is given, Insert blocks until it is
+	// closed. Insert stops at the first batch that fails and returns a
+	// *BatchError, batches that were loaded before are not rolled back.
+	Insert(items interface{}) error
+}
+
+// BatchError is the error returned by BulkInserter when a batch fails.
+type BatchError struct {
+	// Batch is the zero-based index of the batch that failed.
+	Batch int
+
+	// Offset is the position of the first item of the batch in the input.
+	Offset int
+
+	// Size is the number of items in the batch.
+	Size int
+
+	// Err is the error that made the batch fail.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("upper: batch %d (items %d to %d) failed: %v", e.Batch, e.Offset, e.Offset+e.Size-1, e.Err)
 }
 
 // Result is an interface that defines methods useful for working with result
@@ -657,18 +1136,82 @@ type Result interface {
 	// or columns.
 	Group(...interface{}) Result
 
+	// Preload loads the given relations of the items that are fetched by All()
+	// or One(), relations are struct fields that are declared with the
+	// "relation" tag. Each relation is loaded with a single query:
+	//
+	//   type User struct {
+	//     ID     int64   `db:"id"`
+	//     Orders []Order `db:"-" relation:"has_many,fk=user_id"`
+	//   }
+	//
+	//   err := sess.Collection("users").Find().Preload("Orders").All(&users)
+	Preload(...string) Result
+
+	// Unscoped makes the result set include soft deleted items and makes
+	// Delete() remove items permanently. Soft deletes are enabled on structs
+	// that have a field with the "softdelete" option, the column must be NULL
+	// for items that were not deleted:
+	//
+	//   type User struct {
+	//     ID        int64      `db:"id"`
+	//     DeletedAt *time.Time `db:"deleted_at,softdelete"`
+	//   }
+	//
+	// Results fetched into such structs exclude soft deleted items, and
+	// Delete() on a result set that was created with Collection.Find(&user)
+	// sets the soft delete column to the current time instead of deleting the
+	// row.
+	Unscoped() Result
+
 	// Delete deletes all items within the result set. `Offset()` and `Limit()` are
 	// not honoured by `Delete()`.
 	Delete() error
 
+	// DeleteContext is like Delete, but the statement runs within the given
+	// context.
+	DeleteContext(context.Context) error
+
 	// Update modifies all items within the result set. `Offset()` and `Limit()`
 	// are not honoured by `Update()`.
 	Update(interface{}) error
 
+	// UpdateContext is like Update, but the statement runs within the given
+	// context.
+	UpdateContext(context.Context, interface{}) error
+
 	// Count returns the number of items that match the set conditions. `Offset()`
 	// and `Limit()` are not honoured by `Count()`
 	Count() (uint64, error)
 
+	// CountContext is like Count, but the query runs within the given context.
+	CountContext(context.Context) (uint64, error)
+
+	// Exists returns true if at least one item matches the set conditions.
+	// It's cheaper than Count() because the database stops looking after the
+	// first match.
+	Exists() (bool, error)
+
+	// ExistsContext is like Exists, but the query runs within the given
+	// context.
+	ExistsContext(context.Context) (bool, error)
+
+	// CacheCount returns a result set that remembers the value returned by
+	// the first successful Count() call. Result sets that are derived from it
+	// with Limit(), Offset(), OrderBy(), Select() or Preload() share the
+	// remembered value, so the items of a set are counted only once while its
+	// pages are rendered:
+	//
+	//	res = col.Find(db.Cond{"active": true}).CacheCount()
+	//	total, err := res.Count()
+	//	...
+	//	err = res.Limit(20).Offset(40).All(&items)
+	//	total, err = res.Limit(20).Offset(40).Count() // No query is sent.
+	//
+	// Where(), And(), Group() and Unscoped() change the items of the set and
+	// make derived result sets count them again.
+	CacheCount() Result
+
 	// Next fetches the next result within the result set and dumps it into the
 	// given pointer to struct or pointer to map. You must call
 	// `Close()` after finishing using `Next()`.
@@ -684,12 +1227,24 @@ type Result interface {
 	// after using One().
 	One(ptrToStruct interface{}) error
 
+	// OneContext is like One, but the query runs within the given context, so
+	// it's cancelled once the context is done:
+	//
+	//   ctx, cancel := context.WithTimeout(ctx, time.Second)
+	//   defer cancel()
+	//
+	//   err := col.Find(id).OneContext(ctx, &item)
+	OneContext(ctx context.Context, ptrToStruct interface{}) error
+
 	// All fetches all results within the result set and dumps them into the
 	// given pointer to slice of maps or structs.  The result set is
 	// automatically closed, so there is no need to call Close() after
 	// using All().
 	All(sliceOfStructs interface{}) error
 
+	// AllContext is like All, but the query runs within the given context.
+	AllContext(ctx context.Context, sliceOfStructs interface{}) error
+
 	// Close closes the result set and frees all locked resources.
 	Close() error
 }
@@ -701,6 +1256,14 @@ type ConnectionURL interface {
 	String() string
 }
 
+// Conn is a single connection of the pool of a session, it's given to the
+// OnConnect function of the connection URL of SQL adapters, which is called on
+// every new connection before it's used.
+type Conn interface {
+	// Exec executes a statement on the connection.
+	Exec(query string, args ...interface{}) error
+}
+
 type condKeys []interface{}
 
 func (ck condKeys) Len() int {
@@ -22,6 +22,7 @@
 package db_test
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -31,8 +32,8 @@ import (
 	"testing"
 	"time"
 
-	"gopkg.in/mgo.v2"
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	mongodriver "go.mongodb.org/mongo-driver/v2/mongo"
 	"upper.io/db.v3"
 	"upper.io/db.v3/mongo"
 	"upper.io/db.v3/mssql"
@@ -125,19 +126,13 @@ func init() {
 
 var setupFn = map[string]func(driver interface{}) error{
 	`mongo`: func(driver interface{}) error {
-		if mgod, ok := driver.(*mgo.Session); ok {
-			var col *mgo.Collection
-			col = mgod.DB("upperio_tests").C("birthdays")
-			col.DropCollection()
-
-			col = mgod.DB("upperio_tests").C("fibonacci")
-			col.DropCollection()
-
-			col = mgod.DB("upperio_tests").C("is_even")
-			col.DropCollection()
-
-			col = mgod.DB("upperio_tests").C("CaSe_TesT")
-			col.DropCollection()
+		if client, ok := driver.(*mongodriver.Client); ok {
+			ctx := context.Background()
+			for _, name := range []string{"birthdays", "fibonacci", "is_even", "CaSe_TesT"} {
+				if err := client.Database("upperio_tests").Collection(name).Drop(ctx); err != nil {
+					return err
+				}
+			}
 			return nil
 		}
 		return errDriverErr
@@ -467,7 +462,7 @@ type oddEven struct {
 	// Test for JSON option.
 	Input int `json:"input" db:"input"`
 	// Test for JSON option.
-	// The "bson" tag is required by mgo.
+	// The "bson" tag is required by the mongo driver.
 	IsEven bool `json:"is_even" db:"is_even,json" bson:"is_even"`
 	OmitMe bool `json:"omit_me" db:"-" bson:"-"`
 }
@@ -475,14 +470,14 @@ type oddEven struct {
 // Struct that relies on explicit mapping.
 type mapE struct {
 	ID       uint          `db:"id,omitempty" bson:"-"`
-	MongoID  bson.ObjectId `db:"-" bson:"_id,omitempty"`
+	MongoID  bson.ObjectID `db:"-" bson:"_id,omitempty"`
 	CaseTest string        `db:"case_test" bson:"case_test"`
 }
 
 // Struct that will fallback to default mapping.
 type mapN struct {
 	ID        uint          `db:"id,omitempty"`
-	MongoID   bson.ObjectId `db:"-" bson:"_id,omitempty"`
+	MongoID   bson.ObjectID `db:"-" bson:"_id,omitempty"`
 	Case_TEST string        `db:"case_test"`
 }
 
@@ -626,7 +621,7 @@ func TestSimpleCRUD(t *testing.T) {
 			var res db.Result
 			switch wrapper {
 			case `mongo`:
-				res = col.Find(db.Cond{"_id": id.(bson.ObjectId)})
+				res = col.Find(db.Cond{"_id": id.(bson.ObjectID)})
 			case `ql`:
 				res = col.Find(db.Cond{"id()": id})
 			default:
@@ -1037,7 +1032,7 @@ func TestEven(t *testing.T) {
 			res = col.Find()
 
 			var item2 struct {
-				Value uint `db:"input" bson:"input"` // The "bson" tag is required by mgo.
+				Value uint `db:"input" bson:"input"` // The "bson" tag is required by the mongo driver.
 			}
 			for res.Next(&item2) {
 				if item2.Value%2 == 0 {
@@ -1155,7 +1150,7 @@ func TestExplicitAndDefaultMapping(t *testing.T) {
 			}
 
 			if wrapper == `mongo` {
-				if testE.MongoID.Valid() == false {
+				if testE.MongoID.IsZero() {
 					t.Fatalf("Expecting an ID.")
 				}
 			} else {
@@ -1188,7 +1183,7 @@ func TestExplicitAndDefaultMapping(t *testing.T) {
 			}
 
 			if wrapper == `mongo` {
-				if testN.MongoID.Valid() == false {
+				if testN.MongoID.IsZero() {
 					t.Fatalf("Expecting an ID.")
 				}
 			} else {
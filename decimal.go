@@ -0,0 +1,398 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrInexactDecimal is returned when scanning a rational number, like the
+// ones of ql's bigrat columns, that has no exact decimal representation.
+var ErrInexactDecimal = errors.New(`upper: number has no exact decimal representation`)
+
+var bigTen = big.NewInt(10)
+
+// maxDecimalExponent bounds the exponents ParseDecimal accepts, so numbers
+// like "1e999999999" can't make it allocate huge amounts of memory. It's
+// larger than the number of digits PostgreSQL's NUMERIC type can hold.
+const maxDecimalExponent = 1 << 18
+
+// Decimal is an exact decimal number, meant for NUMERIC and DECIMAL columns
+// that hold values float64 can't represent exactly, like amounts of money.
+// The zero value is 0, use *Decimal for nullable columns.
+//
+// Decimals are immutable, arithmetic methods return new values:
+//
+//	price, err := db.ParseDecimal("19.99")
+//	...
+//	total := price.Mul(db.NewDecimal(3, 0)).Add(shipping)
+//
+// Decimals are written as strings, which every database converts to its
+// numeric types without losing precision, except for ql and Spanner which
+// receive them as *big.Rat values.
+type Decimal struct {
+	// The value is unscaled * 10^-scale.
+	unscaled *big.Int
+	scale    int32
+}
+
+// NewDecimal returns unscaled * 10^-scale, like NewDecimal(1999, 2) for
+// 19.99. Negative scales multiply the value by powers of ten.
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	return newDecimal(big.NewInt(unscaled), scale)
+}
+
+// NewDecimalFromBigInt is like NewDecimal for big integers, unscaled is not
+// modified.
+func NewDecimalFromBigInt(unscaled *big.Int, scale int32) Decimal {
+	return newDecimal(new(big.Int).Set(unscaled), scale)
+}
+
+// NewDecimalFromFloat returns the shortest decimal that converts back to f,
+// like 0.1 for 0.1 even though float64 can't represent it exactly. It fails
+// for NaN and infinities.
+func NewDecimalFromFloat(f float64) (Decimal, error) {
+	return ParseDecimal(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// newDecimal takes ownership of unscaled.
+func newDecimal(unscaled *big.Int, scale int32) Decimal {
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+// ParseDecimal parses a decimal number, like "19.99", "-0.5", "+3" or
+// "1.5e-3".
+func ParseDecimal(s string) (Decimal, error) {
+	str := s
+
+	var exp int64
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.ParseInt(str[i+1:], 10, 32); err != nil {
+			return Decimal{}, fmt.Errorf("Invalid decimal %q", s)
+		}
+		str = str[:i]
+	}
+
+	digits := str
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		digits = str[:i] + str[i+1:]
+		exp -= int64(len(str) - i - 1)
+	}
+
+	unsigned := strings.TrimLeft(digits, "+-")
+	if unsigned == "" || len(digits)-len(unsigned) > 1 || strings.IndexFunc(unsigned, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return Decimal{}, fmt.Errorf("Invalid decimal %q", s)
+	}
+	if exp < -maxDecimalExponent || exp > maxDecimalExponent {
+		return Decimal{}, fmt.Errorf("Invalid decimal %q", s)
+	}
+
+	unscaled, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("Invalid decimal %q", s)
+	}
+	return newDecimal(unscaled, int32(-exp)), nil
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) bigInt() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// rescale returns the unscaled value of d at the given scale, which must be
+// greater than or equal to the one of d.
+func (d Decimal) rescale(scale int32) *big.Int {
+	v := new(big.Int).Set(d.bigInt())
+	if scale > d.scale {
+		v.Mul(v, pow10(scale-d.scale))
+	}
+	return v
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign returns -1, 0 or 1 for negative, zero and positive numbers.
+func (d Decimal) Sign() int {
+	return d.bigInt().Sign()
+}
+
+// IsZero returns true if d is zero.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp compares d and d2 and returns -1, 0 or 1 if d is less than, equal to
+// or greater than d2. Numbers that only differ in their trailing zeros, like
+// 1.5 and 1.50, are equal.
+func (d Decimal) Cmp(d2 Decimal) int {
+	scale := maxScale(d, d2)
+	return d.rescale(scale).Cmp(d2.rescale(scale))
+}
+
+// Equal returns true if d and d2 are the same number, see Cmp.
+func (d Decimal) Equal(d2 Decimal) bool {
+	return d.Cmp(d2) == 0
+}
+
+// Add returns d + d2.
+func (d Decimal) Add(d2 Decimal) Decimal {
+	scale := maxScale(d, d2)
+	v := d.rescale(scale)
+	return Decimal{unscaled: v.Add(v, d2.rescale(scale)), scale: scale}
+}
+
+// Sub returns d - d2.
+func (d Decimal) Sub(d2 Decimal) Decimal {
+	scale := maxScale(d, d2)
+	v := d.rescale(scale)
+	return Decimal{unscaled: v.Sub(v, d2.rescale(scale)), scale: scale}
+}
+
+// Mul returns d * d2, its scale is the sum of the scales of d and d2.
+func (d Decimal) Mul(d2 Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.bigInt(), d2.bigInt()), scale: d.scale + d2.scale}
+}
+
+// Div returns d / d2 rounded half away from zero to the given number of
+// decimal places. It panics if d2 is zero.
+func (d Decimal) Div(d2 Decimal, places int32) Decimal {
+	if d2.IsZero() {
+		panic("db: division by zero")
+	}
+	// d / d2 = (a * 10^-s1) / (b * 10^-s2), which is computed with an extra
+	// digit to round.
+	num := new(big.Int).Mul(d.bigInt(), pow10(places+1+d2.scale))
+	den := new(big.Int).Mul(d2.bigInt(), pow10(d.scale))
+	q := num.Quo(num, den)
+	return Decimal{unscaled: q, scale: places + 1}.Round(places)
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.bigInt()), scale: d.scale}
+}
+
+// Abs returns the absolute value of d.
+func (d Decimal) Abs() Decimal {
+	return Decimal{unscaled: new(big.Int).Abs(d.bigInt()), scale: d.scale}
+}
+
+// Round returns d rounded half away from zero to the given number of decimal
+// places, like 2 for cents. Numbers with fewer places are returned as they
+// are.
+func (d Decimal) Round(places int32) Decimal {
+	if places < 0 {
+		places = 0
+	}
+	if d.scale <= places {
+		return d
+	}
+	q, r := new(big.Int).QuoRem(d.bigInt(), pow10(d.scale-places), new(big.Int))
+	// Compares twice the remainder with the divisor to round halves away
+	// from zero.
+	r.Abs(r).Mul(r, big.NewInt(2))
+	if r.Cmp(pow10(d.scale-places)) >= 0 {
+		if d.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return Decimal{unscaled: q, scale: places}
+}
+
+// Truncate returns d without the digits after the given number of decimal
+// places.
+func (d Decimal) Truncate(places int32) Decimal {
+	if places < 0 {
+		places = 0
+	}
+	if d.scale <= places {
+		return d
+	}
+	q := new(big.Int).Quo(d.bigInt(), pow10(d.scale-places))
+	return Decimal{unscaled: q, scale: places}
+}
+
+// String returns the number with all the digits of its scale, like "19.90".
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(d.bigInt()).String()
+	if d.scale > 0 {
+		if len(s) <= int(d.scale) {
+			s = strings.Repeat("0", int(d.scale)-len(s)+1) + s
+		}
+		s = s[:len(s)-int(d.scale)] + "." + s[len(s)-int(d.scale):]
+	}
+	if d.Sign() < 0 {
+		return "-" + s
+	}
+	return s
+}
+
+// Float64 returns the nearest float64 to d.
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// Rat returns d as a rational number.
+func (d Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.bigInt(), pow10(d.scale))
+}
+
+// Scan implements the sql.Scanner interface. Numbers are read from their
+// text representation, which is how most drivers return NUMERIC and DECIMAL
+// columns, from integers, from floats, for databases that store decimals as
+// floating point numbers like SQLite, and from *big.Rat values.
+func (d *Decimal) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case []byte:
+		return d.Scan(string(src))
+	case string:
+		v, err := ParseDecimal(strings.TrimSpace(src))
+		if err != nil {
+			return err
+		}
+		*d = v
+		return nil
+	case int64:
+		*d = NewDecimal(src, 0)
+		return nil
+	case float64:
+		v, err := NewDecimalFromFloat(src)
+		if err != nil {
+			return err
+		}
+		*d = v
+		return nil
+	case *big.Rat:
+		v, err := decimalFromRat(src)
+		if err != nil {
+			return err
+		}
+		*d = v
+		return nil
+	}
+	return fmt.Errorf("Cannot scan %T into Decimal", src)
+}
+
+// decimalFromRat converts r into a decimal, which is only possible if the
+// prime factors of its denominator are 2 and 5.
+func decimalFromRat(r *big.Rat) (Decimal, error) {
+	den := new(big.Int).Set(r.Denom())
+	var twos, fives int32
+	for _, f := range []struct {
+		n     int64
+		count *int32
+	}{{2, &twos}, {5, &fives}} {
+		q, m := new(big.Int), new(big.Int)
+		for {
+			q.QuoRem(den, big.NewInt(f.n), m)
+			if m.Sign() != 0 {
+				break
+			}
+			den.Set(q)
+			*f.count++
+		}
+	}
+	if den.Cmp(big.NewInt(1)) != 0 {
+		return Decimal{}, ErrInexactDecimal
+	}
+
+	scale := twos
+	if fives > scale {
+		scale = fives
+	}
+	// num / den = num * (10^scale / den) / 10^scale
+	unscaled := new(big.Int).Quo(pow10(scale), r.Denom())
+	unscaled.Mul(unscaled, r.Num())
+	return Decimal{unscaled: unscaled, scale: scale}, nil
+}
+
+// Value implements the driver.Valuer interface.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (d *Decimal) UnmarshalText(text []byte) error {
+	v, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, decimals are encoded
+// as strings, like "19.99", so JavaScript clients don't read them into
+// floating point numbers.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, both strings and
+// numbers are accepted.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+func maxScale(d, d2 Decimal) int32 {
+	if d.scale > d2.scale {
+		return d.scale
+	}
+	return d2.scale
+}
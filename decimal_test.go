@@ -0,0 +1,218 @@
+package db
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func mustDecimal(t *testing.T, s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestParseDecimal(t *testing.T) {
+	testCases := []struct {
+		in  string
+		out string
+	}{
+		{"0", "0"},
+		{"19.99", "19.99"},
+		{"-0.5", "-0.5"},
+		{"+3", "3"},
+		{".25", "0.25"},
+		{"-.25", "-0.25"},
+		{"19.90", "19.90"},
+		{"1.5e-3", "0.0015"},
+		{"1.5E3", "1500"},
+		{"12345678901234567890.123456789012345678", "12345678901234567890.123456789012345678"},
+	}
+	for _, test := range testCases {
+		d, err := ParseDecimal(test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.String() != test.out {
+			t.Fatalf("Got: %v, Expecting: %v", d.String(), test.out)
+		}
+	}
+
+	for _, s := range []string{"", ".", "-", "+-1", "1.2.3", "1e", "1e1.5", "abc", "1_000", "NaN", "Inf", "1e999999999"} {
+		if _, err := ParseDecimal(s); err == nil {
+			t.Fatalf("Expecting an error for %q", s)
+		}
+	}
+}
+
+func TestNewDecimal(t *testing.T) {
+	testCases := []struct {
+		d        Decimal
+		expected string
+	}{
+		{NewDecimal(1999, 2), "19.99"},
+		{NewDecimal(-5, 2), "-0.05"},
+		{NewDecimal(15, -2), "1500"},
+		{Decimal{}, "0"},
+	}
+	for _, test := range testCases {
+		if s := test.d.String(); s != test.expected {
+			t.Fatalf("Got: %v, Expecting: %v", s, test.expected)
+		}
+	}
+
+	unscaled := big.NewInt(1999)
+	d := NewDecimalFromBigInt(unscaled, 2)
+	unscaled.SetInt64(0)
+	if d.String() != "19.99" {
+		t.Fatalf("Unexpected decimal %v", d)
+	}
+
+	for f, expected := range map[float64]string{0.1: "0.1", -1e-7: "-0.0000001", 19.99: "19.99"} {
+		d, err := NewDecimalFromFloat(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.String() != expected {
+			t.Fatalf("Got: %v, Expecting: %v", d, expected)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	price := mustDecimal(t, "19.99")
+
+	testCases := []struct {
+		d        Decimal
+		expected string
+	}{
+		{price.Mul(NewDecimal(3, 0)), "59.97"},
+		{price.Add(mustDecimal(t, "5.25")), "25.24"},
+		{price.Sub(mustDecimal(t, "5.250")), "14.740"},
+		{price.Neg(), "-19.99"},
+		{price.Neg().Abs(), "19.99"},
+		{mustDecimal(t, "0.1").Add(mustDecimal(t, "0.2")), "0.3"},
+
+		{price.Div(NewDecimal(3, 0), 2), "6.66"},
+		{NewDecimal(2, 0).Div(NewDecimal(3, 0), 2), "0.67"},
+		{NewDecimal(-2, 0).Div(NewDecimal(3, 0), 2), "-0.67"},
+		{NewDecimal(1, 0).Div(mustDecimal(t, "0.025"), 0), "40"},
+
+		{mustDecimal(t, "2.345").Round(2), "2.35"},
+		{mustDecimal(t, "-2.345").Round(2), "-2.35"},
+		{mustDecimal(t, "2.3449").Round(2), "2.34"},
+		{mustDecimal(t, "2.5").Round(0), "3"},
+		{mustDecimal(t, "2.5").Round(3), "2.5"},
+		{mustDecimal(t, "2.349").Truncate(2), "2.34"},
+		{mustDecimal(t, "-2.349").Truncate(2), "-2.34"},
+	}
+	for i, test := range testCases {
+		if s := test.d.String(); s != test.expected {
+			t.Fatalf("%d: Got: %v, Expecting: %v", i, s, test.expected)
+		}
+	}
+
+	if c := mustDecimal(t, "1.5").Cmp(mustDecimal(t, "1.50")); c != 0 {
+		t.Fatalf("Got: %v, Expecting: 0", c)
+	}
+	if !mustDecimal(t, "1.5").Equal(mustDecimal(t, "1.500")) {
+		t.Fatal("Expecting 1.5 and 1.500 to be equal")
+	}
+	if c := mustDecimal(t, "1.49").Cmp(mustDecimal(t, "1.5")); c != -1 {
+		t.Fatalf("Got: %v, Expecting: -1", c)
+	}
+	if c := mustDecimal(t, "-1").Cmp(mustDecimal(t, "-1.01")); c != 1 {
+		t.Fatalf("Got: %v, Expecting: 1", c)
+	}
+
+	if !(Decimal{}).IsZero() || !mustDecimal(t, "0.00").IsZero() {
+		t.Fatal("Expecting zero")
+	}
+	if price.Neg().Sign() != -1 || price.Scale() != 2 {
+		t.Fatalf("Unexpected sign or scale of %v", price)
+	}
+	if f := price.Float64(); f != 19.99 {
+		t.Fatalf("Got: %v, Expecting: 19.99", f)
+	}
+	if r := price.Rat(); r.Cmp(big.NewRat(1999, 100)) != 0 {
+		t.Fatalf("Got: %v, Expecting: 1999/100", r)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expecting a panic when dividing by zero")
+		}
+	}()
+	price.Div(Decimal{}, 2)
+}
+
+func TestDecimalScan(t *testing.T) {
+	testCases := []struct {
+		src      interface{}
+		expected string
+	}{
+		{[]byte("19.990"), "19.990"},
+		{"42", "42"},
+		{int64(-7), "-7"},
+		{19.99, "19.99"},
+		{big.NewRat(-3, 8), "-0.375"},
+		{big.NewRat(7, 1), "7"},
+		{nil, "0"},
+	}
+	for _, test := range testCases {
+		var d Decimal
+		if err := d.Scan(test.src); err != nil {
+			t.Fatal(err)
+		}
+		if d.String() != test.expected {
+			t.Fatalf("Got: %v, Expecting: %v", d, test.expected)
+		}
+	}
+
+	var d Decimal
+	if err := d.Scan(big.NewRat(1, 3)); err != ErrInexactDecimal {
+		t.Fatalf("Got: %v, Expecting: %v", err, ErrInexactDecimal)
+	}
+	for _, src := range []interface{}{"abc", true} {
+		if err := d.Scan(src); err == nil {
+			t.Fatalf("Expecting an error for %v", src)
+		}
+	}
+
+	v, err := mustDecimal(t, "-0.05").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "-0.05" {
+		t.Fatalf("Got: %v, Expecting: -0.05", v)
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	type order struct {
+		Total    Decimal  `json:"total"`
+		Discount *Decimal `json:"discount"`
+	}
+
+	buf, err := json.Marshal(order{Total: mustDecimal(t, "19.90")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != `{"total":"19.90","discount":null}` {
+		t.Fatalf("Unexpected JSON %s", buf)
+	}
+
+	var o order
+	if err := json.Unmarshal([]byte(`{"total": 19.90, "discount": "1.5"}`), &o); err != nil {
+		t.Fatal(err)
+	}
+	if o.Total.String() != "19.90" || o.Discount.String() != "1.5" {
+		t.Fatalf("Unexpected order %v", o)
+	}
+
+	if err := json.Unmarshal([]byte(`{"total": "x"}`), &o); err == nil {
+		t.Fatal("Expecting an error")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Enum is implemented by the Go types of enum columns, which are written and
+// read as one of the values EnumValues returns:
+//
+//	type Status string
+//
+//	func (Status) EnumValues() []string {
+//		return []string{"active", "disabled"}
+//	}
+//
+// The values of a field can also be listed with the enum option, like
+// `db:"status,enum=active|disabled"`. String fields hold the values as they
+// are while integer fields hold their positions, so the values of an iota
+// enum are listed in the order of its constants:
+//
+//	type Priority int
+//
+//	const (
+//		Low Priority = iota
+//		Normal
+//		High
+//	)
+//
+//	func (Priority) EnumValues() []string {
+//		return []string{"low", "normal", "high"}
+//	}
+//
+// Writing a value that is not an enum value, or reading one from the column,
+// fails with an *EnumError.
+type Enum interface {
+	EnumValues() []string
+}
+
+// EnumError is returned when a value is not one of the values of its enum
+// column.
+type EnumError struct {
+	Column string
+	Value  string
+	Values []string
+}
+
+func (e *EnumError) Error() string {
+	values := make([]string, len(e.Values))
+	for i := range e.Values {
+		values[i] = fmt.Sprintf("%q", e.Values[i])
+	}
+	return fmt.Sprintf("upper: invalid value %q for enum column %q, expecting one of %s", e.Value, e.Column, strings.Join(values, ", "))
+}
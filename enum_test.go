@@ -0,0 +1,21 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestEnumError(t *testing.T) {
+	var err error = &EnumError{Column: "status", Value: "deleted", Values: []string{"active", "disabled"}}
+
+	expected := `upper: invalid value "deleted" for enum column "status", expecting one of "active", "disabled"`
+	if err.Error() != expected {
+		t.Fatalf("Got: %v, Expecting: %v", err.Error(), expected)
+	}
+
+	var enumErr *EnumError
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &enumErr) || enumErr.Value != "deleted" {
+		t.Fatalf("Expecting an *EnumError, got %v", enumErr)
+	}
+}
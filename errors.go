@@ -22,31 +22,149 @@
 package db
 
 import (
+	"database/sql/driver"
 	"errors"
+	"io"
+	"net"
+	"strings"
 )
 
 // Error messages.
 var (
-	ErrNoMoreRows               = errors.New(`upper: no more rows in this result set`)
-	ErrNotConnected             = errors.New(`upper: you're currently not connected`)
-	ErrMissingDatabaseName      = errors.New(`upper: missing database name`)
-	ErrMissingCollectionName    = errors.New(`upper: missing collection name`)
-	ErrCollectionDoesNotExist   = errors.New(`upper: collection does not exist`)
-	ErrSockerOrHost             = errors.New(`upper: you may connect either to a unix socket or a tcp address, but not both`)
-	ErrQueryLimitParam          = errors.New(`upper: a query can accept only one limit parameter`)
-	ErrQuerySortParam           = errors.New(`upper: a query can accept only one order by parameter`)
-	ErrQueryOffsetParam         = errors.New(`upper: a query can accept only one offset parameter`)
-	ErrMissingConditions        = errors.New(`upper: missing selector conditions`)
-	ErrUnsupported              = errors.New(`upper: this action is currently unsupported on this database`)
-	ErrUndefined                = errors.New(`upper: this value is undefined`)
-	ErrQueryIsPending           = errors.New(`upper: can't execute this instruction while the result set is still open`)
-	ErrUnsupportedDestination   = errors.New(`upper: unsupported destination type`)
-	ErrUnsupportedType          = errors.New(`upper: this type does not support marshaling`)
-	ErrUnsupportedValue         = errors.New(`upper: this value does not support unmarshaling`)
-	ErrUnknownConditionType     = errors.New(`upper: arguments of type %T can't be used as constraints`)
-	ErrTooManyClients           = errors.New(`upper: can't connect to database server: too many clients`)
-	ErrGivingUpTryingToConnect  = errors.New(`upper: giving up trying to connect: too many clients`)
-	ErrMissingConnURL           = errors.New(`upper: missing DSN`)
-	ErrNotImplemented           = errors.New(`upper: call not implemented`)
-	ErrAlreadyWithinTransaction = errors.New(`upper: already within a transaction`)
+	ErrNoMoreRows                 = errors.New(`upper: no more rows in this result set`)
+	ErrNotConnected               = errors.New(`upper: you're currently not connected`)
+	ErrMissingDatabaseName        = errors.New(`upper: missing database name`)
+	ErrMissingCollectionName      = errors.New(`upper: missing collection name`)
+	ErrCollectionDoesNotExist     = errors.New(`upper: collection does not exist`)
+	ErrSockerOrHost               = errors.New(`upper: you may connect either to a unix socket or a tcp address, but not both`)
+	ErrQueryLimitParam            = errors.New(`upper: a query can accept only one limit parameter`)
+	ErrQuerySortParam             = errors.New(`upper: a query can accept only one order by parameter`)
+	ErrQueryOffsetParam           = errors.New(`upper: a query can accept only one offset parameter`)
+	ErrMissingConditions          = errors.New(`upper: missing selector conditions`)
+	ErrUnsupported                = errors.New(`upper: this action is currently unsupported on this database`)
+	ErrUndefined                  = errors.New(`upper: this value is undefined`)
+	ErrQueryIsPending             = errors.New(`upper: can't execute this instruction while the result set is still open`)
+	ErrUnsupportedDestination     = errors.New(`upper: unsupported destination type`)
+	ErrUnsupportedType            = errors.New(`upper: this type does not support marshaling`)
+	ErrUnsupportedValue           = errors.New(`upper: this value does not support unmarshaling`)
+	ErrUnknownConditionType       = errors.New(`upper: arguments of type %T can't be used as constraints`)
+	ErrTooManyClients             = errors.New(`upper: can't connect to database server: too many clients`)
+	ErrGivingUpTryingToConnect    = errors.New(`upper: giving up trying to connect: too many clients`)
+	ErrMissingConnURL             = errors.New(`upper: missing DSN`)
+	ErrNotImplemented             = errors.New(`upper: call not implemented`)
+	ErrAlreadyWithinTransaction   = errors.New(`upper: already within a transaction`)
+	ErrSerializationFailure       = errors.New(`upper: could not serialize transaction`)
+	ErrUniqueViolation            = errors.New(`upper: unique constraint violation`)
+	ErrForeignKeyViolation        = errors.New(`upper: foreign key constraint violation`)
+	ErrCheckViolation             = errors.New(`upper: check constraint violation`)
+	ErrPreparedStatementsDisabled = errors.New(`upper: prepared statements are disabled on this session`)
+	ErrInvalidSchemaName          = errors.New(`upper: invalid schema name`)
 )
+
+// Error is a driver error that was classified by an adapter. Statements that
+// fail with a constraint violation or a serialization failure return an
+// *Error, its Kind can be compared against ErrUniqueViolation,
+// ErrForeignKeyViolation, ErrCheckViolation or ErrSerializationFailure:
+//
+//	if ErrorKind(err) == db.ErrUniqueViolation {
+//		// ...
+//	}
+//
+// On Go 1.13 and later errors.Is(err, db.ErrUniqueViolation) works as well.
+type Error struct {
+	// Kind is the sentinel error that describes the failure.
+	Kind error
+
+	// Table, Constraint and Columns identify the constraint that was
+	// violated, they are empty when the driver does not report them.
+	Table      string
+	Constraint string
+	Columns    []string
+
+	// Err is the original error returned by the driver.
+	Err error
+}
+
+// Error returns the message of the original driver error.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the original driver error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the Kind of e.
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+// ConstraintError is returned by Insert and Update when a statement violates
+// a unique constraint that the item maps to one of its fields with the unique
+// tag, so the violation can be reported on the right field of a form:
+//
+//	type User struct {
+//		_     struct{} `db:"-" unique:"users_email_key->Email"`
+//		ID    int64    `db:"id,omitempty"`
+//		Email string   `db:"email"`
+//	}
+//
+//	if err, ok := err.(*db.ConstraintError); ok {
+//		form.SetError(err.Field, "is already taken")
+//	}
+//
+// The tag holds comma separated constraint->Field pairs. A constraint may be
+// given by the name of the violated column instead, for databases that don't
+// report constraint names, like SQLite.
+type ConstraintError struct {
+	// Field is the name of the struct field the constraint maps to.
+	Field string
+
+	// Err is the classified error of the violation.
+	Err *Error
+}
+
+// Error returns the message of the original driver error.
+func (e *ConstraintError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the classified error of the violation.
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKind returns the Kind of err if it is an *Error or a
+// *ConstraintError, or err itself otherwise.
+func ErrorKind(err error) error {
+	switch e := err.(type) {
+	case *Error:
+		return e.Kind
+	case *ConstraintError:
+		return e.Err.Kind
+	}
+	return err
+}
+
+// IsTransientError returns true if err looks like a network error that may
+// not happen again on a new attempt, like a connection that was reset by the
+// server or a pooled connection that went stale while idle.
+func IsTransientError(err error) bool {
+	switch err {
+	case nil:
+		return false
+	case driver.ErrBadConn, io.EOF, io.ErrUnexpectedEOF:
+		return true
+	}
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	s := err.Error()
+	for _, msg := range []string{"connection reset by peer", "broken pipe", "connection refused", "bad connection"} {
+		if strings.Contains(s, msg) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorKind(t *testing.T) {
+	driverErr := errors.New(`duplicate key value violates unique constraint "artist_name_key"`)
+
+	err := error(&Error{
+		Kind:       ErrUniqueViolation,
+		Table:      "artist",
+		Constraint: "artist_name_key",
+		Columns:    []string{"name"},
+		Err:        driverErr,
+	})
+
+	if kind := ErrorKind(err); kind != ErrUniqueViolation {
+		t.Fatalf("Got: %v, Expecting: %v", kind, ErrUniqueViolation)
+	}
+	if err.Error() != driverErr.Error() {
+		t.Fatalf("Got: %v, Expecting: %v", err.Error(), driverErr.Error())
+	}
+	if cause := err.(*Error).Unwrap(); cause != driverErr {
+		t.Fatalf("Got: %v, Expecting: %v", cause, driverErr)
+	}
+	if !err.(*Error).Is(ErrUniqueViolation) || err.(*Error).Is(ErrCheckViolation) {
+		t.Fatalf("Expecting error to match ErrUniqueViolation only")
+	}
+
+	if kind := ErrorKind(driverErr); kind != driverErr {
+		t.Fatalf("Got: %v, Expecting: %v", kind, driverErr)
+	}
+	if kind := ErrorKind(nil); kind != nil {
+		t.Fatalf("Got: %v, Expecting: %v", kind, nil)
+	}
+}
+
+func TestConstraintError(t *testing.T) {
+	dbErr := &Error{
+		Kind:       ErrUniqueViolation,
+		Constraint: "users_email_key",
+		Err:        errors.New(`duplicate key value violates unique constraint "users_email_key"`),
+	}
+
+	err := error(&ConstraintError{Field: "Email", Err: dbErr})
+
+	if kind := ErrorKind(err); kind != ErrUniqueViolation {
+		t.Fatalf("Got: %v, Expecting: %v", kind, ErrUniqueViolation)
+	}
+	if err.Error() != dbErr.Error() {
+		t.Fatalf("Got: %v, Expecting: %v", err.Error(), dbErr.Error())
+	}
+	if cause := err.(*ConstraintError).Unwrap(); cause != dbErr {
+		t.Fatalf("Got: %v, Expecting: %v", cause, dbErr)
+	}
+}
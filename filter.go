@@ -0,0 +1,278 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilterType is the type filter values are converted to before they're used
+// on conditions.
+type FilterType uint
+
+// Values for FilterType.
+const (
+	FilterString FilterType = iota
+	FilterInt
+	FilterFloat
+	FilterBool
+	FilterTime
+)
+
+// Filter operators, a parameter like "age[gte]=18" uses the "gte" operator
+// and a parameter without an operator, like "age=18", uses "eq".
+const (
+	FilterEq    = "eq"
+	FilterNe    = "ne"
+	FilterLt    = "lt"
+	FilterLte   = "lte"
+	FilterGt    = "gt"
+	FilterGte   = "gte"
+	FilterLike  = "like"
+	FilterILike = "ilike"
+	FilterIn    = "in"
+	FilterNotIn = "nin"
+	FilterNull  = "null"
+)
+
+var filterComparisons = map[string]string{
+	FilterEq:    "=",
+	FilterNe:    "<>",
+	FilterLt:    "<",
+	FilterLte:   "<=",
+	FilterGt:    ">",
+	FilterGte:   ">=",
+	FilterLike:  "LIKE",
+	FilterILike: "ILIKE",
+}
+
+// Filter describes a parameter that can be used to filter results, see
+// FilterSet.
+type Filter struct {
+	// Column is the column the parameter is compared against, the name of the
+	// parameter is used if empty.
+	Column string
+
+	// Type is the type values are converted to. FilterTime values are parsed
+	// as RFC 3339 timestamps or as dates, like "2006-01-02".
+	Type FilterType
+
+	// Operators are the allowed operators, only FilterEq is allowed if
+	// empty.
+	Operators []string
+}
+
+// FilterError is returned by FilterSet.Conditions when a parameter can't be
+// converted into a condition.
+type FilterError struct {
+	// Param is the name of the parameter, like "age[gte]".
+	Param string
+
+	// Err is the reason the parameter was rejected.
+	Err error
+}
+
+// Error returns a message that includes the name of the parameter.
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("upper: invalid filter %q: %v", e.Param, e.Err)
+}
+
+// Unwrap returns the reason the parameter was rejected.
+func (e *FilterError) Unwrap() error {
+	return e.Err
+}
+
+// FilterSet maps query parameters, like the ones of an HTTP request, into
+// conditions. Only the parameters and operators that were explicitly allowed
+// are converted and values are always passed as arguments, so clients can't
+// inject SQL or compare against arbitrary columns.
+//
+// Example:
+//
+//	filters := db.NewFilterSet().
+//		Allow("name", db.Filter{Operators: []string{db.FilterEq, db.FilterILike}}).
+//		Allow("age", db.Filter{Type: db.FilterInt, Operators: []string{db.FilterGte, db.FilterLte}}).
+//		Allow("status", db.Filter{Operators: []string{db.FilterEq, db.FilterIn}})
+//
+//	// ?age[gte]=18&status[in]=active,pending&name=Joe&name=Jane
+//	cond, err := filters.Conditions(req.URL.Query())
+//	if err != nil {
+//		// Respond with 400 Bad Request.
+//	}
+//
+//	// ("age" >= 18 AND ("name" = 'Joe' OR "name" = 'Jane') AND "status" IN ('active', 'pending'))
+//	res := col.Find(cond)
+//
+// Values of the FilterIn and FilterNotIn operators are separated by commas,
+// FilterNull takes a boolean, like "deleted_at[null]=true". A parameter that
+// is given more than once matches any of its values.
+type FilterSet struct {
+	filters map[string]Filter
+}
+
+// NewFilterSet creates a FilterSet that allows no parameters.
+func NewFilterSet() *FilterSet {
+	return &FilterSet{filters: map[string]Filter{}}
+}
+
+// Allow allows filtering with the given parameter.
+func (fs *FilterSet) Allow(param string, filter Filter) *FilterSet {
+	if filter.Column == "" {
+		filter.Column = param
+	}
+	if len(filter.Operators) == 0 {
+		filter.Operators = []string{FilterEq}
+	}
+	fs.filters[param] = filter
+	return fs
+}
+
+// Conditions converts the given parameters into conditions joined by AND.
+// Parameters that were not allowed are ignored, so values can hold other
+// parameters as well, like the ones used for pagination. Parameters that
+// were allowed but have an operator that was not or values that can't be
+// converted return a *FilterError.
+func (fs *FilterSet) Conditions(values url.Values) (*Intersection, error) {
+	params := make([]string, 0, len(values))
+	for param := range values {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	conds := []Compound{}
+	for _, param := range params {
+		name, operator := splitFilterParam(param)
+
+		filter, ok := fs.filters[name]
+		if !ok {
+			continue
+		}
+
+		if !filter.allows(operator) {
+			return nil, &FilterError{Param: param, Err: fmt.Errorf("operator %q is not allowed", operator)}
+		}
+
+		alternatives := make([]Compound, 0, len(values[param]))
+		for _, value := range values[param] {
+			cond, err := filter.condition(operator, value)
+			if err != nil {
+				return nil, &FilterError{Param: param, Err: err}
+			}
+			alternatives = append(alternatives, cond)
+		}
+
+		switch len(alternatives) {
+		case 0:
+		case 1:
+			conds = append(conds, alternatives[0])
+		default:
+			conds = append(conds, Or(alternatives...))
+		}
+	}
+
+	return And(conds...), nil
+}
+
+// splitFilterParam separates a parameter like "age[gte]" into its name and
+// operator.
+func splitFilterParam(param string) (name string, operator string) {
+	if i := strings.IndexByte(param, '['); i > 0 && strings.HasSuffix(param, "]") {
+		return param[:i], strings.ToLower(param[i+1 : len(param)-1])
+	}
+	return param, FilterEq
+}
+
+func (f *Filter) allows(operator string) bool {
+	for i := range f.Operators {
+		if f.Operators[i] == operator {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) condition(operator string, value string) (Cond, error) {
+	switch operator {
+	case FilterNull:
+		isNull, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, err
+		}
+		if isNull {
+			return IsNull(f.Column), nil
+		}
+		return NotNull(f.Column), nil
+	case FilterIn, FilterNotIn:
+		chunks := strings.Split(value, ",")
+		list := make([]interface{}, len(chunks))
+		for i := range chunks {
+			v, err := f.convert(strings.TrimSpace(chunks[i]))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = v
+		}
+		if operator == FilterNotIn {
+			return NotIn(f.Column, list), nil
+		}
+		return Cond{Comparison{f.Column, "IN"}: list}, nil
+	case FilterLike, FilterILike:
+		if f.Type != FilterString {
+			return nil, fmt.Errorf("operator %q requires a string filter", operator)
+		}
+	}
+
+	comparison, ok := filterComparisons[operator]
+	if !ok {
+		return nil, fmt.Errorf("unknown operator %q", operator)
+	}
+
+	v, err := f.convert(value)
+	if err != nil {
+		return nil, err
+	}
+	return Cond{Comparison{f.Column, comparison}: v}, nil
+}
+
+func (f *Filter) convert(value string) (interface{}, error) {
+	switch f.Type {
+	case FilterString:
+		return value, nil
+	case FilterInt:
+		return strconv.ParseInt(value, 10, 64)
+	case FilterFloat:
+		return strconv.ParseFloat(value, 64)
+	case FilterBool:
+		return strconv.ParseBool(value)
+	case FilterTime:
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t, nil
+		}
+		return time.Parse("2006-01-02", value)
+	}
+	return nil, fmt.Errorf("unknown filter type %d", f.Type)
+}
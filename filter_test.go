@@ -0,0 +1,95 @@
+package db
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFilterSet(t *testing.T) {
+	filters := NewFilterSet().
+		Allow("name", Filter{Operators: []string{FilterEq, FilterILike}}).
+		Allow("age", Filter{Type: FilterInt, Operators: []string{FilterGte, FilterLt, FilterIn}}).
+		Allow("since", Filter{Column: "created_at", Type: FilterTime, Operators: []string{FilterGt}}).
+		Allow("deleted", Filter{Column: "deleted_at", Operators: []string{FilterNull}})
+
+	values, err := url.ParseQuery("age[gte]=18&name=Joe&name=Jane&since[gt]=2020-01-02&deleted[null]=true&page=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cond, err := filters.Conditions(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sentences := cond.Sentences()
+	if len(sentences) != 4 {
+		t.Fatalf("Got: %d, Expecting: %d", len(sentences), 4)
+	}
+
+	expected := []Compound{
+		Cond{Comparison{"age", ">="}: int64(18)},
+		IsNull("deleted_at"),
+	}
+	for i := range expected {
+		if !reflect.DeepEqual(sentences[i], expected[i]) {
+			t.Fatalf("Got: %v, Expecting: %v", sentences[i], expected[i])
+		}
+	}
+
+	union, ok := sentences[2].(*Union)
+	if !ok {
+		t.Fatalf("Expecting a *Union, got %T", sentences[2])
+	}
+	alternatives := union.Sentences()
+	if !reflect.DeepEqual(alternatives, []Compound{Cond{Comparison{"name", "="}: "Joe"}, Cond{Comparison{"name", "="}: "Jane"}}) {
+		t.Fatalf("Got: %v", alternatives)
+	}
+
+	since := Cond{Comparison{"created_at", ">"}: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)}
+	if !reflect.DeepEqual(sentences[3], since) {
+		t.Fatalf("Got: %v, Expecting: %v", sentences[3], since)
+	}
+
+	cond, err = filters.Conditions(url.Values{"age[in]": {"1, 2,3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := Cond{Comparison{"age", "IN"}: []interface{}{int64(1), int64(2), int64(3)}}
+	if sentences := cond.Sentences(); len(sentences) != 1 || !reflect.DeepEqual(sentences[0], in) {
+		t.Fatalf("Got: %v, Expecting: %v", sentences, in)
+	}
+
+	cond, err = filters.Conditions(url.Values{"page": {"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cond.Empty() {
+		t.Fatalf("Expecting no conditions, got %v", cond.Sentences())
+	}
+}
+
+func TestFilterSetErrors(t *testing.T) {
+	filters := NewFilterSet().
+		Allow("name", Filter{}).
+		Allow("age", Filter{Type: FilterInt, Operators: []string{FilterEq, FilterLike}})
+
+	testCases := []url.Values{
+		{"name[ne]": {"Joe"}},
+		{"age": {"eighteen"}},
+		{"age[like]": {"1%"}},
+		{"name[eq]": {"Joe"}, "age": {"1", "x"}},
+	}
+
+	for _, values := range testCases {
+		_, err := filters.Conditions(values)
+		if err == nil {
+			t.Fatalf("Expecting an error for %v", values)
+		}
+		if _, ok := err.(*FilterError); !ok {
+			t.Fatalf("Expecting a *FilterError, got %T", err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+// Hooks are optional interfaces that items can implement to run logic around
+// inserts, updates and deletes, the session that is running the operation
+// (which may be a transaction) is passed to the hook. Hooks are currently
+// supported by SQL adapters only.
+
+// BeforeInsertHook is implemented by items that need to run logic before
+// being inserted by Collection.Insert() or Collection.InsertReturning(). If
+// BeforeInsert returns an error the item is not inserted.
+type BeforeInsertHook interface {
+	BeforeInsert(Database) error
+}
+
+// AfterInsertHook is implemented by items that need to run logic after being
+// inserted by Collection.Insert() or Collection.InsertReturning().
+type AfterInsertHook interface {
+	AfterInsert(Database) error
+}
+
+// BeforeUpdateHook is implemented by items that need to run logic before
+// being used to update a result set with Result.Update() or
+// Collection.UpdateReturning(). If BeforeUpdate returns an error the result
+// set is not updated.
+type BeforeUpdateHook interface {
+	BeforeUpdate(Database) error
+}
+
+// AfterUpdateHook is implemented by items that need to run logic after being
+// used to update a result set with Result.Update() or
+// Collection.UpdateReturning().
+type AfterUpdateHook interface {
+	AfterUpdate(Database) error
+}
+
+// BeforeDeleteHook is implemented by items that need to run logic before
+// being deleted. The hook is called by Result.Delete() on result sets that
+// were created by passing the item to Collection.Find():
+//
+//   err := col.Find(&item).Delete()
+//
+// If BeforeDelete returns an error the item is not deleted.
+type BeforeDeleteHook interface {
+	BeforeDelete(Database) error
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"strings"
+)
+
+// Identifier represents the name of a table or a column that is quoted as it
+// is, see Ident.
+type Identifier interface {
+	// Parts returns the parts of the identifier, like the schema and the
+	// table of "schema.table".
+	Parts() []string
+}
+
+type identifier struct {
+	name string
+}
+
+func (i identifier) Parts() []string {
+	return strings.Split(i.name, ".")
+}
+
+func (i identifier) String() string {
+	return i.name
+}
+
+// Ident marks a table or column name, like "name" or "schema.table", to be
+// quoted as it is instead of being parsed, quotes within the name are
+// escaped. Identifiers can be used wherever the SQL builder accepts table
+// and column names, including the keys of conditions, so names that come
+// from user input can't alter queries:
+//
+//	// SELECT "name" FROM "artist" ORDER BY "name"
+//	sess.Select(db.Ident(column)).From("artist").OrderBy(db.Ident(column))
+//
+//	// WHERE "name" = ?
+//	db.Cond{db.Ident(column): value}
+func Ident(name string) Identifier {
+	return identifier{name: name}
+}
@@ -0,0 +1,22 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIdent(t *testing.T) {
+	id := Ident("public.artist")
+	if parts := id.Parts(); !reflect.DeepEqual(parts, []string{"public", "artist"}) {
+		t.Fatalf("Unexpected parts: %v", parts)
+	}
+
+	id.Parts()[0] = "private"
+	if parts := id.Parts(); parts[0] != "public" {
+		t.Fatalf("Expecting parts not to be modified, got: %v", parts)
+	}
+
+	if s := id.(identifier).String(); s != "public.artist" {
+		t.Fatalf("Unexpected string: %s", s)
+	}
+}
@@ -80,12 +80,14 @@ func (c *Cache) Read(h Hashable) (string, bool) {
 }
 
 // ReadRaw attempts to retrieve a cached value as an interface{}, if the value
-// does not exists returns nil and false.
+// does not exists returns nil and false. Values that are read are marked as
+// recently used so they're the last ones to be evicted.
 func (c *Cache) ReadRaw(h Hashable) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	data, ok := c.cache[h.Hash()]
 	if ok {
+		c.li.MoveToFront(data)
 		return data.Value.(*item).value, true
 	}
 	return nil, false
@@ -105,7 +107,29 @@ func (c *Cache) Write(h Hashable, value interface{}) {
 	}
 
 	c.cache[key] = c.li.PushFront(&item{key, value})
+	c.evict()
+}
+
+// Delete removes a value from the cache, values that implement HasOnPurge are
+// notified.
+func (c *Cache) Delete(h Hashable) {
+	key := h.Hash()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
+	if el, ok := c.cache[key]; ok {
+		c.li.Remove(el)
+		delete(c.cache, key)
+		if p, ok := el.Value.(*item).value.(HasOnPurge); ok {
+			p.OnPurge()
+		}
+	}
+}
+
+// evict removes the least recently used values until the cache fits within
+// its capacity.
+func (c *Cache) evict() {
 	for c.li.Len() > c.capacity {
 		el := c.li.Remove(c.li.Back())
 		delete(c.cache, el.(*item).key)
@@ -115,6 +139,33 @@ func (c *Cache) Write(h Hashable, value interface{}) {
 	}
 }
 
+// SetCapacity changes the maximum number of values the cache can hold, least
+// recently used values are evicted if the cache holds more than that.
+func (c *Cache) SetCapacity(capacity int) error {
+	if capacity < 1 {
+		return errors.New("Capacity must be greater than zero.")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.capacity = capacity
+	c.evict()
+	return nil
+}
+
+// Capacity returns the maximum number of values the cache can hold.
+func (c *Cache) Capacity() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.capacity
+}
+
+// Len returns the number of values currently stored in the cache.
+func (c *Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.li.Len()
+}
+
 // Clear generates a new memory space, leaving the old memory unreferenced, so
 // it can be claimed by the garbage collector.
 func (c *Cache) Clear() {
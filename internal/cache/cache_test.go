@@ -71,6 +71,91 @@ func TestCacheReadExistentValue(t *testing.T) {
 	}
 }
 
+type purgeableT struct {
+	purged bool
+}
+
+func (p *purgeableT) OnPurge() {
+	p.purged = true
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	z, err := NewCacheWithCapacity(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b, c := String("a"), String("b"), String("c")
+	pa, pb := &purgeableT{}, &purgeableT{}
+
+	z.Write(a, pa)
+	z.Write(b, pb)
+
+	// Reading "a" makes "b" the least recently used value.
+	if _, ok := z.ReadRaw(a); !ok {
+		t.Fatal("Expecting true.")
+	}
+
+	z.Write(c, "c")
+
+	if _, ok := z.ReadRaw(b); ok {
+		t.Fatal("Expecting b to be evicted.")
+	}
+	if !pb.purged || pa.purged {
+		t.Fatal("Expecting only b to be purged.")
+	}
+	if z.Len() != 2 {
+		t.Fatalf("Expecting 2 items, got %d.", z.Len())
+	}
+}
+
+func TestCacheSetCapacity(t *testing.T) {
+	z := NewCache()
+	for i := 0; i < 10; i++ {
+		z.Write(String(fmt.Sprintf("item-%d", i)), value)
+	}
+
+	if err := z.SetCapacity(0); err == nil {
+		t.Fatal("Expecting an error.")
+	}
+
+	if err := z.SetCapacity(3); err != nil {
+		t.Fatal(err)
+	}
+	if z.Capacity() != 3 || z.Len() != 3 {
+		t.Fatalf("Expecting 3 items, got %d.", z.Len())
+	}
+	if _, ok := z.Read(String("item-9")); !ok {
+		t.Fatal("Expecting the most recent item to be kept.")
+	}
+	if _, ok := z.Read(String("item-0")); ok {
+		t.Fatal("Expecting the oldest item to be evicted.")
+	}
+}
+
+func TestCacheDelete(t *testing.T) {
+	z := NewCache()
+
+	a, b := String("a"), String("b")
+	pa := &purgeableT{}
+
+	z.Write(a, pa)
+	z.Write(b, value)
+
+	z.Delete(a)
+	z.Delete(String("missing"))
+
+	if _, ok := z.ReadRaw(a); ok {
+		t.Fatal("Expecting a to be deleted.")
+	}
+	if !pa.purged {
+		t.Fatal("Expecting a to be purged.")
+	}
+	if z.Len() != 1 {
+		t.Fatalf("Expecting 1 item, got %d.", z.Len())
+	}
+}
+
 func BenchmarkNewCache(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		NewCache()
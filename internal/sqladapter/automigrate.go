@@ -0,0 +1,252 @@
+package sqladapter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/reflectx"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// hasIndexes is implemented by adapters that can list the indexes of a table,
+// AutoMigrate uses it to tell which of the indexes of a struct are missing on
+// tables that already exist.
+type hasIndexes interface {
+	Indexes(name string) ([]string, error)
+}
+
+// migrationStep is a statement of a migration plan.
+type migrationStep interface {
+	sqlbuilder.Execer
+	fmt.Stringer
+}
+
+// structIndex is an index declared by the fields of a struct with the index
+// or unique options, like `db:"email,unique"` or `db:"zip,index=addr_idx"`.
+type structIndex struct {
+	name    string
+	columns []string
+	unique  bool
+}
+
+// indexSuffixes are added to the names of the indexes that are declared
+// without one.
+var indexSuffixes = map[string]string{
+	"index":  "_idx",
+	"unique": "_key",
+}
+
+// AutoMigrate creates the tables, columns and indexes of the given structs
+// that don't exist on the database yet.
+func (d *database) AutoMigrate(items ...interface{}) error {
+	steps, err := d.migrationPlan(items)
+	if err != nil {
+		return err
+	}
+	defer d.ClearCache()
+
+	for i := range steps {
+		if _, err := steps[i].Exec(); err != nil {
+			return fmt.Errorf("%v: %v", steps[i], err)
+		}
+	}
+	return nil
+}
+
+// AutoMigrateDryRun returns the statements AutoMigrate would run for the
+// given structs, without running them.
+func (d *database) AutoMigrateDryRun(items ...interface{}) ([]string, error) {
+	steps, err := d.migrationPlan(items)
+	if err != nil {
+		return nil, err
+	}
+
+	queries := make([]string, len(steps))
+	for i := range steps {
+		queries[i] = steps[i].String()
+	}
+	return queries, nil
+}
+
+// migrationPlan compares the given structs with the live schema and returns
+// the statements that add the tables, nullable columns and indexes that are
+// missing. Changes that could lose data or break existing rows, like dropping
+// or altering columns, are never planned.
+func (d *database) migrationPlan(items []interface{}) ([]migrationStep, error) {
+	var steps []migrationStep
+
+	for _, item := range items {
+		name, err := d.migrationTableName(item)
+		if err != nil {
+			return nil, err
+		}
+		table := d.qualifiedName(name)
+
+		indexes, err := structIndexes(d.Mapper(), table, item)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := d.PartialDatabase.TableExists(table); err != nil {
+			if err != db.ErrCollectionDoesNotExist {
+				return nil, err
+			}
+			steps = append(steps, d.PartialDatabase.CreateTable(table).FromStruct(item))
+			for _, index := range indexes {
+				steps = append(steps, d.createIndex(table, index))
+			}
+			continue
+		}
+
+		columns, _, err := sqlbuilder.StructColumns(d.Mapper(), item)
+		if err != nil {
+			return nil, err
+		}
+
+		existing, err := d.tableColumns(table)
+		if err != nil {
+			return nil, err
+		}
+
+		var alter sqlbuilder.TableAlterer
+		for _, column := range columns {
+			if existing[column.Name] {
+				continue
+			}
+			if column.NotNull {
+				// Rows that already exist would have no value for the column.
+				return nil, fmt.Errorf("AutoMigrate() can't add the NOT NULL column %q to %q, make its field nullable or add it with a migration.", column.Name, table)
+			}
+			if alter == nil {
+				alter = d.PartialDatabase.AlterTable(table)
+			}
+			alter = alter.AddColumn(column)
+		}
+		if alter != nil {
+			steps = append(steps, alter)
+		}
+
+		if len(indexes) == 0 {
+			continue
+		}
+		lister, ok := d.PartialDatabase.(hasIndexes)
+		if !ok {
+			// Without a way to list them, indexes are only created along with
+			// their tables.
+			continue
+		}
+		names, err := lister.Indexes(table)
+		if err != nil {
+			return nil, err
+		}
+		existingIndexes := map[string]bool{}
+		for _, name := range names {
+			existingIndexes[strings.ToLower(name)] = true
+		}
+		for _, index := range indexes {
+			if !existingIndexes[strings.ToLower(index.name)] {
+				steps = append(steps, d.createIndex(table, index))
+			}
+		}
+	}
+
+	return steps, nil
+}
+
+func (d *database) createIndex(table string, index structIndex) sqlbuilder.IndexCreator {
+	q := d.PartialDatabase.CreateIndex(index.name).On(table, index.columns...)
+	if index.unique {
+		q = q.Unique()
+	}
+	return q
+}
+
+// migrationTableName returns the name of the table of the given struct, see
+// db.TableNamer.
+func (d *database) migrationTableName(item interface{}) (string, error) {
+	if namer, ok := item.(db.TableNamer); ok {
+		return namer.TableName(), nil
+	}
+	t := reflect.TypeOf(item)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || t.Name() == "" {
+		return "", errors.New("AutoMigrate() expects named structs or db.TableNamer values.")
+	}
+	if strategy := d.Settings.NamingStrategy(); strategy != nil {
+		return strategy(t.Name()), nil
+	}
+	return db.SnakeCase(t.Name()), nil
+}
+
+// tableColumns returns the set of columns of the given table, they're read
+// from the result of a query that matches no rows, which works the same way
+// on every database.
+func (d *database) tableColumns(table string) (map[string]bool, error) {
+	rows, err := d.PartialDatabase.SelectFrom(table).Where(db.Raw("1 = 0")).Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]bool, len(names))
+	for _, name := range names {
+		columns[name] = true
+	}
+	return columns, nil
+}
+
+// structIndexes returns the indexes the fields of item declare. Indexes are
+// named after the table and their column, like "users_email_idx" or
+// "users_email_key" for unique ones, fields that set the same name make up a
+// single index on many columns.
+func structIndexes(m *reflectx.Mapper, table string, item interface{}) ([]structIndex, error) {
+	t := reflect.TypeOf(item)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("AutoMigrate() expects named structs or db.TableNamer values.")
+	}
+
+	_, prefix := SplitTableName(table)
+
+	var indexes []structIndex
+	byName := map[string]int{}
+
+	for _, fi := range m.TypeMap(t).Index {
+		if fi.Name == "" || fi.Embedded {
+			continue
+		}
+		for _, option := range []string{"index", "unique"} {
+			name, ok := fi.Options[option]
+			if !ok {
+				continue
+			}
+			if name == "" {
+				name = prefix + "_" + fi.Name + indexSuffixes[option]
+			}
+			if i, ok := byName[name]; ok {
+				indexes[i].columns = append(indexes[i].columns, fi.Name)
+				continue
+			}
+			byName[name] = len(indexes)
+			indexes = append(indexes, structIndex{
+				name:    name,
+				columns: []string{fi.Name},
+				unique:  option == "unique",
+			})
+		}
+	}
+
+	return indexes, nil
+}
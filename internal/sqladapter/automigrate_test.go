@@ -0,0 +1,37 @@
+package sqladapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/reflectx"
+)
+
+func TestStructIndexes(t *testing.T) {
+	type address struct {
+		Street string `db:"street,index=users_address_idx"`
+		Zip    string `db:"zip,index=users_address_idx"`
+	}
+
+	type user struct {
+		ID    int64  `db:"id,pk,omitempty"`
+		Email string `db:"email,unique"`
+		Name  string `db:"name,index"`
+		Notes string `db:"notes"`
+		address
+	}
+
+	m := reflectx.NewMapperFunc("db", db.SnakeCase)
+
+	indexes, err := structIndexes(m, "public.users", &user{})
+	assert.NoError(t, err)
+	assert.Equal(t, []structIndex{
+		{name: "users_email_key", columns: []string{"email"}, unique: true},
+		{name: "users_name_idx", columns: []string{"name"}},
+		{name: "users_address_idx", columns: []string{"street", "zip"}},
+	}, indexes)
+
+	_, err = structIndexes(m, "users", map[string]interface{}{})
+	assert.Error(t, err)
+}
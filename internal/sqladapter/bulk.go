@@ -0,0 +1,142 @@
+package sqladapter
+
+import (
+	"fmt"
+	"reflect"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+const defaultBulkBatchSize = 1000
+
+// bulkLoader is implemented by collections that have a faster way than
+// multi-row INSERT statements to load batches of rows.
+type bulkLoader interface {
+	BulkLoad(columns []string, rows [][]interface{}) error
+}
+
+type bulkInserter struct {
+	collection *collection
+	size       int
+}
+
+var _ = db.BulkInserter(&bulkInserter{})
+
+// BulkInsert returns a BulkInserter for the collection.
+func (c *collection) BulkInsert() db.BulkInserter {
+	return &bulkInserter{collection: c, size: defaultBulkBatchSize}
+}
+
+// BatchSize sets the maximum number of items per batch.
+func (b *bulkInserter) BatchSize(size int) db.BulkInserter {
+	if size < 1 {
+		size = 1
+	}
+	clone := *b
+	clone.size = size
+	return &clone
+}
+
+// Insert loads the given slice or channel of items in batches.
+func (b *bulkInserter) Insert(items interface{}) error {
+	var (
+		columns []string
+		rows    [][]interface{}
+		batch   int
+		offset  int
+	)
+
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := b.load(columns, rows); err != nil {
+			return &db.BatchError{Batch: batch, Offset: offset, Size: len(rows), Err: err}
+		}
+		batch++
+		offset += len(rows)
+		rows = nil
+		return nil
+	}
+
+	mapOptions := &sqlbuilder.MapOptions{Mapper: b.collection.Database().Mapper(), Codec: b.collection.Database().Codec}
+
+	err := eachItem(items, func(item interface{}) error {
+		itemColumns, values, err := sqlbuilder.Map(item, mapOptions)
+		if err != nil {
+			return err
+		}
+		// All the rows within a batch must have the same columns.
+		if len(rows) > 0 && !equalColumns(columns, itemColumns) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		columns = itemColumns
+		rows = append(rows, values)
+		if len(rows) >= b.size {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+func (b *bulkInserter) load(columns []string, rows [][]interface{}) error {
+	c := b.collection
+
+	if loader, ok := c.PartialCollection.(bulkLoader); ok {
+		return loader.BulkLoad(columns, rows)
+	}
+
+	q := c.Database().InsertInto(c.Name()).Columns(columns...)
+	for i := range rows {
+		q = q.Values(rows[i]...)
+	}
+	_, err := q.Exec()
+	return err
+}
+
+// eachItem calls fn for every element on the given slice, array or channel.
+func eachItem(items interface{}, fn func(interface{}) error) error {
+	itemsV := reflect.ValueOf(items)
+
+	switch itemsV.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < itemsV.Len(); i++ {
+			if err := fn(itemsV.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Chan:
+		for {
+			item, ok := itemsV.Recv()
+			if !ok {
+				return nil
+			}
+			if err := fn(item.Interface()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return fmt.Errorf("Expecting a slice or a channel but got %T", items)
+}
+
+func equalColumns(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -9,8 +9,22 @@ import (
 	"upper.io/db.v3/lib/reflectx"
 )
 
+// mapper is used to map structs when the session does not provide a mapper
+// of its own, see mapperOf.
 var mapper = reflectx.NewMapper("db")
 
+// mapperOf returns the mapper sess uses to map struct fields to columns.
+func mapperOf(sess interface{}) *reflectx.Mapper {
+	if s, ok := sess.(interface {
+		Mapper() *reflectx.Mapper
+	}); ok {
+		if m := s.Mapper(); m != nil {
+			return m
+		}
+	}
+	return mapper
+}
+
 // Collection represents a SQL table.
 type Collection interface {
 	PartialCollection
@@ -51,6 +65,9 @@ type BaseCollection interface {
 
 	// PrimaryKeys returns the table's primary keys.
 	PrimaryKeys() []string
+
+	// BulkInsert returns a BulkInserter for the table.
+	BulkInsert() db.BulkInserter
 }
 
 type condsFilter interface {
@@ -82,6 +99,13 @@ func (c *collection) PrimaryKeys() []string {
 	return c.pk
 }
 
+// modelPrimaryKeys returns the primary keys the given item is matched by, the
+// fields tagged with the pk option take precedence over the primary keys of
+// the table.
+func (c *collection) modelPrimaryKeys(item interface{}) []string {
+	return modelPrimaryKeys(c.Database().Mapper(), item, c.pk)
+}
+
 func (c *collection) filterConds(conds ...interface{}) []interface{} {
 	if tr, ok := c.PartialCollection.(condsFilter); ok {
 		return tr.FilterConds(conds...)
@@ -101,11 +125,41 @@ func (c *collection) Find(conds ...interface{}) db.Result {
 		res.setErr(c.err)
 		return res
 	}
-	return NewResult(
-		c.Database(),
-		c.Name(),
-		c.filterConds(conds...),
-	)
+
+	if len(conds) == 1 && isModel(conds[0]) {
+		// An item was given, it's going to be matched by its primary keys.
+		cond, err := modelCond(c.Database().Mapper(), conds[0], c.modelPrimaryKeys(conds[0]))
+		if err != nil {
+			res := &Result{}
+			res.setErr(err)
+			return res
+		}
+		res := NewResult(c.Database(), c.Name(), []interface{}{cond}).model(conds[0])
+		return c.recordResult(res, []interface{}{cond})
+	}
+
+	conds = c.filterConds(conds...)
+	return c.recordResult(NewResult(c.Database(), c.Name(), conds), conds)
+}
+
+// recordResult marks res as a primary key lookup if the given conditions
+// match a single item by its primary key and the session has a record cache.
+func (c *collection) recordResult(res *Result, conds []interface{}) *Result {
+	if len(c.pk) != 1 || len(conds) != 1 || c.Database().records() == nil {
+		return res
+	}
+	cond, ok := conds[0].(db.Cond)
+	if !ok || len(cond) != 1 {
+		return res
+	}
+	value, ok := cond[c.pk[0]]
+	if !ok {
+		return res
+	}
+	if id, ok := recordID(value); ok {
+		return res.record(id)
+	}
+	return res
 }
 
 // Exists returns true if the collection exists.
@@ -122,8 +176,12 @@ func (c *collection) InsertReturning(item interface{}) error {
 		return fmt.Errorf("Expecting a pointer but got %T", item)
 	}
 
+	if supportsReturning(c.Database()) {
+		return c.insertReturning(item)
+	}
+
 	// Grab primary keys
-	pks := c.PrimaryKeys()
+	pks := c.modelPrimaryKeys(item)
 	if len(pks) == 0 {
 		return fmt.Errorf("InsertReturning: Cannot update an item without primary keys")
 	}
@@ -146,9 +204,7 @@ func (c *collection) InsertReturning(item interface{}) error {
 
 	// Allocate a clone of item.
 	newItem := reflect.New(reflect.ValueOf(item).Elem().Type()).Interface()
-	var newItemFieldMap map[string]reflect.Value
-
-	itemValue := reflect.ValueOf(item)
+	var cond db.Cond
 
 	col := tx.(Database).Collection(c.Name())
 
@@ -162,30 +218,19 @@ func (c *collection) InsertReturning(item interface{}) error {
 		goto cancel
 	}
 
+	// All the parts of a compound key are needed to fetch the new row.
+	cond, err = insertedCond(c.Database().Mapper(), item, id, pks)
+	if err != nil {
+		goto cancel
+	}
+
 	// Fetch the row that was just interted into newItem
-	err = col.Find(id).One(newItem)
+	err = col.Find(cond).One(newItem)
 	if err != nil {
 		goto cancel
 	}
 
-	switch reflect.ValueOf(newItem).Elem().Kind() {
-	case reflect.Struct:
-		// Get valid fields from newItem to overwrite those that are on item.
-		newItemFieldMap = mapper.ValidFieldMap(reflect.ValueOf(newItem))
-		for fieldName := range newItemFieldMap {
-			mapper.FieldByName(itemValue, fieldName).Set(newItemFieldMap[fieldName])
-		}
-	case reflect.Map:
-		newItemV := reflect.ValueOf(newItem).Elem()
-		itemV := reflect.ValueOf(item)
-		if itemV.Kind() == reflect.Ptr {
-			itemV = itemV.Elem()
-		}
-		for _, keyV := range newItemV.MapKeys() {
-			itemV.SetMapIndex(keyV, newItemV.MapIndex(keyV))
-		}
-	default:
-		err = fmt.Errorf("InsertReturning: expecting a pointer to map or struct, got %T", newItem)
+	if err = setItem(c.Database().Mapper(), item, newItem); err != nil {
 		goto cancel
 	}
 
@@ -209,17 +254,23 @@ cancel:
 	return err
 }
 
+// UpdateReturning updates an item by its primary keys and updates the given
+// variable reference with the values of the row after the update.
 func (c *collection) UpdateReturning(item interface{}) error {
 	if item == nil || reflect.TypeOf(item).Kind() != reflect.Ptr {
 		return fmt.Errorf("Expecting a pointer but got %T", item)
 	}
 
 	// Grab primary keys
-	pks := c.PrimaryKeys()
+	pks := c.modelPrimaryKeys(item)
 	if len(pks) == 0 {
 		return fmt.Errorf("InsertReturning: Cannot update an item without primary keys")
 	}
 
+	if supportsReturning(c.Database()) {
+		return c.updateReturning(item, pks)
+	}
+
 	var tx DatabaseTx
 	inTx := false
 
@@ -238,18 +289,15 @@ func (c *collection) UpdateReturning(item interface{}) error {
 
 	// Allocate a clone of item.
 	defaultItem := reflect.New(reflect.ValueOf(item).Elem().Type()).Interface()
-	var defaultItemFieldMap map[string]reflect.Value
 
-	itemValue := reflect.ValueOf(item)
+	col := tx.(Database).Collection(c.Name())
 
-	conds := db.Cond{}
-	for _, pk := range pks {
-		conds[pk] = mapper.FieldByName(itemValue, pk).Interface()
+	conds, err := modelCond(c.Database().Mapper(), item, pks)
+	if err != nil {
+		goto cancel
 	}
 
-	col := tx.(Database).Collection(c.Name())
-
-	err := col.Find(conds).Update(item)
+	err = col.Find(conds).Update(item)
 	if err != nil {
 		goto cancel
 	}
@@ -258,24 +306,8 @@ func (c *collection) UpdateReturning(item interface{}) error {
 		goto cancel
 	}
 
-	switch reflect.ValueOf(defaultItem).Elem().Kind() {
-	case reflect.Struct:
-		// Get valid fields from defaultItem to overwrite those that are on item.
-		defaultItemFieldMap = mapper.ValidFieldMap(reflect.ValueOf(defaultItem))
-		for fieldName := range defaultItemFieldMap {
-			mapper.FieldByName(itemValue, fieldName).Set(defaultItemFieldMap[fieldName])
-		}
-	case reflect.Map:
-		defaultItemV := reflect.ValueOf(defaultItem).Elem()
-		itemV := reflect.ValueOf(item)
-		if itemV.Kind() == reflect.Ptr {
-			itemV = itemV.Elem()
-		}
-		for _, keyV := range defaultItemV.MapKeys() {
-			itemV.SetMapIndex(keyV, defaultItemV.MapIndex(keyV))
-		}
-	default:
-		panic("default")
+	if err = setItem(c.Database().Mapper(), item, defaultItem); err != nil {
+		goto cancel
 	}
 
 	if !inTx {
@@ -308,3 +340,75 @@ func (c *collection) Truncate() error {
 	}
 	return nil
 }
+
+// insertReturning inserts an item and reads the new row back into it within
+// the same statement.
+func (c *collection) insertReturning(item interface{}) error {
+	newItem := reflect.New(reflect.ValueOf(item).Elem().Type()).Interface()
+
+	_, err := InsertWithHooks(c.Database(), item, func(item interface{}) (interface{}, error) {
+		q := c.Database().InsertInto(c.Name()).
+			Values(item).
+			Returning("*")
+		return nil, q.Iterator().One(newItem)
+	})
+	if err != nil {
+		return err
+	}
+
+	return setItem(c.Database().Mapper(), item, newItem)
+}
+
+// updateReturning updates an item and reads the updated row back into it
+// within the same statement.
+func (c *collection) updateReturning(item interface{}, pks []string) error {
+	conds, err := modelCond(c.Database().Mapper(), item, pks)
+	if err != nil {
+		return err
+	}
+
+	newItem := reflect.New(reflect.ValueOf(item).Elem().Type()).Interface()
+
+	res := c.recordResult(NewResult(c.Database(), c.Name(), []interface{}{conds}), []interface{}{conds})
+	if err := res.updateReturning(c.Database().Context(), item, newItem); err != nil {
+		return err
+	}
+
+	return setItem(c.Database().Mapper(), item, newItem)
+}
+
+// setItem overwrites the fields (or keys) of item with the ones of newItem,
+// both must be pointers to structs or maps of the same type.
+func setItem(m *reflectx.Mapper, item interface{}, newItem interface{}) error {
+	itemV := reflect.ValueOf(item)
+	if itemV.Kind() == reflect.Ptr {
+		itemV = itemV.Elem()
+	}
+
+	switch reflect.ValueOf(newItem).Elem().Kind() {
+	case reflect.Struct:
+		// Get valid fields from newItem to overwrite those that are on item.
+		newItemFieldMap := m.ValidFieldMap(reflect.ValueOf(newItem))
+		for fieldName := range newItemFieldMap {
+			m.FieldByName(reflect.ValueOf(item), fieldName).Set(newItemFieldMap[fieldName])
+		}
+	case reflect.Map:
+		newItemV := reflect.ValueOf(newItem).Elem()
+		for _, keyV := range newItemV.MapKeys() {
+			itemV.SetMapIndex(keyV, newItemV.MapIndex(keyV))
+		}
+	default:
+		return fmt.Errorf("Expecting a pointer to map or struct, got %T", newItem)
+	}
+
+	return nil
+}
+
+// supportsReturning returns true if the given database can return the rows
+// written by INSERT and UPDATE statements.
+func supportsReturning(d Database) bool {
+	if r, ok := d.(hasReturning); ok {
+		return r.SupportsReturning()
+	}
+	return false
+}
@@ -70,3 +70,11 @@ type TxStarter interface {
 func BeginTx(p TxStarter, ctx context.Context, opts interface{}) (*sql.Tx, error) {
 	return p.Begin()
 }
+
+type Pinger interface {
+	Ping() error
+}
+
+func PingContext(p Pinger, ctx context.Context) error {
+	return p.Ping()
+}
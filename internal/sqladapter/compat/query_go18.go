@@ -70,3 +70,11 @@ type TxStarter interface {
 func BeginTx(p TxStarter, ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
 	return p.BeginTx(ctx, opts)
 }
+
+type Pinger interface {
+	PingContext(context.Context) error
+}
+
+func PingContext(p Pinger, ctx context.Context) error {
+	return p.PingContext(ctx)
+}
@@ -0,0 +1,25 @@
+// +build !go1.11
+
+package compat
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Stats holds connection pool statistics.
+type Stats struct {
+	OpenConnections int
+	InUse           int
+	Idle            int
+	WaitCount       int64
+	WaitDuration    time.Duration
+}
+
+// DBStats returns the statistics of the given pool, only the number of open
+// connections is available before Go 1.11.
+func DBStats(sess *sql.DB) Stats {
+	return Stats{
+		OpenConnections: sess.Stats().OpenConnections,
+	}
+}
@@ -0,0 +1,29 @@
+// +build go1.11
+
+package compat
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Stats holds connection pool statistics.
+type Stats struct {
+	OpenConnections int
+	InUse           int
+	Idle            int
+	WaitCount       int64
+	WaitDuration    time.Duration
+}
+
+// DBStats returns the statistics of the given pool.
+func DBStats(sess *sql.DB) Stats {
+	s := sess.Stats()
+	return Stats{
+		OpenConnections: s.OpenConnections,
+		InUse:           s.InUse,
+		Idle:            s.Idle,
+		WaitCount:       s.WaitCount,
+		WaitDuration:    s.WaitDuration,
+	}
+}
@@ -0,0 +1,115 @@
+package sqladapter
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"upper.io/db.v3"
+)
+
+// OpenDB opens a *sql.DB like sql.Open does, every new connection of its pool
+// runs the given statements and then the onConnect function, if any, before
+// it's used. Connections that fail to do so are closed and the error is
+// returned to the caller that requested the connection.
+func OpenDB(driverName string, dsn string, statements []string, onConnect func(db.Conn) error) (*sql.DB, error) {
+	sess, err := sql.Open(driverName, dsn)
+	if err != nil || (len(statements) == 0 && onConnect == nil) {
+		return sess, err
+	}
+
+	drv := sess.Driver()
+	if err := sess.Close(); err != nil {
+		return nil, err
+	}
+
+	return sql.OpenDB(&connector{
+		driver:     drv,
+		dsn:        dsn,
+		statements: statements,
+		onConnect:  onConnect,
+	}), nil
+}
+
+type connector struct {
+	driver     driver.Driver
+	dsn        string
+	statements []string
+	onConnect  func(db.Conn) error
+}
+
+var _ = driver.Connector(&connector{})
+
+// Connect satisfies driver.Connector.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	setup := &setupConn{ctx: ctx, conn: conn}
+	for _, stmt := range c.statements {
+		if err = setup.Exec(stmt); err != nil {
+			break
+		}
+	}
+	if err == nil && c.onConnect != nil {
+		err = c.onConnect(setup)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// Driver satisfies driver.Connector.
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// setupConn runs statements on a driver connection that was not handed to
+// the pool yet.
+type setupConn struct {
+	ctx  context.Context
+	conn driver.Conn
+}
+
+var _ = db.Conn(&setupConn{})
+
+func (s *setupConn) Exec(query string, args ...interface{}) error {
+	values := make([]driver.NamedValue, len(args))
+	for i := range args {
+		v, err := driver.DefaultParameterConverter.ConvertValue(args[i])
+		if err != nil {
+			return err
+		}
+		values[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+
+	if execer, ok := s.conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(s.ctx, query, values)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+
+	stmt, err := s.conn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if execer, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = execer.ExecContext(s.ctx, values)
+		return err
+	}
+
+	plain := make([]driver.Value, len(values))
+	for i := range values {
+		plain[i] = values[i].Value
+	}
+	_, err = stmt.Exec(plain)
+	return err
+}
@@ -0,0 +1,91 @@
+package sqladapter
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"upper.io/db.v3"
+)
+
+// uniqueFields caches the unique constraints of struct types, by type.
+var uniqueFields = struct {
+	sync.Mutex
+	m map[reflect.Type]map[string]string
+}{m: map[reflect.Type]map[string]string{}}
+
+// uniqueConstraints returns the unique constraints the fields of t declare
+// with the unique tag, like `unique:"users_email_key->Email"`, mapped to the
+// names of the fields.
+func uniqueConstraints(t reflect.Type) map[string]string {
+	uniqueFields.Lock()
+	defer uniqueFields.Unlock()
+
+	if constraints, ok := uniqueFields.m[t]; ok {
+		return constraints
+	}
+
+	constraints := map[string]string{}
+	collectUniqueConstraints(t, constraints)
+	uniqueFields.m[t] = constraints
+
+	return constraints
+}
+
+func collectUniqueConstraints(t reflect.Type, constraints map[string]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectUniqueConstraints(ft, constraints)
+			}
+		}
+		tag, ok := field.Tag.Lookup("unique")
+		if !ok {
+			continue
+		}
+		for _, pair := range strings.Split(tag, ",") {
+			parts := strings.SplitN(pair, "->", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			constraint, name := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+			if constraint != "" && name != "" {
+				constraints[constraint] = name
+			}
+		}
+	}
+}
+
+// constraintError returns a *db.ConstraintError if err is a unique violation
+// of a constraint that item maps to one of its fields, or err otherwise.
+func constraintError(item interface{}, err error) error {
+	dbErr, ok := err.(*db.Error)
+	if !ok || dbErr.Kind != db.ErrUniqueViolation || item == nil {
+		return err
+	}
+
+	t := reflect.TypeOf(item)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return err
+	}
+
+	constraints := uniqueConstraints(t)
+	if field, ok := constraints[dbErr.Constraint]; ok && dbErr.Constraint != "" {
+		return &db.ConstraintError{Field: field, Err: dbErr}
+	}
+	if dbErr.Constraint == "" && len(dbErr.Columns) == 1 {
+		if field, ok := constraints[dbErr.Columns[0]]; ok {
+			return &db.ConstraintError{Field: field, Err: dbErr}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,61 @@
+package sqladapter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+)
+
+type uniqueBase struct {
+	_ struct{} `db:"-" unique:"users_username_key->Username"`
+}
+
+type uniqueUser struct {
+	uniqueBase
+	_        struct{} `db:"-" unique:"users_email_key->Email, email->Email"`
+	ID       int64    `db:"id,omitempty"`
+	Email    string   `db:"email"`
+	Username string   `db:"username"`
+}
+
+func TestConstraintError(t *testing.T) {
+	driverErr := errors.New("duplicate key")
+
+	{
+		dbErr := &db.Error{Kind: db.ErrUniqueViolation, Constraint: "users_email_key", Err: driverErr}
+		err := constraintError(&uniqueUser{}, dbErr)
+		if assert.IsType(t, &db.ConstraintError{}, err) {
+			assert.Equal(t, "Email", err.(*db.ConstraintError).Field)
+			assert.Equal(t, dbErr, err.(*db.ConstraintError).Err)
+		}
+	}
+
+	{
+		dbErr := &db.Error{Kind: db.ErrUniqueViolation, Constraint: "users_username_key", Err: driverErr}
+		err := constraintError(uniqueUser{}, dbErr)
+		if assert.IsType(t, &db.ConstraintError{}, err) {
+			assert.Equal(t, "Username", err.(*db.ConstraintError).Field)
+		}
+	}
+
+	{
+		dbErr := &db.Error{Kind: db.ErrUniqueViolation, Table: "users", Columns: []string{"email"}, Err: driverErr}
+		err := constraintError(&uniqueUser{}, dbErr)
+		if assert.IsType(t, &db.ConstraintError{}, err) {
+			assert.Equal(t, "Email", err.(*db.ConstraintError).Field)
+		}
+	}
+
+	for _, err := range []error{
+		driverErr,
+		&db.Error{Kind: db.ErrUniqueViolation, Constraint: "users_pkey", Err: driverErr},
+		&db.Error{Kind: db.ErrCheckViolation, Constraint: "users_email_key", Err: driverErr},
+	} {
+		assert.Equal(t, err, constraintError(&uniqueUser{}, err))
+	}
+
+	dbErr := &db.Error{Kind: db.ErrUniqueViolation, Constraint: "users_email_key", Err: driverErr}
+	assert.Equal(t, dbErr, constraintError(map[string]interface{}{"email": "a"}, dbErr))
+}
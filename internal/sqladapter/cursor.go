@@ -0,0 +1,117 @@
+package sqladapter
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/compat"
+	"upper.io/db.v3/internal/sqladapter/exql"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// cursor is a server-side cursor that lives within a transaction, ownTx is
+// set when the transaction was started for the cursor and has to be finished
+// when the cursor is closed.
+type cursor struct {
+	d     *database
+	tx    *baseTx
+	ownTx bool
+
+	fetch       *exql.Statement
+	closeCursor *exql.Statement
+	closed      bool
+}
+
+var _ = sqlbuilder.Cursor(&cursor{})
+
+// StatementCursor declares a server-side cursor with the given statements.
+// The cursor is declared within the current transaction or, if there is
+// none, within a new transaction that is committed when the cursor is closed.
+func (d *database) StatementCursor(ctx context.Context, declare, fetch, closeCursor *exql.Statement, args ...interface{}) (sqlbuilder.Cursor, error) {
+	if txd := d.ambientTx(ctx); txd != nil {
+		return txd.StatementCursor(ctx, declare, fetch, closeCursor, args...)
+	}
+
+	c := &cursor{d: d, fetch: fetch, closeCursor: closeCursor}
+
+	if tx := d.Transaction(); tx != nil {
+		c.tx = tx.(*baseTx)
+	} else {
+		sess := d.Session()
+		if sess == nil {
+			return nil, db.ErrNotConnected
+		}
+		sqlTx, err := compat.BeginTx(sess, ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		c.tx, c.ownTx = newBaseTx(sqlTx, d.metrics).(*baseTx), true
+	}
+
+	if err := c.exec(ctx, declare, args...); err != nil {
+		if c.ownTx {
+			_ = c.tx.Rollback()
+		}
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *cursor) logQuery(ctx context.Context, stmt *exql.Statement, query string, args []interface{}, err error, start time.Time) {
+	c.d.metrics.observeQuery(stmt, start, err)
+	c.d.observeStats(query, start, err, 0)
+	c.d.observeSlowQuery(ctx, query, args, start, err)
+	if c.d.Settings.LoggingEnabled() {
+		db.LogQuery(ctx, c.d.Logger(), &db.QueryStatus{
+			TxID:   c.d.txID,
+			SessID: c.d.sessID,
+			Query:  query,
+			Args:   args,
+			Err:    err,
+			Start:  start,
+			End:    time.Now(),
+		})
+	}
+}
+
+func (c *cursor) exec(ctx context.Context, stmt *exql.Statement, args ...interface{}) (err error) {
+	start := time.Now()
+	query, args := c.d.compileStatement(stmt, args)
+	_, err = compat.ExecContext(c.tx, ctx, query, args)
+	c.logQuery(ctx, stmt, query, args, err, start)
+	return err
+}
+
+// Fetch retrieves the next batch of rows from the cursor.
+func (c *cursor) Fetch(ctx context.Context) (rows *sql.Rows, err error) {
+	if c.closed {
+		return nil, sql.ErrTxDone
+	}
+	start := time.Now()
+	query, args := c.d.compileStatement(c.fetch, nil)
+	rows, err = compat.QueryContext(c.tx, ctx, query, args)
+	c.logQuery(ctx, c.fetch, query, args, err, start)
+	return rows, err
+}
+
+// Close closes the cursor and finishes the transaction it was declared in if
+// it was started for the cursor.
+func (c *cursor) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	err := c.exec(c.d.Context(), c.closeCursor)
+	if !c.ownTx {
+		return err
+	}
+	if err != nil {
+		_ = c.tx.Rollback()
+		return err
+	}
+	return c.tx.Commit()
+}
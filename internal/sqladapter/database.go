@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"math"
+	"math/big"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,6 +15,7 @@ import (
 	"upper.io/db.v3/internal/cache"
 	"upper.io/db.v3/internal/sqladapter/compat"
 	"upper.io/db.v3/internal/sqladapter/exql"
+	"upper.io/db.v3/lib/reflectx"
 	"upper.io/db.v3/lib/sqlbuilder"
 )
 
@@ -32,6 +35,21 @@ type hasStatementExec interface {
 	StatementExec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
+// hasStaleStatement is implemented by adapters that can tell whether an error
+// means that a cached prepared statement is no longer valid, like after a
+// schema change, and must be prepared again.
+type hasStaleStatement interface {
+	IsStaleStatement(err error) bool
+}
+
+// hasReturning is implemented by adapters that can return the rows written by
+// INSERT and UPDATE statements within the same statement, like PostgreSQL with
+// RETURNING or MSSQL with OUTPUT. InsertReturning and UpdateReturning use it
+// to avoid querying the row again.
+type hasReturning interface {
+	SupportsReturning() bool
+}
+
 // Database represents a SQL database.
 type Database interface {
 	PartialDatabase
@@ -81,6 +99,10 @@ type PartialDatabase interface {
 type BaseDatabase interface {
 	db.Settings
 
+	// Mapper returns the mapper that maps struct fields to columns following
+	// the naming strategy of the session.
+	Mapper() *reflectx.Mapper
+
 	// Name returns the name of the database.
 	Name() string
 
@@ -90,6 +112,10 @@ type BaseDatabase interface {
 	// Ping checks if the database server is reachable.
 	Ping() error
 
+	// PingContext checks if the database server is reachable within the given
+	// context.
+	PingContext(context.Context) error
+
 	// ClearCache clears all caches the session is using
 	ClearCache()
 
@@ -109,6 +135,43 @@ type BaseDatabase interface {
 	// Session returns the *sql.DB the session is using.
 	Session() *sql.DB
 
+	// BindReplicas sets the read replicas the session sends SELECT statements
+	// to when they're not part of a transaction.
+	BindReplicas(...*sql.DB) error
+
+	// ResultCache returns the store cached queries keep their results in, nil
+	// within transactions.
+	ResultCache() db.QueryCache
+
+	// SetRecordCache makes primary key lookups read items from the given
+	// store, a nil store disables the record cache.
+	SetRecordCache(store db.QueryCache, ttl time.Duration)
+
+	// InvalidateRecordCache drops all the cached items of a collection.
+	InvalidateRecordCache(collection string)
+
+	// records returns the record cache of the session, if any.
+	records() *recordCache
+
+	// base returns the session that implements BaseDatabase.
+	base() *database
+
+	// Replicas returns the read replicas the session is using.
+	Replicas() []*sql.DB
+
+	// ForcePrimary sets whether the session ignores its read replicas.
+	ForcePrimary(bool)
+
+	// Schema returns the schema the collections of the session belong to.
+	Schema() string
+
+	// SetSchema sets the schema the collections of the session belong to.
+	SetSchema(string)
+
+	// SchemaSession returns the session that resolves collections against the
+	// given schema, creating it with the given function if needed.
+	SchemaSession(string, func() (Database, error)) (Database, error)
+
 	// BindTx binds a transaction to the current session.
 	BindTx(context.Context, *sql.Tx) error
 
@@ -123,6 +186,28 @@ type BaseDatabase interface {
 
 	// SetContext sets a default context for the session.
 	SetContext(context.Context)
+
+	// Metrics returns a snapshot of the connection pool statistics and query
+	// counters of the session.
+	Metrics() db.Metrics
+
+	// Stats returns a report of the statements the session executed, grouped
+	// by fingerprint.
+	Stats() db.Stats
+
+	// ResetStats drops the statistics returned by Stats.
+	ResetStats()
+
+	// ObserveRows adds the number of rows that were read from the result of
+	// a statement to its statistics.
+	ObserveRows(stmt *exql.Statement, rows int)
+
+	// AutoMigrate creates the tables, columns and indexes of the given
+	// structs that are missing.
+	AutoMigrate(items ...interface{}) error
+
+	// AutoMigrateDryRun returns the statements AutoMigrate would run.
+	AutoMigrateDryRun(items ...interface{}) ([]string, error)
 }
 
 // NewBaseDatabase provides a BaseDatabase given a PartialDatabase
@@ -131,11 +216,20 @@ func NewBaseDatabase(p PartialDatabase) BaseDatabase {
 		Settings:          db.NewSettings(),
 		PartialDatabase:   p,
 		cachedCollections: cache.NewCache(),
-		cachedStatements:  cache.NewCache(),
+		metrics:           newMetrics(),
 	}
+	d.cachedStatements = newStatementCache(d.Settings.PreparedStatementCacheSize())
 	return d
 }
 
+func newStatementCache(size int) *cache.Cache {
+	c, err := cache.NewCacheWithCapacity(size)
+	if err != nil {
+		return cache.NewCache()
+	}
+	return c
+}
+
 // database is the actual implementation of Database and joins methods from
 // BaseDatabase and PartialDatabase
 type database struct {
@@ -153,6 +247,22 @@ type database struct {
 	sess   *sql.DB
 	sessMu sync.Mutex
 
+	replicas     []*sql.DB
+	replicaIndex uint64
+	forcePrimary bool
+
+	schema         string
+	schemaSessions map[string]Database
+	schemaMu       sync.Mutex
+
+	txInvalidated   []string
+	txInvalidateAll bool
+
+	recordCache *recordCache
+
+	healthStop chan struct{}
+	healthMu   sync.Mutex
+
 	psMu sync.Mutex
 
 	sessID uint64
@@ -162,6 +272,11 @@ type database struct {
 	cachedCollections *cache.Cache
 
 	template *exql.Template
+
+	mapper   *reflectx.Mapper
+	mapperMu sync.Mutex
+
+	metrics *metrics
 }
 
 var (
@@ -190,12 +305,36 @@ func (d *database) Context() context.Context {
 	return d.ctx
 }
 
+func (d *database) base() *database {
+	return d
+}
+
+// ambientTx returns the session of the transaction ctx carries, see
+// db.ContextWithTx, if it was started on the same database and d is not
+// within a transaction already.
+func (d *database) ambientTx(ctx context.Context) *database {
+	if ctx == nil || d.Transaction() != nil {
+		return nil
+	}
+	tx, ok := db.TxFromContext(ctx).(interface {
+		base() *database
+	})
+	if !ok {
+		return nil
+	}
+	txd := tx.base()
+	if txd.Transaction() == nil || txd.Session() != d.Session() {
+		return nil
+	}
+	return txd
+}
+
 // BindTx binds a *sql.Tx into *database
 func (d *database) BindTx(ctx context.Context, t *sql.Tx) error {
 	d.sessMu.Lock()
 	defer d.sessMu.Unlock()
 
-	d.baseTx = newBaseTx(t)
+	d.baseTx = newBaseTx(t, d.metrics)
 	if err := d.Ping(); err != nil {
 		return err
 	}
@@ -241,9 +380,54 @@ func (d *database) BindSession(sess *sql.DB) error {
 
 	d.name = name
 
+	d.startHealthCheck()
+
 	return nil
 }
 
+// Metrics returns a snapshot of the connection pool statistics and query
+// counters of the session.
+func (d *database) Metrics() db.Metrics {
+	return d.metrics.snapshot(d.Session())
+}
+
+// Stats returns a report of the statements the session executed, grouped by
+// fingerprint, statistics are only collected if they're enabled with
+// SetQueryStats.
+func (d *database) Stats() db.Stats {
+	if d.metrics == nil {
+		return db.Stats{}
+	}
+	return d.metrics.stats.snapshot()
+}
+
+// ResetStats drops the statistics returned by Stats.
+func (d *database) ResetStats() {
+	if d.metrics == nil {
+		return
+	}
+	d.metrics.stats.reset()
+}
+
+// ObserveRows adds the number of rows that were read from the result of stmt
+// to its statistics.
+func (d *database) ObserveRows(stmt *exql.Statement, rows int) {
+	if d.metrics == nil || !d.Settings.QueryStatsEnabled() {
+		return
+	}
+	query, _ := d.compileStatement(stmt, nil)
+	d.metrics.stats.observeRows(query, rows)
+}
+
+// observeStats adds an executed statement to the statistics of the session,
+// if they're enabled.
+func (d *database) observeStats(query string, start time.Time, err error, rows int64) {
+	if query == "" || d.metrics == nil || !d.Settings.QueryStatsEnabled() {
+		return
+	}
+	d.metrics.stats.observe(query, time.Since(start), err, rows)
+}
+
 // Ping checks whether a connection to the database is still alive by pinging
 // it
 func (d *database) Ping() error {
@@ -260,6 +444,9 @@ func (d *database) SetConnMaxLifetime(t time.Duration) {
 	if sess := d.Session(); sess != nil {
 		sess.SetConnMaxLifetime(d.Settings.ConnMaxLifetime())
 	}
+	for _, replica := range d.Replicas() {
+		replica.SetConnMaxLifetime(d.Settings.ConnMaxLifetime())
+	}
 }
 
 // SetMaxIdleConns sets the maximum number of connections in the idle
@@ -269,6 +456,9 @@ func (d *database) SetMaxIdleConns(n int) {
 	if sess := d.Session(); sess != nil {
 		sess.SetMaxIdleConns(d.MaxIdleConns())
 	}
+	for _, replica := range d.Replicas() {
+		replica.SetMaxIdleConns(d.MaxIdleConns())
+	}
 }
 
 // SetMaxOpenConns sets the maximum number of open connections to the
@@ -278,6 +468,36 @@ func (d *database) SetMaxOpenConns(n int) {
 	if sess := d.Session(); sess != nil {
 		sess.SetMaxOpenConns(d.MaxOpenConns())
 	}
+	for _, replica := range d.Replicas() {
+		replica.SetMaxOpenConns(d.MaxOpenConns())
+	}
+}
+
+// SetPreparedStatementCacheSize sets the maximum number of prepared statements
+// the session keeps, closing the least recently used ones if there are more.
+func (d *database) SetPreparedStatementCacheSize(n int) {
+	d.Settings.SetPreparedStatementCacheSize(n)
+	_ = d.cachedStatements.SetCapacity(d.Settings.PreparedStatementCacheSize())
+}
+
+// SetNamingStrategy sets the strategy that maps struct fields without a `db`
+// tag to column names.
+func (d *database) SetNamingStrategy(strategy db.NamingStrategy) {
+	d.Settings.SetNamingStrategy(strategy)
+	d.mapperMu.Lock()
+	d.mapper = nil
+	d.mapperMu.Unlock()
+}
+
+// Mapper returns the mapper that maps struct fields to columns following the
+// naming strategy of the session.
+func (d *database) Mapper() *reflectx.Mapper {
+	d.mapperMu.Lock()
+	defer d.mapperMu.Unlock()
+	if d.mapper == nil {
+		d.mapper = reflectx.NewMapperFunc("db", d.Settings.NamingStrategy())
+	}
+	return d.mapper
 }
 
 // ClearCache removes all caches.
@@ -299,6 +519,9 @@ func (d *database) NewClone(p PartialDatabase, checkConn bool) (BaseDatabase, er
 
 	nd.name = d.name
 	nd.sess = d.sess
+	nd.replicas = d.Replicas()
+	nd.schema = d.Schema()
+	nd.metrics = d.metrics
 
 	if checkConn {
 		if err := nd.Ping(); err != nil {
@@ -310,12 +533,19 @@ func (d *database) NewClone(p PartialDatabase, checkConn bool) (BaseDatabase, er
 
 	// New transaction should inherit parent settings
 	copySettings(d, nd)
+	nd.mapper = d.Mapper()
+
+	if rc := d.records(); rc != nil {
+		nd.SetRecordCache(rc.store, rc.ttl)
+	}
 
 	return nd, nil
 }
 
 // Close terminates the current database session
 func (d *database) Close() error {
+	d.stopHealthCheck()
+
 	defer func() {
 		d.sessMu.Lock()
 		d.sess = nil
@@ -330,9 +560,17 @@ func (d *database) Close() error {
 		d.cachedCollections.Clear()
 		d.cachedStatements.Clear() // Closes prepared statements as well.
 
+		d.schemaMu.Lock()
+		d.schemaSessions = nil
+		d.schemaMu.Unlock()
+
 		tx := d.Transaction()
 		if tx == nil {
 			// Not within a transaction.
+			if err := closeReplicas(d.Replicas()); err != nil {
+				d.sess.Close()
+				return err
+			}
 			return d.sess.Close()
 		}
 
@@ -340,6 +578,10 @@ func (d *database) Close() error {
 			tx.Rollback()
 			return nil
 		}
+		d.commitQueryCache()
+		if rc := d.records(); rc != nil {
+			rc.commit()
+		}
 	}
 	return nil
 }
@@ -356,7 +598,7 @@ func (d *database) Collection(name string) db.Collection {
 		return ccol.(db.Collection)
 	}
 
-	col := d.PartialDatabase.NewCollection(name)
+	col := d.PartialDatabase.NewCollection(d.qualifiedName(name))
 	d.cachedCollections.Write(h, col)
 
 	return col
@@ -364,11 +606,19 @@ func (d *database) Collection(name string) db.Collection {
 
 // StatementPrepare creates a prepared statement.
 func (d *database) StatementPrepare(ctx context.Context, stmt *exql.Statement) (sqlStmt *sql.Stmt, err error) {
+	if txd := d.ambientTx(ctx); txd != nil {
+		return txd.StatementPrepare(ctx, stmt)
+	}
+
 	var query string
 
+	defer func(start time.Time) {
+		d.metrics.observeQuery(stmt, start, err)
+	}(time.Now())
+
 	if d.Settings.LoggingEnabled() {
 		defer func(start time.Time) {
-			d.Logger().Log(&db.QueryStatus{
+			db.LogQuery(ctx, d.Logger(), &db.QueryStatus{
 				TxID:   d.txID,
 				SessID: d.sessID,
 				Query:  query,
@@ -379,6 +629,10 @@ func (d *database) StatementPrepare(ctx context.Context, stmt *exql.Statement) (
 		}(time.Now())
 	}
 
+	if !d.Settings.PreparedStatementsEnabled() {
+		return nil, db.ErrPreparedStatementsDisabled
+	}
+
 	tx := d.Transaction()
 
 	query, _ = d.compileStatement(stmt, nil)
@@ -391,11 +645,39 @@ func (d *database) StatementPrepare(ctx context.Context, stmt *exql.Statement) (
 	return
 }
 
+// classifyErr passes err through the Err method of the adapter and returns
+// the result if the adapter classified it as a *db.Error. Any other error is
+// returned unchanged.
+func (d *database) classifyErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := d.PartialDatabase.Err(err).(*db.Error); ok {
+		return e
+	}
+	return err
+}
+
 // StatementExec compiles and executes a statement that does not return any
 // rows.
 func (d *database) StatementExec(ctx context.Context, stmt *exql.Statement, args ...interface{}) (res sql.Result, err error) {
+	if txd := d.ambientTx(ctx); txd != nil {
+		return txd.StatementExec(ctx, stmt, args...)
+	}
+
 	var query string
 
+	defer func(start time.Time) {
+		d.metrics.observeQuery(stmt, start, err)
+
+		var rowsAffected int64
+		if res != nil {
+			rowsAffected, _ = res.RowsAffected()
+		}
+		d.observeStats(query, start, err, rowsAffected)
+		d.observeSlowQuery(ctx, query, args, start, err)
+	}(time.Now())
+
 	if d.Settings.LoggingEnabled() {
 		defer func(start time.Time) {
 
@@ -419,10 +701,18 @@ func (d *database) StatementExec(ctx context.Context, stmt *exql.Statement, args
 				}
 			}
 
-			d.Logger().Log(&status)
+			db.LogQuery(ctx, d.Logger(), &status)
 		}(time.Now())
 	}
 
+	defer func() {
+		err = d.classifyErr(err)
+		if err == nil {
+			d.invalidateQueryCache(stmt)
+			d.invalidateRecordCache(ctx, stmt)
+		}
+	}()
+
 	if execer, ok := d.PartialDatabase.(hasStatementExec); ok {
 		query, args = d.compileStatement(stmt, args)
 		res, err = execer.StatementExec(ctx, query, args...)
@@ -431,14 +721,11 @@ func (d *database) StatementExec(ctx context.Context, stmt *exql.Statement, args
 
 	tx := d.Transaction()
 
-	if d.Settings.PreparedStatementCacheEnabled() && tx == nil {
-		var p *Stmt
-		if p, query, args, err = d.prepareStatement(ctx, stmt, args); err != nil {
-			return nil, err
-		}
-		defer p.Close()
-
-		res, err = compat.PreparedExecContext(p, ctx, args)
+	if d.preparedStatementCacheEnabled() && tx == nil {
+		query, args, err = d.withPreparedStatement(ctx, stmt, args, func(p *Stmt, args []interface{}) (err error) {
+			res, err = compat.PreparedExecContext(p, ctx, args)
+			return
+		})
 		return
 	}
 
@@ -452,13 +739,45 @@ func (d *database) StatementExec(ctx context.Context, stmt *exql.Statement, args
 	return
 }
 
-// StatementQuery compiles and executes a statement that returns rows.
-func (d *database) StatementQuery(ctx context.Context, stmt *exql.Statement, args ...interface{}) (rows *sql.Rows, err error) {
+// StatementQuery compiles and executes a statement that returns rows. SELECT
+// statements that run outside of transactions are retried according to the
+// retry policy of the session, if any.
+func (d *database) StatementQuery(ctx context.Context, stmt *exql.Statement, args ...interface{}) (*sql.Rows, error) {
+	if txd := d.ambientTx(ctx); txd != nil {
+		return txd.StatementQuery(ctx, stmt, args...)
+	}
+
+	policy := d.RetryPolicy()
+	if policy == nil || stmt.Type != exql.Select || d.Transaction() != nil {
+		return d.statementQuery(ctx, stmt, args...)
+	}
+
+	for attempt := 1; ; attempt++ {
+		rows, err := d.statementQuery(ctx, stmt, args...)
+		if err == nil || attempt >= policy.MaxAttempts || !policy.IsRetryable(err) {
+			return rows, err
+		}
+
+		select {
+		case <-time.After(policy.Backoff(attempt)):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
+
+func (d *database) statementQuery(ctx context.Context, stmt *exql.Statement, args ...interface{}) (rows *sql.Rows, err error) {
 	var query string
 
+	defer func(start time.Time) {
+		d.metrics.observeQuery(stmt, start, err)
+		d.observeStats(query, start, err, 0)
+		d.observeSlowQuery(ctx, query, args, start, err)
+	}(time.Now())
+
 	if d.Settings.LoggingEnabled() {
 		defer func(start time.Time) {
-			d.Logger().Log(&db.QueryStatus{
+			db.LogQuery(ctx, d.Logger(), &db.QueryStatus{
 				TxID:   d.txID,
 				SessID: d.sessID,
 				Query:  query,
@@ -470,16 +789,28 @@ func (d *database) StatementQuery(ctx context.Context, stmt *exql.Statement, arg
 		}(time.Now())
 	}
 
-	tx := d.Transaction()
-
-	if d.Settings.PreparedStatementCacheEnabled() && tx == nil {
-		var p *Stmt
-		if p, query, args, err = d.prepareStatement(ctx, stmt, args); err != nil {
-			return nil, err
+	defer func() {
+		err = d.classifyErr(err)
+		if err == nil && !isReadOnlySQL(stmt) {
+			// Writes that return rows, like UPDATE ... RETURNING.
+			d.invalidateQueryCache(stmt)
+			d.invalidateRecordCache(ctx, stmt)
 		}
-		defer p.Close()
+	}()
+
+	if replica := d.replica(stmt); replica != nil {
+		query, args = d.compileStatement(stmt, args)
+		rows, err = compat.QueryContext(replica, ctx, query, args)
+		return
+	}
 
-		rows, err = compat.PreparedQueryContext(p, ctx, args)
+	tx := d.Transaction()
+
+	if d.preparedStatementCacheEnabled() && tx == nil {
+		query, args, err = d.withPreparedStatement(ctx, stmt, args, func(p *Stmt, args []interface{}) (err error) {
+			rows, err = compat.PreparedQueryContext(p, ctx, args)
+			return
+		})
 		return
 	}
 
@@ -494,14 +825,44 @@ func (d *database) StatementQuery(ctx context.Context, stmt *exql.Statement, arg
 
 }
 
+// readOnlySQL holds the leading keywords of raw statements that only read.
+var readOnlySQL = []string{"SELECT", "SHOW", "EXPLAIN", "DESCRIBE", "VALUES"}
+
+// isReadOnlySQL returns false for raw statements that may write, that is, the
+// ones that don't start with a keyword from readOnlySQL. Other statements are
+// left to invalidateQueryCache and invalidateRecordCache, which ignore the
+// ones that only read.
+func isReadOnlySQL(stmt *exql.Statement) bool {
+	if stmt.Type != exql.SQL {
+		return false
+	}
+	s := strings.TrimLeft(stmt.SQL, " \t\r\n(")
+	for _, keyword := range readOnlySQL {
+		if len(s) >= len(keyword) && strings.EqualFold(s[:len(keyword)], keyword) {
+			return true
+		}
+	}
+	return false
+}
+
 // StatementQueryRow compiles and executes a statement that returns at most one
 // row.
 func (d *database) StatementQueryRow(ctx context.Context, stmt *exql.Statement, args ...interface{}) (row *sql.Row, err error) {
+	if txd := d.ambientTx(ctx); txd != nil {
+		return txd.StatementQueryRow(ctx, stmt, args...)
+	}
+
 	var query string
 
+	defer func(start time.Time) {
+		d.metrics.observeQuery(stmt, start, err)
+		d.observeStats(query, start, err, 0)
+		d.observeSlowQuery(ctx, query, args, start, err)
+	}(time.Now())
+
 	if d.Settings.LoggingEnabled() {
 		defer func(start time.Time) {
-			d.Logger().Log(&db.QueryStatus{
+			db.LogQuery(ctx, d.Logger(), &db.QueryStatus{
 				TxID:   d.txID,
 				SessID: d.sessID,
 				Query:  query,
@@ -513,16 +874,26 @@ func (d *database) StatementQueryRow(ctx context.Context, stmt *exql.Statement,
 		}(time.Now())
 	}
 
-	tx := d.Transaction()
-
-	if d.Settings.PreparedStatementCacheEnabled() && tx == nil {
-		var p *Stmt
-		if p, query, args, err = d.prepareStatement(ctx, stmt, args); err != nil {
-			return nil, err
+	defer func() {
+		if err == nil && !isReadOnlySQL(stmt) {
+			d.invalidateQueryCache(stmt)
+			d.invalidateRecordCache(ctx, stmt)
 		}
-		defer p.Close()
+	}()
 
-		row = compat.PreparedQueryRowContext(p, ctx, args)
+	if replica := d.replica(stmt); replica != nil {
+		query, args = d.compileStatement(stmt, args)
+		row = compat.QueryRowContext(replica, ctx, query, args)
+		return
+	}
+
+	tx := d.Transaction()
+
+	if d.preparedStatementCacheEnabled() && tx == nil {
+		query, args, err = d.withPreparedStatement(ctx, stmt, args, func(p *Stmt, args []interface{}) error {
+			row = compat.PreparedQueryRowContext(p, ctx, args)
+			return nil
+		})
 		return
 	}
 
@@ -550,6 +921,45 @@ func (d *database) compileStatement(stmt *exql.Statement, args []interface{}) (s
 	return d.PartialDatabase.CompileStatement(stmt, args)
 }
 
+// preparedStatementCacheEnabled returns true if statements must be prepared
+// and kept in the cache.
+func (d *database) preparedStatementCacheEnabled() bool {
+	return d.Settings.PreparedStatementsEnabled() && d.Settings.PreparedStatementCacheEnabled()
+}
+
+// withPreparedStatement runs fn with the cached prepared statement of stmt.
+// If the adapter reports that the statement went stale, like after a schema
+// change on PostgreSQL ("cached plan must not change result type"), it's
+// removed from the cache and fn is run once more with a new one.
+func (d *database) withPreparedStatement(ctx context.Context, stmt *exql.Statement, args []interface{}, fn func(*Stmt, []interface{}) error) (string, []interface{}, error) {
+	for attempt := 1; ; attempt++ {
+		p, query, compiledArgs, err := d.prepareStatement(ctx, stmt, args)
+		if err != nil {
+			return query, compiledArgs, err
+		}
+
+		err = fn(p, compiledArgs)
+		p.Close()
+
+		if attempt > 1 || !d.isStaleStatement(err) {
+			return query, compiledArgs, err
+		}
+		d.cachedStatements.Delete(stmt)
+	}
+}
+
+// isStaleStatement returns true if err means that a cached prepared statement
+// must be prepared again.
+func (d *database) isStaleStatement(err error) bool {
+	if err == nil {
+		return false
+	}
+	if checker, ok := d.PartialDatabase.(hasStaleStatement); ok {
+		return checker.IsStaleStatement(err)
+	}
+	return false
+}
+
 // prepareStatement compiles a query and tries to use previously generated
 // statement.
 func (d *database) prepareStatement(ctx context.Context, stmt *exql.Statement, args []interface{}) (*Stmt, string, []interface{}, error) {
@@ -653,13 +1063,63 @@ func ReplaceWithDollarSign(in string) string {
 	return string(out)
 }
 
+// DecimalsToRats returns args with its db.Decimal values, and pointers to
+// them, replaced by *big.Rat values, for drivers that read exact numbers from
+// them, like ql and Spanner. args is not modified.
+func DecimalsToRats(args []interface{}) []interface{} {
+	var out []interface{}
+	for i := range args {
+		var rat *big.Rat
+		switch v := args[i].(type) {
+		case db.Decimal:
+			rat = v.Rat()
+		case *db.Decimal:
+			if v == nil {
+				continue
+			}
+			rat = v.Rat()
+		default:
+			continue
+		}
+		if out == nil {
+			out = make([]interface{}, len(args))
+			copy(out, args)
+		}
+		out[i] = rat
+	}
+	if out == nil {
+		return args
+	}
+	return out
+}
+
+// copySettings copies the settings of a session into its clone, except for the
+// health check policy: the health checker of the parent covers the clone.
 func copySettings(from BaseDatabase, into BaseDatabase) {
 	into.SetLogging(from.LoggingEnabled())
 	into.SetLogger(from.Logger())
+	into.SetPreparedStatements(from.PreparedStatementsEnabled())
 	into.SetPreparedStatementCache(from.PreparedStatementCacheEnabled())
+	into.SetPreparedStatementCacheSize(from.PreparedStatementCacheSize())
+	into.SetAutoTimestamps(from.AutoTimestampsEnabled())
+	into.SetStrictIdentifiers(from.StrictIdentifiersEnabled())
+	into.SetQueryStats(from.QueryStatsEnabled())
 	into.SetConnMaxLifetime(from.ConnMaxLifetime())
 	into.SetMaxIdleConns(from.MaxIdleConns())
 	into.SetMaxOpenConns(from.MaxOpenConns())
+	into.SetTxRetryPolicy(from.TxRetryPolicy())
+	into.SetSlowQueryThreshold(from.SlowQueryThreshold())
+	into.SetNamingStrategy(from.NamingStrategy())
+	into.SetReplicaPolicy(from.ReplicaPolicy())
+	into.SetRetryPolicy(from.RetryPolicy())
+	into.SetQueryCache(from.QueryCache())
+	for name, codec := range from.Codecs() {
+		into.SetCodec(name, codec)
+	}
+	into.SetTablePrefix(from.TablePrefix())
+	for collection, prefix := range from.CollectionTablePrefixes() {
+		into.SetCollectionTablePrefix(collection, prefix)
+	}
 }
 
 func newSessionID() uint64 {
@@ -0,0 +1,24 @@
+package sqladapter
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+)
+
+func TestDecimalsToRats(t *testing.T) {
+	price := db.NewDecimal(1999, 2)
+	var missing *db.Decimal
+
+	args := []interface{}{"a", price, &price, missing, 1}
+	out := DecimalsToRats(args)
+
+	assert.Equal(t, []interface{}{"a", big.NewRat(1999, 100), big.NewRat(1999, 100), missing, 1}, out)
+	assert.Equal(t, price, args[1], "args must not be modified")
+
+	args = []interface{}{"a", 1}
+	assert.Equal(t, args, DecimalsToRats(args))
+	assert.Nil(t, DecimalsToRats(nil))
+}
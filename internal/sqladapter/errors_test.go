@@ -0,0 +1,52 @@
+//go:build go1.10
+// +build go1.10
+
+package sqladapter
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+// classifyingPartialDatabase reports errServerDown as a unique violation.
+type classifyingPartialDatabase struct {
+	rawPartialDatabase
+}
+
+func (classifyingPartialDatabase) Err(err error) error {
+	if err == errServerDown {
+		return &db.Error{Kind: db.ErrUniqueViolation, Constraint: "artist_name_key", Err: err}
+	}
+	return err
+}
+
+func TestStatementErrorClassification(t *testing.T) {
+	d := &database{
+		PartialDatabase: classifyingPartialDatabase{},
+		Settings:        db.NewSettings(),
+		sess:            sql.OpenDB(&switchConnector{}),
+		metrics:         newMetrics(),
+	}
+	defer d.sess.Close()
+
+	ctx := context.Background()
+
+	_, err := d.StatementExec(ctx, &exql.Statement{Type: exql.Insert, SQL: "INSERT INTO artist VALUES (1)"})
+	if assert.IsType(t, &db.Error{}, err) {
+		assert.Equal(t, db.ErrUniqueViolation, db.ErrorKind(err))
+		assert.Equal(t, "artist_name_key", err.(*db.Error).Constraint)
+		assert.Equal(t, errServerDown, err.(*db.Error).Err)
+	}
+
+	_, err = d.StatementQuery(ctx, &exql.Statement{Type: exql.Select, SQL: "SELECT 1"})
+	assert.Equal(t, db.ErrUniqueViolation, db.ErrorKind(err))
+
+	d.PartialDatabase = rawPartialDatabase{}
+	_, err = d.StatementExec(ctx, &exql.Statement{Type: exql.Insert, SQL: "INSERT INTO artist VALUES (1)"})
+	assert.Equal(t, errServerDown, err)
+}
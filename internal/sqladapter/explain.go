@@ -0,0 +1,65 @@
+package sqladapter
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/compat"
+	"upper.io/db.v3/internal/sqladapter/exql"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// hasExplain is implemented by adapters that can return query plans.
+type hasExplain interface {
+	// Explain wraps a compiled query into a statement that returns its plan,
+	// or returns db.ErrUnsupported.
+	Explain(query string, analyze bool) (string, error)
+
+	// ScanQueryPlan reads the rows returned by the statement Explain
+	// created.
+	ScanQueryPlan(rows *sql.Rows) (*sqlbuilder.QueryPlan, error)
+}
+
+// StatementExplain returns the plan the database uses to run the given
+// statement. If analyze is set the statement is run and the plan includes
+// actual timings.
+func (d *database) StatementExplain(ctx context.Context, stmt *exql.Statement, analyze bool, args ...interface{}) (plan *sqlbuilder.QueryPlan, err error) {
+	explainer, ok := d.PartialDatabase.(hasExplain)
+	if !ok {
+		return nil, db.ErrUnsupported
+	}
+
+	query, args := d.compileStatement(stmt, args)
+	if query, err = explainer.Explain(query, analyze); err != nil {
+		return nil, err
+	}
+
+	if d.Settings.LoggingEnabled() {
+		defer func(start time.Time) {
+			db.LogQuery(ctx, d.Logger(), &db.QueryStatus{
+				TxID:   d.txID,
+				SessID: d.sessID,
+				Query:  query,
+				Args:   args,
+				Err:    err,
+				Start:  start,
+				End:    time.Now(),
+			})
+		}(time.Now())
+	}
+
+	var rows *sql.Rows
+	if tx := d.Transaction(); tx != nil {
+		rows, err = compat.QueryContext(tx.(*baseTx), ctx, query, args)
+	} else {
+		rows, err = compat.QueryContext(d.sess, ctx, query, args)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return explainer.ScanQueryPlan(rows)
+}
@@ -0,0 +1,74 @@
+package exql
+
+import (
+	"errors"
+	"strings"
+)
+
+var errEmptyCase = errors.New("CASE expressions require at least one WHEN branch")
+
+// CaseWhen represents a "WHEN ... THEN ..." branch of a CASE expression.
+type CaseWhen struct {
+	Cond  Fragment
+	Value Fragment
+}
+
+// Case represents a CASE expression, like "CASE WHEN ... THEN ... ELSE ...
+// END".
+type Case struct {
+	Whens []*CaseWhen
+	Else  Fragment
+	Alias string
+	hash  hash
+}
+
+var _ = Fragment(&Case{})
+
+// Hash returns a unique identifier for the struct.
+func (c *Case) Hash() string {
+	return c.hash.Hash(c)
+}
+
+// Compile transforms the Case into an equivalent SQL representation.
+func (c *Case) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(c); ok {
+		return z, nil
+	}
+
+	if len(c.Whens) == 0 {
+		return "", errEmptyCase
+	}
+
+	chunks := []string{"CASE"}
+	for i := range c.Whens {
+		cond, err := c.Whens[i].Cond.Compile(layout)
+		if err != nil {
+			return "", err
+		}
+		value, err := c.Whens[i].Value.Compile(layout)
+		if err != nil {
+			return "", err
+		}
+		chunks = append(chunks, "WHEN", cond, "THEN", value)
+	}
+
+	if c.Else != nil {
+		value, err := c.Else.Compile(layout)
+		if err != nil {
+			return "", err
+		}
+		chunks = append(chunks, "ELSE", value)
+	}
+
+	chunks = append(chunks, "END")
+	compiled = strings.Join(chunks, " ")
+
+	if c.Alias != "" {
+		alias := mustParse(layout.IdentifierQuote, Raw{Value: c.Alias})
+		compiled = mustParse(layout.ColumnAliasLayout, columnT{compiled, alias})
+	}
+
+	layout.Write(c, compiled)
+
+	return
+}
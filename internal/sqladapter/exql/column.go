@@ -43,11 +43,27 @@ func (c *Column) Compile(layout *Template) (compiled string, err error) {
 
 		chunks := separateByAS(input)
 
-		if len(chunks) == 1 {
+		// Function calls, like "JSON_EXTRACT(data, '$.status')", are kept
+		// verbatim.
+		if isFunctionCall(chunks[0]) {
+			compiled = chunks[0]
+			if len(chunks) > 1 {
+				alias = mustParse(layout.IdentifierQuote, Raw{Value: trimString(chunks[1])})
+			}
+			break
+		}
+
+		if len(chunks) == 1 && jsonPathIndex(input) < 0 {
 			chunks = separateBySpace(input)
 		}
 
-		name := chunks[0]
+		name, path := chunks[0], ""
+
+		// JSON path expressions, like "data->>'status'", are appended verbatim
+		// to the quoted column name.
+		if i := jsonPathIndex(name); i > 0 {
+			name, path = trimString(name[:i]), name[i:]
+		}
 
 		nameChunks := strings.SplitN(name, layout.ColumnSeparator, 2)
 
@@ -59,7 +75,7 @@ func (c *Column) Compile(layout *Template) (compiled string, err error) {
 			nameChunks[i] = mustParse(layout.IdentifierQuote, Raw{Value: nameChunks[i]})
 		}
 
-		compiled = strings.Join(nameChunks, layout.ColumnSeparator)
+		compiled = strings.Join(nameChunks, layout.ColumnSeparator) + path
 
 		if len(chunks) > 1 {
 			alias = trimString(chunks[1])
@@ -74,6 +74,20 @@ func TestColumnRaw(t *testing.T) {
 	}
 }
 
+func TestColumnFunctionCall(t *testing.T) {
+	column := Column{Name: "JSON_EXTRACT(data, '$.status') AS status"}
+
+	s, err := column.Compile(defaultTemplate)
+	if err != nil {
+		t.Fatal()
+	}
+
+	e := `JSON_EXTRACT(data, '$.status') AS "status"`
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+}
+
 func BenchmarkColumnWithName(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_ = ColumnWithName("a")
@@ -5,6 +5,9 @@ import (
 )
 
 // ColumnValue represents a bundle between a column and a corresponding value.
+// Templates that define an ILikeLayout use it to compile ILIKE and NOT ILIKE
+// operators, with the operator replaced by LIKE or NOT LIKE, and templates
+// that define a MatchLayout use it to compile full-text MATCH operators.
 type ColumnValue struct {
 	Column   Fragment
 	Operator string
@@ -48,7 +51,21 @@ func (c *ColumnValue) Compile(layout *Template) (compiled string, err error) {
 		}
 	}
 
-	compiled = mustParse(layout.ColumnValue, data)
+	switch strings.ToUpper(data.Operator) {
+	case "ILIKE", "NOT ILIKE":
+		if layout.ILikeLayout != "" {
+			data.Operator = strings.TrimSuffix(strings.ToUpper(data.Operator), "ILIKE") + "LIKE"
+			compiled = mustParse(layout.ILikeLayout, data)
+		}
+	case "MATCH":
+		if layout.MatchLayout != "" {
+			compiled = mustParse(layout.MatchLayout, data)
+		}
+	}
+
+	if compiled == "" {
+		compiled = mustParse(layout.ColumnValue, data)
+	}
 
 	layout.Write(c, compiled)
 
@@ -0,0 +1,50 @@
+package exql
+
+import (
+	"errors"
+	"strings"
+)
+
+var errCursorUnsupported = errors.New("Server-side cursors are not supported by this template")
+
+type cursorT struct {
+	Name      string
+	Query     string
+	FetchSize int
+}
+
+// Cursor represents a server-side cursor named Name that iterates over the
+// results of Query, FetchSize rows at a time.
+type Cursor struct {
+	Name      string
+	Query     string
+	FetchSize int
+}
+
+func (c *Cursor) data() cursorT {
+	return cursorT{Name: c.Name, Query: c.Query, FetchSize: c.FetchSize}
+}
+
+// Declare returns the statement that declares the cursor.
+func (c *Cursor) Declare(layout *Template) (string, error) {
+	if layout.DeclareCursorLayout == "" {
+		return "", errCursorUnsupported
+	}
+	return strings.TrimSpace(mustParse(layout.DeclareCursorLayout, c.data())), nil
+}
+
+// Fetch returns the statement that retrieves the next rows from the cursor.
+func (c *Cursor) Fetch(layout *Template) (string, error) {
+	if layout.FetchCursorLayout == "" {
+		return "", errCursorUnsupported
+	}
+	return strings.TrimSpace(mustParse(layout.FetchCursorLayout, c.data())), nil
+}
+
+// Close returns the statement that closes the cursor.
+func (c *Cursor) Close(layout *Template) (string, error) {
+	if layout.CloseCursorLayout == "" {
+		return "", errCursorUnsupported
+	}
+	return strings.TrimSpace(mustParse(layout.CloseCursorLayout, c.data())), nil
+}
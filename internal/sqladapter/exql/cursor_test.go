@@ -0,0 +1,40 @@
+package exql
+
+import (
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	c := &Cursor{Name: "c1", Query: `SELECT * FROM "artist"`, FetchSize: 100}
+
+	s, err := c.Declare(defaultTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := `DECLARE c1 NO SCROLL CURSOR FOR SELECT * FROM "artist"`
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+
+	s, err = c.Fetch(defaultTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = `FETCH FORWARD 100 FROM c1`
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+
+	s, err = c.Close(defaultTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = `CLOSE c1`
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+
+	if _, err = c.Declare(&Template{}); err == nil {
+		t.Fatal("Expecting an error")
+	}
+}
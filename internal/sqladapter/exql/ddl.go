@@ -0,0 +1,138 @@
+package exql
+
+import (
+	"errors"
+	"strings"
+)
+
+var errDDLUnsupported = errors.New("This kind of DDL statement is not supported by this template")
+
+type columnDefinitionT struct {
+	Name          string
+	Type          string
+	NotNull       bool
+	Unique        bool
+	PrimaryKey    bool
+	AutoIncrement bool
+	Default       string
+}
+
+type columnTypeT struct {
+	Name          string
+	Size          int
+	Scale         int
+	Values        string
+	AutoIncrement bool
+}
+
+// ColumnDefinition represents the definition of a column in CREATE TABLE and
+// ALTER TABLE ... ADD COLUMN statements. Type is looked up in the ColumnTypes
+// of the template and used as it is if it's not there.
+type ColumnDefinition struct {
+	Name          string
+	Type          string
+	Size          int
+	Scale         int
+	Values        []string
+	NotNull       bool
+	Unique        bool
+	PrimaryKey    bool
+	AutoIncrement bool
+	Default       Fragment
+	hash          hash
+}
+
+var _ = Fragment(&ColumnDefinition{})
+
+// Hash returns a unique identifier for the struct.
+func (c *ColumnDefinition) Hash() string {
+	return c.hash.Hash(c)
+}
+
+// Compile transforms the ColumnDefinition into an equivalent SQL
+// representation.
+func (c *ColumnDefinition) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(c); ok {
+		return z, nil
+	}
+
+	if layout.ColumnDefinitionLayout == "" {
+		return "", errDDLUnsupported
+	}
+
+	data := columnDefinitionT{
+		Name:          layout.QuoteIdentifier(c.Name),
+		Type:          layout.columnType(c),
+		NotNull:       c.NotNull,
+		Unique:        c.Unique,
+		PrimaryKey:    c.PrimaryKey,
+		AutoIncrement: c.AutoIncrement,
+	}
+
+	if c.Default != nil {
+		if data.Default, err = c.Default.Compile(layout); err != nil {
+			return "", err
+		}
+	}
+
+	compiled = strings.TrimSpace(mustParse(layout.ColumnDefinitionLayout, data))
+
+	layout.Write(c, compiled)
+
+	return
+}
+
+func (layout *Template) columnType(c *ColumnDefinition) string {
+	typeLayout, ok := layout.ColumnTypes[c.Type]
+	if !ok {
+		return c.Type
+	}
+	values := make([]string, len(c.Values))
+	for i := range c.Values {
+		values[i] = "'" + strings.Replace(c.Values[i], "'", "''", -1) + "'"
+	}
+	return mustParse(typeLayout, columnTypeT{
+		Name:          layout.QuoteIdentifier(c.Name),
+		Size:          c.Size,
+		Scale:         c.Scale,
+		Values:        strings.Join(values, ", "),
+		AutoIncrement: c.AutoIncrement,
+	})
+}
+
+// ColumnDefinitions represents the list of column definitions of a CREATE
+// TABLE statement.
+type ColumnDefinitions struct {
+	Columns []*ColumnDefinition
+	hash    hash
+}
+
+var _ = Fragment(&ColumnDefinitions{})
+
+// Hash returns a unique identifier for the struct.
+func (cs *ColumnDefinitions) Hash() string {
+	return cs.hash.Hash(cs)
+}
+
+// Compile transforms the ColumnDefinitions into an equivalent SQL
+// representation.
+func (cs *ColumnDefinitions) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(cs); ok {
+		return z, nil
+	}
+
+	chunks := make([]string, 0, len(cs.Columns))
+	for i := range cs.Columns {
+		chunk, err := cs.Columns[i].Compile(layout)
+		if err != nil {
+			return "", err
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	compiled = strings.Join(chunks, layout.IdentifierSeparator)
+
+	layout.Write(cs, compiled)
+
+	return
+}
@@ -0,0 +1,81 @@
+package exql
+
+import (
+	"testing"
+)
+
+func TestCreateTable(t *testing.T) {
+	stmt := Statement{
+		Type:  CreateTable,
+		Table: IdentifierWithParts("books"),
+		Definitions: &ColumnDefinitions{
+			Columns: []*ColumnDefinition{
+				{Name: "id", Type: "integer", NotNull: true},
+				{Name: "title", Type: "string", Size: 60, Default: RawValue(`'untitled'`)},
+				{Name: "location", Type: "GEOGRAPHY"},
+			},
+		},
+		PrimaryKey:  JoinColumns(IdentifierWithParts("id")),
+		IfNotExists: true,
+	}
+
+	s := mustTrim(stmt.Compile(defaultTemplate))
+	e := `CREATE TABLE IF NOT EXISTS "books" ("id" INTEGER NOT NULL, "title" VARCHAR(60) DEFAULT 'untitled', "location" GEOGRAPHY, PRIMARY KEY ("id"))`
+
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+}
+
+func TestCreateIndex(t *testing.T) {
+	stmt := Statement{
+		Type:    CreateIndex,
+		Index:   IdentifierWithParts("books_title_idx"),
+		Table:   IdentifierWithParts("books"),
+		Columns: JoinColumns(IdentifierWithParts("title")),
+		Unique:  true,
+	}
+
+	s := mustTrim(stmt.Compile(defaultTemplate))
+	e := `CREATE UNIQUE INDEX "books_title_idx" ON "books" ("title")`
+
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+}
+
+func TestAlterTable(t *testing.T) {
+	stmt := Statement{
+		Type:   AddColumn,
+		Table:  IdentifierWithParts("books"),
+		Column: &ColumnDefinition{Name: "isbn", Type: "string", Size: 13, Unique: true},
+	}
+
+	s := mustTrim(stmt.Compile(defaultTemplate))
+	e := `ALTER TABLE "books" ADD COLUMN "isbn" VARCHAR(13) UNIQUE`
+
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+
+	stmt = Statement{
+		Type:    RenameColumn,
+		Table:   IdentifierWithParts("books"),
+		Column:  IdentifierWithParts("title"),
+		NewName: "name",
+	}
+
+	s = mustTrim(stmt.Compile(defaultTemplate))
+	e = `ALTER TABLE "books" RENAME COLUMN "title" TO "name"`
+
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+
+	layout := DefaultTemplate()
+	layout.RenameColumnLayout = ""
+
+	if _, err := stmt.Compile(layout); err != errDDLUnsupported {
+		t.Fatalf("Expecting errDDLUnsupported, got: %v", err)
+	}
+}
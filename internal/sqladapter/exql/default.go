@@ -66,7 +66,9 @@ const (
 
 	defaultSelectLayout = `
     SELECT
-      {{if .Distinct}}
+      {{if .DistinctOn}}
+        {{.DistinctOn}}
+      {{else if .Distinct}}
         DISTINCT
       {{end}}
 
@@ -95,6 +97,10 @@ const (
       {{if .Offset}}
         OFFSET {{.Offset}}
       {{end}}
+
+      {{if .Lock}}
+        {{.Lock}}
+      {{end}}
   `
 	defaultDeleteLayout = `
     DELETE
@@ -106,12 +112,37 @@ const (
     {{if .Offset}}
       OFFSET {{.Offset}}
     {{end}}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
+  `
+	defaultDeleteUsingLayout = `
+    DELETE
+      FROM {{.Table}}
+    USING {{.Sources}}
+      {{.Where}}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
   `
 	defaultUpdateLayout = `
     UPDATE
       {{.Table}}
     SET {{.ColumnValues}}
       {{ .Where }}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
+  `
+	defaultUpdateFromLayout = `
+    UPDATE
+      {{.Table}}
+    SET {{.ColumnValues}}
+    FROM {{.Sources}}
+      {{ .Where }}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
   `
 
 	defaultCountLayout = `
@@ -134,6 +165,7 @@ const (
       {{if .Columns }}({{.Columns}}){{end}}
     VALUES
       {{.Values}}
+    {{.OnConflict}}
     {{if .Returning}}
       RETURNING {{.Returning}}
     {{end}}
@@ -151,8 +183,31 @@ const (
     DROP TABLE {{.Table}}
   `
 
+	defaultWithLayout          = `WITH {{if .Recursive}}RECURSIVE {{end}}{{.Clauses}}`
+	defaultOnConflictLayout    = `ON CONFLICT {{if .Columns}}({{.Columns}}) {{end}}{{if .ColumnValues}}DO UPDATE SET {{.ColumnValues}} {{.Where}}{{else}}DO NOTHING{{end}}`
+	defaultExcludedLayout      = `EXCLUDED.{{.}}`
+	defaultDistinctOnLayout    = `DISTINCT ON ({{.}})`
+	defaultLockLayout          = `FOR {{if .Share}}SHARE{{else}}UPDATE{{end}}{{if .SkipLocked}} SKIP LOCKED{{else if .NoWait}} NOWAIT{{end}}`
+	defaultDeclareCursorLayout = `DECLARE {{.Name}} NO SCROLL CURSOR FOR {{.Query}}`
+	defaultFetchCursorLayout   = `FETCH FORWARD {{.FetchSize}} FROM {{.Name}}`
+	defaultCloseCursorLayout   = `CLOSE {{.Name}}`
+	defaultRollupLayout        = `ROLLUP({{.}})`
+	defaultCubeLayout          = `CUBE({{.}})`
+	defaultGroupingSetsLayout  = `GROUPING SETS ({{.}})`
+	defaultTupleInLayout       = `({{.Columns}}) {{if .Negate}}NOT {{end}}IN ({{.Values}})`
+	defaultValuesListLayout    = `(VALUES {{range $i, $r := .Rows}}{{if $i}}, {{end}}({{$r}}){{end}}){{if .Name}} AS {{.Name}}{{if .Columns}} ({{range $i, $c := .Columns}}{{if $i}}, {{end}}{{$c.Name}}{{end}}){{end}}{{end}}`
+
 	defaultGroupByColumnLayout = `{{.Column}}`
 
+	defaultCreateTableLayout      = `CREATE TABLE {{if .IfNotExists}}IF NOT EXISTS {{end}}{{.Table}} ({{.Definitions}}{{if .PrimaryKey}}, PRIMARY KEY ({{.PrimaryKey}}){{end}})`
+	defaultCreateIndexLayout      = `CREATE {{if .Unique}}UNIQUE {{end}}INDEX {{.Index}} ON {{.Table}} ({{.Columns}})`
+	defaultAddColumnLayout        = `ALTER TABLE {{.Table}} ADD COLUMN {{.Column}}`
+	defaultDropColumnLayout       = `ALTER TABLE {{.Table}} DROP COLUMN {{.Column}}`
+	defaultRenameColumnLayout     = `ALTER TABLE {{.Table}} RENAME COLUMN {{.Column}} TO {{.NewColumn}}`
+	defaultColumnDefinitionLayout = `{{.Name}} {{.Type}}{{if .NotNull}} NOT NULL{{end}}{{if .Default}} DEFAULT {{.Default}}{{end}}{{if .PrimaryKey}} PRIMARY KEY{{end}}{{if .Unique}} UNIQUE{{end}}`
+	defaultTrueKeyword            = `TRUE`
+	defaultFalseKeyword           = `FALSE`
+
 	defaultGroupByLayout = `
     {{if .GroupColumns}}
       GROUP BY {{.GroupColumns}}
@@ -166,33 +221,89 @@ var defaultTemplate = &Template{
 	AssignmentOperator:  defaultAssignmentOperator,
 	ClauseGroup:         defaultClauseGroup,
 	ClauseOperator:      defaultClauseOperator,
+	CloseCursorLayout:   defaultCloseCursorLayout,
 	ColumnAliasLayout:   defaultColumnAliasLayout,
 	ColumnSeparator:     defaultColumnSeparator,
 	ColumnValue:         defaultColumnValue,
 	CountLayout:         defaultCountLayout,
+	CubeLayout:          defaultCubeLayout,
+	DeclareCursorLayout: defaultDeclareCursorLayout,
 	DefaultOperator:     defaultDefaultOperator,
 	DeleteLayout:        defaultDeleteLayout,
+	DeleteUsingLayout:   defaultDeleteUsingLayout,
 	DescKeyword:         defaultDescKeyword,
+	DistinctOnLayout:    defaultDistinctOnLayout,
 	DropDatabaseLayout:  defaultDropDatabaseLayout,
 	DropTableLayout:     defaultDropTableLayout,
+	FetchCursorLayout:   defaultFetchCursorLayout,
 	GroupByLayout:       defaultGroupByLayout,
+	GroupingSetsLayout:  defaultGroupingSetsLayout,
 	IdentifierQuote:     defaultIdentifierQuote,
 	IdentifierSeparator: defaultIdentifierSeparator,
 	InsertLayout:        defaultInsertLayout,
 	JoinLayout:          defaultJoinLayout,
+	LockLayout:          defaultLockLayout,
 	NotKeyword:          defaultNotKeyword,
 	OnLayout:            defaultOnLayout,
 	OrKeyword:           defaultOrKeyword,
 	OrderByLayout:       defaultOrderByLayout,
+	RollupLayout:        defaultRollupLayout,
 	SelectLayout:        defaultSelectLayout,
 	SortByColumnLayout:  defaultSortByColumnLayout,
 	TableAliasLayout:    defaultTableAliasLayout,
 	TruncateLayout:      defaultTruncateLayout,
+	TupleInLayout:       defaultTupleInLayout,
+	UpdateFromLayout:    defaultUpdateFromLayout,
 	UpdateLayout:        defaultUpdateLayout,
 	UsingLayout:         defaultUsingLayout,
+	ValuesListLayout:    defaultValuesListLayout,
 	ValueQuote:          defaultValueQuote,
 	ValueSeparator:      defaultValueSeparator,
 	WhereLayout:         defaultWhereLayout,
+	WithLayout:          defaultWithLayout,
+	OnConflictLayout:    defaultOnConflictLayout,
+	ExcludedLayout:      defaultExcludedLayout,
+
+	CreateTableLayout:      defaultCreateTableLayout,
+	CreateIndexLayout:      defaultCreateIndexLayout,
+	AddColumnLayout:        defaultAddColumnLayout,
+	DropColumnLayout:       defaultDropColumnLayout,
+	RenameColumnLayout:     defaultRenameColumnLayout,
+	ColumnDefinitionLayout: defaultColumnDefinitionLayout,
+	ColumnTypes:            defaultColumnTypes,
+	TrueKeyword:            defaultTrueKeyword,
+	FalseKeyword:           defaultFalseKeyword,
 
 	Cache: cache.NewCache(),
 }
+
+var defaultColumnTypes = map[string]string{
+	"boolean":   `BOOLEAN`,
+	"smallint":  `SMALLINT`,
+	"integer":   `INTEGER`,
+	"bigint":    `BIGINT`,
+	"float":     `DOUBLE PRECISION`,
+	"decimal":   `DECIMAL{{if .Size}}({{.Size}}, {{.Scale}}){{end}}`,
+	"string":    `VARCHAR({{.Size}})`,
+	"text":      `TEXT`,
+	"bytes":     `BLOB`,
+	"date":      `DATE`,
+	"timestamp": `TIMESTAMP`,
+	"interval":  `VARCHAR(64)`,
+	"json":      `TEXT`,
+	"uuid":      `CHAR(36)`,
+	"enum":      `VARCHAR({{.Size}}) CHECK ({{.Name}} IN ({{.Values}}))`,
+}
+
+// DefaultTemplate returns a copy of the template the builder falls back to,
+// with a cache of its own, so adapters can start from it and only override
+// the layouts their database needs.
+func DefaultTemplate() *Template {
+	t := *defaultTemplate
+	t.Cache = cache.NewCache()
+	t.ColumnTypes = make(map[string]string, len(defaultColumnTypes))
+	for k, v := range defaultColumnTypes {
+		t.ColumnTypes[k] = v
+	}
+	return &t
+}
@@ -0,0 +1,72 @@
+package exql
+
+import (
+	"errors"
+	"strings"
+)
+
+var errGroupingUnsupported = errors.New("This grouping expression is not supported by this template")
+
+// GroupingType represents the kind of a grouping expression.
+type GroupingType uint8
+
+// Values for GroupingType.
+const (
+	Rollup = GroupingType(iota)
+	Cube
+	GroupingSets
+)
+
+// Grouping represents a grouping expression on a GROUP BY clause, like
+// "ROLLUP(...)", "CUBE(...)" or "GROUPING SETS (...)".
+type Grouping struct {
+	Type GroupingType
+	Sets []*Columns
+	hash hash
+}
+
+var _ = Fragment(&Grouping{})
+
+// Hash returns a unique identifier for the struct.
+func (g *Grouping) Hash() string {
+	return g.hash.Hash(g)
+}
+
+// Compile transforms the Grouping into an equivalent SQL representation.
+func (g *Grouping) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(g); ok {
+		return z, nil
+	}
+
+	var groupingLayout string
+	switch g.Type {
+	case Rollup:
+		groupingLayout = layout.RollupLayout
+	case Cube:
+		groupingLayout = layout.CubeLayout
+	case GroupingSets:
+		groupingLayout = layout.GroupingSetsLayout
+	}
+
+	if groupingLayout == "" {
+		return "", errGroupingUnsupported
+	}
+
+	elements := make([]string, len(g.Sets))
+	for i := range g.Sets {
+		columns, err := g.Sets[i].Compile(layout)
+		if err != nil {
+			return "", err
+		}
+		if g.Type == GroupingSets || len(g.Sets[i].Columns) != 1 {
+			columns = "(" + columns + ")"
+		}
+		elements[i] = columns
+	}
+
+	compiled = mustParse(groupingLayout, strings.Join(elements, layout.IdentifierSeparator))
+
+	layout.Write(g, compiled)
+
+	return
+}
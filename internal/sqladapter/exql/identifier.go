@@ -0,0 +1,57 @@
+package exql
+
+import (
+	"strings"
+)
+
+// Identifier represents the name of a table or a column, like "schema.table",
+// made of parts that are quoted as they are, without being parsed.
+type Identifier struct {
+	Parts []string
+	hash  hash
+}
+
+var _ = Fragment(&Identifier{})
+
+// IdentifierWithParts creates and returns an Identifier with the given parts.
+func IdentifierWithParts(parts ...string) *Identifier {
+	return &Identifier{Parts: parts}
+}
+
+// Hash returns a unique identifier for the struct.
+func (i *Identifier) Hash() string {
+	return i.hash.Hash(i)
+}
+
+// Compile transforms the Identifier into an equivalent SQL representation.
+func (i *Identifier) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(i); ok {
+		return z, nil
+	}
+
+	compiled = layout.QuoteIdentifier(i.Parts...)
+
+	layout.Write(i, compiled)
+
+	return
+}
+
+// QuoteIdentifier quotes every part of an identifier with IdentifierQuote
+// and joins them with ColumnSeparator. Quotes within parts are escaped by
+// doubling them, so any value can be quoted safely.
+func (layout *Template) QuoteIdentifier(parts ...string) string {
+	quote := mustParse(layout.IdentifierQuote, Raw{Value: ""})
+
+	quoted := make([]string, len(parts))
+	for i := range parts {
+		part := parts[i]
+		if len(quote) == 2 {
+			// Closing quotes, like " or ], are escaped by doubling them.
+			end := quote[1:]
+			part = strings.Replace(part, end, end+end, -1)
+		}
+		quoted[i] = mustParse(layout.IdentifierQuote, Raw{Value: part})
+	}
+
+	return strings.Join(quoted, layout.ColumnSeparator)
+}
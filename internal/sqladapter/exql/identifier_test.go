@@ -0,0 +1,29 @@
+package exql
+
+import (
+	"testing"
+)
+
+func TestIdentifier(t *testing.T) {
+	for _, c := range []struct {
+		parts    []string
+		expected string
+	}{
+		{[]string{"public", "artist"}, `"public"."artist"`},
+		{[]string{"first name"}, `"first name"`},
+		{[]string{`name"; DROP TABLE artist; --`}, `"name""; DROP TABLE artist; --"`},
+	} {
+		s, err := IdentifierWithParts(c.parts...).Compile(defaultTemplate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != c.expected {
+			t.Fatalf("Got: %s, Expecting: %s", s, c.expected)
+		}
+	}
+
+	brackets := &Template{IdentifierQuote: "[{{.Value}}]", ColumnSeparator: "."}
+	if s := brackets.QuoteIdentifier("dbo", "a]b"); s != `[dbo].[a]]b]` {
+		t.Fatalf("Got: %s, Expecting: %s", s, `[dbo].[a]]b]`)
+	}
+}
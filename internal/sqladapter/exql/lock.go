@@ -0,0 +1,53 @@
+package exql
+
+import (
+	"errors"
+	"strings"
+)
+
+var errLockUnsupported = errors.New("Row locking clauses are not supported by this template")
+
+type lockT struct {
+	Share      bool
+	SkipLocked bool
+	NoWait     bool
+}
+
+// Lock represents a row locking clause on a SELECT statement, like "FOR
+// UPDATE SKIP LOCKED". A Lock with Share set represents a "FOR SHARE" clause.
+type Lock struct {
+	Share      bool
+	SkipLocked bool
+	NoWait     bool
+	hash       hash
+}
+
+var _ = Fragment(&Lock{})
+
+// Hash returns a unique identifier for the struct.
+func (l *Lock) Hash() string {
+	return l.hash.Hash(l)
+}
+
+// Compile transforms the Lock into an equivalent SQL representation.
+func (l *Lock) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(l); ok {
+		return z, nil
+	}
+
+	if layout.LockLayout == "" {
+		return "", errLockUnsupported
+	}
+
+	data := lockT{
+		Share:      l.Share,
+		SkipLocked: l.SkipLocked,
+		NoWait:     l.NoWait,
+	}
+
+	compiled = strings.TrimSpace(mustParse(layout.LockLayout, data))
+
+	layout.Write(l, compiled)
+
+	return
+}
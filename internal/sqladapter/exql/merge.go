@@ -0,0 +1,108 @@
+package exql
+
+import (
+	"errors"
+	"strings"
+)
+
+var errMergeUnsupported = errors.New("MERGE statements are not supported by this template")
+
+type mergeActionT struct {
+	Matched      bool
+	Delete       bool
+	ColumnValues string
+	Columns      string
+	Values       string
+}
+
+// MergeAction represents a WHEN [NOT] MATCHED clause of a MERGE statement. A
+// matched action either updates the target row with ColumnValues or deletes
+// it, a not matched action inserts Values into Columns.
+type MergeAction struct {
+	Matched      bool
+	Delete       bool
+	ColumnValues *ColumnValues
+	Columns      *Columns
+	Values       *Values
+	hash         hash
+}
+
+var _ = Fragment(&MergeAction{})
+
+// Hash returns a unique identifier for the struct.
+func (m *MergeAction) Hash() string {
+	return m.hash.Hash(m)
+}
+
+// Compile transforms the MergeAction into an equivalent SQL representation.
+func (m *MergeAction) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(m); ok {
+		return z, nil
+	}
+
+	if layout.MergeActionLayout == "" {
+		return "", errMergeUnsupported
+	}
+
+	data := mergeActionT{
+		Matched: m.Matched,
+		Delete:  m.Delete,
+	}
+
+	if m.ColumnValues != nil {
+		if data.ColumnValues, err = m.ColumnValues.Compile(layout); err != nil {
+			return "", err
+		}
+	}
+	if m.Columns != nil {
+		if data.Columns, err = m.Columns.Compile(layout); err != nil {
+			return "", err
+		}
+	}
+	if m.Values != nil {
+		if data.Values, err = m.Values.Compile(layout); err != nil {
+			return "", err
+		}
+	}
+
+	compiled = strings.TrimSpace(mustParse(layout.MergeActionLayout, data))
+
+	layout.Write(m, compiled)
+
+	return
+}
+
+// MergeActions represents the list of actions of a MERGE statement.
+type MergeActions struct {
+	Actions []*MergeAction
+	hash    hash
+}
+
+var _ = Fragment(&MergeActions{})
+
+// Hash returns a unique identifier for the struct.
+func (m *MergeActions) Hash() string {
+	return m.hash.Hash(m)
+}
+
+// Compile transforms the MergeActions into an equivalent SQL representation.
+func (m *MergeActions) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(m); ok {
+		return z, nil
+	}
+
+	chunks := make([]string, 0, len(m.Actions))
+	for i := range m.Actions {
+		chunk, err := m.Actions[i].Compile(layout)
+		if err != nil {
+			return "", err
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	compiled = strings.Join(chunks, " ")
+
+	layout.Write(m, compiled)
+
+	return
+}
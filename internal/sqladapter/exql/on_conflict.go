@@ -0,0 +1,136 @@
+package exql
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	errOnConflictUnsupported      = errors.New("ON CONFLICT clauses are not supported by this template")
+	errOnConflictWhereUnsupported = errors.New("ON CONFLICT ... WHERE clauses are not supported by this template")
+	errOnConflictColumnRequired   = errors.New("ON CONFLICT ... DO NOTHING requires a conflict column on this template")
+)
+
+type onConflictT struct {
+	Columns      string
+	Column       string
+	ColumnValues string
+	Where        string
+}
+
+// OnConflict represents the action to take when an INSERT statement violates
+// a unique constraint, like "ON CONFLICT (...) DO UPDATE SET ...". An
+// OnConflict with no ColumnValues represents a "do nothing" action.
+type OnConflict struct {
+	Columns      *Columns
+	ColumnValues Fragment
+	Where        Fragment
+	hash         hash
+}
+
+var _ = Fragment(&OnConflict{})
+
+// Hash returns a unique identifier for the struct.
+func (o *OnConflict) Hash() string {
+	return o.hash.Hash(o)
+}
+
+// Compile transforms the OnConflict into an equivalent SQL representation.
+func (o *OnConflict) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(o); ok {
+		return z, nil
+	}
+
+	if layout.OnConflictLayout == "" {
+		return "", errOnConflictUnsupported
+	}
+
+	if o.Where != nil && !layout.onConflictUsesWhere() {
+		return "", errOnConflictWhereUnsupported
+	}
+
+	data := onConflictT{}
+
+	if o.Columns != nil && len(o.Columns.Columns) > 0 {
+		if data.Columns, err = o.Columns.Compile(layout); err != nil {
+			return "", err
+		}
+		if data.Column, err = o.Columns.Columns[0].Compile(layout); err != nil {
+			return "", err
+		}
+	}
+
+	if data.ColumnValues, err = layout.doCompile(o.ColumnValues); err != nil {
+		return "", err
+	}
+
+	if data.ColumnValues == "" && data.Column == "" && layout.onConflictUsesColumn() {
+		return "", errOnConflictColumnRequired
+	}
+
+	if data.Where, err = layout.doCompile(o.Where); err != nil {
+		return "", err
+	}
+
+	compiled = strings.TrimSpace(mustParse(layout.OnConflictLayout, data))
+
+	layout.Write(o, compiled)
+
+	return
+}
+
+// onConflictUsesWhere reports whether OnConflictLayout can render a WHERE
+// clause for the update action.
+func (layout *Template) onConflictUsesWhere() bool {
+	return strings.Contains(layout.OnConflictLayout, "{{.Where}}")
+}
+
+// onConflictUsesColumn reports whether OnConflictLayout needs a conflict
+// column to render a "do nothing" action, as in MySQL's "ON DUPLICATE KEY
+// UPDATE id = id".
+func (layout *Template) onConflictUsesColumn() bool {
+	return strings.Contains(layout.OnConflictLayout, "{{.Column}}")
+}
+
+// Excluded represents the value that was proposed for insertion on a row
+// that caused a conflict, like "EXCLUDED.column" on PostgreSQL or
+// "VALUES(column)" on MySQL.
+type Excluded struct {
+	Column Fragment
+	hash   hash
+}
+
+var _ = Fragment(&Excluded{})
+
+// ExcludedColumn creates and returns an Excluded reference to the given
+// column.
+func ExcludedColumn(name string) *Excluded {
+	return &Excluded{Column: ColumnWithName(name)}
+}
+
+// Hash returns a unique identifier for the struct.
+func (e *Excluded) Hash() string {
+	return e.hash.Hash(e)
+}
+
+// Compile transforms the Excluded into an equivalent SQL representation.
+func (e *Excluded) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(e); ok {
+		return z, nil
+	}
+
+	if layout.ExcludedLayout == "" {
+		return "", errOnConflictUnsupported
+	}
+
+	column, err := e.Column.Compile(layout)
+	if err != nil {
+		return "", err
+	}
+
+	compiled = mustParse(layout.ExcludedLayout, column)
+
+	layout.Write(e, compiled)
+
+	return
+}
@@ -1,5 +1,9 @@
 package exql
 
+import (
+	"strings"
+)
+
 // Returning represents a RETURNING clause.
 type Returning struct {
 	*Columns
@@ -33,3 +37,27 @@ func (r *Returning) Compile(layout *Template) (compiled string, err error) {
 
 	return
 }
+
+// returningColumnT is a returned column, Source is set when the column was
+// qualified with the INSERTED or DELETED pseudo tables of MSSQL's OUTPUT
+// clause, like "DELETED.name".
+type returningColumnT struct {
+	Source string
+	Name   string
+}
+
+func compileReturningColumn(layout *Template, f Fragment) (returningColumnT, error) {
+	if c, ok := f.(*Column); ok {
+		if name, ok := c.Name.(string); ok {
+			if i := strings.IndexByte(name, '.'); i > 0 {
+				switch source := strings.ToUpper(name[:i]); source {
+				case "INSERTED", "DELETED":
+					compiled, err := ColumnWithName(name[i+1:]).Compile(layout)
+					return returningColumnT{Source: source, Name: compiled}, err
+				}
+			}
+		}
+	}
+	compiled, err := f.Compile(layout)
+	return returningColumnT{Name: compiled}, err
+}
@@ -0,0 +1,56 @@
+package exql
+
+import (
+	"errors"
+	"strings"
+)
+
+var errRankUnsupported = errors.New("Full-text search rank expressions are not supported by this template")
+
+type searchRankT struct {
+	Column string
+	Query  string
+}
+
+// SearchRank represents an expression that scores how well a column matches
+// a full-text query, higher values are better matches. Templates compile it
+// with their RankLayout, the query is a placeholder.
+type SearchRank struct {
+	Column Fragment
+	Alias  string
+	hash   hash
+}
+
+var _ = Fragment(&SearchRank{})
+
+// Hash returns a unique identifier for the struct.
+func (r *SearchRank) Hash() string {
+	return r.hash.Hash(r)
+}
+
+// Compile transforms the SearchRank into an equivalent SQL representation.
+func (r *SearchRank) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(r); ok {
+		return z, nil
+	}
+
+	if layout.RankLayout == "" {
+		return "", errRankUnsupported
+	}
+
+	column, err := r.Column.Compile(layout)
+	if err != nil {
+		return "", err
+	}
+
+	compiled = strings.TrimSpace(mustParse(layout.RankLayout, searchRankT{Column: column, Query: "?"}))
+
+	if r.Alias != "" {
+		alias := mustParse(layout.IdentifierQuote, Raw{Value: r.Alias})
+		compiled = mustParse(layout.ColumnAliasLayout, columnT{compiled, alias})
+	}
+
+	layout.Write(r, compiled)
+
+	return
+}
@@ -8,7 +8,13 @@ import (
 	"upper.io/db.v3/internal/cache"
 )
 
-var errUnknownTemplateType = errors.New("Unknown template type")
+var (
+	errUnknownTemplateType    = errors.New("Unknown template type")
+	errDistinctOnUnsupported  = errors.New("DISTINCT ON clauses are not supported by this template")
+	errUpdateFromUnsupported  = errors.New("UPDATE ... FROM statements are not supported by this template")
+	errDeleteUsingUnsupported = errors.New("DELETE ... USING statements are not supported by this template")
+	errReturningUnsupported   = errors.New("RETURNING clauses on UPDATE and DELETE statements are not supported by this template")
+)
 
 // Statement represents different kinds of SQL statements.
 type Statement struct {
@@ -18,12 +24,27 @@ type Statement struct {
 	Columns      Fragment
 	Values       Fragment
 	Distinct     bool
+	DistinctOn   Fragment
 	ColumnValues Fragment
 	OrderBy      Fragment
 	GroupBy      Fragment
 	Joins        Fragment
 	Where        Fragment
 	Returning    Fragment
+	With         Fragment
+	OnConflict   Fragment
+	Lock         Fragment
+	Sources      Fragment
+	Timeout      Fragment
+	On           Fragment
+	MergeActions Fragment
+	Definitions  Fragment
+	PrimaryKey   Fragment
+	Index        Fragment
+	Column       Fragment
+	NewName      string
+	IfNotExists  bool
+	Unique       bool
 
 	Limit
 	Offset
@@ -40,12 +61,31 @@ type statementT struct {
 	Columns      string
 	Values       string
 	Distinct     bool
+	DistinctOn   string
 	ColumnValues string
 	OrderBy      string
 	GroupBy      string
 	Where        string
 	Joins        string
 	Returning    string
+	With         string
+	OnConflict   string
+	Lock         string
+	Sources      string
+	Timeout      string
+	On           string
+	MergeActions string
+	Definitions  string
+	PrimaryKey   string
+	Index        string
+	Column       string
+	NewColumn    string
+	NewName      string
+	IfNotExists  bool
+	Unique       bool
+
+	ReturningColumns []returningColumnT
+
 	Limit
 	Offset
 }
@@ -57,6 +97,12 @@ func (layout *Template) doCompile(c Fragment) (string, error) {
 	return "", nil
 }
 
+// usesReturning reports whether the given layout renders the RETURNING (or
+// OUTPUT) clause of the statement.
+func usesReturning(layout string) bool {
+	return strings.Contains(layout, ".Returning")
+}
+
 func getHash(h cache.Hashable) string {
 	if h != nil && !reflect.ValueOf(h).IsNil() {
 		return h.Hash()
@@ -92,9 +138,11 @@ func (s *Statement) Compile(layout *Template) (compiled string, err error) {
 	}
 
 	data := statementT{
-		Limit:    s.Limit,
-		Offset:   s.Offset,
-		Distinct: s.Distinct,
+		Limit:       s.Limit,
+		Offset:      s.Offset,
+		Distinct:    s.Distinct,
+		IfNotExists: s.IfNotExists,
+		Unique:      s.Unique,
 	}
 
 	data.Table, err = layout.doCompile(s.Table)
@@ -112,6 +160,17 @@ func (s *Statement) Compile(layout *Template) (compiled string, err error) {
 		return "", err
 	}
 
+	if s.DistinctOn != nil {
+		if layout.DistinctOnLayout == "" {
+			return "", errDistinctOnUnsupported
+		}
+		var columns string
+		if columns, err = layout.doCompile(s.DistinctOn); err != nil {
+			return "", err
+		}
+		data.DistinctOn = mustParse(layout.DistinctOnLayout, columns)
+	}
+
 	data.Values, err = layout.doCompile(s.Values)
 	if err != nil {
 		return "", err
@@ -142,11 +201,84 @@ func (s *Statement) Compile(layout *Template) (compiled string, err error) {
 		return "", err
 	}
 
+	if r, ok := s.Returning.(*Returning); ok && r != nil && r.Columns != nil {
+		// Some templates need to decorate each returned column, like
+		// "INSERTED.column" on MSSQL.
+		data.ReturningColumns = make([]returningColumnT, len(r.Columns.Columns))
+		for i := range r.Columns.Columns {
+			if data.ReturningColumns[i], err = compileReturningColumn(layout, r.Columns.Columns[i]); err != nil {
+				return "", err
+			}
+		}
+	}
+
 	data.Joins, err = layout.doCompile(s.Joins)
 	if err != nil {
 		return "", err
 	}
 
+	data.With, err = layout.doCompile(s.With)
+	if err != nil {
+		return "", err
+	}
+
+	data.OnConflict, err = layout.doCompile(s.OnConflict)
+	if err != nil {
+		return "", err
+	}
+
+	data.Lock, err = layout.doCompile(s.Lock)
+	if err != nil {
+		return "", err
+	}
+
+	data.Sources, err = layout.doCompile(s.Sources)
+	if err != nil {
+		return "", err
+	}
+
+	data.Timeout, err = layout.doCompile(s.Timeout)
+	if err != nil {
+		return "", err
+	}
+
+	data.On, err = layout.doCompile(s.On)
+	if err != nil {
+		return "", err
+	}
+
+	data.MergeActions, err = layout.doCompile(s.MergeActions)
+	if err != nil {
+		return "", err
+	}
+
+	data.Definitions, err = layout.doCompile(s.Definitions)
+	if err != nil {
+		return "", err
+	}
+
+	data.PrimaryKey, err = layout.doCompile(s.PrimaryKey)
+	if err != nil {
+		return "", err
+	}
+
+	data.Index, err = layout.doCompile(s.Index)
+	if err != nil {
+		return "", err
+	}
+
+	data.Column, err = layout.doCompile(s.Column)
+	if err != nil {
+		return "", err
+	}
+
+	if s.NewName != "" {
+		// NewName can be used within string literals, like the arguments of
+		// sp_rename on MSSQL.
+		data.NewColumn = layout.QuoteIdentifier(s.NewName)
+		data.NewName = strings.Replace(s.NewName, "'", "''", -1)
+	}
+
 	switch s.Type {
 	case Truncate:
 		compiled = mustParse(layout.TruncateLayout, data)
@@ -159,16 +291,69 @@ func (s *Statement) Compile(layout *Template) (compiled string, err error) {
 	case Select:
 		compiled = mustParse(layout.SelectLayout, data)
 	case Delete:
-		compiled = mustParse(layout.DeleteLayout, data)
+		text := layout.DeleteLayout
+		if data.Sources != "" {
+			if layout.DeleteUsingLayout == "" {
+				return "", errDeleteUsingUnsupported
+			}
+			text = layout.DeleteUsingLayout
+		}
+		if data.Returning != "" && !usesReturning(text) {
+			return "", errReturningUnsupported
+		}
+		compiled = mustParse(text, data)
 	case Update:
-		compiled = mustParse(layout.UpdateLayout, data)
+		text := layout.UpdateLayout
+		if data.Sources != "" {
+			if layout.UpdateFromLayout == "" {
+				return "", errUpdateFromUnsupported
+			}
+			text = layout.UpdateFromLayout
+		}
+		if data.Returning != "" && !usesReturning(text) {
+			return "", errReturningUnsupported
+		}
+		compiled = mustParse(text, data)
 	case Insert:
 		compiled = mustParse(layout.InsertLayout, data)
+	case Merge:
+		if layout.MergeLayout == "" {
+			return "", errMergeUnsupported
+		}
+		compiled = mustParse(layout.MergeLayout, data)
+	case CreateTable:
+		if layout.CreateTableLayout == "" {
+			return "", errDDLUnsupported
+		}
+		compiled = mustParse(layout.CreateTableLayout, data)
+	case CreateIndex:
+		if layout.CreateIndexLayout == "" {
+			return "", errDDLUnsupported
+		}
+		compiled = mustParse(layout.CreateIndexLayout, data)
+	case AddColumn:
+		if layout.AddColumnLayout == "" {
+			return "", errDDLUnsupported
+		}
+		compiled = mustParse(layout.AddColumnLayout, data)
+	case DropColumn:
+		if layout.DropColumnLayout == "" {
+			return "", errDDLUnsupported
+		}
+		compiled = mustParse(layout.DropColumnLayout, data)
+	case RenameColumn:
+		if layout.RenameColumnLayout == "" {
+			return "", errDDLUnsupported
+		}
+		compiled = mustParse(layout.RenameColumnLayout, data)
 	default:
 		return "", errUnknownTemplateType
 	}
 
 	compiled = strings.TrimSpace(compiled)
+	if data.With != "" {
+		compiled = data.With + " " + compiled
+	}
 	layout.Write(s, compiled)
 
 	return s.Amend(compiled), nil
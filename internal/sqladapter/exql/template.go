@@ -2,6 +2,7 @@ package exql
 
 import (
 	"bytes"
+	"strings"
 	"sync"
 	"text/template"
 
@@ -23,6 +24,12 @@ const (
 	Select
 	Update
 	Delete
+	Merge
+	CreateTable
+	CreateIndex
+	AddColumn
+	DropColumn
+	RenameColumn
 
 	SQL
 )
@@ -40,42 +47,92 @@ var (
 
 // Template is an SQL template.
 type Template struct {
+	AddColumnLayout     string
 	AndKeyword          string
 	AscKeyword          string
 	AssignmentOperator  string
 	ClauseGroup         string
 	ClauseOperator      string
+	CloseCursorLayout   string
 	ColumnAliasLayout   string
 	ColumnSeparator     string
 	ColumnValue         string
 	CountLayout         string
+	CreateIndexLayout   string
+	CreateTableLayout   string
+	CubeLayout          string
+	DeclareCursorLayout string
 	DefaultOperator     string
 	DeleteLayout        string
+	DeleteUsingLayout   string
 	DescKeyword         string
+	DistinctOnLayout    string
+	DropColumnLayout    string
 	DropDatabaseLayout  string
 	DropTableLayout     string
+	ExcludedLayout      string
+	FalseKeyword        string
+	FetchCursorLayout   string
 	GroupByLayout       string
+	GroupingSetsLayout  string
 	IdentifierQuote     string
 	IdentifierSeparator string
+	ILikeLayout         string
 	InsertLayout        string
 	JoinLayout          string
+	LockLayout          string
+	MatchLayout         string
+	MergeActionLayout   string
+	MergeLayout         string
 	NotKeyword          string
+	OnConflictLayout    string
 	OnLayout            string
 	OrKeyword           string
 	OrderByLayout       string
+	RankLayout          string
+	RenameColumnLayout  string
+	RollupLayout        string
 	SelectLayout        string
 	SortByColumnLayout  string
 	TableAliasLayout    string
+	TimeoutLayout       string
+	TrueKeyword         string
 	TruncateLayout      string
+	TupleInLayout       string
+	UpdateFromLayout    string
 	UpdateLayout        string
 	UsingLayout         string
+	ValuesListLayout    string
 	ValueQuote          string
 	ValueSeparator      string
 	WhereLayout         string
+	WithLayout          string
+
+	// ColumnDefinitionLayout renders the definition of a column, its type is
+	// looked up in ColumnTypes, which maps the generic types of the DDL
+	// builder (like "integer" or "string") to layouts that render the type
+	// of the database.
+	ColumnDefinitionLayout string
+	ColumnTypes            map[string]string
 
 	*cache.Cache
 }
 
+// UpdateSourcesFirst reports whether UpdateFromLayout renders the additional
+// source tables before the SET clause (as in MySQL's multi-table UPDATE), in
+// which case their arguments must be passed before the ones in SET.
+func (layout *Template) UpdateSourcesFirst() bool {
+	sources := strings.Index(layout.UpdateFromLayout, "{{.Sources}}")
+	columnValues := strings.Index(layout.UpdateFromLayout, "{{.ColumnValues}}")
+	return sources >= 0 && columnValues >= 0 && sources < columnValues
+}
+
+// RankUsesQuery reports whether RankLayout includes the full-text query, in
+// which case it must be passed as an argument.
+func (layout *Template) RankUsesQuery() bool {
+	return strings.Contains(layout.RankLayout, "{{.Query}}")
+}
+
 func mustParse(text string, data interface{}) string {
 	var b bytes.Buffer
 	var ok bool
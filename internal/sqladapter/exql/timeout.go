@@ -0,0 +1,44 @@
+package exql
+
+import (
+	"strings"
+	"time"
+)
+
+type timeoutT struct {
+	Milliseconds int64
+}
+
+// Timeout represents a server-side execution time limit on a SELECT
+// statement, like MySQL's MAX_EXECUTION_TIME optimizer hint. Templates that
+// don't have a TimeoutLayout compile it into an empty string, the query is
+// still bound by the deadline of its context.
+type Timeout struct {
+	Duration time.Duration
+	hash     hash
+}
+
+var _ = Fragment(&Timeout{})
+
+// Hash returns a unique identifier for the struct.
+func (t *Timeout) Hash() string {
+	return t.hash.Hash(t)
+}
+
+// Compile transforms the Timeout into an equivalent SQL representation.
+func (t *Timeout) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(t); ok {
+		return z, nil
+	}
+
+	if layout.TimeoutLayout != "" {
+		data := timeoutT{
+			Milliseconds: int64(t.Duration / time.Millisecond),
+		}
+		compiled = strings.TrimSpace(mustParse(layout.TimeoutLayout, data))
+	}
+
+	layout.Write(t, compiled)
+
+	return
+}
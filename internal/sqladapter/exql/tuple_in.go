@@ -0,0 +1,103 @@
+package exql
+
+import (
+	"strings"
+)
+
+type tupleInT struct {
+	Columns string
+	Values  string
+	Negate  bool
+}
+
+// TupleIn represents a row value IN condition, like "(a, b) IN ((?, ?), (?,
+// ?))". Templates that don't define a TupleInLayout get an equivalent
+// condition joined with OR and AND operators, like "((a = ? AND b = ?) OR (a =
+// ? AND b = ?))".
+type TupleIn struct {
+	Columns *Columns
+	Rows    int
+	Negate  bool
+	hash    hash
+}
+
+var _ = Fragment(&TupleIn{})
+
+// Hash returns a unique identifier for the struct.
+func (t *TupleIn) Hash() string {
+	return t.hash.Hash(t)
+}
+
+// Compile transforms the TupleIn into an equivalent SQL representation.
+func (t *TupleIn) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(t); ok {
+		return z, nil
+	}
+
+	if t.Rows == 0 || len(t.Columns.Columns) == 0 {
+		// Nothing can be IN an empty list.
+		if t.Negate {
+			compiled = "1 = 1"
+		} else {
+			compiled = "1 = 0"
+		}
+	} else if layout.TupleInLayout != "" {
+		compiled, err = t.compileTuples(layout)
+	} else {
+		compiled, err = t.compileConditions(layout)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	layout.Write(t, compiled)
+
+	return
+}
+
+func (t *TupleIn) compileTuples(layout *Template) (string, error) {
+	columns, err := t.Columns.Compile(layout)
+	if err != nil {
+		return "", err
+	}
+
+	row := "(?" + strings.Repeat(", ?", len(t.Columns.Columns)-1) + ")"
+	rows := make([]string, t.Rows)
+	for i := range rows {
+		rows[i] = row
+	}
+
+	data := tupleInT{
+		Columns: columns,
+		Values:  strings.Join(rows, layout.ValueSeparator),
+		Negate:  t.Negate,
+	}
+
+	return strings.TrimSpace(mustParse(layout.TupleInLayout, data)), nil
+}
+
+func (t *TupleIn) compileConditions(layout *Template) (string, error) {
+	rows := make([]Fragment, t.Rows)
+	for i := range rows {
+		conds := make([]Fragment, len(t.Columns.Columns))
+		for j := range conds {
+			conds[j] = &ColumnValue{
+				Column:   t.Columns.Columns[j],
+				Operator: layout.DefaultOperator,
+				Value:    RawValue("?"),
+			}
+		}
+		rows[i] = JoinWithAnd(conds...)
+	}
+
+	compiled, err := JoinWithOr(rows...).Compile(layout)
+	if err != nil {
+		return "", err
+	}
+
+	if t.Negate {
+		compiled = layout.NotKeyword + " " + compiled
+	}
+
+	return compiled, nil
+}
@@ -2,6 +2,7 @@ package exql
 
 import (
 	"strings"
+	"unicode"
 )
 
 const (
@@ -95,6 +96,39 @@ func separateByComma(in string) (out []string) {
 }
 */
 
+// isFunctionCall returns true if the given string looks like a function call,
+// like "JSON_EXTRACT(data, '$.status')".
+func isFunctionCall(in string) bool {
+	i := strings.IndexByte(in, '(')
+	if i < 1 || !strings.HasSuffix(in, ")") {
+		return false
+	}
+	for _, c := range in[:i] {
+		if !(c == '_' || c == '.' || unicode.IsLetter(c) || unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonPathIndex returns the index of the first JSON path operator ("->",
+// "->>", "#>" or "#>>") that appears outside of single quotes in the given
+// string, or -1 if there is none.
+func jsonPathIndex(in string) int {
+	quoted := false
+	for i := 0; i < len(in)-1; i++ {
+		switch {
+		case in[i] == '\'':
+			quoted = !quoted
+		case quoted:
+			continue
+		case (in[i] == '-' || in[i] == '#') && in[i+1] == '>':
+			return i
+		}
+	}
+	return -1
+}
+
 // Separates by a comma, ignoring spaces too.
 func separateByComma(in string) (out []string) {
 	out = strings.Split(in, ",")
@@ -0,0 +1,85 @@
+package exql
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	errValuesListUnsupported = errors.New("VALUES lists are not supported by this template")
+	errEmptyValuesList       = errors.New("VALUES lists require at least one row")
+)
+
+type valuesListColumnT struct {
+	Position int
+	Name     string
+}
+
+type valuesListT struct {
+	Rows    []string
+	Name    string
+	Columns []valuesListColumnT
+}
+
+// ValuesList represents a VALUES list used as a table, like "(VALUES (?, ?),
+// (?, ?)) AS v (id, name)".
+type ValuesList struct {
+	Rows    [][]Fragment
+	Name    string
+	Columns []string
+	hash    hash
+}
+
+var _ = Fragment(&ValuesList{})
+
+// Hash returns a unique identifier for the struct.
+func (v *ValuesList) Hash() string {
+	return v.hash.Hash(v)
+}
+
+// Compile transforms the ValuesList into an equivalent SQL representation.
+func (v *ValuesList) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(v); ok {
+		return z, nil
+	}
+
+	if layout.ValuesListLayout == "" {
+		return "", errValuesListUnsupported
+	}
+
+	if len(v.Rows) == 0 || len(v.Rows[0]) == 0 {
+		return "", errEmptyValuesList
+	}
+
+	data := valuesListT{
+		Rows: make([]string, len(v.Rows)),
+	}
+
+	for i := range v.Rows {
+		values := make([]string, len(v.Rows[i]))
+		for j := range v.Rows[i] {
+			if values[j], err = v.Rows[i][j].Compile(layout); err != nil {
+				return "", err
+			}
+		}
+		data.Rows[i] = strings.Join(values, layout.ValueSeparator)
+	}
+
+	if v.Name != "" {
+		data.Name = mustParse(layout.IdentifierQuote, Raw{Value: v.Name})
+	}
+
+	data.Columns = make([]valuesListColumnT, len(v.Columns))
+	for i := range v.Columns {
+		data.Columns[i] = valuesListColumnT{
+			Position: i + 1,
+			Name:     mustParse(layout.IdentifierQuote, Raw{Value: v.Columns[i]}),
+		}
+	}
+
+	compiled = strings.TrimSpace(mustParse(layout.ValuesListLayout, data))
+
+	layout.Write(v, compiled)
+
+	return
+}
@@ -0,0 +1,65 @@
+package exql
+
+import (
+	"strings"
+)
+
+// WindowFunction represents a function that is evaluated over a window of
+// rows, like "ROW_NUMBER() OVER (PARTITION BY ... ORDER BY ...)".
+type WindowFunction struct {
+	Name        string
+	Args        *Columns
+	PartitionBy *Columns
+	OrderBy     *OrderBy
+	Alias       string
+	hash        hash
+}
+
+var _ = Fragment(&WindowFunction{})
+
+// Hash returns a unique identifier for the struct.
+func (w *WindowFunction) Hash() string {
+	return w.hash.Hash(w)
+}
+
+// Compile transforms the WindowFunction into an equivalent SQL
+// representation.
+func (w *WindowFunction) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(w); ok {
+		return z, nil
+	}
+
+	args, err := layout.doCompile(w.Args)
+	if err != nil {
+		return "", err
+	}
+
+	window := []string{}
+
+	partitionBy, err := layout.doCompile(w.PartitionBy)
+	if err != nil {
+		return "", err
+	}
+	if partitionBy != "" {
+		window = append(window, "PARTITION BY "+partitionBy)
+	}
+
+	orderBy, err := layout.doCompile(w.OrderBy)
+	if err != nil {
+		return "", err
+	}
+	if orderBy = trimString(orderBy); orderBy != "" {
+		window = append(window, orderBy)
+	}
+
+	compiled = w.Name + "(" + args + ") OVER (" + strings.Join(window, " ") + ")"
+
+	if w.Alias != "" {
+		alias := mustParse(layout.IdentifierQuote, Raw{Value: w.Alias})
+		compiled = mustParse(layout.ColumnAliasLayout, columnT{compiled, alias})
+	}
+
+	layout.Write(w, compiled)
+
+	return
+}
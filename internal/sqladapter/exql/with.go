@@ -0,0 +1,116 @@
+package exql
+
+import (
+	"errors"
+	"strings"
+)
+
+var errWithUnsupported = errors.New("WITH clauses are not supported by this template")
+
+type withT struct {
+	Recursive bool
+	Clauses   string
+}
+
+// With represents a WITH clause that holds one or many common table
+// expressions.
+type With struct {
+	Recursive bool
+	Clauses   []Fragment
+	hash      hash
+}
+
+var _ = Fragment(&With{})
+
+// WithClauses creates and returns a With clause with the given common table
+// expressions.
+func WithClauses(recursive bool, clauses ...Fragment) *With {
+	return &With{Recursive: recursive, Clauses: clauses}
+}
+
+// Hash returns a unique identifier for the struct.
+func (w *With) Hash() string {
+	return w.hash.Hash(w)
+}
+
+// Compile transforms the With into an equivalent SQL representation.
+func (w *With) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(w); ok {
+		return z, nil
+	}
+
+	if len(w.Clauses) == 0 {
+		return "", nil
+	}
+
+	if layout.WithLayout == "" {
+		return "", errWithUnsupported
+	}
+
+	out := make([]string, len(w.Clauses))
+	for i := range w.Clauses {
+		if out[i], err = w.Clauses[i].Compile(layout); err != nil {
+			return "", err
+		}
+	}
+
+	data := withT{
+		Recursive: w.Recursive,
+		Clauses:   strings.Join(out, layout.IdentifierSeparator),
+	}
+
+	compiled = strings.TrimSpace(mustParse(layout.WithLayout, data))
+
+	layout.Write(w, compiled)
+
+	return
+}
+
+// CommonTableExpression represents a named subquery that is part of a WITH
+// clause, like "name AS (SELECT ...)" or "name(a, b) AS (SELECT ...)".
+type CommonTableExpression struct {
+	Name  string
+	Query Fragment
+	hash  hash
+}
+
+var _ = Fragment(&CommonTableExpression{})
+
+// Hash returns a unique identifier for the struct.
+func (c *CommonTableExpression) Hash() string {
+	return c.hash.Hash(c)
+}
+
+// Compile transforms the CommonTableExpression into an equivalent SQL
+// representation.
+func (c *CommonTableExpression) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(c); ok {
+		return z, nil
+	}
+
+	name, columns := trimString(c.Name), ""
+	if i := strings.IndexByte(name, '('); i > 0 && name[len(name)-1] == ')' {
+		name, columns = trimString(name[:i]), name[i+1:len(name)-1]
+	}
+
+	compiled = mustParse(layout.IdentifierQuote, Raw{Value: name})
+
+	if columns != "" {
+		parts := separateByComma(columns)
+		for i := range parts {
+			parts[i] = mustParse(layout.IdentifierQuote, Raw{Value: trimString(parts[i])})
+		}
+		compiled = compiled + "(" + strings.Join(parts, layout.IdentifierSeparator) + ")"
+	}
+
+	query, err := c.Query.Compile(layout)
+	if err != nil {
+		return "", err
+	}
+
+	compiled = compiled + " AS (" + query + ")"
+
+	layout.Write(c, compiled)
+
+	return
+}
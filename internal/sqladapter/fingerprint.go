@@ -0,0 +1,140 @@
+package sqladapter
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+var (
+	reFingerprintList   = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	reFingerprintTuples = regexp.MustCompile(`\(\.\.\.\)(?:\s*,\s*\(\.\.\.\))+`)
+)
+
+// fingerprint normalizes a query so statements that only differ in their
+// values share it: comments are removed, whitespace is collapsed, literals and
+// placeholders (like 'a', 42, $1, :name or @p1) are replaced by "?" and lists
+// of them, like the ones of IN or VALUES, are collapsed into "(...)".
+func fingerprint(query string) string {
+	var buf bytes.Buffer
+
+	space := func() {
+		if n := buf.Len(); n > 0 && buf.Bytes()[n-1] != ' ' {
+			buf.WriteByte(' ')
+		}
+	}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space()
+			i++
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			if j := strings.IndexByte(query[i:], '\n'); j >= 0 {
+				i += j
+			} else {
+				i = len(query)
+			}
+			space()
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			if j := strings.Index(query[i+2:], "*/"); j >= 0 {
+				i += j + 4
+			} else {
+				i = len(query)
+			}
+			space()
+		case c == '\'':
+			i = skipQuoted(query, i, '\'')
+			buf.WriteByte('?')
+		case c == '"' || c == '`':
+			j := skipQuoted(query, i, c)
+			buf.WriteString(query[i:j])
+			i = j
+		case isIdentifierStart(c):
+			j := i + 1
+			for j < len(query) && isIdentifierChar(query[j]) {
+				j++
+			}
+			if j-i == 1 && j < len(query) && query[j] == '\'' && strings.ContainsRune("EeNnXxBb", rune(c)) {
+				// Prefixed strings, like E'\n' or N'name'.
+				i = skipQuoted(query, j, '\'')
+				buf.WriteByte('?')
+				break
+			}
+			buf.WriteString(query[i:j])
+			i = j
+		case isDigit(c), c == '.' && i+1 < len(query) && isDigit(query[i+1]):
+			i++
+			for i < len(query) && (isDigit(query[i]) || query[i] == '.' || query[i] == 'e' || query[i] == 'E') {
+				i++
+			}
+			buf.WriteByte('?')
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			i++
+			for i < len(query) && isDigit(query[i]) {
+				i++
+			}
+			buf.WriteByte('?')
+		case c == '@' && i+2 < len(query) && query[i+1] == 'p' && isDigit(query[i+2]):
+			i += 2
+			for i < len(query) && isDigit(query[i]) {
+				i++
+			}
+			buf.WriteByte('?')
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			// Type casts, like "::text".
+			buf.WriteString("::")
+			i += 2
+		case c == ':' && i+1 < len(query) && isIdentifierStart(query[i+1]):
+			i++
+			for i < len(query) && isIdentifierChar(query[i]) {
+				i++
+			}
+			buf.WriteByte('?')
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+
+	s := strings.TrimSpace(buf.String())
+	s = reFingerprintList.ReplaceAllString(s, "(...)")
+	s = reFingerprintTuples.ReplaceAllString(s, "(...)")
+
+	return s
+}
+
+// skipQuoted returns the position that follows the quoted string or
+// identifier that starts at i, quotes within it are escaped by doubling them
+// or, for strings, with a backslash.
+func skipQuoted(query string, i int, quote byte) int {
+	for j := i + 1; j < len(query); j++ {
+		switch query[j] {
+		case '\\':
+			if quote == '\'' {
+				j++
+			}
+		case quote:
+			if j+1 < len(query) && query[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isIdentifierChar(c byte) bool {
+	return isIdentifierStart(c) || isDigit(c) || c == '$'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
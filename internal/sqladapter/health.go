@@ -0,0 +1,116 @@
+package sqladapter
+
+import (
+	"context"
+	"time"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/compat"
+)
+
+// PingContext checks whether a connection to the database is still alive by
+// pinging it within the given context.
+func (d *database) PingContext(ctx context.Context) error {
+	if sess := d.Session(); sess != nil {
+		return compat.PingContext(sess, ctx)
+	}
+	return nil
+}
+
+// SetHealthCheckPolicy sets the policy of the background health checker and
+// restarts it, a nil policy stops it.
+func (d *database) SetHealthCheckPolicy(policy *db.HealthCheckPolicy) {
+	d.Settings.SetHealthCheckPolicy(policy)
+	d.startHealthCheck()
+}
+
+// startHealthCheck starts the health checker of the session if it has a
+// health check policy, stopping the previous one.
+func (d *database) startHealthCheck() {
+	d.healthMu.Lock()
+	defer d.healthMu.Unlock()
+
+	if d.healthStop != nil {
+		close(d.healthStop)
+		d.healthStop = nil
+	}
+
+	policy := d.HealthCheckPolicy()
+	if policy == nil || policy.Interval <= 0 || d.Session() == nil || d.Transaction() != nil {
+		return
+	}
+
+	d.healthStop = make(chan struct{})
+	go d.healthCheck(d.healthStop, policy)
+}
+
+func (d *database) stopHealthCheck() {
+	d.healthMu.Lock()
+	defer d.healthMu.Unlock()
+
+	if d.healthStop != nil {
+		close(d.healthStop)
+		d.healthStop = nil
+	}
+}
+
+// healthCheck pings the server every policy.Interval until stop is closed,
+// backing off after failed checks.
+func (d *database) healthCheck(stop <-chan struct{}, policy *db.HealthCheckPolicy) {
+	failures := 0
+	for {
+		wait := policy.Interval
+		if failures > 0 {
+			if backoff := policy.Backoff(failures); backoff > 0 {
+				wait = backoff
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		err := d.checkHealth(policy.Timeout)
+		if err == nil {
+			if failures > 0 && policy.OnStateChange != nil {
+				policy.OnStateChange(nil)
+			}
+			failures = 0
+			continue
+		}
+
+		if failures == 0 && policy.OnStateChange != nil {
+			policy.OnStateChange(err)
+		}
+		failures++
+
+		d.resetConns()
+	}
+}
+
+func (d *database) checkHealth(timeout time.Duration) error {
+	ctx := d.Context()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return d.PingContext(ctx)
+}
+
+// resetConns drops the idle connections of the session, which may point to a
+// server that is gone, so new ones are opened for the next queries. Prepared
+// statements are cleared as well.
+func (d *database) resetConns() {
+	sess := d.Session()
+	if sess == nil {
+		return
+	}
+	sess.SetMaxIdleConns(0)
+	sess.SetMaxIdleConns(d.MaxIdleConns())
+	d.cachedStatements.Clear()
+}
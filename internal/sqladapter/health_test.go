@@ -0,0 +1,95 @@
+//go:build go1.10
+// +build go1.10
+
+package sqladapter
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/cache"
+)
+
+var errServerDown = errors.New("server is down")
+
+// switchConnector opens connections only while up is set.
+type switchConnector struct {
+	up int32
+}
+
+func (c *switchConnector) Connect(context.Context) (driver.Conn, error) {
+	if atomic.LoadInt32(&c.up) == 0 {
+		return nil, errServerDown
+	}
+	return stubConn{}, nil
+}
+
+func (c *switchConnector) Driver() driver.Driver {
+	return nil
+}
+
+type stubConn struct{}
+
+func (stubConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (stubConn) Close() error {
+	return nil
+}
+
+func (stubConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestHealthCheck(t *testing.T) {
+	connector := &switchConnector{}
+
+	d := &database{
+		Settings:          db.NewSettings(),
+		sess:              sql.OpenDB(connector),
+		cachedStatements:  cache.NewCache(),
+		cachedCollections: cache.NewCache(),
+	}
+	defer d.Close()
+
+	assert.Equal(t, errServerDown, d.PingContext(context.Background()))
+
+	changes := make(chan error, 4)
+	d.SetHealthCheckPolicy(&db.HealthCheckPolicy{
+		Interval:       10 * time.Millisecond,
+		InitialBackoff: 5 * time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+		OnStateChange: func(err error) {
+			changes <- err
+		},
+	})
+
+	select {
+	case err := <-changes:
+		assert.Equal(t, errServerDown, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expecting the health check to fail")
+	}
+
+	atomic.StoreInt32(&connector.up, 1)
+
+	select {
+	case err := <-changes:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expecting the session to recover")
+	}
+
+	assert.NoError(t, d.PingContext(context.Background()))
+
+	d.SetHealthCheckPolicy(nil)
+	assert.Nil(t, d.healthStop)
+}
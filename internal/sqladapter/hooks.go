@@ -0,0 +1,160 @@
+package sqladapter
+
+import (
+	"fmt"
+	"reflect"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/reflectx"
+)
+
+// InsertWithHooks runs the given insert function, calling the BeforeInsert
+// and AfterInsert hooks of the item (if any) around it. Automatic timestamps
+// are set before calling any hook, unique violations of the constraints the
+// item declares are returned as *db.ConstraintError. Adapters use it to
+// implement Collection.Insert.
+func InsertWithHooks(sess db.Database, item interface{}, insert func(interface{}) (interface{}, error)) (interface{}, error) {
+	if autoTimestamps(sess) {
+		setTimestamps(item, true)
+	}
+
+	if hook, ok := item.(db.BeforeInsertHook); ok {
+		if err := hook.BeforeInsert(sess); err != nil {
+			return nil, err
+		}
+	}
+
+	id, err := insert(item)
+	if err != nil {
+		return nil, constraintError(item, err)
+	}
+
+	if hook, ok := item.(db.AfterInsertHook); ok {
+		if err := hook.AfterInsert(sess); err != nil {
+			return nil, err
+		}
+	}
+
+	return id, nil
+}
+
+// isModel returns true if the given condition is actually an item (a struct
+// or a pointer to a struct) that must be matched by its primary keys.
+func isModel(v interface{}) bool {
+	switch v.(type) {
+	case db.Constraint, db.Constraints, db.Compound, db.RawValue, db.Function:
+		return false
+	}
+	if IsKeyValue(v) {
+		return false
+	}
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// modelPrimaryKeys returns the columns of the fields of the given item that
+// are tagged with the pk option, like `db:"id,pk"`, or pks if there are none.
+func modelPrimaryKeys(m *reflectx.Mapper, item interface{}, pks []string) []string {
+	t := reflect.TypeOf(item)
+	if t == nil {
+		return pks
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return pks
+	}
+
+	var tagged []string
+	for _, fi := range m.TypeMap(t).Index {
+		if _, ok := fi.Options["pk"]; ok {
+			tagged = append(tagged, fi.Name)
+		}
+	}
+	if len(tagged) == 0 {
+		return pks
+	}
+	return tagged
+}
+
+// keyValues returns the values of the given primary keys that are present on
+// item, which can be a struct or a map.
+func keyValues(m *reflectx.Mapper, item interface{}, pks []string) map[string]interface{} {
+	values := make(map[string]interface{}, len(pks))
+
+	itemV := reflect.Indirect(reflect.ValueOf(item))
+	switch itemV.Kind() {
+	case reflect.Struct:
+		fieldMap := m.TypeMap(itemV.Type()).Names
+		for _, pk := range pks {
+			if fi, ok := fieldMap[pk]; ok {
+				values[pk] = reflectx.FieldByIndexesReadOnly(itemV, fi.Index).Interface()
+			}
+		}
+	case reflect.Map:
+		keyT := itemV.Type().Key()
+		if keyT.Kind() != reflect.String {
+			break
+		}
+		for _, pk := range pks {
+			if v := itemV.MapIndex(reflect.ValueOf(pk).Convert(keyT)); v.IsValid() {
+				values[pk] = v.Interface()
+			}
+		}
+	}
+
+	return values
+}
+
+// modelCond returns a condition that matches the given item by its primary
+// keys.
+func modelCond(m *reflectx.Mapper, item interface{}, pks []string) (db.Cond, error) {
+	if len(pks) == 0 {
+		return nil, fmt.Errorf("Cannot find an item without primary keys")
+	}
+
+	values := keyValues(m, item, pks)
+
+	cond := db.Cond{}
+	for _, pk := range pks {
+		value, ok := values[pk]
+		if !ok {
+			return nil, fmt.Errorf("Expecting %T to have a field for primary key %q", item, pk)
+		}
+		cond[pk] = value
+	}
+
+	return cond, nil
+}
+
+// insertedCond returns a condition that matches the item that was inserted
+// with the given ID. Key parts that are missing from the ID, like the ones
+// that were not generated by the database, are read from the item.
+func insertedCond(m *reflectx.Mapper, item interface{}, id interface{}, pks []string) (db.Cond, error) {
+	cond := db.Cond{}
+	if keys, ok := id.(db.Cond); ok {
+		for k := range keys {
+			cond[k] = keys[k]
+		}
+	} else if len(pks) == 1 {
+		cond[pks[0]] = id
+	}
+
+	values := keyValues(m, item, pks)
+	for _, pk := range pks {
+		if _, ok := cond[pk]; ok {
+			continue
+		}
+		value, ok := values[pk]
+		if !ok {
+			return nil, fmt.Errorf("Could not get a value for primary key %q after inserting", pk)
+		}
+		cond[pk] = value
+	}
+
+	return cond, nil
+}
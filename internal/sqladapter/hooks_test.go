@@ -0,0 +1,63 @@
+package sqladapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/reflectx"
+)
+
+type membership struct {
+	GroupID int64  `db:"group_id,pk"`
+	UserID  int64  `db:"user_id,pk,omitempty"`
+	Role    string `db:"role"`
+}
+
+func TestModelPrimaryKeys(t *testing.T) {
+	m := reflectx.NewMapper("db")
+
+	pks := modelPrimaryKeys(m, &membership{}, []string{"id"})
+	assert.Equal(t, []string{"group_id", "user_id"}, pks)
+
+	pks = modelPrimaryKeys(m, struct {
+		ID int64 `db:"id"`
+	}{}, []string{"id"})
+	assert.Equal(t, []string{"id"}, pks)
+
+	pks = modelPrimaryKeys(m, map[string]interface{}{}, []string{"id"})
+	assert.Equal(t, []string{"id"}, pks)
+}
+
+func TestModelCond(t *testing.T) {
+	m := reflectx.NewMapper("db")
+	pks := []string{"group_id", "user_id"}
+
+	cond, err := modelCond(m, &membership{GroupID: 1, UserID: 2}, pks)
+	assert.NoError(t, err)
+	assert.Equal(t, db.Cond{"group_id": int64(1), "user_id": int64(2)}, cond)
+
+	cond, err = modelCond(m, map[string]interface{}{"group_id": 1, "user_id": 2, "role": "admin"}, pks)
+	assert.NoError(t, err)
+	assert.Equal(t, db.Cond{"group_id": 1, "user_id": 2}, cond)
+
+	_, err = modelCond(m, map[string]interface{}{"group_id": 1}, pks)
+	assert.Error(t, err)
+}
+
+func TestInsertedCond(t *testing.T) {
+	m := reflectx.NewMapper("db")
+	pks := []string{"group_id", "user_id"}
+
+	// The key part that was generated by the database is taken from the ID.
+	cond, err := insertedCond(m, &membership{GroupID: 1}, db.Cond{"user_id": int64(7)}, pks)
+	assert.NoError(t, err)
+	assert.Equal(t, db.Cond{"group_id": int64(1), "user_id": int64(7)}, cond)
+
+	cond, err = insertedCond(m, map[string]interface{}{"name": "Joe"}, int64(5), []string{"id"})
+	assert.NoError(t, err)
+	assert.Equal(t, db.Cond{"id": int64(5)}, cond)
+
+	_, err = insertedCond(m, map[string]interface{}{"group_id": 1}, int64(5), pks)
+	assert.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package sqladapter
+
+import (
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/compat"
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+// metrics collects query and transaction counters, it's shared by a session,
+// its clones and its transactions.
+type metrics struct {
+	txCommits   uint64
+	txRollbacks uint64
+
+	recordCacheHits   uint64
+	recordCacheMisses uint64
+
+	mu      sync.Mutex
+	queries map[string]db.QueryMetrics
+
+	stats *queryStats
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		queries: make(map[string]db.QueryMetrics),
+		stats:   newQueryStats(),
+	}
+}
+
+func queryKind(t exql.Type) string {
+	switch t {
+	case exql.Select, exql.Count:
+		return db.QueryKindSelect
+	case exql.Insert:
+		return db.QueryKindInsert
+	case exql.Update:
+		return db.QueryKindUpdate
+	case exql.Delete:
+		return db.QueryKindDelete
+	}
+	return db.QueryKindOther
+}
+
+func (m *metrics) observeQuery(stmt *exql.Statement, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+
+	elapsed := time.Since(start)
+	kind := queryKind(stmt.Type)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	q := m.queries[kind]
+	q.Count++
+	if err != nil {
+		q.Errors++
+	}
+	q.TotalDuration += elapsed
+	if elapsed > q.MaxDuration {
+		q.MaxDuration = elapsed
+	}
+	m.queries[kind] = q
+}
+
+func (m *metrics) observeCommit(err error) {
+	if m == nil || err != nil {
+		return
+	}
+	atomic.AddUint64(&m.txCommits, 1)
+}
+
+func (m *metrics) observeRollback(err error) {
+	if m == nil || err != nil {
+		return
+	}
+	atomic.AddUint64(&m.txRollbacks, 1)
+}
+
+func (m *metrics) snapshot(sess *sql.DB) db.Metrics {
+	var s db.Metrics
+
+	if sess != nil {
+		stats := compat.DBStats(sess)
+		s.OpenConnections = stats.OpenConnections
+		s.InUse = stats.InUse
+		s.Idle = stats.Idle
+		s.WaitCount = stats.WaitCount
+		s.WaitDuration = stats.WaitDuration
+	}
+
+	s.Queries = make(map[string]db.QueryMetrics)
+	if m == nil {
+		return s
+	}
+
+	m.mu.Lock()
+	for kind, q := range m.queries {
+		s.Queries[kind] = q
+	}
+	m.mu.Unlock()
+
+	s.TxCommits = atomic.LoadUint64(&m.txCommits)
+	s.TxRollbacks = atomic.LoadUint64(&m.txRollbacks)
+
+	s.RecordCacheHits = atomic.LoadUint64(&m.recordCacheHits)
+	s.RecordCacheMisses = atomic.LoadUint64(&m.recordCacheMisses)
+
+	return s
+}
@@ -0,0 +1,48 @@
+package sqladapter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+func TestMetrics(t *testing.T) {
+	m := newMetrics()
+
+	start := time.Now().Add(-10 * time.Millisecond)
+
+	m.observeQuery(&exql.Statement{Type: exql.Select}, start, nil)
+	m.observeQuery(&exql.Statement{Type: exql.Count}, start, nil)
+	m.observeQuery(&exql.Statement{Type: exql.Insert}, start, errors.New("failed"))
+	m.observeQuery(&exql.Statement{Type: exql.SQL}, start, nil)
+
+	m.observeCommit(nil)
+	m.observeCommit(errors.New("failed"))
+	m.observeRollback(nil)
+
+	s := m.snapshot(nil)
+
+	selects := s.Queries[db.QueryKindSelect]
+	assert.Equal(t, uint64(2), selects.Count)
+	assert.Equal(t, uint64(0), selects.Errors)
+	assert.True(t, selects.MaxDuration >= 10*time.Millisecond)
+	assert.True(t, selects.AverageDuration() >= 10*time.Millisecond)
+
+	inserts := s.Queries[db.QueryKindInsert]
+	assert.Equal(t, uint64(1), inserts.Count)
+	assert.Equal(t, uint64(1), inserts.Errors)
+
+	assert.Equal(t, uint64(1), s.Queries[db.QueryKindOther].Count)
+	assert.Equal(t, uint64(0), s.Queries[db.QueryKindUpdate].Count)
+
+	assert.Equal(t, uint64(1), s.TxCommits)
+	assert.Equal(t, uint64(1), s.TxRollbacks)
+
+	// Snapshots are not affected by later queries.
+	m.observeQuery(&exql.Statement{Type: exql.Select}, start, nil)
+	assert.Equal(t, uint64(2), s.Queries[db.QueryKindSelect].Count)
+}
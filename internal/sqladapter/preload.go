@@ -0,0 +1,300 @@
+package sqladapter
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/reflectx"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+const (
+	relationHasMany   = "has_many"
+	relationBelongsTo = "belongs_to"
+)
+
+// relation describes how a struct field is related to another table, as
+// declared by a "relation" struct tag:
+//
+//   type User struct {
+//     ID     int64   `db:"id"`
+//     Orders []Order `db:"-" relation:"has_many,fk=user_id"`
+//   }
+//
+//   type Order struct {
+//     ID     int64 `db:"id"`
+//     UserID int64 `db:"user_id"`
+//     User   *User `db:"-" relation:"belongs_to,fk=user_id,table=users"`
+//   }
+//
+// The "fk" option is required, the "table" option defaults to the name of the
+// field in snake case and the "key" option (the referenced column) defaults
+// to "id".
+type relation struct {
+	kind  string
+	table string
+	fk    string
+	key   string
+}
+
+func parseRelation(field reflect.StructField) (*relation, error) {
+	tag, ok := field.Tag.Lookup("relation")
+	if !ok {
+		return nil, fmt.Errorf("Field %q has no relation tag", field.Name)
+	}
+
+	options := strings.Split(tag, ",")
+
+	rel := &relation{
+		kind:  strings.TrimSpace(options[0]),
+		table: db.SnakeCase(field.Name),
+		key:   "id",
+	}
+
+	for _, option := range options[1:] {
+		chunks := strings.SplitN(option, "=", 2)
+		if len(chunks) != 2 {
+			return nil, fmt.Errorf("Invalid relation option %q on field %q", option, field.Name)
+		}
+		name, value := strings.TrimSpace(chunks[0]), strings.TrimSpace(chunks[1])
+		switch name {
+		case "fk":
+			rel.fk = value
+		case "table":
+			rel.table = value
+		case "key":
+			rel.key = value
+		default:
+			return nil, fmt.Errorf("Unknown relation option %q on field %q", name, field.Name)
+		}
+	}
+
+	if rel.kind != relationHasMany && rel.kind != relationBelongsTo {
+		return nil, fmt.Errorf("Unknown relation type %q on field %q", rel.kind, field.Name)
+	}
+	if rel.fk == "" {
+		return nil, fmt.Errorf("Missing fk option for relation on field %q", field.Name)
+	}
+
+	return rel, nil
+}
+
+// preload loads the named relations of the structs dst points to, dst can be
+// a pointer to a struct or a pointer to a slice of structs (or pointers to
+// structs). Each relation is loaded with a single query.
+func preload(ctx context.Context, builder sqlbuilder.SQLBuilder, dst interface{}, names []string) error {
+	items, itemT, err := preloadItems(dst)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		field, ok := itemT.FieldByName(name)
+		if !ok {
+			return fmt.Errorf("Preload: type %v has no field %q", itemT, name)
+		}
+
+		rel, err := parseRelation(field)
+		if err != nil {
+			return err
+		}
+
+		if len(items) == 0 {
+			continue
+		}
+
+		switch rel.kind {
+		case relationHasMany:
+			err = preloadHasMany(ctx, builder, items, field, rel)
+		case relationBelongsTo:
+			err = preloadBelongsTo(ctx, builder, items, field, rel)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func preloadHasMany(ctx context.Context, builder sqlbuilder.SQLBuilder, items []reflect.Value, field reflect.StructField, rel *relation) error {
+	if field.Type.Kind() != reflect.Slice {
+		return fmt.Errorf("Expecting field %q to be a slice (using has_many relation)", field.Name)
+	}
+
+	m := mapperOf(builder)
+
+	parents := map[string][]reflect.Value{}
+	keys := []interface{}{}
+
+	for _, item := range items {
+		item.FieldByIndex(field.Index).Set(reflect.MakeSlice(field.Type, 0, 0))
+
+		key, ok := relationKey(m, item, rel.key)
+		if !ok {
+			continue
+		}
+		k := fmt.Sprintf("%v", key)
+		if _, ok := parents[k]; !ok {
+			keys = append(keys, key)
+		}
+		parents[k] = append(parents[k], item)
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	related := reflect.New(field.Type)
+	if err := builder.SelectFrom(rel.table).Where(db.Cond{rel.fk: keys}).IteratorContext(ctx).All(related.Interface()); err != nil {
+		return err
+	}
+
+	related = related.Elem()
+	for i := 0; i < related.Len(); i++ {
+		child := related.Index(i)
+
+		key, ok := relationKey(m, child, rel.fk)
+		if !ok {
+			continue
+		}
+		for _, parent := range parents[fmt.Sprintf("%v", key)] {
+			children := parent.FieldByIndex(field.Index)
+			children.Set(reflect.Append(children, child))
+		}
+	}
+
+	return nil
+}
+
+func preloadBelongsTo(ctx context.Context, builder sqlbuilder.SQLBuilder, items []reflect.Value, field reflect.StructField, rel *relation) error {
+	parentT := field.Type
+	if parentT.Kind() == reflect.Ptr {
+		parentT = parentT.Elem()
+	}
+	if parentT.Kind() != reflect.Struct {
+		return fmt.Errorf("Expecting field %q to be a struct or a pointer to a struct (using belongs_to relation)", field.Name)
+	}
+
+	m := mapperOf(builder)
+
+	keys := []interface{}{}
+	seen := map[string]bool{}
+
+	for _, item := range items {
+		key, ok := relationKey(m, item, rel.fk)
+		if !ok {
+			continue
+		}
+		if k := fmt.Sprintf("%v", key); !seen[k] {
+			seen[k] = true
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	related := reflect.New(reflect.SliceOf(field.Type))
+	if err := builder.SelectFrom(rel.table).Where(db.Cond{rel.key: keys}).IteratorContext(ctx).All(related.Interface()); err != nil {
+		return err
+	}
+
+	related = related.Elem()
+
+	byKey := map[string]reflect.Value{}
+	for i := 0; i < related.Len(); i++ {
+		if key, ok := relationKey(m, related.Index(i), rel.key); ok {
+			byKey[fmt.Sprintf("%v", key)] = related.Index(i)
+		}
+	}
+
+	for _, item := range items {
+		key, ok := relationKey(m, item, rel.fk)
+		if !ok {
+			continue
+		}
+		if parent, ok := byKey[fmt.Sprintf("%v", key)]; ok {
+			item.FieldByIndex(field.Index).Set(parent)
+		}
+	}
+
+	return nil
+}
+
+// preloadItems returns the addressable structs dst points to and their type.
+func preloadItems(dst interface{}) ([]reflect.Value, reflect.Type, error) {
+	dstV := reflect.ValueOf(dst)
+	if dstV.Kind() != reflect.Ptr || dstV.IsNil() {
+		return nil, nil, fmt.Errorf("Expecting a pointer but got %T", dst)
+	}
+
+	dstV = dstV.Elem()
+
+	if dstV.Kind() == reflect.Struct {
+		return []reflect.Value{dstV}, dstV.Type(), nil
+	}
+
+	if dstV.Kind() != reflect.Slice {
+		return nil, nil, fmt.Errorf("Expecting a pointer to a struct or to a slice of structs but got %T", dst)
+	}
+
+	itemT := dstV.Type().Elem()
+	if itemT.Kind() == reflect.Ptr {
+		itemT = itemT.Elem()
+	}
+	if itemT.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("Expecting a pointer to a struct or to a slice of structs but got %T", dst)
+	}
+
+	items := make([]reflect.Value, 0, dstV.Len())
+	for i := 0; i < dstV.Len(); i++ {
+		item := dstV.Index(i)
+		if item.Kind() == reflect.Ptr {
+			if item.IsNil() {
+				continue
+			}
+			item = item.Elem()
+		}
+		items = append(items, item)
+	}
+
+	return items, itemT, nil
+}
+
+// relationKey returns the value of the given column on the given struct, nil
+// values are reported as missing.
+func relationKey(m *reflectx.Mapper, item reflect.Value, column string) (interface{}, bool) {
+	item = reflect.Indirect(item)
+	if !item.IsValid() {
+		return nil, false
+	}
+
+	fi, ok := m.TypeMap(item.Type()).Names[column]
+	if !ok {
+		return nil, false
+	}
+
+	v := reflectx.FieldByIndexesReadOnly(item, fi.Index)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+
+	value := v.Interface()
+	if valuer, ok := value.(driver.Valuer); ok {
+		var err error
+		if value, err = valuer.Value(); err != nil || value == nil {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
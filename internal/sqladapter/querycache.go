@@ -0,0 +1,70 @@
+package sqladapter
+
+import (
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/exql"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// ResultCache returns the store the queries marked with Cache keep their
+// results in. Transactions neither read nor write cached results, so it's nil
+// within them.
+func (d *database) ResultCache() db.QueryCache {
+	if d.Transaction() != nil {
+		return nil
+	}
+	return d.QueryCache()
+}
+
+// invalidateQueryCache expires the cached results that depend on the table
+// written by stmt, raw SQL statements expire every cached result. Within
+// transactions results are expired once the transaction is committed.
+func (d *database) invalidateQueryCache(stmt *exql.Statement) {
+	if d.QueryCache() == nil {
+		return
+	}
+
+	var tables []string
+	switch stmt.Type {
+	case exql.Insert, exql.Update, exql.Delete, exql.Merge, exql.Truncate, exql.DropTable:
+		if t, ok := stmt.Table.(*exql.Table); ok {
+			if name, ok := t.Name.(string); ok {
+				tables = []string{name}
+			}
+		}
+	case exql.SQL:
+	default:
+		return
+	}
+
+	if d.Transaction() != nil {
+		d.sessMu.Lock()
+		if tables == nil {
+			d.txInvalidateAll = true
+		}
+		d.txInvalidated = append(d.txInvalidated, tables...)
+		d.sessMu.Unlock()
+		return
+	}
+
+	sqlbuilder.InvalidateQueryCache(d.QueryCache(), tables...)
+}
+
+// commitQueryCache expires the cached results that depend on the tables
+// written by the transaction of the session.
+func (d *database) commitQueryCache() {
+	d.sessMu.Lock()
+	all, tables := d.txInvalidateAll, d.txInvalidated
+	d.txInvalidateAll, d.txInvalidated = false, nil
+	d.sessMu.Unlock()
+
+	cache := d.QueryCache()
+	if cache == nil || (!all && len(tables) == 0) {
+		return
+	}
+	if all {
+		sqlbuilder.InvalidateQueryCache(cache)
+		return
+	}
+	sqlbuilder.InvalidateQueryCache(cache, tables...)
+}
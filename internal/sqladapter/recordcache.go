@@ -0,0 +1,303 @@
+package sqladapter
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+// Keys of the record cache, records are kept under the generation of their
+// collection, which is renewed to drop all the records of the collection at
+// once.
+const (
+	recordKeyPrefix     = "upper:record:"
+	recordGenerationKey = "upper:recgen:"
+)
+
+// recordAnyTable is the name the generation of all the collections is kept
+// under, it's renewed when the written tables are unknown, like with raw SQL.
+const recordAnyTable = "*"
+
+// recordCache keeps the items read by primary key lookups, like
+// col.Find(id).One(&item). Every session has its own recordCache, sessions
+// within transactions don't read cached items and only drop the ones they
+// modify once the transaction is committed.
+type recordCache struct {
+	store db.QueryCache
+	ttl   time.Duration
+
+	metrics *metrics
+	sess    *database
+
+	mu      sync.Mutex
+	pending []func()
+}
+
+// recordEntry is the marshaled form of a cached item, items are only read
+// into destinations of the type they were cached with.
+type recordEntry struct {
+	Type string
+	Data []byte
+}
+
+// SetRecordCache makes the primary key lookups of the session read items from
+// store, items are kept for the given time. A nil store disables the record
+// cache.
+func (d *database) SetRecordCache(store db.QueryCache, ttl time.Duration) {
+	var rc *recordCache
+	if store != nil {
+		rc = &recordCache{store: store, ttl: ttl, metrics: d.metrics, sess: d}
+	}
+	d.sessMu.Lock()
+	d.recordCache = rc
+	d.sessMu.Unlock()
+}
+
+// InvalidateRecordCache drops all the cached items of the given collection.
+// Writes made through the session, including the ones made with the SQL
+// builder or raw SQL, keep the record cache up to date, this is only required
+// when rows are modified by other sessions.
+func (d *database) InvalidateRecordCache(collection string) {
+	if rc := d.records(); rc != nil {
+		rc.invalidate(d.qualifiedName(collection))
+	}
+}
+
+func (d *database) records() *recordCache {
+	d.sessMu.Lock()
+	defer d.sessMu.Unlock()
+	return d.recordCache
+}
+
+// recordID returns the value an item is cached by, only scalar primary key
+// values are supported.
+func recordID(value interface{}) (string, bool) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", false
+		}
+		value = v
+	}
+	switch value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, string:
+		return fmt.Sprintf("%v", value), true
+	case []byte:
+		return fmt.Sprintf("%s", value), true
+	}
+	return "", false
+}
+
+func (rc *recordCache) inTx() bool {
+	return rc.sess.Transaction() != nil
+}
+
+type managedRecordsKey struct{}
+
+// withManagedRecords returns a copy of ctx for statements whose changes to the
+// record cache are made by the caller, like the ones of result sets that
+// modify a single item.
+func withManagedRecords(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, managedRecordsKey{}, true)
+}
+
+// invalidateRecordCache drops the cached items of the table written by the
+// given statement. Plain inserts are ignored, as they can't modify the items
+// that were already cached.
+func (d *database) invalidateRecordCache(ctx context.Context, stmt *exql.Statement) {
+	rc := d.records()
+	if rc == nil {
+		return
+	}
+	if ctx != nil && ctx.Value(managedRecordsKey{}) != nil {
+		return
+	}
+
+	switch stmt.Type {
+	case exql.Insert:
+		if stmt.OnConflict == nil {
+			return
+		}
+	case exql.Update, exql.Delete, exql.Merge, exql.Truncate, exql.DropTable:
+	case exql.SQL:
+		rc.invalidate(recordAnyTable)
+		return
+	default:
+		return
+	}
+
+	t, ok := stmt.Table.(*exql.Table)
+	if !ok {
+		rc.invalidate(recordAnyTable)
+		return
+	}
+	name, ok := t.Name.(string)
+	if fields := strings.Fields(name); ok && len(fields) > 0 {
+		// The statement may name the table either as the collection does or
+		// without the schema of the session.
+		rc.invalidate(fields[0])
+		if qualified := d.qualifiedName(fields[0]); qualified != fields[0] {
+			rc.invalidate(qualified)
+		}
+		return
+	}
+	rc.invalidate(recordAnyTable)
+}
+
+func (rc *recordCache) key(table string, id string) string {
+	return recordKeyPrefix + table + ":" +
+		hex.EncodeToString(rc.generation(table)) + hex.EncodeToString(rc.generation(recordAnyTable)) +
+		":" + id
+}
+
+func (rc *recordCache) generation(table string) []byte {
+	gen, ok := rc.store.Get(recordGenerationKey + table)
+	if !ok {
+		gen = newRecordGeneration()
+		rc.store.Set(recordGenerationKey+table, gen, 0)
+	}
+	return gen
+}
+
+func newRecordGeneration() []byte {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return []byte(time.Now().Format(time.RFC3339Nano))
+	}
+	return buf
+}
+
+func recordType(dst interface{}) string {
+	t := reflect.TypeOf(dst)
+	return t.PkgPath() + "." + t.String()
+}
+
+// read reads the cached item with the given ID into dst, it returns false if
+// there's no such item.
+func (rc *recordCache) read(table string, id string, dst interface{}) bool {
+	if rc.inTx() {
+		return false
+	}
+
+	data, ok := rc.store.Get(rc.key(table, id))
+	if ok {
+		var entry recordEntry
+		err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entry)
+		if err == nil && entry.Type == recordType(dst) {
+			// Zero values are not encoded, so items are decoded into a new
+			// value instead of dst.
+			item := reflect.New(reflect.TypeOf(dst).Elem())
+			if err := gob.NewDecoder(bytes.NewReader(entry.Data)).Decode(item.Interface()); err == nil {
+				reflect.ValueOf(dst).Elem().Set(item.Elem())
+				rc.metrics.observeRecordCache(true)
+				return true
+			}
+		}
+	}
+
+	rc.metrics.observeRecordCache(false)
+	return false
+}
+
+// keep caches an item that was read from the database, items are not cached
+// within transactions.
+func (rc *recordCache) keep(table string, id string, item interface{}) {
+	if rc.inTx() {
+		return
+	}
+	rc.set(table, id, item)
+}
+
+// write caches an item that was modified, within transactions the cached item
+// is dropped once the transaction is committed instead.
+func (rc *recordCache) write(table string, id string, item interface{}) {
+	if rc.inTx() {
+		rc.evict(table, id)
+		return
+	}
+	rc.set(table, id, item)
+}
+
+// set caches the item with the given ID, items that can't be encoded are
+// dropped from the cache.
+func (rc *recordCache) set(table string, id string, item interface{}) {
+	key := rc.key(table, id)
+
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(item); err != nil {
+		rc.store.Delete(key)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(recordEntry{Type: recordType(item), Data: data.Bytes()}); err != nil {
+		rc.store.Delete(key)
+		return
+	}
+	rc.store.Set(key, buf.Bytes(), rc.ttl)
+}
+
+// evict drops the cached item with the given ID.
+func (rc *recordCache) evict(table string, id string) {
+	rc.run(func() {
+		rc.store.Delete(rc.key(table, id))
+	})
+}
+
+// invalidate drops all the cached items of the given table.
+func (rc *recordCache) invalidate(table string) {
+	rc.run(func() {
+		rc.store.Set(recordGenerationKey+table, newRecordGeneration(), 0)
+	})
+}
+
+// run runs fn, or keeps it until the transaction of the session is
+// committed.
+func (rc *recordCache) run(fn func()) {
+	if !rc.inTx() {
+		fn()
+		return
+	}
+	rc.mu.Lock()
+	rc.pending = append(rc.pending, fn)
+	rc.mu.Unlock()
+}
+
+// commit runs the changes that were kept until the transaction of the
+// session was committed.
+func (rc *recordCache) commit() {
+	rc.mu.Lock()
+	pending := rc.pending
+	rc.pending = nil
+	rc.mu.Unlock()
+
+	for _, fn := range pending {
+		fn()
+	}
+}
+
+func (m *metrics) observeRecordCache(hit bool) {
+	if m == nil {
+		return
+	}
+	if hit {
+		atomic.AddUint64(&m.recordCacheHits, 1)
+		return
+	}
+	atomic.AddUint64(&m.recordCacheMisses, 1)
+}
@@ -0,0 +1,192 @@
+//go:build go1.10
+// +build go1.10
+
+package sqladapter
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/exql"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+func TestRecordID(t *testing.T) {
+	for value, expected := range map[interface{}]string{
+		int64(10): "10",
+		uint(10):  "10",
+		"abc":     "abc",
+	} {
+		id, ok := recordID(value)
+		assert.True(t, ok)
+		assert.Equal(t, expected, id)
+	}
+
+	id, ok := recordID(sql.NullInt64{Int64: 3, Valid: true})
+	assert.True(t, ok)
+	assert.Equal(t, "3", id)
+
+	for _, value := range []interface{}{nil, []int{1, 2}, db.Cond{"id": 1}, sql.NullInt64{}} {
+		_, ok := recordID(value)
+		assert.False(t, ok, "%v", value)
+	}
+}
+
+func TestRecordCacheHitRatio(t *testing.T) {
+	assert.Equal(t, float64(0), db.Metrics{}.RecordCacheHitRatio())
+	assert.Equal(t, 0.75, db.Metrics{RecordCacheHits: 3, RecordCacheMisses: 1}.RecordCacheHitRatio())
+}
+
+// execPartialDatabase runs the statements of the SQL builder without sending
+// them to a database.
+type execPartialDatabase struct {
+	rawPartialDatabase
+	sqlbuilder.SQLBuilder
+}
+
+func (execPartialDatabase) StatementExec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func TestRecordCacheInvalidation(t *testing.T) {
+	d := &database{
+		Settings: db.NewSettings(),
+		sess:     sql.OpenDB(&switchConnector{}),
+		metrics:  newMetrics(),
+	}
+	defer d.sess.Close()
+	d.PartialDatabase = execPartialDatabase{SQLBuilder: sqlbuilder.WithSession(d, exql.DefaultTemplate())}
+	d.SetRecordCache(db.NewMemoryQueryCache(100), time.Hour)
+
+	type artist struct {
+		ID   int64
+		Name string
+	}
+
+	find := func(id string) error {
+		var item artist
+		return NewResult(d, "artist", []interface{}{db.Cond{"id": id}}).record(id).One(&item)
+	}
+	keep := func(table string, id string) {
+		d.records().keep(table, id, &artist{Name: "Ozzie"})
+	}
+
+	keep("artist", "1")
+	keep("artist", "2")
+	assert.NoError(t, find("1"))
+	assert.NoError(t, find("2"))
+
+	// The primary key lookup is served from the record cache until the row is
+	// modified by other means than the collection, like the SQL builder.
+	_, err := d.Update("artist").Set("name", "Ozzy").Where("id", 1).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, errServerDown, find("1"))
+	assert.Equal(t, errServerDown, find("2"))
+
+	// Plain inserts can't modify cached items.
+	keep("artist", "1")
+	_, err = d.InsertInto("artist").Values(map[string]interface{}{"name": "Lila Downs"}).Exec()
+	assert.NoError(t, err)
+	assert.NoError(t, find("1"))
+
+	// Other tables are not affected.
+	_, err = d.DeleteFrom("publication").Where("author_id", 1).Exec()
+	assert.NoError(t, err)
+	assert.NoError(t, find("1"))
+
+	// Raw SQL may modify any table.
+	keep("publication", "3")
+	_, err = d.StatementExec(context.Background(), exql.RawSQL("UPDATE artist SET name = 'Ozzy'"))
+	assert.NoError(t, err)
+	assert.Equal(t, errServerDown, find("1"))
+	assert.False(t, d.records().read("publication", "3", &artist{}))
+
+	// Changes made by result sets to single items update the cache themselves.
+	keep("artist", "1")
+	keep("artist", "2")
+	assert.NoError(t, NewResult(d, "artist", []interface{}{db.Cond{"id": "1"}}).record("1").Delete())
+	assert.Equal(t, errServerDown, find("1"))
+	assert.NoError(t, find("2"))
+}
+
+// rowsConnector opens connections that run every query, returning no rows.
+type rowsConnector struct{}
+
+func (rowsConnector) Connect(context.Context) (driver.Conn, error) {
+	return rowsConn{}, nil
+}
+
+func (rowsConnector) Driver() driver.Driver {
+	return nil
+}
+
+type rowsConn struct {
+	stubConn
+}
+
+func (rowsConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string {
+	return []string{"id"}
+}
+
+func (emptyRows) Close() error {
+	return nil
+}
+
+func (emptyRows) Next([]driver.Value) error {
+	return io.EOF
+}
+
+func TestRecordCacheInvalidationByQuery(t *testing.T) {
+	d := &database{
+		Settings: db.NewSettings(),
+		sess:     sql.OpenDB(rowsConnector{}),
+		metrics:  newMetrics(),
+	}
+	defer d.sess.Close()
+	d.PartialDatabase = execPartialDatabase{SQLBuilder: sqlbuilder.WithSession(d, exql.DefaultTemplate())}
+	d.SetRecordCache(db.NewMemoryQueryCache(100), time.Hour)
+
+	type artist struct {
+		ID   int64
+		Name string
+	}
+
+	ctx := context.Background()
+	find := func(id string) error {
+		var item artist
+		return NewResult(d, "artist", []interface{}{db.Cond{"id": id}}).record(id).One(&item)
+	}
+	keep := func(id string) {
+		d.records().keep("artist", id, &artist{Name: "Ozzie"})
+	}
+
+	// Raw reads leave the cache alone.
+	keep("1")
+	rows, err := d.StatementQuery(ctx, exql.RawSQL("SELECT id FROM artist"))
+	assert.NoError(t, err)
+	assert.NoError(t, rows.Close())
+	assert.NoError(t, find("1"))
+
+	// Raw writes that return rows are writes too.
+	rows, err = d.StatementQuery(ctx, exql.RawSQL("UPDATE artist SET name = 'Ozzy' WHERE id = 1 RETURNING id"))
+	assert.NoError(t, err)
+	assert.NoError(t, rows.Close())
+	assert.Error(t, find("1"))
+
+	keep("1")
+	_, err = d.StatementQueryRow(ctx, exql.RawSQL("DELETE FROM artist WHERE id = 1 RETURNING id"))
+	assert.NoError(t, err)
+	assert.Error(t, find("1"))
+}
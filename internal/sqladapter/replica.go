@@ -0,0 +1,83 @@
+package sqladapter
+
+import (
+	"database/sql"
+	"sync/atomic"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+// BindReplicas sets the read replicas of the session, SELECT statements that
+// run outside transactions are sent to them instead of the primary server.
+func (d *database) BindReplicas(replicas ...*sql.DB) error {
+	for _, replica := range replicas {
+		if err := replica.Ping(); err != nil {
+			return err
+		}
+		replica.SetConnMaxLifetime(d.ConnMaxLifetime())
+		replica.SetMaxIdleConns(d.MaxIdleConns())
+		replica.SetMaxOpenConns(d.MaxOpenConns())
+	}
+
+	d.sessMu.Lock()
+	d.replicas = replicas
+	d.sessMu.Unlock()
+
+	return nil
+}
+
+// Replicas returns the read replicas of the session.
+func (d *database) Replicas() []*sql.DB {
+	d.sessMu.Lock()
+	defer d.sessMu.Unlock()
+	return d.replicas
+}
+
+// ForcePrimary sets whether the session sends all statements to the primary
+// server, even if it has read replicas.
+func (d *database) ForcePrimary(value bool) {
+	d.sessMu.Lock()
+	d.forcePrimary = value
+	d.sessMu.Unlock()
+}
+
+// replica returns the read replica the given statement has to be sent to, or
+// nil if it has to be sent to the primary server. SELECT statements that lock
+// rows always go to the primary server.
+func (d *database) replica(stmt *exql.Statement) *sql.DB {
+	if stmt.Type != exql.Select || stmt.Lock != nil || d.Transaction() != nil {
+		return nil
+	}
+
+	d.sessMu.Lock()
+	replicas, forcePrimary := d.replicas, d.forcePrimary
+	d.sessMu.Unlock()
+
+	if forcePrimary || len(replicas) == 0 {
+		return nil
+	}
+
+	if d.ReplicaPolicy() == db.LeastConns {
+		replica, inUse := replicas[0], replicas[0].Stats().InUse
+		for _, r := range replicas[1:] {
+			if n := r.Stats().InUse; n < inUse {
+				replica, inUse = r, n
+			}
+		}
+		return replica
+	}
+
+	n := atomic.AddUint64(&d.replicaIndex, 1)
+	return replicas[(n-1)%uint64(len(replicas))]
+}
+
+func closeReplicas(replicas []*sql.DB) error {
+	var err error
+	for _, replica := range replicas {
+		if rerr := replica.Close(); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,34 @@
+package sqladapter
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+func TestReplica(t *testing.T) {
+	replicas := []*sql.DB{{}, {}, {}}
+
+	d := &database{Settings: db.NewSettings(), replicas: replicas}
+
+	selectStmt := &exql.Statement{Type: exql.Select}
+	for i := 0; i < 6; i++ {
+		assert.True(t, replicas[i%3] == d.replica(selectStmt))
+	}
+
+	assert.Nil(t, d.replica(&exql.Statement{Type: exql.Update}))
+	assert.Nil(t, d.replica(&exql.Statement{Type: exql.SQL}))
+	assert.Nil(t, d.replica(&exql.Statement{Type: exql.Select, Lock: &exql.Lock{}}))
+	assert.Nil(t, d.replica(&exql.Statement{Type: exql.Select, Lock: &exql.Lock{Share: true, SkipLocked: true}}))
+
+	d.SetReplicaPolicy(db.LeastConns)
+	assert.True(t, replicas[0] == d.replica(selectStmt))
+
+	d.ForcePrimary(true)
+	assert.Nil(t, d.replica(selectStmt))
+
+	assert.Nil(t, (&database{Settings: db.NewSettings()}).replica(selectStmt))
+}
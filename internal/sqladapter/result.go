@@ -22,14 +22,22 @@
 package sqladapter
 
 import (
+	"context"
+	"fmt"
+	"reflect"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"upper.io/db.v3"
 	"upper.io/db.v3/internal/immutable"
 	"upper.io/db.v3/lib/sqlbuilder"
 )
 
+type hasContext interface {
+	Context() context.Context
+}
+
 type Result struct {
 	builder sqlbuilder.SQLBuilder
 
@@ -44,14 +52,42 @@ type Result struct {
 
 // result represents a delimited set of items bound by a condition.
 type result struct {
-	table   string
-	limit   int
-	offset  int
-	fields  []interface{}
-	columns []interface{}
-	orderBy []interface{}
-	groupBy []interface{}
-	conds   [][]interface{}
+	table    string
+	limit    int
+	offset   int
+	fields   []interface{}
+	columns  []interface{}
+	orderBy  []interface{}
+	groupBy  []interface{}
+	conds    [][]interface{}
+	preload  []string
+	model    interface{}
+	unscoped bool
+
+	counter *counter
+
+	// recordID is the primary key value of the only item of the set, if the
+	// set was created by a primary key lookup that can use the record cache.
+	recordID string
+}
+
+// counter remembers the number of items of a result set, see CacheCount.
+type counter struct {
+	mu      sync.Mutex
+	count   uint64
+	counted bool
+}
+
+func (c *counter) get() (uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.count, c.counted
+}
+
+func (c *counter) set(count uint64) {
+	c.mu.Lock()
+	c.count, c.counted = count, true
+	c.mu.Unlock()
 }
 
 func filter(conds []interface{}) []interface{} {
@@ -88,10 +124,44 @@ func (r *Result) from(table string) *Result {
 func (r *Result) where(conds []interface{}) *Result {
 	return r.frame(func(res *result) error {
 		res.conds = [][]interface{}{conds}
+		res.counter = nil
+		res.recordID = ""
+		return nil
+	})
+}
+
+// record marks the result set as a primary key lookup of the item with the
+// given ID.
+func (r *Result) record(id string) *Result {
+	return r.frame(func(res *result) error {
+		res.recordID = id
 		return nil
 	})
 }
 
+func (r *Result) model(item interface{}) *Result {
+	return r.frame(func(res *result) error {
+		res.model = item
+		return nil
+	})
+}
+
+// session returns the session the result set uses, it is passed to item
+// hooks.
+func (r *Result) session() db.Database {
+	sess, _ := r.SQLBuilder().(db.Database)
+	return sess
+}
+
+// context returns the context of the session the result set uses, queries
+// that are not given a context run within it.
+func (r *Result) context() context.Context {
+	if sess, ok := r.SQLBuilder().(hasContext); ok {
+		return sess.Context()
+	}
+	return context.Background()
+}
+
 func (r *Result) setErr(err error) error {
 	if err == nil {
 		return nil
@@ -118,6 +188,8 @@ func (r *Result) Where(conds ...interface{}) db.Result {
 func (r *Result) And(conds ...interface{}) db.Result {
 	return r.frame(func(res *result) error {
 		res.conds = append(res.conds, conds)
+		res.counter = nil
+		res.recordID = ""
 		return nil
 	})
 }
@@ -126,6 +198,7 @@ func (r *Result) And(conds ...interface{}) db.Result {
 func (r *Result) Limit(n int) db.Result {
 	return r.frame(func(res *result) error {
 		res.limit = n
+		res.recordID = ""
 		return nil
 	})
 }
@@ -135,6 +208,7 @@ func (r *Result) Limit(n int) db.Result {
 func (r *Result) Offset(n int) db.Result {
 	return r.frame(func(res *result) error {
 		res.offset = n
+		res.recordID = ""
 		return nil
 	})
 }
@@ -144,6 +218,8 @@ func (r *Result) Offset(n int) db.Result {
 func (r *Result) Group(fields ...interface{}) db.Result {
 	return r.frame(func(res *result) error {
 		res.groupBy = fields
+		res.counter = nil
+		res.recordID = ""
 		return nil
 	})
 }
@@ -162,13 +238,44 @@ func (r *Result) OrderBy(fields ...interface{}) db.Result {
 func (r *Result) Select(fields ...interface{}) db.Result {
 	return r.frame(func(res *result) error {
 		res.fields = fields
+		res.recordID = ""
+		return nil
+	})
+}
+
+// Preload loads the given relations of the items that are fetched by All()
+// or One().
+func (r *Result) Preload(relations ...string) db.Result {
+	return r.frame(func(res *result) error {
+		res.preload = append(res.preload, relations...)
+		return nil
+	})
+}
+
+// Unscoped makes the result set include soft deleted items and makes
+// Delete() remove items permanently.
+func (r *Result) Unscoped() db.Result {
+	return r.frame(func(res *result) error {
+		res.unscoped = true
+		res.counter = nil
+		res.recordID = ""
+		return nil
+	})
+}
+
+// CacheCount makes the result set, and the ones derived from it, remember the
+// number of items the first successful Count() call returns.
+func (r *Result) CacheCount() db.Result {
+	c := &counter{}
+	return r.frame(func(res *result) error {
+		res.counter = c
 		return nil
 	})
 }
 
 // String satisfies fmt.Stringer
 func (r *Result) String() string {
-	query, err := r.buildSelect()
+	query, err := r.buildSelect(nil)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -177,22 +284,94 @@ func (r *Result) String() string {
 
 // All dumps all Results into a pointer to an slice of structs or maps.
 func (r *Result) All(dst interface{}) error {
-	query, err := r.buildSelect()
+	return r.AllContext(r.context(), dst)
+}
+
+// AllContext is like All, but the query runs within the given context.
+func (r *Result) AllContext(ctx context.Context, dst interface{}) error {
+	query, err := r.buildSelect(dst)
 	if err != nil {
 		return r.setErr(err)
 	}
-	err = query.Iterator().All(dst)
-	return r.setErr(err)
+	if err = query.IteratorContext(ctx).All(dst); err != nil {
+		return r.setErr(err)
+	}
+	return r.setErr(r.preload(ctx, dst))
 }
 
 // One fetches only one Result from the set.
 func (r *Result) One(dst interface{}) error {
-	query, err := r.buildSelect()
+	return r.OneContext(r.context(), dst)
+}
+
+// OneContext is like One, but the query runs within the given context.
+func (r *Result) OneContext(ctx context.Context, dst interface{}) error {
+	res, err := r.fastForward()
 	if err != nil {
 		return r.setErr(err)
 	}
-	err = query.Iterator().One(dst)
-	return r.setErr(err)
+
+	rc := r.records(res)
+	if rc != nil && rc.read(res.table, res.recordID, dst) {
+		return r.setErr(r.preload(ctx, dst))
+	}
+
+	query, err := r.buildSelect(dst)
+	if err != nil {
+		return r.setErr(err)
+	}
+	if err = query.IteratorContext(ctx).One(dst); err != nil {
+		return r.setErr(err)
+	}
+	if rc != nil {
+		rc.keep(res.table, res.recordID, dst)
+	}
+	return r.setErr(r.preload(ctx, dst))
+}
+
+// records returns the record cache of the session if the result set is a
+// primary key lookup that can use it.
+func (r *Result) records(res *result) *recordCache {
+	if res.recordID == "" {
+		return nil
+	}
+	if sess, ok := r.SQLBuilder().(BaseDatabase); ok {
+		return sess.records()
+	}
+	return nil
+}
+
+// forget updates the record cache of the session after the items of the set
+// were modified, item is the updated item, if known.
+func (r *Result) forget(res *result, item interface{}) {
+	sess, ok := r.SQLBuilder().(BaseDatabase)
+	if !ok {
+		return
+	}
+	rc := sess.records()
+	if rc == nil {
+		return
+	}
+	switch {
+	case res.recordID == "":
+		rc.invalidate(res.table)
+	case item != nil:
+		rc.write(res.table, res.recordID, item)
+	default:
+		rc.evict(res.table, res.recordID)
+	}
+}
+
+// preload loads the relations that were requested with Preload() into dst.
+func (r *Result) preload(ctx context.Context, dst interface{}) error {
+	res, err := r.fastForward()
+	if err != nil {
+		return err
+	}
+	if len(res.preload) == 0 {
+		return nil
+	}
+	return preload(ctx, r.SQLBuilder(), dst, res.preload)
 }
 
 // Next fetches the next Result from the set.
@@ -201,7 +380,7 @@ func (r *Result) Next(dst interface{}) bool {
 	defer r.iterMu.Unlock()
 
 	if r.iter == nil {
-		query, err := r.buildSelect()
+		query, err := r.buildSelect(dst)
 		if err != nil {
 			r.setErr(err)
 			return false
@@ -222,13 +401,67 @@ func (r *Result) Next(dst interface{}) bool {
 
 // Delete deletes all matching items from the collection.
 func (r *Result) Delete() error {
+	return r.DeleteContext(r.context())
+}
+
+// DeleteContext is like Delete, but the statement runs within the given
+// context.
+func (r *Result) DeleteContext(ctx context.Context) error {
 	query, err := r.buildDelete()
 	if err != nil {
 		return r.setErr(err)
 	}
 
-	_, err = query.Exec()
-	return r.setErr(err)
+	res, err := r.fastForward()
+	if err != nil {
+		return r.setErr(err)
+	}
+	if res.recordID != "" {
+		ctx = withManagedRecords(ctx)
+	}
+	if hook, ok := res.model.(db.BeforeDeleteHook); ok {
+		if err := hook.BeforeDelete(r.session()); err != nil {
+			return r.setErr(err)
+		}
+	}
+
+	if column := res.softDeleteColumn(nil); column != "" {
+		err = r.softDelete(ctx, res, column)
+	} else {
+		_, err = query.ExecContext(ctx)
+	}
+	if err != nil {
+		return r.setErr(err)
+	}
+
+	r.forget(res, nil)
+	return nil
+}
+
+// softDelete marks the item of the result set as deleted by setting the given
+// column to the current time.
+func (r *Result) softDelete(ctx context.Context, res *result, column string) error {
+	fld, ok := timestampField(res.model, column)
+	if !ok {
+		return fmt.Errorf("Expecting field %q to be a time.Time, a *time.Time or an integer (using softdelete option)", column)
+	}
+
+	now := reflect.New(fld.Type()).Elem()
+	setTimestamp(now, time.Now())
+
+	upd := r.SQLBuilder().Update(res.table).Set(column, now.Interface())
+	for i := range res.conds {
+		upd = upd.And(filter(res.conds[i])...)
+	}
+
+	if _, err := upd.ExecContext(ctx); err != nil {
+		return err
+	}
+
+	if fld.CanSet() {
+		fld.Set(now)
+	}
+	return nil
 }
 
 // Close closes the Result set.
@@ -242,36 +475,119 @@ func (r *Result) Close() error {
 // Update updates matching items from the collection with values of the given
 // map or struct.
 func (r *Result) Update(values interface{}) error {
+	return r.UpdateContext(r.context(), values)
+}
+
+// UpdateContext is like Update, but the statement runs within the given
+// context.
+func (r *Result) UpdateContext(ctx context.Context, values interface{}) error {
+	return r.updateReturning(ctx, values, nil)
+}
+
+// updateReturning updates the elements on the set with the given values, the
+// updated row is read into dst using a RETURNING clause if dst is not nil.
+func (r *Result) updateReturning(ctx context.Context, values interface{}, dst interface{}) error {
+	if sess := r.session(); autoTimestamps(sess) {
+		setTimestamps(values, false)
+	}
+
+	if hook, ok := values.(db.BeforeUpdateHook); ok {
+		if err := hook.BeforeUpdate(r.session()); err != nil {
+			return r.setErr(err)
+		}
+	}
+
 	query, err := r.buildUpdate(values)
 	if err != nil {
 		return r.setErr(err)
 	}
 
-	_, err = query.Exec()
-	return r.setErr(err)
+	res, err := r.fastForward()
+	if err != nil {
+		return r.setErr(err)
+	}
+	if res.recordID != "" {
+		ctx = withManagedRecords(ctx)
+	}
+
+	if dst != nil {
+		err = query.Returning("*").IteratorContext(ctx).One(dst)
+	} else {
+		_, err = query.ExecContext(ctx)
+	}
+	if err != nil {
+		return r.setErr(constraintError(values, err))
+	}
+
+	r.forget(res, dst)
+
+	if hook, ok := values.(db.AfterUpdateHook); ok {
+		return r.setErr(hook.AfterUpdate(r.session()))
+	}
+	return nil
 }
 
 // Count counts the elements on the set.
 func (r *Result) Count() (uint64, error) {
-	query, err := r.buildCount()
+	return r.CountContext(r.context())
+}
+
+// CountContext is like Count, but the query runs within the given context.
+func (r *Result) CountContext(ctx context.Context) (uint64, error) {
+	query, res, err := r.buildCount()
 	if err != nil {
 		return 0, r.setErr(err)
 	}
 
+	if res.counter != nil {
+		if count, ok := res.counter.get(); ok {
+			return count, nil
+		}
+	}
+
 	counter := struct {
 		Count uint64 `db:"_t"`
 	}{}
-	if err := query.Iterator().One(&counter); err != nil {
-		if err == db.ErrNoMoreRows {
-			return 0, nil
+	if err := query.IteratorContext(ctx).One(&counter); err != nil {
+		if err != db.ErrNoMoreRows {
+			return 0, r.setErr(err)
 		}
-		return 0, r.setErr(err)
+	}
+
+	if res.counter != nil {
+		res.counter.set(counter.Count)
 	}
 
 	return counter.Count, nil
 }
 
-func (r *Result) buildSelect() (sqlbuilder.Selector, error) {
+// Exists returns true if at least one item matches the conditions of the set.
+func (r *Result) Exists() (bool, error) {
+	return r.ExistsContext(r.context())
+}
+
+// ExistsContext is like Exists, but the query runs within the given context.
+func (r *Result) ExistsContext(ctx context.Context) (bool, error) {
+	query, err := r.buildExists()
+	if err != nil {
+		return false, r.setErr(err)
+	}
+
+	var found int
+	if err := query.IteratorContext(ctx).ScanOne(&found); err != nil {
+		if err == db.ErrNoMoreRows {
+			return false, nil
+		}
+		return false, r.setErr(err)
+	}
+
+	return true, nil
+}
+
+// buildSelect builds the SELECT query for the result set, dst is the
+// destination the results are going to be fetched into (if known), it's used
+// to exclude soft deleted items.
+func (r *Result) buildSelect(dst interface{}) (sqlbuilder.Selector, error) {
 	if err := r.Err(); err != nil {
 		return nil, err
 	}
@@ -292,6 +608,10 @@ func (r *Result) buildSelect() (sqlbuilder.Selector, error) {
 		sel = sel.And(filter(res.conds[i])...)
 	}
 
+	if column := res.softDeleteColumn(dst); column != "" {
+		sel = sel.And(db.Cond{column: nil})
+	}
+
 	return sel, nil
 }
 
@@ -336,14 +656,14 @@ func (r *Result) buildUpdate(values interface{}) (sqlbuilder.Updater, error) {
 	return upd, nil
 }
 
-func (r *Result) buildCount() (sqlbuilder.Selector, error) {
+func (r *Result) buildCount() (sqlbuilder.Selector, *result, error) {
 	if err := r.Err(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	res, err := r.fastForward()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	sel := r.SQLBuilder().Select(db.Raw("count(1) AS _t")).
@@ -354,6 +674,38 @@ func (r *Result) buildCount() (sqlbuilder.Selector, error) {
 		sel = sel.And(filter(res.conds[i])...)
 	}
 
+	if column := res.softDeleteColumn(nil); column != "" {
+		sel = sel.And(db.Cond{column: nil})
+	}
+
+	return sel, res, nil
+}
+
+// buildExists builds a query that returns a single row if the set has any
+// items and no rows otherwise.
+func (r *Result) buildExists() (sqlbuilder.Selector, error) {
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	res, err := r.fastForward()
+	if err != nil {
+		return nil, err
+	}
+
+	sel := r.SQLBuilder().Select(db.Raw("1 AS _t")).
+		From(res.table).
+		GroupBy(res.groupBy...).
+		Limit(1)
+
+	for i := range res.conds {
+		sel = sel.And(filter(res.conds[i])...)
+	}
+
+	if column := res.softDeleteColumn(nil); column != "" {
+		sel = sel.And(db.Cond{column: nil})
+	}
+
 	return sel, nil
 }
 
@@ -0,0 +1,57 @@
+//go:build go1.10
+// +build go1.10
+
+package sqladapter
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+// rawPartialDatabase compiles statements into their raw SQL.
+type rawPartialDatabase struct {
+	PartialDatabase
+}
+
+func (rawPartialDatabase) CompileStatement(stmt *exql.Statement, args []interface{}) (string, []interface{}) {
+	return stmt.SQL, args
+}
+
+func (rawPartialDatabase) Err(err error) error {
+	return err
+}
+
+func TestStatementQueryRetry(t *testing.T) {
+	d := &database{
+		PartialDatabase: rawPartialDatabase{},
+		Settings:        db.NewSettings(),
+		sess:            sql.OpenDB(&switchConnector{}),
+		metrics:         newMetrics(),
+	}
+	defer d.sess.Close()
+
+	failures := 0
+	d.SetRetryPolicy(&db.RetryPolicy{
+		MaxAttempts: 3,
+		Retryable: func(err error) bool {
+			failures++
+			return err == errServerDown
+		},
+	})
+
+	ctx := context.Background()
+
+	_, err := d.StatementQuery(ctx, &exql.Statement{Type: exql.Select, SQL: "SELECT 1"})
+	assert.Equal(t, errServerDown, err)
+	assert.Equal(t, 2, failures)
+
+	failures = 0
+	_, err = d.StatementQuery(ctx, &exql.Statement{Type: exql.SQL, SQL: "SELECT 1"})
+	assert.Equal(t, errServerDown, err)
+	assert.Equal(t, 0, failures)
+}
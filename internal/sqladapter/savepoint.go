@@ -0,0 +1,91 @@
+package sqladapter
+
+import (
+	"time"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/compat"
+	"upper.io/db.v3/internal/sqladapter/exql"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// hasSavepoints is implemented by adapters that don't use the SAVEPOINT,
+// ROLLBACK TO SAVEPOINT and RELEASE SAVEPOINT statements.
+type hasSavepoints interface {
+	// SavepointStatements returns the statements that create the savepoint
+	// with the given name, roll back to it and release it. The release
+	// statement is empty if savepoints are only released with the
+	// transaction.
+	SavepointStatements(name string) (create, rollbackTo, release string)
+}
+
+func (w *databaseTx) savepointStatements(name string) (string, string, string) {
+	if sp, ok := w.Database.(hasSavepoints); ok {
+		return sp.SavepointStatements(name)
+	}
+	return "SAVEPOINT " + name, "ROLLBACK TO SAVEPOINT " + name, "RELEASE SAVEPOINT " + name
+}
+
+// validSavepointName reports whether name can be used as the name of a
+// savepoint without being quoted.
+func validSavepointName(name string) bool {
+	if name == "" || len(name) > 63 {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (w *databaseTx) Savepoint(name string) error {
+	create, _, _ := w.savepointStatements(name)
+	return w.execSavepoint(name, create)
+}
+
+func (w *databaseTx) RollbackTo(name string) error {
+	_, rollbackTo, _ := w.savepointStatements(name)
+	return w.execSavepoint(name, rollbackTo)
+}
+
+func (w *databaseTx) ReleaseSavepoint(name string) error {
+	_, _, release := w.savepointStatements(name)
+	return w.execSavepoint(name, release)
+}
+
+// execSavepoint runs a savepoint statement on the transaction, savepoint
+// statements don't modify tables so they don't expire cached results.
+func (w *databaseTx) execSavepoint(name string, query string) (err error) {
+	if !validSavepointName(name) {
+		return sqlbuilder.ErrInvalidSavepointName
+	}
+	if query == "" {
+		return nil
+	}
+
+	d := w.Database.base()
+	ctx := d.Context()
+	stmt := exql.RawSQL(query)
+
+	defer func(start time.Time) {
+		d.metrics.observeQuery(stmt, start, err)
+		if d.Settings.LoggingEnabled() {
+			db.LogQuery(ctx, d.Logger(), &db.QueryStatus{
+				TxID:   d.txID,
+				SessID: d.sessID,
+				Query:  query,
+				Err:    err,
+				Start:  start,
+				End:    time.Now(),
+			})
+		}
+	}(time.Now())
+
+	_, err = compat.ExecContext(w.BaseTx.(*baseTx), ctx, query, nil)
+	return d.classifyErr(err)
+}
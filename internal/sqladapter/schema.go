@@ -0,0 +1,109 @@
+package sqladapter
+
+import (
+	"strings"
+	"unicode"
+
+	"upper.io/db.v3"
+)
+
+// Schema returns the schema the collections of the session belong to, or an
+// empty string if they belong to the default one.
+func (d *database) Schema() string {
+	d.sessMu.Lock()
+	defer d.sessMu.Unlock()
+	return d.schema
+}
+
+// SetSchema sets the schema the collections of the session belong to.
+func (d *database) SetSchema(schema string) {
+	d.sessMu.Lock()
+	d.schema = schema
+	d.sessMu.Unlock()
+	d.cachedCollections.Clear()
+}
+
+// SchemaSession returns the session of d that resolves collections against
+// the given schema, newFn is called to create it the first time the schema is
+// requested. Schema sessions share the connection pool of d and are kept
+// until d is closed.
+func (d *database) SchemaSession(schema string, newFn func() (Database, error)) (Database, error) {
+	if !ValidSchemaName(schema) {
+		return nil, db.ErrInvalidSchemaName
+	}
+
+	d.schemaMu.Lock()
+	defer d.schemaMu.Unlock()
+
+	if sess, ok := d.schemaSessions[schema]; ok {
+		return sess, nil
+	}
+
+	sess, err := newFn()
+	if err != nil {
+		return nil, err
+	}
+	sess.SetSchema(schema)
+
+	if d.schemaSessions == nil {
+		d.schemaSessions = make(map[string]Database)
+	}
+	d.schemaSessions[schema] = sess
+
+	return sess, nil
+}
+
+// ValidSchemaName reports whether name can be used as a schema name, only
+// letters, digits, underscores, dollar signs and hyphens are allowed so the
+// name can be quoted safely by any adapter.
+func ValidSchemaName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			continue
+		}
+		if r != '_' && r != '$' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitTableName splits a table name like "schema.table" into its schema and
+// table parts, schema is empty if the name is not qualified.
+func SplitTableName(name string) (schema string, table string) {
+	if i := strings.Index(name, "."); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// SetTablePrefix sets the prefix that is added to the names of collections.
+func (d *database) SetTablePrefix(prefix string) {
+	d.Settings.SetTablePrefix(prefix)
+	d.cachedCollections.Clear()
+}
+
+// SetCollectionTablePrefix sets the prefix that is added to the name of the
+// given collection.
+func (d *database) SetCollectionTablePrefix(collection string, prefix string) {
+	d.Settings.SetCollectionTablePrefix(collection, prefix)
+	d.cachedCollections.Clear()
+}
+
+// qualifiedName returns the name of the table of a collection, with its
+// prefix and the schema of the session, if any. Prefixes are only added to
+// the table part of names like "schema.table".
+func (d *database) qualifiedName(name string) string {
+	if schema, table := SplitTableName(name); schema != "" {
+		name = schema + "." + d.TableName(table)
+	} else {
+		name = d.TableName(table)
+	}
+	if schema := d.Schema(); schema != "" {
+		return schema + "." + name
+	}
+	return name
+}
@@ -0,0 +1,26 @@
+package sqladapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidSchemaName(t *testing.T) {
+	for _, name := range []string{"tenant_123", "Tenant", "tenant-1", "$tenant", "tenánt"} {
+		assert.True(t, ValidSchemaName(name), name)
+	}
+	for _, name := range []string{"", "tenant.1", "tenant 1", `tenant"1`, "tenant`1", "[tenant]", "tenant;--"} {
+		assert.False(t, ValidSchemaName(name), name)
+	}
+}
+
+func TestSplitTableName(t *testing.T) {
+	schema, table := SplitTableName("tenant_1.artist")
+	assert.Equal(t, "tenant_1", schema)
+	assert.Equal(t, "artist", table)
+
+	schema, table = SplitTableName("artist")
+	assert.Equal(t, "", schema)
+	assert.Equal(t, "artist", table)
+}
@@ -0,0 +1,80 @@
+package sqladapter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"upper.io/db.v3"
+)
+
+// maxStackDepth is the number of frames captured for slow queries.
+const maxStackDepth = 32
+
+// observeSlowQuery calls the slow query handler of the session if the given
+// statement took longer than its threshold.
+func (d *database) observeSlowQuery(ctx context.Context, query string, args []interface{}, start time.Time, err error) {
+	threshold, handler := d.Settings.SlowQueryThreshold()
+	if threshold <= 0 || handler == nil || query == "" {
+		return
+	}
+
+	end := time.Now()
+	if end.Sub(start) < threshold {
+		return
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	handler(ctx, &db.SlowQuery{
+		QueryStatus: db.QueryStatus{
+			TxID:   d.txID,
+			SessID: d.sessID,
+			Query:  query,
+			Args:   args,
+			Err:    err,
+			Start:  start,
+			End:    end,
+		},
+		Threshold: threshold,
+		Stack:     callerStack(),
+	})
+}
+
+// callerStack formats the call stack of the current goroutine, leaving out
+// the frames of this package and the packages that call it, like the adapters
+// and the SQL builder.
+func callerStack() string {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var buf bytes.Buffer
+	caller := false
+	for {
+		frame, more := frames.Next()
+		if !caller && !internalFrame(frame) {
+			caller = true
+		}
+		if caller {
+			fmt.Fprintf(&buf, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func internalFrame(frame runtime.Frame) bool {
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	return strings.HasPrefix(frame.Function, "upper.io/db.v3.") ||
+		strings.HasPrefix(frame.Function, "upper.io/db.v3/")
+}
@@ -0,0 +1,56 @@
+package sqladapter
+
+import (
+	"reflect"
+
+	"upper.io/db.v3/lib/reflectx"
+)
+
+// softDeleteColumn returns the name of the column that marks items of the
+// result set as deleted, like `db:"deleted_at,softdelete"`. The column is
+// looked up on the type of dst or, if dst is nil, on the type of the item the
+// result set was created with. An empty string is returned if the result set
+// is unscoped or if there's no such column.
+func (res *result) softDeleteColumn(dst interface{}) string {
+	if res.unscoped {
+		return ""
+	}
+	if dst == nil {
+		dst = res.model
+	}
+	if dst == nil {
+		return ""
+	}
+
+	t := reflect.TypeOf(dst)
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for name, fi := range mapper.TypeMap(t).Names {
+		if _, ok := fi.Options["softdelete"]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// timestampField returns the field of item that is mapped to the given
+// column, if it can hold a timestamp.
+func timestampField(item interface{}, column string) (reflect.Value, bool) {
+	itemV := reflect.Indirect(reflect.ValueOf(item))
+
+	fi, ok := mapper.TypeMap(itemV.Type()).Names[column]
+	if !ok || !isTimestampType(fi.Field.Type) {
+		return reflect.Value{}, false
+	}
+
+	if !itemV.CanAddr() {
+		// Can't be modified, but its type is still useful.
+		return reflect.New(fi.Field.Type).Elem(), true
+	}
+	return reflectx.FieldByIndexes(itemV, fi.Index), true
+}
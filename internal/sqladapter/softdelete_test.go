@@ -0,0 +1,57 @@
+package sqladapter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoftDeleteColumn(t *testing.T) {
+	type itemType struct {
+		ID        int64      `db:"id"`
+		DeletedAt *time.Time `db:"deleted_at,softdelete"`
+	}
+
+	type otherType struct {
+		ID        int64      `db:"id"`
+		DeletedAt *time.Time `db:"deleted_at"`
+	}
+
+	res := &result{}
+
+	assert.Equal(t, "", res.softDeleteColumn(nil))
+	assert.Equal(t, "deleted_at", res.softDeleteColumn(&itemType{}))
+	assert.Equal(t, "deleted_at", res.softDeleteColumn(&[]itemType{}))
+	assert.Equal(t, "deleted_at", res.softDeleteColumn(&[]*itemType{}))
+	assert.Equal(t, "", res.softDeleteColumn(&[]otherType{}))
+	assert.Equal(t, "", res.softDeleteColumn(&map[string]interface{}{}))
+
+	res.model = &itemType{ID: 1}
+	assert.Equal(t, "deleted_at", res.softDeleteColumn(nil))
+	assert.Equal(t, "", res.softDeleteColumn(&otherType{}))
+
+	res.unscoped = true
+	assert.Equal(t, "", res.softDeleteColumn(nil))
+	assert.Equal(t, "", res.softDeleteColumn(&itemType{}))
+}
+
+func TestTimestampField(t *testing.T) {
+	type itemType struct {
+		DeletedAt *time.Time `db:"deleted_at,softdelete"`
+		Name      string     `db:"name"`
+	}
+
+	item := &itemType{}
+
+	fld, ok := timestampField(item, "deleted_at")
+	assert.True(t, ok)
+	setTimestamp(fld, time.Now())
+	assert.NotNil(t, item.DeletedAt)
+
+	_, ok = timestampField(item, "name")
+	assert.False(t, ok)
+
+	_, ok = timestampField(itemType{}, "deleted_at")
+	assert.True(t, ok)
+}
@@ -0,0 +1,171 @@
+package sqladapter
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"upper.io/db.v3"
+)
+
+const (
+	// maxQueryStats is the number of fingerprints statistics are kept for,
+	// the least executed one is dropped to make room for a new one.
+	maxQueryStats = 1000
+
+	// queryStatsSamples is the number of recent durations percentiles are
+	// computed from.
+	queryStatsSamples = 512
+
+	// maxFingerprints is the number of queries whose fingerprints are kept to
+	// avoid normalizing them again.
+	maxFingerprints = 4096
+)
+
+type queryStatsEntry struct {
+	stats   db.QueryStats
+	samples []time.Duration
+	next    int
+}
+
+// queryStats groups statements by fingerprint and collects their counters
+// and latencies, it's shared by a session, its clones and its transactions.
+type queryStats struct {
+	mu           sync.Mutex
+	since        time.Time
+	entries      map[string]*queryStatsEntry
+	fingerprints map[string]string
+}
+
+func newQueryStats() *queryStats {
+	return &queryStats{
+		since:        time.Now(),
+		entries:      make(map[string]*queryStatsEntry),
+		fingerprints: make(map[string]string),
+	}
+}
+
+// entry returns the entry of the fingerprint of query, the caller must hold
+// the lock.
+func (s *queryStats) entry(query string) *queryStatsEntry {
+	fp, ok := s.fingerprints[query]
+	if !ok {
+		if len(s.fingerprints) >= maxFingerprints {
+			s.fingerprints = make(map[string]string)
+		}
+		fp = fingerprint(query)
+		s.fingerprints[query] = fp
+	}
+
+	e, ok := s.entries[fp]
+	if ok {
+		return e
+	}
+
+	if len(s.entries) >= maxQueryStats {
+		var evict string
+		for k, v := range s.entries {
+			if evict == "" || v.stats.Count < s.entries[evict].stats.Count {
+				evict = k
+			}
+		}
+		delete(s.entries, evict)
+	}
+
+	e = &queryStatsEntry{stats: db.QueryStats{Fingerprint: fp}}
+	s.entries[fp] = e
+	return e
+}
+
+func (s *queryStats) observe(query string, elapsed time.Duration, err error, rows int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entry(query)
+
+	q := &e.stats
+	q.Count++
+	if err != nil {
+		q.Errors++
+	}
+	if rows > 0 {
+		q.Rows += uint64(rows)
+	}
+	q.TotalDuration += elapsed
+	if q.Count == 1 || elapsed < q.MinDuration {
+		q.MinDuration = elapsed
+	}
+	if elapsed > q.MaxDuration {
+		q.MaxDuration = elapsed
+	}
+
+	if len(e.samples) < queryStatsSamples {
+		e.samples = append(e.samples, elapsed)
+	} else {
+		e.samples[e.next] = elapsed
+		e.next = (e.next + 1) % queryStatsSamples
+	}
+}
+
+func (s *queryStats) observeRows(query string, rows int) {
+	if rows <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entry(query).stats.Rows += uint64(rows)
+}
+
+func (s *queryStats) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.since = time.Now()
+	s.entries = make(map[string]*queryStatsEntry)
+}
+
+func (s *queryStats) snapshot() db.Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := db.Stats{
+		Since:   s.since,
+		Queries: make([]db.QueryStats, 0, len(s.entries)),
+	}
+
+	for _, e := range s.entries {
+		q := e.stats
+
+		samples := append([]time.Duration(nil), e.samples...)
+		sort.Slice(samples, func(i, j int) bool {
+			return samples[i] < samples[j]
+		})
+		q.P50Duration = percentile(samples, 0.50)
+		q.P95Duration = percentile(samples, 0.95)
+		q.P99Duration = percentile(samples, 0.99)
+
+		stats.Queries = append(stats.Queries, q)
+	}
+
+	sort.SliceStable(stats.Queries, func(i, j int) bool {
+		return stats.Queries[i].TotalDuration > stats.Queries[j].TotalDuration
+	})
+
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of the given sorted
+// durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
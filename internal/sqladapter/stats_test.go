@@ -0,0 +1,64 @@
+package sqladapter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	for query, expected := range map[string]string{
+		`SELECT * FROM "users" WHERE ("id" = $1)`:                           `SELECT * FROM "users" WHERE ("id" = ?)`,
+		"SELECT *\n  FROM users -- all of them\n WHERE id = 42":             `SELECT * FROM users WHERE id = ?`,
+		`SELECT * FROM users WHERE name = 'it''s' /* name */ AND age > 1.5`: `SELECT * FROM users WHERE name = ? AND age > ?`,
+		`SELECT * FROM "users" WHERE ("id" IN ($1, $2, $3))`:                `SELECT * FROM "users" WHERE ("id" IN (...))`,
+		`INSERT INTO "t1" ("a", "b") VALUES (?, ?), (?, ?), (?, ?)`:         `INSERT INTO "t1" ("a", "b") VALUES (...)`,
+		`SELECT "col2" FROM t2 WHERE x = :name AND y::text = @p1`:           `SELECT "col2" FROM t2 WHERE x = ? AND y::text = ?`,
+		`SELECT "it's 1" FROM t WHERE s = E'a\'b'`:                          `SELECT "it's 1" FROM t WHERE s = ?`,
+	} {
+		assert.Equal(t, expected, fingerprint(query), query)
+	}
+}
+
+func TestQueryStats(t *testing.T) {
+	s := newQueryStats()
+
+	for i := 1; i <= 100; i++ {
+		s.observe(`SELECT * FROM users WHERE id = $1`, time.Duration(i)*time.Millisecond, nil, 0)
+	}
+	s.observeRows(`SELECT * FROM users WHERE id = $1`, 7)
+	s.observe(`UPDATE users SET name = 'joe' WHERE id = 1`, time.Second, nil, 3)
+	s.observe(`UPDATE users SET name = 'ann' WHERE id = 2`, time.Second, errors.New("failed"), 0)
+
+	stats := s.snapshot()
+	if !assert.Len(t, stats.Queries, 2) {
+		return
+	}
+
+	update := stats.Queries[1]
+	assert.Equal(t, `UPDATE users SET name = ? WHERE id = ?`, update.Fingerprint)
+	assert.Equal(t, uint64(2), update.Count)
+	assert.Equal(t, uint64(1), update.Errors)
+	assert.Equal(t, uint64(3), update.Rows)
+
+	sel := stats.Queries[0]
+	assert.Equal(t, `SELECT * FROM users WHERE id = ?`, sel.Fingerprint)
+	assert.Equal(t, uint64(100), sel.Count)
+	assert.Equal(t, uint64(7), sel.Rows)
+	assert.Equal(t, time.Millisecond, sel.MinDuration)
+	assert.Equal(t, 100*time.Millisecond, sel.MaxDuration)
+	assert.Equal(t, 50*time.Millisecond, sel.P50Duration)
+	assert.Equal(t, 95*time.Millisecond, sel.P95Duration)
+	assert.Equal(t, 99*time.Millisecond, sel.P99Duration)
+	assert.Equal(t, 50500*time.Microsecond, sel.MeanDuration())
+
+	assert.Equal(t, sel, stats.TopByCount(1)[0])
+	assert.Equal(t, sel, stats.TopByRows(1)[0])
+	assert.Equal(t, update, stats.TopByMeanDuration(1)[0])
+	assert.Len(t, stats.TopByTotalDuration(5), 2)
+
+	s.reset()
+	assert.Empty(t, s.snapshot().Queries)
+}
@@ -3,7 +3,11 @@
 package ADAPTER
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -17,6 +21,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"upper.io/db.v3"
+	"upper.io/db.v3/lib/migrate"
 	"upper.io/db.v3/lib/sqlbuilder"
 )
 
@@ -186,6 +191,59 @@ func TestPreparedStatementsCache(t *testing.T) {
 	assert.NoError(t, sess.Close())
 }
 
+func TestPreparedStatementsCacheSize(t *testing.T) {
+	sess := mustOpen()
+
+	sess.SetPreparedStatementCache(true)
+	defer sess.SetPreparedStatementCache(false)
+
+	sess.SetPreparedStatementCacheSize(4)
+	assert.Equal(t, 4, sess.PreparedStatementCacheSize())
+
+	// Evicted statements are closed and prepared again when needed.
+	for j := 0; j < 2; j++ {
+		for i := 0; i < 10; i++ {
+			var count map[string]uint64
+			err := sess.Collection("artist").Find().Select(db.Raw(fmt.Sprintf("count(%d)", i))).One(&count)
+			assert.NoError(t, err)
+		}
+	}
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestPreparedStatementsDisabled(t *testing.T) {
+	sess := mustOpen()
+
+	sess.SetPreparedStatements(false)
+	assert.False(t, sess.PreparedStatementsEnabled())
+
+	// The cache is not used while statement preparation is disabled.
+	sess.SetPreparedStatementCache(true)
+
+	_, err := sess.Prepare("SELECT 1")
+	assert.Equal(t, db.ErrPreparedStatementsDisabled, err)
+
+	count, err := sess.Collection("artist").Find().Count()
+	assert.NoError(t, err)
+
+	var total uint64
+	err = sess.Select(db.Raw("count(1) AS total")).From("artist").Iterator().ScanOne(&total)
+	assert.NoError(t, err)
+	assert.Equal(t, count, total)
+
+	results, err := sess.Batch("UPDATE artist SET name = name WHERE id = ?").Add(1).Add(2).Exec()
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	for i := range results {
+		assert.NoError(t, results[i].Err)
+	}
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
 func TestTruncateAllCollections(t *testing.T) {
 	sess := mustOpen()
 
@@ -227,6 +285,44 @@ func TestCustomQueryLogger(t *testing.T) {
 	assert.NoError(t, sess.Close())
 }
 
+type ctxQueryLoggerKey struct{}
+
+type ctxQueryLogger struct {
+	requests []interface{}
+}
+
+func (*ctxQueryLogger) Log(q *db.QueryStatus) {
+}
+
+func (lg *ctxQueryLogger) LogQuery(ctx context.Context, q *db.QueryStatus) {
+	lg.requests = append(lg.requests, ctx.Value(ctxQueryLoggerKey{}))
+}
+
+func TestContextQueryLogger(t *testing.T) {
+	sess := mustOpen()
+
+	lg := &ctxQueryLogger{}
+
+	sess.SetLogger(lg)
+	sess.SetLogging(true)
+	defer func() {
+		sess.SetLogger(nil)
+		sess.SetLogging(false)
+	}()
+
+	ctx := context.WithValue(context.Background(), ctxQueryLoggerKey{}, "request-1")
+
+	_, err := sess.WithContext(ctx).Collection("artist").Find().Count()
+	assert.NoError(t, err)
+
+	if assert.NotEmpty(t, lg.requests) {
+		assert.Equal(t, "request-1", lg.requests[len(lg.requests)-1])
+	}
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
 func TestExpectCursorError(t *testing.T) {
 	sess := mustOpen()
 
@@ -1025,6 +1121,193 @@ func TestGroup(t *testing.T) {
 	assert.NoError(t, sess.Close())
 }
 
+func TestExistsAndCacheCount(t *testing.T) {
+	sess := mustOpen()
+
+	type statsType struct {
+		Numeric int `db:"numeric"`
+		Value   int `db:"value"`
+	}
+
+	stats := sess.Collection("stats_test")
+	assert.NoError(t, stats.Truncate())
+
+	exists, err := stats.Find().Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	for i := 0; i < 10; i++ {
+		_, err := stats.Insert(statsType{i % 2, i})
+		assert.NoError(t, err)
+	}
+
+	exists, err = stats.Find(db.Cond{"numeric": 1}).Exists()
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = stats.Find(db.Cond{"numeric": 2}).Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	res := stats.Find().CacheCount()
+
+	total, err := res.Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), total)
+
+	_, err = stats.Insert(statsType{1, 10})
+	assert.NoError(t, err)
+
+	// The count is remembered by the set and the pages derived from it.
+	total, err = res.Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), total)
+
+	total, err = res.Limit(3).Offset(3).OrderBy("value").Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), total)
+
+	// Changing the conditions makes the set count its items again.
+	total, err = res.And(db.Cond{"numeric": 1}).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), total)
+
+	total, err = stats.Find().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(11), total)
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestQueryCache(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	other := mustOpen()
+	defer other.Close()
+
+	sess.SetQueryCache(db.NewMemoryQueryCache(100))
+
+	type statsType struct {
+		Numeric int `db:"numeric"`
+		Value   int `db:"value"`
+	}
+
+	stats := sess.Collection("stats_test")
+	assert.NoError(t, stats.Truncate())
+
+	for i := 0; i < 5; i++ {
+		_, err := stats.Insert(statsType{i % 2, i})
+		assert.NoError(t, err)
+	}
+
+	values := func() []int {
+		var items []int
+		err := sess.Select("value").From("stats_test").OrderBy("value").Cache(time.Minute).All(&items)
+		assert.NoError(t, err)
+		return items
+	}
+
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, values())
+
+	// Writes of other sessions are not seen until the result expires.
+	_, err := other.Collection("stats_test").Insert(statsType{1, 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, values())
+
+	// Writes of the session expire the results that read from the table.
+	_, err = stats.Insert(statsType{0, 6})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6}, values())
+
+	_, err = sess.Update("stats_test").Set("value", 60).Where("value", 6).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 60}, values())
+
+	assert.NoError(t, stats.Find(db.Cond{"value": 0}).Delete())
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 60}, values())
+
+	// Results expire once transactions are committed.
+	err = sess.Tx(nil, func(tx sqlbuilder.Tx) error {
+		_, err := tx.Collection("stats_test").Insert(statsType{1, 7})
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 7, 60}, values())
+
+	// Rows are read into any destination, like uncached results.
+	var value int
+	err = sess.Select("value").From("stats_test").Where("value", 7).Cache(time.Minute).Iterator().ScanOne(&value)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, value)
+}
+func TestRecordCache(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	cached, err := sess.WithRecordCache(db.NewMemoryQueryCache(100), time.Minute)
+	assert.NoError(t, err)
+
+	artist := cached.Collection("artist")
+	id, err := artist.Insert(artistType{Name: "Cached 1"})
+	assert.NoError(t, err)
+
+	before := cached.Metrics()
+
+	var item artistType
+	assert.NoError(t, artist.Find(id).One(&item))
+	assert.Equal(t, "Cached 1", item.Name)
+
+	// Writes that are not made through collections are not seen until the
+	// collection is invalidated.
+	_, err = sess.Update("artist").Set("name", "Cached 2").Where("id", id).Exec()
+	assert.NoError(t, err)
+
+	assert.NoError(t, artist.Find(id).One(&item))
+	assert.Equal(t, "Cached 1", item.Name)
+
+	cached.InvalidateRecordCache("artist")
+	assert.NoError(t, artist.Find(id).One(&item))
+	assert.Equal(t, "Cached 2", item.Name)
+
+	// Other lookups don't use the record cache.
+	assert.NoError(t, artist.Find(db.Cond{"name": "Cached 2"}).One(&item))
+
+	after := cached.Metrics()
+	assert.Equal(t, uint64(2), after.RecordCacheMisses-before.RecordCacheMisses)
+	assert.Equal(t, uint64(1), after.RecordCacheHits-before.RecordCacheHits)
+
+	// Updates through collections replace or drop the cached item.
+	assert.NoError(t, artist.Find(id).Update(map[string]interface{}{"name": "Cached 3"}))
+	assert.NoError(t, artist.Find(id).One(&item))
+	assert.Equal(t, "Cached 3", item.Name)
+
+	item.Name = "Cached 4"
+	assert.NoError(t, artist.UpdateReturning(&item))
+	assert.NoError(t, artist.Find(id).One(&item))
+	assert.Equal(t, "Cached 4", item.Name)
+
+	// Updates that may modify many items drop the whole collection.
+	assert.NoError(t, artist.Find(db.Cond{"name": "Cached 4"}).Update(map[string]interface{}{"name": "Cached 5"}))
+	assert.NoError(t, artist.Find(id).One(&item))
+	assert.Equal(t, "Cached 5", item.Name)
+
+	// Transactions update the record cache once they're committed.
+	err = cached.Tx(nil, func(tx sqlbuilder.Tx) error {
+		return tx.Collection("artist").Find(id).Update(map[string]interface{}{"name": "Cached 6"})
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, artist.Find(id).One(&item))
+	assert.Equal(t, "Cached 6", item.Name)
+
+	assert.NoError(t, artist.Find(id).Delete())
+	assert.Equal(t, db.ErrNoMoreRows, artist.Find(id).One(&item))
+
+	assert.True(t, cached.Metrics().RecordCacheHitRatio() > 0)
+}
+
+
 func TestDelete(t *testing.T) {
 	sess := mustOpen()
 
@@ -1089,6 +1372,48 @@ func TestCompositeKeys(t *testing.T) {
 
 		err := compositeKeys.InsertReturning(&item)
 		assert.NoError(t, err)
+
+		item.SomeVal = "Another value"
+		assert.NoError(t, compositeKeys.UpdateReturning(&item))
+		assert.Equal(t, "Another value", item.SomeVal)
+
+		// Finding by all the key parts of the item.
+		var item2 itemWithCompoundKey
+		assert.NoError(t, compositeKeys.Find(item).One(&item2))
+		assert.Equal(t, item, item2)
+
+		itemMap := map[string]interface{}{
+			"code":     item.Code,
+			"user_id":  item.UserID,
+			"some_val": "Value from map",
+		}
+		assert.NoError(t, compositeKeys.UpdateReturning(&itemMap))
+		assert.Equal(t, "Value from map", itemMap["some_val"])
+	}
+
+	{
+		// Key parts may be declared by tagging fields with the pk option.
+		type taggedItem struct {
+			Code    string `db:"code,pk"`
+			UserID  string `db:"user_id,pk"`
+			SomeVal string `db:"some_val"`
+		}
+
+		item := taggedItem{"GHIJKL", strconv.Itoa(rand.Intn(100000)), "Tagged value"}
+		assert.NoError(t, compositeKeys.InsertReturning(&item))
+
+		item.SomeVal = "Updated tagged value"
+		assert.NoError(t, compositeKeys.Find(&item).Update(&item))
+
+		var item2 taggedItem
+		assert.NoError(t, compositeKeys.Find(&item).One(&item2))
+		assert.Equal(t, item, item2)
+
+		assert.NoError(t, compositeKeys.Find(&item).Delete())
+
+		count, err := compositeKeys.Find(db.Cond{"code": "GHIJKL"}).Count()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), count)
 	}
 
 	assert.NoError(t, cleanUpCheck(sess))
@@ -1496,96 +1821,990 @@ func TestBatchInsertReturningKeys(t *testing.T) {
 	assert.NoError(t, sess.Close())
 }
 
-func TestSQLBuilder(t *testing.T) {
+func TestBulkInsert(t *testing.T) {
 	sess := mustOpen()
 
-	var all []map[string]interface{}
+	type artistType struct {
+		Name string `db:"name"`
+	}
 
-	err := sess.Collection("artist").Truncate()
-	assert.NoError(t, err)
+	for batchSize := 1; batchSize < 9; batchSize++ {
+		err := sess.Collection("artist").Truncate()
+		assert.NoError(t, err)
 
-	_, err = sess.InsertInto("artist").Values(struct {
-		Name string `db:"name"`
-	}{"Rinko Kikuchi"}).Exec()
-	assert.NoError(t, err)
+		totalItems := int(rand.Int31n(21))
 
-	// Using explicit iterator.
-	iter := sess.SelectFrom("artist").Iterator()
-	err = iter.All(&all)
+		artists := make([]artistType, totalItems)
+		for i := range artists {
+			artists[i].Name = fmt.Sprintf("artist-%d", i)
+		}
 
-	assert.NoError(t, err)
-	assert.NotZero(t, all)
+		err = sess.Collection("artist").BulkInsert().BatchSize(batchSize).Insert(artists)
+		assert.NoError(t, err)
 
-	// Using explicit iterator to fetch one item.
-	var item map[string]interface{}
-	iter = sess.SelectFrom("artist").Iterator()
-	err = iter.One(&item)
+		c, err := sess.Collection("artist").Find().Count()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(totalItems), c)
 
-	assert.NoError(t, err)
-	assert.NotZero(t, item)
+		items := make(chan map[string]interface{})
+		go func() {
+			defer close(items)
+			for i := 0; i < totalItems; i++ {
+				items <- map[string]interface{}{"name": fmt.Sprintf("artist-%d", i+totalItems)}
+			}
+		}()
 
-	// Using explicit iterator and NextScan.
-	iter = sess.SelectFrom("artist").Iterator()
-	var id int
-	var name string
+		err = sess.Collection("artist").BulkInsert().BatchSize(batchSize).Insert(items)
+		assert.NoError(t, err)
 
-	if Adapter == "ql" {
-		err = iter.NextScan(&name)
-		id = 1
-	} else {
-		err = iter.NextScan(&id, &name)
+		c, err = sess.Collection("artist").Find().Count()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(totalItems*2), c)
 	}
 
-	assert.NoError(t, err)
-	assert.NotZero(t, id)
-	assert.NotEmpty(t, name)
-	assert.NoError(t, iter.Close())
-
-	err = iter.NextScan(&id, &name)
+	err := sess.Collection("artist").BulkInsert().Insert(artistType{})
 	assert.Error(t, err)
 
-	// Using explicit iterator and ScanOne.
-	iter = sess.SelectFrom("artist").Iterator()
-	id, name = 0, ""
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestPreload(t *testing.T) {
 	if Adapter == "ql" {
-		err = iter.ScanOne(&name)
-		id = 1
-	} else {
-		err = iter.ScanOne(&id, &name)
+		t.Skip("Currently not supported.")
 	}
 
-	assert.NoError(t, err)
-	assert.NotZero(t, id)
-	assert.NotEmpty(t, name)
-
-	err = iter.ScanOne(&id, &name)
-	assert.Error(t, err)
+	sess := mustOpen()
 
-	// Using explicit iterator and Next.
-	iter = sess.SelectFrom("artist").Iterator()
+	type publicationType struct {
+		ID       int64  `db:"id,omitempty"`
+		Title    string `db:"title"`
+		AuthorID int64  `db:"author_id"`
 
-	var artist map[string]interface{}
-	for iter.Next(&artist) {
-		if Adapter != "ql" {
-			assert.NotZero(t, artist["id"])
-		}
-		assert.NotEmpty(t, artist["name"])
+		Author *struct {
+			ID   int64  `db:"id"`
+			Name string `db:"name"`
+		} `db:"-" relation:"belongs_to,fk=author_id,table=artist"`
 	}
-	// We should not have any error after finishing successfully exiting a Next() loop.
-	assert.Empty(t, iter.Err())
 
-	for i := 0; i < 5; i++ {
-		// But we'll get errors if we attempt to continue using Next().
-		assert.False(t, iter.Next(&artist))
-		assert.Error(t, iter.Err())
+	type artistType struct {
+		ID   int64  `db:"id,omitempty"`
+		Name string `db:"name"`
+
+		Publications []publicationType `db:"-" relation:"has_many,fk=author_id,table=publication"`
 	}
 
-	// Using implicit iterator.
-	q := sess.SelectFrom("artist")
-	err = q.All(&all)
+	artist := sess.Collection("artist")
+	publication := sess.Collection("publication")
 
-	assert.NoError(t, err)
-	assert.NotZero(t, all)
+	assert.NoError(t, artist.Truncate())
+	assert.NoError(t, publication.Truncate())
+
+	for _, name := range []string{"Borges", "Cortázar", "Rulfo"} {
+		_, err := artist.Insert(artistType{Name: name})
+		assert.NoError(t, err)
+	}
+
+	var authors []artistType
+	assert.NoError(t, artist.Find().All(&authors))
+
+	authorIDs := map[string]int64{}
+	for _, author := range authors {
+		authorIDs[author.Name] = author.ID
+	}
+
+	books := map[string]string{
+		"Ficciones":       "Borges",
+		"El Aleph":        "Borges",
+		"Rayuela":         "Cortázar",
+		"Bestiario":       "Cortázar",
+		"Final del juego": "Cortázar",
+	}
+	for title, author := range books {
+		_, err := publication.Insert(publicationType{Title: title, AuthorID: authorIDs[author]})
+		assert.NoError(t, err)
+	}
+
+	var artists []artistType
+	err := artist.Find().OrderBy("name").Preload("Publications").All(&artists)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(artists))
+	assert.Equal(t, 2, len(artists[0].Publications))
+	assert.Equal(t, 3, len(artists[1].Publications))
+	assert.NotNil(t, artists[2].Publications)
+	assert.Equal(t, 0, len(artists[2].Publications))
+
+	var publications []*publicationType
+	err = publication.Find().Preload("Author").All(&publications)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(publications))
+	for _, p := range publications {
+		assert.NotNil(t, p.Author)
+		assert.Equal(t, books[p.Title], p.Author.Name)
+	}
+
+	var book publicationType
+	err = publication.Find(db.Cond{"title": "Rayuela"}).Preload("Author").One(&book)
+	assert.NoError(t, err)
+	assert.Equal(t, "Cortázar", book.Author.Name)
+
+	err = publication.Find().Preload("Title").All(&publications)
+	assert.Error(t, err)
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+type hookedArtist struct {
+	ID   int64  `db:"id,omitempty"`
+	Name string `db:"name"`
+
+	calls []string
+}
+
+func (a *hookedArtist) BeforeInsert(sess db.Database) error {
+	a.calls = append(a.calls, "BeforeInsert")
+	if a.Name == "" {
+		return errors.New("name is required")
+	}
+	a.Name = strings.ToUpper(a.Name)
+	return nil
+}
+
+func (a *hookedArtist) AfterInsert(sess db.Database) error {
+	a.calls = append(a.calls, "AfterInsert")
+	return nil
+}
+
+func (a *hookedArtist) BeforeUpdate(sess db.Database) error {
+	a.calls = append(a.calls, "BeforeUpdate")
+	return nil
+}
+
+func (a *hookedArtist) AfterUpdate(sess db.Database) error {
+	a.calls = append(a.calls, "AfterUpdate")
+	return nil
+}
+
+func (a *hookedArtist) BeforeDelete(sess db.Database) error {
+	a.calls = append(a.calls, "BeforeDelete")
+	if a.Name == "PROTECTED" {
+		return errors.New("can't delete a protected artist")
+	}
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	sess := mustOpen()
+
+	artist := sess.Collection("artist")
+	assert.NoError(t, artist.Truncate())
+
+	_, err := artist.Insert(&hookedArtist{})
+	assert.Error(t, err)
+
+	item := &hookedArtist{Name: "Ozzy"}
+	err = artist.InsertReturning(item)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"BeforeInsert", "AfterInsert"}, item.calls)
+	assert.Equal(t, "OZZY", item.Name)
+
+	item.calls = nil
+	item.Name = "Ozzy Osbourne"
+	err = artist.UpdateReturning(item)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"BeforeUpdate", "AfterUpdate"}, item.calls)
+
+	err = sess.Tx(context.Background(), func(tx sqlbuilder.Tx) error {
+		item.calls = nil
+		item.Name = "PROTECTED"
+		if err := tx.Collection("artist").Find(item.ID).Update(item); err != nil {
+			return err
+		}
+		return tx.Collection("artist").Find(item).Delete()
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"BeforeUpdate", "AfterUpdate", "BeforeDelete"}, item.calls)
+
+	item.calls = nil
+	item.Name = "Ozzy"
+	err = artist.Find(item).Delete()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"BeforeDelete"}, item.calls)
+
+	count, err := artist.Find().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), count)
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestTupleIn(t *testing.T) {
+	sess := mustOpen()
+
+	artist := sess.Collection("artist")
+	assert.NoError(t, artist.Truncate())
+
+	ids := []interface{}{}
+	for _, name := range []string{"Ozzy", "Flea", "Slash"} {
+		id, err := artist.Insert(map[string]string{"name": name})
+		assert.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	count, err := artist.Find(db.In([]string{"id", "name"}, [][]interface{}{
+		{ids[0], "Ozzy"},
+		{ids[1], "Slash"},
+		{ids[2], "Slash"},
+	})).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), count)
+
+	count, err = artist.Find(db.Cond{"(id, name) NOT IN": [][]interface{}{{ids[0], "Ozzy"}}}).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), count)
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestValuesList(t *testing.T) {
+	if Adapter == "ql" {
+		t.Skip("Currently not supported.")
+	}
+
+	sess := mustOpen()
+
+	var rows []struct {
+		ID    int64  `db:"id"`
+		Title string `db:"title"`
+	}
+
+	err := sess.Select("v.id", "v.title").
+		From(db.Values([][]interface{}{{1, "Hamlet"}, {2, "Ulysses"}}).As("v(id, title)")).
+		Where("v.title", "Ulysses").
+		All(&rows)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(rows)) {
+		assert.Equal(t, "Ulysses", rows[0].Title)
+	}
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestUpdateFromDeleteUsing(t *testing.T) {
+	if Adapter == "ql" {
+		t.Skip("Currently not supported.")
+	}
+
+	sess := mustOpen()
+
+	err := sess.Collection("artist").Truncate()
+	assert.NoError(t, err)
+
+	err = sess.Collection("publication").Truncate()
+	assert.NoError(t, err)
+
+	artistID, err := sess.Collection("artist").Insert(map[string]string{"name": "Ozzie"})
+	assert.NoError(t, err)
+
+	_, err = sess.Collection("publication").Insert(map[string]interface{}{
+		"title":     "Tomorrow",
+		"author_id": artistID,
+	})
+	assert.NoError(t, err)
+
+	_, err = sess.Update("artist").
+		Set("name = ?", "Ozzie (author)").
+		From("publication").
+		Where("publication.author_id = artist.id AND publication.title = ?", "Tomorrow").
+		Exec()
+	assert.NoError(t, err)
+
+	count, err := sess.Collection("artist").Find("name", "Ozzie (author)").Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	if Adapter != "sqlite" {
+		_, err = sess.DeleteFrom("artist").
+			Using("publication").
+			Where("publication.author_id = artist.id AND publication.title = ?", "Tomorrow").
+			Exec()
+		assert.NoError(t, err)
+
+		count, err = sess.Collection("artist").Find().Count()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), count)
+	}
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestNamedParameters(t *testing.T) {
+	sess := mustOpen()
+
+	err := sess.Collection("artist").Truncate()
+	assert.NoError(t, err)
+
+	_, err = sess.Exec(
+		"INSERT INTO artist (name) VALUES (:name)",
+		struct {
+			Name string `db:"name"`
+		}{"Toshiro Mifune"},
+	)
+	assert.NoError(t, err)
+
+	var artists []map[string]interface{}
+	err = sess.Iterator(
+		"SELECT name FROM artist WHERE name = :name",
+		map[string]interface{}{"name": "Toshiro Mifune"},
+	).All(&artists)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(artists))
+
+	count, err := sess.Collection("artist").Find(db.Raw("name = :name", map[string]string{"name": "Toshiro Mifune"})).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	_, err = sess.Query("SELECT name FROM artist WHERE name = :missing", map[string]string{})
+	assert.Error(t, err)
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestBatch(t *testing.T) {
+	if Adapter == "ql" {
+		t.Skip("Currently not supported.")
+	}
+
+	sess := mustOpen()
+
+	err := sess.Collection("artist").Truncate()
+	assert.NoError(t, err)
+
+	b := sess.Batch("INSERT INTO artist (name) VALUES (?)").
+		Add("Akira").
+		Add().
+		Add("Yasujiro")
+	assert.Equal(t, 3, b.Len())
+
+	results, err := b.Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, b.Len())
+
+	if assert.Equal(t, 3, len(results)) {
+		assert.NoError(t, results[0].Err)
+		assert.Error(t, results[1].Err)
+		assert.NoError(t, results[2].Err)
+	}
+
+	results, err = sess.Batch("UPDATE artist SET name = :name WHERE name = :old").
+		Add(map[string]string{"old": "Akira", "name": "Akira Kurosawa"}).
+		Add(map[string]string{"old": "Yasujiro", "name": "Yasujiro Ozu"}).
+		Exec()
+	assert.NoError(t, err)
+	for i := range results {
+		assert.NoError(t, results[i].Err)
+		affected, err := results[i].RowsAffected()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), affected)
+	}
+
+	count, err := sess.Collection("artist").Find("name IN", []string{"Akira Kurosawa", "Yasujiro Ozu"}).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), count)
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestCursor(t *testing.T) {
+	sess := mustOpen()
+
+	err := sess.Collection("artist").Truncate()
+	assert.NoError(t, err)
+
+	for i := 0; i < 25; i++ {
+		_, err := sess.Collection("artist").Insert(artistType{Name: fmt.Sprintf("artist-%d", i)})
+		assert.NoError(t, err)
+	}
+
+	for _, fetchSize := range []int{1, 10, 25, 100} {
+		iter := sess.SelectFrom("artist").OrderBy("id").Cursor(context.Background(), fetchSize)
+
+		var artist artistType
+		names := []string{}
+		for iter.Next(&artist) {
+			names = append(names, artist.Name)
+		}
+		assert.NoError(t, iter.Err())
+		assert.NoError(t, iter.Close())
+
+		if assert.Equal(t, 25, len(names)) {
+			assert.Equal(t, "artist-0", names[0])
+			assert.Equal(t, "artist-24", names[24])
+		}
+	}
+
+	{
+		var artists []artistType
+		err := sess.SelectFrom("artist").OrderBy("id").Limit(11).Cursor(context.Background(), 4).All(&artists)
+		assert.NoError(t, err)
+		assert.Equal(t, 11, len(artists))
+	}
+
+	err = sess.Tx(context.Background(), func(tx sqlbuilder.Tx) error {
+		var artists []artistType
+		if err := tx.SelectFrom("artist").Cursor(context.Background(), 7).All(&artists); err != nil {
+			return err
+		}
+		assert.Equal(t, 25, len(artists))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestIteratorChunk(t *testing.T) {
+	sess := mustOpen()
+
+	err := sess.Collection("artist").Truncate()
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err := sess.Collection("artist").Insert(artistType{Name: fmt.Sprintf("artist-%d", i)})
+		assert.NoError(t, err)
+	}
+
+	var artists []artistType
+	chunks := []int{}
+	err = sess.SelectFrom("artist").OrderBy("id").Iterator().Chunk(4, &artists, func() error {
+		chunks = append(chunks, len(artists))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{4, 4, 2}, chunks)
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestIteratorEncodeJSON(t *testing.T) {
+	sess := mustOpen()
+
+	err := sess.Collection("artist").Truncate()
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := sess.Collection("artist").Insert(artistType{Name: fmt.Sprintf("artist-%d", i)})
+		assert.NoError(t, err)
+	}
+
+	var buf bytes.Buffer
+	err = sess.Select("name").From("artist").OrderBy("id").Iterator().EncodeJSON(&buf)
+	assert.NoError(t, err)
+
+	var artists []map[string]string
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &artists))
+	assert.Equal(t, []map[string]string{
+		{"name": "artist-0"},
+		{"name": "artist-1"},
+		{"name": "artist-2"},
+	}, artists)
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestIteratorWriteCSV(t *testing.T) {
+	sess := mustOpen()
+
+	err := sess.Collection("artist").Truncate()
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := sess.Collection("artist").Insert(artistType{Name: fmt.Sprintf("artist-%d", i)})
+		assert.NoError(t, err)
+	}
+
+	var buf bytes.Buffer
+	err = sess.Select("name").From("artist").OrderBy("id").Iterator().WriteCSV(&buf, &sqlbuilder.CSVOptions{Header: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "name\nartist-0\nartist-1\nartist-2\n", buf.String())
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestFetchScalars(t *testing.T) {
+	sess := mustOpen()
+
+	err := sess.Collection("artist").Truncate()
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := sess.Collection("artist").Insert(artistType{Name: fmt.Sprintf("artist-%d", i)})
+		assert.NoError(t, err)
+	}
+
+	var names []string
+	err = sess.Select("name").From("artist").OrderBy("id").All(&names)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"artist-0", "artist-1", "artist-2"}, names)
+
+	var ids []int64
+	err = sess.Select("id").From("artist").OrderBy("id").All(&ids)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(ids))
+
+	var name string
+	err = sess.Select("name").From("artist").OrderBy("id").One(&name)
+	assert.NoError(t, err)
+	assert.Equal(t, "artist-0", name)
+
+	var artists []map[string]interface{}
+	err = sess.SelectFrom("artist").All(&artists)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(artists))
+
+	var artist map[string]interface{}
+	err = sess.SelectFrom("artist").OrderBy("id").One(&artist)
+	assert.NoError(t, err)
+	assert.NotNil(t, artist["name"])
+
+	err = sess.SelectFrom("artist").All(&ids)
+	assert.Equal(t, sqlbuilder.ErrExpectingSingleColumn, err)
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestFetchPrefixedStruct(t *testing.T) {
+	if Adapter == "ql" {
+		t.Skip("Currently not supported.")
+	}
+
+	sess := mustOpen()
+
+	assert.NoError(t, sess.Collection("artist").Truncate())
+	assert.NoError(t, sess.Collection("publication").Truncate())
+
+	id, err := sess.Collection("artist").Insert(artistType{Name: "Borges"})
+	assert.NoError(t, err)
+
+	_, err = sess.Collection("publication").Insert(map[string]interface{}{
+		"title":     "Ficciones",
+		"author_id": id,
+	})
+	assert.NoError(t, err)
+
+	type bookType struct {
+		Title  string `db:"title"`
+		Author struct {
+			ID   int64  `db:"id"`
+			Name string `db:"name"`
+		} `db:"author,prefix=author_"`
+	}
+
+	var books []bookType
+	err = sess.Select("p.title", "a.id AS author_id", "a.name AS author_name").
+		From("publication AS p").
+		Join("artist AS a").On("p.author_id = a.id").
+		All(&books)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(books)) {
+		assert.Equal(t, "Ficciones", books[0].Title)
+		assert.Equal(t, "Borges", books[0].Author.Name)
+		assert.NotZero(t, books[0].Author.ID)
+	}
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestNamingStrategy(t *testing.T) {
+	if Adapter == "ql" {
+		t.Skip("Currently not supported.")
+	}
+
+	sess := mustOpen()
+
+	artist := sess.Collection("artist")
+	assert.NoError(t, artist.Truncate())
+
+	type untaggedArtist struct {
+		ID   int64 `db:"id,omitempty"`
+		Name string
+	}
+
+	// Fields without a `db` tag are only mapped once a strategy is set.
+	sess.SetNamingStrategy(db.SnakeCase)
+
+	_, err := artist.Insert(untaggedArtist{Name: "Ozzy"})
+	assert.NoError(t, err)
+
+	var item untaggedArtist
+	err = artist.Find().One(&item)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ozzy", item.Name)
+
+	sess.SetNamingStrategy(func(field string) string {
+		if field == "FullName" {
+			return "name"
+		}
+		return db.SnakeCase(field)
+	})
+
+	type customArtist struct {
+		ID       int64 `db:"id,omitempty"`
+		FullName string
+	}
+
+	_, err = artist.Insert(customArtist{FullName: "Flea"})
+	assert.NoError(t, err)
+
+	var artists []customArtist
+	err = artist.Find(db.Cond{"name": "Flea"}).All(&artists)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(artists)) {
+		assert.Equal(t, "Flea", artists[0].FullName)
+	}
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestPingContext(t *testing.T) {
+	sess := mustOpen()
+
+	assert.NoError(t, sess.PingContext(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if Adapter != "ql" {
+		assert.Error(t, sess.PingContext(ctx))
+	}
+
+	assert.NoError(t, sess.Close())
+}
+
+func TestUniqueViolation(t *testing.T) {
+	if Adapter == "ql" {
+		t.Skip("Currently not supported.")
+	}
+
+	sess := mustOpen()
+
+	item := itemWithCompoundKey{
+		"ABCDEF",
+		strconv.Itoa(rand.Intn(100000)),
+		"Some value",
+	}
+
+	_, err := sess.InsertInto("composite_keys").Values(item).Exec()
+	assert.NoError(t, err)
+
+	_, err = sess.InsertInto("composite_keys").Values(item).Exec()
+	if assert.IsType(t, &db.Error{}, err) {
+		assert.Equal(t, db.ErrUniqueViolation, db.ErrorKind(err))
+		assert.NotNil(t, err.(*db.Error).Err)
+	}
+
+	assert.NoError(t, sess.Collection("composite_keys").Find(db.Cond{"user_id": item.UserID}).Delete())
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestResultContext(t *testing.T) {
+	sess := mustOpen()
+
+	artist := sess.Collection("artist")
+	assert.NoError(t, artist.Truncate())
+
+	_, err := artist.Insert(artistType{Name: "Ozzy"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	res := artist.Find(db.Cond{"name": "Ozzy"})
+
+	var item artistType
+	assert.NoError(t, res.OneContext(ctx, &item))
+	assert.Equal(t, "Ozzy", item.Name)
+
+	var items []artistType
+	assert.NoError(t, res.AllContext(ctx, &items))
+	assert.Equal(t, 1, len(items))
+
+	count, err := res.CountContext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	assert.NoError(t, res.UpdateContext(ctx, map[string]interface{}{"name": "Ozzy Osbourne"}))
+	count, err = artist.Find(db.Cond{"name": "Ozzy Osbourne"}).CountContext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	if Adapter != "ql" {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		res := artist.Find()
+		assert.Error(t, res.OneContext(cancelled, &item))
+		assert.Error(t, res.AllContext(cancelled, &items))
+		assert.Error(t, res.UpdateContext(cancelled, map[string]interface{}{"name": "Flea"}))
+		assert.Error(t, res.DeleteContext(cancelled))
+
+		_, err = res.CountContext(cancelled)
+		assert.Error(t, err)
+	}
+
+	assert.NoError(t, artist.Find().DeleteContext(ctx))
+	count, err = artist.Find().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), count)
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestQueryTimeout(t *testing.T) {
+	sess := mustOpen()
+
+	artist := sess.Collection("artist")
+	assert.NoError(t, artist.Truncate())
+
+	_, err := sess.InsertInto("artist").Values(artistType{Name: "Ozzy"}).Timeout(time.Minute).Exec()
+	assert.NoError(t, err)
+
+	var items []artistType
+	err = sess.SelectFrom("artist").Timeout(time.Minute).All(&items)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(items))
+
+	_, err = sess.Update("artist").Set("name", "Ozzy Osbourne").Timeout(time.Minute).Exec()
+	assert.NoError(t, err)
+
+	_, err = sess.DeleteFrom("artist").Timeout(time.Minute).Exec()
+	assert.NoError(t, err)
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
+func TestExplain(t *testing.T) {
+	sess := mustOpen()
+
+	ctx := context.Background()
+	q := sess.SelectFrom("artist").Where("id", 1)
+
+	switch Adapter {
+	case "postgresql", "sqlite":
+		plan, err := q.Explain(ctx)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, plan.Raw)
+		if assert.NotEmpty(t, plan.Nodes) {
+			tables := []string{}
+			plan.Walk(func(node *sqlbuilder.PlanNode) {
+				if node.Table != "" {
+					tables = append(tables, node.Table)
+				}
+			})
+			assert.Equal(t, []string{"artist"}, tables)
+		}
+
+		_, err = q.ExplainAnalyze(ctx)
+		if Adapter == "sqlite" {
+			assert.Equal(t, db.ErrUnsupported, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	case "mssql", "ql":
+		_, err := q.Explain(ctx)
+		assert.Equal(t, db.ErrUnsupported, err)
+	}
+
+	assert.NoError(t, sess.Close())
+}
+
+func TestMigrate(t *testing.T) {
+	if Adapter == "ql" {
+		t.Skip("Migrations are not supported on ql.")
+	}
+
+	sess := mustOpen()
+
+	for _, table := range []string{"migrate_tracking", "migrate_tracking_lock", "migrate_people"} {
+		if sess.Collection(table).Exists() {
+			_, err := sess.Exec("DROP TABLE " + table)
+			assert.NoError(t, err)
+		}
+	}
+	sess.ClearCache()
+
+	m := migrate.New()
+	m.Table = "migrate_tracking"
+	m.LockTimeout = time.Millisecond * 500
+
+	assert.NoError(t, m.AddSQL(1, "create_people",
+		"CREATE TABLE migrate_people (id INTEGER NOT NULL PRIMARY KEY, name VARCHAR(60))",
+		"DROP TABLE migrate_people",
+	))
+	assert.NoError(t, m.Add(&migrate.Migration{
+		Version: 2,
+		Name:    "add_person",
+		Up: func(tx sqlbuilder.Tx) error {
+			_, err := tx.InsertInto("migrate_people").Values(map[string]interface{}{"id": 1, "name": "Ana"}).Exec()
+			return err
+		},
+		Down: func(tx sqlbuilder.Tx) error {
+			_, err := tx.DeleteFrom("migrate_people").Where("id", 1).Exec()
+			return err
+		},
+	}))
+	assert.Equal(t, migrate.ErrDuplicated, m.AddSQL(2, "again", "SELECT 1", ""))
+
+	assert.NoError(t, m.Migrate(sess))
+	assert.NoError(t, m.Migrate(sess))
+
+	applied, err := m.Applied(sess)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(applied)) {
+		assert.Equal(t, int64(1), applied[0].Version)
+		assert.Equal(t, "create_people", applied[0].Name)
+		assert.Equal(t, int64(2), applied[1].Version)
+	}
+
+	count, err := sess.Collection("migrate_people").Find().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	// Another process holds the lock.
+	_, err = sess.InsertInto("migrate_tracking_lock").Values(map[string]interface{}{"id": 1}).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, migrate.ErrLocked, m.Rollback(sess, 1))
+	assert.NoError(t, m.Unlock(sess))
+
+	assert.NoError(t, m.Rollback(sess, 1))
+	count, err = sess.Collection("migrate_people").Find().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), count)
+
+	assert.NoError(t, m.AddSQL(3, "irreversible", "INSERT INTO migrate_people (id, name) VALUES (3, 'Eva')", ""))
+	assert.NoError(t, m.Migrate(sess))
+
+	applied, err = m.Applied(sess)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(applied))
+
+	assert.Equal(t, migrate.ErrIrreversible, m.Rollback(sess, 3))
+
+	_, err = sess.Exec("DROP TABLE migrate_people")
+	assert.NoError(t, err)
+	_, err = sess.Exec("DROP TABLE migrate_tracking")
+	assert.NoError(t, err)
+	_, err = sess.Exec("DROP TABLE migrate_tracking_lock")
+	assert.NoError(t, err)
+
+	assert.NoError(t, sess.Close())
+}
+
+func TestSQLBuilder(t *testing.T) {
+	sess := mustOpen()
+
+	var all []map[string]interface{}
+
+	err := sess.Collection("artist").Truncate()
+	assert.NoError(t, err)
+
+	_, err = sess.InsertInto("artist").Values(struct {
+		Name string `db:"name"`
+	}{"Rinko Kikuchi"}).Exec()
+	assert.NoError(t, err)
+
+	// Using explicit iterator.
+	iter := sess.SelectFrom("artist").Iterator()
+	err = iter.All(&all)
+
+	assert.NoError(t, err)
+	assert.NotZero(t, all)
+
+	// Using explicit iterator to fetch one item.
+	var item map[string]interface{}
+	iter = sess.SelectFrom("artist").Iterator()
+	err = iter.One(&item)
+
+	assert.NoError(t, err)
+	assert.NotZero(t, item)
+
+	// Using explicit iterator and NextScan.
+	iter = sess.SelectFrom("artist").Iterator()
+	var id int
+	var name string
+
+	if Adapter == "ql" {
+		err = iter.NextScan(&name)
+		id = 1
+	} else {
+		err = iter.NextScan(&id, &name)
+	}
+
+	assert.NoError(t, err)
+	assert.NotZero(t, id)
+	assert.NotEmpty(t, name)
+	assert.NoError(t, iter.Close())
+
+	err = iter.NextScan(&id, &name)
+	assert.Error(t, err)
+
+	// Using explicit iterator and ScanOne.
+	iter = sess.SelectFrom("artist").Iterator()
+	id, name = 0, ""
+	if Adapter == "ql" {
+		err = iter.ScanOne(&name)
+		id = 1
+	} else {
+		err = iter.ScanOne(&id, &name)
+	}
+
+	assert.NoError(t, err)
+	assert.NotZero(t, id)
+	assert.NotEmpty(t, name)
+
+	err = iter.ScanOne(&id, &name)
+	assert.Error(t, err)
+
+	// Using explicit iterator and Next.
+	iter = sess.SelectFrom("artist").Iterator()
+
+	var artist map[string]interface{}
+	for iter.Next(&artist) {
+		if Adapter != "ql" {
+			assert.NotZero(t, artist["id"])
+		}
+		assert.NotEmpty(t, artist["name"])
+	}
+	// We should not have any error after finishing successfully exiting a Next() loop.
+	assert.Empty(t, iter.Err())
+
+	for i := 0; i < 5; i++ {
+		// But we'll get errors if we attempt to continue using Next().
+		assert.False(t, iter.Next(&artist))
+		assert.Error(t, iter.Err())
+	}
+
+	// Using implicit iterator.
+	q := sess.SelectFrom("artist")
+	err = q.All(&all)
+
+	assert.NoError(t, err)
+	assert.NotZero(t, all)
 
 	tx, err := sess.NewTx(nil)
 	assert.NoError(t, err)
@@ -1605,6 +2824,37 @@ func TestSQLBuilder(t *testing.T) {
 	assert.NoError(t, sess.Close())
 }
 
+func TestMetrics(t *testing.T) {
+	sess := mustOpen()
+
+	before := sess.Metrics()
+
+	_, err := sess.Collection("artist").Find().Count()
+	assert.NoError(t, err)
+
+	err = sess.Tx(nil, func(tx sqlbuilder.Tx) error {
+		_, err := tx.Collection("artist").Insert(map[string]string{"name": "Metrics"})
+		return err
+	})
+	assert.NoError(t, err)
+
+	err = sess.Tx(nil, func(tx sqlbuilder.Tx) error {
+		return errors.New("rollback")
+	})
+	assert.Error(t, err)
+
+	after := sess.Metrics()
+
+	assert.True(t, after.OpenConnections > 0)
+	assert.True(t, after.Queries[db.QueryKindSelect].Count > before.Queries[db.QueryKindSelect].Count)
+	assert.True(t, after.Queries[db.QueryKindInsert].Count > before.Queries[db.QueryKindInsert].Count)
+	assert.Equal(t, before.TxCommits+1, after.TxCommits)
+	assert.Equal(t, before.TxRollbacks+1, after.TxRollbacks)
+
+	assert.NoError(t, cleanUpCheck(sess))
+	assert.NoError(t, sess.Close())
+}
+
 func TestExhaustConnectionPool(t *testing.T) {
 	if Adapter == "ql" {
 		t.Skip("Currently not supported.")
@@ -0,0 +1,96 @@
+package sqladapter
+
+import (
+	"reflect"
+	"time"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/reflectx"
+)
+
+const createdAtColumn = "created_at"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// autoTimestamps returns true if the given session has automatic timestamps
+// enabled.
+func autoTimestamps(sess db.Database) bool {
+	return sess != nil && sess.AutoTimestampsEnabled()
+}
+
+// setTimestamps sets the fields of item that have the "auto" option to the
+// current time. The "created_at" field is only set on inserts and only if it
+// is zero, all the other auto fields are set on both inserts and updates.
+// Fields can be of type time.Time, *time.Time or integers (which are set to a
+// Unix timestamp). Items that are not pointers to structs are left untouched.
+func setTimestamps(item interface{}, inserting bool) {
+	itemV := reflect.ValueOf(item)
+	if itemV.Kind() != reflect.Ptr || itemV.IsNil() || itemV.Elem().Kind() != reflect.Struct {
+		return
+	}
+	itemV = itemV.Elem()
+
+	now := time.Now()
+
+	for name, fi := range mapper.TypeMap(itemV.Type()).Names {
+		if _, ok := fi.Options["auto"]; !ok {
+			continue
+		}
+		if name == createdAtColumn && !inserting {
+			continue
+		}
+
+		fld := reflectx.FieldByIndexes(itemV, fi.Index)
+		if !fld.CanSet() {
+			continue
+		}
+		if name == createdAtColumn && !isZeroTimestamp(fld) {
+			continue
+		}
+
+		setTimestamp(fld, now)
+	}
+}
+
+// isTimestampType returns true if values of the given type can be set with
+// setTimestamp.
+func isTimestampType(t reflect.Type) bool {
+	switch {
+	case t == timeType, t == reflect.PtrTo(timeType):
+		return true
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		return true
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// setTimestamp sets fld to the given time, integers are set to a Unix
+// timestamp.
+func setTimestamp(fld reflect.Value, now time.Time) {
+	switch {
+	case fld.Type() == timeType:
+		fld.Set(reflect.ValueOf(now))
+	case fld.Type() == reflect.PtrTo(timeType):
+		t := now
+		fld.Set(reflect.ValueOf(&t))
+	case fld.Kind() >= reflect.Int && fld.Kind() <= reflect.Int64:
+		fld.SetInt(now.Unix())
+	case fld.Kind() >= reflect.Uint && fld.Kind() <= reflect.Uint64:
+		fld.SetUint(uint64(now.Unix()))
+	}
+}
+
+func isZeroTimestamp(fld reflect.Value) bool {
+	if fld.Kind() == reflect.Ptr {
+		if fld.IsNil() {
+			return true
+		}
+		fld = fld.Elem()
+	}
+	if t, ok := fld.Interface().(time.Time); ok {
+		return t.IsZero()
+	}
+	return reflect.DeepEqual(fld.Interface(), reflect.Zero(fld.Type()).Interface())
+}
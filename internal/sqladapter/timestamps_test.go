@@ -0,0 +1,51 @@
+package sqladapter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetTimestamps(t *testing.T) {
+	type itemType struct {
+		Name      string     `db:"name"`
+		CreatedAt time.Time  `db:"created_at,auto"`
+		UpdatedAt *time.Time `db:"updated_at,auto"`
+		Touched   int64      `db:"touched,auto"`
+		Manual    time.Time  `db:"manual"`
+	}
+
+	{
+		item := itemType{}
+		setTimestamps(&item, true)
+
+		assert.False(t, item.CreatedAt.IsZero())
+		assert.NotNil(t, item.UpdatedAt)
+		assert.True(t, item.Touched > 0)
+		assert.True(t, item.Manual.IsZero())
+	}
+
+	{
+		createdAt := time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		item := itemType{CreatedAt: createdAt}
+		setTimestamps(&item, true)
+		assert.Equal(t, createdAt, item.CreatedAt)
+
+		item = itemType{}
+		setTimestamps(&item, false)
+		assert.True(t, item.CreatedAt.IsZero())
+		assert.NotNil(t, item.UpdatedAt)
+		assert.True(t, item.Touched > 0)
+	}
+
+	{
+		// Items that are not pointers can't be modified.
+		item := itemType{}
+		setTimestamps(item, true)
+		assert.True(t, item.CreatedAt.IsZero())
+
+		setTimestamps(map[string]interface{}{"name": "foo"}, true)
+	}
+}
@@ -24,7 +24,9 @@ package sqladapter
 import (
 	"context"
 	"database/sql"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"upper.io/db.v3"
 	"upper.io/db.v3/lib/sqlbuilder"
@@ -36,6 +38,27 @@ type DatabaseTx interface {
 	PartialDatabase
 
 	BaseTx
+
+	// OnCommit registers a function that is called once the transaction is
+	// committed.
+	OnCommit(fn func())
+
+	// OnRollback registers a function that is called once the transaction is
+	// rolled back.
+	OnRollback(fn func(err error))
+
+	// Savepoint creates a savepoint with the given name.
+	Savepoint(name string) error
+
+	// RollbackTo rolls back the transaction to the savepoint with the given
+	// name.
+	RollbackTo(name string) error
+
+	// ReleaseSavepoint releases the savepoint with the given name.
+	ReleaseSavepoint(name string) error
+
+	// rollback rolls back the transaction because of the given error.
+	rollback(cause error) error
 }
 
 // BaseTx provides logic for methods that can be shared across all SQL
@@ -50,6 +73,8 @@ type BaseTx interface {
 type databaseTx struct {
 	Database
 	BaseTx
+
+	hooks TxHooks
 }
 
 // NewDatabaseTx creates a database session within a transaction.
@@ -63,10 +88,12 @@ func NewDatabaseTx(db Database) DatabaseTx {
 type baseTx struct {
 	*sql.Tx
 	committed atomic.Value
+
+	metrics *metrics
 }
 
-func newBaseTx(tx *sql.Tx) BaseTx {
-	return &baseTx{Tx: tx}
+func newBaseTx(tx *sql.Tx, m *metrics) BaseTx {
+	return &baseTx{Tx: tx, metrics: m}
 }
 
 func (b *baseTx) Committed() bool {
@@ -79,6 +106,7 @@ func (b *baseTx) Committed() bool {
 
 func (b *baseTx) Commit() (err error) {
 	err = b.Tx.Commit()
+	b.metrics.observeCommit(err)
 	if err != nil {
 		return err
 	}
@@ -86,18 +114,155 @@ func (b *baseTx) Commit() (err error) {
 	return nil
 }
 
+func (b *baseTx) Rollback() (err error) {
+	err = b.Tx.Rollback()
+	b.metrics.observeRollback(err)
+	return err
+}
+
 func (w *databaseTx) Commit() error {
-	defer w.Database.Close() // Automatic close on commit.
-	return w.BaseTx.Commit()
+	err := w.BaseTx.Commit()
+	w.Database.Close() // Automatic close on commit.
+
+	if err != nil {
+		w.hooks.RunOnRollback(err)
+		return err
+	}
+	w.hooks.RunOnCommit()
+	return nil
 }
 
 func (w *databaseTx) Rollback() error {
-	defer w.Database.Close() // Automatic close on rollback.
-	return w.BaseTx.Rollback()
+	return w.rollback(nil)
+}
+
+func (w *databaseTx) rollback(cause error) error {
+	err := w.BaseTx.Rollback()
+	w.Database.Close() // Automatic close on rollback.
+
+	w.hooks.RunOnRollback(cause)
+	return err
+}
+
+func (w *databaseTx) OnCommit(fn func()) {
+	w.hooks.OnCommit(fn)
+}
+
+func (w *databaseTx) OnRollback(fn func(err error)) {
+	w.hooks.OnRollback(fn)
+}
+
+// TxHooks keeps the functions registered with OnCommit and OnRollback on a
+// transaction until its outcome is known. Only the functions of one outcome
+// are called, and only once.
+type TxHooks struct {
+	mu         sync.Mutex
+	onCommit   []func()
+	onRollback []func(err error)
+}
+
+// OnCommit registers a function to be called by RunOnCommit.
+func (h *TxHooks) OnCommit(fn func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onCommit = append(h.onCommit, fn)
+}
+
+// OnRollback registers a function to be called by RunOnRollback.
+func (h *TxHooks) OnRollback(fn func(err error)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onRollback = append(h.onRollback, fn)
 }
 
-// RunTx creates a transaction context and runs fn within it.
+// RunOnCommit calls the functions registered with OnCommit, in order, and
+// forgets all the registered functions.
+func (h *TxHooks) RunOnCommit() {
+	onCommit, _ := h.reset()
+	for _, fn := range onCommit {
+		fn()
+	}
+}
+
+// RunOnRollback calls the functions registered with OnRollback with the
+// error that caused the rollback, in order, and forgets all the registered
+// functions.
+func (h *TxHooks) RunOnRollback(err error) {
+	_, onRollback := h.reset()
+	for _, fn := range onRollback {
+		fn(err)
+	}
+}
+
+func (h *TxHooks) reset() ([]func(), []func(error)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	onCommit, onRollback := h.onCommit, h.onRollback
+	h.onCommit, h.onRollback = nil, nil
+	return onCommit, onRollback
+}
+
+// ValidGID reports whether gid can be used as the global identifier of a
+// distributed transaction, identifiers are at most 64 bytes long and only
+// letters, digits, underscores, hyphens, dots and colons are allowed so they
+// can be quoted safely by any adapter.
+func ValidGID(gid string) bool {
+	if gid == "" || len(gid) > 64 {
+		return false
+	}
+	for _, r := range gid {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '_', r == '-', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type hasErr interface {
+	Err(error) error
+}
+
+// RunTx creates a transaction context and runs fn within it. If the session
+// has a retry policy, transactions that fail because of serialization
+// failures or deadlocks are run again.
 func RunTx(d sqlbuilder.Database, ctx context.Context, fn func(tx sqlbuilder.Tx) error) error {
+	policy := d.TxRetryPolicy()
+	if policy == nil {
+		return runTx(d, ctx, fn)
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := runTx(d, ctx, fn)
+		if err == nil || attempt >= policy.MaxAttempts || !isSerializationFailure(d, err) {
+			return err
+		}
+
+		select {
+		case <-time.After(policy.Backoff(attempt)):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+func isSerializationFailure(d sqlbuilder.Database, err error) bool {
+	if db.ErrorKind(err) == db.ErrSerializationFailure {
+		return true
+	}
+	if e, ok := d.(hasErr); ok {
+		return db.ErrorKind(e.Err(err)) == db.ErrSerializationFailure
+	}
+	return false
+}
+
+func runTx(d sqlbuilder.Database, ctx context.Context, fn func(tx sqlbuilder.Tx) error) error {
 	tx, err := d.NewTx(ctx)
 	if err != nil {
 		return err
@@ -105,7 +270,13 @@ func RunTx(d sqlbuilder.Database, ctx context.Context, fn func(tx sqlbuilder.Tx)
 
 	defer tx.Close()
 	if err := fn(tx); err != nil {
-		tx.Rollback()
+		if t, ok := tx.(interface {
+			rollback(cause error) error
+		}); ok {
+			t.rollback(err)
+		} else {
+			tx.Rollback()
+		}
 		return err
 	}
 	return tx.Commit()
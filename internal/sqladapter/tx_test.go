@@ -0,0 +1,57 @@
+package sqladapter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidGID(t *testing.T) {
+	for _, gid := range []string{"transfer-42", "order_1", "app:node.1", strings.Repeat("x", 64)} {
+		assert.True(t, ValidGID(gid), gid)
+	}
+	for _, gid := range []string{"", "it's", "a b", "tx?", "tx;--", "tránsfer", strings.Repeat("x", 65)} {
+		assert.False(t, ValidGID(gid), gid)
+	}
+}
+
+func TestValidSavepointName(t *testing.T) {
+	for _, name := range []string{"item", "row_1", "_sp", strings.Repeat("x", 63)} {
+		assert.True(t, validSavepointName(name), name)
+	}
+	for _, name := range []string{"", "1st", "a b", "sp;--", "sp-1", "ítem", strings.Repeat("x", 64)} {
+		assert.False(t, validSavepointName(name), name)
+	}
+}
+
+func TestTxHooks(t *testing.T) {
+	var calls []string
+
+	var hooks TxHooks
+	hooks.OnCommit(func() { calls = append(calls, "commit 1") })
+	hooks.OnCommit(func() { calls = append(calls, "commit 2") })
+	hooks.OnRollback(func(err error) { calls = append(calls, "rollback") })
+
+	hooks.RunOnCommit()
+	assert.Equal(t, []string{"commit 1", "commit 2"}, calls)
+
+	// Hooks are only called once.
+	hooks.RunOnCommit()
+	hooks.RunOnRollback(nil)
+	assert.Equal(t, []string{"commit 1", "commit 2"}, calls)
+
+	calls = nil
+	cause := errors.New("failed")
+
+	hooks.OnCommit(func() { calls = append(calls, "commit") })
+	hooks.OnRollback(func(err error) {
+		assert.Equal(t, cause, err)
+		calls = append(calls, "rollback")
+	})
+
+	hooks.RunOnRollback(cause)
+	hooks.RunOnCommit()
+	assert.Equal(t, []string{"rollback"}, calls)
+}
@@ -0,0 +1,461 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInexactInterval is returned when converting an interval with months
+// into a time.Duration, as the length of a month depends on the date it's
+// added to.
+var ErrInexactInterval = errors.New(`upper: interval with months can't be converted into a time.Duration`)
+
+// Interval is a span of time, meant for PostgreSQL interval columns and
+// MySQL TIME columns. Like PostgreSQL, it keeps months and days apart from
+// the rest of the span, as their lengths depend on the date they are added
+// to: one month from January 31 is February 28 or 29 and one day may last 23
+// or 25 hours when daylight saving time starts or ends.
+//
+// Intervals without months or days are written as clock times, like
+// "-04:05:06.5", which both PostgreSQL and MySQL accept, the rest are written
+// in the ISO 8601 format PostgreSQL accepts, like "P1Y2M3DT4H5M6.5S". They
+// can be scanned from both formats and from the default output format of
+// PostgreSQL, like "1 year 2 mons 3 days 04:05:06.5".
+type Interval struct {
+	Months   int32
+	Days     int32
+	Duration time.Duration
+}
+
+// ParseInterval parses an interval in ISO 8601 format, like "P1Y2M3DT4H" or
+// "-PT1H30M", in the PostgreSQL format, like "1 year 2 mons 3 days 04:00:00",
+// or a clock time, like "-838:59:59.000000".
+func ParseInterval(s string) (Interval, error) {
+	str := strings.TrimSpace(s)
+
+	var p intervalParser
+	if strings.HasPrefix(str, "P") || strings.HasPrefix(str, "-P") {
+		p.parseISO(str)
+	} else {
+		p.parseText(str)
+	}
+
+	if p.invalid || p.months < math.MinInt32 || p.months > math.MaxInt32 || p.days < math.MinInt32 || p.days > math.MaxInt32 {
+		return Interval{}, fmt.Errorf("Invalid interval %q", s)
+	}
+	return Interval{Months: int32(p.months), Days: int32(p.days), Duration: time.Duration(p.nanos)}, nil
+}
+
+// intervalParser accumulates the components of an interval, invalid is set
+// for malformed input and for values that overflow.
+type intervalParser struct {
+	months, days, nanos int64
+	invalid             bool
+}
+
+// parseISO parses the ISO 8601 format, components may be negative, like in
+// "P-1Y-2M3DT-4H", which is how PostgreSQL writes them, and a leading sign
+// negates the whole interval.
+func (p *intervalParser) parseISO(s string) {
+	negate := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "P")
+	if s == "" {
+		p.invalid = true
+		return
+	}
+
+	inTime := false
+	for s != "" && !p.invalid {
+		if s[0] == 'T' {
+			if inTime || len(s) == 1 {
+				p.invalid = true
+				return
+			}
+			inTime = true
+			s = s[1:]
+			continue
+		}
+
+		i := strings.IndexFunc(s, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '-' && r != '+' && r != '.'
+		})
+		if i <= 0 {
+			p.invalid = true
+			return
+		}
+		number, designator := s[:i], s[i]
+		s = s[i+1:]
+
+		switch {
+		case !inTime && designator == 'Y':
+			p.addInt(&p.months, number, 12)
+		case !inTime && designator == 'M':
+			p.addInt(&p.months, number, 1)
+		case !inTime && designator == 'W':
+			p.addInt(&p.days, number, 7)
+		case !inTime && designator == 'D':
+			p.addInt(&p.days, number, 1)
+		case inTime && designator == 'H':
+			p.addNanos(number, time.Hour)
+		case inTime && designator == 'M':
+			p.addNanos(number, time.Minute)
+		case inTime && designator == 'S':
+			p.addNanos(number, time.Second)
+		default:
+			p.invalid = true
+		}
+	}
+
+	if negate && !p.invalid {
+		p.months, p.days = -p.months, -p.days
+		if p.nanos == math.MinInt64 {
+			p.invalid = true
+		}
+		p.nanos = -p.nanos
+	}
+}
+
+// parseText parses the PostgreSQL format, which is a list of numbers and
+// units followed by a clock time, like "-1 days +02:03:00". A clock time
+// alone is how MySQL writes TIME values.
+func (p *intervalParser) parseText(s string) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		p.invalid = true
+		return
+	}
+
+	clock := false
+	for i := 0; i < len(fields) && !p.invalid; i++ {
+		if strings.Contains(fields[i], ":") {
+			if clock {
+				p.invalid = true
+				return
+			}
+			clock = true
+			p.addClock(fields[i])
+			continue
+		}
+
+		if i+1 == len(fields) {
+			p.invalid = true
+			return
+		}
+		number, unit := fields[i], fields[i+1]
+		i++
+
+		switch unit {
+		case "year", "years":
+			p.addInt(&p.months, number, 12)
+		case "mon", "mons", "month", "months":
+			p.addInt(&p.months, number, 1)
+		case "week", "weeks":
+			p.addInt(&p.days, number, 7)
+		case "day", "days":
+			p.addInt(&p.days, number, 1)
+		case "hour", "hours":
+			p.addNanos(number, time.Hour)
+		case "min", "mins", "minute", "minutes":
+			p.addNanos(number, time.Minute)
+		case "sec", "secs", "second", "seconds":
+			p.addNanos(number, time.Second)
+		default:
+			p.invalid = true
+		}
+	}
+}
+
+// addClock adds a clock time, like "-04:05:06.5", "+02:03" or "838:59:59".
+func (p *intervalParser) addClock(s string) {
+	negative := strings.HasPrefix(s, "-")
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 || !isDigits(parts[0]) || len(parts[1]) != 2 || !isDigits(parts[1]) {
+		p.invalid = true
+		return
+	}
+	if len(parts) == 3 && (len(parts[2]) < 2 || strings.ContainsAny(parts[2], "+-")) {
+		p.invalid = true
+		return
+	}
+
+	var seconds intervalParser
+	if len(parts) == 3 {
+		seconds.addNanos(parts[2], time.Second)
+	}
+	if seconds.invalid || seconds.nanos >= int64(time.Minute) || parts[1] > "59" {
+		p.invalid = true
+		return
+	}
+
+	clock := seconds
+	clock.addNanos(parts[0], time.Hour)
+	clock.addNanos(parts[1], time.Minute)
+	if clock.invalid {
+		p.invalid = true
+		return
+	}
+
+	if negative {
+		clock.nanos = -clock.nanos
+	}
+	var ok bool
+	if p.nanos, ok = addInt64(p.nanos, clock.nanos); !ok {
+		p.invalid = true
+	}
+}
+
+// addInt adds an integer number of units to dst.
+func (p *intervalParser) addInt(dst *int64, number string, unit int64) {
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		p.invalid = true
+		return
+	}
+	v, ok := mulInt64(n, unit)
+	if ok {
+		*dst, ok = addInt64(*dst, v)
+	}
+	if !ok {
+		p.invalid = true
+	}
+}
+
+// addNanos adds a number of units, which may have up to nine decimal
+// places, to the nanoseconds of the interval.
+func (p *intervalParser) addNanos(number string, unit time.Duration) {
+	negative := strings.HasPrefix(number, "-")
+	digits := strings.TrimLeft(number, "+-")
+	if len(number)-len(digits) > 1 {
+		p.invalid = true
+		return
+	}
+
+	whole, frac := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		whole, frac = digits[:i], digits[i+1:]
+	}
+	if whole == "" && frac == "" || len(frac) > 9 || !isDigits(whole) || !isDigits(frac) {
+		p.invalid = true
+		return
+	}
+
+	var n int64
+	if whole != "" {
+		var err error
+		if n, err = strconv.ParseInt(whole, 10, 64); err != nil {
+			p.invalid = true
+			return
+		}
+	}
+	nanos, ok := mulInt64(n, int64(unit))
+	if ok && frac != "" {
+		f, _ := strconv.ParseInt(frac, 10, 64)
+		// Every unit is a multiple of 10^9 nanoseconds, so the fraction is
+		// exact.
+		nanos, ok = addInt64(nanos, f*(int64(unit)/int64(math.Pow10(len(frac)))))
+	}
+	if negative {
+		nanos = -nanos
+	}
+	if ok {
+		p.nanos, ok = addInt64(p.nanos, nanos)
+	}
+	if !ok {
+		p.invalid = true
+	}
+}
+
+func isDigits(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' }) < 0
+}
+
+func addInt64(a, b int64) (int64, bool) {
+	c := a + b
+	return c, (c > a) == (b > 0)
+}
+
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	return c, c/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64)
+}
+
+// IsZero returns whether the interval is empty.
+func (i Interval) IsZero() bool {
+	return i.Months == 0 && i.Days == 0 && i.Duration == 0
+}
+
+// Neg returns the interval with the sign of its components reversed.
+func (i Interval) Neg() Interval {
+	return Interval{Months: -i.Months, Days: -i.Days, Duration: -i.Duration}
+}
+
+// AddTo returns t plus the interval, months are added first, then days and
+// then the rest of the interval, like PostgreSQL does.
+func (i Interval) AddTo(t time.Time) time.Time {
+	return t.AddDate(0, int(i.Months), int(i.Days)).Add(i.Duration)
+}
+
+// TimeDuration converts the interval into a time.Duration, days are counted
+// as 24 hours. It fails with ErrInexactInterval for intervals with months.
+func (i Interval) TimeDuration() (time.Duration, error) {
+	if i.Months != 0 {
+		return 0, ErrInexactInterval
+	}
+	days, ok := mulInt64(int64(i.Days), int64(24*time.Hour))
+	if ok {
+		days, ok = addInt64(days, int64(i.Duration))
+	}
+	if !ok {
+		return 0, fmt.Errorf("Interval %v overflows a time.Duration", i)
+	}
+	return time.Duration(days), nil
+}
+
+// String returns the interval in ISO 8601 format, like "P1Y2M3DT4H5M6.5S",
+// components keep their own sign, like in "PT-1H-30M".
+func (i Interval) String() string {
+	if i.IsZero() {
+		return "PT0S"
+	}
+
+	b := []byte{'P'}
+	for _, c := range []struct {
+		n          int64
+		designator byte
+	}{{int64(i.Months / 12), 'Y'}, {int64(i.Months % 12), 'M'}, {int64(i.Days), 'D'}} {
+		if c.n != 0 {
+			b = strconv.AppendInt(b, c.n, 10)
+			b = append(b, c.designator)
+		}
+	}
+
+	if i.Duration != 0 {
+		b = append(b, 'T')
+		d := i.Duration
+		if h := d / time.Hour; h != 0 {
+			b = strconv.AppendInt(b, int64(h), 10)
+			b = append(b, 'H')
+		}
+		d %= time.Hour
+		if m := d / time.Minute; m != 0 {
+			b = strconv.AppendInt(b, int64(m), 10)
+			b = append(b, 'M')
+		}
+		d %= time.Minute
+		if d != 0 {
+			if d < 0 {
+				b = append(b, '-')
+				d = -d
+			}
+			b = appendSeconds(b, d)
+			b = append(b, 'S')
+		}
+	}
+	return string(b)
+}
+
+// clock returns the duration as a clock time, like "-04:05:06.5".
+func clock(d time.Duration) string {
+	var b []byte
+	u := uint64(d)
+	if d < 0 {
+		b = append(b, '-')
+		u = -u
+	}
+	h, m, s := u/uint64(time.Hour), u%uint64(time.Hour)/uint64(time.Minute), time.Duration(u%uint64(time.Minute))
+	if h < 10 {
+		b = append(b, '0')
+	}
+	b = strconv.AppendUint(b, h, 10)
+	b = append(b, ':', byte('0'+m/10), byte('0'+m%10), ':')
+	if s < 10*time.Second {
+		b = append(b, '0')
+	}
+	return string(appendSeconds(b, s))
+}
+
+// appendSeconds appends the positive duration d, which is less than a
+// minute, as seconds with as many decimal places as needed.
+func appendSeconds(b []byte, d time.Duration) []byte {
+	b = strconv.AppendInt(b, int64(d/time.Second), 10)
+	if ns := d % time.Second; ns != 0 {
+		frac := strconv.FormatInt(int64(ns)+int64(time.Second), 10)[1:]
+		b = append(b, '.')
+		b = append(b, strings.TrimRight(frac, "0")...)
+	}
+	return b
+}
+
+// Scan implements the sql.Scanner interface.
+func (i *Interval) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*i = Interval{}
+		return nil
+	case []byte:
+		return i.Scan(string(src))
+	case string:
+		v, err := ParseInterval(src)
+		if err != nil {
+			return err
+		}
+		*i = v
+		return nil
+	}
+	return fmt.Errorf("Cannot scan %T into Interval", src)
+}
+
+// Value implements the driver.Valuer interface.
+func (i Interval) Value() (driver.Value, error) {
+	if i.Months == 0 && i.Days == 0 {
+		return clock(i.Duration), nil
+	}
+	return i.String(), nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (i Interval) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (i *Interval) UnmarshalText(text []byte) error {
+	v, err := ParseInterval(string(text))
+	if err != nil {
+		return err
+	}
+	*i = v
+	return nil
+}
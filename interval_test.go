@@ -0,0 +1,149 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseInterval(t *testing.T) {
+	testCases := []struct {
+		in       string
+		expected Interval
+	}{
+		{"P1Y2M3DT4H5M6.5S", Interval{Months: 14, Days: 3, Duration: 4*time.Hour + 5*time.Minute + 6500*time.Millisecond}},
+		{"PT0S", Interval{}},
+		{"P2W", Interval{Days: 14}},
+		{"PT1.5H", Interval{Duration: 90 * time.Minute}},
+		{"-P1DT1H", Interval{Days: -1, Duration: -time.Hour}},
+		{"P-1Y-2M3DT-4H-5M-6S", Interval{Months: -14, Days: 3, Duration: -(4*time.Hour + 5*time.Minute + 6*time.Second)}},
+
+		{"1 year 2 mons 3 days 04:05:06.5", Interval{Months: 14, Days: 3, Duration: 4*time.Hour + 5*time.Minute + 6500*time.Millisecond}},
+		{"-1 days +02:03:00", Interval{Days: -1, Duration: 2*time.Hour + 3*time.Minute}},
+		{"3 mons", Interval{Months: 3}},
+		{"2 weeks 1 day", Interval{Days: 15}},
+		{"1.5 hours 30 mins", Interval{Duration: 2 * time.Hour}},
+		{"00:00:00", Interval{}},
+
+		{"-838:59:59.000000", Interval{Duration: -(838*time.Hour + 59*time.Minute + 59*time.Second)}},
+		{"12:30", Interval{Duration: 12*time.Hour + 30*time.Minute}},
+		{"00:00:00.000001", Interval{Duration: time.Microsecond}},
+	}
+	for _, test := range testCases {
+		i, err := ParseInterval(test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i != test.expected {
+			t.Fatalf("%s: Got: %#v, Expecting: %#v", test.in, i, test.expected)
+		}
+	}
+
+	for _, s := range []string{
+		"", "P", "PT", "P1", "PY", "P1.5D", "P1H", "PT1D", "P1DT", "P--1D", "PT1.0000000001S",
+		"1", "1 fortnight", "1 day 2", "01:60:00", "01:00:60", "01:00:100", "1:2:3", "--01:00:00", "01:00:00 02:00:00",
+		"P99999999999Y", "9999999999999:00:00",
+	} {
+		if _, err := ParseInterval(s); err == nil {
+			t.Fatalf("Expecting an error for %q", s)
+		}
+	}
+}
+
+func TestIntervalString(t *testing.T) {
+	testCases := []struct {
+		i        Interval
+		expected string
+		value    string
+	}{
+		{Interval{}, "PT0S", "00:00:00"},
+		{Interval{Months: 14, Days: 3, Duration: 4*time.Hour + 5*time.Minute + 6500*time.Millisecond}, "P1Y2M3DT4H5M6.5S", "P1Y2M3DT4H5M6.5S"},
+		{Interval{Duration: -90 * time.Minute}, "PT-1H-30M", "-01:30:00"},
+		{Interval{Duration: -time.Millisecond}, "PT-0.001S", "-00:00:00.001"},
+		{Interval{Duration: 838*time.Hour + 59*time.Minute + 59*time.Second}, "PT838H59M59S", "838:59:59"},
+		{Interval{Days: -1}, "P-1D", "P-1D"},
+	}
+	for _, test := range testCases {
+		if s := test.i.String(); s != test.expected {
+			t.Fatalf("Got: %v, Expecting: %v", s, test.expected)
+		}
+		v, err := test.i.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != test.value {
+			t.Fatalf("Got: %v, Expecting: %v", v, test.value)
+		}
+
+		// Both formats are read back.
+		for _, s := range []string{test.expected, test.value} {
+			var i Interval
+			if err := i.Scan([]byte(s)); err != nil {
+				t.Fatal(err)
+			}
+			if i != test.i {
+				t.Fatalf("%s: Got: %#v, Expecting: %#v", s, i, test.i)
+			}
+		}
+	}
+}
+
+func TestIntervalDuration(t *testing.T) {
+	d, err := Interval{Days: 1, Duration: time.Hour}.TimeDuration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != 25*time.Hour {
+		t.Fatalf("Got: %v, Expecting: %v", d, 25*time.Hour)
+	}
+
+	if _, err := (Interval{Months: 1}).TimeDuration(); err != ErrInexactInterval {
+		t.Fatalf("Got: %v, Expecting: %v", err, ErrInexactInterval)
+	}
+	if _, err := (Interval{Days: 1 << 30}).TimeDuration(); err == nil {
+		t.Fatal("Expecting an error")
+	}
+
+	jan31 := time.Date(2024, time.January, 31, 10, 0, 0, 0, time.UTC)
+	if got := (Interval{Months: 1, Days: 1, Duration: time.Hour}).AddTo(jan31); !got.Equal(time.Date(2024, time.March, 3, 11, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected time %v", got)
+	}
+	if got := (Interval{Months: 1, Days: 1, Duration: time.Hour}).Neg(); got != (Interval{Months: -1, Days: -1, Duration: -time.Hour}) {
+		t.Fatalf("Unexpected interval %v", got)
+	}
+
+	var i Interval
+	if err := i.Scan(int64(1)); err == nil {
+		t.Fatal("Expecting an error")
+	}
+	if err := i.Scan(nil); err != nil || !i.IsZero() {
+		t.Fatalf("Unexpected interval %v (%v)", i, err)
+	}
+}
+
+func TestIntervalJSON(t *testing.T) {
+	type plan struct {
+		Trial  Interval  `json:"trial"`
+		Period *Interval `json:"period"`
+	}
+
+	buf, err := json.Marshal(plan{Trial: Interval{Days: 14}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != `{"trial":"P14D","period":null}` {
+		t.Fatalf("Unexpected JSON %s", buf)
+	}
+
+	var p plan
+	if err := json.Unmarshal([]byte(`{"trial": "PT12H", "period": "P1M"}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Trial != (Interval{Duration: 12 * time.Hour}) || *p.Period != (Interval{Months: 1}) {
+		t.Fatalf("Unexpected plan %v", p)
+	}
+
+	if err := json.Unmarshal([]byte(`{"trial": "x"}`), &p); err == nil {
+		t.Fatal("Expecting an error")
+	}
+}
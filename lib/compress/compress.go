@@ -0,0 +1,124 @@
+// Package compress provides codecs that compress the values of large text or
+// binary fields, like documents stored in bytea or BLOB columns:
+//
+//	type Page struct {
+//		ID   int64  `db:"id,omitempty"`
+//		Body string `db:"body,codec=zstd"`
+//	}
+//
+//	compress.Register(sess)
+//
+// Values that were not compressed by the codec, like the ones written before
+// the codec was enabled on a column, are read as they are.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"upper.io/db.v3"
+)
+
+// Names the codecs are registered under by Register.
+const (
+	GzipName = "gzip"
+	ZstdName = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Gzip compresses values with gzip at the default compression level.
+var Gzip = GzipLevel(gzip.DefaultCompression)
+
+// Zstd compresses values with zstd at the default compression level.
+var Zstd db.Codec = &zstdCodec{}
+
+// Register registers Gzip and Zstd on the given session under the "gzip" and
+// "zstd" names.
+func Register(sess db.Settings) {
+	sess.SetCodec(GzipName, Gzip)
+	sess.SetCodec(ZstdName, Zstd)
+}
+
+// GzipLevel returns a codec that compresses values with gzip at the given
+// compression level, see compress/gzip.
+func GzipLevel(level int) db.Codec {
+	return gzipCodec{level: level}
+}
+
+type gzipCodec struct {
+	level int
+}
+
+func (c gzipCodec) Encode(value []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, c.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(value); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c gzipCodec) Decode(value []byte) ([]byte, error) {
+	if !bytes.HasPrefix(value, gzipMagic) {
+		return value, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(value))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// zstdCodec shares an encoder and a decoder among all the values, both are
+// safe for concurrent use with EncodeAll and DecodeAll.
+type zstdCodec struct {
+	once    sync.Once
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+	err     error
+}
+
+func (c *zstdCodec) init() error {
+	c.once.Do(func() {
+		if c.encoder, c.err = zstd.NewWriter(nil); c.err != nil {
+			return
+		}
+		c.decoder, c.err = zstd.NewReader(nil)
+	})
+	return c.err
+}
+
+func (c *zstdCodec) Encode(value []byte) ([]byte, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	return c.encoder.EncodeAll(value, nil), nil
+}
+
+func (c *zstdCodec) Decode(value []byte) ([]byte, error) {
+	if !bytes.HasPrefix(value, zstdMagic) {
+		return value, nil
+	}
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	return c.decoder.DecodeAll(value, nil)
+}
@@ -0,0 +1,51 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+)
+
+func TestCodecs(t *testing.T) {
+	document := bytes.Repeat([]byte("upper.io/db "), 1000)
+
+	for name, codec := range map[string]db.Codec{
+		"gzip":      Gzip,
+		"gzip-best": GzipLevel(gzip.BestCompression),
+		"zstd":      Zstd,
+	} {
+		encoded, err := codec.Encode(document)
+		assert.NoError(t, err, name)
+		assert.True(t, len(encoded) < len(document)/10, name)
+
+		decoded, err := codec.Decode(encoded)
+		assert.NoError(t, err, name)
+		assert.Equal(t, document, decoded, name)
+
+		empty, err := codec.Encode([]byte{})
+		assert.NoError(t, err, name)
+		decoded, err = codec.Decode(empty)
+		assert.NoError(t, err, name)
+		assert.Empty(t, decoded, name)
+
+		decoded, err = codec.Decode([]byte("not compressed"))
+		assert.NoError(t, err, name)
+		assert.Equal(t, []byte("not compressed"), decoded, name)
+	}
+
+	_, err := GzipLevel(42).Encode(document)
+	assert.Error(t, err)
+
+	_, err = Zstd.Decode(append(append([]byte{}, zstdMagic...), "corrupted"...))
+	assert.Error(t, err)
+}
+
+func TestRegister(t *testing.T) {
+	s := db.NewSettings()
+	Register(s)
+	assert.Equal(t, Gzip, s.Codec("gzip"))
+	assert.Equal(t, Zstd, s.Codec("zstd"))
+}
@@ -0,0 +1,46 @@
+package dbtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3/mysql"
+	"upper.io/db.v3/postgresql"
+)
+
+func TestPublishedPort(t *testing.T) {
+	port, err := publishedPort("0.0.0.0:49153\n[::]:49153")
+	assert.NoError(t, err)
+	assert.Equal(t, "49153", port)
+
+	port, err = publishedPort("[::]:5432")
+	assert.NoError(t, err)
+	assert.Equal(t, "5432", port)
+
+	_, err = publishedPort("")
+	assert.Error(t, err)
+}
+
+func TestDockerHost(t *testing.T) {
+	assert.Equal(t, "127.0.0.1", dockerHost(""))
+	assert.Equal(t, "127.0.0.1", dockerHost("unix:///var/run/docker.sock"))
+	assert.Equal(t, "10.0.0.7", dockerHost("tcp://10.0.0.7:2376"))
+}
+
+func TestWithDatabase(t *testing.T) {
+	{
+		u, err := postgresServer.parseURL("user=user password=secret host=localhost port=5433 dbname=postgres")
+		assert.NoError(t, err)
+		u = postgresServer.withDatabase(u, "dbtest_1_1")
+		assert.Equal(t, "dbtest_1_1", u.(postgresql.ConnectionURL).Database)
+		assert.Equal(t, "localhost:5433", u.(postgresql.ConnectionURL).Host)
+	}
+
+	{
+		u, err := mysqlServer.parseURL("user:secret@tcp(localhost:3307)/mysql")
+		assert.NoError(t, err)
+		u = mysqlServer.withDatabase(u, "dbtest_1_1")
+		assert.Equal(t, "dbtest_1_1", u.(mysql.ConnectionURL).Database)
+		assert.Equal(t, "localhost:3307", u.(mysql.ConnectionURL).Host)
+	}
+}
@@ -0,0 +1,443 @@
+// Package fixtures loads rows from YAML or JSON files into SQL databases, so
+// integration tests can start from known data.
+//
+// Fixture files map tables to the rows they should have:
+//
+//	users:
+//	  - id: 1
+//	    email: ana@example.com
+//	    created_at: '{{ ago "24h" }}'
+//	orders:
+//	  - id: 1
+//	    user_id: 1
+//	    items: [{sku: "A-1", qty: 2}]
+//
+// Files are rendered as text/template templates before they're parsed, see
+// Funcs for the functions they can use. Nested objects and lists, like the
+// items of the order above, are stored as JSON.
+//
+// Rows are inserted in an order that satisfies foreign keys, tables are
+// inserted after the ones they reference on databases that can tell which
+// ones those are (PostgreSQL, MySQL, SQLite and SQL Server), and in the order
+// they were added on the rest:
+//
+//	f := fixtures.New()
+//	err := f.AddFile("testdata/fixtures.yml")
+//	...
+//	f.Setup(t, sess) // Loads the rows and deletes them once the test ends.
+//
+// Explicit values for auto incremented columns don't advance sequences on
+// PostgreSQL, use ids that won't clash with the ones of rows created by the
+// test, like large ones.
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// TimeLayout is the layout of the times rendered by the now, ago and later
+// template functions, every supported database can read it.
+const TimeLayout = "2006-01-02 15:04:05"
+
+// ErrUnknownFormat is returned when the format of a fixture file can't be
+// told from its extension.
+var ErrUnknownFormat = errors.New(`upper: unknown fixture format, expecting .json, .yml or .yaml`)
+
+// referencer is implemented by sessions that can tell which tables a table
+// references with foreign keys.
+type referencer interface {
+	ReferencedTables(table string) ([]string, error)
+}
+
+// T is the part of testing.TB that Setup and Tx use.
+type T interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+type table struct {
+	name string
+	rows []map[string]interface{}
+}
+
+// Fixtures is a set of rows to be loaded into a database.
+type Fixtures struct {
+	tables []*table
+	funcs  template.FuncMap
+}
+
+// New returns an empty set of fixtures.
+func New() *Fixtures {
+	return &Fixtures{
+		funcs: template.FuncMap{
+			"now": func() string {
+				return time.Now().UTC().Format(TimeLayout)
+			},
+			"ago": func(d string) (string, error) {
+				v, err := time.ParseDuration(d)
+				if err != nil {
+					return "", err
+				}
+				return time.Now().UTC().Add(-v).Format(TimeLayout), nil
+			},
+			"later": func(d string) (string, error) {
+				v, err := time.ParseDuration(d)
+				if err != nil {
+					return "", err
+				}
+				return time.Now().UTC().Add(v).Format(TimeLayout), nil
+			},
+		},
+	}
+}
+
+// Funcs adds the given functions to the ones fixture files can use, which
+// are now, ago and later. now renders the current time in UTC, ago and
+// later render the current time minus or plus a duration, like
+// {{ ago "2h30m" }}. Funcs must be called before adding files.
+func (f *Fixtures) Funcs(funcs template.FuncMap) *Fixtures {
+	for name, fn := range funcs {
+		f.funcs[name] = fn
+	}
+	return f
+}
+
+// Add adds rows to the given table.
+func (f *Fixtures) Add(table string, rows ...map[string]interface{}) {
+	t := f.table(table)
+	t.rows = append(t.rows, rows...)
+}
+
+// Tables returns the names of the tables of the fixtures, in the order they
+// were added.
+func (f *Fixtures) Tables() []string {
+	names := make([]string, len(f.tables))
+	for i := range f.tables {
+		names[i] = f.tables[i].name
+	}
+	return names
+}
+
+func (f *Fixtures) table(name string) *table {
+	for _, t := range f.tables {
+		if t.name == name {
+			return t
+		}
+	}
+	t := &table{name: name}
+	f.tables = append(f.tables, t)
+	return t
+}
+
+// Parse adds the rows of the given fixture file content, name is only used
+// to tell the format, by its extension, and in errors.
+func (f *Fixtures) Parse(name string, content []byte) error {
+	tmpl, err := template.New(name).Funcs(f.funcs).Parse(string(content))
+	if err != nil {
+		return err
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := tmpl.Execute(buf, nil); err != nil {
+		return err
+	}
+
+	var tables []*table
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json":
+		tables, err = parseJSON(buf.Bytes())
+	case ".yml", ".yaml":
+		tables, err = parseYAML(buf.Bytes())
+	default:
+		return ErrUnknownFormat
+	}
+	if err != nil {
+		return fmt.Errorf("upper: can't parse fixture %q: %v", name, err)
+	}
+
+	for _, t := range tables {
+		f.Add(t.name, t.rows...)
+	}
+	return nil
+}
+
+// AddFile adds the rows of the given fixture file.
+func (f *Fixtures) AddFile(name string) error {
+	content, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return f.Parse(name, content)
+}
+
+// Load deletes the rows of the tables of the fixtures and inserts the rows of
+// the fixtures, within a transaction.
+func (f *Fixtures) Load(sess sqlbuilder.Database) error {
+	tables, err := f.ordered(sess)
+	if err != nil {
+		return err
+	}
+	return sess.Tx(sess.Context(), func(tx sqlbuilder.Tx) error {
+		return load(tx, tables)
+	})
+}
+
+// Clean deletes the rows of the tables of the fixtures, tables that
+// reference others are emptied first.
+func (f *Fixtures) Clean(sess sqlbuilder.Database) error {
+	tables, err := f.ordered(sess)
+	if err != nil {
+		return err
+	}
+	return sess.Tx(sess.Context(), func(tx sqlbuilder.Tx) error {
+		return clean(tx, tables)
+	})
+}
+
+// Setup loads the fixtures and cleans them up once the test and its subtests
+// end, the test fails if either can't be done.
+func (f *Fixtures) Setup(t T, sess sqlbuilder.Database) {
+	t.Helper()
+	if err := f.Load(sess); err != nil {
+		t.Fatalf("fixtures: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := f.Clean(sess); err != nil {
+			t.Fatalf("fixtures: %v", err)
+		}
+	})
+}
+
+// Tx begins a transaction, loads the fixtures within it and returns it, the
+// transaction is rolled back once the test and its subtests end, so nothing
+// the test writes on it is kept.
+func (f *Fixtures) Tx(t T, sess sqlbuilder.Database) sqlbuilder.Tx {
+	t.Helper()
+	tables, err := f.ordered(sess)
+	if err != nil {
+		t.Fatalf("fixtures: %v", err)
+	}
+	tx, err := sess.NewTx(sess.Context())
+	if err != nil {
+		t.Fatalf("fixtures: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = tx.Rollback()
+	})
+	if err := load(tx, tables); err != nil {
+		t.Fatalf("fixtures: %v", err)
+	}
+	return tx
+}
+
+func load(tx sqlbuilder.Tx, tables []*table) error {
+	if err := clean(tx, tables); err != nil {
+		return err
+	}
+	for _, t := range tables {
+		for i, row := range t.rows {
+			values, err := rowValues(row)
+			if err != nil {
+				return fmt.Errorf("upper: row %d of %q: %v", i, t.name, err)
+			}
+			if _, err := tx.InsertInto(t.name).Values(values).Exec(); err != nil {
+				return fmt.Errorf("upper: row %d of %q: %v", i, t.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func clean(tx sqlbuilder.Tx, tables []*table) error {
+	for i := len(tables) - 1; i >= 0; i-- {
+		if _, err := tx.DeleteFrom(tables[i].name).Exec(); err != nil {
+			return fmt.Errorf("upper: can't clean %q: %v", tables[i].name, err)
+		}
+	}
+	return nil
+}
+
+// rowValues returns the values of a row as they're stored, nested objects and
+// lists are encoded as JSON.
+func rowValues(row map[string]interface{}) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		switch reflect.ValueOf(value).Kind() {
+		case reflect.Map, reflect.Slice:
+			if _, ok := value.([]byte); ok {
+				break
+			}
+			buf, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			value = string(buf)
+		}
+		values[column] = value
+	}
+	return values, nil
+}
+
+// ordered returns the tables of the fixtures sorted so tables come after the
+// ones they reference, tables that don't depend on each other (or that
+// reference each other) keep the order they were added in.
+func (f *Fixtures) ordered(sess sqlbuilder.Database) ([]*table, error) {
+	r, ok := sess.(referencer)
+	if !ok {
+		return f.tables, nil
+	}
+
+	deps := make(map[string][]string, len(f.tables))
+	for _, t := range f.tables {
+		refs, err := r.ReferencedTables(t.name)
+		if err != nil {
+			return nil, err
+		}
+		deps[t.name] = refs
+	}
+
+	return sortTables(f.tables, deps), nil
+}
+
+// sortTables sorts tables so they come after the tables they depend on,
+// cycles are broken by taking the first table that is left.
+func sortTables(tables []*table, deps map[string][]string) []*table {
+	byName := make(map[string]int, len(tables))
+	for i, t := range tables {
+		byName[baseName(t.name)] = i
+	}
+
+	pending := make([]map[int]bool, len(tables))
+	for i, t := range tables {
+		pending[i] = map[int]bool{}
+		for _, dep := range deps[t.name] {
+			if j, ok := byName[baseName(dep)]; ok && j != i {
+				pending[i][j] = true
+			}
+		}
+	}
+
+	sorted := make([]*table, 0, len(tables))
+	done := make([]bool, len(tables))
+
+	for len(sorted) < len(tables) {
+		next := -1
+		for i := range tables {
+			if !done[i] && len(pending[i]) == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			for i := range tables {
+				if !done[i] {
+					next = i
+					break
+				}
+			}
+		}
+		done[next] = true
+		sorted = append(sorted, tables[next])
+		for i := range pending {
+			delete(pending[i], next)
+		}
+	}
+
+	return sorted
+}
+
+// baseName returns the name of a table without its schema.
+func baseName(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func parseJSON(content []byte) ([]*table, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, errors.New("expecting an object of tables")
+	}
+
+	var tables []*table
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		t := &table{name: tok.(string)}
+		if err := dec.Decode(&t.rows); err != nil {
+			return nil, err
+		}
+		for _, row := range t.rows {
+			for column, value := range row {
+				row[column] = jsonValue(value)
+			}
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// jsonValue converts the numbers of a decoded JSON value into int64 or
+// float64 values.
+func jsonValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k := range t {
+			t[k] = jsonValue(t[k])
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = jsonValue(t[i])
+		}
+	}
+	return v
+}
+
+func parseYAML(content []byte) ([]*table, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("expecting a mapping of tables")
+	}
+
+	var tables []*table
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		t := &table{name: root.Content[i].Value}
+		if err := root.Content[i+1].Decode(&t.rows); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
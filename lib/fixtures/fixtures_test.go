@@ -0,0 +1,143 @@
+package fixtures
+
+import (
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseYAML(t *testing.T) {
+	f := New()
+	err := f.Parse("fixtures.yml", []byte(`
+users:
+  - id: 1
+    email: ana@example.com
+  - id: 2
+    email: 'bob@example.com'
+orders:
+  - id: 1
+    user_id: 1
+    items: [{sku: "A-1", qty: 2}]
+`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"users", "orders"}, f.Tables())
+	if assert.Len(t, f.tables[0].rows, 2) {
+		assert.Equal(t, "ana@example.com", f.tables[0].rows[0]["email"])
+		assert.Equal(t, "bob@example.com", f.tables[0].rows[1]["email"])
+	}
+	if assert.Len(t, f.tables[1].rows, 1) {
+		values, err := rowValues(f.tables[1].rows[0])
+		assert.NoError(t, err)
+		assert.Equal(t, `[{"qty":2,"sku":"A-1"}]`, values["items"])
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	f := New()
+	err := f.Parse("fixtures.json", []byte(`{
+		"orders": [{"id": 1, "user_id": 1, "total": 9.5, "meta": {"gift": true}}],
+		"users": [{"id": 1, "email": "ana@example.com", "deleted_at": null}]
+	}`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"orders", "users"}, f.Tables())
+
+	row := f.tables[0].rows[0]
+	assert.Equal(t, int64(1), row["id"])
+	assert.Equal(t, 9.5, row["total"])
+
+	values, err := rowValues(row)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"gift":true}`, values["meta"])
+
+	assert.Nil(t, f.tables[1].rows[0]["deleted_at"])
+}
+
+func TestParseMerge(t *testing.T) {
+	f := New()
+	assert.NoError(t, f.Parse("a.json", []byte(`{"users": [{"id": 1}]}`)))
+	assert.NoError(t, f.Parse("b.json", []byte(`{"users": [{"id": 2}], "orders": []}`)))
+	f.Add("users", map[string]interface{}{"id": 3})
+
+	assert.Equal(t, []string{"users", "orders"}, f.Tables())
+	assert.Len(t, f.tables[0].rows, 3)
+}
+
+func TestParseErrors(t *testing.T) {
+	assert.Equal(t, ErrUnknownFormat, New().Parse("fixtures.txt", []byte(`{}`)))
+	assert.Error(t, New().Parse("fixtures.json", []byte(`[]`)))
+	assert.Error(t, New().Parse("fixtures.json", []byte(`{"users": [{"id": }]}`)))
+	assert.Error(t, New().Parse("fixtures.yml", []byte(`{{ ago "forever" }}`)))
+	assert.Error(t, New().Parse("fixtures.yml", []byte(`{{ missing }}`)))
+}
+
+func TestTemplates(t *testing.T) {
+	f := New().Funcs(template.FuncMap{
+		"upper": func() string { return "UPPER" },
+	})
+	err := f.Parse("fixtures.json", []byte(`{"events": [
+		{"name": "{{ upper }}", "at": "{{ now }}", "from": "{{ ago "1h" }}", "to": "{{ later "1h" }}"}
+	]}`))
+	assert.NoError(t, err)
+
+	row := f.tables[0].rows[0]
+	assert.Equal(t, "UPPER", row["name"])
+
+	at, err := time.Parse(TimeLayout, row["at"].(string))
+	assert.NoError(t, err)
+	from, err := time.Parse(TimeLayout, row["from"].(string))
+	assert.NoError(t, err)
+	to, err := time.Parse(TimeLayout, row["to"].(string))
+	assert.NoError(t, err)
+
+	assert.WithinDuration(t, time.Now().UTC(), at, time.Minute)
+	assert.WithinDuration(t, at.Add(-time.Hour), from, time.Minute)
+	assert.WithinDuration(t, at.Add(time.Hour), to, time.Minute)
+}
+
+func TestSortTables(t *testing.T) {
+	names := func(tables []*table) []string {
+		s := make([]string, len(tables))
+		for i := range tables {
+			s[i] = tables[i].name
+		}
+		return s
+	}
+	tables := func(names ...string) []*table {
+		t := make([]*table, len(names))
+		for i := range names {
+			t[i] = &table{name: names[i]}
+		}
+		return t
+	}
+
+	{
+		sorted := sortTables(tables("order_items", "orders", "users", "products"), map[string][]string{
+			"order_items": {"orders", "products"},
+			"orders":      {"users"},
+		})
+		assert.Equal(t, []string{"users", "orders", "products", "order_items"}, names(sorted))
+	}
+
+	{
+		// Tables the fixtures don't have and references to themselves are
+		// ignored, schemas are too.
+		sorted := sortTables(tables("public.employees", "public.departments"), map[string][]string{
+			"public.employees":   {"employees", "departments", "audit_log"},
+			"public.departments": {"public.departments"},
+		})
+		assert.Equal(t, []string{"public.departments", "public.employees"}, names(sorted))
+	}
+
+	{
+		// Cycles are broken by the order tables were added in.
+		sorted := sortTables(tables("a", "b", "c"), map[string][]string{
+			"a": {"b"},
+			"b": {"a"},
+		})
+		assert.Equal(t, []string{"c", "a", "b"}, names(sorted))
+	}
+}
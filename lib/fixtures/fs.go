@@ -0,0 +1,39 @@
+//go:build go1.16
+// +build go1.16
+
+package fixtures
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// AddFS adds the rows of the fixture files found in the dir directory of
+// fsys, which is usually an embed.FS, sorted by name. Files that are not
+// .json, .yml or .yaml are ignored.
+func (f *Fixtures) AddFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(path.Ext(entry.Name())) {
+		case ".json", ".yml", ".yaml":
+		default:
+			continue
+		}
+		name := path.Join(dir, entry.Name())
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if err := f.Parse(name, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
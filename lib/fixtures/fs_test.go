@@ -0,0 +1,25 @@
+//go:build go1.16
+// +build go1.16
+
+package fixtures
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"fixtures/01_users.yml":   {Data: []byte("users:\n  - id: 1\n")},
+		"fixtures/02_orders.json": {Data: []byte(`{"orders": [{"id": 1, "user_id": 1}]}`)},
+		"fixtures/README.md":      {Data: []byte("Fixtures")},
+	}
+
+	f := New()
+	assert.NoError(t, f.AddFS(fsys, "fixtures"))
+	assert.Equal(t, []string{"users", "orders"}, f.Tables())
+
+	assert.Error(t, New().AddFS(fstest.MapFS{}, "missing"))
+}
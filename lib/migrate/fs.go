@@ -0,0 +1,93 @@
+//go:build go1.16
+// +build go1.16
+
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+)
+
+var reMigrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// RegisterFS adds the SQL migrations found in the dir directory of fsys to
+// the default Migrator, it panics if the files can't be read or if a version
+// was already registered. See Migrator.AddFS.
+func RegisterFS(fsys fs.FS, dir string) {
+	if err := defaultMigrator.AddFS(fsys, dir); err != nil {
+		panic(fmt.Sprintf("migrate.RegisterFS(%q): %v", dir, err))
+	}
+}
+
+// AddFS adds the SQL migrations found in the dir directory of fsys, which is
+// usually an embed.FS:
+//
+//	//go:embed migrations/*.sql
+//	var migrations embed.FS
+//
+//	err = m.AddFS(migrations, "migrations")
+//
+// Files are named after the version and the name of the migration, followed
+// by .up.sql or .down.sql, like 0001_create_users.up.sql. Other files are
+// ignored. Migrations with no .down.sql file are irreversible.
+func (m *Migrator) AddFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	type files struct {
+		name     string
+		up, down string
+	}
+	found := map[int64]*files{}
+	versions := []int64{}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := reMigrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("upper: invalid migration file %q: %v", entry.Name(), err)
+		}
+
+		f := found[version]
+		if f == nil {
+			f = &files{name: match[2]}
+			found[version] = f
+			versions = append(versions, version)
+		}
+		if f.name != match[2] {
+			return fmt.Errorf("upper: migration %d has files with different names: %q and %q", version, f.name, match[2])
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if match[3] == "up" {
+			f.up = string(content)
+		} else {
+			f.down = string(content)
+		}
+	}
+
+	for _, version := range versions {
+		f := found[version]
+		if f.up == "" {
+			return fmt.Errorf("upper: migration %d (%s) has no .up.sql file", version, f.name)
+		}
+		if err := m.AddSQL(version, f.name, f.up, f.down); err != nil {
+			return err
+		}
+	}
+	return nil
+}
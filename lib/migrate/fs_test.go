@@ -0,0 +1,55 @@
+//go:build go1.16
+// +build go1.16
+
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INTEGER)")},
+		"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users")},
+		"migrations/0002_seed_users.up.sql":     {Data: []byte("INSERT INTO users (id) VALUES (1)")},
+		"migrations/README.md":                  {Data: []byte("Migrations")},
+	}
+
+	m := New()
+	assert.NoError(t, m.AddFS(fsys, "migrations"))
+
+	migrations := m.Migrations()
+	if assert.Equal(t, 2, len(migrations)) {
+		assert.Equal(t, int64(1), migrations[0].Version)
+		assert.Equal(t, "create_users", migrations[0].Name)
+		assert.NotNil(t, migrations[0].Down)
+
+		assert.Equal(t, int64(2), migrations[1].Version)
+		assert.Equal(t, "seed_users", migrations[1].Name)
+		assert.Nil(t, migrations[1].Down)
+	}
+
+	assert.Equal(t, ErrDuplicated, m.AddFS(fsys, "migrations"))
+}
+
+func TestAddFSErrors(t *testing.T) {
+	{
+		fsys := fstest.MapFS{
+			"0001_create_users.down.sql": {Data: []byte("DROP TABLE users")},
+		}
+		assert.Error(t, New().AddFS(fsys, "."))
+	}
+
+	{
+		fsys := fstest.MapFS{
+			"0001_create_users.up.sql":    {Data: []byte("CREATE TABLE users (id INTEGER)")},
+			"0001_create_people.down.sql": {Data: []byte("DROP TABLE people")},
+		}
+		assert.Error(t, New().AddFS(fsys, "."))
+	}
+
+	assert.Error(t, New().AddFS(fstest.MapFS{}, "missing"))
+}
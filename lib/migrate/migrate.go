@@ -0,0 +1,425 @@
+// Package migrate runs versioned schema migrations on SQL databases.
+//
+// Migrations are registered with a version number, which defines the order
+// they run in, and a pair of functions that apply and revert them:
+//
+//	func init() {
+//		migrate.Register(1, "create_users", func(tx sqlbuilder.Tx) error {
+//			_, err := tx.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name VARCHAR(60))`)
+//			return err
+//		}, func(tx sqlbuilder.Tx) error {
+//			_, err := tx.Exec(`DROP TABLE users`)
+//			return err
+//		})
+//	}
+//
+// Migrations can also be plain SQL, see RegisterSQL and RegisterFS. Once
+// registered, Migrate applies all pending migrations and Rollback reverts the
+// most recent ones:
+//
+//	err = migrate.Migrate(sess)
+//	...
+//	err = migrate.Rollback(sess, 1)
+//
+// Applied versions are tracked in the schema_migrations table, which is
+// created when needed. Each migration runs within its own transaction, keep
+// in mind that some databases, like MySQL, commit DDL statements implicitly.
+//
+// Concurrent runs, like several instances of an application starting at the
+// same time, are serialized with a lock row kept in the
+// schema_migrations_lock table. The lock is released when the run finishes,
+// if a process dies while holding it Unlock can be used to release it.
+package migrate
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// Errors returned by the migrator.
+var (
+	ErrLocked        = errors.New(`upper: migrations are locked by another process`)
+	ErrIrreversible  = errors.New(`upper: migration can not be rolled back`)
+	ErrUnknown       = errors.New(`upper: applied migration is not registered`)
+	ErrDuplicated    = errors.New(`upper: migration version already registered`)
+	ErrInvalidNumber = errors.New(`upper: invalid number of migrations`)
+)
+
+const (
+	defaultTable     = "schema_migrations"
+	lockTableSuffix  = "_lock"
+	lockPollInterval = 250 * time.Millisecond
+)
+
+// LockTimeout is the default amount of time a Migrator waits for a lock held
+// by another process before failing with ErrLocked.
+var LockTimeout = time.Minute
+
+// MigrationFunc applies or reverts a migration within the given transaction.
+type MigrationFunc func(tx sqlbuilder.Tx) error
+
+// Migration represents a versioned change to the database schema.
+type Migration struct {
+	// Version defines the order migrations are applied in.
+	Version int64
+
+	// Name is a human readable description of the migration.
+	Name string
+
+	// Up applies the migration.
+	Up MigrationFunc
+
+	// Down reverts the migration, migrations without Down can't be rolled
+	// back.
+	Down MigrationFunc
+}
+
+// AppliedMigration is a migration that was recorded as applied in the
+// tracking table.
+type AppliedMigration struct {
+	Version   int64     `db:"version"`
+	Name      string    `db:"name"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// Migrator keeps a set of migrations and applies them to databases.
+type Migrator struct {
+	// Table is the name of the table that keeps track of the applied
+	// migrations, defaults to "schema_migrations".
+	Table string
+
+	// LockTimeout is the amount of time to wait for a lock held by another
+	// process, defaults to the package level LockTimeout.
+	LockTimeout time.Duration
+
+	mu         sync.RWMutex
+	migrations map[int64]*Migration
+}
+
+var defaultMigrator = New()
+
+// New returns a Migrator with no migrations.
+func New() *Migrator {
+	return &Migrator{
+		migrations: make(map[int64]*Migration),
+	}
+}
+
+// Register adds a migration to the default Migrator, it panics if the
+// version was already registered.
+func Register(version int64, name string, up, down MigrationFunc) {
+	if err := defaultMigrator.Add(&Migration{Version: version, Name: name, Up: up, Down: down}); err != nil {
+		panic(fmt.Sprintf("migrate.Register(%d): %v", version, err))
+	}
+}
+
+// RegisterSQL adds a migration that runs the given SQL statements to the
+// default Migrator, it panics if the version was already registered. An
+// empty down statement makes the migration irreversible.
+func RegisterSQL(version int64, name string, up, down string) {
+	if err := defaultMigrator.AddSQL(version, name, up, down); err != nil {
+		panic(fmt.Sprintf("migrate.RegisterSQL(%d): %v", version, err))
+	}
+}
+
+// Migrate applies all pending migrations of the default Migrator.
+func Migrate(sess sqlbuilder.Database) error {
+	return defaultMigrator.Migrate(sess)
+}
+
+// Rollback reverts the last n applied migrations of the default Migrator.
+func Rollback(sess sqlbuilder.Database, n int) error {
+	return defaultMigrator.Rollback(sess, n)
+}
+
+// Applied returns the migrations that were applied on the database, sorted
+// by version.
+func Applied(sess sqlbuilder.Database) ([]AppliedMigration, error) {
+	return defaultMigrator.Applied(sess)
+}
+
+// Unlock releases a lock that was left behind by a process that died while
+// running migrations.
+func Unlock(sess sqlbuilder.Database) error {
+	return defaultMigrator.Unlock(sess)
+}
+
+// Add adds a migration to the set, it returns ErrDuplicated if the version
+// was already added.
+func (m *Migrator) Add(migration *Migration) error {
+	if migration.Up == nil {
+		return fmt.Errorf("upper: migration %d has no Up function", migration.Version)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.migrations[migration.Version]; ok {
+		return ErrDuplicated
+	}
+	m.migrations[migration.Version] = migration
+	return nil
+}
+
+// AddSQL adds a migration that runs the given SQL statements. An empty down
+// statement makes the migration irreversible.
+//
+// Each statement is sent to the database as is, a statement with several
+// queries requires a driver that supports them, like MySQL with the
+// multiStatements option.
+func (m *Migrator) AddSQL(version int64, name string, up, down string) error {
+	migration := &Migration{Version: version, Name: name, Up: execSQL(up)}
+	if down != "" {
+		migration.Down = execSQL(down)
+	}
+	return m.Add(migration)
+}
+
+func execSQL(query string) MigrationFunc {
+	return func(tx sqlbuilder.Tx) error {
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// Migrations returns the registered migrations sorted by version.
+func (m *Migrator) Migrations() []*Migration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	migrations := make([]*Migration, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations
+}
+
+// Migrate applies all pending migrations in order of version.
+func (m *Migrator) Migrate(sess sqlbuilder.Database) error {
+	return m.locked(sess, func() error {
+		applied, err := m.applied(sess)
+		if err != nil {
+			return err
+		}
+
+		done := make(map[int64]bool, len(applied))
+		for i := range applied {
+			done[applied[i].Version] = true
+		}
+
+		for _, migration := range m.Migrations() {
+			if done[migration.Version] {
+				continue
+			}
+			if err := m.apply(sess, migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Rollback reverts the last n applied migrations, most recent first. It stops
+// with ErrIrreversible at the first migration that has no Down function, and
+// with ErrUnknown if an applied version is not part of the set.
+func (m *Migrator) Rollback(sess sqlbuilder.Database, n int) error {
+	if n < 0 {
+		return ErrInvalidNumber
+	}
+
+	return m.locked(sess, func() error {
+		applied, err := m.applied(sess)
+		if err != nil {
+			return err
+		}
+
+		if n > len(applied) {
+			n = len(applied)
+		}
+
+		for i := len(applied) - 1; i >= len(applied)-n; i-- {
+			m.mu.RLock()
+			migration := m.migrations[applied[i].Version]
+			m.mu.RUnlock()
+
+			if migration == nil {
+				return ErrUnknown
+			}
+			if err := m.revert(sess, migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Applied returns the migrations that were applied on the database, sorted
+// by version.
+func (m *Migrator) Applied(sess sqlbuilder.Database) ([]AppliedMigration, error) {
+	if err := m.createTables(sess); err != nil {
+		return nil, err
+	}
+	return m.applied(sess)
+}
+
+// Unlock releases a lock that was left behind by a process that died while
+// running migrations.
+func (m *Migrator) Unlock(sess sqlbuilder.Database) error {
+	if !sess.Collection(m.lockTable()).Exists() {
+		return nil
+	}
+	_, err := sess.DeleteFrom(m.lockTable()).Exec()
+	return err
+}
+
+func (m *Migrator) apply(sess sqlbuilder.Database, migration *Migration) error {
+	err := sess.Tx(sess.Context(), func(tx sqlbuilder.Tx) error {
+		if err := migration.Up(tx); err != nil {
+			return err
+		}
+		_, err := tx.InsertInto(m.table()).Values(AppliedMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now().UTC(),
+		}).Exec()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("upper: migration %d (%s) failed: %v", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+func (m *Migrator) revert(sess sqlbuilder.Database, migration *Migration) error {
+	if migration.Down == nil {
+		return ErrIrreversible
+	}
+	err := sess.Tx(sess.Context(), func(tx sqlbuilder.Tx) error {
+		if err := migration.Down(tx); err != nil {
+			return err
+		}
+		_, err := tx.DeleteFrom(m.table()).Where("version", migration.Version).Exec()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("upper: rollback of migration %d (%s) failed: %v", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+func (m *Migrator) applied(sess sqlbuilder.Database) ([]AppliedMigration, error) {
+	var applied []AppliedMigration
+	err := sess.Select("version", "name", "applied_at").
+		From(m.table()).
+		OrderBy("version").
+		All(&applied)
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// locked runs fn while holding the migration lock, the lock is a row in the
+// lock table: inserting it fails with a unique violation while another
+// process holds it.
+func (m *Migrator) locked(sess sqlbuilder.Database, fn func() error) error {
+	if err := m.createTables(sess); err != nil {
+		return err
+	}
+
+	timeout := m.LockTimeout
+	if timeout <= 0 {
+		timeout = LockTimeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	for {
+		_, err := sess.InsertInto(m.lockTable()).Values(map[string]interface{}{
+			"id": 1,
+		}).Exec()
+		if err == nil {
+			break
+		}
+		if db.ErrorKind(err) != db.ErrUniqueViolation {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return ErrLocked
+		}
+		select {
+		case <-sess.Context().Done():
+			return sess.Context().Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	defer func() {
+		_, _ = sess.DeleteFrom(m.lockTable()).Exec()
+	}()
+
+	return fn()
+}
+
+func (m *Migrator) createTables(sess sqlbuilder.Database) error {
+	tables := []struct {
+		name string
+		ddl  string
+	}{
+		{
+			m.table(),
+			fmt.Sprintf(`CREATE TABLE %s (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at %s NOT NULL)`, m.table(), timestampType(sess)),
+		},
+		{
+			m.lockTable(),
+			fmt.Sprintf(`CREATE TABLE %s (id INTEGER NOT NULL PRIMARY KEY)`, m.lockTable()),
+		},
+	}
+	for _, table := range tables {
+		if sess.Collection(table.name).Exists() {
+			continue
+		}
+		if _, err := sess.Exec(table.ddl); err != nil {
+			// Another process may have created the table in the meantime.
+			sess.ClearCache()
+			if !sess.Collection(table.name).Exists() {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) table() string {
+	if m.Table != "" {
+		return m.Table
+	}
+	return defaultTable
+}
+
+func (m *Migrator) lockTable() string {
+	return m.table() + lockTableSuffix
+}
+
+// timestampType returns the column type for timestamps, TIMESTAMP works on
+// all supported databases except on SQL Server, where it means rowversion.
+func timestampType(sess sqlbuilder.Database) string {
+	if sqlDB, ok := sess.Driver().(interface {
+		Driver() driver.Driver
+	}); ok {
+		if reflect.TypeOf(sqlDB.Driver()).String() == "*mssql.Driver" {
+			return "DATETIME2"
+		}
+	}
+	return "TIMESTAMP"
+}
@@ -0,0 +1,57 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+func TestAdd(t *testing.T) {
+	m := New()
+
+	noop := func(sqlbuilder.Tx) error { return nil }
+
+	assert.NoError(t, m.Add(&Migration{Version: 3, Name: "third", Up: noop}))
+	assert.NoError(t, m.Add(&Migration{Version: 1, Name: "first", Up: noop, Down: noop}))
+	assert.NoError(t, m.AddSQL(2, "second", "CREATE TABLE a (id INTEGER)", "DROP TABLE a"))
+	assert.NoError(t, m.AddSQL(4, "fourth", "CREATE TABLE b (id INTEGER)", ""))
+
+	assert.Equal(t, ErrDuplicated, m.Add(&Migration{Version: 1, Name: "again", Up: noop}))
+	assert.Error(t, m.Add(&Migration{Version: 5, Name: "no_up"}))
+
+	migrations := m.Migrations()
+	versions := []int64{}
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+	assert.Equal(t, []int64{1, 2, 3, 4}, versions)
+
+	assert.Equal(t, "second", migrations[1].Name)
+	assert.NotNil(t, migrations[1].Down)
+	assert.Nil(t, migrations[3].Down)
+}
+
+func TestRegister(t *testing.T) {
+	defer func(m *Migrator) {
+		defaultMigrator = m
+	}(defaultMigrator)
+	defaultMigrator = New()
+
+	RegisterSQL(1, "first", "CREATE TABLE a (id INTEGER)", "DROP TABLE a")
+	assert.Equal(t, 1, len(defaultMigrator.Migrations()))
+
+	assert.Panics(t, func() {
+		RegisterSQL(1, "again", "CREATE TABLE a (id INTEGER)", "")
+	})
+}
+
+func TestTable(t *testing.T) {
+	m := New()
+	assert.Equal(t, "schema_migrations", m.table())
+	assert.Equal(t, "schema_migrations_lock", m.lockTable())
+
+	m.Table = "migrations"
+	assert.Equal(t, "migrations", m.table())
+	assert.Equal(t, "migrations_lock", m.lockTable())
+}
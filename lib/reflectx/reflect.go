@@ -280,9 +280,10 @@ func methodName() string {
 }
 
 type typeQueue struct {
-	t  reflect.Type
-	fi *FieldInfo
-	pp string // Parent path
+	t   reflect.Type
+	fi  *FieldInfo
+	pp  string // Parent path
+	pfx string // Name prefix, set by the "prefix" option
 }
 
 // A copying append that creates a new slice each time.
@@ -302,7 +303,7 @@ func getMapping(t reflect.Type, tagName string, mapFunc, tagMapFunc func(string)
 
 	root := &FieldInfo{}
 	queue := []typeQueue{}
-	queue = append(queue, typeQueue{Deref(t), root, ""})
+	queue = append(queue, typeQueue{Deref(t), root, "", ""})
 
 	for len(queue) != 0 {
 		// pop the first item off of the queue
@@ -353,11 +354,15 @@ func getMapping(t reflect.Type, tagName string, mapFunc, tagMapFunc func(string)
 			fi.Name = name
 
 			if tq.pp == "" || (tq.pp == "" && tag == "") {
-				fi.Path = fi.Name
+				fi.Path = tq.pfx + fi.Name
 			} else {
-				fi.Path = fmt.Sprintf("%s.%s", tq.pp, fi.Name)
+				fi.Path = fmt.Sprintf("%s.%s", tq.pp, tq.pfx+fi.Name)
 			}
 
+			// a field with a prefix option maps its children to flat names, like
+			// "author_name" instead of "author.name".
+			prefix, hasPrefix := fi.Options["prefix"]
+
 			// if the name is "-", disabled via a tag, skip it
 			if name == "-" {
 				continue
@@ -370,9 +375,11 @@ func getMapping(t reflect.Type, tagName string, mapFunc, tagMapFunc func(string)
 
 			// bfs search of anonymous embedded structs
 			if f.Anonymous {
-				pp := tq.pp
-				if tag != "" {
-					pp = fi.Path
+				pp, pfx := tq.pp, tq.pfx
+				if hasPrefix {
+					pfx = tq.pfx + prefix
+				} else if tag != "" {
+					pp, pfx = fi.Path, ""
 				}
 
 				fi.Embedded = true
@@ -383,11 +390,16 @@ func getMapping(t reflect.Type, tagName string, mapFunc, tagMapFunc func(string)
 					nChildren = ft.NumField()
 				}
 				fi.Children = make([]*FieldInfo, nChildren)
-				queue = append(queue, typeQueue{Deref(f.Type), &fi, pp})
+				queue = append(queue, typeQueue{Deref(f.Type), &fi, pp, pfx})
 			} else if fi.Zero.Kind() == reflect.Struct || (fi.Zero.Kind() == reflect.Ptr && fi.Zero.Type().Elem().Kind() == reflect.Struct) {
+				pp, pfx := fi.Path, ""
+				if hasPrefix {
+					pp, pfx = tq.pp, tq.pfx+prefix
+				}
+
 				fi.Index = apnd(tq.fi.Index, fieldPos)
 				fi.Children = make([]*FieldInfo, Deref(f.Type).NumField())
-				queue = append(queue, typeQueue{Deref(f.Type), &fi, fi.Path})
+				queue = append(queue, typeQueue{Deref(f.Type), &fi, pp, pfx})
 			}
 
 			fi.Index = apnd(tq.fi.Index, fieldPos)
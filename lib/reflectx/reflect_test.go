@@ -247,6 +247,54 @@ func TestInlineStruct(t *testing.T) {
 	}
 }
 
+func TestPrefixedStruct(t *testing.T) {
+	m := NewMapper("db")
+
+	type Address struct {
+		City string `db:"city"`
+	}
+	type Author struct {
+		Name    string  `db:"name"`
+		Address Address `db:"address,prefix=address_"`
+	}
+	type Book struct {
+		Title   string `db:"title"`
+		Author  `db:"author,prefix=author_"`
+		Editor  *Author `db:"editor,prefix=editor_"`
+		Related Author  `db:"related"`
+	}
+	// Book columns: (title author_name author_address_city editor_name
+	// editor_address_city related.name related.address_city)
+
+	book := Book{
+		Title:   "Dune",
+		Author:  Author{Name: "Frank", Address: Address{City: "Tacoma"}},
+		Editor:  &Author{Name: "Sterling"},
+		Related: Author{Address: Address{City: "Pasadena"}},
+	}
+	bv := reflect.ValueOf(book)
+
+	names := m.TypeMap(bv.Type()).Names
+	for _, name := range []string{"title", "author_name", "author_address_city", "editor_name", "editor_address_city", "related.name", "related.address_city"} {
+		if _, ok := names[name]; !ok {
+			t.Errorf("Expecting field %q", name)
+		}
+	}
+
+	v := m.FieldByName(bv, "author_address_city")
+	if v.Interface().(string) != book.Author.Address.City {
+		t.Errorf("Expecting %s, got %s", book.Author.Address.City, v.Interface().(string))
+	}
+	v = m.FieldByName(bv, "editor_name")
+	if v.Interface().(string) != book.Editor.Name {
+		t.Errorf("Expecting %s, got %s", book.Editor.Name, v.Interface().(string))
+	}
+	v = m.FieldByName(bv, "related.address_city")
+	if v.Interface().(string) != book.Related.Address.City {
+		t.Errorf("Expecting %s, got %s", book.Related.Address.City, v.Interface().(string))
+	}
+}
+
 func TestFieldsEmbedded(t *testing.T) {
 	m := NewMapper("db")
 
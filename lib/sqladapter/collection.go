@@ -0,0 +1,95 @@
+package sqladapter
+
+import (
+	"database/sql"
+
+	"upper.io/db.v3"
+	base "upper.io/db.v3/internal/sqladapter"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// table is the collection of a registered adapter.
+type table struct {
+	base.BaseCollection
+
+	d    *database
+	name string
+}
+
+var (
+	_ = base.Collection(&table{})
+	_ = db.Collection(&table{})
+)
+
+// newTable binds *table with sqladapter.
+func newTable(d *database, name string) *table {
+	t := &table{
+		name: name,
+		d:    d,
+	}
+	t.BaseCollection = base.NewBaseCollection(t)
+	return t
+}
+
+func (t *table) Name() string {
+	return t.name
+}
+
+func (t *table) Database() base.Database {
+	return t.d
+}
+
+// Insert inserts an item (map or struct) into the collection.
+func (t *table) Insert(item interface{}) (interface{}, error) {
+	return base.InsertWithHooks(t.d, item, t.insert)
+}
+
+func (t *table) insert(item interface{}) (interface{}, error) {
+	columnNames, columnValues, err := sqlbuilder.Map(item, &sqlbuilder.MapOptions{Mapper: t.d.Mapper(), Codec: t.d.Codec})
+	if err != nil {
+		return nil, err
+	}
+
+	pKey := t.BaseCollection.PrimaryKeys()
+
+	q := t.d.InsertInto(t.Name()).
+		Columns(columnNames...).
+		Values(columnValues...)
+
+	if t.d.a.Returning && len(pKey) > 0 {
+		// Asking the database to return the primary key after insertion.
+		var keyMap db.Cond
+		if err = q.Returning(pKey...).Iterator().One(&keyMap); err != nil {
+			return nil, err
+		}
+		if len(keyMap) == 1 {
+			return keyMap[pKey[0]], nil
+		}
+		return keyMap, nil
+	}
+
+	var res sql.Result
+	if res, err = q.Exec(); err != nil {
+		return nil, err
+	}
+
+	if len(pKey) <= 1 {
+		// Attempt to use LastInsertId() (probably won't work, but the Exec()
+		// succeeded, so we can safely ignore the error from LastInsertId()).
+		lastID, _ := res.LastInsertId()
+
+		return lastID, nil
+	}
+
+	keyMap := db.Cond{}
+
+	for i := range columnNames {
+		for j := 0; j < len(pKey); j++ {
+			if pKey[j] == columnNames[i] {
+				keyMap[pKey[j]] = columnValues[i]
+			}
+		}
+	}
+
+	return keyMap, nil
+}
@@ -0,0 +1,376 @@
+// Package sqladapter lets adapters for databases that are not part of this
+// repository, like DuckDB or Firebird, be written on top of the same SQL
+// builder, templates and sessions the bundled adapters use. An adapter only
+// describes what is particular to its database:
+//
+//	func init() {
+//		t := sqladapter.DefaultTemplate()
+//		t.IdentifierQuote = `"{{.Value}}"`
+//
+//		sqladapter.Register("duckdb", sqladapter.AdapterFuncs{
+//			Driver:   "duckdb",
+//			Template: t,
+//			Collections: func(sess sqlbuilder.Database) ([]string, error) {
+//				...
+//			},
+//		})
+//	}
+//
+// Once registered, sessions are opened with sqlbuilder.Open (or db.Open)
+// using the given name.
+package sqladapter
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/cache"
+	base "upper.io/db.v3/internal/sqladapter"
+	"upper.io/db.v3/internal/sqladapter/exql"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// Template is the set of layouts the builder compiles statements with, see
+// DefaultTemplate.
+type Template = exql.Template
+
+// DefaultTemplate returns a copy of the template the builder uses by default,
+// adapters can modify it to match the SQL dialect of their database.
+func DefaultTemplate() *Template {
+	return exql.DefaultTemplate()
+}
+
+// DollarPlaceholders turns the "?" placeholders of a query into numbered
+// ones, like $1, $2, ..., $n. It can be used as AdapterFuncs.Placeholders.
+func DollarPlaceholders(query string) string {
+	return base.ReplaceWithDollarSign(query)
+}
+
+// AdapterFuncs describes a SQL database adapter. Only Driver is required,
+// the functions that are left nil fall back to the behaviour described next
+// to them.
+type AdapterFuncs struct {
+	// Driver is the name of the database/sql driver sessions are opened with,
+	// the connection URL given to Open is passed to it as data source name.
+	Driver string
+
+	// Template is the template statements are compiled with, defaults to
+	// DefaultTemplate().
+	Template *Template
+
+	// Placeholders rewrites the "?" placeholders of compiled statements into
+	// the ones the driver expects, like DollarPlaceholders. Statements are
+	// left as they are if nil.
+	Placeholders func(query string) string
+
+	// Returning tells whether the database supports INSERT ... RETURNING, in
+	// which case Insert uses it to retrieve primary keys instead of
+	// LastInsertId.
+	Returning bool
+
+	// Collections returns the names of the tables of the database. Returns
+	// db.ErrNotImplemented if nil.
+	Collections func(sess sqlbuilder.Database) ([]string, error)
+
+	// TableExists returns db.ErrCollectionDoesNotExist if the given table
+	// does not exist. Looks for the table within Collections if nil.
+	TableExists func(sess sqlbuilder.Database, name string) error
+
+	// PrimaryKeys returns the primary key columns of the given table. Tables
+	// are assumed not to have primary keys if nil.
+	PrimaryKeys func(sess sqlbuilder.Database, name string) ([]string, error)
+
+	// LookupName returns the name of the database. Name returns an empty
+	// string if nil.
+	LookupName func(sess sqlbuilder.Database) (string, error)
+
+	// Err translates errors returned by the driver into the errors of the db
+	// package, like *db.Error. Errors are returned as they are if nil.
+	Err func(err error) error
+}
+
+// Register registers a SQL database adapter under the given name, it's
+// meant to be called from the init function of the adapter's package and
+// panics if the name is already in use or no driver is given.
+func Register(name string, funcs AdapterFuncs) {
+	if funcs.Driver == "" {
+		panic(`Missing driver name for adapter: ` + name)
+	}
+
+	a := &adapter{AdapterFuncs: funcs}
+	if a.Template == nil {
+		a.Template = DefaultTemplate()
+	}
+	if a.Template.Cache == nil {
+		a.Template.Cache = cache.NewCache()
+	}
+
+	sqlbuilder.RegisterAdapter(name, &sqlbuilder.AdapterFuncMap{
+		New:   a.new,
+		NewTx: a.newTx,
+		Open:  a.open,
+	})
+}
+
+type adapter struct {
+	AdapterFuncs
+}
+
+func (a *adapter) open(settings db.ConnectionURL) (sqlbuilder.Database, error) {
+	d := newDatabase(a, settings)
+	if err := d.Open(settings); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (a *adapter) newTx(sqlTx *sql.Tx) (sqlbuilder.Tx, error) {
+	d := newDatabase(a, nil)
+
+	// Binding with sqladapter's logic.
+	d.BaseDatabase = base.NewBaseDatabase(d)
+
+	// Binding with sqlbuilder.
+	d.SQLBuilder = sqlbuilder.WithSession(d.BaseDatabase, a.Template)
+
+	if err := d.BaseDatabase.BindTx(d.Context(), sqlTx); err != nil {
+		return nil, err
+	}
+
+	newTx := base.NewDatabaseTx(d)
+	return &tx{DatabaseTx: newTx}, nil
+}
+
+func (a *adapter) new(sess *sql.DB) (sqlbuilder.Database, error) {
+	d := newDatabase(a, nil)
+
+	// Binding with sqladapter's logic.
+	d.BaseDatabase = base.NewBaseDatabase(d)
+
+	// Binding with sqlbuilder.
+	d.SQLBuilder = sqlbuilder.WithSession(d.BaseDatabase, a.Template)
+
+	if err := d.BaseDatabase.BindSession(sess); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// database is the session of a registered adapter.
+type database struct {
+	base.BaseDatabase
+
+	sqlbuilder.SQLBuilder
+
+	a       *adapter
+	connURL db.ConnectionURL
+	mu      sync.Mutex
+}
+
+var (
+	_ = sqlbuilder.Database(&database{})
+	_ = base.Database(&database{})
+)
+
+func newDatabase(a *adapter, settings db.ConnectionURL) *database {
+	return &database{
+		a:       a,
+		connURL: settings,
+	}
+}
+
+// ConnectionURL returns this database's ConnectionURL.
+func (d *database) ConnectionURL() db.ConnectionURL {
+	return d.connURL
+}
+
+// Open attempts to open a connection to the database server.
+func (d *database) Open(connURL db.ConnectionURL) error {
+	if connURL == nil {
+		return db.ErrMissingConnURL
+	}
+	d.connURL = connURL
+
+	// Binding with sqladapter's logic.
+	d.BaseDatabase = base.NewBaseDatabase(d)
+
+	// Binding with sqlbuilder.
+	d.SQLBuilder = sqlbuilder.WithSession(d.BaseDatabase, d.a.Template)
+
+	openFn := func() error {
+		sess, err := sql.Open(d.a.Driver, connURL.String())
+		if err == nil {
+			return d.BaseDatabase.BindSession(sess)
+		}
+		return err
+	}
+
+	return d.BaseDatabase.WaitForConnection(openFn)
+}
+
+func (d *database) clone(ctx context.Context, checkConn bool) (*database, error) {
+	clone := newDatabase(d.a, d.connURL)
+
+	var err error
+	clone.BaseDatabase, err = d.NewClone(clone, checkConn)
+	if err != nil {
+		return nil, err
+	}
+
+	clone.SetContext(ctx)
+
+	clone.SQLBuilder = sqlbuilder.WithSession(clone.BaseDatabase, d.a.Template)
+
+	return clone, nil
+}
+
+// NewTx starts a transaction block.
+func (d *database) NewTx(ctx context.Context) (sqlbuilder.Tx, error) {
+	nTx, err := d.NewDatabaseTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tx{DatabaseTx: nTx}, nil
+}
+
+// Tx creates a transaction and passes it to the given function, if the
+// function returns no error then the transaction is commited.
+func (d *database) Tx(ctx context.Context, fn func(tx sqlbuilder.Tx) error) error {
+	return base.RunTx(d, ctx, fn)
+}
+
+// NewDatabaseTx allows sqladapter start a transaction block.
+func (d *database) NewDatabaseTx(ctx context.Context) (base.DatabaseTx, error) {
+	clone, err := d.clone(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	clone.mu.Lock()
+	defer clone.mu.Unlock()
+
+	openFn := func() error {
+		sqlTx, err := clone.BaseDatabase.Session().Begin()
+		if err == nil {
+			return clone.BindTx(ctx, sqlTx)
+		}
+		return err
+	}
+
+	if err := d.BaseDatabase.WaitForConnection(openFn); err != nil {
+		return nil, err
+	}
+
+	return base.NewDatabaseTx(clone), nil
+}
+
+// CompileStatement allows sqladapter to compile the given statement into the
+// format the driver expects.
+func (d *database) CompileStatement(stmt *exql.Statement, args []interface{}) (string, []interface{}) {
+	compiled, err := stmt.Compile(d.a.Template)
+	if err != nil {
+		panic(err.Error())
+	}
+	query, args := sqlbuilder.Preprocess(compiled, args)
+	if d.a.Placeholders != nil {
+		query = d.a.Placeholders(query)
+	}
+	return query, args
+}
+
+// Err allows sqladapter to translate driver errors with the adapter's Err
+// function.
+func (d *database) Err(err error) error {
+	if err != nil && d.a.AdapterFuncs.Err != nil {
+		return d.a.AdapterFuncs.Err(err)
+	}
+	return err
+}
+
+// SupportsReturning tells whether INSERT and UPDATE statements can return the
+// rows they write.
+func (d *database) SupportsReturning() bool {
+	return d.a.Returning
+}
+
+// NewCollection allows sqladapter create a local db.Collection.
+func (d *database) NewCollection(name string) db.Collection {
+	return newTable(d, name)
+}
+
+// Collections returns a list of non-system tables from the database.
+func (d *database) Collections() ([]string, error) {
+	if d.a.AdapterFuncs.Collections == nil {
+		return nil, db.ErrNotImplemented
+	}
+	return d.a.AdapterFuncs.Collections(d)
+}
+
+// LookupName allows sqladapter look up the database's name.
+func (d *database) LookupName() (string, error) {
+	if d.a.AdapterFuncs.LookupName == nil {
+		return "", nil
+	}
+	return d.a.AdapterFuncs.LookupName(d)
+}
+
+// TableExists allows sqladapter check whether a table exists and returns an
+// error in case it doesn't.
+func (d *database) TableExists(name string) error {
+	if d.a.AdapterFuncs.TableExists != nil {
+		return d.a.AdapterFuncs.TableExists(d, name)
+	}
+
+	collections, err := d.Collections()
+	if err != nil {
+		return err
+	}
+	for i := range collections {
+		if collections[i] == name {
+			return nil
+		}
+	}
+	return db.ErrCollectionDoesNotExist
+}
+
+// PrimaryKeys allows sqladapter find a table's primary keys.
+func (d *database) PrimaryKeys(name string) ([]string, error) {
+	if d.a.AdapterFuncs.PrimaryKeys == nil {
+		return nil, nil
+	}
+	return d.a.AdapterFuncs.PrimaryKeys(d, name)
+}
+
+// WithContext creates a copy of the session on the given context.
+func (d *database) WithContext(ctx context.Context) sqlbuilder.Database {
+	newDB, _ := d.clone(ctx, false)
+	return newDB
+}
+
+// WithSchema returns a session whose collections belong to the given schema.
+func (d *database) WithSchema(schema string) (sqlbuilder.Database, error) {
+	sess, err := d.SchemaSession(schema, func() (base.Database, error) {
+		clone, err := d.clone(d.Context(), false)
+		if err != nil {
+			return nil, err
+		}
+		return clone, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sess.(*database), nil
+}
+
+// WithRecordCache returns a copy of the session whose primary key lookups,
+// like col.Find(id).One(&item), read items from the given store.
+func (d *database) WithRecordCache(store db.QueryCache, ttl time.Duration) (sqlbuilder.Database, error) {
+	clone, err := d.clone(d.Context(), false)
+	if err != nil {
+		return nil, err
+	}
+	clone.SetRecordCache(store, ttl)
+	return clone, nil
+}
@@ -0,0 +1,118 @@
+package sqladapter
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+type dataSource string
+
+func (s dataSource) String() string {
+	return string(s)
+}
+
+func init() {
+	Register("sqladapter_test", AdapterFuncs{
+		Driver:       "sqlite3",
+		Placeholders: DollarPlaceholders,
+		Collections: func(sess sqlbuilder.Database) ([]string, error) {
+			var names []string
+			iter := sess.Select("name").From("sqlite_master").Where("type = ?", "table").Iterator()
+			defer iter.Close()
+			for iter.Next() {
+				var name string
+				if err := iter.Scan(&name); err != nil {
+					return nil, err
+				}
+				names = append(names, name)
+			}
+			return names, iter.Err()
+		},
+		PrimaryKeys: func(sess sqlbuilder.Database, name string) ([]string, error) {
+			var columns []struct {
+				Name string `db:"name"`
+				PK   int    `db:"pk"`
+			}
+			rows, err := sess.Query("SELECT name, pk FROM pragma_table_info(?)", name)
+			if err != nil {
+				return nil, err
+			}
+			if err := sqlbuilder.NewIterator(rows).All(&columns); err != nil {
+				return nil, err
+			}
+			var pk []string
+			for _, column := range columns {
+				if column.PK > 0 {
+					pk = append(pk, column.Name)
+				}
+			}
+			return pk, nil
+		},
+		LookupName: func(sess sqlbuilder.Database) (string, error) {
+			return "main", nil
+		},
+	})
+}
+
+func TestRegister(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqladapter")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sess, err := sqlbuilder.Open("sqladapter_test", dataSource(filepath.Join(dir, "test.db")))
+	require.NoError(t, err)
+	defer sess.Close()
+
+	assert.Equal(t, "main", sess.Name())
+
+	_, err = sess.Exec(`CREATE TABLE artist (id INTEGER PRIMARY KEY, name VARCHAR(60))`)
+	require.NoError(t, err)
+
+	collections, err := sess.Collections()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"artist"}, collections)
+
+	assert.True(t, sess.Collection("artist").Exists())
+	assert.False(t, sess.Collection("fan").Exists())
+
+	artist := sess.Collection("artist")
+
+	id, err := artist.Insert(map[string]string{"name": "Ozzie"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, id)
+
+	var item struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+	require.NoError(t, artist.Find(id).One(&item))
+	assert.Equal(t, "Ozzie", item.Name)
+
+	err = sess.Tx(context.Background(), func(tx sqlbuilder.Tx) error {
+		_, err := tx.Update("artist").Set("name", "Flea").Where("id", id).Exec()
+		return err
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, artist.Find(db.Cond{"name": "Flea"}).One(&item))
+	assert.EqualValues(t, 1, item.ID)
+
+	query := sess.Select("name").From("artist").Where("id = ? OR id = ?", 1, 2).String()
+	assert.Contains(t, query, "$1")
+	assert.Contains(t, query, "$2")
+}
+
+func TestRegisterWithoutDriver(t *testing.T) {
+	assert.Panics(t, func() {
+		Register("sqladapter_test_nodriver", AdapterFuncs{})
+	})
+}
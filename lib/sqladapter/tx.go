@@ -0,0 +1,23 @@
+package sqladapter
+
+import (
+	"context"
+
+	base "upper.io/db.v3/internal/sqladapter"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+type tx struct {
+	base.DatabaseTx
+}
+
+var (
+	_ = sqlbuilder.Tx(&tx{})
+)
+
+func (t *tx) WithContext(ctx context.Context) sqlbuilder.Tx {
+	var newTx tx
+	newTx = *t
+	newTx.DatabaseTx.SetContext(ctx)
+	return &newTx
+}
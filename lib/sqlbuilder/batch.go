@@ -1,5 +1,14 @@
 package sqlbuilder
 
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
 // BatchInserter provides a helper that can be used to do massive insertions in
 // batches.
 type BatchInserter struct {
@@ -80,3 +89,97 @@ func (b *BatchInserter) Wait() error {
 func (b *BatchInserter) Err() error {
 	return b.err
 }
+
+// BatchResult holds the outcome of executing one set of arguments within a
+// Batch.
+type BatchResult struct {
+	sql.Result
+	Err error
+}
+
+// Batch executes the same statement many times, once for every set of
+// arguments added with Add, using a single prepared statement unless
+// statement preparation is disabled on the session.
+type Batch struct {
+	builder *sqlBuilder
+	query   interface{}
+	args    [][]interface{}
+}
+
+func newBatch(builder *sqlBuilder, query interface{}) *Batch {
+	return &Batch{builder: builder, query: query}
+}
+
+// Add appends a set of arguments to the batch. Named parameters can be bound
+// by passing a single map or struct (see db.BindNamed).
+func (b *Batch) Add(args ...interface{}) *Batch {
+	b.args = append(b.args, args)
+	return b
+}
+
+// Len returns the number of argument sets in the batch.
+func (b *Batch) Len() int {
+	return len(b.args)
+}
+
+// Exec executes the statement with every set of arguments in the batch and
+// clears it. A result is returned for every set of arguments, in order, an
+// error in one of them does not stop the others from being executed. The
+// returned error is only set if the statement could not be prepared.
+func (b *Batch) Exec() ([]BatchResult, error) {
+	return b.ExecContext(b.builder.sess.Context())
+}
+
+// ExecContext executes the batch with the given context, see Exec.
+func (b *Batch) ExecContext(ctx context.Context) ([]BatchResult, error) {
+	var query string
+	switch q := b.query.(type) {
+	case string:
+		query = q
+	case db.RawValue:
+		if err := rawValueErr(q); err != nil {
+			return nil, err
+		}
+		query = q.Raw()
+	default:
+		return nil, fmt.Errorf("Unsupported query type %T.", b.query)
+	}
+
+	sets := b.args
+	b.args = nil
+	if len(sets) == 0 {
+		return nil, nil
+	}
+
+	var (
+		stmt       *sql.Stmt
+		unprepared bool
+	)
+	results := make([]BatchResult, len(sets))
+	for i := range sets {
+		bound, args, err := db.BindNamedWithMapper(b.builder.structMapper(), query, sets[i]...)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if stmt == nil && !unprepared {
+			stmt, err = b.builder.sess.StatementPrepare(ctx, exql.RawSQL(bound))
+			if err == db.ErrPreparedStatementsDisabled {
+				unprepared = true
+			} else if err != nil {
+				return nil, err
+			} else {
+				defer stmt.Close()
+			}
+		}
+		if unprepared {
+			// Sessions that don't prepare statements send every set of arguments
+			// along with the query.
+			results[i].Result, results[i].Err = b.builder.sess.StatementExec(ctx, exql.RawSQL(bound), args...)
+			continue
+		}
+		results[i].Result, results[i].Err = stmt.ExecContext(ctx, args...)
+	}
+
+	return results, nil
+}
@@ -33,6 +33,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"upper.io/db.v3"
 	"upper.io/db.v3/internal/sqladapter/compat"
@@ -44,6 +45,14 @@ import (
 type MapOptions struct {
 	IncludeZeroed bool
 	IncludeNil    bool
+
+	// Mapper maps struct fields to columns, a mapper that follows the default
+	// naming strategy is used if nil.
+	Mapper *reflectx.Mapper
+
+	// Codec returns the codec registered under the given name, fields tagged
+	// with codec=name are encoded with it, see db.Codec.
+	Codec func(name string) db.Codec
 }
 
 var defaultMapOptions = MapOptions{
@@ -68,9 +77,18 @@ type hasStatement interface {
 	statement() *exql.Statement
 }
 
+// hasMapper is implemented by sessions that map struct fields to columns
+// following their own naming strategy.
+type hasMapper interface {
+	Mapper() *reflectx.Mapper
+}
+
 type iterator struct {
-	cursor *sql.Rows // This is the main query cursor. It starts as a nil value.
+	cursor sqlRows // This is the main query cursor. It starts as a nil value.
 	err    error
+	mapper *reflectx.Mapper
+	codec  func(name string) db.Codec
+	cancel context.CancelFunc // Releases the context of a query with a timeout.
 }
 
 type fieldValue struct {
@@ -99,6 +117,7 @@ type exprDB interface {
 type sqlBuilder struct {
 	sess exprDB
 	t    *templateWithUtils
+	ctes []*commonTableExpression
 }
 
 // WithSession returns a query builder that is bound to the given database session.
@@ -121,7 +140,40 @@ func WithTemplate(t *exql.Template) SQLBuilder {
 
 // NewIterator creates an iterator using the given *sql.Rows.
 func NewIterator(rows *sql.Rows) Iterator {
-	return &iterator{rows, nil}
+	return newIterator(rows, nil)
+}
+
+func newIterator(rows *sql.Rows, err error) *iterator {
+	if rows == nil {
+		// Avoids storing a typed nil within the rows interface.
+		return &iterator{err: err}
+	}
+	return &iterator{cursor: rows, err: err}
+}
+
+// newIterator creates an iterator that maps structs the way the session does.
+func (b *sqlBuilder) newIterator(rows *sql.Rows, err error) *iterator {
+	iter := newIterator(rows, err)
+	iter.mapper = b.structMapper()
+	iter.codec = b.codec
+	return iter
+}
+
+// setColumnValues converts the terms given to Set() or DoUpdate() into
+// assignment fragments, mapping structs the way the session does.
+func (b *sqlBuilder) setColumnValues(terms []interface{}) ([]exql.Fragment, []interface{}, error) {
+	return b.t.setColumnValues(&MapOptions{Mapper: b.structMapper(), Codec: b.codec}, terms)
+}
+
+// structMapper returns the mapper the session uses to map struct fields to
+// columns.
+func (b *sqlBuilder) structMapper() *reflectx.Mapper {
+	if sess, ok := b.sess.(hasMapper); ok {
+		if m := sess.Mapper(); m != nil {
+			return m
+		}
+	}
+	return mapper
 }
 
 func (b *sqlBuilder) Iterator(query interface{}, args ...interface{}) Iterator {
@@ -130,7 +182,7 @@ func (b *sqlBuilder) Iterator(query interface{}, args ...interface{}) Iterator {
 
 func (b *sqlBuilder) IteratorContext(ctx context.Context, query interface{}, args ...interface{}) Iterator {
 	rows, err := b.QueryContext(ctx, query, args...)
-	return &iterator{rows, err}
+	return b.newIterator(rows, err)
 }
 
 func (b *sqlBuilder) Prepare(query interface{}) (*sql.Stmt, error) {
@@ -144,6 +196,9 @@ func (b *sqlBuilder) PrepareContext(ctx context.Context, query interface{}) (*sq
 	case string:
 		return b.sess.StatementPrepare(ctx, exql.RawSQL(q))
 	case db.RawValue:
+		if err := rawValueErr(q); err != nil {
+			return nil, err
+		}
 		return b.PrepareContext(ctx, q.Raw())
 	default:
 		return nil, fmt.Errorf("Unsupported query type %T.", query)
@@ -159,8 +214,15 @@ func (b *sqlBuilder) ExecContext(ctx context.Context, query interface{}, args ..
 	case *exql.Statement:
 		return b.sess.StatementExec(ctx, q, args...)
 	case string:
+		q, args, err := db.BindNamedWithMapper(b.structMapper(), q, args...)
+		if err != nil {
+			return nil, err
+		}
 		return b.sess.StatementExec(ctx, exql.RawSQL(q), args...)
 	case db.RawValue:
+		if err := rawValueErr(q); err != nil {
+			return nil, err
+		}
 		return b.ExecContext(ctx, q.Raw(), q.Arguments()...)
 	default:
 		return nil, fmt.Errorf("Unsupported query type %T.", query)
@@ -176,8 +238,15 @@ func (b *sqlBuilder) QueryContext(ctx context.Context, query interface{}, args .
 	case *exql.Statement:
 		return b.sess.StatementQuery(ctx, q, args...)
 	case string:
+		q, args, err := db.BindNamedWithMapper(b.structMapper(), q, args...)
+		if err != nil {
+			return nil, err
+		}
 		return b.sess.StatementQuery(ctx, exql.RawSQL(q), args...)
 	case db.RawValue:
+		if err := rawValueErr(q); err != nil {
+			return nil, err
+		}
 		return b.QueryContext(ctx, q.Raw(), q.Arguments()...)
 	default:
 		return nil, fmt.Errorf("Unsupported query type %T.", query)
@@ -193,8 +262,15 @@ func (b *sqlBuilder) QueryRowContext(ctx context.Context, query interface{}, arg
 	case *exql.Statement:
 		return b.sess.StatementQueryRow(ctx, q, args...)
 	case string:
+		q, args, err := db.BindNamedWithMapper(b.structMapper(), q, args...)
+		if err != nil {
+			return nil, err
+		}
 		return b.sess.StatementQueryRow(ctx, exql.RawSQL(q), args...)
 	case db.RawValue:
+		if err := rawValueErr(q); err != nil {
+			return nil, err
+		}
 		return b.QueryRowContext(ctx, q.Raw(), q.Arguments()...)
 	default:
 		return nil, fmt.Errorf("Unsupported query type %T.", query)
@@ -236,12 +312,69 @@ func (b *sqlBuilder) Update(table string) Updater {
 	return qu.setTable(table)
 }
 
+func (b *sqlBuilder) MergeInto(table string) Merger {
+	qm := &merger{
+		builder: b,
+	}
+	return qm.setTable(table)
+}
+
+func (b *sqlBuilder) CreateTable(table string) TableCreator {
+	qc := &tableCreator{
+		builder: b,
+	}
+	return qc.setTable(table)
+}
+
+func (b *sqlBuilder) CreateIndex(name string) IndexCreator {
+	qc := &indexCreator{
+		builder: b,
+	}
+	return qc.setName(name)
+}
+
+func (b *sqlBuilder) AlterTable(table string) TableAlterer {
+	qa := &tableAlterer{
+		builder: b,
+	}
+	return qa.setTable(table)
+}
+
+func (b *sqlBuilder) Batch(query interface{}) *Batch {
+	return newBatch(b, query)
+}
+
+// isColumnField returns true if the field has a `db` tag or if it was named by
+// the naming strategy, which happens to fields of the mapped struct and of the
+// structs that are embedded or prefixed in it. Fields of other nested structs,
+// like sql.NullString, are not columns on their own.
+func isColumnField(fi *reflectx.FieldInfo) bool {
+	if _, ok := fi.Field.Tag.Lookup("db"); ok {
+		return true
+	}
+	for p := fi.Parent; p != nil && p.Parent != nil; p = p.Parent {
+		if _, ok := p.Options["prefix"]; !ok && !p.Embedded {
+			return false
+		}
+	}
+	return true
+}
+
 // Map receives a pointer to map or struct and maps it to columns and values.
+// Struct fields tagged with the json option, like `db:"payload,json"`, are
+// sent as JSON text, so they can be stored in JSON or text columns of any
+// database. Fields tagged with the codec option, like `db:"ssn,codec=aes"`,
+// are encoded with the codec given by options and sent as []byte, see
+// db.Codec.
 func Map(item interface{}, options *MapOptions) ([]string, []interface{}, error) {
 	var fv fieldValue
 	if options == nil {
 		options = &defaultMapOptions
 	}
+	m := options.Mapper
+	if m == nil {
+		m = mapper
+	}
 
 	itemV := reflect.ValueOf(item)
 	if !itemV.IsValid() {
@@ -260,19 +393,30 @@ func Map(item interface{}, options *MapOptions) ([]string, []interface{}, error)
 
 	switch itemT.Kind() {
 	case reflect.Struct:
-		fieldMap := mapper.TypeMap(itemT).Names
+		fieldMap := m.TypeMap(itemT).Names
 		nfields := len(fieldMap)
 
 		fv.values = make([]interface{}, 0, nfields)
 		fv.fields = make([]string, 0, nfields)
 
 		for _, fi := range fieldMap {
+			if !isColumnField(fi) {
+				continue
+			}
 
 			// Field options
 			_, tagOmitEmpty := fi.Options["omitempty"]
 			_, tagStringArray := fi.Options["stringarray"]
 			_, tagInt64Array := fi.Options["int64array"]
 			_, tagJSONB := fi.Options["jsonb"]
+			_, tagJSON := fi.Options["json"]
+			_, tagInterval := fi.Options["interval"]
+			enumValues := fieldEnum(fi)
+
+			codec, err := fieldCodec(options.Codec, fi)
+			if err != nil {
+				return nil, nil, err
+			}
 
 			fld := reflectx.FieldByIndexesReadOnly(itemV, fi.Index)
 			if fld.Kind() == reflect.Ptr && fld.IsNil() {
@@ -304,20 +448,34 @@ func Map(item interface{}, options *MapOptions) ([]string, []interface{}, error)
 				value = int64Array(v)
 			case tagJSONB:
 				value = jsonbType{fld.Interface()}
+			case tagJSON:
+				value = jsonType{fld.Interface()}
+			case tagInterval:
+				v, ok := reflect.Indirect(fld).Interface().(time.Duration)
+				if !ok {
+					return nil, nil, fmt.Errorf(`Expecting field %q to be time.Duration (using "interval" tag)`, fi.Name)
+				}
+				value = db.Interval{Duration: v}
 			default:
 				value = fld.Interface()
 			}
 
+			if codec != nil {
+				if value, err = encodeField(codec, fi, fld, value); err != nil {
+					return nil, nil, err
+				}
+			}
+
 			isZero := false
 			if t, ok := fld.Interface().(hasIsZero); ok {
 				if t.IsZero() {
 					isZero = true
 				}
-			} else if fld.Kind() == reflect.Array || fld.Kind() == reflect.Slice {
+			} else if fld.Kind() == reflect.Slice {
 				if fld.Len() == 0 {
 					isZero = true
 				}
-			} else if reflect.DeepEqual(fi.Zero.Interface(), value) {
+			} else if reflect.DeepEqual(fi.Zero.Interface(), fld.Interface()) {
 				isZero = true
 			}
 
@@ -325,6 +483,12 @@ func Map(item interface{}, options *MapOptions) ([]string, []interface{}, error)
 				continue
 			}
 
+			if enumValues != nil && !(isZero && tagOmitEmpty) {
+				if value, err = enumValue(fi, enumValues, fld); err != nil {
+					return nil, nil, err
+				}
+			}
+
 			fv.fields = append(fv.fields, fi.Name)
 			v, err := marshal(value)
 			if err != nil {
@@ -400,9 +564,32 @@ func columnFragments(columns []interface{}) ([]exql.Fragment, []interface{}, err
 			f[i] = exql.RawValue(fnName)
 			args = append(args, fnArgs...)
 		case db.RawValue:
+			if err := rawValueErr(v); err != nil {
+				return nil, nil, err
+			}
 			q, a := Preprocess(v.Raw(), v.Arguments())
 			f[i] = exql.RawValue(q)
 			args = append(args, a...)
+		case *Window:
+			w, a := v.fragment()
+			f[i] = w
+			args = append(args, a...)
+		case db.ValuesList:
+			vl, a, err := valuesListFragment(v)
+			if err != nil {
+				return nil, nil, err
+			}
+			f[i] = vl
+			args = append(args, a...)
+		case db.Grouping:
+			g, a, err := groupingFragment(v)
+			if err != nil {
+				return nil, nil, err
+			}
+			f[i] = g
+			args = append(args, a...)
+		case db.Identifier:
+			f[i] = exql.IdentifierWithParts(v.Parts()...)
 		case exql.Fragment:
 			f[i] = v
 		case string:
@@ -418,6 +605,78 @@ func columnFragments(columns []interface{}) ([]exql.Fragment, []interface{}, err
 	return f, args, nil
 }
 
+func groupingFragment(g db.Grouping) (*exql.Grouping, []interface{}, error) {
+	f := &exql.Grouping{}
+
+	switch g.Type() {
+	case "ROLLUP":
+		f.Type = exql.Rollup
+	case "CUBE":
+		f.Type = exql.Cube
+	case "GROUPING SETS":
+		f.Type = exql.GroupingSets
+	default:
+		return nil, nil, fmt.Errorf("Unsupported grouping type %q.", g.Type())
+	}
+
+	args := []interface{}{}
+
+	sets := g.Sets()
+	f.Sets = make([]*exql.Columns, len(sets))
+	for i := range sets {
+		fragments, a, err := columnFragments(sets[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		f.Sets[i] = exql.JoinColumns(fragments...)
+		args = append(args, a...)
+	}
+
+	return f, args, nil
+}
+
+func valuesListFragment(v db.ValuesList) (*exql.ValuesList, []interface{}, error) {
+	rows := v.Rows()
+
+	f := &exql.ValuesList{Rows: make([][]exql.Fragment, len(rows))}
+	args := []interface{}{}
+
+	for i := range rows {
+		if len(rows[i]) != len(rows[0]) {
+			return nil, nil, fmt.Errorf("Expecting %d values on row %d but got %d.", len(rows[0]), i, len(rows[i]))
+		}
+		f.Rows[i] = make([]exql.Fragment, len(rows[i]))
+		for j := range rows[i] {
+			if raw, ok := rows[i][j].(db.RawValue); ok {
+				if err := rawValueErr(raw); err != nil {
+					return nil, nil, err
+				}
+				q, a := Preprocess(raw.Raw(), raw.Arguments())
+				f.Rows[i][j] = exql.RawValue(q)
+				args = append(args, a...)
+				continue
+			}
+			f.Rows[i][j] = sqlPlaceholder
+			args = append(args, rows[i][j])
+		}
+	}
+
+	alias := strings.TrimSpace(v.Alias())
+	if open := strings.Index(alias, "("); open >= 0 {
+		if !strings.HasSuffix(alias, ")") {
+			return nil, nil, fmt.Errorf("Malformed alias %q.", alias)
+		}
+		columns := strings.Split(alias[open+1:len(alias)-1], ",")
+		for i := range columns {
+			f.Columns = append(f.Columns, strings.TrimSpace(columns[i]))
+		}
+		alias = strings.TrimSpace(alias[:open])
+	}
+	f.Name = alias
+
+	return f, args, nil
+}
+
 func prepareQueryForDisplay(in string) (out string) {
 	j := 1
 	for i := range in {
@@ -475,7 +734,20 @@ func (iter *iterator) All(dst interface{}) error {
 	defer iter.Close()
 
 	// Fetching all results within the cursor.
-	if err := fetchRows(iter.cursor, dst); err != nil {
+	if err := fetchRows(iter.structMapper(), iter.codec, iter.cursor, dst); err != nil {
+		return iter.setErr(err)
+	}
+
+	return nil
+}
+
+func (iter *iterator) Chunk(size int, dst interface{}, fn func() error) error {
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	if err := fetchChunks(iter.structMapper(), iter.codec, iter.cursor, size, dst, fn); err != nil {
 		return iter.setErr(err)
 	}
 
@@ -519,7 +791,7 @@ func (iter *iterator) next(dst ...interface{}) error {
 		}
 		return nil
 	case 1:
-		if err := fetchRow(iter.cursor, dst[0]); err != nil {
+		if err := fetchRow(iter.structMapper(), iter.codec, iter.cursor, dst[0]); err != nil {
 			defer iter.Close()
 			return err
 		}
@@ -529,11 +801,22 @@ func (iter *iterator) next(dst ...interface{}) error {
 	return errors.New("Next does not currently supports more than one parameters")
 }
 
+func (iter *iterator) structMapper() *reflectx.Mapper {
+	if iter.mapper != nil {
+		return iter.mapper
+	}
+	return mapper
+}
+
 func (iter *iterator) Close() (err error) {
 	if iter.cursor != nil {
 		err = iter.cursor.Close()
 		iter.cursor = nil
 	}
+	if iter.cancel != nil {
+		iter.cancel()
+		iter.cancel = nil
+	}
 	return err
 }
 
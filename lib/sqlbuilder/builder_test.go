@@ -1,12 +1,19 @@
 package sqlbuilder
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/exql"
+	"upper.io/db.v3/lib/reflectx"
 )
 
 func TestSelect(t *testing.T) {
@@ -74,6 +81,33 @@ func TestSelect(t *testing.T) {
 		b.Select().Distinct(db.Raw(`ON ? col1`, []interface{}{db.Raw(`SELECT foo FROM bar`), db.Raw(`SELECT baz from qux`)})).Columns("col2", "col3").Distinct("col4", "col5").From("artist").String(),
 	)
 
+	assert.Equal(
+		`SELECT DISTINCT ON ("author_id") "author_id", "title" FROM "books" ORDER BY "author_id" ASC, "published_at" DESC`,
+		b.Select("author_id", "title").DistinctOn("author_id").From("books").OrderBy("author_id", "-published_at").String(),
+	)
+
+	assert.Equal(
+		`SELECT DISTINCT ON ("a"."author_id", lower(title)) * FROM "books" AS "a"`,
+		b.SelectFrom("books AS a").DistinctOn("a.author_id", db.Raw("lower(title)")).String(),
+	)
+
+	{
+		q := b.SelectFrom("books").DistinctOn(db.Raw("date_trunc(?, published_at)", "month")).Where("author_id", 1)
+		assert.Equal(
+			`SELECT DISTINCT ON (date_trunc($1, published_at)) * FROM "books" WHERE ("author_id" = $2)`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{"month", 1},
+			q.Arguments(),
+		)
+	}
+
+	{
+		_, err := b.SelectFrom("books").DistinctOn().(compilable).Compile()
+		assert.Error(err)
+	}
+
 	{
 		q := b.Select().Distinct(db.Raw(`ON ? col1`, []db.RawValue{db.Raw(`SELECT foo FROM bar WHERE id = ?`, 1), db.Raw(`SELECT baz from qux WHERE id = 2`)})).Columns("col2", "col3").Distinct("col4", "col5").From("artist").
 			Where("id", 3)
@@ -209,6 +243,52 @@ func TestSelect(t *testing.T) {
 		b.Select().From("artist").Where(db.Cond{"id IN": []int{0, -1}}).String(),
 	)
 
+	{
+		q := b.Select().From("artist").Where(db.Cond{"data->>'status'": "active"})
+		assert.Equal(
+			`SELECT * FROM "artist" WHERE ("data"->>'status' = $1)`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{"active"},
+			q.Arguments(),
+		)
+	}
+
+	{
+		q := b.Select().From("artist").Where(db.Cond{"artist.data -> 'meta' ->> 'first name' ILIKE": "J%"})
+		assert.Equal(
+			`SELECT * FROM "artist" WHERE ("artist"."data"->'meta'->>'first name' ILIKE $1)`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{"J%"},
+			q.Arguments(),
+		)
+	}
+
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE ("data"#>>'{tags,0}' IS NULL)`,
+		b.Select().From("artist").Where(db.Cond{"data#>>'{tags,0}'": nil}).String(),
+	)
+
+	assert.Equal(
+		`SELECT "data"->>'name' AS "name" FROM "artist"`,
+		b.Select("data->>'name' AS name").From("artist").String(),
+	)
+
+	{
+		q := b.Select().From("artist").Where(db.Cond{"JSON_EXTRACT(data, '$.tags[0]') IN": []string{"a", "b"}})
+		assert.Equal(
+			`SELECT * FROM "artist" WHERE (JSON_EXTRACT(data, '$.tags[0]') IN ($1, $2))`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{"a", "b"},
+			q.Arguments(),
+		)
+	}
+
 	assert.Equal(
 		`SELECT * FROM "artist" WHERE (("id" = $1 OR "id" = $2 OR "id" = $3))`,
 		b.Select().From("artist").Where(
@@ -461,6 +541,286 @@ func TestSelect(t *testing.T) {
 		b.SelectFrom("artist").Join("publication").Using("id").String(),
 	)
 
+	assert.Equal(
+		`SELECT * FROM "jobs" WHERE ("status" = $1) LIMIT 1 FOR UPDATE SKIP LOCKED`,
+		b.SelectFrom("jobs").Where("status", "pending").Limit(1).ForUpdate().SkipLocked().String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "jobs" FOR UPDATE`,
+		b.SelectFrom("jobs").ForUpdate().String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "jobs" FOR SHARE NOWAIT`,
+		b.SelectFrom("jobs").ForShare().NoWait().String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "jobs" FOR SHARE SKIP LOCKED`,
+		b.SelectFrom("jobs").ForShare().NoWait().SkipLocked().String(),
+	)
+
+	{
+		q := b.SelectFrom("books").Where(db.Cond{"(author_id, title) IN": [][]interface{}{{1, "Hamlet"}, {2, "Ulysses"}}})
+		assert.Equal(
+			`SELECT * FROM "books" WHERE (("author_id", "title") IN (($1, $2), ($3, $4)))`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{1, "Hamlet", 2, "Ulysses"},
+			q.Arguments(),
+		)
+
+		q = b.SelectFrom("books").Where(db.In([]string{"author_id", "title"}, [][]interface{}{{1, "Hamlet"}}), db.Cond{"year >": 1900})
+		assert.Equal(
+			`SELECT * FROM "books" WHERE (("author_id", "title") IN (($1, $2)) AND "year" > $3)`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{1, "Hamlet", 1900},
+			q.Arguments(),
+		)
+
+		q = b.SelectFrom("books").Where(db.Cond{"(author_id, title) NOT IN": [][]int{{1, 2}}})
+		assert.Equal(
+			`SELECT * FROM "books" WHERE (("author_id", "title") NOT IN (($1, $2)))`,
+			q.String(),
+		)
+
+		q = b.SelectFrom("books").Where(db.In([]string{"author_id", "title"}, [][]interface{}{}))
+		assert.Equal(
+			`SELECT * FROM "books" WHERE (1 = 0)`,
+			q.String(),
+		)
+
+		// Rows of the wrong size are an error, not a panic.
+		rows := [][]interface{}{{1, "Hamlet"}, {2}}
+		for _, c := range []compilable{
+			b.SelectFrom("books").Where(db.Cond{"(author_id, title) IN": rows}).(compilable),
+			b.SelectFrom("books").Where("year >", 1900).And(db.Or(db.In([]string{"author_id", "title"}, rows))).(compilable),
+			b.Update("books").Set("year", 1922).Where(db.Cond{"(author_id, title) IN": rows}).(compilable),
+			b.DeleteFrom("books").Where(db.Cond{"(author_id, title) NOT IN": [][]int{{1, 2, 3}}}).(compilable),
+		} {
+			_, err := c.Compile()
+			assert.Error(err)
+		}
+	}
+
+	{
+		sub := b.Select(db.Raw("1")).From("publication").Where("author_id = artist.id").And("year >", 2000)
+
+		q := b.SelectFrom("artist").Where(db.Exists(sub)).And("name <>", "Ozzy")
+		assert.Equal(
+			`SELECT * FROM "artist" WHERE (EXISTS (SELECT 1 FROM "publication" WHERE (author_id = artist.id AND "year" > $1)) AND "name" <> $2)`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{2000, "Ozzy"},
+			q.Arguments(),
+		)
+
+		q = b.SelectFrom("artist").Where(db.Or(db.Cond{"id": 1}, db.NotExists(sub)))
+		assert.Equal(
+			`SELECT * FROM "artist" WHERE (("id" = $1 OR NOT EXISTS (SELECT 1 FROM "publication" WHERE (author_id = artist.id AND "year" > $2))))`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{1, 2000},
+			q.Arguments(),
+		)
+	}
+
+	{
+		q := b.SelectFrom("artist").Where(db.IsNull("deleted_at"), db.NotNull("a.name"))
+		assert.Equal(
+			`SELECT * FROM "artist" WHERE ("deleted_at" IS NULL AND "a"."name" IS NOT NULL)`,
+			q.String(),
+		)
+
+		q = b.SelectFrom("artist").Where(db.ILike("name", "%smith%")).And(db.NotIn("id", []int{1, 2}))
+		assert.Equal(
+			`SELECT * FROM "artist" WHERE ("name" ILIKE $1 AND "id" NOT IN ($2, $3))`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{"%smith%", 1, 2},
+			q.Arguments(),
+		)
+
+		// Excluding nothing matches all rows, it used to render as
+		// "id" NOT IN (NULL), which matches none. An empty IN still matches no
+		// rows.
+		q = b.SelectFrom("artist").Where(db.NotIn("id", []int{}))
+		assert.Equal(
+			`SELECT * FROM "artist" WHERE (1 = 1)`,
+			q.String(),
+		)
+
+		q = b.SelectFrom("artist").Where(db.Cond{"id NOT IN": []int{}})
+		assert.Equal(
+			`SELECT * FROM "artist" WHERE (1 = 1)`,
+			q.String(),
+		)
+
+		q = b.SelectFrom("artist").Where(db.Cond{"id IN": []int{}})
+		assert.Equal(
+			`SELECT * FROM "artist" WHERE ("id" IN (NULL))`,
+			q.String(),
+		)
+
+		q = b.SelectFrom("artist").Where(db.Or(db.ILike("name", "%smith%"), db.NotIn("id", []int{})), db.Cond{"id NOT IN": []string{}})
+		assert.Equal(
+			`SELECT * FROM "artist" WHERE (("name" ILIKE $1 OR 1 = 1) AND 1 = 1)`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{"%smith%"},
+			q.Arguments(),
+		)
+
+		q = b.SelectFrom("books").Where(db.Between("year", 1900, 2000), db.Cond{"title <>": "Hamlet"})
+		assert.Equal(
+			`SELECT * FROM "books" WHERE ("year" BETWEEN $1 AND $2 AND "title" <> $3)`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{1900, 2000, "Hamlet"},
+			q.Arguments(),
+		)
+
+		q = b.SelectFrom("books").Where(db.Or(db.Between("year", db.Raw("NOW()"), 2000), db.IsNull("year")))
+		assert.Equal(
+			`SELECT * FROM "books" WHERE (("year" BETWEEN NOW() AND $1 OR "year" IS NULL))`,
+			q.String(),
+		)
+	}
+
+	{
+		filters := db.NewFilterSet().
+			Allow("name", db.Filter{Operators: []string{db.FilterEq, db.FilterILike}}).
+			Allow("year", db.Filter{Type: db.FilterInt, Operators: []string{db.FilterGte, db.FilterNotIn}})
+
+		cond, err := filters.Conditions(url.Values{"name": {"Hamlet", "Ulysses"}, "year[gte]": {"1900"}, "year[nin]": {"1922,1923"}})
+		assert.NoError(err)
+
+		q := b.SelectFrom("books").Where(cond)
+		assert.Equal(
+			`SELECT * FROM "books" WHERE ((("name" = $1 OR "name" = $2) AND "year" >= $3 AND "year" NOT IN ($4, $5)))`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{"Hamlet", "Ulysses", int64(1900), int64(1922), int64(1923)},
+			q.Arguments(),
+		)
+	}
+
+	{
+		v := db.Values([][]interface{}{{1, "Hamlet"}, {2, "Ulysses"}}).As("v(id, title)")
+
+		q := b.SelectFrom(v)
+		assert.Equal(
+			`SELECT * FROM (VALUES ($1, $2), ($3, $4)) AS "v" ("id", "title")`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{1, "Hamlet", 2, "Ulysses"},
+			q.Arguments(),
+		)
+
+		q = b.Select("b.id").From("books AS b").Join(v).On("b.id = v.id AND b.title <> v.title").Where("b.year", 2000)
+		assert.Equal(
+			`SELECT "b"."id" FROM "books" AS "b" JOIN (VALUES ($1, $2), ($3, $4)) AS "v" ("id", "title") ON (b.id = v.id AND b.title <> v.title) WHERE ("b"."year" = $5)`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{1, "Hamlet", 2, "Ulysses", 2000},
+			q.Arguments(),
+		)
+
+		q = b.SelectFrom(db.Values([][]interface{}{{db.Raw("?::int", 1), "Hamlet"}}).As("v"))
+		assert.Equal(
+			`SELECT * FROM (VALUES ($1::int, $2)) AS "v"`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{1, "Hamlet"},
+			q.Arguments(),
+		)
+
+		_, err := b.SelectFrom(db.Values([][]interface{}{{1, 2}, {3}})).(compilable).Compile()
+		assert.Error(err)
+
+		_, err = b.SelectFrom(db.Values([][]interface{}{})).(compilable).Compile()
+		assert.Error(err)
+	}
+
+	{
+		availability := db.Case().
+			When(db.Cond{"stock": 0}, "sold out").
+			When(db.Cond{"stock <": 10}, "few left").
+			Else("available")
+
+		q := b.Select("id", availability.As("availability")).From("products").Where("category", "books")
+		assert.Equal(
+			`SELECT "id", CASE WHEN ("stock" = $1) THEN $2 WHEN ("stock" < $3) THEN $4 ELSE $5 END AS "availability" FROM "products" WHERE ("category" = $6)`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{0, "sold out", 10, "few left", "available", "books"},
+			q.Arguments(),
+		)
+
+		q = b.SelectFrom("tickets").OrderBy(db.Case().When(db.Raw("priority = ?", "high"), 0).Else(1), "id")
+		assert.Equal(
+			`SELECT * FROM "tickets" ORDER BY CASE WHEN (priority = $1) THEN $2 ELSE $3 END , "id" ASC`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{"high", 0, 1},
+			q.Arguments(),
+		)
+	}
+
+	assert.Equal(
+		`SELECT "year", "month", SUM(amount) FROM "sales" GROUP BY ROLLUP("year", "month")`,
+		b.Select("year", "month", db.Raw("SUM(amount)")).From("sales").GroupBy(db.Rollup("year", "month")).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "sales" GROUP BY "region", CUBE("country", ("product", "color"))`,
+		b.SelectFrom("sales").GroupBy("region", db.Cube("country", db.Raw(`("product", "color")`))).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "sales" GROUP BY GROUPING SETS (("country", "product"), ("country"), ())`,
+		b.SelectFrom("sales").GroupBy(db.GroupingSets(
+			[]interface{}{"country", "product"},
+			[]interface{}{"country"},
+			[]interface{}{},
+		)).String(),
+	)
+
+	{
+		q := b.SelectFrom("sales").GroupBy(db.Rollup(db.Raw("date_trunc(?, sold_at)", "month"), "product"))
+		assert.Equal(
+			`SELECT * FROM "sales" GROUP BY ROLLUP(date_trunc($1, sold_at), "product")`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{"month"},
+			q.Arguments(),
+		)
+	}
+
+	{
+		_, err := b.SelectFrom("jobs").SkipLocked().(compilable).Compile()
+		assert.Error(err)
+
+		_, err = b.SelectFrom("jobs").NoWait().ForUpdate().(compilable).Compile()
+		assert.Error(err)
+	}
+
 	assert.Equal(
 		`SELECT * FROM "artist" WHERE ("id" IS NULL)`,
 		b.SelectFrom("artist").Where(db.Cond{"id": nil}).String(),
@@ -946,6 +1306,27 @@ func TestInsert(t *testing.T) {
 		)
 	}
 
+	{
+		type artistStruct struct {
+			ID   [16]byte `db:"id,omitempty"`
+			Name string   `db:"name,omitempty"`
+		}
+
+		assert.Equal(
+			`INSERT INTO "artist" ("name") VALUES ($1)`,
+			b.InsertInto("artist").
+				Values(artistStruct{Name: "Chavela Vargas"}).
+				String(),
+		)
+
+		assert.Equal(
+			`INSERT INTO "artist" ("id", "name") VALUES ($1, $2)`,
+			b.InsertInto("artist").
+				Values(artistStruct{ID: [16]byte{1}, Name: "Chavela Vargas"}).
+				String(),
+		)
+	}
+
 	{
 		type artistStruct struct {
 			ID   int    `db:"id,omitempty"`
@@ -1078,32 +1459,310 @@ func TestInsert(t *testing.T) {
 	)
 }
 
-func TestUpdate(t *testing.T) {
+func TestInsertOnConflict(t *testing.T) {
 	b := &sqlBuilder{t: newTemplateWithUtils(&testTemplate)}
 	assert := assert.New(t)
 
 	assert.Equal(
-		`UPDATE "artist" SET "name" = $1`,
-		b.Update("artist").Set("name", "Artist").String(),
+		`INSERT INTO "artist" ("id", "name") VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		b.InsertInto("artist").Columns("id", "name").Values(12, "Chavela Vargas").
+			OnConflict().DoNothing().String(),
 	)
 
 	assert.Equal(
-		`UPDATE "artist" SET "name" = $1 RETURNING "name"`,
-		b.Update("artist").Set("name", "Artist").Amend(func(query string) string {
-			return query + ` RETURNING "name"`
-		}).String(),
+		`INSERT INTO "artist" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO NOTHING RETURNING "id"`,
+		b.InsertInto("artist").Columns("id", "name").Values(12, "Chavela Vargas").
+			OnConflict("id").DoNothing().Returning("id").String(),
 	)
 
 	{
-		idSlice := []int64{8, 7, 6}
-		q := b.Update("artist").Set(db.Cond{"some_column": 10}).Where(db.Cond{"id": 1}, db.Cond{"another_val": idSlice})
+		q := b.InsertInto("artist").Columns("id", "name").Values(12, "Chavela Vargas").
+			OnConflict("id").DoUpdate(map[string]interface{}{"name": Excluded("name")})
+
 		assert.Equal(
-			`UPDATE "artist" SET "some_column" = $1 WHERE ("id" = $2 AND "another_val" IN ($3, $4, $5))`,
+			`INSERT INTO "artist" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`,
 			q.String(),
 		)
-		assert.Equal(
-			[]interface{}{10, 1, int64(8), int64(7), int64(6)},
-			q.Arguments(),
+		assert.Equal([]interface{}{12, "Chavela Vargas"}, q.Arguments())
+	}
+
+	{
+		q := b.InsertInto("artist").Columns("id", "name").Values(12, "Chavela Vargas").
+			OnConflict("id").DoUpdate("name = ?", "Alondra de la Parra").
+			DoUpdateWhere(db.Cond{"artist.id >": 10})
+
+		assert.Equal(
+			`INSERT INTO "artist" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = $3 WHERE ("artist"."id" > $4)`,
+			q.String(),
+		)
+		assert.Equal([]interface{}{12, "Chavela Vargas", "Alondra de la Parra", 10}, q.Arguments())
+	}
+
+	{
+		_, err := b.InsertInto("artist").Values(12, "Chavela Vargas").DoNothing().(compilable).Compile()
+		assert.Error(err)
+
+		_, err = b.InsertInto("artist").Values(12, "Chavela Vargas").DoUpdate("name = ?", "x").(compilable).Compile()
+		assert.Error(err)
+
+		_, err = b.InsertInto("artist").Values(12, "Chavela Vargas").OnConflict("id").DoUpdateWhere(db.Cond{"id": 1}).(compilable).Compile()
+		assert.Error(err)
+	}
+}
+
+// mapperSession is a session that maps structs with its own mapper.
+type mapperSession struct {
+	exprDB
+	mapper *reflectx.Mapper
+}
+
+func (s *mapperSession) Mapper() *reflectx.Mapper {
+	return s.mapper
+}
+
+func TestNamingStrategy(t *testing.T) {
+	type artistType struct {
+		ID        int64 `db:"id,omitempty"`
+		FirstName string
+		LastName  sql.NullString
+		Skip      string `db:"-"`
+	}
+	item := artistType{FirstName: "Chavela", LastName: sql.NullString{String: "Vargas", Valid: true}}
+
+	{
+		b := &sqlBuilder{
+			sess: &mapperSession{mapper: reflectx.NewMapperFunc("db", db.SnakeCase)},
+			t:    newTemplateWithUtils(&testTemplate),
+		}
+
+		q := b.InsertInto("artist").Values(item)
+		assert.Equal(t, `INSERT INTO "artist" ("first_name", "last_name") VALUES ($1, $2)`, q.String())
+		assert.Equal(t, []interface{}{"Chavela", item.LastName}, q.Arguments())
+
+		assert.Equal(t,
+			`UPDATE "artist" SET "first_name" = $1, "last_name" = $2`,
+			b.Update("artist").Set(item).String(),
+		)
+	}
+
+	{
+		b := &sqlBuilder{
+			sess: &mapperSession{mapper: reflectx.NewMapperFunc("db", db.CamelCase)},
+			t:    newTemplateWithUtils(&testTemplate),
+		}
+
+		assert.Equal(t,
+			`INSERT INTO "artist" ("firstName", "lastName") VALUES ($1, $2)`,
+			b.InsertInto("artist").Values(item).String(),
+		)
+	}
+
+	{
+		columns, _, err := Map(item, &MapOptions{Mapper: reflectx.NewMapper("db")})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, columns)
+	}
+
+	{
+		// Fields without a `db` tag are not mapped by default.
+		columns, _, err := Map(item, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, columns)
+	}
+}
+
+func TestRawNamedErrors(t *testing.T) {
+	b := &sqlBuilder{sess: &mapperSession{}, t: newTemplateWithUtils(&testTemplate)}
+
+	// A named parameter without a value makes the raw value fail wherever it's
+	// used.
+	raw := db.Raw("name = :name", map[string]interface{}{"title": "Hamlet"})
+
+	for _, c := range []compilable{
+		b.SelectFrom("books").Where(raw).(compilable),
+		b.SelectFrom("books").Where(db.Cond{"id": 1}, db.Or(raw)).(compilable),
+		b.SelectFrom("books").Columns(raw).(compilable),
+		b.SelectFrom("books").OrderBy(raw).(compilable),
+		b.Update("books").Set(raw).(compilable),
+		b.Update("books").Set(map[string]interface{}{"title": raw}).(compilable),
+		b.DeleteFrom("books").Where(db.Cond{"title": raw}).(compilable),
+	} {
+		_, err := c.Compile()
+		assert.Error(t, err)
+	}
+
+	_, err := b.QueryContext(context.Background(), raw)
+	assert.Error(t, err)
+
+	_, err = b.ExecContext(context.Background(), raw)
+	assert.Error(t, err)
+}
+
+// contextSession records the context statements are executed with.
+type contextSession struct {
+	exprDB
+	ctx context.Context
+}
+
+func (s *contextSession) Context() context.Context {
+	return context.Background()
+}
+
+func (s *contextSession) StatementExec(ctx context.Context, stmt *exql.Statement, args ...interface{}) (sql.Result, error) {
+	s.ctx = ctx
+	return nil, ctx.Err()
+}
+
+func (s *contextSession) StatementQuery(ctx context.Context, stmt *exql.Statement, args ...interface{}) (*sql.Rows, error) {
+	s.ctx = ctx
+	return nil, ctx.Err()
+}
+
+func TestTimeout(t *testing.T) {
+	sess := &contextSession{}
+	b := &sqlBuilder{sess: sess, t: newTemplateWithUtils(&testTemplate)}
+
+	_, err := b.Update("artist").Set("name", "Artist").Exec()
+	assert.NoError(t, err)
+	_, ok := sess.ctx.Deadline()
+	assert.False(t, ok)
+
+	_, err = b.Update("artist").Set("name", "Artist").Timeout(time.Minute).Exec()
+	assert.NoError(t, err)
+	deadline, ok := sess.ctx.Deadline()
+	if assert.True(t, ok) {
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+	}
+	// The context is released after Exec returns.
+	assert.Equal(t, context.Canceled, sess.ctx.Err())
+
+	_, err = b.DeleteFrom("artist").Timeout(time.Minute).Exec()
+	assert.NoError(t, err)
+	_, ok = sess.ctx.Deadline()
+	assert.True(t, ok)
+
+	iter := b.SelectFrom("artist").Timeout(time.Minute).Iterator()
+	_, ok = sess.ctx.Deadline()
+	assert.True(t, ok)
+	assert.NoError(t, sess.ctx.Err())
+	assert.NoError(t, iter.Close())
+	assert.Equal(t, context.Canceled, sess.ctx.Err())
+
+	_, err = b.SelectFrom("artist").Timeout(time.Minute).Query()
+	assert.NoError(t, err)
+	_, ok = sess.ctx.Deadline()
+	assert.True(t, ok)
+
+	_, err = b.InsertInto("artist").Values(map[string]string{"name": "Artist"}).Timeout(time.Minute).Exec()
+	assert.NoError(t, err)
+	_, ok = sess.ctx.Deadline()
+	assert.True(t, ok)
+}
+
+// explainSession returns a fixed plan and records the statement it explains.
+type explainSession struct {
+	contextSession
+	query   string
+	analyze bool
+}
+
+func (s *explainSession) StatementExplain(ctx context.Context, stmt *exql.Statement, analyze bool, args ...interface{}) (*QueryPlan, error) {
+	query, _ := stmt.Compile(&testTemplate)
+	s.query = prepareQueryForDisplay(query)
+	s.analyze = analyze
+	return &QueryPlan{
+		Nodes: []*PlanNode{
+			{Operation: "Hash Join", Children: []*PlanNode{
+				{Operation: "Seq Scan", Table: "publication"},
+				{Operation: "SCAN artist", Table: "artist"},
+			}},
+		},
+	}, nil
+}
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+
+	{
+		b := &sqlBuilder{sess: &contextSession{}, t: newTemplateWithUtils(&testTemplate)}
+		_, err := b.SelectFrom("artist").Explain(ctx)
+		assert.Equal(t, db.ErrUnsupported, err)
+	}
+
+	sess := &explainSession{}
+	b := &sqlBuilder{sess: sess, t: newTemplateWithUtils(&testTemplate)}
+
+	plan, err := b.SelectFrom("artist").Where("id", 1).Explain(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT * FROM "artist" WHERE ("id" = $1)`, sess.query)
+	assert.False(t, sess.analyze)
+
+	_, err = b.SelectFrom("artist").ExplainAnalyze(ctx)
+	assert.NoError(t, err)
+	assert.True(t, sess.analyze)
+
+	assert.Equal(t, "Hash Join\n  Seq Scan on publication\n  SCAN artist\n", plan.String())
+
+	var operations []string
+	plan.Walk(func(node *PlanNode) {
+		operations = append(operations, node.Operation)
+	})
+	assert.Equal(t, []string{"Hash Join", "Seq Scan", "SCAN artist"}, operations)
+}
+
+func TestUpdate(t *testing.T) {
+	b := &sqlBuilder{t: newTemplateWithUtils(&testTemplate)}
+	assert := assert.New(t)
+
+	assert.Equal(
+		`UPDATE "artist" SET "name" = $1`,
+		b.Update("artist").Set("name", "Artist").String(),
+	)
+
+	{
+		q := b.Update("products").Set("price", db.Case().
+			When(db.Cond{"category": "books"}, db.Raw("price * ?", 0.9)).
+			Else(db.Raw("price")),
+		).Where("active", true)
+		assert.Equal(
+			`UPDATE "products" SET "price" = CASE WHEN ("category" = $1) THEN price * $2 ELSE price END WHERE ("active" = $3)`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{"books", 0.9, true},
+			q.Arguments(),
+		)
+
+		q = b.Update("products").Set(map[string]interface{}{
+			"label": db.Case().When(db.Cond{"stock": 0}, "sold out").Else(db.Raw("label")),
+		})
+		assert.Equal(
+			`UPDATE "products" SET "label" = CASE WHEN ("stock" = $1) THEN $2 ELSE label END`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{0, "sold out"},
+			q.Arguments(),
+		)
+	}
+
+	assert.Equal(
+		`UPDATE "artist" SET "name" = $1 RETURNING "name"`,
+		b.Update("artist").Set("name", "Artist").Amend(func(query string) string {
+			return query + ` RETURNING "name"`
+		}).String(),
+	)
+
+	{
+		idSlice := []int64{8, 7, 6}
+		q := b.Update("artist").Set(db.Cond{"some_column": 10}).Where(db.Cond{"id": 1}, db.Cond{"another_val": idSlice})
+		assert.Equal(
+			`UPDATE "artist" SET "some_column" = $1 WHERE ("id" = $2 AND "another_val" IN ($3, $4, $5))`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{10, 1, int64(8), int64(7), int64(6)},
+			q.Arguments(),
 		)
 	}
 
@@ -1172,6 +1831,20 @@ func TestUpdate(t *testing.T) {
 		).Where("id > ?", 0).String(),
 	)
 
+	{
+		q := b.Update("posts").Set("column = ?", "foo").Where("id", 1).Returning("id", "column")
+
+		assert.Equal(
+			`UPDATE "posts" SET "column" = $1 WHERE ("id" = $2) RETURNING "id", "column"`,
+			q.String(),
+		)
+
+		assert.Equal(
+			[]interface{}{"foo", 1},
+			q.Arguments(),
+		)
+	}
+
 	{
 		q := b.Update("posts").Set("column = ?", "foo")
 
@@ -1229,6 +1902,35 @@ func TestUpdate(t *testing.T) {
 			q.Arguments(),
 		)
 	}
+
+	{
+		q := b.Update("products").
+			Set("price = prices.amount").
+			From("prices").
+			Where("products.id = prices.product_id")
+
+		assert.Equal(
+			`UPDATE "products" SET "price" = prices.amount FROM "prices" WHERE (products.id = prices.product_id)`,
+			q.String(),
+		)
+	}
+
+	{
+		q := b.Update("products").
+			Set("name", "Tortilla").
+			From(db.Values([][]interface{}{{1, 10}, {2, 20}}).As("v(id, price)")).
+			Where("products.id = v.id AND products.price < ?", 15).
+			Returning("products.id")
+
+		assert.Equal(
+			`UPDATE "products" SET "name" = $1 FROM (VALUES ($2, $3), ($4, $5)) AS "v" ("id", "price") WHERE (products.id = v.id AND products.price < $6) RETURNING "products"."id"`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{"Tortilla", 1, 10, 2, 20, 15},
+			q.Arguments(),
+		)
+	}
 }
 
 func TestDelete(t *testing.T) {
@@ -1251,6 +1953,115 @@ func TestDelete(t *testing.T) {
 		`DELETE FROM "artist" WHERE (id > 5)`,
 		bt.DeleteFrom("artist").Where("id > 5").String(),
 	)
+
+	{
+		q := bt.DeleteFrom("artist").Where("id >", 5).Returning("id", "name")
+		assert.Equal(
+			`DELETE FROM "artist" WHERE ("id" > $1) RETURNING "id", "name"`,
+			q.String(),
+		)
+		assert.Equal([]interface{}{5}, q.Arguments())
+	}
+
+	{
+		q := bt.DeleteFrom("orders").
+			Using("customers c").
+			Where("orders.customer_id = c.id AND c.banned = ?", true)
+		assert.Equal(
+			`DELETE FROM "orders" USING "customers" AS "c" WHERE (orders.customer_id = c.id AND c.banned = $1)`,
+			q.String(),
+		)
+		assert.Equal([]interface{}{true}, q.Arguments())
+	}
+}
+
+func TestMergeUnsupported(t *testing.T) {
+	bt := WithTemplate(&testTemplate)
+
+	_, err := bt.MergeInto("artist").
+		Using("incoming").
+		On("artist.id = incoming.id").
+		WhenMatchedDelete().(compilable).Compile()
+	assert.Error(t, err)
+}
+
+func TestBatchUnsupportedQuery(t *testing.T) {
+	bt := WithTemplate(&testTemplate)
+
+	_, err := bt.Batch(10).Add(1).ExecContext(context.Background())
+	assert.Error(t, err)
+}
+
+func TestWith(t *testing.T) {
+	bt := WithTemplate(&testTemplate)
+	assert := assert.New(t)
+
+	{
+		q := bt.With("recent", bt.SelectFrom("artist").Where("id > ?", 5)).
+			SelectFrom("recent").Where("name = ?", "Chavela Vargas")
+
+		assert.Equal(
+			`WITH "recent" AS (SELECT * FROM "artist" WHERE (id > $1)) SELECT * FROM "recent" WHERE (name = $2)`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{5, "Chavela Vargas"},
+			q.Arguments(),
+		)
+	}
+
+	{
+		q := bt.With("a", bt.Select("id").From("artist").Where("name = ?", "a")).
+			With("b", bt.Select("id").From("publication").Where("title = ?", "b")).
+			Select("a.id").From("a").Join("b").On("a.id = b.id")
+
+		assert.Equal(
+			`WITH "a" AS (SELECT "id" FROM "artist" WHERE (name = $1)), "b" AS (SELECT "id" FROM "publication" WHERE (title = $2)) SELECT "a"."id" FROM "a" JOIN "b" ON (a.id = b.id)`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{"a", "b"},
+			q.Arguments(),
+		)
+	}
+
+	{
+		q := bt.WithRecursive("t(n)", bt.Select(db.Raw("1"))).SelectFrom("t")
+		assert.Equal(
+			`WITH RECURSIVE "t"("n") AS (SELECT 1) SELECT * FROM "t"`,
+			q.String(),
+		)
+	}
+
+	{
+		sel := bt.Select("id").From("artist").Where("name = ?", "Chavela Vargas")
+
+		q := bt.With("old", sel).Update("artist").Set("name", "Lila Downs").Where(db.Raw("id IN (SELECT id FROM old)"))
+		assert.Equal(
+			`WITH "old" AS (SELECT "id" FROM "artist" WHERE (name = $1)) UPDATE "artist" SET "name" = $2 WHERE (id IN (SELECT id FROM old))`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{"Chavela Vargas", "Lila Downs"},
+			q.Arguments(),
+		)
+
+		d := bt.With("old", sel).DeleteFrom("artist").Where(db.Raw("id IN (SELECT id FROM old)"))
+		assert.Equal(
+			`WITH "old" AS (SELECT "id" FROM "artist" WHERE (name = $1)) DELETE FROM "artist" WHERE (id IN (SELECT id FROM old))`,
+			d.String(),
+		)
+
+		i := bt.With("old", sel).InsertInto("artist").Values(map[string]string{"name": "Lila Downs"})
+		assert.Equal(
+			`WITH "old" AS (SELECT "id" FROM "artist" WHERE (name = $1)) INSERT INTO "artist" ("name") VALUES ($2)`,
+			i.String(),
+		)
+		assert.Equal(
+			[]interface{}{"Chavela Vargas", "Lila Downs"},
+			i.Arguments(),
+		)
+	}
 }
 
 func BenchmarkDelete1(b *testing.B) {
@@ -1387,3 +2198,362 @@ func stripWhitespace(in string) string {
 	q := reInvisibleChars.ReplaceAllString(in, ` `)
 	return strings.TrimSpace(q)
 }
+
+func TestWindow(t *testing.T) {
+	b := &sqlBuilder{t: newTemplateWithUtils(&testTemplate)}
+	assert := assert.New(t)
+
+	assert.Equal(
+		`SELECT "id", ROW_NUMBER() OVER () FROM "products"`,
+		b.Select("id", RowNumber()).From("products").String(),
+	)
+
+	assert.Equal(
+		`SELECT "id", ROW_NUMBER() OVER (PARTITION BY "category_id" ORDER BY "price" DESC) AS "rank" FROM "products"`,
+		b.Select("id", RowNumber().PartitionBy("category_id").OrderBy("-price").As("rank")).From("products").String(),
+	)
+
+	assert.Equal(
+		`SELECT "id", RANK() OVER (ORDER BY "score" DESC, "id" ASC), DENSE_RANK() OVER (PARTITION BY "team", "season" ORDER BY "score" DESC) FROM "players"`,
+		b.Select("id", Rank().OrderBy("score DESC", "id"), DenseRank().PartitionBy("team", "season").OrderBy("-score")).From("players").String(),
+	)
+
+	assert.Equal(
+		`SELECT "day", SUM("amount") OVER (ORDER BY "day" ASC) AS "total" FROM "sales"`,
+		b.Select("day", Sum("amount").OrderBy("day").As("total")).From("sales").String(),
+	)
+
+	{
+		q := b.Select("day", Lag("price", 1, 0).OrderBy("day").As("prev"), Lead("price").OrderBy("day")).From("prices").Where("day >", 10)
+		assert.Equal(
+			`SELECT "day", LAG("price", $1, $2) OVER (ORDER BY "day" ASC) AS "prev", LEAD("price") OVER (ORDER BY "day" ASC) FROM "prices" WHERE ("day" > $3)`,
+			q.String(),
+		)
+		assert.Equal([]interface{}{1, 0, 10}, q.Arguments())
+	}
+
+	{
+		w := RowNumber().OrderBy("id")
+		assert.Equal(
+			`SELECT ROW_NUMBER() OVER (PARTITION BY "a" ORDER BY "id" ASC), ROW_NUMBER() OVER (ORDER BY "id" ASC) FROM "t"`,
+			b.Select(w.PartitionBy("a"), w).From("t").String(),
+		)
+	}
+
+	assert.Equal(
+		`SELECT NTILE(4) OVER (ORDER BY "score" ASC) FROM "players"`,
+		b.Select(WindowFunc("NTILE", db.Raw("4")).OrderBy("score")).From("players").String(),
+	)
+}
+
+func TestCompound(t *testing.T) {
+	b := &sqlBuilder{t: newTemplateWithUtils(&testTemplate)}
+	assert := assert.New(t)
+
+	{
+		q := b.SelectFrom("posts").Where("author_id", 1).
+			Union(b.SelectFrom("archived_posts").Where("author_id", 2))
+		assert.Equal(
+			`SELECT * FROM "posts" WHERE ("author_id" = $1) UNION SELECT * FROM "archived_posts" WHERE ("author_id" = $2)`,
+			q.String(),
+		)
+		assert.Equal([]interface{}{1, 2}, q.Arguments())
+	}
+
+	assert.Equal(
+		`SELECT "id" FROM "a" UNION ALL SELECT "id" FROM "b" INTERSECT SELECT "id" FROM "c" EXCEPT SELECT "id" FROM "d"`,
+		b.Select("id").From("a").
+			UnionAll(b.Select("id").From("b")).
+			Intersect(b.Select("id").From("c")).
+			Except(b.Select("id").From("d")).
+			String(),
+	)
+
+	{
+		q := b.Select("id", "title").From("posts").Where("author_id", 1).
+			Union(b.Select("id", "title").From("archived_posts").Where("author_id", 2)).
+			OrderBy("-id").Limit(10).Offset(5)
+		assert.Equal(
+			`SELECT * FROM (SELECT "id", "title" FROM "posts" WHERE ("author_id" = $1) UNION SELECT "id", "title" FROM "archived_posts" WHERE ("author_id" = $2)) AS "__u" ORDER BY "id" DESC LIMIT 10 OFFSET 5`,
+			q.String(),
+		)
+		assert.Equal([]interface{}{1, 2}, q.Arguments())
+	}
+
+	{
+		q := b.Select("id").From("posts").OrderBy("-id").Limit(3).
+			UnionAll(b.Select("id").From("archived_posts").Where("id >", 10).OrderBy("id").Limit(3))
+		assert.Equal(
+			`SELECT * FROM (SELECT "id" FROM "posts" ORDER BY "id" DESC LIMIT 3) AS "__u0" UNION ALL SELECT * FROM (SELECT "id" FROM "archived_posts" WHERE ("id" > $1) ORDER BY "id" ASC LIMIT 3) AS "__u1"`,
+			q.String(),
+		)
+		assert.Equal([]interface{}{10}, q.Arguments())
+	}
+
+	{
+		q := b.Select("id").From("posts").
+			Union(b.Select("id").From("archived_posts")).
+			Where("id >", 5).Columns(db.Raw("COUNT(*)"))
+		assert.Equal(
+			`SELECT COUNT(*) FROM (SELECT "id" FROM "posts" UNION SELECT "id" FROM "archived_posts") AS "__u" WHERE ("id" > $1)`,
+			q.String(),
+		)
+		assert.Equal([]interface{}{5}, q.Arguments())
+	}
+
+	{
+		bw := b.With("recent", b.SelectFrom("posts").Where("created_at >", 100))
+		q := bw.Select("id").From("recent").Union(bw.Select("id").From("archived_posts").Where("id", 7))
+		assert.Equal(
+			`WITH "recent" AS (SELECT * FROM "posts" WHERE ("created_at" > $1)) SELECT "id" FROM "recent" UNION SELECT "id" FROM "archived_posts" WHERE ("id" = $2)`,
+			q.String(),
+		)
+		assert.Equal([]interface{}{100, 7}, q.Arguments())
+	}
+
+	{
+		q := b.Select("id").From("posts").
+			Union(b.With("old", b.SelectFrom("archived_posts").Where("created_at <", 50)).Select("id").From("old")).
+			UnionAll(b.Select("id").From("drafts").Where("id", 3))
+		assert.Equal(
+			`WITH "old" AS (SELECT * FROM "archived_posts" WHERE ("created_at" < $1)) SELECT "id" FROM "posts" UNION SELECT "id" FROM "old" UNION ALL SELECT "id" FROM "drafts" WHERE ("id" = $2)`,
+			q.String(),
+		)
+		assert.Equal([]interface{}{50, 3}, q.Arguments())
+	}
+
+	{
+		_, err := b.With("t", b.SelectFrom("posts")).Select("id").From("t").
+			Union(b.With("t", b.SelectFrom("archived_posts")).Select("id").From("t")).(compilable).Compile()
+		assert.Error(err)
+	}
+
+	{
+		_, err := b.Select("id").From("a").Union(b.Select("id").From("b")).From("c").(compilable).Compile()
+		assert.Error(err)
+	}
+}
+
+func TestNewIterator(t *testing.T) {
+	iter := newIterator(nil, errors.New("failed"))
+	assert.Nil(t, iter.cursor)
+	assert.Error(t, iter.Err())
+
+	rows := &sql.Rows{}
+	iter = newIterator(rows, nil)
+	assert.Equal(t, rows, iter.cursor)
+	assert.NoError(t, iter.Err())
+}
+
+type strictSession struct {
+	exprDB
+}
+
+func (s *strictSession) StrictIdentifiersEnabled() bool {
+	return true
+}
+
+func TestIdent(t *testing.T) {
+	b := &sqlBuilder{t: newTemplateWithUtils(&testTemplate)}
+	assert := assert.New(t)
+
+	column := `name"; DROP TABLE artist; --`
+
+	assert.Equal(
+		`SELECT "name""; DROP TABLE artist; --" FROM "public"."artist" WHERE ("name""; DROP TABLE artist; --" = $1) ORDER BY "name""; DROP TABLE artist; --"`,
+		b.Select(db.Ident(column)).From(db.Ident("public.artist")).
+			Where(db.Cond{db.Ident(column): "Joe"}).
+			OrderBy(db.Ident(column)).
+			String(),
+	)
+
+	assert.Equal(`"public"."artist"`, b.Quote("public.artist"))
+	assert.Equal(`"a""b"`, b.Quote(`a"b`))
+}
+
+func TestStrictIdentifiers(t *testing.T) {
+	b := &sqlBuilder{sess: &strictSession{}, t: newTemplateWithUtils(&testTemplate)}
+	assert := assert.New(t)
+
+	{
+		q := b.Select("a.id", "name AS n", "artist.*").From("public.artist a").
+			Where(db.Cond{"id >=": 1, "name NOT LIKE": "J%"}, db.Or(db.Cond{"id IN": []int{1, 2}})).
+			And("name", "Joe").
+			And("id = ? OR id = ?", 1, 2).
+			OrderBy("-id", "name DESC")
+		_, err := q.(compilable).Compile()
+		assert.NoError(err)
+	}
+
+	{
+		_, err := b.Select(db.Ident("first name")).From(db.Ident("my-table")).
+			Where(db.Cond{db.Ident("first name"): "Joe"}).(compilable).Compile()
+		assert.NoError(err)
+	}
+
+	for _, q := range []compilable{
+		b.Select("id; DROP TABLE artist").From("artist").(compilable),
+		b.SelectFrom("artist; --").(compilable),
+		b.SelectFrom("artist").OrderBy("id; DROP TABLE artist").(compilable),
+		b.SelectFrom("artist").Where(db.Cond{"id = 1 OR 1 =": 1}).(compilable),
+		b.SelectFrom("artist").Where(db.Or(db.Cond{"id) OR (1": 1})).(compilable),
+		b.SelectFrom("artist").Where("name --", "Joe").(compilable),
+		b.InsertInto("artist").Columns("first name").Values("Joe").(compilable),
+		b.InsertInto("artist").Values(map[string]interface{}{"name) VALUES ('x'); --": "Joe"}).(compilable),
+		b.Update("artist").Set("name = 'x', id", 1).(compilable),
+		b.Update("artist").Set(map[string]interface{}{"name = 'x', id": 1}).(compilable),
+		b.DeleteFrom("artist").Where(db.Cond{"1 = 1 --": 1}).(compilable),
+	} {
+		_, err := q.Compile()
+		assert.Equal(ErrInvalidIdentifier, err)
+	}
+}
+
+func TestCreateTable(t *testing.T) {
+	b := &sqlBuilder{t: newTemplateWithUtils(&testTemplate)}
+	assert := assert.New(t)
+
+	assert.Equal(
+		`CREATE TABLE "books" ("id" BIGSERIAL PRIMARY KEY, "title" VARCHAR(120) NOT NULL, "price" NUMERIC(10, 2) DEFAULT 0, "status" VARCHAR(255) NOT NULL DEFAULT 'it''s new', "public" BOOLEAN DEFAULT FALSE, "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP)`,
+		b.CreateTable("books").
+			Columns(
+				Column{Name: "id", Type: BigInt, AutoIncrement: true},
+				Column{Name: "title", Type: String, Size: 120, NotNull: true},
+				Column{Name: "price", Type: Decimal, Size: 10, Scale: 2, Default: 0},
+				Column{Name: "status", Type: String, NotNull: true, Default: "it's new"},
+				Column{Name: "public", Type: Boolean, Default: false},
+				Column{Name: "created_at", Type: Timestamp, Default: db.Raw("CURRENT_TIMESTAMP")},
+			).
+			PrimaryKey("id").
+			String(),
+	)
+
+	assert.Equal(
+		`CREATE TABLE IF NOT EXISTS "public"."tags" ("book_id" INTEGER NOT NULL, "tag" VARCHAR(32) NOT NULL, PRIMARY KEY ("book_id", "tag"))`,
+		b.CreateTable("public.tags").
+			IfNotExists().
+			Columns(
+				Column{Name: "book_id", Type: Integer, NotNull: true},
+				Column{Name: "tag", Type: String, Size: 32, NotNull: true},
+			).
+			PrimaryKey("book_id", "tag").
+			String(),
+	)
+
+	type book struct {
+		ID        int64          `db:"id,pk,omitempty"`
+		Title     string         `db:"title"`
+		Summary   *string        `db:"summary"`
+		Rating    sql.NullInt32  `db:"rating"`
+		Meta      map[string]int `db:"meta,jsonb"`
+		Published time.Time      `db:"published_at"`
+		Ignored   string         `db:"-"`
+	}
+
+	assert.Equal(
+		`CREATE TABLE "books" ("id" BIGSERIAL NOT NULL PRIMARY KEY, "title" TEXT NOT NULL, "summary" VARCHAR(255), "rating" INTEGER, "meta" JSONB NOT NULL, "published_at" TIMESTAMP WITH TIME ZONE NOT NULL)`,
+		b.CreateTable("books").
+			FromStruct(&book{}).
+			Columns(Column{Name: "title", Type: Text, NotNull: true}).
+			String(),
+	)
+
+	type product struct {
+		Price    db.Decimal  `db:"price,precision=10,scale=2"`
+		Weight   *db.Decimal `db:"weight"`
+		Discount db.Decimal  `db:"discount,precision=5"`
+	}
+
+	assert.Equal(
+		`CREATE TABLE "products" ("price" NUMERIC(10, 2) NOT NULL, "weight" NUMERIC, "discount" NUMERIC(5, 0) NOT NULL)`,
+		b.CreateTable("products").FromStruct(product{}).String(),
+	)
+
+	type profile struct {
+		Birthday db.NullTime        `db:"birthday"`
+		Settings db.NullJSON        `db:"settings"`
+		Tags     db.NullStringSlice `db:"tags"`
+	}
+
+	assert.Equal(
+		`CREATE TABLE "profiles" ("birthday" TIMESTAMP WITH TIME ZONE, "settings" JSONB, "tags" TEXT[])`,
+		b.CreateTable("profiles").FromStruct(profile{}).String(),
+	)
+
+	type job struct {
+		Timeout time.Duration  `db:"timeout,interval"`
+		Retry   *time.Duration `db:"retry,interval"`
+		Window  db.Interval    `db:"window"`
+		Elapsed time.Duration  `db:"elapsed"`
+	}
+
+	assert.Equal(
+		`CREATE TABLE "jobs" ("timeout" INTERVAL NOT NULL, "retry" INTERVAL, "window" INTERVAL NOT NULL, "elapsed" BIGINT NOT NULL)`,
+		b.CreateTable("jobs").FromStruct(job{}).String(),
+	)
+
+	{
+		_, err := b.CreateTable("books").FromStruct(struct {
+			Ch chan int `db:"ch"`
+		}{}).(compilable).Compile()
+		assert.Error(err)
+	}
+
+	{
+		_, err := b.CreateTable("products").FromStruct(struct {
+			Price db.Decimal `db:"price,scale=2"`
+		}{}).(compilable).Compile()
+		assert.Error(err)
+
+		_, err = b.CreateTable("products").FromStruct(struct {
+			Price db.Decimal `db:"price,precision=x"`
+		}{}).(compilable).Compile()
+		assert.Error(err)
+	}
+
+	{
+		_, err := b.CreateTable("books").(compilable).Compile()
+		assert.Error(err)
+	}
+
+	{
+		_, err := b.CreateTable("books").Columns(Column{Name: "id"}).(compilable).Compile()
+		assert.Error(err)
+	}
+}
+
+func TestCreateIndex(t *testing.T) {
+	b := &sqlBuilder{t: newTemplateWithUtils(&testTemplate)}
+	assert := assert.New(t)
+
+	assert.Equal(
+		`CREATE INDEX "books_title_idx" ON "books" ("title")`,
+		b.CreateIndex("books_title_idx").On("books", "title").String(),
+	)
+
+	assert.Equal(
+		`CREATE UNIQUE INDEX "books_isbn_idx" ON "public"."books" ("isbn", "edition")`,
+		b.CreateIndex("books_isbn_idx").Unique().On("public.books", "isbn", "edition").String(),
+	)
+
+	_, err := b.CreateIndex("books_title_idx").(compilable).Compile()
+	assert.Error(err)
+}
+
+func TestAlterTable(t *testing.T) {
+	b := &sqlBuilder{t: newTemplateWithUtils(&testTemplate)}
+	assert := assert.New(t)
+
+	assert.Equal(
+		`ALTER TABLE "books" ADD COLUMN "isbn" VARCHAR(13) UNIQUE`,
+		b.AlterTable("books").AddColumn(Column{Name: "isbn", Type: String, Size: 13, Unique: true}).String(),
+	)
+
+	assert.Equal(
+		`ALTER TABLE "books" RENAME COLUMN "title" TO "name"; ALTER TABLE "books" DROP COLUMN "summary"`,
+		b.AlterTable("books").RenameColumn("title", "name").DropColumn("summary").String(),
+	)
+
+	_, err := b.AlterTable("books").(compilable).Compile()
+	assert.Error(err)
+}
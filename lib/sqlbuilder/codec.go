@@ -0,0 +1,107 @@
+package sqlbuilder
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/reflectx"
+)
+
+// hasCodecs is implemented by sessions that encode and decode the values of
+// fields tagged with the codec option, see db.Codec.
+type hasCodecs interface {
+	Codec(name string) db.Codec
+}
+
+// codec returns the codec the session registered under the given name, if
+// any.
+func (b *sqlBuilder) codec(name string) db.Codec {
+	if sess, ok := b.sess.(hasCodecs); ok {
+		return sess.Codec(name)
+	}
+	return nil
+}
+
+// fieldCodec returns the codec a field tagged with codec=name is encoded
+// with, or nil if the field has no codec option.
+func fieldCodec(codecs func(string) db.Codec, fi *reflectx.FieldInfo) (db.Codec, error) {
+	name, ok := fi.Options["codec"]
+	if !ok {
+		return nil, nil
+	}
+	var codec db.Codec
+	if codecs != nil {
+		codec = codecs(name)
+	}
+	if codec == nil {
+		return nil, fmt.Errorf(`Unknown codec %q for field %q (using "codec" tag)`, name, fi.Name)
+	}
+	return codec, nil
+}
+
+// encodeField encodes the value of a field with its codec, value is the
+// value the field would be written as without a codec.
+func encodeField(codec db.Codec, fi *reflectx.FieldInfo, fld reflect.Value, value interface{}) (interface{}, error) {
+	var b []byte
+
+	switch v := value.(type) {
+	case jsonType, jsonbType:
+		jv, err := v.(driver.Valuer).Value()
+		if err != nil {
+			return nil, err
+		}
+		switch jv := jv.(type) {
+		case string:
+			b = []byte(jv)
+		case []byte:
+			b = jv
+		}
+	default:
+		if fld.Kind() == reflect.Ptr {
+			fld = fld.Elem()
+		}
+		switch {
+		case fld.Kind() == reflect.String:
+			b = []byte(fld.String())
+		case fld.Kind() == reflect.Slice && fld.Type().Elem().Kind() == reflect.Uint8:
+			b = fld.Bytes()
+		default:
+			return nil, fmt.Errorf(`Expecting field %q to be a string or []byte (using "codec" tag)`, fi.Name)
+		}
+	}
+
+	return codec.Encode(b)
+}
+
+// decodeField decodes a value read from the column of a field with a codec
+// and sets the field with it, fields with the json option are decoded by the
+// caller.
+func decodeField(codec db.Codec, fi *reflectx.FieldInfo, f reflect.Value, src []byte) ([]byte, error) {
+	b, err := codec.Decode(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := fi.Options["json"]; ok {
+		return b, nil
+	}
+	if _, ok := fi.Options["jsonb"]; ok {
+		return b, nil
+	}
+
+	if f.Kind() == reflect.Ptr {
+		f.Set(reflect.New(f.Type().Elem()))
+		f = f.Elem()
+	}
+	switch {
+	case f.Kind() == reflect.String:
+		f.SetString(string(b))
+	case f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Uint8:
+		f.SetBytes(b)
+	default:
+		return nil, fmt.Errorf(`Expecting field %q to be a string or []byte (using "codec" tag)`, fi.Name)
+	}
+	return b, nil
+}
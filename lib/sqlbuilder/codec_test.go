@@ -0,0 +1,91 @@
+package sqlbuilder
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+)
+
+// prefixCodec marks encoded values with a prefix.
+type prefixCodec struct{}
+
+func (prefixCodec) Encode(value []byte) ([]byte, error) {
+	return append([]byte("enc:"), value...), nil
+}
+
+func (prefixCodec) Decode(value []byte) ([]byte, error) {
+	if !bytes.HasPrefix(value, []byte("enc:")) {
+		return nil, errors.New("not encoded")
+	}
+	return value[4:], nil
+}
+
+func testCodecs(name string) db.Codec {
+	if name == "prefix" {
+		return prefixCodec{}
+	}
+	return nil
+}
+
+func TestMapCodec(t *testing.T) {
+	type person struct {
+		ID    int64             `db:"id"`
+		SSN   string            `db:"ssn,codec=prefix"`
+		Notes *string           `db:"notes,codec=prefix,omitempty"`
+		Extra map[string]string `db:"extra,json,codec=prefix"`
+	}
+
+	columns, values, err := Map(person{ID: 1, SSN: "123", Extra: map[string]string{"a": "b"}}, &MapOptions{Codec: testCodecs})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"extra", "id", "ssn"}, columns)
+	assert.Equal(t, []interface{}{[]byte(`enc:{"a":"b"}`), int64(1), []byte("enc:123")}, values)
+
+	_, _, err = Map(person{ID: 1}, nil)
+	assert.Error(t, err)
+
+	type unsupported struct {
+		Age int `db:"age,codec=prefix"`
+	}
+	_, _, err = Map(unsupported{Age: 1}, &MapOptions{Codec: testCodecs})
+	assert.Error(t, err)
+}
+
+func TestFetchCodec(t *testing.T) {
+	type person struct {
+		ID   int64   `db:"id"`
+		Name *string `db:"name,codec=prefix"`
+	}
+
+	{
+		rows := &fakeRows{rows: [][]interface{}{{int64(1), "enc:Joe"}}}
+
+		var p person
+		assert.NoError(t, fetchRow(mapper, testCodecs, rows, &p))
+		if assert.NotNil(t, p.Name) {
+			assert.Equal(t, "Joe", *p.Name)
+		}
+	}
+
+	{
+		rows := &fakeRows{rows: [][]interface{}{{int64(1), "Joe"}}}
+
+		var p person
+		assert.Error(t, fetchRow(mapper, testCodecs, rows, &p))
+	}
+
+	{
+		type document struct {
+			ID   int64                  `db:"id"`
+			Data map[string]interface{} `db:"name,json,codec=prefix"`
+		}
+
+		rows := &fakeRows{rows: [][]interface{}{{int64(1), `enc:{"status":"active"}`}}}
+
+		var d document
+		assert.NoError(t, fetchRow(mapper, testCodecs, rows, &d))
+		assert.Equal(t, map[string]interface{}{"status": "active"}, d.Data)
+	}
+}
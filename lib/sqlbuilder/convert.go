@@ -13,8 +13,10 @@ import (
 var (
 	sqlNull            = exql.RawValue(`NULL`)
 	sqlDefault         = exql.RawValue(`DEFAULT`)
+	sqlOne             = exql.RawValue(`1`)
 	sqlIsOperator      = `IS`
 	sqlInOperator      = `IN`
+	sqlNotInOperator   = `NOT IN`
 	sqlDefaultOperator = `=`
 )
 
@@ -56,7 +58,12 @@ func expandQuery(in string, args []interface{}, fn func(interface{}) (string, []
 func (tu *templateWithUtils) PlaceholderValue(in interface{}) (exql.Fragment, []interface{}) {
 	switch t := in.(type) {
 	case db.RawValue:
+		checkRawValue(t)
 		return exql.RawValue(t.String()), t.Arguments()
+	case *exql.Excluded:
+		return t, nil
+	case db.CaseExpression:
+		return tu.caseFragment(t)
 	case db.Function:
 		fnName := t.Name()
 		fnArgs := []interface{}{}
@@ -78,6 +85,54 @@ func (tu *templateWithUtils) PlaceholderValue(in interface{}) (exql.Fragment, []
 	}
 }
 
+// caseFragment converts a db.CaseExpression into an exql.Case fragment, the
+// alias is left out since it's only valid on columns.
+func (tu *templateWithUtils) caseFragment(c db.CaseExpression) (*exql.Case, []interface{}) {
+	f := &exql.Case{}
+	args := []interface{}{}
+
+	branches := c.Branches()
+	f.Whens = make([]*exql.CaseWhen, len(branches))
+	for i := range branches {
+		where, condArgs := tu.toWhereWithArguments([]interface{}{branches[i].Cond})
+		cond := exql.And(where)
+
+		value, valueArgs := tu.PlaceholderValue(branches[i].Value)
+
+		f.Whens[i] = &exql.CaseWhen{Cond: &cond, Value: value}
+		args = append(args, condArgs...)
+		args = append(args, valueArgs...)
+	}
+
+	if v, ok := c.ElseValue(); ok {
+		value, valueArgs := tu.PlaceholderValue(v)
+		f.Else = value
+		args = append(args, valueArgs...)
+	}
+
+	return f, args
+}
+
+// rankFragment converts a db.SearchRank into an exql.SearchRank fragment, the
+// alias is left out since it's only valid on columns.
+func (tu *templateWithUtils) rankFragment(r db.SearchRank) (*exql.SearchRank, []interface{}) {
+	f := &exql.SearchRank{Column: exql.ColumnWithName(r.Column())}
+	if tu.RankUsesQuery() {
+		return f, []interface{}{r.Query()}
+	}
+	return f, nil
+}
+
+// caseArgument returns the CASE expression given as the only argument of a
+// "column = ?" term.
+func caseArgument(format string, args []interface{}) (db.CaseExpression, bool) {
+	if format != "?" || len(args) == 0 {
+		return nil, false
+	}
+	c, ok := args[0].(db.CaseExpression)
+	return c, ok
+}
+
 // toInterfaceArguments converts the given value into an array of interfaces.
 func toInterfaceArguments(value interface{}) (args []interface{}, isSlice bool) {
 	v := reflect.ValueOf(value)
@@ -160,6 +215,7 @@ func preprocessFn(arg interface{}) (string, []interface{}) {
 	if len(values) == 1 {
 		switch t := arg.(type) {
 		case db.RawValue:
+			checkRawValue(t)
 			return Preprocess(t.Raw(), t.Arguments())
 		case compilable:
 			c, err := t.Compile()
@@ -181,6 +237,51 @@ func Preprocess(in string, args []interface{}) (string, []interface{}) {
 	return expandQuery(in, args, preprocessFn)
 }
 
+// condError is raised while converting conditions that can't be represented,
+// like row value IN conditions with rows of the wrong size, and returned by
+// toWhere.
+type condError struct {
+	err error
+}
+
+// catchCondError recovers from a condError and stores its error in err, it
+// must be deferred.
+func catchCondError(err *error) {
+	if r := recover(); r != nil {
+		ce, ok := r.(condError)
+		if !ok {
+			panic(r)
+		}
+		*err = ce.err
+	}
+}
+
+// rawValueErr returns the error carried by raw values that could not be
+// built, like the ones with named parameters that have no value, see db.Raw.
+func rawValueErr(v db.RawValue) error {
+	if e, ok := v.(interface {
+		Err() error
+	}); ok {
+		return e.Err()
+	}
+	return nil
+}
+
+// checkRawValue raises a condError for raw values that carry an error.
+func checkRawValue(v db.RawValue) {
+	if err := rawValueErr(v); err != nil {
+		panic(condError{err})
+	}
+}
+
+// toWhere is like toWhereWithArguments, but returns an error for conditions
+// that can't be represented instead of panicking.
+func (tu *templateWithUtils) toWhere(term interface{}) (where exql.Where, args []interface{}, err error) {
+	defer catchCondError(&err)
+	where, args = tu.toWhereWithArguments(term)
+	return where, args, nil
+}
+
 // toWhereWithArguments converts the given parameters into a exql.Where
 // value.
 func (tu *templateWithUtils) toWhereWithArguments(term interface{}) (where exql.Where, args []interface{}) {
@@ -220,6 +321,7 @@ func (tu *templateWithUtils) toWhereWithArguments(term interface{}) (where exql.
 		}
 		return
 	case db.RawValue:
+		checkRawValue(t)
 		r, v := Preprocess(t.Raw(), t.Arguments())
 		where.Conditions = []exql.Fragment{exql.RawValue(r)}
 		args = append(args, v...)
@@ -272,6 +374,148 @@ func (tu *templateWithUtils) toWhereWithArguments(term interface{}) (where exql.
 	panic(fmt.Sprintf("Unknown condition type %T", term))
 }
 
+// setColumnValues converts the terms given to Set() or DoUpdate() into
+// assignment fragments. A single struct or map is expanded into one
+// assignment per field, anything else is handled by toColumnValues.
+func (tu *templateWithUtils) setColumnValues(options *MapOptions, terms []interface{}) (cvs []exql.Fragment, args []interface{}, err error) {
+	defer catchCondError(&err)
+
+	if len(terms) == 1 {
+		ff, vv, mapErr := Map(terms[0], options)
+		if mapErr == nil && len(ff) > 0 {
+			cvs = make([]exql.Fragment, 0, len(ff))
+			args = make([]interface{}, 0, len(vv))
+
+			for i := range ff {
+				cv := &exql.ColumnValue{
+					Column:   exql.ColumnWithName(ff[i]),
+					Operator: tu.AssignmentOperator,
+				}
+
+				var localArgs []interface{}
+				cv.Value, localArgs = tu.PlaceholderValue(vv[i])
+
+				args = append(args, localArgs...)
+				cvs = append(cvs, cv)
+			}
+
+			return cvs, args, nil
+		}
+	}
+
+	cv, args := tu.toColumnValues(terms)
+	return cv.ColumnValues, args, nil
+}
+
+// splitColumnOperator separates a condition key, like "name !=", into its
+// column and operator parts. JSON path expressions, like "data -> 'tags' ->>
+// 0", and function calls, like "JSON_EXTRACT(data, '$.tags')", are kept
+// together as part of the column.
+func splitColumnOperator(key string) (column string, operator string) {
+	tokens := []string{}
+
+	quoted, depth, start := false, 0, -1
+	for i := 0; i < len(key); i++ {
+		switch {
+		case key[i] == '\'':
+			quoted = !quoted
+		case quoted:
+		case key[i] == '(':
+			depth++
+		case key[i] == ')':
+			depth--
+		case depth > 0:
+		case key[i] == ' ' || key[i] == '\t' || key[i] == '\r' || key[i] == '\n':
+			if start >= 0 {
+				tokens = append(tokens, key[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, key[start:])
+	}
+
+	if len(tokens) == 0 {
+		return "", ""
+	}
+
+	isPathOperator := func(s string) bool {
+		return strings.HasPrefix(s, "->") || strings.HasPrefix(s, "#>")
+	}
+
+	column = tokens[0]
+
+	i := 1
+	for ; i < len(tokens); i++ {
+		if !isPathOperator(tokens[i]) && !strings.HasSuffix(column, ">") {
+			break
+		}
+		column = column + tokens[i]
+	}
+
+	return column, strings.Join(tokens[i:], " ")
+}
+
+// splitTupleColumns separates a row value condition key, like "(a, b) IN" or
+// "(a, b) NOT IN", into its columns.
+func splitTupleColumns(key string) (columns []string, negate bool, ok bool) {
+	key = strings.TrimSpace(key)
+	if !strings.HasPrefix(key, "(") {
+		return nil, false, false
+	}
+
+	end := strings.Index(key, ")")
+	if end < 0 {
+		return nil, false, false
+	}
+
+	switch strings.ToUpper(strings.Join(strings.Fields(key[end+1:]), " ")) {
+	case "IN":
+	case "NOT IN":
+		negate = true
+	default:
+		return nil, false, false
+	}
+
+	columns = strings.Split(key[1:end], ",")
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+
+	return columns, negate, true
+}
+
+// toTupleIn converts a list of rows, like [][]interface{}{{1, "x"}, {2, "y"}},
+// into a row value IN condition on the given columns.
+func toTupleIn(columns []string, negate bool, value interface{}) (*exql.TupleIn, []interface{}) {
+	fragments := make([]exql.Fragment, len(columns))
+	for i := range columns {
+		fragments[i] = exql.ColumnWithName(columns[i])
+	}
+
+	rows, _ := toInterfaceArguments(value)
+
+	args := make([]interface{}, 0, len(rows)*len(columns))
+	for i := range rows {
+		row, isSlice := toInterfaceArguments(rows[i])
+		if !isSlice || len(row) != len(columns) {
+			panic(condError{fmt.Errorf("Expecting rows of %d values for %v but got %v.", len(columns), columns, rows[i])})
+		}
+		args = append(args, row...)
+	}
+
+	return &exql.TupleIn{
+		Columns: exql.JoinColumns(fragments...),
+		Rows:    len(rows),
+		Negate:  negate,
+	}, args
+}
+
 func (tu *templateWithUtils) toColumnValues(term interface{}) (cv exql.ColumnValues, args []interface{}) {
 	args = []interface{}{}
 
@@ -303,6 +547,15 @@ func (tu *templateWithUtils) toColumnValues(term interface{}) (cv exql.ColumnVal
 				Value:    exql.RawValue(format),
 			}
 
+			if c, ok := caseArgument(format, t[i+1:]); ok {
+				var a []interface{}
+				columnValue.Value, a = tu.caseFragment(c)
+				args = append(args, a...)
+				cv.ColumnValues = append(cv.ColumnValues, &columnValue)
+				i = i + 1
+				continue
+			}
+
 			ps := strings.Count(format, "?")
 			if i+ps < l {
 				for j := 0; j < ps; j++ {
@@ -317,17 +570,29 @@ func (tu *templateWithUtils) toColumnValues(term interface{}) (cv exql.ColumnVal
 		}
 		return cv, args
 	case db.Constraint:
+		if key, ok := t.Key().(string); ok {
+			if columns, negate, ok := splitTupleColumns(key); ok {
+				tupleIn, a := toTupleIn(columns, negate, t.Value())
+				cv.ColumnValues = append(cv.ColumnValues, tupleIn)
+				return cv, a
+			}
+		}
+
 		columnValue := exql.ColumnValue{}
 
 		// Guessing operator from input, or using a default one.
 		if column, ok := t.Key().(string); ok {
-			chunks := strings.SplitN(strings.TrimSpace(column), ` `, 2)
-			columnValue.Column = exql.ColumnWithName(chunks[0])
-			if len(chunks) > 1 {
-				columnValue.Operator = chunks[1]
-			}
+			column, operator := splitColumnOperator(column)
+			columnValue.Column = exql.ColumnWithName(column)
+			columnValue.Operator = operator
+		} else if comparison, ok := t.Key().(db.Comparison); ok {
+			columnValue.Column = exql.ColumnWithName(comparison.Column)
+			columnValue.Operator = comparison.Operator
+		} else if identifier, ok := t.Key().(db.Identifier); ok {
+			columnValue.Column = exql.IdentifierWithParts(identifier.Parts()...)
 		} else {
 			if rawValue, ok := t.Key().(db.RawValue); ok {
+				checkRawValue(rawValue)
 				columnValue.Column = exql.RawValue(rawValue.Raw())
 				args = append(args, rawValue.Arguments()...)
 			} else {
@@ -349,9 +614,14 @@ func (tu *templateWithUtils) toColumnValues(term interface{}) (cv exql.ColumnVal
 			columnValue.Value = exql.RawValue(fnName)
 			args = append(args, fnArgs...)
 		case db.RawValue:
+			checkRawValue(value)
 			q, a := Preprocess(value.Raw(), value.Arguments())
 			columnValue.Value = exql.RawValue(q)
 			args = append(args, a...)
+		case db.CaseExpression:
+			var a []interface{}
+			columnValue.Value, a = tu.caseFragment(value)
+			args = append(args, a...)
 		case driver.Valuer:
 			columnValue.Value = exql.RawValue("?")
 			args = append(args, value)
@@ -365,6 +635,12 @@ func (tu *templateWithUtils) toColumnValues(term interface{}) (cv exql.ColumnVal
 				if columnValue.Operator == "" {
 					columnValue.Operator = sqlInOperator
 				}
+				if len(v) == 0 && strings.EqualFold(columnValue.Operator, sqlNotInOperator) {
+					// Excluding nothing matches all rows, while "NOT IN (NULL)"
+					// matches none.
+					cv.ColumnValues = append(cv.ColumnValues, &exql.ColumnValue{Column: sqlOne, Operator: sqlDefaultOperator, Value: sqlOne})
+					return cv, args
+				}
 				if len(v) > 0 {
 					// Array value given.
 					columnValue.Value = exql.RawValue(fmt.Sprintf(`(?%s)`, strings.Repeat(`, ?`, len(v)-1)))
@@ -401,6 +677,7 @@ func (tu *templateWithUtils) toColumnValues(term interface{}) (cv exql.ColumnVal
 
 		return cv, args
 	case db.RawValue:
+		checkRawValue(t)
 		columnValue := exql.ColumnValue{}
 		p, q := Preprocess(t.Raw(), t.Arguments())
 
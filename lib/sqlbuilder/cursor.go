@@ -0,0 +1,120 @@
+package sqlbuilder
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"sync/atomic"
+
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+// sqlRows is the subset of *sql.Rows iterators read results from.
+type sqlRows interface {
+	Next() bool
+	Err() error
+	Columns() ([]string, error)
+	Scan(dest ...interface{}) error
+	Close() error
+}
+
+// Cursor represents a server-side cursor that has been declared on the
+// database.
+type Cursor interface {
+	// Fetch retrieves the next batch of rows from the cursor.
+	Fetch(ctx context.Context) (*sql.Rows, error)
+
+	// Close closes the cursor and releases the resources it holds.
+	Close() error
+}
+
+// cursorDB is implemented by sessions that can declare server-side cursors.
+type cursorDB interface {
+	StatementCursor(ctx context.Context, declare, fetch, close *exql.Statement, args ...interface{}) (Cursor, error)
+}
+
+var lastCursorID uint64
+
+func newCursorName() string {
+	return "upper_cursor_" + strconv.FormatUint(atomic.AddUint64(&lastCursorID, 1), 10)
+}
+
+// cursorRows reads rows from a Cursor one batch at a time, it's done when a
+// batch returns less than fetchSize rows.
+type cursorRows struct {
+	ctx       context.Context
+	cursor    Cursor
+	fetchSize int
+
+	rows    *sql.Rows
+	n       int
+	columns []string
+	done    bool
+	err     error
+}
+
+func (c *cursorRows) fetch() bool {
+	if c.rows != nil {
+		return true
+	}
+	if c.done || c.err != nil {
+		return false
+	}
+	c.n = 0
+	if c.rows, c.err = c.cursor.Fetch(c.ctx); c.err != nil {
+		return false
+	}
+	if c.columns == nil {
+		c.columns, c.err = c.rows.Columns()
+	}
+	return c.err == nil
+}
+
+func (c *cursorRows) Next() bool {
+	for c.fetch() {
+		if c.rows.Next() {
+			c.n++
+			return true
+		}
+		if c.err = c.rows.Err(); c.err != nil {
+			return false
+		}
+		c.err = c.rows.Close()
+		c.rows = nil
+		if c.n < c.fetchSize {
+			c.done = true
+		}
+	}
+	return false
+}
+
+func (c *cursorRows) Err() error {
+	return c.err
+}
+
+func (c *cursorRows) Columns() ([]string, error) {
+	if c.columns == nil {
+		c.fetch()
+	}
+	return c.columns, c.err
+}
+
+func (c *cursorRows) Scan(dest ...interface{}) error {
+	if c.rows == nil {
+		return sql.ErrNoRows
+	}
+	return c.rows.Scan(dest...)
+}
+
+func (c *cursorRows) Close() error {
+	var err error
+	if c.rows != nil {
+		err = c.rows.Close()
+		c.rows = nil
+	}
+	c.done = true
+	if cerr := c.cursor.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
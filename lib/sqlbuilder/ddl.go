@@ -0,0 +1,843 @@
+package sqlbuilder
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/immutable"
+	"upper.io/db.v3/internal/sqladapter/exql"
+	"upper.io/db.v3/lib/reflectx"
+)
+
+// ColumnType is a generic column type, adapters translate it into the type
+// their database uses, like Integer into INTEGER or INT. Types that are not
+// listed here are used as they are, like ColumnType("GEOGRAPHY").
+type ColumnType string
+
+// Generic column types.
+const (
+	Boolean   ColumnType = "boolean"
+	SmallInt  ColumnType = "smallint"
+	Integer   ColumnType = "integer"
+	BigInt    ColumnType = "bigint"
+	Float     ColumnType = "float"
+	Decimal   ColumnType = "decimal"
+	String    ColumnType = "string"
+	Text      ColumnType = "text"
+	Bytes     ColumnType = "bytes"
+	Date      ColumnType = "date"
+	Timestamp ColumnType = "timestamp"
+	Interval  ColumnType = "interval"
+	JSON      ColumnType = "json"
+	UUID      ColumnType = "uuid"
+	Enum      ColumnType = "enum"
+)
+
+// defaultStringSize is the size of String columns that don't set one.
+const defaultStringSize = 255
+
+// Column describes a column of a CREATE TABLE or ALTER TABLE statement.
+type Column struct {
+	Name string
+	Type ColumnType
+
+	// Size is the length of String columns, which defaults to 255, the
+	// length of Enum columns, which defaults to the length of their longest
+	// value, and the precision of Decimal columns.
+	Size int
+
+	// Scale is the scale of Decimal columns.
+	Scale int
+
+	// Values are the values of Enum columns, which are stored as strings
+	// limited to those values, see db.Enum.
+	Values []string
+
+	NotNull bool
+	Unique  bool
+
+	// AutoIncrement makes the database fill integer columns with increasing
+	// values, it's meant for primary keys.
+	AutoIncrement bool
+
+	// Default is the default value of the column, it can be a string, a
+	// number, a bool or a db.Raw expression, like db.Raw("CURRENT_TIMESTAMP").
+	Default interface{}
+}
+
+var sqlNullTypes = map[reflect.Type]ColumnType{
+	reflect.TypeOf(sql.NullBool{}):    Boolean,
+	reflect.TypeOf(sql.NullInt64{}):   BigInt,
+	reflect.TypeOf(sql.NullInt32{}):   Integer,
+	reflect.TypeOf(sql.NullFloat64{}): Float,
+	reflect.TypeOf(sql.NullString{}):  String,
+	reflect.TypeOf(sql.NullTime{}):    Timestamp,
+
+	reflect.TypeOf(db.NullTime{}):        Timestamp,
+	reflect.TypeOf(db.NullJSON{}):        JSON,
+	reflect.TypeOf(db.NullStringSlice{}): ColumnType("TEXT[]"),
+}
+
+var (
+	bytesType    = reflect.TypeOf([]byte{})
+	decimalType  = reflect.TypeOf(db.Decimal{})
+	intervalType = reflect.TypeOf(db.Interval{})
+	nullTimeType = reflect.TypeOf(db.NullTime{})
+)
+
+// StructColumns returns the columns of the given struct and its primary key,
+// made of the fields with the pk option, fields are mapped with the given
+// mapper. See TableCreator.FromStruct.
+func StructColumns(m *reflectx.Mapper, item interface{}) ([]Column, []string, error) {
+	itemT := reflect.TypeOf(item)
+	for itemT != nil && itemT.Kind() == reflect.Ptr {
+		itemT = itemT.Elem()
+	}
+	if itemT == nil || itemT.Kind() != reflect.Struct {
+		return nil, nil, errors.New("FromStruct() expects a struct.")
+	}
+
+	var columns []Column
+	var pKey []string
+	var pKeyFields []*reflectx.FieldInfo
+
+	for _, fi := range m.TypeMap(itemT).Index {
+		if fi.Name == "" || fi.Embedded || !isColumnField(fi) {
+			continue
+		}
+		if _, ok := fi.Options["prefix"]; ok {
+			// The fields of the struct are the columns.
+			continue
+		}
+
+		column, err := fieldColumn(fi)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if _, ok := fi.Options["pk"]; ok {
+			column.NotNull = true
+			pKey = append(pKey, column.Name)
+			pKeyFields = append(pKeyFields, fi)
+		}
+		columns = append(columns, column)
+	}
+
+	if len(pKey) == 1 {
+		// An integer key that is omitted when empty is left for the database
+		// to set.
+		_, omitEmpty := pKeyFields[0].Options["omitempty"]
+		for i := range columns {
+			if columns[i].Name != pKey[0] || !omitEmpty {
+				continue
+			}
+			switch columns[i].Type {
+			case SmallInt, Integer, BigInt:
+				columns[i].AutoIncrement = true
+			}
+		}
+	}
+
+	return columns, pKey, nil
+}
+
+// fieldColumn returns the column of a struct field, fields are NOT NULL
+// unless they are pointers or types like sql.NullString.
+func fieldColumn(fi *reflectx.FieldInfo) (Column, error) {
+	column := Column{Name: fi.Name, NotNull: true}
+
+	t := fi.Field.Type
+	if t.Kind() == reflect.Ptr {
+		column.NotNull = false
+		t = t.Elem()
+	}
+
+	_, tagJSON := fi.Options["json"]
+	_, tagJSONB := fi.Options["jsonb"]
+	_, tagCodec := fi.Options["codec"]
+	_, tagStringArray := fi.Options["stringarray"]
+	_, tagInt64Array := fi.Options["int64array"]
+	_, tagInterval := fi.Options["interval"]
+
+	switch {
+	case tagJSON, tagJSONB:
+		column.Type = JSON
+		return column, nil
+	case tagCodec:
+		column.Type = Bytes
+		return column, nil
+	case tagStringArray:
+		column.Type = ColumnType("TEXT[]")
+		return column, nil
+	case tagInt64Array:
+		column.Type = ColumnType("BIGINT[]")
+		return column, nil
+	case tagInterval:
+		column.Type = Interval
+		return column, nil
+	}
+
+	if values := fieldEnum(fi); values != nil {
+		column.Type = Enum
+		column.Values = values
+		return column, nil
+	}
+
+	if columnType, ok := sqlNullTypes[t]; ok {
+		column.Type = columnType
+		column.NotNull = false
+		return column, nil
+	}
+	if v, ok := genericNullValue(t); ok {
+		column.NotNull = false
+		t = v
+	}
+
+	switch {
+	case t == timeType:
+		column.Type = Timestamp
+		return column, nil
+	case t == bytesType:
+		column.Type = Bytes
+		return column, nil
+	case t == decimalType:
+		return decimalColumn(fi, column)
+	case t == intervalType:
+		column.Type = Interval
+		return column, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		column.Type = Boolean
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		column.Type = SmallInt
+	case reflect.Int32, reflect.Uint16:
+		column.Type = Integer
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		column.Type = BigInt
+	case reflect.Float32, reflect.Float64:
+		column.Type = Float
+	case reflect.String:
+		column.Type = String
+	default:
+		return Column{}, fmt.Errorf("Unsupported type %v for column %q.", fi.Field.Type, fi.Name)
+	}
+
+	return column, nil
+}
+
+// genericNullValue returns the type of the value of a db.Null[T] type,
+// which is looked up by name as generic types can't be listed in
+// sqlNullTypes.
+func genericNullValue(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct || t.PkgPath() != nullTimeType.PkgPath() || !strings.HasPrefix(t.Name(), "Null[") {
+		return nil, false
+	}
+	f, ok := t.FieldByName("V")
+	if !ok {
+		return nil, false
+	}
+	return f.Type, true
+}
+
+// decimalColumn sets the precision and scale of a db.Decimal column from the
+// precision and scale options of its field, like
+// `db:"price,precision=10,scale=2"`. Without them the database defaults are
+// used, which is an arbitrary precision on PostgreSQL but no decimal places
+// on MySQL and SQL Server.
+func decimalColumn(fi *reflectx.FieldInfo, column Column) (Column, error) {
+	column.Type = Decimal
+	for option, dst := range map[string]*int{"precision": &column.Size, "scale": &column.Scale} {
+		value, ok := fi.Options[option]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return Column{}, fmt.Errorf("Invalid %s %q for column %q.", option, value, fi.Name)
+		}
+		*dst = n
+	}
+	if column.Scale > 0 && column.Size == 0 {
+		return Column{}, fmt.Errorf("Missing precision for column %q.", fi.Name)
+	}
+	return column, nil
+}
+
+// columnDefinition converts a Column into its exql representation.
+func (b *sqlBuilder) columnDefinition(column Column) (*exql.ColumnDefinition, error) {
+	if column.Name == "" {
+		return nil, errors.New("Missing column name.")
+	}
+	if column.Type == "" {
+		return nil, fmt.Errorf("Missing type for column %q.", column.Name)
+	}
+
+	def := &exql.ColumnDefinition{
+		Name:          column.Name,
+		Type:          string(column.Type),
+		Size:          column.Size,
+		Scale:         column.Scale,
+		Values:        column.Values,
+		NotNull:       column.NotNull,
+		Unique:        column.Unique,
+		AutoIncrement: column.AutoIncrement,
+	}
+	if column.Type == String && def.Size == 0 {
+		def.Size = defaultStringSize
+	}
+	if column.Type == Enum {
+		if len(column.Values) == 0 {
+			return nil, fmt.Errorf("Missing values for column %q.", column.Name)
+		}
+		if def.Size == 0 {
+			for _, v := range column.Values {
+				if len(v) > def.Size {
+					def.Size = len(v)
+				}
+			}
+		}
+	}
+
+	if column.Default != nil {
+		value, err := b.defaultValue(column.Default)
+		if err != nil {
+			return nil, fmt.Errorf("Default value of column %q: %v", column.Name, err)
+		}
+		def.Default = value
+	}
+
+	return def, nil
+}
+
+// defaultValue renders the default value of a column as a literal, DDL
+// statements can't take placeholders.
+func (b *sqlBuilder) defaultValue(v interface{}) (exql.Fragment, error) {
+	switch t := v.(type) {
+	case db.RawValue:
+		if err := rawValueErr(t); err != nil {
+			return nil, err
+		}
+		if len(t.Arguments()) > 0 {
+			return nil, errors.New("raw default values can't have arguments")
+		}
+		return exql.RawValue(t.Raw()), nil
+	case bool:
+		keyword := b.t.TrueKeyword
+		if !t {
+			keyword = b.t.FalseKeyword
+		}
+		if keyword == "" {
+			keyword = strings.ToUpper(fmt.Sprintf("%v", t))
+		}
+		return exql.RawValue(keyword), nil
+	case string:
+		return exql.RawValue("'" + strings.Replace(t, "'", "''", -1) + "'"), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return exql.RawValue(fmt.Sprintf("%v", t)), nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", v)
+}
+
+// ddlIdentifier splits a name like "schema.table" into its parts.
+func ddlIdentifier(name string) *exql.Identifier {
+	return exql.IdentifierWithParts(strings.Split(name, ".")...)
+}
+
+func ddlColumns(names []string) *exql.Columns {
+	columns := &exql.Columns{Columns: make([]exql.Fragment, len(names))}
+	for i := range names {
+		columns.Columns[i] = exql.IdentifierWithParts(names[i])
+	}
+	return columns
+}
+
+type tableCreatorQuery struct {
+	table       string
+	columns     []Column
+	primaryKey  []string
+	ifNotExists bool
+
+	amendFn func(string) string
+}
+
+func (tq *tableCreatorQuery) setColumn(column Column) {
+	for i := range tq.columns {
+		if tq.columns[i].Name == column.Name {
+			tq.columns[i] = column
+			return
+		}
+	}
+	tq.columns = append(tq.columns, column)
+}
+
+type tableCreator struct {
+	builder *sqlBuilder
+
+	fn   func(*tableCreatorQuery) error
+	prev *tableCreator
+}
+
+var _ = immutable.Immutable(&tableCreator{})
+
+func (tc *tableCreator) SQLBuilder() *sqlBuilder {
+	if tc.prev == nil {
+		return tc.builder
+	}
+	return tc.prev.SQLBuilder()
+}
+
+func (tc *tableCreator) template() *exql.Template {
+	return tc.SQLBuilder().t.Template
+}
+
+func (tc *tableCreator) String() string {
+	s, err := tc.Compile()
+	if err != nil {
+		panic(err.Error())
+	}
+	return prepareQueryForDisplay(s)
+}
+
+func (tc *tableCreator) frame(fn func(*tableCreatorQuery) error) *tableCreator {
+	return &tableCreator{prev: tc, fn: fn}
+}
+
+func (tc *tableCreator) setTable(table string) *tableCreator {
+	return tc.frame(func(tq *tableCreatorQuery) error {
+		tq.table = table
+		return nil
+	})
+}
+
+func (tc *tableCreator) Columns(columns ...Column) TableCreator {
+	return tc.frame(func(tq *tableCreatorQuery) error {
+		for i := range columns {
+			tq.setColumn(columns[i])
+		}
+		return nil
+	})
+}
+
+func (tc *tableCreator) FromStruct(item interface{}) TableCreator {
+	return tc.frame(func(tq *tableCreatorQuery) error {
+		columns, pKey, err := StructColumns(tc.SQLBuilder().structMapper(), item)
+		if err != nil {
+			return err
+		}
+		for i := range columns {
+			tq.setColumn(columns[i])
+		}
+		if len(pKey) > 0 {
+			tq.primaryKey = pKey
+		}
+		return nil
+	})
+}
+
+func (tc *tableCreator) PrimaryKey(columns ...string) TableCreator {
+	return tc.frame(func(tq *tableCreatorQuery) error {
+		tq.primaryKey = columns
+		return nil
+	})
+}
+
+func (tc *tableCreator) IfNotExists() TableCreator {
+	return tc.frame(func(tq *tableCreatorQuery) error {
+		tq.ifNotExists = true
+		return nil
+	})
+}
+
+func (tc *tableCreator) Amend(fn func(string) string) TableCreator {
+	return tc.frame(func(tq *tableCreatorQuery) error {
+		tq.amendFn = fn
+		return nil
+	})
+}
+
+// Arguments returns nil, DDL statements have no arguments.
+func (tc *tableCreator) Arguments() []interface{} {
+	return nil
+}
+
+func (tc *tableCreator) Exec() (sql.Result, error) {
+	return tc.ExecContext(tc.SQLBuilder().sess.Context())
+}
+
+func (tc *tableCreator) ExecContext(ctx context.Context) (sql.Result, error) {
+	stmt, err := tc.statement()
+	if err != nil {
+		return nil, err
+	}
+	return tc.SQLBuilder().sess.StatementExec(ctx, stmt)
+}
+
+func (tc *tableCreator) statement() (*exql.Statement, error) {
+	q, err := immutable.FastForward(tc)
+	if err != nil {
+		return nil, err
+	}
+	tq := q.(*tableCreatorQuery)
+
+	if len(tq.columns) == 0 {
+		return nil, errors.New("CreateTable() requires at least one column.")
+	}
+
+	definitions := &exql.ColumnDefinitions{
+		Columns: make([]*exql.ColumnDefinition, len(tq.columns)),
+	}
+	for i := range tq.columns {
+		if definitions.Columns[i], err = tc.SQLBuilder().columnDefinition(tq.columns[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	stmt := &exql.Statement{
+		Type:        exql.CreateTable,
+		Table:       ddlIdentifier(tq.table),
+		Definitions: definitions,
+		IfNotExists: tq.ifNotExists,
+	}
+
+	inline := false
+	if len(tq.primaryKey) == 1 {
+		for _, def := range definitions.Columns {
+			if def.Name == tq.primaryKey[0] && def.AutoIncrement {
+				// Some databases, like SQLite, only allow auto incremented
+				// keys to be declared along with the column.
+				def.PrimaryKey = true
+				inline = true
+			}
+		}
+	}
+	if len(tq.primaryKey) > 0 && !inline {
+		stmt.PrimaryKey = ddlColumns(tq.primaryKey)
+	}
+
+	stmt.SetAmendment(tq.amendFn)
+
+	return stmt, nil
+}
+
+func (tc *tableCreator) Compile() (string, error) {
+	s, err := tc.statement()
+	if err != nil {
+		return "", err
+	}
+	return s.Compile(tc.template())
+}
+
+func (tc *tableCreator) Prev() immutable.Immutable {
+	if tc == nil {
+		return nil
+	}
+	return tc.prev
+}
+
+func (tc *tableCreator) Fn(in interface{}) error {
+	if tc.fn == nil {
+		return nil
+	}
+	return tc.fn(in.(*tableCreatorQuery))
+}
+
+func (tc *tableCreator) Base() interface{} {
+	return &tableCreatorQuery{}
+}
+
+type indexCreatorQuery struct {
+	name    string
+	table   string
+	columns []string
+	unique  bool
+
+	amendFn func(string) string
+}
+
+type indexCreator struct {
+	builder *sqlBuilder
+
+	fn   func(*indexCreatorQuery) error
+	prev *indexCreator
+}
+
+var _ = immutable.Immutable(&indexCreator{})
+
+func (ic *indexCreator) SQLBuilder() *sqlBuilder {
+	if ic.prev == nil {
+		return ic.builder
+	}
+	return ic.prev.SQLBuilder()
+}
+
+func (ic *indexCreator) template() *exql.Template {
+	return ic.SQLBuilder().t.Template
+}
+
+func (ic *indexCreator) String() string {
+	s, err := ic.Compile()
+	if err != nil {
+		panic(err.Error())
+	}
+	return prepareQueryForDisplay(s)
+}
+
+func (ic *indexCreator) frame(fn func(*indexCreatorQuery) error) *indexCreator {
+	return &indexCreator{prev: ic, fn: fn}
+}
+
+func (ic *indexCreator) setName(name string) *indexCreator {
+	return ic.frame(func(iq *indexCreatorQuery) error {
+		iq.name = name
+		return nil
+	})
+}
+
+func (ic *indexCreator) On(table string, columns ...string) IndexCreator {
+	return ic.frame(func(iq *indexCreatorQuery) error {
+		iq.table = table
+		iq.columns = columns
+		return nil
+	})
+}
+
+func (ic *indexCreator) Unique() IndexCreator {
+	return ic.frame(func(iq *indexCreatorQuery) error {
+		iq.unique = true
+		return nil
+	})
+}
+
+func (ic *indexCreator) Amend(fn func(string) string) IndexCreator {
+	return ic.frame(func(iq *indexCreatorQuery) error {
+		iq.amendFn = fn
+		return nil
+	})
+}
+
+// Arguments returns nil, DDL statements have no arguments.
+func (ic *indexCreator) Arguments() []interface{} {
+	return nil
+}
+
+func (ic *indexCreator) Exec() (sql.Result, error) {
+	return ic.ExecContext(ic.SQLBuilder().sess.Context())
+}
+
+func (ic *indexCreator) ExecContext(ctx context.Context) (sql.Result, error) {
+	stmt, err := ic.statement()
+	if err != nil {
+		return nil, err
+	}
+	return ic.SQLBuilder().sess.StatementExec(ctx, stmt)
+}
+
+func (ic *indexCreator) statement() (*exql.Statement, error) {
+	q, err := immutable.FastForward(ic)
+	if err != nil {
+		return nil, err
+	}
+	iq := q.(*indexCreatorQuery)
+
+	if iq.table == "" || len(iq.columns) == 0 {
+		return nil, errors.New("CreateIndex() requires a table and at least one column, see On().")
+	}
+
+	stmt := &exql.Statement{
+		Type:    exql.CreateIndex,
+		Index:   exql.IdentifierWithParts(iq.name),
+		Table:   ddlIdentifier(iq.table),
+		Columns: ddlColumns(iq.columns),
+		Unique:  iq.unique,
+	}
+
+	stmt.SetAmendment(iq.amendFn)
+
+	return stmt, nil
+}
+
+func (ic *indexCreator) Compile() (string, error) {
+	s, err := ic.statement()
+	if err != nil {
+		return "", err
+	}
+	return s.Compile(ic.template())
+}
+
+func (ic *indexCreator) Prev() immutable.Immutable {
+	if ic == nil {
+		return nil
+	}
+	return ic.prev
+}
+
+func (ic *indexCreator) Fn(in interface{}) error {
+	if ic.fn == nil {
+		return nil
+	}
+	return ic.fn(in.(*indexCreatorQuery))
+}
+
+func (ic *indexCreator) Base() interface{} {
+	return &indexCreatorQuery{}
+}
+
+type tableAltererQuery struct {
+	table   string
+	changes []*exql.Statement
+}
+
+type tableAlterer struct {
+	builder *sqlBuilder
+
+	fn   func(*tableAltererQuery) error
+	prev *tableAlterer
+}
+
+var _ = immutable.Immutable(&tableAlterer{})
+
+func (ta *tableAlterer) SQLBuilder() *sqlBuilder {
+	if ta.prev == nil {
+		return ta.builder
+	}
+	return ta.prev.SQLBuilder()
+}
+
+func (ta *tableAlterer) template() *exql.Template {
+	return ta.SQLBuilder().t.Template
+}
+
+func (ta *tableAlterer) String() string {
+	s, err := ta.Compile()
+	if err != nil {
+		panic(err.Error())
+	}
+	return prepareQueryForDisplay(s)
+}
+
+func (ta *tableAlterer) frame(fn func(*tableAltererQuery) error) *tableAlterer {
+	return &tableAlterer{prev: ta, fn: fn}
+}
+
+func (ta *tableAlterer) setTable(table string) *tableAlterer {
+	return ta.frame(func(aq *tableAltererQuery) error {
+		aq.table = table
+		return nil
+	})
+}
+
+func (ta *tableAlterer) AddColumn(column Column) TableAlterer {
+	return ta.frame(func(aq *tableAltererQuery) error {
+		def, err := ta.SQLBuilder().columnDefinition(column)
+		if err != nil {
+			return err
+		}
+		aq.changes = append(aq.changes, &exql.Statement{
+			Type:   exql.AddColumn,
+			Table:  ddlIdentifier(aq.table),
+			Column: def,
+		})
+		return nil
+	})
+}
+
+func (ta *tableAlterer) DropColumn(name string) TableAlterer {
+	return ta.frame(func(aq *tableAltererQuery) error {
+		aq.changes = append(aq.changes, &exql.Statement{
+			Type:   exql.DropColumn,
+			Table:  ddlIdentifier(aq.table),
+			Column: exql.IdentifierWithParts(name),
+		})
+		return nil
+	})
+}
+
+func (ta *tableAlterer) RenameColumn(name string, newName string) TableAlterer {
+	return ta.frame(func(aq *tableAltererQuery) error {
+		aq.changes = append(aq.changes, &exql.Statement{
+			Type:    exql.RenameColumn,
+			Table:   ddlIdentifier(aq.table),
+			Column:  exql.IdentifierWithParts(name),
+			NewName: newName,
+		})
+		return nil
+	})
+}
+
+// Arguments returns nil, DDL statements have no arguments.
+func (ta *tableAlterer) Arguments() []interface{} {
+	return nil
+}
+
+func (ta *tableAlterer) Exec() (sql.Result, error) {
+	return ta.ExecContext(ta.SQLBuilder().sess.Context())
+}
+
+func (ta *tableAlterer) ExecContext(ctx context.Context) (sql.Result, error) {
+	stmts, err := ta.statements()
+	if err != nil {
+		return nil, err
+	}
+
+	var res sql.Result
+	for i := range stmts {
+		if res, err = ta.SQLBuilder().sess.StatementExec(ctx, stmts[i]); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (ta *tableAlterer) statements() ([]*exql.Statement, error) {
+	q, err := immutable.FastForward(ta)
+	if err != nil {
+		return nil, err
+	}
+	aq := q.(*tableAltererQuery)
+
+	if len(aq.changes) == 0 {
+		return nil, errors.New("AlterTable() requires at least one change.")
+	}
+	return aq.changes, nil
+}
+
+func (ta *tableAlterer) Compile() (string, error) {
+	stmts, err := ta.statements()
+	if err != nil {
+		return "", err
+	}
+
+	compiled := make([]string, len(stmts))
+	for i := range stmts {
+		if compiled[i], err = stmts[i].Compile(ta.template()); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(compiled, ";\n"), nil
+}
+
+func (ta *tableAlterer) Prev() immutable.Immutable {
+	if ta == nil {
+		return nil
+	}
+	return ta.prev
+}
+
+func (ta *tableAlterer) Fn(in interface{}) error {
+	if ta.fn == nil {
+		return nil
+	}
+	return ta.fn(in.(*tableAltererQuery))
+}
+
+func (ta *tableAlterer) Base() interface{} {
+	return &tableAltererQuery{}
+}
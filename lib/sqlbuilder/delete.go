@@ -3,6 +3,7 @@ package sqlbuilder
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"upper.io/db.v3/internal/immutable"
 	"upper.io/db.v3/internal/sqladapter/exql"
@@ -12,14 +13,27 @@ type deleterQuery struct {
 	table string
 	limit int
 
+	sources     *exql.Columns
+	sourcesArgs []interface{}
+
 	where     *exql.Where
 	whereArgs []interface{}
 
+	with     *exql.With
+	withArgs []interface{}
+
+	returning []exql.Fragment
+
+	timeout time.Duration
+
 	amendFn func(string) string
 }
 
 func (dq *deleterQuery) and(b *sqlBuilder, terms ...interface{}) error {
-	where, whereArgs := b.t.toWhereWithArguments(terms)
+	where, whereArgs, err := b.t.toWhere(terms)
+	if err != nil {
+		return err
+	}
 
 	if dq.where == nil {
 		dq.where, dq.whereArgs = &exql.Where{}, []interface{}{}
@@ -36,6 +50,10 @@ func (dq *deleterQuery) statement() *exql.Statement {
 		Table: exql.TableWithName(dq.table),
 	}
 
+	if dq.sources != nil {
+		stmt.Sources = dq.sources
+	}
+
 	if dq.where != nil {
 		stmt.Where = dq.where
 	}
@@ -44,6 +62,14 @@ func (dq *deleterQuery) statement() *exql.Statement {
 		stmt.Limit = exql.Limit(dq.limit)
 	}
 
+	if dq.with != nil {
+		stmt.With = dq.with
+	}
+
+	if len(dq.returning) > 0 {
+		stmt.Returning = exql.ReturningColumns(dq.returning...)
+	}
+
 	stmt.SetAmendment(dq.amendFn)
 
 	return stmt
@@ -79,6 +105,9 @@ func (del *deleter) String() string {
 
 func (del *deleter) setTable(table string) *deleter {
 	return del.frame(func(uq *deleterQuery) error {
+		if err := del.SQLBuilder().checkColumns(table); err != nil {
+			return err
+		}
 		uq.table = table
 		return nil
 	})
@@ -90,6 +119,9 @@ func (del *deleter) frame(fn func(*deleterQuery) error) *deleter {
 
 func (del *deleter) Where(terms ...interface{}) Deleter {
 	return del.frame(func(dq *deleterQuery) error {
+		if err := del.SQLBuilder().checkConditions(terms...); err != nil {
+			return err
+		}
 		dq.where, dq.whereArgs = &exql.Where{}, []interface{}{}
 		return dq.and(del.SQLBuilder(), terms...)
 	})
@@ -97,10 +129,28 @@ func (del *deleter) Where(terms ...interface{}) Deleter {
 
 func (del *deleter) And(terms ...interface{}) Deleter {
 	return del.frame(func(dq *deleterQuery) error {
+		if err := del.SQLBuilder().checkConditions(terms...); err != nil {
+			return err
+		}
 		return dq.and(del.SQLBuilder(), terms...)
 	})
 }
 
+func (del *deleter) Using(tables ...interface{}) Deleter {
+	return del.frame(func(dq *deleterQuery) error {
+		if err := del.SQLBuilder().checkIdentifiers(tables...); err != nil {
+			return err
+		}
+		fragments, args, err := columnFragments(tables)
+		if err != nil {
+			return err
+		}
+		dq.sources = exql.JoinColumns(fragments...)
+		dq.sourcesArgs = args
+		return nil
+	})
+}
+
 func (del *deleter) Limit(limit int) Deleter {
 	return del.frame(func(dq *deleterQuery) error {
 		dq.limit = limit
@@ -116,7 +166,7 @@ func (del *deleter) Amend(fn func(string) string) Deleter {
 }
 
 func (dq *deleterQuery) arguments() []interface{} {
-	return joinArguments(dq.whereArgs)
+	return joinArguments(dq.withArgs, dq.sourcesArgs, dq.whereArgs)
 }
 
 func (del *deleter) Arguments() []interface{} {
@@ -139,6 +189,13 @@ func (del *deleter) PrepareContext(ctx context.Context) (*sql.Stmt, error) {
 	return del.SQLBuilder().sess.StatementPrepare(ctx, dq.statement())
 }
 
+func (del *deleter) Timeout(timeout time.Duration) Deleter {
+	return del.frame(func(dq *deleterQuery) error {
+		dq.timeout = timeout
+		return nil
+	})
+}
+
 func (del *deleter) Exec() (sql.Result, error) {
 	return del.ExecContext(del.SQLBuilder().sess.Context())
 }
@@ -148,9 +205,60 @@ func (del *deleter) ExecContext(ctx context.Context) (sql.Result, error) {
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := withTimeout(ctx, dq.timeout)
+	defer cancel()
 	return del.SQLBuilder().sess.StatementExec(ctx, dq.statement(), dq.arguments()...)
 }
 
+func (del *deleter) Returning(columns ...string) Deleter {
+	return del.frame(func(dq *deleterQuery) error {
+		if err := del.SQLBuilder().checkColumns(columns...); err != nil {
+			return err
+		}
+		columnsToFragments(&dq.returning, columns)
+		return nil
+	})
+}
+
+func (del *deleter) Output(columns ...string) Deleter {
+	return del.Returning(columns...)
+}
+
+func (del *deleter) Query() (*sql.Rows, error) {
+	return del.QueryContext(del.SQLBuilder().sess.Context())
+}
+
+func (del *deleter) QueryContext(ctx context.Context) (*sql.Rows, error) {
+	dq, err := del.build()
+	if err != nil {
+		return nil, err
+	}
+	ctx = withQueryTimeout(ctx, dq.timeout)
+	return del.SQLBuilder().sess.StatementQuery(ctx, dq.statement(), dq.arguments()...)
+}
+
+func (del *deleter) QueryRow() (*sql.Row, error) {
+	return del.QueryRowContext(del.SQLBuilder().sess.Context())
+}
+
+func (del *deleter) QueryRowContext(ctx context.Context) (*sql.Row, error) {
+	dq, err := del.build()
+	if err != nil {
+		return nil, err
+	}
+	ctx = withQueryTimeout(ctx, dq.timeout)
+	return del.SQLBuilder().sess.StatementQueryRow(ctx, dq.statement(), dq.arguments()...)
+}
+
+func (del *deleter) Iterator() Iterator {
+	return del.IteratorContext(del.SQLBuilder().sess.Context())
+}
+
+func (del *deleter) IteratorContext(ctx context.Context) Iterator {
+	rows, err := del.QueryContext(ctx)
+	return del.SQLBuilder().newIterator(rows, err)
+}
+
 func (del *deleter) statement() (*exql.Statement, error) {
 	iq, err := del.build()
 	if err != nil {
@@ -164,7 +272,11 @@ func (del *deleter) build() (*deleterQuery, error) {
 	if err != nil {
 		return nil, err
 	}
-	return dq.(*deleterQuery), nil
+	ret := dq.(*deleterQuery)
+	if ret.with, ret.withArgs, err = del.SQLBuilder().withClause(); err != nil {
+		return nil, err
+	}
+	return ret, nil
 }
 
 func (del *deleter) Compile() (string, error) {
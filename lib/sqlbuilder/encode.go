@@ -0,0 +1,209 @@
+package sqlbuilder
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"upper.io/db.v3"
+)
+
+// CSVOptions configures the output of Iterator.WriteCSV.
+type CSVOptions struct {
+	// Header makes WriteCSV write the column names as the first record.
+	Header bool
+
+	// Delimiter separates the fields of a record, it defaults to a comma. Use
+	// '\t' for TSV output.
+	Delimiter rune
+
+	// Null is written for NULL values, it defaults to an empty string.
+	Null string
+
+	// TimeFormat is the layout time values are formatted with, it defaults to
+	// time.RFC3339Nano.
+	TimeFormat string
+}
+
+func (opts *CSVOptions) timeFormat() string {
+	if opts.TimeFormat == "" {
+		return time.RFC3339Nano
+	}
+	return opts.TimeFormat
+}
+
+func newCSVWriter(w io.Writer, opts *CSVOptions) *csv.Writer {
+	cw := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		cw.Comma = opts.Delimiter
+	}
+	return cw
+}
+
+// FormatField returns the text a value is written as by WriteCSV.
+func (opts *CSVOptions) FormatField(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return opts.Null
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case time.Time:
+		return t.Format(opts.timeFormat())
+	case bool:
+		return strconv.FormatBool(t)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(t), 'f', -1, 32)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// EncodeJSON writes all the rows of the iterator to w as a JSON array of
+// objects, rows are read and written one at a time.
+func (iter *iterator) EncodeJSON(w io.Writer) error {
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if iter.cursor == nil {
+		return db.ErrNoMoreRows
+	}
+	defer iter.Close()
+
+	if err := encodeJSON(w, iter.cursor); err != nil {
+		return iter.setErr(err)
+	}
+	return nil
+}
+
+// WriteCSV writes all the rows of the iterator to w as CSV records, rows are
+// read and written one at a time.
+func (iter *iterator) WriteCSV(w io.Writer, opts *CSVOptions) error {
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if iter.cursor == nil {
+		return db.ErrNoMoreRows
+	}
+	defer iter.Close()
+
+	if opts == nil {
+		opts = &CSVOptions{}
+	}
+
+	if err := writeCSV(w, iter.cursor, opts); err != nil {
+		return iter.setErr(err)
+	}
+	return nil
+}
+
+func writeCSV(w io.Writer, rows sqlRows, opts *CSVOptions) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	cw := newCSVWriter(w, opts)
+	if opts.Header {
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+	}
+
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	record := make([]string, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		for i := range values {
+			record[i] = opts.FormatField(values[i])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func encodeJSON(w io.Writer, rows sqlRows) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	keys := make([][]byte, len(columns))
+	for i := range columns {
+		if keys[i], err = json.Marshal(columns[i]); err != nil {
+			return err
+		}
+	}
+
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	buf := bufio.NewWriter(w)
+	buf.WriteByte('[')
+
+	for n := 0; rows.Next(); n++ {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for i := range values {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(keys[i])
+			buf.WriteByte(':')
+
+			value, err := json.Marshal(jsonValue(values[i]))
+			if err != nil {
+				return err
+			}
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	buf.WriteByte(']')
+	return buf.Flush()
+}
+
+// jsonValue returns the value a column is encoded as. Drivers return text
+// columns as []byte, they're encoded as strings instead of base64 unless
+// they're not valid UTF-8.
+func jsonValue(v interface{}) interface{} {
+	if b, ok := v.([]byte); ok && utf8.Valid(b) {
+		return string(b)
+	}
+	return v
+}
@@ -0,0 +1,96 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/reflectx"
+)
+
+var enumType = reflect.TypeOf((*db.Enum)(nil)).Elem()
+
+// fieldEnum returns the values of a field with the enum option, like
+// `db:"status,enum=active|disabled"`, or of a type that implements db.Enum,
+// or nil for other fields.
+func fieldEnum(fi *reflectx.FieldInfo) []string {
+	if values, ok := fi.Options["enum"]; ok {
+		return strings.Split(values, "|")
+	}
+	t := fi.Field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Implements(enumType) {
+		return reflect.Zero(t).Interface().(db.Enum).EnumValues()
+	}
+	return nil
+}
+
+// enumValue returns the value an enum field is written as, which is the
+// value of string fields and the value at the position of integer fields.
+func enumValue(fi *reflectx.FieldInfo, values []string, fld reflect.Value) (interface{}, error) {
+	f := reflect.Indirect(fld)
+
+	var value string
+	switch f.Kind() {
+	case reflect.String:
+		value = f.String()
+		for i := range values {
+			if values[i] == value {
+				return value, nil
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i := f.Int(); i >= 0 && i < int64(len(values)) {
+			return values[i], nil
+		}
+		value = strconv.FormatInt(f.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if i := f.Uint(); i < uint64(len(values)) {
+			return values[i], nil
+		}
+		value = strconv.FormatUint(f.Uint(), 10)
+	default:
+		return nil, fmt.Errorf(`Expecting field %q to be a string or an integer (using "enum" tag)`, fi.Name)
+	}
+	return nil, &db.EnumError{Column: fi.Name, Value: value, Values: values}
+}
+
+// scanEnum sets an enum field from the value read from its column, NULL
+// values leave the field as it is.
+func scanEnum(fi *reflectx.FieldInfo, values []string, f reflect.Value, src []byte) error {
+	if src == nil {
+		return nil
+	}
+
+	value := string(src)
+	pos := -1
+	for i := range values {
+		if values[i] == value {
+			pos = i
+			break
+		}
+	}
+	if pos < 0 {
+		return &db.EnumError{Column: fi.Name, Value: value, Values: values}
+	}
+
+	if f.Kind() == reflect.Ptr {
+		f.Set(reflect.New(f.Type().Elem()))
+		f = f.Elem()
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f.SetInt(int64(pos))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f.SetUint(uint64(pos))
+	default:
+		return fmt.Errorf(`Expecting field %q to be a string or an integer (using "enum" tag)`, fi.Name)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package sqlbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+)
+
+type testPriority uint8
+
+func (testPriority) EnumValues() []string {
+	return []string{"low", "normal", "high"}
+}
+
+type testStatus string
+
+func (testStatus) EnumValues() []string {
+	return []string{"active", "disabled"}
+}
+
+func TestMapEnum(t *testing.T) {
+	type task struct {
+		ID       int64        `db:"id"`
+		Kind     string       `db:"kind,enum=bug|feature,omitempty"`
+		Priority testPriority `db:"priority"`
+		Status   *testStatus  `db:"status"`
+		Level    int          `db:"level,enum=debug|info|error"`
+	}
+
+	disabled := testStatus("disabled")
+	columns, values, err := Map(task{ID: 1, Kind: "bug", Priority: 2, Status: &disabled, Level: 1}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "kind", "level", "priority", "status"}, columns)
+	assert.Equal(t, []interface{}{int64(1), "bug", "info", "high", "disabled"}, values)
+
+	// Empty values are skipped with omitempty and NULL pointers are kept.
+	columns, values, err = Map(task{ID: 1}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "level", "priority", "status"}, columns)
+	assert.Equal(t, []interface{}{int64(1), "debug", "low", nil}, values)
+
+	for _, item := range []task{
+		{Kind: "chore"},
+		{Priority: 3},
+		{Level: -1},
+	} {
+		_, _, err := Map(item, nil)
+		if assert.IsType(t, &db.EnumError{}, err) {
+			assert.Contains(t, err.Error(), "invalid value")
+		}
+	}
+
+	invalid := testStatus("deleted")
+	_, _, err = Map(task{Status: &invalid}, nil)
+	assert.Equal(t, &db.EnumError{Column: "status", Value: "deleted", Values: []string{"active", "disabled"}}, err)
+
+	_, _, err = Map(struct {
+		Score float64 `db:"score,enum=a|b"`
+	}{}, nil)
+	assert.Error(t, err)
+}
+
+func TestFetchEnum(t *testing.T) {
+	type task struct {
+		ID       int64        `db:"id"`
+		Priority testPriority `db:"name"`
+		Status   *testStatus  `db:"status"`
+		Level    int          `db:"level,enum=debug|info|error"`
+	}
+
+	rows := &fakeRows{
+		rows:    [][]interface{}{{int64(1), "high", "active", "error"}, {int64(2), "low", nil, "debug"}},
+		columns: []string{"id", "name", "status", "level"},
+	}
+
+	var tasks []task
+	assert.NoError(t, fetchRows(mapper, nil, rows, &tasks))
+	active := testStatus("active")
+	assert.Equal(t, []task{
+		{ID: 1, Priority: 2, Status: &active, Level: 2},
+		{ID: 2, Priority: 0, Level: 0},
+	}, tasks)
+
+	{
+		rows := &fakeRows{
+			rows:    [][]interface{}{{int64(1), "urgent", nil, "info"}},
+			columns: []string{"id", "name", "status", "level"},
+		}
+		var item task
+		err := fetchRow(mapper, nil, rows, &item)
+		assert.Equal(t, &db.EnumError{Column: "name", Value: "urgent", Values: []string{"low", "normal", "high"}}, err)
+	}
+}
+
+func TestCreateTableEnum(t *testing.T) {
+	b := &sqlBuilder{t: newTemplateWithUtils(&testTemplate)}
+
+	type task struct {
+		Kind     string       `db:"kind,enum=bug|feature|it's"`
+		Priority testPriority `db:"priority"`
+		Status   *testStatus  `db:"status"`
+	}
+
+	assert.Equal(t,
+		`CREATE TABLE "tasks" ("kind" VARCHAR(7) CHECK ("kind" IN ('bug', 'feature', 'it''s')) NOT NULL, "priority" VARCHAR(6) CHECK ("priority" IN ('low', 'normal', 'high')) NOT NULL, "status" VARCHAR(8) CHECK ("status" IN ('active', 'disabled')))`,
+		b.CreateTable("tasks").FromStruct(task{}).String(),
+	)
+
+	assert.Equal(t,
+		`CREATE TABLE "tasks" ("kind" VARCHAR(20) CHECK ("kind" IN ('bug')))`,
+		b.CreateTable("tasks").Columns(Column{Name: "kind", Type: Enum, Size: 20, Values: []string{"bug"}}).String(),
+	)
+
+	_, err := b.CreateTable("tasks").Columns(Column{Name: "kind", Type: Enum}).(compilable).Compile()
+	assert.Error(t, err)
+}
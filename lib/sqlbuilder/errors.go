@@ -11,4 +11,9 @@ var (
 	ErrExpectingSliceMapStruct             = errors.New(`Argument must be a slice address of maps or structs.`)
 	ErrExpectingMapOrStruct                = errors.New(`Argument must be either a map or a struct.`)
 	ErrExpectingPointerToEitherMapOrStruct = errors.New(`Expecting a pointer to either a map or a struct.`)
+	ErrExpectingSingleColumn               = errors.New(`Scanning into a value that is not a map or a struct requires a single column.`)
+	ErrInvalidChunkSize                    = errors.New(`Chunk size must be greater than zero.`)
+	ErrInvalidGID                          = errors.New(`Global transaction identifiers must be made of letters, digits, underscores, hyphens, dots or colons.`)
+	ErrInvalidIdentifier                   = errors.New(`Strict identifiers are enabled, table and column names must be plain identifiers or built with db.Ident.`)
+	ErrInvalidSavepointName                = errors.New(`Savepoint names must be made of letters, digits or underscores and can't start with a digit.`)
 )
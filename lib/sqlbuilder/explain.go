@@ -0,0 +1,99 @@
+package sqlbuilder
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+// QueryPlan represents the plan the database uses to run a query, as
+// returned by Selector.Explain() and Selector.ExplainAnalyze().
+type QueryPlan struct {
+	// Nodes are the top level steps of the plan.
+	Nodes []*PlanNode
+
+	// Raw is the plan as it was returned by the database.
+	Raw string
+}
+
+// PlanNode represents a step of a query plan.
+type PlanNode struct {
+	// Operation describes the step, like "Seq Scan" on PostgreSQL or "SCAN
+	// artist" on SQLite.
+	Operation string
+
+	// Table is the name of the table the step reads from, if any.
+	Table string
+
+	// Attributes are the properties the database reports for the step, like
+	// costs, row estimates or actual timings.
+	Attributes map[string]interface{}
+
+	// Children are the steps this step gets its rows from.
+	Children []*PlanNode
+}
+
+// String returns the plan as an indented tree of operations.
+func (p *QueryPlan) String() string {
+	var b bytes.Buffer
+	for _, node := range p.Nodes {
+		node.write(&b, 0)
+	}
+	return b.String()
+}
+
+func (n *PlanNode) write(b *bytes.Buffer, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+	b.WriteString(n.Operation)
+	if n.Table != "" && !strings.Contains(n.Operation, n.Table) {
+		b.WriteString(" on ")
+		b.WriteString(n.Table)
+	}
+	b.WriteString("\n")
+	for _, child := range n.Children {
+		child.write(b, depth+1)
+	}
+}
+
+// Walk calls fn for each node of the plan, parents are visited before their
+// children.
+func (p *QueryPlan) Walk(fn func(node *PlanNode)) {
+	var walk func(nodes []*PlanNode)
+	walk = func(nodes []*PlanNode) {
+		for _, node := range nodes {
+			fn(node)
+			walk(node.Children)
+		}
+	}
+	walk(p.Nodes)
+}
+
+// explainDB is implemented by sessions that can return query plans.
+type explainDB interface {
+	StatementExplain(ctx context.Context, stmt *exql.Statement, analyze bool, args ...interface{}) (*QueryPlan, error)
+}
+
+func (sel *selector) Explain(ctx context.Context) (*QueryPlan, error) {
+	return sel.explain(ctx, false)
+}
+
+func (sel *selector) ExplainAnalyze(ctx context.Context) (*QueryPlan, error) {
+	return sel.explain(ctx, true)
+}
+
+func (sel *selector) explain(ctx context.Context, analyze bool) (*QueryPlan, error) {
+	sess, ok := sel.SQLBuilder().sess.(explainDB)
+	if !ok {
+		return nil, db.ErrUnsupported
+	}
+
+	sq, err := sel.build()
+	if err != nil {
+		return nil, err
+	}
+
+	return sess.StatementExplain(ctx, sq.statement(), analyze, sq.arguments()...)
+}
@@ -25,16 +25,26 @@ import (
 	"database/sql"
 	"encoding/json"
 	"reflect"
+	"time"
 
 	"upper.io/db.v3"
 	"upper.io/db.v3/lib/reflectx"
 )
 
+// mapper is used to map structs when the session does not provide a mapper
+// of its own, see hasMapper.
 var mapper = reflectx.NewMapper("db")
 
+var (
+	scannerType     = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*db.Unmarshaler)(nil)).Elem()
+	timeType        = reflect.TypeOf(time.Time{})
+	durationType    = reflect.TypeOf(time.Duration(0))
+)
+
 // fetchRow receives a *sql.Rows value and tries to map all the rows into a
 // single struct given by the pointer `dst`.
-func fetchRow(rows *sql.Rows, dst interface{}) error {
+func fetchRow(m *reflectx.Mapper, codecs func(string) db.Codec, rows sqlRows, dst interface{}) error {
 	var columns []string
 	var err error
 
@@ -62,7 +72,7 @@ func fetchRow(rows *sql.Rows, dst interface{}) error {
 	}
 
 	itemT := itemV.Type()
-	item, err := fetchResult(itemT, rows, columns)
+	item, err := fetchResult(m, codecs, itemT, rows, columns)
 
 	if err != nil {
 		return err
@@ -79,7 +89,7 @@ func fetchRow(rows *sql.Rows, dst interface{}) error {
 
 // fetchRows receives a *sql.Rows value and tries to map all the rows into a
 // slice of structs given by the pointer `dst`.
-func fetchRows(rows *sql.Rows, dst interface{}) error {
+func fetchRows(m *reflectx.Mapper, codecs func(string) db.Codec, rows sqlRows, dst interface{}) error {
 	var err error
 
 	defer rows.Close()
@@ -110,7 +120,7 @@ func fetchRows(rows *sql.Rows, dst interface{}) error {
 	reset(dst)
 
 	for rows.Next() {
-		item, err := fetchResult(itemT, rows, columns)
+		item, err := fetchResult(m, codecs, itemT, rows, columns)
 		if err != nil {
 			return err
 		}
@@ -121,15 +131,131 @@ func fetchRows(rows *sql.Rows, dst interface{}) error {
 		}
 	}
 
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
 	dstv.Elem().Set(slicev)
 
 	return nil
 }
 
-func fetchResult(itemT reflect.Type, rows *sql.Rows, columns []string) (reflect.Value, error) {
+// fetchChunks maps the rows into the slice given by the pointer `dst`, at most
+// size rows at a time, and calls fn after every chunk is mapped.
+func fetchChunks(m *reflectx.Mapper, codecs func(string) db.Codec, rows sqlRows, size int, dst interface{}, fn func() error) error {
+	var err error
+
+	defer rows.Close()
+
+	if size < 1 {
+		return ErrInvalidChunkSize
+	}
+
+	dstv := reflect.ValueOf(dst)
+
+	if dstv.Kind() != reflect.Ptr || dstv.IsNil() {
+		return ErrExpectingPointer
+	}
+
+	if dstv.Elem().Kind() != reflect.Slice {
+		return ErrExpectingSlicePointer
+	}
+
+	var columns []string
+	if columns, err = rows.Columns(); err != nil {
+		return err
+	}
+
+	sliceT := dstv.Elem().Type()
+	itemT := sliceT.Elem()
+
+	// A new slice is used for every chunk, so fn can keep the previous ones.
+	slicev := reflect.MakeSlice(sliceT, 0, size)
+	for rows.Next() {
+		item, err := fetchResult(m, codecs, itemT, rows, columns)
+		if err != nil {
+			return err
+		}
+		if itemT.Kind() == reflect.Ptr {
+			slicev = reflect.Append(slicev, item)
+		} else {
+			slicev = reflect.Append(slicev, reflect.Indirect(item))
+		}
+		if slicev.Len() == size {
+			dstv.Elem().Set(slicev)
+			if err = fn(); err != nil {
+				return err
+			}
+			slicev = reflect.MakeSlice(sliceT, 0, size)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if slicev.Len() > 0 {
+		dstv.Elem().Set(slicev)
+		return fn()
+	}
+
+	return nil
+}
+
+// isScalarType returns true if values of type itemT are read from a single
+// column as a whole (like int64, string or time.Time) instead of being mapped
+// column by column, as maps and structs are.
+func isScalarType(itemT reflect.Type, columns []string) bool {
+	if itemT.Kind() == reflect.Ptr {
+		itemT = itemT.Elem()
+	}
+	switch itemT.Kind() {
+	case reflect.Map:
+		return false
+	case reflect.Struct:
+		if len(columns) != 1 {
+			return false
+		}
+		ptrT := reflect.PtrTo(itemT)
+		return itemT == timeType || ptrT.Implements(scannerType) || ptrT.Implements(unmarshalerType)
+	}
+	return true
+}
+
+// fetchScalar reads the only column of the current row into a new value of
+// type itemT.
+func fetchScalar(itemT reflect.Type, rows sqlRows, columns []string) (reflect.Value, error) {
+	if len(columns) != 1 {
+		return reflect.Value{}, ErrExpectingSingleColumn
+	}
+
+	objT := itemT
+	if objT.Kind() == reflect.Ptr {
+		objT = objT.Elem()
+	}
+
+	item := reflect.New(objT)
+
+	dst := item.Interface()
+	if u, ok := dst.(db.Unmarshaler); ok {
+		dst = scanner{u}
+	}
+
+	if err := rows.Scan(dst); err != nil {
+		return item, err
+	}
+
+	return item, nil
+}
+
+func fetchResult(m *reflectx.Mapper, codecs func(string) db.Codec, itemT reflect.Type, rows sqlRows, columns []string) (reflect.Value, error) {
 	var item reflect.Value
 	var err error
 
+	if isScalarType(itemT, columns) {
+		return fetchScalar(itemT, rows, columns)
+	}
+
 	objT := itemT
 
 	switch objT.Kind() {
@@ -152,7 +278,7 @@ func fetchResult(itemT reflect.Type, rows *sql.Rows, columns []string) (reflect.
 	case reflect.Struct:
 
 		values := make([]interface{}, len(columns))
-		typeMap := mapper.TypeMap(itemT)
+		typeMap := m.TypeMap(itemT)
 		fieldMap := typeMap.Names
 		wrappedValues := map[*reflectx.FieldInfo]interface{}{}
 
@@ -164,7 +290,10 @@ func fetchResult(itemT reflect.Type, rows *sql.Rows, columns []string) (reflect.
 			}
 
 			// TODO: refactor into a nice pattern
-			if _, ok := fi.Options["stringarray"]; ok {
+			if _, ok := fi.Options["codec"]; ok {
+				values[i] = &[]byte{}
+				wrappedValues[fi] = values[i]
+			} else if _, ok := fi.Options["stringarray"]; ok {
 				values[i] = &[]byte{}
 				wrappedValues[fi] = values[i]
 			} else if _, ok := fi.Options["int64array"]; ok {
@@ -173,6 +302,15 @@ func fetchResult(itemT reflect.Type, rows *sql.Rows, columns []string) (reflect.
 			} else if _, ok := fi.Options["jsonb"]; ok {
 				values[i] = &[]byte{}
 				wrappedValues[fi] = values[i]
+			} else if _, ok := fi.Options["json"]; ok {
+				values[i] = &[]byte{}
+				wrappedValues[fi] = values[i]
+			} else if _, ok := fi.Options["interval"]; ok {
+				values[i] = &[]byte{}
+				wrappedValues[fi] = values[i]
+			} else if fieldEnum(fi) != nil {
+				values[i] = &[]byte{}
+				wrappedValues[fi] = values[i]
 			} else {
 				f := reflectx.FieldByIndexes(item, fi.Index)
 				values[i] = f.Addr().Interface()
@@ -202,12 +340,31 @@ func fetchResult(itemT reflect.Type, rows *sql.Rows, columns []string) (reflect.
 				opt = "int64array"
 			} else if _, ok := fi.Options["jsonb"]; ok {
 				opt = "jsonb"
+			} else if _, ok := fi.Options["json"]; ok {
+				opt = "json"
+			} else if _, ok := fi.Options["interval"]; ok {
+				opt = "interval"
+			} else if fieldEnum(fi) != nil {
+				opt = "enum"
 			}
 
 			b := v.(*[]byte)
 
 			f := reflectx.FieldByIndexesReadOnly(item, fi.Index)
 
+			codec, err := fieldCodec(codecs, fi)
+			if err != nil {
+				return item, err
+			}
+			if codec != nil {
+				if *b == nil {
+					continue
+				}
+				if *b, err = decodeField(codec, fi, f, *b); err != nil {
+					return item, err
+				}
+			}
+
 			switch opt {
 			case "stringarray":
 				v := stringArray{}
@@ -223,7 +380,15 @@ func fetchResult(itemT reflect.Type, rows *sql.Rows, columns []string) (reflect.
 					return item, err
 				}
 				f.Set(reflect.ValueOf(v))
-			case "jsonb":
+			case "interval":
+				if err := scanDuration(fi, f, *b); err != nil {
+					return item, err
+				}
+			case "enum":
+				if err := scanEnum(fi, fieldEnum(fi), f, *b); err != nil {
+					return item, err
+				}
+			case "jsonb", "json":
 				if len(*b) == 0 {
 					continue
 				}
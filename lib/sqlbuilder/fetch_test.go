@@ -0,0 +1,375 @@
+package sqlbuilder
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+)
+
+// fakeRows serves the given rows of (id, name) values, or only the given
+// columns of them.
+type fakeRows struct {
+	rows    [][]interface{}
+	columns []string
+	i       int
+	closed  bool
+}
+
+func (r *fakeRows) Next() bool {
+	if r.i >= len(r.rows) {
+		return false
+	}
+	r.i++
+	return true
+}
+
+func (r *fakeRows) Err() error {
+	return nil
+}
+
+func (r *fakeRows) Columns() ([]string, error) {
+	if r.columns != nil {
+		return r.columns, nil
+	}
+	return []string{"id", "name"}, nil
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	row := r.rows[r.i-1]
+	if len(r.columns) == 1 && r.columns[0] == "name" {
+		row = row[1:]
+	}
+	for i := range dest {
+		switch d := dest[i].(type) {
+		case *interface{}:
+			*d = row[i]
+		case *int64:
+			*d = row[i].(int64)
+		case *string:
+			*d = row[i].(string)
+		case *[]byte:
+			if row[i] == nil {
+				*d = nil
+				continue
+			}
+			*d = []byte(row[i].(string))
+		default:
+			return errors.New("unexpected destination")
+		}
+	}
+	return nil
+}
+
+func (r *fakeRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+func newFakeRows(n int) *fakeRows {
+	rows := &fakeRows{}
+	for i := 0; i < n; i++ {
+		rows.rows = append(rows.rows, []interface{}{int64(i), "name"})
+	}
+	return rows
+}
+
+func TestIteratorChunk(t *testing.T) {
+	type item struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	{
+		rows := newFakeRows(7)
+		iter := &iterator{cursor: rows}
+
+		var items []item
+		sizes := []int{}
+		err := iter.Chunk(3, &items, func() error {
+			sizes = append(sizes, len(items))
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{3, 3, 1}, sizes)
+		assert.Equal(t, int64(6), items[0].ID)
+		assert.True(t, rows.closed)
+	}
+
+	{
+		iter := &iterator{cursor: newFakeRows(7)}
+
+		var items []map[string]interface{}
+		calls := 0
+		errStop := errors.New("stop")
+		err := iter.Chunk(2, &items, func() error {
+			calls++
+			return errStop
+		})
+		assert.Equal(t, errStop, err)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, 2, len(items))
+	}
+
+	{
+		iter := &iterator{cursor: newFakeRows(1)}
+		var items []item
+		assert.Equal(t, ErrInvalidChunkSize, iter.Chunk(0, &items, func() error { return nil }))
+	}
+
+	{
+		iter := &iterator{cursor: newFakeRows(1)}
+		var item item
+		assert.Equal(t, ErrExpectingSlicePointer, iter.Chunk(1, &item, func() error { return nil }))
+	}
+}
+
+func TestFetchScalars(t *testing.T) {
+	{
+		rows := newFakeRows(3)
+		rows.columns = []string{"id"}
+
+		var ids []int64
+		assert.NoError(t, fetchRows(mapper, nil, rows, &ids))
+		assert.Equal(t, []int64{0, 1, 2}, ids)
+	}
+
+	{
+		rows := newFakeRows(2)
+		rows.columns = []string{"name"}
+
+		var names []*string
+		assert.NoError(t, fetchRows(mapper, nil, rows, &names))
+		if assert.Equal(t, 2, len(names)) {
+			assert.Equal(t, "name", *names[1])
+		}
+	}
+
+	{
+		rows := newFakeRows(2)
+		rows.columns = []string{"id"}
+
+		var id int64
+		assert.NoError(t, fetchRow(mapper, nil, rows, &id))
+		assert.Equal(t, int64(0), id)
+	}
+
+	{
+		var items []map[string]interface{}
+		assert.NoError(t, fetchRows(mapper, nil, newFakeRows(2), &items))
+		assert.Equal(t, []map[string]interface{}{
+			{"id": int64(0), "name": "name"},
+			{"id": int64(1), "name": "name"},
+		}, items)
+	}
+
+	{
+		var item map[string]interface{}
+		assert.NoError(t, fetchRow(mapper, nil, newFakeRows(2), &item))
+		assert.Equal(t, map[string]interface{}{"id": int64(0), "name": "name"}, item)
+	}
+
+	{
+		var ids []int64
+		assert.Equal(t, ErrExpectingSingleColumn, fetchRows(mapper, nil, newFakeRows(2), &ids))
+	}
+}
+
+func TestFetchPrefixedStruct(t *testing.T) {
+	type author struct {
+		Name string `db:"name"`
+	}
+	type book struct {
+		ID     int64   `db:"id"`
+		Author author  `db:"author,prefix=author_"`
+		Editor *author `db:"editor,prefix=editor_"`
+	}
+
+	{
+		rows := newFakeRows(2)
+		rows.columns = []string{"id", "author_name"}
+
+		var books []book
+		assert.NoError(t, fetchRows(mapper, nil, rows, &books))
+		assert.Equal(t, []book{
+			{ID: 0, Author: author{Name: "name"}},
+			{ID: 1, Author: author{Name: "name"}},
+		}, books)
+	}
+
+	{
+		rows := newFakeRows(1)
+		rows.columns = []string{"id", "editor_name"}
+
+		var b book
+		assert.NoError(t, fetchRow(mapper, nil, rows, &b))
+		if assert.NotNil(t, b.Editor) {
+			assert.Equal(t, "name", b.Editor.Name)
+		}
+	}
+}
+
+func TestFetchJSON(t *testing.T) {
+	type item struct {
+		ID   int64                  `db:"id"`
+		Data map[string]interface{} `db:"name,json"`
+	}
+
+	rows := &fakeRows{rows: [][]interface{}{{int64(1), `{"status":"active"}`}}}
+
+	var i item
+	assert.NoError(t, fetchRow(mapper, nil, rows, &i))
+	assert.Equal(t, item{ID: 1, Data: map[string]interface{}{"status": "active"}}, i)
+
+	_, values, err := Map(i, nil)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(values)) {
+		v, err := values[1].(driver.Valuer).Value()
+		assert.NoError(t, err)
+		assert.Equal(t, `{"status":"active"}`, v)
+	}
+}
+
+func TestMapJSONOmitEmpty(t *testing.T) {
+	type settings struct {
+		Theme string `json:"theme"`
+	}
+	type item struct {
+		ID       int64    `db:"id"`
+		Settings settings `db:"settings,json,omitempty"`
+		Tags     []string `db:"tags,json,omitempty"`
+	}
+
+	columns, _, err := Map(item{ID: 1}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id"}, columns)
+
+	columns, values, err := Map(item{ID: 1, Settings: settings{Theme: "dark"}, Tags: []string{"a"}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "settings", "tags"}, columns)
+	if assert.Equal(t, 3, len(values)) {
+		v, err := values[1].(driver.Valuer).Value()
+		assert.NoError(t, err)
+		assert.Equal(t, `{"theme":"dark"}`, v)
+	}
+
+	b := &sqlBuilder{t: newTemplateWithUtils(&testTemplate)}
+	q := b.InsertInto("items").Columns("settings").Values(values[1])
+	assert.Equal(t, `INSERT INTO "items" ("settings") VALUES ($1)`, q.String())
+	assert.Equal(t, []interface{}{values[1]}, q.Arguments())
+}
+
+func TestFetchInterval(t *testing.T) {
+	type job struct {
+		ID      int64          `db:"id"`
+		Timeout time.Duration  `db:"name,interval"`
+		Retry   *time.Duration `db:"retry,interval"`
+	}
+
+	rows := &fakeRows{
+		rows:    [][]interface{}{{int64(1), "1 day 01:30:00", "00:00:05"}, {int64(2), "-00:00:00.5", nil}},
+		columns: []string{"id", "name", "retry"},
+	}
+
+	var jobs []job
+	assert.NoError(t, fetchRows(mapper, nil, rows, &jobs))
+	retry := 5 * time.Second
+	assert.Equal(t, []job{
+		{ID: 1, Timeout: 25*time.Hour + 30*time.Minute, Retry: &retry},
+		{ID: 2, Timeout: -500 * time.Millisecond},
+	}, jobs)
+
+	columns, values, err := Map(jobs[0], nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "retry"}, columns)
+	if assert.Equal(t, 3, len(values)) {
+		assert.Equal(t, db.Interval{Duration: 25*time.Hour + 30*time.Minute}, values[1])
+		assert.Equal(t, db.Interval{Duration: retry}, values[2])
+	}
+
+	{
+		rows := &fakeRows{rows: [][]interface{}{{int64(1), "1 mon"}}}
+		var j job
+		assert.Error(t, fetchRow(mapper, nil, rows, &j))
+	}
+
+	{
+		type invalid struct {
+			Timeout int64 `db:"name,interval"`
+		}
+		_, _, err := Map(invalid{Timeout: 1}, nil)
+		assert.Error(t, err)
+
+		rows := &fakeRows{rows: [][]interface{}{{int64(1), "01:00:00"}}, columns: []string{"name"}}
+		var i invalid
+		assert.Error(t, fetchRow(mapper, nil, rows, &i))
+	}
+}
+
+func TestIteratorEncodeJSON(t *testing.T) {
+	{
+		rows := newFakeRows(2)
+		rows.rows[1][1] = []byte("Ozzie")
+		iter := &iterator{cursor: rows}
+
+		var buf bytes.Buffer
+		assert.NoError(t, iter.EncodeJSON(&buf))
+		assert.Equal(t, `[{"id":0,"name":"name"},{"id":1,"name":"Ozzie"}]`, buf.String())
+		assert.True(t, rows.closed)
+	}
+
+	{
+		iter := &iterator{cursor: newFakeRows(0)}
+
+		var buf bytes.Buffer
+		assert.NoError(t, iter.EncodeJSON(&buf))
+		assert.Equal(t, `[]`, buf.String())
+	}
+
+	{
+		iter := newIterator(nil, errors.New("failed"))
+		assert.Error(t, iter.EncodeJSON(&bytes.Buffer{}))
+	}
+}
+
+func TestIteratorWriteCSV(t *testing.T) {
+	{
+		rows := newFakeRows(2)
+		rows.rows[1][1] = nil
+		iter := &iterator{cursor: rows}
+
+		var buf bytes.Buffer
+		assert.NoError(t, iter.WriteCSV(&buf, nil))
+		assert.Equal(t, "0,name\n1,\n", buf.String())
+		assert.True(t, rows.closed)
+	}
+
+	{
+		rows := newFakeRows(2)
+		rows.rows[0][1] = "Joe, Jr."
+		rows.rows[1][1] = nil
+		iter := &iterator{cursor: rows}
+
+		var buf bytes.Buffer
+		assert.NoError(t, iter.WriteCSV(&buf, &CSVOptions{Header: true, Delimiter: '\t', Null: `\N`}))
+		assert.Equal(t, "id\tname\n0\tJoe, Jr.\n1\t\\N\n", buf.String())
+	}
+}
+
+func TestCSVOptionsFormatField(t *testing.T) {
+	opts := &CSVOptions{Null: "NULL", TimeFormat: "2006-01-02"}
+
+	assert.Equal(t, "NULL", opts.FormatField(nil))
+	assert.Equal(t, "text", opts.FormatField([]byte("text")))
+	assert.Equal(t, "true", opts.FormatField(true))
+	assert.Equal(t, "-12", opts.FormatField(int64(-12)))
+	assert.Equal(t, "1.5", opts.FormatField(1.5))
+	assert.Equal(t, "2020-01-02", opts.FormatField(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+	opts = &CSVOptions{}
+	assert.Equal(t, "2020-01-02T03:04:05Z", opts.FormatField(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+}
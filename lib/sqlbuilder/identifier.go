@@ -0,0 +1,242 @@
+package sqlbuilder
+
+import (
+	"reflect"
+	"strings"
+
+	"upper.io/db.v3"
+)
+
+// hasStrictIdentifiers is implemented by sessions that can reject table and
+// column names that are not plain identifiers, see
+// db.Settings.SetStrictIdentifiers.
+type hasStrictIdentifiers interface {
+	StrictIdentifiersEnabled() bool
+}
+
+func (b *sqlBuilder) strictIdentifiers() bool {
+	if sess, ok := b.sess.(hasStrictIdentifiers); ok {
+		return sess.StrictIdentifiersEnabled()
+	}
+	return false
+}
+
+// Quote returns the given identifier, like "name" or "schema.table", quoted
+// for the database of the session. Quotes within the identifier are escaped.
+func (b *sqlBuilder) Quote(identifier string) string {
+	return b.t.QuoteIdentifier(strings.Split(identifier, ".")...)
+}
+
+// checkIdentifiers returns ErrInvalidIdentifier if strict identifiers are
+// enabled and any of the given table or column names is a string that is not
+// a plain identifier. Other values, like db.Ident or db.Raw, are not checked.
+func (b *sqlBuilder) checkIdentifiers(names ...interface{}) error {
+	if !b.strictIdentifiers() {
+		return nil
+	}
+	for i := range names {
+		if name, ok := names[i].(string); ok && !plainIdentifier(name, true) {
+			return ErrInvalidIdentifier
+		}
+	}
+	return nil
+}
+
+// checkColumns is like checkIdentifiers for names that can't have aliases,
+// like the columns of INSERT statements.
+func (b *sqlBuilder) checkColumns(columns ...string) error {
+	if !b.strictIdentifiers() {
+		return nil
+	}
+	for i := range columns {
+		if !plainIdentifier(columns[i], false) {
+			return ErrInvalidIdentifier
+		}
+	}
+	return nil
+}
+
+// checkSortColumns is like checkIdentifiers for sort columns, like "-name" or
+// "name DESC".
+func (b *sqlBuilder) checkSortColumns(columns ...interface{}) error {
+	if !b.strictIdentifiers() {
+		return nil
+	}
+	for i := range columns {
+		column, ok := columns[i].(string)
+		if !ok {
+			continue
+		}
+		column = strings.TrimPrefix(column, "-")
+		if chunks := strings.SplitN(column, " ", 2); len(chunks) > 1 {
+			switch strings.ToUpper(strings.TrimSpace(chunks[1])) {
+			case "ASC", "DESC":
+				column = chunks[0]
+			}
+		}
+		if !plainIdentifier(column, false) {
+			return ErrInvalidIdentifier
+		}
+	}
+	return nil
+}
+
+// checkConditions is like checkIdentifiers for the keys of conditions, like
+// the ones of db.Cond, which are made of a column name and an optional
+// operator. Conditions given as SQL strings, like "id = ?", are not checked.
+func (b *sqlBuilder) checkConditions(terms ...interface{}) error {
+	if !b.strictIdentifiers() {
+		return nil
+	}
+	return checkConditions([]interface{}{terms})
+}
+
+func checkConditions(terms []interface{}) error {
+	for i := range terms {
+		switch t := terms[i].(type) {
+		case []interface{}:
+			if len(t) > 0 {
+				// Like in toWhereWithArguments, a leading string is either a
+				// SQL condition followed by its arguments or a condition key
+				// followed by its value.
+				if key, ok := t[0].(string); ok {
+					if len(t) > 1 && !strings.Contains(key, "?") && !plainConditionKey(key) {
+						return ErrInvalidIdentifier
+					}
+					continue
+				}
+			}
+			if err := checkConditions(t); err != nil {
+				return err
+			}
+		case db.RawValue:
+			continue
+		case db.Constraints:
+			for _, c := range t.Constraints() {
+				if err := checkConditions([]interface{}{c}); err != nil {
+					return err
+				}
+			}
+		case db.Compound:
+			for _, s := range t.Sentences() {
+				if err := checkConditions([]interface{}{s}); err != nil {
+					return err
+				}
+			}
+		case db.Constraint:
+			if !plainConditionKey(t.Key()) {
+				return ErrInvalidIdentifier
+			}
+		}
+	}
+	return nil
+}
+
+// checkValues is like checkIdentifiers for the keys of maps given as values
+// of INSERT statements or of SET clauses.
+func (b *sqlBuilder) checkValues(values ...interface{}) error {
+	if !b.strictIdentifiers() {
+		return nil
+	}
+	for i := range values {
+		v := reflect.ValueOf(values[i])
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+			continue
+		}
+		for _, k := range v.MapKeys() {
+			if !plainIdentifier(k.String(), false) {
+				return ErrInvalidIdentifier
+			}
+		}
+	}
+	return nil
+}
+
+func plainConditionKey(key interface{}) bool {
+	switch k := key.(type) {
+	case string:
+		if columns, _, ok := splitTupleColumns(k); ok {
+			for i := range columns {
+				if !plainIdentifier(columns[i], false) {
+					return false
+				}
+			}
+			return true
+		}
+		column, operator := splitColumnOperator(k)
+		return plainIdentifier(column, false) && plainOperator(operator)
+	case db.Comparison:
+		return plainIdentifier(k.Column, false) && plainOperator(k.Operator)
+	}
+	return true
+}
+
+// plainIdentifier reports whether name is made of identifiers joined by dots,
+// like "name" or "schema.table", the last one can be "*". If aliases are
+// allowed the name can be followed by an alias, like "name AS n".
+func plainIdentifier(name string, aliases bool) bool {
+	name = strings.TrimSpace(name)
+	if aliases {
+		fields := strings.Fields(name)
+		switch {
+		case len(fields) == 3 && strings.ToUpper(fields[1]) == "AS":
+			if !plainIdentifier(fields[2], false) {
+				return false
+			}
+			name = fields[0]
+		case len(fields) == 2:
+			if !plainIdentifier(fields[1], false) {
+				return false
+			}
+			name = fields[0]
+		}
+	}
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part == "*" && i == len(parts)-1 {
+			continue
+		}
+		if !plainName(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func plainName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case (r >= '0' && r <= '9') || r == '$':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// plainOperator reports whether operator is made of comparison symbols or
+// SQL keywords, like ">=" or "NOT LIKE".
+func plainOperator(operator string) bool {
+	for _, token := range strings.Fields(operator) {
+		switch strings.ToUpper(token) {
+		case "NOT", "LIKE", "ILIKE", "IN", "IS", "BETWEEN", "REGEXP", "RLIKE", "GLOB", "MATCH", "SIMILAR", "TO", "DISTINCT", "FROM":
+			continue
+		}
+		for _, r := range token {
+			if !strings.ContainsRune("=<>!~*@&|^", r) {
+				return false
+			}
+		}
+	}
+	return true
+}
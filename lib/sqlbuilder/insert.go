@@ -3,9 +3,14 @@ package sqlbuilder
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
 
+	"upper.io/db.v3"
 	"upper.io/db.v3/internal/immutable"
 	"upper.io/db.v3/internal/sqladapter/exql"
+	"upper.io/db.v3/lib/reflectx"
 )
 
 type inserterQuery struct {
@@ -15,18 +20,51 @@ type inserterQuery struct {
 	columns        []exql.Fragment
 	values         []*exql.Values
 	arguments      []interface{}
+	with           *exql.With
+	withArgs       []interface{}
+	onConflict     *onConflictClause
 	extra          string
+	timeout        time.Duration
 	amendFn        func(string) string
 }
 
-func (iq *inserterQuery) processValues() (values []*exql.Values, arguments []interface{}) {
-	var mapOptions *MapOptions
+type onConflictClause struct {
+	columns          []exql.Fragment
+	columnValues     *exql.ColumnValues
+	columnValuesArgs []interface{}
+	where            *exql.Where
+	whereArgs        []interface{}
+	updateInserted   bool
+}
+
+func (oc *onConflictClause) fragment() *exql.OnConflict {
+	f := &exql.OnConflict{}
+	if len(oc.columns) > 0 {
+		f.Columns = exql.JoinColumns(oc.columns...)
+	}
+	if oc.columnValues != nil {
+		f.ColumnValues = oc.columnValues
+	}
+	if oc.where != nil {
+		f.Where = oc.where
+	}
+	return f
+}
+
+func (oc *onConflictClause) arguments() []interface{} {
+	return joinArguments(oc.columnValuesArgs, oc.whereArgs)
+}
+
+func (iq *inserterQuery) processValues(m *reflectx.Mapper, codec func(string) db.Codec) (values []*exql.Values, arguments []interface{}) {
+	mapOptions := &MapOptions{Mapper: m, Codec: codec}
 	if len(iq.enqueuedValues) > 1 {
-		mapOptions = &MapOptions{IncludeZeroed: true, IncludeNil: true}
+		mapOptions.IncludeZeroed, mapOptions.IncludeNil = true, true
 	}
 
 	for _, enqueuedValue := range iq.enqueuedValues {
-		if len(enqueuedValue) == 1 {
+		// A single value is mapped as an item, unless it's a value that's sent
+		// as is, like the JSON encoded fields of items.
+		if len(enqueuedValue) == 1 && !isValuer(enqueuedValue[0]) {
 			ff, vv, err := Map(enqueuedValue[0], mapOptions)
 			if err == nil {
 				columns, vals, args, _ := toColumnsValuesAndArguments(ff, vv)
@@ -57,6 +95,11 @@ func (iq *inserterQuery) processValues() (values []*exql.Values, arguments []int
 	return
 }
 
+func isValuer(v interface{}) bool {
+	_, ok := v.(driver.Valuer)
+	return ok
+}
+
 func (iq *inserterQuery) statement() *exql.Statement {
 	stmt := &exql.Statement{
 		Type:  exql.Insert,
@@ -75,6 +118,14 @@ func (iq *inserterQuery) statement() *exql.Statement {
 		stmt.Returning = exql.ReturningColumns(iq.returning...)
 	}
 
+	if iq.with != nil {
+		stmt.With = iq.with
+	}
+
+	if iq.onConflict != nil {
+		stmt.OnConflict = iq.onConflict.fragment()
+	}
+
 	stmt.SetAmendment(iq.amendFn)
 
 	return stmt
@@ -133,11 +184,92 @@ func (ins *inserter) Arguments() []interface{} {
 
 func (ins *inserter) Returning(columns ...string) Inserter {
 	return ins.frame(func(iq *inserterQuery) error {
+		if err := ins.SQLBuilder().checkColumns(columns...); err != nil {
+			return err
+		}
 		columnsToFragments(&iq.returning, columns)
 		return nil
 	})
 }
 
+func (ins *inserter) Output(columns ...string) Inserter {
+	return ins.Returning(columns...)
+}
+
+func (ins *inserter) OnConflict(columns ...string) Inserter {
+	return ins.frame(func(iq *inserterQuery) error {
+		iq.onConflict = &onConflictClause{}
+		columnsToFragments(&iq.onConflict.columns, columns)
+		return nil
+	})
+}
+
+func (ins *inserter) DoNothing() Inserter {
+	return ins.frame(func(iq *inserterQuery) error {
+		if iq.onConflict == nil {
+			return errors.New("Cannot use DoNothing() without a preceding OnConflict() expression.")
+		}
+		iq.onConflict.columnValues, iq.onConflict.columnValuesArgs = nil, nil
+		iq.onConflict.where, iq.onConflict.whereArgs = nil, nil
+		return nil
+	})
+}
+
+func (ins *inserter) DoUpdate(terms ...interface{}) Inserter {
+	return ins.frame(func(iq *inserterQuery) error {
+		if iq.onConflict == nil {
+			return errors.New("Cannot use DoUpdate() without a preceding OnConflict() expression.")
+		}
+		if iq.onConflict.columnValues == nil {
+			iq.onConflict.columnValues = &exql.ColumnValues{}
+		}
+
+		cvs, args, err := ins.SQLBuilder().setColumnValues(terms)
+		if err != nil {
+			return err
+		}
+		iq.onConflict.columnValues.Insert(cvs...)
+		iq.onConflict.columnValuesArgs = append(iq.onConflict.columnValuesArgs, args...)
+		return nil
+	})
+}
+
+func (ins *inserter) DoUpdateWhere(terms ...interface{}) Inserter {
+	return ins.frame(func(iq *inserterQuery) error {
+		if iq.onConflict == nil || iq.onConflict.columnValues == nil {
+			return errors.New("Cannot use DoUpdateWhere() without a preceding DoUpdate() expression.")
+		}
+
+		where, whereArgs, err := ins.SQLBuilder().t.toWhere(terms)
+		if err != nil {
+			return err
+		}
+		if iq.onConflict.where == nil {
+			iq.onConflict.where, iq.onConflict.whereArgs = &exql.Where{}, []interface{}{}
+		}
+		iq.onConflict.where.Append(&where)
+		iq.onConflict.whereArgs = append(iq.onConflict.whereArgs, whereArgs...)
+		return nil
+	})
+}
+
+func (ins *inserter) OnDuplicateKeyUpdate(values map[string]interface{}) Inserter {
+	if len(values) > 0 {
+		return ins.OnConflict().DoUpdate(values)
+	}
+	return ins.frame(func(iq *inserterQuery) error {
+		iq.onConflict = &onConflictClause{updateInserted: true}
+		return nil
+	})
+}
+
+func (ins *inserter) Timeout(timeout time.Duration) Inserter {
+	return ins.frame(func(iq *inserterQuery) error {
+		iq.timeout = timeout
+		return nil
+	})
+}
+
 func (ins *inserter) Exec() (sql.Result, error) {
 	return ins.ExecContext(ins.SQLBuilder().sess.Context())
 }
@@ -147,6 +279,8 @@ func (ins *inserter) ExecContext(ctx context.Context) (sql.Result, error) {
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := withTimeout(ctx, iq.timeout)
+	defer cancel()
 	return ins.SQLBuilder().sess.StatementExec(ctx, iq.statement(), iq.arguments...)
 }
 
@@ -171,6 +305,7 @@ func (ins *inserter) QueryContext(ctx context.Context) (*sql.Rows, error) {
 	if err != nil {
 		return nil, err
 	}
+	ctx = withQueryTimeout(ctx, iq.timeout)
 	return ins.SQLBuilder().sess.StatementQuery(ctx, iq.statement(), iq.arguments...)
 }
 
@@ -183,6 +318,7 @@ func (ins *inserter) QueryRowContext(ctx context.Context) (*sql.Row, error) {
 	if err != nil {
 		return nil, err
 	}
+	ctx = withQueryTimeout(ctx, iq.timeout)
 	return ins.SQLBuilder().sess.StatementQueryRow(ctx, iq.statement(), iq.arguments...)
 }
 
@@ -192,11 +328,14 @@ func (ins *inserter) Iterator() Iterator {
 
 func (ins *inserter) IteratorContext(ctx context.Context) Iterator {
 	rows, err := ins.QueryContext(ctx)
-	return &iterator{rows, err}
+	return ins.SQLBuilder().newIterator(rows, err)
 }
 
 func (ins *inserter) Into(table string) Inserter {
 	return ins.frame(func(iq *inserterQuery) error {
+		if err := ins.SQLBuilder().checkColumns(table); err != nil {
+			return err
+		}
 		iq.table = table
 		return nil
 	})
@@ -204,6 +343,9 @@ func (ins *inserter) Into(table string) Inserter {
 
 func (ins *inserter) Columns(columns ...string) Inserter {
 	return ins.frame(func(iq *inserterQuery) error {
+		if err := ins.SQLBuilder().checkColumns(columns...); err != nil {
+			return err
+		}
 		columnsToFragments(&iq.columns, columns)
 		return nil
 	})
@@ -211,6 +353,9 @@ func (ins *inserter) Columns(columns ...string) Inserter {
 
 func (ins *inserter) Values(values ...interface{}) Inserter {
 	return ins.frame(func(iq *inserterQuery) error {
+		if err := ins.SQLBuilder().checkValues(values...); err != nil {
+			return err
+		}
 		iq.enqueuedValues = append(iq.enqueuedValues, values)
 		return nil
 	})
@@ -230,7 +375,27 @@ func (ins *inserter) build() (*inserterQuery, error) {
 		return nil, err
 	}
 	ret := iq.(*inserterQuery)
-	ret.values, ret.arguments = ret.processValues()
+	if ret.with, ret.withArgs, err = ins.SQLBuilder().withClause(); err != nil {
+		return nil, err
+	}
+	ret.values, ret.arguments = ret.processValues(ins.SQLBuilder().structMapper(), ins.SQLBuilder().codec)
+	ret.arguments = joinArguments(ret.withArgs, ret.arguments)
+	if ret.onConflict != nil && ret.onConflict.updateInserted {
+		if len(ret.columns) == 0 {
+			return nil, errors.New("OnDuplicateKeyUpdate() with no values requires the inserted columns to be known.")
+		}
+		ret.onConflict.columnValues = &exql.ColumnValues{}
+		for _, column := range ret.columns {
+			ret.onConflict.columnValues.Insert(&exql.ColumnValue{
+				Column:   column,
+				Operator: "=",
+				Value:    &exql.Excluded{Column: column},
+			})
+		}
+	}
+	if ret.onConflict != nil {
+		ret.arguments = joinArguments(ret.arguments, ret.onConflict.arguments())
+	}
 	return ret, nil
 }
 
@@ -260,6 +425,16 @@ func (ins *inserter) Base() interface{} {
 	return &inserterQuery{}
 }
 
+// Excluded represents the value that was proposed for insertion on the
+// conflicting row, it can be used within DoUpdate() to refer to it, e.g.:
+//
+//   i.OnConflict("id").DoUpdate(map[string]interface{}{
+//     "name": sqlbuilder.Excluded("name"),
+//   })
+func Excluded(column string) *exql.Excluded {
+	return exql.ExcludedColumn(column)
+}
+
 func columnsToFragments(dst *[]exql.Fragment, columns []string) error {
 	l := len(columns)
 	f := make([]exql.Fragment, l)
@@ -25,6 +25,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"io"
+	"time"
 )
 
 // SQLBuilder defines methods that can be used to build a SQL query with
@@ -82,6 +84,65 @@ type SQLBuilder interface {
 	//  q := sqlbuilder.Update("profile").Set(...).Where(...)
 	Update(table string) Updater
 
+	// MergeInto prepares and returns a Merger targeted at the given table.
+	//
+	// Example:
+	//
+	//  q := sqlbuilder.MergeInto("inventory").Using(...).On(...).WhenMatchedUpdate(...)
+	MergeInto(table string) Merger
+
+	// CreateTable prepares and returns a TableCreator for the given table.
+	//
+	// Example:
+	//
+	//  q := sqlbuilder.CreateTable("books").FromStruct(Book{})
+	CreateTable(table string) TableCreator
+
+	// CreateIndex prepares and returns an IndexCreator for an index with the
+	// given name.
+	//
+	// Example:
+	//
+	//  q := sqlbuilder.CreateIndex("books_title_idx").On("books", "title")
+	CreateIndex(name string) IndexCreator
+
+	// AlterTable prepares and returns a TableAlterer for the given table.
+	//
+	// Example:
+	//
+	//  q := sqlbuilder.AlterTable("books").AddColumn(sqlbuilder.Column{...})
+	AlterTable(table string) TableAlterer
+
+	// Batch prepares the given query once and executes it with every set of
+	// arguments added to the batch, reporting the result of each one of them.
+	//
+	// Example:
+	//
+	//  b := sqlbuilder.Batch("UPDATE people SET name = ? WHERE id = ?")
+	//  b.Add("Mateo", 1)
+	//  b.Add("Haruki", 2)
+	//  results, err := b.Exec()
+	Batch(query interface{}) *Batch
+
+	// With attaches a common table expression to the builder and returns a new
+	// SQLBuilder, statements created from the returned builder are prefixed
+	// with a WITH clause that defines the given name as the given Selector.
+	// With can be chained to define more than one common table expression.
+	//
+	// Example:
+	//
+	//  q := sess.With("recent", sess.SelectFrom("posts").Where("created_at > ?", t)).
+	//  	SelectFrom("recent")
+	With(name string, sel Selector) SQLBuilder
+
+	// WithRecursive is like With but it produces a WITH RECURSIVE clause. The
+	// name may define the column names of the expression, like "t(n)".
+	//
+	// Example:
+	//
+	//  q := sess.WithRecursive("t(n)", sel).SelectFrom("t")
+	WithRecursive(name string, sel Selector) SQLBuilder
+
 	// Exec executes a SQL query that does not return any rows, like sql.Exec.
 	// Queries can be either strings or upper-db statements.
 	//
@@ -114,6 +175,12 @@ type SQLBuilder interface {
 	// Example:
 	//
 	//  sqlbuilder.Query(`SELECT * FROM people WHERE name = "Mateo"`)
+	//
+	// Named parameters can be bound from a single map or struct argument,
+	// struct fields are matched the way the session maps them. The same
+	// applies to Exec, QueryRow and Iterator (see db.BindNamed):
+	//
+	//  sqlbuilder.Query(`SELECT * FROM people WHERE name = :name`, map[string]interface{}{"name": "Mateo"})
 	Query(query interface{}, args ...interface{}) (*sql.Rows, error)
 
 	// QueryContext executes a SQL query that returns rows, like
@@ -155,6 +222,15 @@ type SQLBuilder interface {
 	//
 	//  sqlbuilder.IteratorContext(ctx, `SELECT * FROM people WHERE name LIKE "M%"`)
 	IteratorContext(ctx context.Context, query interface{}, args ...interface{}) Iterator
+
+	// Quote returns the given table or column name quoted for the database,
+	// dots separate its parts and quotes within them are escaped, so it can be
+	// used to build raw SQL with names that come from user input.
+	//
+	// Example:
+	//
+	//  sqlbuilder.Quote("public.people") // "public"."people" on PostgreSQL
+	Quote(identifier string) string
 }
 
 // Selector represents a SELECT statement.
@@ -207,6 +283,18 @@ type Selector interface {
 	// different.
 	Distinct(columns ...interface{}) Selector
 
+	// DistinctOn represents a DISTINCT ON (...) clause, which keeps only the
+	// first row of each set of rows where the given expressions are equal.
+	//
+	// DISTINCT ON is only supported by PostgreSQL, compiling a query that uses
+	// DistinctOn on other adapters returns an error.
+	//
+	//   s.DistinctOn("author_id").
+	//     Columns("author_id", "title").
+	//     From("books").
+	//     OrderBy("author_id", "-published_at")
+	DistinctOn(columns ...interface{}) Selector
+
 	// As defines an alias for a table.
 	As(string) Selector
 
@@ -254,6 +342,11 @@ type Selector interface {
 	// GroupBy accepts more than one column:
 	//
 	//   s.GroupBy("country_id", "city_id")
+	//
+	// Subtotals can be requested with db.Rollup(), db.Cube() and
+	// db.GroupingSets():
+	//
+	//   s.GroupBy(db.Rollup("country_id", "city_id"))
 	GroupBy(columns ...interface{}) Selector
 
 	// Having(...interface{}) Selector
@@ -279,6 +372,10 @@ type Selector interface {
 	//   s.OrderBy("last_name ASC")
 	//
 	//   s.OrderBy("last_name DESC", "name ASC")
+	//
+	// Full-text search ranks sort the best matches first.
+	//
+	//   s.Where(db.Match("body", q)).OrderBy(db.Rank("body", q))
 	OrderBy(columns ...interface{}) Selector
 
 	// Join represents a JOIN statement.
@@ -339,6 +436,78 @@ type Selector interface {
 	// return results.
 	Offset(int) Selector
 
+	// ForUpdate represents a FOR UPDATE clause, rows returned by the query are
+	// locked as if they were going to be updated, until the end of the current
+	// transaction.
+	//
+	// Row locking is translated into table hints on MSSQL and it's not
+	// supported on SQLite, compiling a query that uses it returns an error.
+	//
+	//   tx.SelectFrom("jobs").Where("status", "pending").Limit(1).
+	//     ForUpdate().SkipLocked()
+	ForUpdate() Selector
+
+	// ForShare represents a FOR SHARE clause, rows returned by the query are
+	// locked against updates by other transactions.
+	ForShare() Selector
+
+	// SkipLocked represents a SKIP LOCKED option, rows that are already locked
+	// by other transactions are skipped. SkipLocked must be preceded by
+	// ForUpdate() or ForShare().
+	SkipLocked() Selector
+
+	// NoWait represents a NOWAIT option, the query fails instead of waiting if
+	// a row is locked by another transaction. NoWait must be preceded by
+	// ForUpdate() or ForShare().
+	NoWait() Selector
+
+	// Union combines the results of this Selector and the given one using
+	// UNION, duplicated rows are discarded.
+	//
+	// The returned Selector represents the whole compound query, further calls
+	// to OrderBy(), Limit() or Offset() apply to the combined result. Selectors
+	// that have their own ORDER BY, LIMIT or OFFSET clauses are wrapped into
+	// a subquery so these clauses are preserved.
+	//
+	//   s.Union(sess.SelectFrom("archived_posts")).OrderBy("-id").Limit(10)
+	//
+	// The WITH clauses of all Selectors are moved to the top of the compound
+	// query, different WITH expressions with the same name are an error.
+	Union(Selector) Selector
+
+	// UnionAll is like Union but uses UNION ALL, duplicated rows are kept.
+	UnionAll(Selector) Selector
+
+	// Intersect is like Union but uses INTERSECT, only the rows that are
+	// returned by both Selectors are kept.
+	Intersect(Selector) Selector
+
+	// Except is like Union but uses EXCEPT, the rows that are returned by the
+	// given Selector are removed from the result.
+	Except(Selector) Selector
+
+	// Timeout limits the time the query can take to run, the query is
+	// executed within a context that expires after the given duration.
+	//
+	// On MySQL the timeout is also sent to the server with a
+	// MAX_EXECUTION_TIME optimizer hint. Other drivers, like lib/pq, ask the
+	// server to cancel the query once the context expires.
+	//
+	//   s.Timeout(2 * time.Second).All(&items)
+	Timeout(time.Duration) Selector
+
+	// Cache keeps the result of the query in the query cache of the session
+	// for the given time, so running the same query with the same arguments
+	// again reads the cached result instead. Cached results are expired once
+	// the session inserts, updates or deletes rows of any table the query
+	// reads from, see db.Settings.SetQueryCache and InvalidateQueryCache.
+	//
+	// Only results read with Iterator, All or One are cached, and never within
+	// transactions.
+	//
+	//   s.Cache(time.Minute).All(&items)
+	Cache(time.Duration) Selector
+
 	// Amend lets you alter the query's text just before sending it to the
 	// database server.
 	Amend(func(queryIn string) (queryOut string)) Selector
@@ -351,6 +520,36 @@ type Selector interface {
 	// the Selector.
 	IteratorContext(ctx context.Context) Iterator
 
+	// Cursor provides an Iterator that reads the results of the Selector in
+	// batches of fetchSize rows, which keeps memory usage bounded when
+	// iterating over very large result sets.
+	//
+	// On PostgreSQL this declares a server-side cursor, which lives within a
+	// transaction: the current one if the Selector belongs to a transaction or
+	// a new one that is closed along with the Iterator. On other databases
+	// rows are streamed by the driver as they're read.
+	//
+	//   iter := s.Cursor(ctx, 1000)
+	//   defer iter.Close()
+	//   for iter.Next(&item) {
+	//     ...
+	//   }
+	Cursor(ctx context.Context, fetchSize int) Iterator
+
+	// Explain returns the plan the database would use to run the query,
+	// without running it. Plans are supported on PostgreSQL, MySQL (8.0.16 or
+	// later) and SQLite, other databases return db.ErrUnsupported.
+	//
+	//   plan, err := s.Explain(ctx)
+	//   ...
+	//   fmt.Print(plan)
+	Explain(ctx context.Context) (*QueryPlan, error)
+
+	// ExplainAnalyze is like Explain, but the query is run and the plan
+	// includes actual timings and row counts. It's supported on PostgreSQL and
+	// MySQL.
+	ExplainAnalyze(ctx context.Context) (*QueryPlan, error)
+
 	// Preparer provides methods for creating prepared statements.
 	Preparer
 
@@ -397,6 +596,55 @@ type Inserter interface {
 	// RETURNING may not be supported by all SQL databases.
 	Returning(columns ...string) Inserter
 
+	// Output represents the OUTPUT clause of MSSQL, it is an alias of
+	// Returning. Columns default to the INSERTED pseudo table.
+	Output(columns ...string) Inserter
+
+	// OnConflict represents an ON CONFLICT clause (ON DUPLICATE KEY UPDATE on
+	// MySQL). The given columns are the conflict target, MySQL ignores them and
+	// uses any unique key instead.
+	//
+	// OnConflict must be followed by DoNothing() or DoUpdate().
+	//
+	//   i.Values(...).OnConflict("id").DoNothing()
+	//
+	// ON CONFLICT may not be supported by all SQL databases.
+	OnConflict(columns ...string) Inserter
+
+	// DoNothing skips the rows that caused a conflict. On MySQL at least one
+	// conflict target column must be given to OnConflict().
+	DoNothing() Inserter
+
+	// DoUpdate updates the rows that caused a conflict, it accepts the same
+	// arguments as Updater.Set(). Use Excluded() to refer to the values that
+	// were proposed for insertion.
+	//
+	//   i.Values(...).OnConflict("id").DoUpdate(map[string]interface{}{
+	//     "name": sqlbuilder.Excluded("name"),
+	//   })
+	DoUpdate(terms ...interface{}) Inserter
+
+	// DoUpdateWhere sets a WHERE clause on the update action of DoUpdate(),
+	// only the rows that match the conditions are updated. Not supported by
+	// MySQL.
+	DoUpdateWhere(conds ...interface{}) Inserter
+
+	// OnDuplicateKeyUpdate is a shortcut for OnConflict().DoUpdate(values)
+	// that follows MySQL's ON DUPLICATE KEY UPDATE syntax, where no conflict
+	// target is needed. If no values are given every inserted column is set to
+	// the value that was proposed for it, which turns a multi-row insert into
+	// an idempotent upsert:
+	//
+	//   i.Columns("id", "name").
+	//     Values(1, "Chavela").
+	//     Values(2, "Lila").
+	//     OnDuplicateKeyUpdate(nil)
+	//   // INSERT INTO `artist` (`id`, `name`) VALUES (?, ?), (?, ?)
+	//   //   ON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `name` = VALUES(`name`)
+	//
+	// Other databases require OnConflict() with a conflict target instead.
+	OnDuplicateKeyUpdate(values map[string]interface{}) Inserter
+
 	// Iterator provides methods to iterate over the results returned by the
 	// Inserter. This is only possible when using Returning().
 	Iterator() Iterator
@@ -405,6 +653,10 @@ type Inserter interface {
 	// the Inserter. This is only possible when using Returning().
 	IteratorContext(ctx context.Context) Iterator
 
+	// Timeout limits the time the statement can take to run, the statement is
+	// executed within a context that expires after the given duration.
+	Timeout(time.Duration) Inserter
+
 	// Amend lets you alter the query's text just before sending it to the
 	// database server.
 	Amend(func(queryIn string) (queryOut string)) Inserter
@@ -440,15 +692,55 @@ type Deleter interface {
 	// conditions that have been already set.
 	And(conds ...interface{}) Deleter
 
+	// Using adds other tables to the DELETE statement, their columns can then
+	// be used within Where:
+	//
+	//   q := sqlbuilder.DeleteFrom("orders").
+	//     Using("customers").
+	//     Where("orders.customer_id = customers.id AND customers.banned")
+	//
+	// On PostgreSQL this renders as DELETE ... USING, MySQL and MSSQL use their
+	// own multi-table DELETE syntax. Not supported on SQLite.
+	Using(tables ...interface{}) Deleter
+
 	// Limit represents the LIMIT clause.
 	//
 	// See Selector.Limit for documentation and usage examples.
 	Limit(int) Deleter
 
+	// Timeout limits the time the statement can take to run, the statement is
+	// executed within a context that expires after the given duration.
+	Timeout(time.Duration) Deleter
+
 	// Amend lets you alter the query's text just before sending it to the
 	// database server.
 	Amend(func(queryIn string) (queryOut string)) Deleter
 
+	// Returning represents a RETURNING clause (OUTPUT on MSSQL).
+	//
+	// RETURNING specifies which columns should be returned from the rows that
+	// were deleted, use Iterator() to read them.
+	//
+	// RETURNING may not be supported by all SQL databases, on MySQL it returns
+	// an error.
+	Returning(columns ...string) Deleter
+
+	// Output represents the OUTPUT clause of MSSQL, it is an alias of
+	// Returning. Columns default to the DELETED pseudo table.
+	Output(columns ...string) Deleter
+
+	// Iterator provides methods to iterate over the results returned by the
+	// Deleter. This is only possible when using Returning().
+	Iterator() Iterator
+
+	// IteratorContext provides methods to iterate over the results returned by
+	// the Deleter. This is only possible when using Returning().
+	IteratorContext(ctx context.Context) Iterator
+
+	// Getter provides methods to return query results from DELETE
+	// statements that support such feature (e.g.: queries with Returning).
+	Getter
+
 	// Preparer provides methods for creating prepared statements.
 	Preparer
 
@@ -477,11 +769,54 @@ type Updater interface {
 	// conditions that have been already set.
 	And(conds ...interface{}) Updater
 
+	// From adds other tables to the UPDATE statement, their columns can then be
+	// used within Set and Where:
+	//
+	//   q := sqlbuilder.Update("products").
+	//     Set("price = prices.amount").
+	//     From("prices").
+	//     Where("products.id = prices.product_id")
+	//
+	// On PostgreSQL, MSSQL and SQLite this renders as UPDATE ... FROM, on MySQL
+	// it uses the multi-table UPDATE syntax. Tables may be given the same way as
+	// in Selector.From, including db.Values lists.
+	From(tables ...interface{}) Updater
+
 	// Limit represents the LIMIT parameter.
 	//
 	// See Selector.Limit for documentation and usage examples.
 	Limit(int) Updater
 
+	// Returning represents a RETURNING clause (OUTPUT on MSSQL).
+	//
+	// RETURNING specifies which columns should be returned from the rows that
+	// were updated, use Iterator() to read them.
+	//
+	// RETURNING may not be supported by all SQL databases, on MySQL it returns
+	// an error.
+	Returning(columns ...string) Updater
+
+	// Output represents the OUTPUT clause of MSSQL, it is an alias of
+	// Returning. Columns default to the INSERTED pseudo table, prefix them with
+	// "DELETED." to get the values the rows had before the update:
+	//
+	//   q := sqlbuilder.Update("accounts").
+	//     Set("balance = balance - ?", 10).
+	//     Output("DELETED.balance", "INSERTED.balance")
+	Output(columns ...string) Updater
+
+	// Iterator provides methods to iterate over the results returned by the
+	// Updater. This is only possible when using Returning().
+	Iterator() Iterator
+
+	// IteratorContext provides methods to iterate over the results returned by
+	// the Updater. This is only possible when using Returning().
+	IteratorContext(ctx context.Context) Iterator
+
+	// Getter provides methods to return query results from UPDATE
+	// statements that support such feature (e.g.: queries with Returning).
+	Getter
+
 	// Preparer provides methods for creating prepared statements.
 	Preparer
 
@@ -495,11 +830,165 @@ type Updater interface {
 	// Arguments returns the arguments that are prepared for this query.
 	Arguments() []interface{}
 
+	// Timeout limits the time the statement can take to run, the statement is
+	// executed within a context that expires after the given duration.
+	Timeout(time.Duration) Updater
+
 	// Amend lets you alter the query's text just before sending it to the
 	// database server.
 	Amend(func(queryIn string) (queryOut string)) Updater
 }
 
+// Merger represents a MERGE statement, it inserts, updates or deletes the rows
+// of a target table depending on whether they match the rows of a source:
+//
+//   q := sqlbuilder.MergeInto("inventory").
+//     Using(db.Values([][]interface{}{{1, 5}}).As("src(id, qty)")).
+//     On("inventory.id = src.id").
+//     WhenMatchedUpdate("qty = inventory.qty + src.qty").
+//     WhenNotMatchedInsert(map[string]interface{}{
+//       "id":  db.Raw("src.id"),
+//       "qty": db.Raw("src.qty"),
+//     })
+//
+// Only MSSQL supports MERGE, the statement takes a HOLDLOCK on the target
+// table so it can be used as an atomic upsert.
+type Merger interface {
+	// Using sets the source of the MERGE statement, it can be a table name, a
+	// db.Values list or a raw expression.
+	Using(source interface{}) Merger
+
+	// On sets the conditions that match the rows of the source with the rows
+	// of the target table, it accepts the same arguments as Selector.Where.
+	On(conds ...interface{}) Merger
+
+	// WhenMatchedUpdate adds a WHEN MATCHED THEN UPDATE action, it accepts the
+	// same arguments as Updater.Set.
+	WhenMatchedUpdate(terms ...interface{}) Merger
+
+	// WhenMatchedDelete adds a WHEN MATCHED THEN DELETE action.
+	WhenMatchedDelete() Merger
+
+	// WhenNotMatchedInsert adds a WHEN NOT MATCHED THEN INSERT action, values
+	// can be a map or a struct, like the ones given to Inserter.Values.
+	WhenNotMatchedInsert(values interface{}) Merger
+
+	// Output represents the OUTPUT clause, use Iterator() to read the returned
+	// rows. Columns default to the INSERTED pseudo table and can be prefixed
+	// with "INSERTED." or "DELETED.".
+	Output(columns ...string) Merger
+
+	// Amend lets you alter the query's text just before sending it to the
+	// database server.
+	Amend(func(queryIn string) (queryOut string)) Merger
+
+	// Arguments returns the arguments that are prepared for this query.
+	Arguments() []interface{}
+
+	// Iterator provides methods to iterate over the results returned by the
+	// Merger. This is only possible when using Output().
+	Iterator() Iterator
+
+	// IteratorContext provides methods to iterate over the results returned by
+	// the Merger. This is only possible when using Output().
+	IteratorContext(ctx context.Context) Iterator
+
+	// Execer provides the Exec method.
+	Execer
+
+	// fmt.Stringer provides `String() string`, you can use `String()` to compile
+	// the `Merger` into a string.
+	fmt.Stringer
+}
+
+// TableCreator represents a CREATE TABLE statement, columns are described
+// with generic types that every adapter translates into the ones of its
+// database:
+//
+//   q := sqlbuilder.CreateTable("books").
+//     Columns(
+//       sqlbuilder.Column{Name: "id", Type: sqlbuilder.BigInt, AutoIncrement: true},
+//       sqlbuilder.Column{Name: "title", Type: sqlbuilder.String, Size: 120, NotNull: true},
+//     ).
+//     PrimaryKey("id")
+type TableCreator interface {
+	// Columns adds the given columns to the table, a column replaces the one
+	// with the same name that was added before.
+	Columns(columns ...Column) TableCreator
+
+	// FromStruct adds the columns of the given struct, which are mapped like
+	// the ones of Inserter.Values. Go types are translated into generic types,
+	// pointers and types like sql.NullString are nullable and fields with the
+	// pk option, like `db:"id,pk,omitempty"`, make the primary key. A single
+	// integer key with the omitempty option is auto incremented. db.Decimal
+	// fields take their precision and scale from the precision and scale
+	// options, like `db:"price,precision=10,scale=2"`, and time.Duration
+	// fields with the interval option, like `db:"timeout,interval"`, are
+	// Interval columns, and fields with the enum option or of a type that
+	// implements db.Enum are Enum columns. Columns can be refined afterwards
+	// with Columns.
+	FromStruct(item interface{}) TableCreator
+
+	// PrimaryKey sets the columns of the primary key.
+	PrimaryKey(columns ...string) TableCreator
+
+	// IfNotExists makes the statement do nothing if the table exists.
+	IfNotExists() TableCreator
+
+	// Amend lets you alter the query's text just before sending it to the
+	// database server.
+	Amend(func(queryIn string) (queryOut string)) TableCreator
+
+	// Execer provides the Exec method.
+	Execer
+
+	// fmt.Stringer provides `String() string`, you can use `String()` to compile
+	// the `TableCreator` into a string.
+	fmt.Stringer
+}
+
+// IndexCreator represents a CREATE INDEX statement.
+type IndexCreator interface {
+	// On sets the table and the columns of the index.
+	On(table string, columns ...string) IndexCreator
+
+	// Unique makes the index a unique one.
+	Unique() IndexCreator
+
+	// Amend lets you alter the query's text just before sending it to the
+	// database server.
+	Amend(func(queryIn string) (queryOut string)) IndexCreator
+
+	// Execer provides the Exec method.
+	Execer
+
+	// fmt.Stringer provides `String() string`, you can use `String()` to compile
+	// the `IndexCreator` into a string.
+	fmt.Stringer
+}
+
+// TableAlterer represents ALTER TABLE statements. Every change is a statement
+// of its own, since not all databases can make several changes at once, Exec
+// runs them in order and stops at the first error.
+type TableAlterer interface {
+	// AddColumn adds the given column to the table.
+	AddColumn(column Column) TableAlterer
+
+	// DropColumn drops the given column.
+	DropColumn(name string) TableAlterer
+
+	// RenameColumn renames the given column.
+	RenameColumn(name string, newName string) TableAlterer
+
+	// Execer provides the Exec method.
+	Execer
+
+	// fmt.Stringer provides `String() string`, you can use `String()` to compile
+	// the `TableAlterer` into a string, statements are separated by
+	// semicolons.
+	fmt.Stringer
+}
+
 // Execer provides methods for executing statements that do not return results.
 type Execer interface {
 	// Exec executes a statement and returns sql.Result.
@@ -537,7 +1026,7 @@ type Getter interface {
 // ResultMapper defined methods for a result mapper.
 type ResultMapper interface {
 	// All dumps all the results into the given slice, All() expects a pointer to
-	// slice of maps or structs.
+	// slice of maps, structs or, for single-column queries, scalars.
 	//
 	// The behaviour of One() extends to each one of the results.
 	All(destSlice interface{}) error
@@ -555,6 +1044,9 @@ type ResultMapper interface {
 	// If dest if a pointer to struct, each one of the fields will be tested for
 	// a `db` tag which defines the column mapping. The value of the result will
 	// be set as the value of the field.
+	//
+	// Any other dest, like a pointer to int64 or string, expects the query to
+	// return a single column which is scanned directly into dest.
 	One(dest interface{}) error
 }
 
@@ -569,9 +1061,43 @@ type Iterator interface {
 	// NextScan advances the iterator and performs Scan.
 	NextScan(dest ...interface{}) error
 
+	// Chunk maps the results into dest, a pointer to a slice of maps or
+	// structs, at most size results at a time and calls fn after every chunk.
+	// Iteration stops if fn returns an error, which is then returned by Chunk.
+	// The iterator is closed when Chunk returns.
+	//
+	//   var people []Person
+	//   err := iter.Chunk(500, &people, func() error {
+	//     return export(people)
+	//   })
+	//
+	// Every chunk is a new slice, so fn may hold on to it.
+	Chunk(size int, dest interface{}, fn func() error) error
+
 	// ScanOne advances the iterator, performs Scan and closes the iterator.
 	ScanOne(dest ...interface{}) error
 
+	// EncodeJSON writes all the remaining results to w as a JSON array of
+	// objects whose keys are the column names (the names used in db struct
+	// tags), without loading them all into memory. The iterator is closed when
+	// EncodeJSON returns.
+	//
+	//   w.Header().Set("Content-Type", "application/json")
+	//   err := sess.SelectFrom("people").Iterator().EncodeJSON(w)
+	EncodeJSON(w io.Writer) error
+
+	// WriteCSV writes all the remaining results to w as CSV records, one per
+	// row, without loading them all into memory. Options may be nil to write
+	// comma separated values without a header. The iterator is closed when
+	// WriteCSV returns.
+	//
+	//   err := sess.SelectFrom("people").Iterator().WriteCSV(w, &sqlbuilder.CSVOptions{
+	//     Header:    true,
+	//     Delimiter: '\t',
+	//     Null:      `\N`,
+	//   })
+	WriteCSV(w io.Writer, opts *CSVOptions) error
+
 	// Next dumps the current element into the given destination, which could be
 	// a pointer to either a map or a struct.
 	Next(dest ...interface{}) bool
@@ -0,0 +1,275 @@
+package sqlbuilder
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"upper.io/db.v3/internal/immutable"
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+type mergerQuery struct {
+	table string
+
+	sources     *exql.Columns
+	sourcesArgs []interface{}
+
+	on     *exql.On
+	onArgs []interface{}
+
+	actions     []*exql.MergeAction
+	actionsArgs []interface{}
+
+	with     *exql.With
+	withArgs []interface{}
+
+	returning []exql.Fragment
+
+	amendFn func(string) string
+}
+
+func (mq *mergerQuery) statement() *exql.Statement {
+	stmt := &exql.Statement{
+		Type:  exql.Merge,
+		Table: exql.TableWithName(mq.table),
+	}
+
+	if mq.sources != nil {
+		stmt.Sources = mq.sources
+	}
+
+	if mq.on != nil {
+		stmt.On = mq.on
+	}
+
+	if len(mq.actions) > 0 {
+		stmt.MergeActions = &exql.MergeActions{Actions: mq.actions}
+	}
+
+	if mq.with != nil {
+		stmt.With = mq.with
+	}
+
+	if len(mq.returning) > 0 {
+		stmt.Returning = exql.ReturningColumns(mq.returning...)
+	}
+
+	stmt.SetAmendment(mq.amendFn)
+
+	return stmt
+}
+
+func (mq *mergerQuery) arguments() []interface{} {
+	return joinArguments(mq.withArgs, mq.sourcesArgs, mq.onArgs, mq.actionsArgs)
+}
+
+type merger struct {
+	builder *sqlBuilder
+
+	fn   func(*mergerQuery) error
+	prev *merger
+}
+
+var _ = immutable.Immutable(&merger{})
+
+func (mrg *merger) SQLBuilder() *sqlBuilder {
+	if mrg.prev == nil {
+		return mrg.builder
+	}
+	return mrg.prev.SQLBuilder()
+}
+
+func (mrg *merger) template() *exql.Template {
+	return mrg.SQLBuilder().t.Template
+}
+
+func (mrg *merger) String() string {
+	s, err := mrg.Compile()
+	if err != nil {
+		panic(err.Error())
+	}
+	return prepareQueryForDisplay(s)
+}
+
+func (mrg *merger) setTable(table string) *merger {
+	return mrg.frame(func(mq *mergerQuery) error {
+		if err := mrg.SQLBuilder().checkColumns(table); err != nil {
+			return err
+		}
+		mq.table = table
+		return nil
+	})
+}
+
+func (mrg *merger) frame(fn func(*mergerQuery) error) *merger {
+	return &merger{prev: mrg, fn: fn}
+}
+
+func (mrg *merger) Using(source interface{}) Merger {
+	return mrg.frame(func(mq *mergerQuery) error {
+		fragments, args, err := columnFragments([]interface{}{source})
+		if err != nil {
+			return err
+		}
+		mq.sources = exql.JoinColumns(fragments...)
+		mq.sourcesArgs = args
+		return nil
+	})
+}
+
+func (mrg *merger) On(conds ...interface{}) Merger {
+	return mrg.frame(func(mq *mergerQuery) error {
+		w, a, err := mrg.SQLBuilder().t.toWhere(conds)
+		if err != nil {
+			return err
+		}
+		mq.on = exql.OnConditions(w.Conditions...)
+		mq.onArgs = a
+		return nil
+	})
+}
+
+func (mrg *merger) WhenMatchedUpdate(terms ...interface{}) Merger {
+	return mrg.frame(func(mq *mergerQuery) error {
+		cvs, args, err := mrg.SQLBuilder().setColumnValues(terms)
+		if err != nil {
+			return err
+		}
+		mq.actions = append(mq.actions, &exql.MergeAction{
+			Matched:      true,
+			ColumnValues: &exql.ColumnValues{ColumnValues: cvs},
+		})
+		mq.actionsArgs = append(mq.actionsArgs, args...)
+		return nil
+	})
+}
+
+func (mrg *merger) WhenMatchedDelete() Merger {
+	return mrg.frame(func(mq *mergerQuery) error {
+		mq.actions = append(mq.actions, &exql.MergeAction{
+			Matched: true,
+			Delete:  true,
+		})
+		return nil
+	})
+}
+
+func (mrg *merger) WhenNotMatchedInsert(values interface{}) Merger {
+	return mrg.frame(func(mq *mergerQuery) (err error) {
+		defer catchCondError(&err)
+
+		ff, vv, err := Map(values, &MapOptions{Mapper: mrg.SQLBuilder().structMapper(), Codec: mrg.SQLBuilder().codec})
+		if err != nil {
+			return err
+		}
+		if len(ff) == 0 {
+			return errors.New("WhenNotMatchedInsert() requires at least one column.")
+		}
+		action := &exql.MergeAction{
+			Columns: &exql.Columns{},
+			Values:  &exql.Values{},
+		}
+		for i := range ff {
+			value, args := mrg.SQLBuilder().t.PlaceholderValue(vv[i])
+			action.Columns.Columns = append(action.Columns.Columns, exql.ColumnWithName(ff[i]))
+			action.Values.Values = append(action.Values.Values, value)
+			mq.actionsArgs = append(mq.actionsArgs, args...)
+		}
+		mq.actions = append(mq.actions, action)
+		return nil
+	})
+}
+
+func (mrg *merger) Output(columns ...string) Merger {
+	return mrg.frame(func(mq *mergerQuery) error {
+		columnsToFragments(&mq.returning, columns)
+		return nil
+	})
+}
+
+func (mrg *merger) Amend(fn func(string) string) Merger {
+	return mrg.frame(func(mq *mergerQuery) error {
+		mq.amendFn = fn
+		return nil
+	})
+}
+
+func (mrg *merger) Arguments() []interface{} {
+	mq, err := mrg.build()
+	if err != nil {
+		return nil
+	}
+	return mq.arguments()
+}
+
+func (mrg *merger) Exec() (sql.Result, error) {
+	return mrg.ExecContext(mrg.SQLBuilder().sess.Context())
+}
+
+func (mrg *merger) ExecContext(ctx context.Context) (sql.Result, error) {
+	mq, err := mrg.build()
+	if err != nil {
+		return nil, err
+	}
+	return mrg.SQLBuilder().sess.StatementExec(ctx, mq.statement(), mq.arguments()...)
+}
+
+func (mrg *merger) Iterator() Iterator {
+	return mrg.IteratorContext(mrg.SQLBuilder().sess.Context())
+}
+
+func (mrg *merger) IteratorContext(ctx context.Context) Iterator {
+	mq, err := mrg.build()
+	if err != nil {
+		return mrg.SQLBuilder().newIterator(nil, err)
+	}
+	rows, err := mrg.SQLBuilder().sess.StatementQuery(ctx, mq.statement(), mq.arguments()...)
+	return mrg.SQLBuilder().newIterator(rows, err)
+}
+
+func (mrg *merger) statement() (*exql.Statement, error) {
+	mq, err := mrg.build()
+	if err != nil {
+		return nil, err
+	}
+	return mq.statement(), nil
+}
+
+func (mrg *merger) build() (*mergerQuery, error) {
+	mq, err := immutable.FastForward(mrg)
+	if err != nil {
+		return nil, err
+	}
+	ret := mq.(*mergerQuery)
+	if ret.with, ret.withArgs, err = mrg.SQLBuilder().withClause(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (mrg *merger) Compile() (string, error) {
+	s, err := mrg.statement()
+	if err != nil {
+		return "", err
+	}
+	return s.Compile(mrg.template())
+}
+
+func (mrg *merger) Prev() immutable.Immutable {
+	if mrg == nil {
+		return nil
+	}
+	return mrg.prev
+}
+
+func (mrg *merger) Fn(in interface{}) error {
+	if mrg.fn == nil {
+		return nil
+	}
+	return mrg.fn(in.(*mergerQuery))
+}
+
+func (mrg *merger) Base() interface{} {
+	return &mergerQuery{}
+}
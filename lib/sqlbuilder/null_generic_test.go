@@ -0,0 +1,32 @@
+//go:build go1.22
+// +build go1.22
+
+package sqlbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+)
+
+func TestCreateTableGenericNull(t *testing.T) {
+	b := &sqlBuilder{t: newTemplateWithUtils(&testTemplate)}
+
+	type user struct {
+		ID       int64               `db:"id,pk,omitempty"`
+		Nickname db.Null[string]     `db:"nickname"`
+		Age      db.Null[int32]      `db:"age"`
+		Balance  db.Null[db.Decimal] `db:"balance,precision=10,scale=2"`
+	}
+
+	assert.Equal(t,
+		`CREATE TABLE "users" ("id" BIGSERIAL NOT NULL PRIMARY KEY, "nickname" VARCHAR(255), "age" INTEGER, "balance" NUMERIC(10, 2))`,
+		b.CreateTable("users").FromStruct(user{}).String(),
+	)
+
+	columns, values, err := Map(user{Nickname: db.NewNull("joe")}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"age", "balance", "nickname"}, columns)
+	assert.Equal(t, []interface{}{db.Null[int32]{}, db.Null[db.Decimal]{}, db.NewNull("joe")}, values)
+}
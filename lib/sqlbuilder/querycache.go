@@ -0,0 +1,378 @@
+package sqlbuilder
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+func init() {
+	// Drivers return time values as time.Time, which gob does not know about.
+	gob.Register(time.Time{})
+}
+
+// hasResultCache is implemented by sessions that keep the results of queries
+// marked with Selector.Cache, a nil store means results must not be cached.
+type hasResultCache interface {
+	ResultCache() db.QueryCache
+}
+
+// Keys of the query cache, every cached result depends on the global
+// generation and on the generation of each table it reads from, results that
+// read from tables that can't be told depend on the "*" generation, which is
+// renewed on every write.
+const (
+	queryCacheKeyPrefix      = "upper:query:"
+	queryCacheGenerationKey  = "upper:gen"
+	queryCacheAnyTable       = "*"
+	queryCacheGenerationSize = 8
+)
+
+// queryCacheEntry is the marshaled form of a cached result.
+type queryCacheEntry struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+func (b *sqlBuilder) resultCache() db.QueryCache {
+	if sess, ok := b.sess.(hasResultCache); ok {
+		return sess.ResultCache()
+	}
+	return nil
+}
+
+// InvalidateQueryCache expires the results kept in cache for the queries that
+// read from any of the given tables, or every result if no table is given.
+// Sessions do this on every INSERT, UPDATE, DELETE or TRUNCATE they run, so
+// this is only required when tables are modified by other means, like raw SQL
+// or other applications.
+func InvalidateQueryCache(cache db.QueryCache, tables ...string) {
+	if len(tables) == 0 {
+		cache.Set(queryCacheGenerationKey, newQueryCacheGeneration(), 0)
+		return
+	}
+	for _, table := range tables {
+		cache.Set(queryCacheTableKey(table), newQueryCacheGeneration(), 0)
+	}
+	cache.Set(queryCacheTableKey(queryCacheAnyTable), newQueryCacheGeneration(), 0)
+}
+
+func newQueryCacheGeneration() []byte {
+	buf := make([]byte, queryCacheGenerationSize)
+	if _, err := rand.Read(buf); err != nil {
+		return []byte(strconv.FormatInt(time.Now().UnixNano(), 36))
+	}
+	return buf
+}
+
+// queryCacheTableKey returns the key of the generation of a table, aliases,
+// quotes and case are ignored.
+func queryCacheTableKey(table string) string {
+	if fields := strings.Fields(table); len(fields) > 0 {
+		table = fields[0]
+	}
+	table = strings.NewReplacer(`"`, "", "`", "", "[", "", "]", "").Replace(table)
+	return queryCacheGenerationKey + ":" + strings.ToLower(table)
+}
+
+// queryCacheGeneration returns the current generation stored under key, a new
+// one is stored if there's none, so results cached under a generation that
+// was evicted can't be read again.
+func queryCacheGeneration(cache db.QueryCache, key string) []byte {
+	if gen, ok := cache.Get(key); ok {
+		return gen
+	}
+	gen := newQueryCacheGeneration()
+	cache.Set(key, gen, 0)
+	return gen
+}
+
+// queryCacheKey returns the key the result of the given query is cached
+// under, tables is nil if the tables the query reads from can't be told.
+func queryCacheKey(cache db.QueryCache, query string, args []interface{}, tables []string) string {
+	h := sha256.New()
+	io.WriteString(h, query)
+	for _, arg := range args {
+		if valuer, ok := arg.(driver.Valuer); ok {
+			if v, err := valuer.Value(); err == nil {
+				arg = v
+			}
+		}
+		fmt.Fprintf(h, "\x00%T:%v", arg, arg)
+	}
+
+	keys := []string{queryCacheGenerationKey}
+	if tables == nil {
+		keys = append(keys, queryCacheTableKey(queryCacheAnyTable))
+	}
+	for _, table := range tables {
+		keys = append(keys, queryCacheTableKey(table))
+	}
+	for _, key := range keys {
+		h.Write([]byte{0})
+		h.Write(queryCacheGeneration(cache, key))
+	}
+
+	return queryCacheKeyPrefix + hex.EncodeToString(h.Sum(nil))
+}
+
+// tables returns the tables the query reads from, or nil if they can't be
+// told, like when reading from subqueries or common table expressions.
+func (sq *selectorQuery) tables() []string {
+	if sq.compoundSQL != "" || sq.with != nil || sq.table == nil {
+		return nil
+	}
+
+	fragments := append([]exql.Fragment{}, sq.table.Columns...)
+	for _, join := range sq.joins {
+		columns, ok := join.Table.(*exql.Columns)
+		if !ok {
+			return nil
+		}
+		fragments = append(fragments, columns.Columns...)
+	}
+
+	tables := make([]string, 0, len(fragments))
+	for _, fragment := range fragments {
+		column, ok := fragment.(*exql.Column)
+		if !ok {
+			return nil
+		}
+		name, ok := column.Name.(string)
+		if !ok || strings.TrimSpace(name) == "" || strings.ContainsAny(name, "()") {
+			return nil
+		}
+		tables = append(tables, name)
+	}
+	return tables
+}
+
+// cachedIterator returns an iterator over the cached result of the query, the
+// query is run and its result is cached if there's none.
+func (sel *selector) cachedIterator(ctx context.Context, cache db.QueryCache, sq *selectorQuery) Iterator {
+	stmt := sq.statement()
+	args := sq.arguments()
+
+	query, err := stmt.Compile(sel.template())
+	if err != nil {
+		return &iterator{err: err}
+	}
+
+	mapper, codec := sel.SQLBuilder().structMapper(), sel.SQLBuilder().codec
+
+	key := queryCacheKey(cache, query, args, sq.tables())
+	if data, ok := cache.Get(key); ok {
+		var entry queryCacheEntry
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entry); err == nil {
+			return &iterator{cursor: &cachedRows{entry: &entry}, mapper: mapper, codec: codec}
+		}
+	}
+
+	ctx, cancel := withTimeout(ctx, sq.timeout)
+	defer cancel()
+
+	rows, err := sel.SQLBuilder().sess.StatementQuery(ctx, stmt, args...)
+	if err != nil {
+		return &iterator{err: err}
+	}
+
+	entry, err := readQueryCacheEntry(rows)
+	if err != nil {
+		return &iterator{err: err}
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entry); err == nil {
+		cache.Set(key, buf.Bytes(), sq.cacheTTL)
+	}
+
+	return &iterator{cursor: &cachedRows{entry: entry}, mapper: mapper, codec: codec}
+}
+
+// readQueryCacheEntry reads all the rows of a result and closes it.
+func readQueryCacheEntry(rows *sql.Rows) (*queryCacheEntry, error) {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &queryCacheEntry{Columns: columns}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dst := make([]interface{}, len(columns))
+		for i := range values {
+			dst[i] = &values[i]
+		}
+		if err := rows.Scan(dst...); err != nil {
+			return nil, err
+		}
+		entry.Rows = append(entry.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// cachedRows walks over the rows of a cached result, values are converted
+// into the destinations given to Scan like database/sql does.
+type cachedRows struct {
+	entry  *queryCacheEntry
+	cursor int
+	closed bool
+}
+
+func (r *cachedRows) Next() bool {
+	if r.closed || r.cursor >= len(r.entry.Rows) {
+		r.closed = true
+		return false
+	}
+	r.cursor++
+	return true
+}
+
+func (r *cachedRows) Err() error {
+	return nil
+}
+
+func (r *cachedRows) Columns() ([]string, error) {
+	if r.closed {
+		return nil, sql.ErrNoRows
+	}
+	return r.entry.Columns, nil
+}
+
+func (r *cachedRows) Scan(dest ...interface{}) error {
+	if r.closed || r.cursor == 0 {
+		return sql.ErrNoRows
+	}
+	row := r.entry.Rows[r.cursor-1]
+	if len(dest) != len(row) {
+		return fmt.Errorf("sql: expected %d destination arguments in Scan, not %d", len(row), len(dest))
+	}
+	for i := range dest {
+		if err := convertAssign(dest[i], row[i]); err != nil {
+			return fmt.Errorf("sql: Scan error on column index %d, name %q: %v", i, r.entry.Columns[i], err)
+		}
+	}
+	return nil
+}
+
+func (r *cachedRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+// convertAssign stores a value read from the database into dst, it follows
+// the conversion rules of database/sql.
+func convertAssign(dst interface{}, src interface{}) error {
+	if s, ok := dst.(sql.Scanner); ok {
+		return s.Scan(src)
+	}
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return ErrExpectingPointer
+	}
+	return assignValue(dv.Elem(), src)
+}
+
+func assignValue(dv reflect.Value, src interface{}) error {
+	if src == nil {
+		switch dv.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			dv.Set(reflect.Zero(dv.Type()))
+			return nil
+		}
+		return fmt.Errorf("converting NULL to %s is unsupported", dv.Type())
+	}
+
+	if dv.Kind() == reflect.Ptr {
+		v := reflect.New(dv.Type().Elem())
+		if err := convertAssign(v.Interface(), src); err != nil {
+			return err
+		}
+		dv.Set(v)
+		return nil
+	}
+
+	// Cached values are shared among iterators, so bytes are copied.
+	if b, ok := src.([]byte); ok {
+		src = append([]byte(nil), b...)
+	}
+
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(dv.Type()) {
+		dv.Set(sv)
+		return nil
+	}
+
+	switch dv.Kind() {
+	case reflect.String:
+		dv.SetString(asString(src))
+		return nil
+	case reflect.Slice:
+		if dv.Type().Elem().Kind() == reflect.Uint8 {
+			dv.SetBytes([]byte(asString(src)))
+			return nil
+		}
+	case reflect.Bool:
+		v, err := strconv.ParseBool(asString(src))
+		if err != nil {
+			return fmt.Errorf("converting %T to %s: %v", src, dv.Type(), err)
+		}
+		dv.SetBool(v)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(asString(src), 10, dv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("converting %T to %s: %v", src, dv.Type(), err)
+		}
+		dv.SetInt(v)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(asString(src), 10, dv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("converting %T to %s: %v", src, dv.Type(), err)
+		}
+		dv.SetUint(v)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(asString(src), dv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("converting %T to %s: %v", src, dv.Type(), err)
+		}
+		dv.SetFloat(v)
+		return nil
+	}
+
+	return fmt.Errorf("unsupported Scan, storing %T into type %s", src, dv.Type())
+}
+
+func asString(src interface{}) string {
+	switch v := src.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("%v", src)
+}
@@ -0,0 +1,125 @@
+package sqlbuilder
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+)
+
+func TestQueryCacheKey(t *testing.T) {
+	cache := db.NewMemoryQueryCache(10)
+
+	books := queryCacheKey(cache, "SELECT * FROM books WHERE id = ?", []interface{}{1}, []string{"books"})
+	assert.Equal(t, books, queryCacheKey(cache, "SELECT * FROM books WHERE id = ?", []interface{}{1}, []string{"books"}))
+	assert.NotEqual(t, books, queryCacheKey(cache, "SELECT * FROM books WHERE id = ?", []interface{}{2}, []string{"books"}))
+
+	any := queryCacheKey(cache, "SELECT * FROM (SELECT 1) AS t", nil, nil)
+
+	InvalidateQueryCache(cache, "authors")
+	assert.Equal(t, books, queryCacheKey(cache, "SELECT * FROM books WHERE id = ?", []interface{}{1}, []string{"books"}))
+	assert.NotEqual(t, any, queryCacheKey(cache, "SELECT * FROM (SELECT 1) AS t", nil, nil))
+
+	InvalidateQueryCache(cache, `"Books" AS b`)
+	assert.NotEqual(t, books, queryCacheKey(cache, "SELECT * FROM books WHERE id = ?", []interface{}{1}, []string{"books"}))
+
+	books = queryCacheKey(cache, "SELECT * FROM books WHERE id = ?", []interface{}{1}, []string{"books"})
+	InvalidateQueryCache(cache)
+	assert.NotEqual(t, books, queryCacheKey(cache, "SELECT * FROM books WHERE id = ?", []interface{}{1}, []string{"books"}))
+}
+
+func TestSelectorTables(t *testing.T) {
+	b := &sqlBuilder{t: newTemplateWithUtils(&testTemplate)}
+
+	tables := func(sel Selector) []string {
+		sq, err := sel.(*selector).build()
+		assert.NoError(t, err)
+		return sq.tables()
+	}
+
+	assert.Equal(t, []string{"books"}, tables(b.SelectFrom("books")))
+	assert.Equal(t, []string{"books AS b", "authors a"}, tables(b.SelectFrom("books AS b").Join("authors a").On("b.author_id = a.id")))
+	assert.Nil(t, tables(b.Select().From(b.SelectFrom("books")).As("t")))
+	assert.Nil(t, tables(b.SelectFrom("books").Union(b.SelectFrom("authors"))))
+}
+
+func TestCachedRows(t *testing.T) {
+	entry := &queryCacheEntry{
+		Columns: []string{"id", "name", "score", "created_at", "deleted_at"},
+		Rows: [][]interface{}{
+			{int64(1), []byte("Hemingway"), []byte("4.5"), time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), nil},
+			{int64(2), "Tolkien", 3.0, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), nil},
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(entry))
+
+	var decoded queryCacheEntry
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+	assert.Equal(t, entry, &decoded)
+
+	type author struct {
+		ID        int        `db:"id"`
+		Name      string     `db:"name"`
+		Score     float64    `db:"score"`
+		CreatedAt time.Time  `db:"created_at"`
+		DeletedAt *time.Time `db:"deleted_at"`
+	}
+
+	var authors []author
+	iter := &iterator{cursor: &cachedRows{entry: &decoded}, mapper: mapper}
+	assert.NoError(t, iter.All(&authors))
+	assert.Equal(t, []author{
+		{ID: 1, Name: "Hemingway", Score: 4.5, CreatedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, Name: "Tolkien", Score: 3.0, CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}, authors)
+
+	// The cached result can be read again.
+	var names []string
+	iter = &iterator{cursor: &cachedRows{entry: &decoded}, mapper: mapper}
+	for iter.Next() {
+		var id int64
+		var name string
+		var rest [3]interface{}
+		assert.NoError(t, iter.Scan(&id, &name, &rest[0], &rest[1], &rest[2]))
+		names = append(names, name)
+	}
+	assert.Equal(t, []string{"Hemingway", "Tolkien"}, names)
+	assert.NoError(t, iter.Err())
+}
+
+func TestConvertAssign(t *testing.T) {
+	var i int32
+	assert.NoError(t, convertAssign(&i, []byte("42")))
+	assert.Equal(t, int32(42), i)
+	assert.Error(t, convertAssign(&i, []byte("4.2")))
+	assert.Error(t, convertAssign(&i, nil))
+
+	var b bool
+	assert.NoError(t, convertAssign(&b, int64(1)))
+	assert.True(t, b)
+
+	var s string
+	assert.NoError(t, convertAssign(&s, int64(7)))
+	assert.Equal(t, "7", s)
+
+	var p *string
+	assert.NoError(t, convertAssign(&p, "x"))
+	assert.Equal(t, "x", *p)
+	assert.NoError(t, convertAssign(&p, nil))
+	assert.Nil(t, p)
+
+	src := []byte("abc")
+	var raw []byte
+	assert.NoError(t, convertAssign(&raw, src))
+	src[0] = 'z'
+	assert.Equal(t, []byte("abc"), raw)
+
+	var v interface{}
+	assert.NoError(t, convertAssign(&v, int64(3)))
+	assert.Equal(t, int64(3), v)
+}
@@ -0,0 +1,212 @@
+//go:build go1.10
+// +build go1.10
+
+package sqlbuilder
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+)
+
+// RecordedStatement is a statement that was received by a Recorder.
+type RecordedStatement struct {
+	Query string
+	Args  []interface{}
+}
+
+// Recorder is a database/sql connector that records the statements it
+// receives instead of running them, it can be used to review or snapshot
+// the SQL an application generates without a database server:
+//
+//	rec := sqlbuilder.NewRecorder()
+//	sess, err := postgresql.New(rec.DB())
+//	...
+//	rec.Reset() // Discards the statements the adapter ran on New().
+//
+//	err = sess.Collection("users").Find(db.Cond{"id": 1}).Update(user)
+//	...
+//	for _, stmt := range rec.Statements() {
+//	  fmt.Println(stmt.Query, stmt.Args)
+//	}
+//
+// Statements are compiled by the adapter as usual, so queries have the
+// placeholders of the target database. Queries return no rows and Exec()
+// reports zero affected rows, operations that expect rows back, like One(),
+// fail with db.ErrNoMoreRows.
+type Recorder struct {
+	mu         sync.Mutex
+	statements []RecordedStatement
+}
+
+var _ = driver.Connector(&Recorder{})
+
+// NewRecorder creates a Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// DB returns a *sql.DB that sends its statements to the recorder.
+func (r *Recorder) DB() *sql.DB {
+	return sql.OpenDB(r)
+}
+
+// Statements returns the statements that were recorded so far, in the order
+// they were received.
+func (r *Recorder) Statements() []RecordedStatement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statements := make([]RecordedStatement, len(r.statements))
+	copy(statements, r.statements)
+	return statements
+}
+
+// Reset discards all recorded statements.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = nil
+}
+
+func (r *Recorder) record(query string, args []driver.NamedValue) {
+	stmt := RecordedStatement{Query: query}
+	if len(args) > 0 {
+		stmt.Args = make([]interface{}, len(args))
+		for i := range args {
+			stmt.Args[i] = args[i].Value
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, stmt)
+}
+
+// Connect satisfies driver.Connector.
+func (r *Recorder) Connect(context.Context) (driver.Conn, error) {
+	return &recorderConn{r: r}, nil
+}
+
+// Driver satisfies driver.Connector.
+func (r *Recorder) Driver() driver.Driver {
+	return recorderDriver{r: r}
+}
+
+type recorderDriver struct {
+	r *Recorder
+}
+
+func (d recorderDriver) Open(string) (driver.Conn, error) {
+	return &recorderConn{r: d.r}, nil
+}
+
+type recorderConn struct {
+	r *Recorder
+}
+
+func (c *recorderConn) Prepare(query string) (driver.Stmt, error) {
+	return &recorderStmt{r: c.r, query: query}, nil
+}
+
+func (c *recorderConn) Close() error {
+	return nil
+}
+
+func (c *recorderConn) Begin() (driver.Tx, error) {
+	return recorderTx{}, nil
+}
+
+func (c *recorderConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return recorderTx{}, nil
+}
+
+// CheckNamedValue accepts all arguments as they are, so they're recorded the
+// way they were given.
+func (c *recorderConn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func (c *recorderConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.r.record(query, args)
+	return recorderResult{}, nil
+}
+
+func (c *recorderConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.r.record(query, args)
+	return recorderRows{}, nil
+}
+
+type recorderStmt struct {
+	r     *Recorder
+	query string
+}
+
+func (s *recorderStmt) Close() error {
+	return nil
+}
+
+func (s *recorderStmt) NumInput() int {
+	return -1
+}
+
+func (s *recorderStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *recorderStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *recorderStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	s.r.record(s.query, args)
+	return recorderResult{}, nil
+}
+
+func (s *recorderStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	s.r.record(s.query, args)
+	return recorderRows{}, nil
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: args[i]}
+	}
+	return named
+}
+
+type recorderTx struct{}
+
+func (recorderTx) Commit() error {
+	return nil
+}
+
+func (recorderTx) Rollback() error {
+	return nil
+}
+
+type recorderResult struct{}
+
+func (recorderResult) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+func (recorderResult) RowsAffected() (int64, error) {
+	return 0, nil
+}
+
+type recorderRows struct{}
+
+func (recorderRows) Columns() []string {
+	return []string{}
+}
+
+func (recorderRows) Close() error {
+	return nil
+}
+
+func (recorderRows) Next([]driver.Value) error {
+	return io.EOF
+}
@@ -0,0 +1,50 @@
+//go:build go1.10
+// +build go1.10
+
+package sqlbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	rec := NewRecorder()
+
+	sess := rec.DB()
+	defer sess.Close()
+
+	res, err := sess.Exec(`INSERT INTO "artist" ("name") VALUES ($1)`, "Chavela")
+	assert.NoError(t, err)
+	affected, err := res.RowsAffected()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), affected)
+
+	rows, err := sess.Query(`SELECT * FROM "artist" WHERE ("id" > $1)`, 0)
+	assert.NoError(t, err)
+	assert.False(t, rows.Next())
+	assert.NoError(t, rows.Close())
+
+	stmt, err := sess.Prepare(`DELETE FROM "artist" WHERE ("id" = $1)`)
+	assert.NoError(t, err)
+	_, err = stmt.Exec(1)
+	assert.NoError(t, err)
+	assert.NoError(t, stmt.Close())
+
+	tx, err := sess.Begin()
+	assert.NoError(t, err)
+	_, err = tx.Exec(`UPDATE "artist" SET "name" = $1`, "Chavela Vargas")
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+
+	assert.Equal(t, []RecordedStatement{
+		{Query: `INSERT INTO "artist" ("name") VALUES ($1)`, Args: []interface{}{"Chavela"}},
+		{Query: `SELECT * FROM "artist" WHERE ("id" > $1)`, Args: []interface{}{0}},
+		{Query: `DELETE FROM "artist" WHERE ("id" = $1)`, Args: []interface{}{1}},
+		{Query: `UPDATE "artist" SET "name" = $1`, Args: []interface{}{"Chavela Vargas"}},
+	}, rec.Statements())
+
+	rec.Reset()
+	assert.Equal(t, 0, len(rec.Statements()))
+}
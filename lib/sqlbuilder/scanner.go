@@ -27,10 +27,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 
 	"upper.io/db.v3"
+	"upper.io/db.v3/lib/reflectx"
 )
 
 const (
@@ -85,6 +87,25 @@ func (j jsonbType) Value() (driver.Value, error) {
 
 //------
 
+// jsonType is like jsonbType but it sends values as text, databases like
+// MySQL reject binary strings for JSON columns.
+type jsonType struct {
+	V interface{}
+}
+
+func (j jsonType) Value() (driver.Value, error) {
+	if v, ok := j.V.(json.RawMessage); ok {
+		return string(v), nil
+	}
+	b, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+//------
+
 type stringArray []string
 
 func (a *stringArray) Scan(src interface{}) error {
@@ -273,3 +294,37 @@ func (a int64Array) Value() (driver.Value, error) {
 
 	return []byte{'{', '}'}, nil
 }
+
+//------
+
+// scanDuration sets a time.Duration or *time.Duration field with the
+// interval option from the interval or TIME value of its column, NULL values
+// leave the field as it is.
+func scanDuration(fi *reflectx.FieldInfo, f reflect.Value, src []byte) error {
+	t := f.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != durationType {
+		return fmt.Errorf(`Expecting field %q to be time.Duration (using "interval" tag)`, fi.Name)
+	}
+	if src == nil {
+		return nil
+	}
+
+	var interval db.Interval
+	if err := interval.Scan(src); err != nil {
+		return err
+	}
+	d, err := interval.TimeDuration()
+	if err != nil {
+		return fmt.Errorf("Cannot scan %v into field %q: %v", interval, fi.Name, err)
+	}
+
+	if f.Kind() == reflect.Ptr {
+		f.Set(reflect.ValueOf(&d))
+	} else {
+		f.Set(reflect.ValueOf(d))
+	}
+	return nil
+}
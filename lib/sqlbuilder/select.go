@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"upper.io/db.v3"
 	"upper.io/db.v3/internal/immutable"
@@ -20,6 +21,9 @@ type selectorQuery struct {
 
 	distinct bool
 
+	distinctOn     *exql.Columns
+	distinctOnArgs []interface{}
+
 	where     *exql.Where
 	whereArgs []interface{}
 
@@ -38,11 +42,31 @@ type selectorQuery struct {
 	joins     []*exql.Join
 	joinsArgs []interface{}
 
+	with     *exql.With
+	withArgs []interface{}
+
+	compound    []*compoundOperand
+	compoundSQL string
+
+	lock *exql.Lock
+
+	timeout time.Duration
+
+	cacheTTL time.Duration
+
 	amendFn func(string) string
 }
 
+type compoundOperand struct {
+	operator string
+	sel      Selector
+}
+
 func (sq *selectorQuery) and(b *sqlBuilder, terms ...interface{}) error {
-	where, whereArgs := b.t.toWhereWithArguments(terms)
+	where, whereArgs, err := b.t.toWhere(terms)
+	if err != nil {
+		return err
+	}
 
 	if sq.where == nil {
 		sq.where, sq.whereArgs = &exql.Where{}, []interface{}{}
@@ -55,6 +79,8 @@ func (sq *selectorQuery) and(b *sqlBuilder, terms ...interface{}) error {
 
 func (sq *selectorQuery) arguments() []interface{} {
 	return joinArguments(
+		sq.withArgs,
+		sq.distinctOnArgs,
 		sq.columnsArgs,
 		sq.tableArgs,
 		sq.joinsArgs,
@@ -65,6 +91,14 @@ func (sq *selectorQuery) arguments() []interface{} {
 }
 
 func (sq *selectorQuery) statement() *exql.Statement {
+	if sq.compoundSQL != "" {
+		compiled := sq.compoundSQL
+		if sq.amendFn != nil {
+			compiled = sq.amendFn(compiled)
+		}
+		return exql.RawSQL(compiled)
+	}
+
 	stmt := &exql.Statement{
 		Type:     exql.Select,
 		Table:    sq.table,
@@ -77,6 +111,22 @@ func (sq *selectorQuery) statement() *exql.Statement {
 		GroupBy:  sq.groupBy,
 	}
 
+	if sq.with != nil {
+		stmt.With = sq.with
+	}
+
+	if sq.distinctOn != nil {
+		stmt.DistinctOn = sq.distinctOn
+	}
+
+	if sq.lock != nil {
+		stmt.Lock = sq.lock
+	}
+
+	if sq.timeout > 0 {
+		stmt.Timeout = &exql.Timeout{Duration: sq.timeout}
+	}
+
 	if len(sq.joins) > 0 {
 		stmt.Joins = exql.JoinConditions(sq.joins...)
 	}
@@ -138,6 +188,9 @@ func (sel *selector) frame(fn func(*selectorQuery) error) *selector {
 func (sel *selector) From(tables ...interface{}) Selector {
 	return sel.frame(
 		func(sq *selectorQuery) error {
+			if err := sel.SQLBuilder().checkIdentifiers(tables...); err != nil {
+				return err
+			}
 			fragments, args, err := columnFragments(tables)
 			if err != nil {
 				return err
@@ -151,14 +204,42 @@ func (sel *selector) From(tables ...interface{}) Selector {
 
 func (sel *selector) Columns(columns ...interface{}) Selector {
 	return sel.frame(func(sq *selectorQuery) error {
-		return sq.pushColumns(columns...)
+		if err := sel.SQLBuilder().checkIdentifiers(columns...); err != nil {
+			return err
+		}
+		return sq.pushColumns(sel.SQLBuilder().t, columns...)
 	})
 }
 
-func (sq *selectorQuery) pushColumns(columns ...interface{}) error {
-	f, args, err := columnFragments(columns)
-	if err != nil {
-		return err
+func (sq *selectorQuery) pushColumns(tu *templateWithUtils, columns ...interface{}) (err error) {
+	defer catchCondError(&err)
+
+	f := make([]exql.Fragment, 0, len(columns))
+	args := []interface{}{}
+
+	for i := range columns {
+		if c, ok := columns[i].(db.CaseExpression); ok {
+			frag, a := tu.caseFragment(c)
+			frag.Alias = c.Alias()
+			f = append(f, frag)
+			args = append(args, a...)
+			continue
+		}
+
+		if r, ok := columns[i].(db.SearchRank); ok {
+			frag, a := tu.rankFragment(r)
+			frag.Alias = r.Alias()
+			f = append(f, frag)
+			args = append(args, a...)
+			continue
+		}
+
+		frag, a, err := columnFragments(columns[i : i+1])
+		if err != nil {
+			return err
+		}
+		f = append(f, frag...)
+		args = append(args, a...)
 	}
 
 	c := exql.JoinColumns(f...)
@@ -175,19 +256,97 @@ func (sq *selectorQuery) pushColumns(columns ...interface{}) error {
 
 func (sel *selector) Distinct(exps ...interface{}) Selector {
 	return sel.frame(func(sq *selectorQuery) error {
+		if err := sel.SQLBuilder().checkIdentifiers(exps...); err != nil {
+			return err
+		}
 		sq.distinct = true
-		return sq.pushColumns(exps...)
+		return sq.pushColumns(sel.SQLBuilder().t, exps...)
+	})
+}
+
+func (sel *selector) DistinctOn(columns ...interface{}) Selector {
+	return sel.frame(func(sq *selectorQuery) error {
+		if len(columns) == 0 {
+			return errors.New("DistinctOn() requires at least one column.")
+		}
+		if err := sel.SQLBuilder().checkIdentifiers(columns...); err != nil {
+			return err
+		}
+
+		fragments, args, err := columnFragments(columns)
+		if err != nil {
+			return err
+		}
+
+		sq.distinctOn = exql.JoinColumns(fragments...)
+		sq.distinctOnArgs = args
+
+		return nil
+	})
+}
+
+func (sel *selector) ForUpdate() Selector {
+	return sel.frame(func(sq *selectorQuery) error {
+		sq.lock = &exql.Lock{}
+		return nil
+	})
+}
+
+func (sel *selector) ForShare() Selector {
+	return sel.frame(func(sq *selectorQuery) error {
+		sq.lock = &exql.Lock{Share: true}
+		return nil
+	})
+}
+
+func (sel *selector) SkipLocked() Selector {
+	return sel.frame(func(sq *selectorQuery) error {
+		if sq.lock == nil {
+			return errors.New("Cannot use SkipLocked() without a preceding ForUpdate() or ForShare() expression.")
+		}
+		sq.lock = &exql.Lock{Share: sq.lock.Share, SkipLocked: true}
+		return nil
+	})
+}
+
+func (sel *selector) NoWait() Selector {
+	return sel.frame(func(sq *selectorQuery) error {
+		if sq.lock == nil {
+			return errors.New("Cannot use NoWait() without a preceding ForUpdate() or ForShare() expression.")
+		}
+		sq.lock = &exql.Lock{Share: sq.lock.Share, NoWait: true}
+		return nil
+	})
+}
+
+func (sel *selector) Timeout(timeout time.Duration) Selector {
+	return sel.frame(func(sq *selectorQuery) error {
+		sq.timeout = timeout
+		return nil
+	})
+}
+
+func (sel *selector) Cache(ttl time.Duration) Selector {
+	return sel.frame(func(sq *selectorQuery) error {
+		sq.cacheTTL = ttl
+		return nil
 	})
 }
 
 func (sel *selector) Where(terms ...interface{}) Selector {
 	return sel.frame(func(sq *selectorQuery) error {
+		if err := sel.SQLBuilder().checkConditions(terms...); err != nil {
+			return err
+		}
 		return sq.and(sel.SQLBuilder(), terms...)
 	})
 }
 
 func (sel *selector) And(terms ...interface{}) Selector {
 	return sel.frame(func(sq *selectorQuery) error {
+		if err := sel.SQLBuilder().checkConditions(terms...); err != nil {
+			return err
+		}
 		return sq.and(sel.SQLBuilder(), terms...)
 	})
 }
@@ -209,6 +368,9 @@ func (sel *selector) Arguments() []interface{} {
 
 func (sel *selector) GroupBy(columns ...interface{}) Selector {
 	return sel.frame(func(sq *selectorQuery) error {
+		if err := sel.SQLBuilder().checkIdentifiers(columns...); err != nil {
+			return err
+		}
 		fragments, args, err := columnFragments(columns)
 		if err != nil {
 			return err
@@ -224,7 +386,13 @@ func (sel *selector) GroupBy(columns ...interface{}) Selector {
 }
 
 func (sel *selector) OrderBy(columns ...interface{}) Selector {
-	return sel.frame(func(sq *selectorQuery) error {
+	return sel.frame(func(sq *selectorQuery) (err error) {
+		defer catchCondError(&err)
+
+		if err := sel.SQLBuilder().checkSortColumns(columns...); err != nil {
+			return err
+		}
+
 		var sortColumns exql.SortColumns
 
 		for i := range columns {
@@ -232,6 +400,9 @@ func (sel *selector) OrderBy(columns ...interface{}) Selector {
 
 			switch value := columns[i].(type) {
 			case db.RawValue:
+				if err := rawValueErr(value); err != nil {
+					return err
+				}
 				query, args := Preprocess(value.Raw(), value.Arguments())
 				sort = &exql.SortColumn{
 					Column: exql.RawValue(query),
@@ -249,25 +420,25 @@ func (sel *selector) OrderBy(columns ...interface{}) Selector {
 					Column: exql.RawValue(fnName),
 				}
 				sq.orderByArgs = append(sq.orderByArgs, fnArgs...)
-			case string:
-				if strings.HasPrefix(value, "-") {
-					sort = &exql.SortColumn{
-						Column: exql.ColumnWithName(value[1:]),
-						Order:  exql.Descendent,
-					}
-				} else {
-					chunks := strings.SplitN(value, " ", 2)
-
-					order := exql.Ascendent
-					if len(chunks) > 1 && strings.ToUpper(chunks[1]) == "DESC" {
-						order = exql.Descendent
-					}
-
-					sort = &exql.SortColumn{
-						Column: exql.ColumnWithName(chunks[0]),
-						Order:  order,
-					}
+			case db.CaseExpression:
+				frag, args := sel.SQLBuilder().t.caseFragment(value)
+				sort = &exql.SortColumn{
+					Column: frag,
 				}
+				sq.orderByArgs = append(sq.orderByArgs, args...)
+			case db.SearchRank:
+				frag, args := sel.SQLBuilder().t.rankFragment(value)
+				sort = &exql.SortColumn{
+					Column: frag,
+					Order:  exql.Descendent,
+				}
+				sq.orderByArgs = append(sq.orderByArgs, args...)
+			case db.Identifier:
+				sort = &exql.SortColumn{
+					Column: exql.IdentifierWithParts(value.Parts()...),
+				}
+			case string:
+				sort = sortColumn(value)
 			default:
 				return fmt.Errorf("Can't sort by type %T", value)
 			}
@@ -281,8 +452,34 @@ func (sel *selector) OrderBy(columns ...interface{}) Selector {
 	})
 }
 
+// sortColumn converts a column name like "name", "-name" or "name DESC" into
+// a sort column.
+func sortColumn(value string) *exql.SortColumn {
+	if strings.HasPrefix(value, "-") {
+		return &exql.SortColumn{
+			Column: exql.ColumnWithName(value[1:]),
+			Order:  exql.Descendent,
+		}
+	}
+
+	chunks := strings.SplitN(value, " ", 2)
+
+	order := exql.Ascendent
+	if len(chunks) > 1 && strings.ToUpper(chunks[1]) == "DESC" {
+		order = exql.Descendent
+	}
+
+	return &exql.SortColumn{
+		Column: exql.ColumnWithName(chunks[0]),
+		Order:  order,
+	}
+}
+
 func (sel *selector) Using(columns ...interface{}) Selector {
 	return sel.frame(func(sq *selectorQuery) error {
+		if err := sel.SQLBuilder().checkIdentifiers(columns...); err != nil {
+			return err
+		}
 
 		joins := len(sq.joins)
 		if joins == 0 {
@@ -308,36 +505,54 @@ func (sel *selector) Using(columns ...interface{}) Selector {
 
 func (sel *selector) FullJoin(tables ...interface{}) Selector {
 	return sel.frame(func(sq *selectorQuery) error {
+		if err := sel.SQLBuilder().checkIdentifiers(tables...); err != nil {
+			return err
+		}
 		return sq.pushJoin("FULL", tables)
 	})
 }
 
 func (sel *selector) CrossJoin(tables ...interface{}) Selector {
 	return sel.frame(func(sq *selectorQuery) error {
+		if err := sel.SQLBuilder().checkIdentifiers(tables...); err != nil {
+			return err
+		}
 		return sq.pushJoin("CROSS", tables)
 	})
 }
 
 func (sel *selector) RightJoin(tables ...interface{}) Selector {
 	return sel.frame(func(sq *selectorQuery) error {
+		if err := sel.SQLBuilder().checkIdentifiers(tables...); err != nil {
+			return err
+		}
 		return sq.pushJoin("RIGHT", tables)
 	})
 }
 
 func (sel *selector) LeftJoin(tables ...interface{}) Selector {
 	return sel.frame(func(sq *selectorQuery) error {
+		if err := sel.SQLBuilder().checkIdentifiers(tables...); err != nil {
+			return err
+		}
 		return sq.pushJoin("LEFT", tables)
 	})
 }
 
 func (sel *selector) Join(tables ...interface{}) Selector {
 	return sel.frame(func(sq *selectorQuery) error {
+		if err := sel.SQLBuilder().checkIdentifiers(tables...); err != nil {
+			return err
+		}
 		return sq.pushJoin("", tables)
 	})
 }
 
 func (sel *selector) On(terms ...interface{}) Selector {
 	return sel.frame(func(sq *selectorQuery) error {
+		if err := sel.SQLBuilder().checkConditions(terms...); err != nil {
+			return err
+		}
 		joins := len(sq.joins)
 
 		if joins == 0 {
@@ -349,7 +564,10 @@ func (sel *selector) On(terms ...interface{}) Selector {
 			return errors.New(`Cannot use Using() and On() with the same Join() expression.`)
 		}
 
-		w, a := sel.SQLBuilder().t.toWhereWithArguments(terms)
+		w, a, err := sel.SQLBuilder().t.toWhere(terms)
+		if err != nil {
+			return err
+		}
 		o := exql.On(w)
 
 		lastJoin.On = &o
@@ -374,6 +592,132 @@ func (sel *selector) Offset(n int) Selector {
 	})
 }
 
+func (sel *selector) Union(other Selector) Selector {
+	return sel.combine("UNION", other)
+}
+
+func (sel *selector) UnionAll(other Selector) Selector {
+	return sel.combine("UNION ALL", other)
+}
+
+func (sel *selector) Intersect(other Selector) Selector {
+	return sel.combine("INTERSECT", other)
+}
+
+func (sel *selector) Except(other Selector) Selector {
+	return sel.combine("EXCEPT", other)
+}
+
+func (sel *selector) combine(operator string, other Selector) Selector {
+	// The WITH clauses of the operands are hoisted to the top of the compound
+	// query.
+	var err error
+	builder := sel.SQLBuilder()
+	if o, ok := other.(*selector); ok {
+		builder, err = builder.mergeCTEs(o.SQLBuilder())
+	}
+	qs := &selector{
+		builder: builder,
+	}
+	return qs.frame(func(sq *selectorQuery) error {
+		if err != nil {
+			return err
+		}
+		sq.compound = []*compoundOperand{
+			{sel: sel},
+			{operator: operator, sel: other},
+		}
+		return nil
+	})
+}
+
+// resolveCompound compiles the operands of a compound query. If no other
+// clauses were added after combining the operands, the query is represented
+// as-is, otherwise it's used as a subquery.
+func (sq *selectorQuery) resolveCompound(t *exql.Template) error {
+	if sq.table != nil {
+		return errors.New("Cannot use From() on a compound query.")
+	}
+
+	chunks := make([]string, 0, len(sq.compound))
+	args := []interface{}{}
+
+	for i, operand := range sq.compound {
+		q, a, err := compileCompoundOperand(t, operand.sel, i)
+		if err != nil {
+			return err
+		}
+		if operand.operator != "" {
+			chunks = append(chunks, operand.operator)
+		}
+		chunks = append(chunks, q)
+		args = append(args, a...)
+	}
+
+	body := strings.Join(chunks, " ")
+
+	if sq.columns == nil && sq.where == nil && len(sq.joins) == 0 && sq.groupBy == nil &&
+		sq.orderBy == nil && sq.limit == 0 && sq.offset == 0 && !sq.distinct && sq.distinctOn == nil && sq.lock == nil {
+		sq.compoundSQL, sq.tableArgs = body, args
+		return nil
+	}
+
+	alias, err := exql.ColumnWithName("__u").Compile(t)
+	if err != nil {
+		return err
+	}
+	sq.table = exql.JoinColumns(exql.RawValue("(" + body + ") AS " + alias))
+	sq.tableArgs = args
+
+	return nil
+}
+
+func compileCompoundOperand(t *exql.Template, operand Selector, i int) (string, []interface{}, error) {
+	var (
+		compiled string
+		args     []interface{}
+		wrap     bool
+	)
+
+	if s, ok := operand.(*selector); ok {
+		// WITH clauses are only allowed at the top of the compound query, see
+		// combine.
+		sq, err := s.buildQuery()
+		if err != nil {
+			return "", nil, err
+		}
+		if compiled, err = sq.statement().Compile(s.template()); err != nil {
+			return "", nil, err
+		}
+		args = sq.arguments()
+		wrap = sq.orderBy != nil || sq.limit != 0 || sq.offset != 0
+	} else {
+		c, ok := operand.(compilable)
+		if !ok {
+			return "", nil, fmt.Errorf("Unsupported query type %T for compound query.", operand)
+		}
+		var err error
+		if compiled, err = c.Compile(); err != nil {
+			return "", nil, err
+		}
+		args = c.Arguments()
+	}
+
+	compiled, args = Preprocess(compiled, args)
+
+	if wrap {
+		// ORDER BY, LIMIT and OFFSET would apply to the whole compound query
+		// otherwise.
+		alias, err := exql.ColumnWithName(fmt.Sprintf("__u%d", i)).Compile(t)
+		if err != nil {
+			return "", nil, err
+		}
+		compiled = "SELECT * FROM (" + compiled + ") AS " + alias
+	}
+
+	return compiled, args, nil
+}
+
 func (sel *selector) template() *exql.Template {
 	return sel.SQLBuilder().t.Template
 }
@@ -410,6 +754,7 @@ func (sel *selector) QueryRowContext(ctx context.Context) (*sql.Row, error) {
 		return nil, err
 	}
 
+	ctx = withQueryTimeout(ctx, sq.timeout)
 	return sel.SQLBuilder().sess.StatementQueryRow(ctx, sq.statement(), sq.arguments()...)
 }
 
@@ -434,6 +779,7 @@ func (sel *selector) QueryContext(ctx context.Context) (*sql.Rows, error) {
 	if err != nil {
 		return nil, err
 	}
+	ctx = withQueryTimeout(ctx, sq.timeout)
 	return sel.SQLBuilder().sess.StatementQuery(ctx, sq.statement(), sq.arguments()...)
 }
 
@@ -444,11 +790,72 @@ func (sel *selector) Iterator() Iterator {
 func (sel *selector) IteratorContext(ctx context.Context) Iterator {
 	sq, err := sel.build()
 	if err != nil {
-		return &iterator{nil, err}
+		return &iterator{err: err}
+	}
+
+	if sq.cacheTTL > 0 && db.TxFromContext(ctx) == nil {
+		if cache := sel.SQLBuilder().resultCache(); cache != nil {
+			return sel.cachedIterator(ctx, cache, sq)
+		}
+	}
+
+	ctx, cancel := withTimeout(ctx, sq.timeout)
+	stmt := sq.statement()
+	rows, err := sel.SQLBuilder().sess.StatementQuery(ctx, stmt, sq.arguments()...)
+
+	iter := sel.SQLBuilder().countRows(sel.SQLBuilder().newIterator(rows, err), stmt)
+	iter.cancel = cancel
+	return iter
+}
+
+func (sel *selector) Cursor(ctx context.Context, fetchSize int) Iterator {
+	t := sel.template()
+
+	sess, ok := sel.SQLBuilder().sess.(cursorDB)
+	if !ok || t.DeclareCursorLayout == "" || fetchSize < 1 {
+		// Rows are streamed by the driver as they're read.
+		return sel.IteratorContext(ctx)
+	}
+
+	sq, err := sel.build()
+	if err != nil {
+		return &iterator{err: err}
+	}
+
+	query, err := sq.statement().Compile(t)
+	if err != nil {
+		return &iterator{err: err}
 	}
 
-	rows, err := sel.SQLBuilder().sess.StatementQuery(ctx, sq.statement(), sq.arguments()...)
-	return &iterator{rows, err}
+	c := &exql.Cursor{Name: newCursorName(), Query: query, FetchSize: fetchSize}
+
+	declare, err := c.Declare(t)
+	if err != nil {
+		return &iterator{err: err}
+	}
+	fetch, err := c.Fetch(t)
+	if err != nil {
+		return &iterator{err: err}
+	}
+	closeCursor, err := c.Close(t)
+	if err != nil {
+		return &iterator{err: err}
+	}
+
+	ctx, cancel := withTimeout(ctx, sq.timeout)
+
+	cursor, err := sess.StatementCursor(ctx, exql.RawSQL(declare), exql.RawSQL(fetch), exql.RawSQL(closeCursor), sq.arguments()...)
+	if err != nil {
+		cancel()
+		return &iterator{err: err}
+	}
+
+	return &iterator{
+		cursor: &cursorRows{ctx: ctx, cursor: cursor, fetchSize: fetchSize},
+		mapper: sel.SQLBuilder().structMapper(),
+		codec:  sel.SQLBuilder().codec,
+		cancel: cancel,
+	}
 }
 
 func (sel *selector) All(destSlice interface{}) error {
@@ -460,15 +867,45 @@ func (sel *selector) One(dest interface{}) error {
 }
 
 func (sel *selector) build() (*selectorQuery, error) {
+	ret, err := sel.buildQuery()
+	if err != nil {
+		return nil, err
+	}
+	if ret.with, ret.withArgs, err = sel.SQLBuilder().withClause(); err != nil {
+		return nil, err
+	}
+	if ret.compoundSQL != "" && ret.with != nil {
+		with, err := ret.with.Compile(sel.template())
+		if err != nil {
+			return nil, err
+		}
+		ret.compoundSQL = with + " " + ret.compoundSQL
+	}
+	return ret, nil
+}
+
+// buildQuery is like build but does not attach the WITH clause of the
+// builder.
+func (sel *selector) buildQuery() (*selectorQuery, error) {
 	sq, err := immutable.FastForward(sel)
 	if err != nil {
 		return nil, err
 	}
-	return sq.(*selectorQuery), nil
+	ret := sq.(*selectorQuery)
+	if ret.compound != nil {
+		if err := ret.resolveCompound(sel.template()); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
 }
 
 func (sel *selector) Compile() (string, error) {
-	return sel.statement().Compile(sel.template())
+	sq, err := sel.build()
+	if err != nil {
+		return "", err
+	}
+	return sq.statement().Compile(sel.template())
 }
 
 func (sel *selector) Prev() immutable.Immutable {
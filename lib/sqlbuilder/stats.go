@@ -0,0 +1,52 @@
+package sqlbuilder
+
+import (
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+// hasQueryStats is implemented by sessions that collect statistics of the
+// statements they execute, see db.Settings.SetQueryStats.
+type hasQueryStats interface {
+	QueryStatsEnabled() bool
+	ObserveRows(stmt *exql.Statement, rows int)
+}
+
+// countRows makes iter count the rows it reads and add them to the
+// statistics of stmt once it's closed.
+func (b *sqlBuilder) countRows(iter *iterator, stmt *exql.Statement) *iterator {
+	sess, ok := b.sess.(hasQueryStats)
+	if !ok || iter.cursor == nil || !sess.QueryStatsEnabled() {
+		return iter
+	}
+	iter.cursor = &countingRows{
+		sqlRows: iter.cursor,
+		observe: func(rows int) {
+			sess.ObserveRows(stmt, rows)
+		},
+	}
+	return iter
+}
+
+// countingRows counts the rows that are read with Next.
+type countingRows struct {
+	sqlRows
+	rows    int
+	observe func(rows int)
+}
+
+func (r *countingRows) Next() bool {
+	if r.sqlRows.Next() {
+		r.rows++
+		return true
+	}
+	return false
+}
+
+func (r *countingRows) Close() error {
+	err := r.sqlRows.Close()
+	if r.observe != nil {
+		r.observe(r.rows)
+		r.observe = nil
+	}
+	return err
+}
@@ -24,6 +24,19 @@ const (
 	defaultTableAliasLayout    = `{{.Name}}{{if .Alias}} AS {{.Alias}}{{end}}`
 	defaultColumnAliasLayout   = `{{.Name}}{{if .Alias}} AS {{.Alias}}{{end}}`
 	defaultSortByColumnLayout  = `{{.Column}} {{.Order}}`
+	defaultWithLayout          = `WITH {{if .Recursive}}RECURSIVE {{end}}{{.Clauses}}`
+	defaultOnConflictLayout    = `ON CONFLICT {{if .Columns}}({{.Columns}}) {{end}}{{if .ColumnValues}}DO UPDATE SET {{.ColumnValues}} {{.Where}}{{else}}DO NOTHING{{end}}`
+	defaultExcludedLayout      = `EXCLUDED.{{.}}`
+	defaultDistinctOnLayout    = `DISTINCT ON ({{.}})`
+	defaultLockLayout          = `FOR {{if .Share}}SHARE{{else}}UPDATE{{end}}{{if .SkipLocked}} SKIP LOCKED{{else if .NoWait}} NOWAIT{{end}}`
+	defaultDeclareCursorLayout = `DECLARE {{.Name}} NO SCROLL CURSOR FOR {{.Query}}`
+	defaultFetchCursorLayout   = `FETCH FORWARD {{.FetchSize}} FROM {{.Name}}`
+	defaultCloseCursorLayout   = `CLOSE {{.Name}}`
+	defaultRollupLayout        = `ROLLUP({{.}})`
+	defaultCubeLayout          = `CUBE({{.}})`
+	defaultGroupingSetsLayout  = `GROUPING SETS ({{.}})`
+	defaultTupleInLayout       = `({{.Columns}}) {{if .Negate}}NOT {{end}}IN ({{.Values}})`
+	defaultValuesListLayout    = `(VALUES {{range $i, $r := .Rows}}{{if $i}}, {{end}}({{$r}}){{end}}){{if .Name}} AS {{.Name}}{{if .Columns}} ({{range $i, $c := .Columns}}{{if $i}}, {{end}}{{$c.Name}}{{end}}){{end}}{{end}}`
 
 	defaultOrderByLayout = `
     {{if .SortColumns}}
@@ -67,7 +80,9 @@ const (
 
 	defaultSelectLayout = `
     SELECT
-      {{if .Distinct}}
+      {{if .DistinctOn}}
+        {{.DistinctOn}}
+      {{else if .Distinct}}
         DISTINCT
       {{end}}
 
@@ -96,17 +111,46 @@ const (
       {{if .Offset}}
         OFFSET {{.Offset}}
       {{end}}
+
+      {{if .Lock}}
+        {{.Lock}}
+      {{end}}
   `
 	defaultDeleteLayout = `
     DELETE
       FROM {{.Table}}
       {{.Where}}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
+  `
+	defaultDeleteUsingLayout = `
+    DELETE
+      FROM {{.Table}}
+    USING {{.Sources}}
+      {{.Where}}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
   `
 	defaultUpdateLayout = `
     UPDATE
       {{.Table}}
     SET {{.ColumnValues}}
       {{ .Where }}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
+  `
+	defaultUpdateFromLayout = `
+    UPDATE
+      {{.Table}}
+    SET {{.ColumnValues}}
+    FROM {{.Sources}}
+      {{ .Where }}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
   `
 
 	defaultCountLayout = `
@@ -133,6 +177,7 @@ const (
     {{else}}
       (default)
     {{end}}
+    {{.OnConflict}}
     {{if .Returning}}
       RETURNING {{.Returning}}
     {{end}}
@@ -157,8 +202,29 @@ const (
       GROUP BY {{.GroupColumns}}
     {{end}}
   `
+
+	defaultCreateTableLayout      = `CREATE TABLE {{if .IfNotExists}}IF NOT EXISTS {{end}}{{.Table}} ({{.Definitions}}{{if .PrimaryKey}}, PRIMARY KEY ({{.PrimaryKey}}){{end}})`
+	defaultCreateIndexLayout      = `CREATE {{if .Unique}}UNIQUE {{end}}INDEX {{.Index}} ON {{.Table}} ({{.Columns}})`
+	defaultAddColumnLayout        = `ALTER TABLE {{.Table}} ADD COLUMN {{.Column}}`
+	defaultDropColumnLayout       = `ALTER TABLE {{.Table}} DROP COLUMN {{.Column}}`
+	defaultRenameColumnLayout     = `ALTER TABLE {{.Table}} RENAME COLUMN {{.Column}} TO {{.NewColumn}}`
+	defaultColumnDefinitionLayout = `{{.Name}} {{.Type}}{{if .NotNull}} NOT NULL{{end}}{{if .Default}} DEFAULT {{.Default}}{{end}}{{if .PrimaryKey}} PRIMARY KEY{{end}}{{if .Unique}} UNIQUE{{end}}`
 )
 
+var defaultColumnTypes = map[string]string{
+	"boolean":   `BOOLEAN`,
+	"integer":   `{{if .AutoIncrement}}SERIAL{{else}}INTEGER{{end}}`,
+	"bigint":    `{{if .AutoIncrement}}BIGSERIAL{{else}}BIGINT{{end}}`,
+	"float":     `DOUBLE PRECISION`,
+	"decimal":   `NUMERIC{{if .Size}}({{.Size}}, {{.Scale}}){{end}}`,
+	"string":    `VARCHAR({{.Size}})`,
+	"text":      `TEXT`,
+	"timestamp": `TIMESTAMP WITH TIME ZONE`,
+	"interval":  `INTERVAL`,
+	"json":      `JSONB`,
+	"enum":      `VARCHAR({{.Size}}) CHECK ({{.Name}} IN ({{.Values}}))`,
+}
+
 var testTemplate = exql.Template{
 	ColumnSeparator:     defaultColumnSeparator,
 	IdentifierSeparator: defaultIdentifierSeparator,
@@ -169,6 +235,7 @@ var testTemplate = exql.Template{
 	OrKeyword:           defaultOrKeyword,
 	NotKeyword:          defaultNotKeyword,
 	DescKeyword:         defaultDescKeyword,
+	DistinctOnLayout:    defaultDistinctOnLayout,
 	AscKeyword:          defaultAscKeyword,
 	DefaultOperator:     defaultDefaultOperator,
 	AssignmentOperator:  defaultAssignmentOperator,
@@ -179,18 +246,42 @@ var testTemplate = exql.Template{
 	ColumnAliasLayout:   defaultColumnAliasLayout,
 	SortByColumnLayout:  defaultSortByColumnLayout,
 	WhereLayout:         defaultWhereLayout,
+	WithLayout:          defaultWithLayout,
+	OnConflictLayout:    defaultOnConflictLayout,
+	ExcludedLayout:      defaultExcludedLayout,
 	OnLayout:            defaultOnLayout,
 	UsingLayout:         defaultUsingLayout,
+	ValuesListLayout:    defaultValuesListLayout,
 	JoinLayout:          defaultJoinLayout,
+	LockLayout:          defaultLockLayout,
+	DeclareCursorLayout: defaultDeclareCursorLayout,
+	FetchCursorLayout:   defaultFetchCursorLayout,
+	CloseCursorLayout:   defaultCloseCursorLayout,
 	OrderByLayout:       defaultOrderByLayout,
 	InsertLayout:        defaultInsertLayout,
 	SelectLayout:        defaultSelectLayout,
 	UpdateLayout:        defaultUpdateLayout,
+	UpdateFromLayout:    defaultUpdateFromLayout,
 	DeleteLayout:        defaultDeleteLayout,
+	DeleteUsingLayout:   defaultDeleteUsingLayout,
 	TruncateLayout:      defaultTruncateLayout,
+	TupleInLayout:       defaultTupleInLayout,
 	DropDatabaseLayout:  defaultDropDatabaseLayout,
 	DropTableLayout:     defaultDropTableLayout,
 	CountLayout:         defaultCountLayout,
 	GroupByLayout:       defaultGroupByLayout,
-	Cache:               cache.NewCache(),
+	RollupLayout:        defaultRollupLayout,
+	CubeLayout:          defaultCubeLayout,
+	GroupingSetsLayout:  defaultGroupingSetsLayout,
+
+	CreateTableLayout:      defaultCreateTableLayout,
+	CreateIndexLayout:      defaultCreateIndexLayout,
+	AddColumnLayout:        defaultAddColumnLayout,
+	DropColumnLayout:       defaultDropColumnLayout,
+	RenameColumnLayout:     defaultRenameColumnLayout,
+	ColumnDefinitionLayout: defaultColumnDefinitionLayout,
+	ColumnTypes:            defaultColumnTypes,
+	TrueKeyword:            `TRUE`,
+	FalseKeyword:           `FALSE`,
+	Cache:                  cache.NewCache(),
 }
@@ -0,0 +1,26 @@
+package sqlbuilder
+
+import (
+	"context"
+	"time"
+)
+
+// withTimeout returns a copy of ctx that expires after the given timeout, ctx
+// is returned as is if there's no timeout.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// withQueryTimeout is like withTimeout, but for queries whose rows are read
+// after returning, the context is released once the timeout expires.
+func withQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	if timeout <= 0 {
+		return ctx
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	time.AfterFunc(timeout, cancel)
+	return ctx
+}
@@ -3,6 +3,7 @@ package sqlbuilder
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"upper.io/db.v3/internal/immutable"
 	"upper.io/db.v3/internal/sqladapter/exql"
@@ -14,18 +15,31 @@ type updaterQuery struct {
 	columnValues     *exql.ColumnValues
 	columnValuesArgs []interface{}
 
+	sources     *exql.Columns
+	sourcesArgs []interface{}
+
 	limit int
 
 	where     *exql.Where
 	whereArgs []interface{}
 
+	with     *exql.With
+	withArgs []interface{}
+
+	returning []exql.Fragment
+
+	timeout time.Duration
+
 	err error
 
 	amendFn func(string) string
 }
 
 func (uq *updaterQuery) and(b *sqlBuilder, terms ...interface{}) error {
-	where, whereArgs := b.t.toWhereWithArguments(terms)
+	where, whereArgs, err := b.t.toWhere(terms)
+	if err != nil {
+		return err
+	}
 
 	if uq.where == nil {
 		uq.where, uq.whereArgs = &exql.Where{}, []interface{}{}
@@ -43,6 +57,10 @@ func (uq *updaterQuery) statement() *exql.Statement {
 		ColumnValues: uq.columnValues,
 	}
 
+	if uq.sources != nil {
+		stmt.Sources = uq.sources
+	}
+
 	if uq.where != nil {
 		stmt.Where = uq.where
 	}
@@ -51,14 +69,32 @@ func (uq *updaterQuery) statement() *exql.Statement {
 		stmt.Limit = exql.Limit(uq.limit)
 	}
 
+	if uq.with != nil {
+		stmt.With = uq.with
+	}
+
+	if len(uq.returning) > 0 {
+		stmt.Returning = exql.ReturningColumns(uq.returning...)
+	}
+
 	stmt.SetAmendment(uq.amendFn)
 
 	return stmt
 }
 
-func (uq *updaterQuery) arguments() []interface{} {
+func (uq *updaterQuery) arguments(t *exql.Template) []interface{} {
+	if t.UpdateSourcesFirst() {
+		return joinArguments(
+			uq.withArgs,
+			uq.sourcesArgs,
+			uq.columnValuesArgs,
+			uq.whereArgs,
+		)
+	}
 	return joinArguments(
+		uq.withArgs,
 		uq.columnValuesArgs,
+		uq.sourcesArgs,
 		uq.whereArgs,
 	)
 }
@@ -93,6 +129,9 @@ func (upd *updater) String() string {
 
 func (upd *updater) setTable(table string) *updater {
 	return upd.frame(func(uq *updaterQuery) error {
+		if err := upd.SQLBuilder().checkColumns(table); err != nil {
+			return err
+		}
 		uq.table = table
 		return nil
 	})
@@ -104,39 +143,37 @@ func (upd *updater) frame(fn func(*updaterQuery) error) *updater {
 
 func (upd *updater) Set(terms ...interface{}) Updater {
 	return upd.frame(func(uq *updaterQuery) error {
+		if err := upd.SQLBuilder().checkValues(terms...); err != nil {
+			return err
+		}
+		if err := upd.SQLBuilder().checkConditions(terms...); err != nil {
+			return err
+		}
 		if uq.columnValues == nil {
 			uq.columnValues = &exql.ColumnValues{}
 		}
 
-		if len(terms) == 1 {
-			ff, vv, err := Map(terms[0], nil)
-			if err == nil && len(ff) > 0 {
-				cvs := make([]exql.Fragment, 0, len(ff))
-				args := make([]interface{}, 0, len(vv))
-
-				for i := range ff {
-					cv := &exql.ColumnValue{
-						Column:   exql.ColumnWithName(ff[i]),
-						Operator: upd.SQLBuilder().t.AssignmentOperator,
-					}
-
-					var localArgs []interface{}
-					cv.Value, localArgs = upd.SQLBuilder().t.PlaceholderValue(vv[i])
-
-					args = append(args, localArgs...)
-					cvs = append(cvs, cv)
-				}
-
-				uq.columnValues.Insert(cvs...)
-				uq.columnValuesArgs = append(uq.columnValuesArgs, args...)
-
-				return nil
-			}
+		cvs, args, err := upd.SQLBuilder().setColumnValues(terms)
+		if err != nil {
+			return err
 		}
+		uq.columnValues.Insert(cvs...)
+		uq.columnValuesArgs = append(uq.columnValuesArgs, args...)
+		return nil
+	})
+}
 
-		cv, arguments := upd.SQLBuilder().t.toColumnValues(terms)
-		uq.columnValues.Insert(cv.ColumnValues...)
-		uq.columnValuesArgs = append(uq.columnValuesArgs, arguments...)
+func (upd *updater) From(tables ...interface{}) Updater {
+	return upd.frame(func(uq *updaterQuery) error {
+		if err := upd.SQLBuilder().checkIdentifiers(tables...); err != nil {
+			return err
+		}
+		fragments, args, err := columnFragments(tables)
+		if err != nil {
+			return err
+		}
+		uq.sources = exql.JoinColumns(fragments...)
+		uq.sourcesArgs = args
 		return nil
 	})
 }
@@ -153,11 +190,14 @@ func (upd *updater) Arguments() []interface{} {
 	if err != nil {
 		return nil
 	}
-	return uq.arguments()
+	return uq.arguments(upd.template())
 }
 
 func (upd *updater) Where(terms ...interface{}) Updater {
 	return upd.frame(func(uq *updaterQuery) error {
+		if err := upd.SQLBuilder().checkConditions(terms...); err != nil {
+			return err
+		}
 		uq.where, uq.whereArgs = &exql.Where{}, []interface{}{}
 		return uq.and(upd.SQLBuilder(), terms...)
 	})
@@ -165,6 +205,9 @@ func (upd *updater) Where(terms ...interface{}) Updater {
 
 func (upd *updater) And(terms ...interface{}) Updater {
 	return upd.frame(func(uq *updaterQuery) error {
+		if err := upd.SQLBuilder().checkConditions(terms...); err != nil {
+			return err
+		}
 		return uq.and(upd.SQLBuilder(), terms...)
 	})
 }
@@ -181,6 +224,13 @@ func (upd *updater) PrepareContext(ctx context.Context) (*sql.Stmt, error) {
 	return upd.SQLBuilder().sess.StatementPrepare(ctx, uq.statement())
 }
 
+func (upd *updater) Timeout(timeout time.Duration) Updater {
+	return upd.frame(func(uq *updaterQuery) error {
+		uq.timeout = timeout
+		return nil
+	})
+}
+
 func (upd *updater) Exec() (sql.Result, error) {
 	return upd.ExecContext(upd.SQLBuilder().sess.Context())
 }
@@ -190,7 +240,58 @@ func (upd *updater) ExecContext(ctx context.Context) (sql.Result, error) {
 	if err != nil {
 		return nil, err
 	}
-	return upd.SQLBuilder().sess.StatementExec(ctx, uq.statement(), uq.arguments()...)
+	ctx, cancel := withTimeout(ctx, uq.timeout)
+	defer cancel()
+	return upd.SQLBuilder().sess.StatementExec(ctx, uq.statement(), uq.arguments(upd.template())...)
+}
+
+func (upd *updater) Returning(columns ...string) Updater {
+	return upd.frame(func(uq *updaterQuery) error {
+		if err := upd.SQLBuilder().checkColumns(columns...); err != nil {
+			return err
+		}
+		columnsToFragments(&uq.returning, columns)
+		return nil
+	})
+}
+
+func (upd *updater) Output(columns ...string) Updater {
+	return upd.Returning(columns...)
+}
+
+func (upd *updater) Query() (*sql.Rows, error) {
+	return upd.QueryContext(upd.SQLBuilder().sess.Context())
+}
+
+func (upd *updater) QueryContext(ctx context.Context) (*sql.Rows, error) {
+	uq, err := upd.build()
+	if err != nil {
+		return nil, err
+	}
+	ctx = withQueryTimeout(ctx, uq.timeout)
+	return upd.SQLBuilder().sess.StatementQuery(ctx, uq.statement(), uq.arguments(upd.template())...)
+}
+
+func (upd *updater) QueryRow() (*sql.Row, error) {
+	return upd.QueryRowContext(upd.SQLBuilder().sess.Context())
+}
+
+func (upd *updater) QueryRowContext(ctx context.Context) (*sql.Row, error) {
+	uq, err := upd.build()
+	if err != nil {
+		return nil, err
+	}
+	ctx = withQueryTimeout(ctx, uq.timeout)
+	return upd.SQLBuilder().sess.StatementQueryRow(ctx, uq.statement(), uq.arguments(upd.template())...)
+}
+
+func (upd *updater) Iterator() Iterator {
+	return upd.IteratorContext(upd.SQLBuilder().sess.Context())
+}
+
+func (upd *updater) IteratorContext(ctx context.Context) Iterator {
+	rows, err := upd.QueryContext(ctx)
+	return upd.SQLBuilder().newIterator(rows, err)
 }
 
 func (upd *updater) Limit(limit int) Updater {
@@ -213,7 +314,11 @@ func (upd *updater) build() (*updaterQuery, error) {
 	if err != nil {
 		return nil, err
 	}
-	return uq.(*updaterQuery), nil
+	ret := uq.(*updaterQuery)
+	if ret.with, ret.withArgs, err = upd.SQLBuilder().withClause(); err != nil {
+		return nil, err
+	}
+	return ret, nil
 }
 
 func (upd *updater) Compile() (string, error) {
@@ -0,0 +1,142 @@
+package sqlbuilder
+
+import (
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+// Window represents a window function, like "ROW_NUMBER() OVER (PARTITION BY
+// ... ORDER BY ...)". Windows can be used as columns within Select():
+//
+//   q := sess.Select(
+//     "id",
+//     sqlbuilder.RowNumber().PartitionBy("category_id").OrderBy("-price").As("rank"),
+//   ).From("products")
+//
+// Like queries, windows are immutable, every method returns a new Window.
+type Window struct {
+	name        string
+	args        []interface{}
+	partitionBy []string
+	orderBy     []string
+	alias       string
+}
+
+// WindowFunc creates a Window that calls the given function. String arguments
+// are treated as column names, db.Raw values are used verbatim and any other
+// value is passed as an argument.
+//
+//   sqlbuilder.WindowFunc("NTILE", db.Raw("4")).OrderBy("score")
+func WindowFunc(name string, args ...interface{}) *Window {
+	return &Window{name: name, args: args}
+}
+
+// RowNumber creates a ROW_NUMBER() window function.
+func RowNumber() *Window {
+	return WindowFunc("ROW_NUMBER")
+}
+
+// Rank creates a RANK() window function.
+func Rank() *Window {
+	return WindowFunc("RANK")
+}
+
+// DenseRank creates a DENSE_RANK() window function.
+func DenseRank() *Window {
+	return WindowFunc("DENSE_RANK")
+}
+
+// Lag creates a LAG() window function that returns the value of the given
+// column on a previous row. The optional args are the offset and the default
+// value.
+func Lag(column string, args ...interface{}) *Window {
+	return WindowFunc("LAG", append([]interface{}{column}, args...)...)
+}
+
+// Lead creates a LEAD() window function that returns the value of the given
+// column on a following row. The optional args are the offset and the
+// default value.
+func Lead(column string, args ...interface{}) *Window {
+	return WindowFunc("LEAD", append([]interface{}{column}, args...)...)
+}
+
+// Sum creates a SUM() window function, when combined with OrderBy() it
+// produces a running sum of the given column.
+func Sum(column string) *Window {
+	return WindowFunc("SUM", column)
+}
+
+// PartitionBy represents the PARTITION BY clause of the window.
+func (w *Window) PartitionBy(columns ...string) *Window {
+	c := w.clone()
+	c.partitionBy = append(c.partitionBy, columns...)
+	return c
+}
+
+// OrderBy represents the ORDER BY clause of the window. Columns can be
+// prefixed with "-" to sort them in descending order, see Selector.OrderBy.
+func (w *Window) OrderBy(columns ...string) *Window {
+	c := w.clone()
+	c.orderBy = append(c.orderBy, columns...)
+	return c
+}
+
+// As sets an alias for the window function.
+func (w *Window) As(alias string) *Window {
+	c := w.clone()
+	c.alias = alias
+	return c
+}
+
+func (w *Window) clone() *Window {
+	c := *w
+	c.args = append([]interface{}(nil), w.args...)
+	c.partitionBy = append([]string(nil), w.partitionBy...)
+	c.orderBy = append([]string(nil), w.orderBy...)
+	return &c
+}
+
+// fragment converts the window into an exql fragment and the list of
+// arguments it requires.
+func (w *Window) fragment() (*exql.WindowFunction, []interface{}) {
+	f := &exql.WindowFunction{
+		Name:  w.name,
+		Alias: w.alias,
+	}
+
+	fnArgs := []interface{}{}
+
+	argFragments := make([]exql.Fragment, len(w.args))
+	for i := range w.args {
+		switch v := w.args[i].(type) {
+		case string:
+			argFragments[i] = exql.ColumnWithName(v)
+		case db.RawValue:
+			q, a := Preprocess(v.Raw(), v.Arguments())
+			argFragments[i] = exql.RawValue(q)
+			fnArgs = append(fnArgs, a...)
+		default:
+			argFragments[i] = sqlPlaceholder
+			fnArgs = append(fnArgs, v)
+		}
+	}
+	f.Args = exql.JoinColumns(argFragments...)
+
+	if len(w.partitionBy) > 0 {
+		columns := make([]exql.Fragment, len(w.partitionBy))
+		for i := range w.partitionBy {
+			columns[i] = exql.ColumnWithName(w.partitionBy[i])
+		}
+		f.PartitionBy = exql.JoinColumns(columns...)
+	}
+
+	if len(w.orderBy) > 0 {
+		sortColumns := make([]exql.Fragment, len(w.orderBy))
+		for i := range w.orderBy {
+			sortColumns[i] = sortColumn(w.orderBy[i])
+		}
+		f.OrderBy = exql.JoinWithOrderBy(exql.JoinSortColumns(sortColumns...))
+	}
+
+	return f, fnArgs
+}
@@ -0,0 +1,104 @@
+package sqlbuilder
+
+import (
+	"fmt"
+
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+type commonTableExpression struct {
+	name      string
+	recursive bool
+	sel       Selector
+}
+
+func (b *sqlBuilder) With(name string, sel Selector) SQLBuilder {
+	return b.pushCTE(name, sel, false)
+}
+
+func (b *sqlBuilder) WithRecursive(name string, sel Selector) SQLBuilder {
+	return b.pushCTE(name, sel, true)
+}
+
+func (b *sqlBuilder) pushCTE(name string, sel Selector, recursive bool) *sqlBuilder {
+	ctes := make([]*commonTableExpression, len(b.ctes), len(b.ctes)+1)
+	copy(ctes, b.ctes)
+
+	return &sqlBuilder{
+		sess: b.sess,
+		t:    b.t,
+		ctes: append(ctes, &commonTableExpression{
+			name:      name,
+			recursive: recursive,
+			sel:       sel,
+		}),
+	}
+}
+
+// mergeCTEs returns a builder with the common table expressions of b followed
+// by the ones of other that b doesn't have. Different expressions with the
+// same name can't be merged.
+func (b *sqlBuilder) mergeCTEs(other *sqlBuilder) (*sqlBuilder, error) {
+	if len(other.ctes) == 0 {
+		return b, nil
+	}
+
+	ctes := make([]*commonTableExpression, len(b.ctes), len(b.ctes)+len(other.ctes))
+	copy(ctes, b.ctes)
+
+next:
+	for _, cte := range other.ctes {
+		for _, c := range ctes {
+			if c.name == cte.name {
+				if c != cte {
+					return nil, fmt.Errorf("Cannot combine queries with different WITH expressions named %q.", cte.name)
+				}
+				continue next
+			}
+		}
+		ctes = append(ctes, cte)
+	}
+
+	return &sqlBuilder{
+		sess: b.sess,
+		t:    b.t,
+		ctes: ctes,
+	}, nil
+}
+
+// withClause compiles all the common table expressions that were attached to
+// the builder into a WITH clause.
+func (b *sqlBuilder) withClause() (*exql.With, []interface{}, error) {
+	if len(b.ctes) == 0 {
+		return nil, nil, nil
+	}
+
+	recursive := false
+	clauses := make([]exql.Fragment, 0, len(b.ctes))
+	args := []interface{}{}
+
+	for _, cte := range b.ctes {
+		c, ok := cte.sel.(compilable)
+		if !ok {
+			return nil, nil, fmt.Errorf("Unsupported query type %T for WITH clause.", cte.sel)
+		}
+
+		compiled, err := c.Compile()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		q, a := Preprocess(compiled, c.Arguments())
+		clauses = append(clauses, &exql.CommonTableExpression{
+			Name:  cte.name,
+			Query: exql.RawValue(q),
+		})
+		args = append(args, a...)
+
+		if cte.recursive {
+			recursive = true
+		}
+	}
+
+	return exql.WithClauses(recursive, clauses...), args, nil
+}
@@ -26,6 +26,7 @@ import (
 	"database/sql"
 	"fmt"
 	"sync"
+	"time"
 
 	"upper.io/db.v3"
 )
@@ -66,6 +67,60 @@ type Tx interface {
 	// as default. Copies are safe to use concurrently but they're backed by the
 	// same *sql.Tx, so any copy may commit or rollback the parent transaction.
 	WithContext(context.Context) Tx
+
+	// OnCommit registers a function that is called once the transaction is
+	// committed, so side effects like invalidating caches or publishing events
+	// only happen if the changes of the transaction were saved:
+	//
+	//	sess.Tx(ctx, func(tx sqlbuilder.Tx) error {
+	//		// ...
+	//		tx.OnCommit(func() {
+	//			events.Publish("order.created", order.ID)
+	//		})
+	//		return nil
+	//	})
+	//
+	// Functions are called in the order they were registered, after the
+	// transaction is closed.
+	OnCommit(fn func())
+
+	// OnRollback registers a function that is called once the transaction is
+	// rolled back. The function is given the error that caused the rollback:
+	// the error returned by the function passed to Tx or the error of a
+	// failed Commit, or nil when Rollback is called explicitly.
+	OnRollback(fn func(err error))
+
+	// Savepoint marks the current state of the transaction with the given
+	// name, the statements that run after it can be undone with RollbackTo
+	// without rolling back the whole transaction, like the rows that fail to
+	// be inserted by a bulk loader:
+	//
+	//	for _, item := range items {
+	//		if err := tx.Savepoint("item"); err != nil {
+	//			return err
+	//		}
+	//		if _, err := tx.Collection("items").Insert(item); err != nil {
+	//			if err := tx.RollbackTo("item"); err != nil {
+	//				return err
+	//			}
+	//			continue
+	//		}
+	//		if err := tx.ReleaseSavepoint("item"); err != nil {
+	//			return err
+	//		}
+	//	}
+	//
+	// Names must be made of letters, digits or underscores.
+	Savepoint(name string) error
+
+	// RollbackTo undoes the statements that ran after the savepoint with the
+	// given name was created, the savepoint is kept so it can be rolled back
+	// to again.
+	RollbackTo(name string) error
+
+	// ReleaseSavepoint forgets the savepoint with the given name, the
+	// statements that ran after it are kept.
+	ReleaseSavepoint(name string) error
 }
 
 // Database represents a SQL database.
@@ -99,6 +154,125 @@ type Database interface {
 	// same *sql.DB. You may close a copy at any point but that won't close the
 	// parent session.
 	WithContext(context.Context) Database
+
+	// WithSchema returns a session whose collections resolve against the given
+	// schema (or database, on MySQL), like:
+	//
+	//	tenant, err := sess.WithSchema("tenant_123")
+	//	...
+	//	err = tenant.Collection("accounts").Find().All(&accounts)
+	//
+	// Schema names may only contain letters, digits, underscores, dollar signs
+	// and hyphens. The returned session shares the connection pool of the
+	// parent session and is reused by later calls with the same schema, it
+	// must not be closed.
+	WithSchema(schema string) (Database, error)
+
+	// WithRecordCache returns a copy of the session whose primary key lookups,
+	// like col.Find(id).One(&item), read items from the given store before
+	// querying the database, items are kept for the given time:
+	//
+	//	cached, err := sess.WithRecordCache(db.NewMemoryQueryCache(10000), time.Hour)
+	//	...
+	//	err = cached.Collection("accounts").Find(id).One(&account)
+	//
+	// Updates and deletes of a single item through the collections of the
+	// copy replace or drop the cached item, other writes of the copy, like the
+	// ones of the SQL builder, drop all the cached items of the table they
+	// modify (all tables for raw SQL). Transactions don't read cached items
+	// and modify them once they're committed. See
+	// db.Metrics.RecordCacheHitRatio.
+	WithRecordCache(store db.QueryCache, ttl time.Duration) (Database, error)
+
+	// InvalidateRecordCache drops all the items of the given collection from
+	// the record cache of the session, this is only required when rows are
+	// modified by other sessions.
+	InvalidateRecordCache(collection string)
+
+	// Metrics returns a snapshot of the connection pool statistics, query
+	// counters and latencies by statement kind, and transaction counters of
+	// this session.
+	Metrics() db.Metrics
+
+	// Stats returns a report of the statements this session executed grouped
+	// by fingerprint, with their counts, latencies and rows, see db.Stats.
+	// Statistics are collected once enabled with SetQueryStats.
+	Stats() db.Stats
+
+	// ResetStats drops the statistics returned by Stats.
+	ResetStats()
+
+	// PingContext returns an error if the database server can't be reached
+	// within the given context.
+	PingContext(ctx context.Context) error
+
+	// AutoMigrate compares the given structs with the tables of the database
+	// and makes the changes that can't lose data or break existing rows: it
+	// creates the tables that don't exist, adds the nullable columns that are
+	// missing and creates the missing indexes. Columns are never dropped or
+	// altered, and missing columns that are NOT NULL are reported as errors,
+	// since the rows that exist would have no value for them.
+	//
+	//	err := sess.AutoMigrate(&User{}, &Order{})
+	//
+	// Tables are named after their structs, see db.TableNamer, and columns
+	// are defined like with CreateTable().FromStruct(). Fields declare indexes
+	// with the index and unique options, like `db:"email,unique"`, which are
+	// named after the table and the column (like "users_email_key") unless
+	// they set a name, like `db:"zip,index=users_address_idx"`. Fields that
+	// set the same name make up a single index.
+	AutoMigrate(items ...interface{}) error
+
+	// AutoMigrateDryRun returns the statements AutoMigrate would run for the
+	// given structs without running them, so they can be reviewed first.
+	AutoMigrateDryRun(items ...interface{}) ([]string, error)
+}
+
+// DistributedTx is a transaction that takes part in a two-phase commit among
+// many databases. Once every transaction is prepared with PrepareCommit, they
+// can all be committed (or rolled back) with the guarantee that none of them
+// will fail to commit:
+//
+//	tx1, _ := pgSess.(sqlbuilder.DistributedTxSession).NewDistributedTx(ctx, "transfer-42")
+//	tx2, _ := mySess.(sqlbuilder.DistributedTxSession).NewDistributedTx(ctx, "transfer-42")
+//	// ... write on tx1 and tx2
+//	if err := tx1.PrepareCommit(); err != nil { /* roll back both */ }
+//	if err := tx2.PrepareCommit(); err != nil { /* roll back both */ }
+//	tx1.Commit()
+//	tx2.Commit()
+//
+// Prepared transactions survive crashes of the application and of the
+// database server, the ones that were left behind can be listed with
+// PreparedTransactions and finished with CommitPrepared or RollbackPrepared.
+type DistributedTx interface {
+	Tx
+
+	// GID returns the global identifier of the transaction.
+	GID() string
+
+	// PrepareCommit ends the first phase of the commit, no more statements
+	// can run on the transaction after it's prepared. Commit and Rollback
+	// finish the prepared transaction.
+	PrepareCommit() error
+}
+
+// DistributedTxSession is implemented by the sessions of databases that
+// support two-phase commits, like PostgreSQL and MySQL.
+type DistributedTxSession interface {
+	// NewDistributedTx begins a transaction identified by gid, which must be
+	// unique among the transactions that are prepared and made only of
+	// letters, digits, underscores, hyphens, dots and colons.
+	NewDistributedTx(ctx context.Context, gid string) (DistributedTx, error)
+
+	// PreparedTransactions returns the identifiers of the transactions that
+	// were prepared but not committed or rolled back yet.
+	PreparedTransactions() ([]string, error)
+
+	// CommitPrepared commits the prepared transaction identified by gid.
+	CommitPrepared(gid string) error
+
+	// RollbackPrepared rolls back the prepared transaction identified by gid.
+	RollbackPrepared(gid string) error
 }
 
 // AdapterFuncMap is a struct that defines a set of functions that adapters
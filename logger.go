@@ -22,6 +22,7 @@
 package db
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"regexp"
@@ -62,6 +63,11 @@ type QueryStatus struct {
 	End   time.Time
 }
 
+// Duration returns the time it took to execute the query.
+func (q *QueryStatus) Duration() time.Duration {
+	return q.End.Sub(q.Start)
+}
+
 // String returns a formatted log message.
 func (q *QueryStatus) String() string {
 	lines := make([]string, 0, 8)
@@ -95,7 +101,7 @@ func (q *QueryStatus) String() string {
 		lines = append(lines, fmt.Sprintf(fmtLogError, q.Err))
 	}
 
-	lines = append(lines, fmt.Sprintf(fmtLogTimeTaken, float64(q.End.UnixNano()-q.Start.UnixNano())/float64(1e9)))
+	lines = append(lines, fmt.Sprintf(fmtLogTimeTaken, q.Duration().Seconds()))
 
 	return strings.Join(lines, "\n")
 }
@@ -103,6 +109,10 @@ func (q *QueryStatus) String() string {
 // EnvEnableDebug can be used by adapters to determine if the user has enabled
 // debugging.
 //
+// Setting a Logger on a session with SetLogger and SetLogging is the preferred
+// way to collect queries, the environment variable only enables the default
+// logger on DefaultSettings.
+//
 // If the user sets the `UPPERIO_DB_DEBUG` environment variable to a
 // non-empty value, all generated statements will be printed at runtime to
 // the standard logger.
@@ -123,6 +133,67 @@ type Logger interface {
 	Log(*QueryStatus)
 }
 
+// QueryLogger is an optional interface a Logger can implement to receive the
+// context the query was executed with, this is useful to pick a request-scoped
+// logger (like a zap or zerolog instance) or to read values like request IDs.
+// When a Logger implements QueryLogger, LogQuery is called instead of Log.
+//
+// Example:
+//
+//	type ctxLogger struct{}
+//
+//	func (ctxLogger) Log(q *db.QueryStatus) {
+//	  log.Print(q)
+//	}
+//
+//	func (ctxLogger) LogQuery(ctx context.Context, q *db.QueryStatus) {
+//	  requestLogger(ctx).Info(q.Query, "args", q.Args, "duration", q.Duration(), "err", q.Err)
+//	}
+//
+//	sess.SetLogger(ctxLogger{})
+//	sess.SetLogging(true)
+type QueryLogger interface {
+	LogQuery(context.Context, *QueryStatus)
+}
+
+// LogQuery sends the given QueryStatus to the logger, using LogQuery if the
+// logger implements QueryLogger and Log otherwise.
+func LogQuery(ctx context.Context, lg Logger, q *QueryStatus) {
+	if ql, ok := lg.(QueryLogger); ok {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ql.LogQuery(ctx, q)
+		return
+	}
+	lg.Log(q)
+}
+
+// SlowQuery describes a statement that took longer than the slow query
+// threshold of the session, see Settings.SetSlowQueryThreshold.
+type SlowQuery struct {
+	QueryStatus
+
+	// Threshold is the slow query threshold the statement exceeded.
+	Threshold time.Duration
+
+	// Stack is the call stack the statement was executed from, starting at
+	// the first caller outside of this package and its adapters.
+	Stack string
+}
+
+// SlowQueryHandler is called with the statements that exceed the slow query
+// threshold of a session, ctx is the context they were executed with.
+type SlowQueryHandler func(ctx context.Context, q *SlowQuery)
+
+func logSlowQuery(ctx context.Context, q *SlowQuery) {
+	log.Printf("\n\tSlow query (over %v):\n\t%s\n\t%s\n\n",
+		q.Threshold,
+		strings.Replace(q.QueryStatus.String(), "\n", "\n\t", -1),
+		strings.Replace(q.Stack, "\n", "\n\t", -1),
+	)
+}
+
 type defaultLogger struct {
 }
 
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type testCtxKey struct{}
+
+type testLogger struct {
+	logged  []*QueryStatus
+	queried []*QueryStatus
+	values  []interface{}
+}
+
+func (lg *testLogger) Log(q *QueryStatus) {
+	lg.logged = append(lg.logged, q)
+}
+
+type testQueryLogger struct {
+	testLogger
+}
+
+func (lg *testQueryLogger) LogQuery(ctx context.Context, q *QueryStatus) {
+	lg.queried = append(lg.queried, q)
+	lg.values = append(lg.values, ctx.Value(testCtxKey{}))
+}
+
+func TestQueryStatusDuration(t *testing.T) {
+	start := time.Now()
+	q := &QueryStatus{Start: start, End: start.Add(1500 * time.Millisecond)}
+
+	if q.Duration() != 1500*time.Millisecond {
+		t.Fatalf("Got: %v, Expecting: %v", q.Duration(), 1500*time.Millisecond)
+	}
+}
+
+func TestLogQuery(t *testing.T) {
+	ctx := context.WithValue(context.Background(), testCtxKey{}, "request-1")
+	q := &QueryStatus{Query: "SELECT 1"}
+
+	lg := &testLogger{}
+	LogQuery(ctx, lg, q)
+	if len(lg.logged) != 1 || lg.logged[0] != q {
+		t.Fatal("Expecting Log to be called")
+	}
+
+	qlg := &testQueryLogger{}
+	LogQuery(ctx, qlg, q)
+	if len(qlg.logged) != 0 {
+		t.Fatal("Expecting Log not to be called")
+	}
+	if len(qlg.queried) != 1 || qlg.queried[0] != q {
+		t.Fatal("Expecting LogQuery to be called")
+	}
+	if qlg.values[0] != "request-1" {
+		t.Fatalf("Got: %v, Expecting: %v", qlg.values[0], "request-1")
+	}
+
+	LogQuery(nil, qlg, q)
+	if len(qlg.queried) != 2 || qlg.values[1] != nil {
+		t.Fatal("Expecting LogQuery to be called with a background context")
+	}
+}
+
+func TestSettingsLogger(t *testing.T) {
+	s := NewSettings()
+
+	if _, ok := s.Logger().(*defaultLogger); !ok {
+		t.Fatal("Expecting the default logger")
+	}
+
+	lg := &testQueryLogger{}
+	s.SetLogger(lg)
+	if s.Logger() != Logger(lg) {
+		t.Fatal("Expecting the custom logger")
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"sort"
+	"time"
+)
+
+// Statement kinds used as keys in Metrics.Queries.
+const (
+	QueryKindSelect = "select"
+	QueryKindInsert = "insert"
+	QueryKindUpdate = "update"
+	QueryKindDelete = "delete"
+	QueryKindOther  = "other"
+)
+
+// QueryMetrics holds counters for a kind of statement.
+type QueryMetrics struct {
+	// Count is the number of statements that were executed.
+	Count uint64
+	// Errors is the number of statements that returned an error.
+	Errors uint64
+	// TotalDuration is the time spent executing statements.
+	TotalDuration time.Duration
+	// MaxDuration is the time taken by the slowest statement.
+	MaxDuration time.Duration
+}
+
+// AverageDuration returns the mean time it took to execute a statement.
+func (q QueryMetrics) AverageDuration() time.Duration {
+	if q.Count == 0 {
+		return 0
+	}
+	return q.TotalDuration / time.Duration(q.Count)
+}
+
+// Metrics is a snapshot of the connection pool statistics and query counters
+// of a session. Counters are shared by a session, its copies and the
+// transactions created from it, they only increase and can be exported as
+// Prometheus counters or gauges.
+//
+// Example:
+//
+//	m := sess.Metrics()
+//	openConns.Set(float64(m.OpenConnections))
+//	waitSeconds.Set(m.WaitDuration.Seconds())
+//	for kind, q := range m.Queries {
+//	  queries.WithLabelValues(kind).Set(float64(q.Count))
+//	}
+type Metrics struct {
+	// OpenConnections is the number of established connections, both in use
+	// and idle.
+	OpenConnections int
+	// InUse is the number of connections currently in use.
+	InUse int
+	// Idle is the number of idle connections.
+	Idle int
+	// WaitCount is the number of times a query had to wait for a connection.
+	WaitCount int64
+	// WaitDuration is the total time spent waiting for a connection.
+	WaitDuration time.Duration
+
+	// Queries maps statement kinds (QueryKindSelect, QueryKindInsert, etc.) to
+	// their counters.
+	Queries map[string]QueryMetrics
+
+	// TxCommits is the number of transactions that were committed.
+	TxCommits uint64
+	// TxRollbacks is the number of transactions that were rolled back.
+	TxRollbacks uint64
+
+	// RecordCacheHits is the number of primary key lookups that were read
+	// from the record cache.
+	RecordCacheHits uint64
+	// RecordCacheMisses is the number of primary key lookups that were not
+	// found in the record cache.
+	RecordCacheMisses uint64
+}
+
+// RecordCacheHitRatio returns the fraction of primary key lookups that were
+// read from the record cache, or zero if there were none.
+func (m Metrics) RecordCacheHitRatio() float64 {
+	total := m.RecordCacheHits + m.RecordCacheMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(m.RecordCacheHits) / float64(total)
+}
+
+// QueryStats holds the statistics of the statements that share a
+// fingerprint, see Stats.
+type QueryStats struct {
+	// Fingerprint is the statement with its literals and placeholders replaced
+	// by "?" and its lists of values collapsed, like
+	// `SELECT * FROM "users" WHERE ("id" IN (...))`.
+	Fingerprint string
+	// Count is the number of statements that were executed.
+	Count uint64
+	// Errors is the number of statements that returned an error.
+	Errors uint64
+	// Rows is the number of rows read from queries with iterators plus the
+	// number of rows affected by other statements.
+	Rows uint64
+	// TotalDuration is the time spent executing statements.
+	TotalDuration time.Duration
+	// MinDuration is the time taken by the fastest statement.
+	MinDuration time.Duration
+	// MaxDuration is the time taken by the slowest statement.
+	MaxDuration time.Duration
+	// P50Duration, P95Duration and P99Duration are percentiles of the time
+	// taken by the most recent statements.
+	P50Duration time.Duration
+	P95Duration time.Duration
+	P99Duration time.Duration
+}
+
+// MeanDuration returns the mean time it took to execute a statement.
+func (q QueryStats) MeanDuration() time.Duration {
+	if q.Count == 0 {
+		return 0
+	}
+	return q.TotalDuration / time.Duration(q.Count)
+}
+
+// Stats is a report of the statements a session executed grouped by
+// fingerprint, like pg_stat_statements on the client side. Statistics are
+// only collected when enabled with Settings.SetQueryStats, they're shared by
+// a session, its copies and the transactions created from it.
+//
+// Example:
+//
+//	sess.SetQueryStats(true)
+//	...
+//	for _, q := range sess.Stats().TopByTotalDuration(10) {
+//	  log.Printf("%8d %10v %10v %s", q.Count, q.MeanDuration(), q.P99Duration, q.Fingerprint)
+//	}
+type Stats struct {
+	// Since is the time statistics started to be collected or were last
+	// reset.
+	Since time.Time
+	// Queries holds the statistics of each fingerprint, by descending total
+	// duration.
+	Queries []QueryStats
+}
+
+// TopByCount returns the n fingerprints that were executed the most.
+func (s Stats) TopByCount(n int) []QueryStats {
+	return s.top(n, func(a, b QueryStats) bool {
+		return a.Count > b.Count
+	})
+}
+
+// TopByTotalDuration returns the n fingerprints that took the most time
+// overall.
+func (s Stats) TopByTotalDuration(n int) []QueryStats {
+	return s.top(n, func(a, b QueryStats) bool {
+		return a.TotalDuration > b.TotalDuration
+	})
+}
+
+// TopByMeanDuration returns the n fingerprints that took the most time per
+// statement.
+func (s Stats) TopByMeanDuration(n int) []QueryStats {
+	return s.top(n, func(a, b QueryStats) bool {
+		return a.MeanDuration() > b.MeanDuration()
+	})
+}
+
+// TopByRows returns the n fingerprints that read or affected the most rows.
+func (s Stats) TopByRows(n int) []QueryStats {
+	return s.top(n, func(a, b QueryStats) bool {
+		return a.Rows > b.Rows
+	})
+}
+
+func (s Stats) top(n int, less func(a, b QueryStats) bool) []QueryStats {
+	queries := append([]QueryStats(nil), s.Queries...)
+	sort.SliceStable(queries, func(i, j int) bool {
+		return less(queries[i], queries[j])
+	})
+	if n >= 0 && n < len(queries) {
+		queries = queries[:n]
+	}
+	return queries
+}
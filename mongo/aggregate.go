@@ -0,0 +1,180 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// Accumulator computes a value for every group of a $group stage, see Sum,
+// Avg, Min, Max, First, Last and Push.
+type Accumulator bson.M
+
+// Sum returns a $sum accumulator, Sum(1) counts the documents of the group.
+func Sum(expr interface{}) Accumulator {
+	return Accumulator{"$sum": expr}
+}
+
+// Avg returns an $avg accumulator.
+func Avg(expr interface{}) Accumulator {
+	return Accumulator{"$avg": expr}
+}
+
+// Min returns a $min accumulator.
+func Min(expr interface{}) Accumulator {
+	return Accumulator{"$min": expr}
+}
+
+// Max returns a $max accumulator.
+func Max(expr interface{}) Accumulator {
+	return Accumulator{"$max": expr}
+}
+
+// First returns a $first accumulator.
+func First(expr interface{}) Accumulator {
+	return Accumulator{"$first": expr}
+}
+
+// Last returns a $last accumulator.
+func Last(expr interface{}) Accumulator {
+	return Accumulator{"$last": expr}
+}
+
+// Push returns a $push accumulator.
+func Push(expr interface{}) Accumulator {
+	return Accumulator{"$push": expr}
+}
+
+// Aggregation represents an aggregation pipeline, stages run in the order
+// they were added:
+//
+//	iter := sess.Collection("sales").(*mongo.Collection).Aggregate().
+//		Match(db.Cond{"year >=": 2020}).
+//		Group("$artist_id", map[string]mongo.Accumulator{
+//			"total": mongo.Sum("$amount"),
+//		}).
+//		Sort("-total").
+//		Limit(10).
+//		Iterator()
+//
+// Field paths within expressions are prefixed with $, like "$amount".
+type Aggregation struct {
+	col    *Collection
+	stages []bson.D
+	err    error
+}
+
+// Aggregate creates an empty aggregation pipeline on the collection.
+func (col *Collection) Aggregate() *Aggregation {
+	return &Aggregation{col: col}
+}
+
+func (a *Aggregation) stage(name string, value interface{}) *Aggregation {
+	a.stages = append(a.stages, bson.D{{Key: name, Value: value}})
+	return a
+}
+
+// Match adds a $match stage, it accepts the same conditions as
+// Collection.Find.
+func (a *Aggregation) Match(terms ...interface{}) *Aggregation {
+	return a.stage("$match", a.col.compileQuery(terms...))
+}
+
+// Group adds a $group stage that groups documents by the id expression, use
+// nil to compute the accumulators over all documents.
+func (a *Aggregation) Group(id interface{}, fields map[string]Accumulator) *Aggregation {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	group := bson.D{{Key: "_id", Value: id}}
+	for _, name := range names {
+		group = append(group, bson.E{Key: name, Value: fields[name]})
+	}
+	return a.stage("$group", group)
+}
+
+// Lookup adds a $lookup stage, documents of the from collection whose
+// foreignField equals localField are added to the as array field.
+func (a *Aggregation) Lookup(from, localField, foreignField, as string) *Aggregation {
+	return a.stage("$lookup", bson.D{
+		{Key: "from", Value: from},
+		{Key: "localField", Value: localField},
+		{Key: "foreignField", Value: foreignField},
+		{Key: "as", Value: as},
+	})
+}
+
+// Sort adds a $sort stage, fields may be prefixed by - (minus) to sort them
+// in descending order, like in Result.OrderBy.
+func (a *Aggregation) Sort(fields ...string) *Aggregation {
+	doc, err := sortDocument(fields)
+	if err != nil {
+		a.err = err
+		return a
+	}
+	return a.stage("$sort", doc)
+}
+
+// Limit adds a $limit stage.
+func (a *Aggregation) Limit(n int) *Aggregation {
+	return a.stage("$limit", int64(n))
+}
+
+// String returns the pipeline in the syntax of the mongo shell.
+func (a *Aggregation) String() string {
+	buf := bytes.NewBufferString("aggregate([")
+	for i := range a.stages {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		stage, err := bson.MarshalExtJSON(a.stages[i], false, false)
+		if err != nil {
+			panic(err.Error())
+		}
+		buf.Write(stage)
+	}
+	buf.WriteString("])")
+	return buf.String()
+}
+
+// Iterator runs the pipeline and returns an iterator over the resulting
+// documents.
+func (a *Aggregation) Iterator() sqlbuilder.Iterator {
+	return a.IteratorContext(a.col.parent.Context())
+}
+
+// IteratorContext is like Iterator, but the pipeline runs within the given
+// context.
+func (a *Aggregation) IteratorContext(ctx context.Context) sqlbuilder.Iterator {
+	if a.err != nil {
+		return &iterator{ctx: ctx, err: a.err}
+	}
+
+	var (
+		cursor *mongo.Cursor
+		err    error
+	)
+
+	if a.col.parent.LoggingEnabled() {
+		defer func(start time.Time) {
+			db.LogQuery(ctx, a.col.parent.Logger(), &db.QueryStatus{
+				Query: a.String(),
+				Err:   err,
+				Start: start,
+				End:   time.Now(),
+			})
+		}(time.Now())
+	}
+
+	cursor, err = a.col.collection.Aggregate(ctx, mongo.Pipeline(a.stages))
+	return &iterator{ctx: ctx, cursor: cursor, err: err}
+}
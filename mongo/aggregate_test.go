@@ -0,0 +1,85 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+
+	"upper.io/db.v3"
+)
+
+func TestAggregatePipeline(t *testing.T) {
+	col := &Collection{}
+
+	a := col.Aggregate().
+		Match(db.Cond{"year >=": 2000}).
+		Lookup("artist", "artist_id", "_id", "artist").
+		Group("$artist_id", map[string]Accumulator{
+			"total": Sum("$amount"),
+			"count": Sum(1),
+		}).
+		Sort("-total", "_id").
+		Limit(3)
+
+	expected := `aggregate([` +
+		`{"$match":{"year":{"$gte":2000}}}, ` +
+		`{"$lookup":{"from":"artist","localField":"artist_id","foreignField":"_id","as":"artist"}}, ` +
+		`{"$group":{"_id":"$artist_id","count":{"$sum":1},"total":{"$sum":"$amount"}}}, ` +
+		`{"$sort":{"total":-1,"_id":1}}, ` +
+		`{"$limit":3}` +
+		`])`
+
+	if a.String() != expected {
+		t.Fatalf("Got: %s, Expecting: %s", a.String(), expected)
+	}
+
+	if err := col.Aggregate().Sort("-").IteratorContext(context.Background()).Err(); err == nil {
+		t.Fatalf("Expecting an error.")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	sess, err := Open(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sess.Close()
+
+	artist := sess.Collection("artist").(*Collection)
+
+	total, err := artist.Find().Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result struct {
+		Count uint64 `bson:"count"`
+	}
+	err = artist.Aggregate().
+		Match(db.Cond{"name $ne": ""}).
+		Group(nil, map[string]Accumulator{"count": Sum(1)}).
+		Iterator().
+		One(&result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != total {
+		t.Fatalf("Expecting %d documents, got %d.", total, result.Count)
+	}
+
+	var names []string
+	iter := artist.Aggregate().Sort("name").Limit(2).Iterator()
+	for iter.Next() {
+		var id interface{}
+		var name string
+		if err := iter.Scan(&id, &name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] > names[1] {
+		t.Fatalf("Expecting two sorted names, got %v.", names)
+	}
+}
@@ -0,0 +1,85 @@
+package mongo
+
+import (
+	"fmt"
+	"reflect"
+
+	"upper.io/db.v3"
+)
+
+const defaultBulkBatchSize = 1000
+
+type bulkInserter struct {
+	col  *Collection
+	size int
+}
+
+var _ = db.BulkInserter(&bulkInserter{})
+
+// BulkInsert returns a BulkInserter for the collection.
+func (col *Collection) BulkInsert() db.BulkInserter {
+	return &bulkInserter{col: col, size: defaultBulkBatchSize}
+}
+
+// BatchSize sets the maximum number of documents per batch.
+func (b *bulkInserter) BatchSize(size int) db.BulkInserter {
+	if size < 1 {
+		size = 1
+	}
+	clone := *b
+	clone.size = size
+	return &clone
+}
+
+// Insert loads the given slice or channel of documents in batches.
+func (b *bulkInserter) Insert(items interface{}) error {
+	var (
+		docs   []interface{}
+		batch  int
+		offset int
+	)
+
+	flush := func() error {
+		if len(docs) == 0 {
+			return nil
+		}
+		if _, err := b.col.collection.InsertMany(b.col.parent.Context(), docs); err != nil {
+			return &db.BatchError{Batch: batch, Offset: offset, Size: len(docs), Err: err}
+		}
+		batch++
+		offset += len(docs)
+		docs = nil
+		return nil
+	}
+
+	itemsV := reflect.ValueOf(items)
+
+	switch itemsV.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < itemsV.Len(); i++ {
+			docs = append(docs, itemsV.Index(i).Interface())
+			if len(docs) >= b.size {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Chan:
+		for {
+			item, ok := itemsV.Recv()
+			if !ok {
+				break
+			}
+			docs = append(docs, item.Interface())
+			if len(docs) >= b.size {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("Expecting a slice or a channel but got %T", items)
+	}
+
+	return flush()
+}
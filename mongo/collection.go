@@ -28,8 +28,9 @@ import (
 
 	"reflect"
 
-	"gopkg.in/mgo.v2"
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"upper.io/db.v3"
 )
 
@@ -37,7 +38,7 @@ import (
 type Collection struct {
 	name       string
 	parent     *Source
-	collection *mgo.Collection
+	collection *mongo.Collection
 }
 
 type chunks struct {
@@ -76,22 +77,40 @@ func (col *Collection) Find(terms ...interface{}) db.Result {
 	return r
 }
 
-// compileStatement transforms conditions into something *mgo.Session can
-// understand.
+// compileStatement transforms conditions into a filter document.
 func compileStatement(cond db.Cond) bson.M {
 	conds := bson.M{}
 
 	// Walking over conditions
 	for fieldI, value := range cond {
-		// Removing leading or trailing spaces.
-		field := strings.TrimSpace(fmt.Sprintf("%v", fieldI))
+		var chunks []string
 
-		chunks := strings.SplitN(field, ` `, 2)
+		if comparison, ok := fieldI.(db.Comparison); ok {
+			chunks = []string{comparison.Column, comparison.Operator}
+		} else {
+			// Removing leading or trailing spaces.
+			field := strings.TrimSpace(fmt.Sprintf("%v", fieldI))
+			chunks = strings.SplitN(field, ` `, 2)
+		}
 
 		var op string
 
 		if len(chunks) > 1 {
 			switch chunks[1] {
+			case `=`:
+				op = ``
+			case `<>`, `!=`:
+				op = `$ne`
+			case `IS`:
+				op = `$eq`
+			case `IS NOT`:
+				op = `$ne`
+			case `BETWEEN`:
+				if raw, ok := value.(db.RawValue); ok && len(raw.Arguments()) == 2 {
+					conds[chunks[0]] = bson.M{`$gte`: raw.Arguments()[0], `$lte`: raw.Arguments()[1]}
+					continue
+				}
+				op = chunks[1]
 			case `IN`:
 				op = `$in`
 			case `NOT IN`:
@@ -120,8 +139,7 @@ func compileStatement(cond db.Cond) bson.M {
 	return conds
 }
 
-// compileConditions compiles terms into something *mgo.Session can
-// understand.
+// compileConditions compiles terms into filter documents.
 func (col *Collection) compileConditions(term interface{}) interface{} {
 
 	switch t := term.(type) {
@@ -158,8 +176,7 @@ func (col *Collection) compileConditions(term interface{}) interface{} {
 	return nil
 }
 
-// compileQuery compiles terms into something that *mgo.Session can
-// understand.
+// compileQuery compiles terms into a filter document.
 func (col *Collection) compileQuery(terms ...interface{}) interface{} {
 	var query interface{}
 
@@ -176,8 +193,8 @@ func (col *Collection) compileQuery(terms ...interface{}) interface{} {
 			// attempt to workaround https://jira.mongodb.org/browse/SERVER-4572
 			mapped := map[string]interface{}{}
 			for _, v := range conditions {
-				for kk := range v.(map[string]interface{}) {
-					mapped[kk] = v.(map[string]interface{})[kk]
+				for kk := range v.(bson.M) {
+					mapped[kk] = v.(bson.M)[kk]
 				}
 			}
 
@@ -192,12 +209,12 @@ func (col *Collection) compileQuery(terms ...interface{}) interface{} {
 
 // Name returns the name of the table or tables that form the collection.
 func (col *Collection) Name() string {
-	return col.collection.Name
+	return col.collection.Name()
 }
 
 // Truncate deletes all rows from the table.
 func (col *Collection) Truncate() error {
-	err := col.collection.DropCollection()
+	err := col.collection.Drop(col.parent.Context())
 
 	if err != nil {
 		return err
@@ -216,27 +233,11 @@ func (col *Collection) UpdateReturning(item interface{}) error {
 
 // Insert inserts an item (map or struct) into the collection.
 func (col *Collection) Insert(item interface{}) (interface{}, error) {
-	var err error
-
 	id := getID(item)
 
-	if col.parent.versionAtLeast(2, 6, 0, 0) {
-		// this breaks MongoDb older than 2.6
-		if _, err = col.collection.Upsert(bson.M{"_id": id}, item); err != nil {
-			return nil, err
-		}
-	} else {
-		// Allocating a new ID.
-		if err = col.collection.Insert(bson.M{"_id": id}); err != nil {
-			return nil, err
-		}
-
-		// Now append data the user wants to append.
-		if err = col.collection.Update(bson.M{"_id": id}, item); err != nil {
-			// Cleanup allocated ID
-			col.collection.Remove(bson.M{"_id": id})
-			return nil, err
-		}
+	opts := options.Replace().SetUpsert(true)
+	if _, err := col.collection.ReplaceOne(col.parent.Context(), bson.M{"_id": id}, item, opts); err != nil {
+		return nil, err
 	}
 
 	return id, nil
@@ -244,12 +245,11 @@ func (col *Collection) Insert(item interface{}) (interface{}, error) {
 
 // Exists returns true if the collection exists.
 func (col *Collection) Exists() bool {
-	query := col.parent.database.C(`system.namespaces`).Find(map[string]string{`name`: fmt.Sprintf(`%s.%s`, col.parent.database.Name, col.collection.Name)})
-	count, _ := query.Count()
-	if count > 0 {
-		return true
+	names, err := col.parent.database.ListCollectionNames(col.parent.Context(), bson.M{"name": col.collection.Name()})
+	if err != nil {
+		return false
 	}
-	return false
+	return len(names) > 0
 }
 
 // Fetches object _id or generates a new one if object doesn't have one or the one it has is invalid
@@ -261,7 +261,7 @@ func getID(item interface{}) interface{} {
 	case reflect.Map:
 		if inItem, ok := item.(map[string]interface{}); ok {
 			if id, ok := inItem["_id"]; ok {
-				bsonID, ok := id.(bson.ObjectId)
+				bsonID, ok := id.(bson.ObjectID)
 				if ok {
 					return bsonID
 				}
@@ -302,8 +302,8 @@ func getID(item interface{}) interface{} {
 			}
 		}
 		if fieldName != "" {
-			if bsonID, ok := v.FieldByName(fieldName).Interface().(bson.ObjectId); ok {
-				if bsonID.Valid() {
+			if bsonID, ok := v.FieldByName(fieldName).Interface().(bson.ObjectID); ok {
+				if !bsonID.IsZero() {
 					return bsonID
 				}
 			} else {
@@ -312,5 +312,5 @@ func getID(item interface{}) interface{} {
 		}
 	}
 
-	return bson.NewObjectId()
+	return bson.NewObjectID()
 }
@@ -27,7 +27,10 @@ import (
 	"strings"
 )
 
-const connectionScheme = `mongodb`
+const (
+	connectionScheme    = `mongodb`
+	srvConnectionScheme = `mongodb+srv`
+)
 
 // ConnectionURL implements a MongoDB connection struct.
 type ConnectionURL struct {
@@ -36,6 +39,10 @@ type ConnectionURL struct {
 	Host     string
 	Database string
 	Options  map[string]string
+
+	// SRV makes the URL use the mongodb+srv scheme, Host is then the name of a
+	// DNS SRV record that lists the servers of the cluster.
+	SRV bool
 }
 
 func (c ConnectionURL) String() (s string) {
@@ -68,8 +75,13 @@ func (c ConnectionURL) String() (s string) {
 	}
 
 	// Building URL.
+	scheme := connectionScheme
+	if c.SRV {
+		scheme = srvConnectionScheme
+	}
+
 	u := url.URL{
-		Scheme:   connectionScheme,
+		Scheme:   scheme,
 		Path:     c.Database,
 		Host:     c.Host,
 		User:     userInfo,
@@ -83,8 +95,12 @@ func (c ConnectionURL) String() (s string) {
 func ParseURL(s string) (conn ConnectionURL, err error) {
 	var u *url.URL
 
-	if strings.HasPrefix(s, connectionScheme+"://") == false {
-		return conn, fmt.Errorf(`Expecting mongodb:// connection scheme.`)
+	switch {
+	case strings.HasPrefix(s, connectionScheme+"://"):
+	case strings.HasPrefix(s, srvConnectionScheme+"://"):
+		conn.SRV = true
+	default:
+		return conn, fmt.Errorf(`Expecting mongodb:// or mongodb+srv:// connection scheme.`)
 	}
 
 	if u, err = url.Parse(s); err != nil {
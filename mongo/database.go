@@ -19,17 +19,27 @@
 // OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
 // WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
 
-// Package mongo wraps the gopkg.in/mgo.v2 MongoDB driver. See
+// Package mongo wraps the go.mongodb.org/mongo-driver/v2 MongoDB driver. See
 // https://upper.io/db.v3/mongo for documentation, particularities and usage
 // examples.
+//
+// Connection pooling and authentication are configured with the options of
+// the connection URL, like maxPoolSize, minPoolSize, maxIdleTimeMS,
+// authSource or authMechanism (SCRAM-SHA-256 is negotiated by default on
+// MongoDB 4.0 and later). Set ConnectionURL.SRV to use a mongodb+srv:// seed
+// list, or use New to wrap a *mongo.Client that was configured elsewhere.
 package mongo // import "upper.io/db.v3/mongo"
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"sync"
 	"time"
 
-	"gopkg.in/mgo.v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"upper.io/db.v3"
 )
 
@@ -38,15 +48,26 @@ const Adapter = `mongo`
 
 var connTimeout = time.Second * 5
 
+var errMissingDatabaseName = errors.New(`Missing database name.`)
+
+// bsonOptions makes the driver decode nested documents into maps and times
+// into the local time zone, like the mgo driver used to.
+var bsonOptions = &options.BSONOptions{
+	DefaultDocumentM: true,
+	UseLocalTimeZone: true,
+}
+
 // Source represents a MongoDB database.
 type Source struct {
 	db.Settings
 
+	ctx context.Context
+
 	name          string
 	connURL       db.ConnectionURL
-	session       *mgo.Session
-	database      *mgo.Database
-	version       []int
+	client        *mongo.Client
+	database      *mongo.Database
+	shared        bool
 	collections   map[string]*Collection
 	collectionsMu sync.Mutex
 }
@@ -66,21 +87,35 @@ func Open(settings db.ConnectionURL) (db.Database, error) {
 	return d, nil
 }
 
+// New wraps the given *mongo.Client and returns a session that works on the
+// named database. Closing the session does not disconnect the client.
+func New(client *mongo.Client, name string) (db.Database, error) {
+	if name == "" {
+		return nil, errMissingDatabaseName
+	}
+	d := &Source{Settings: db.NewSettings(), shared: true}
+	d.bind(client, name)
+	return d, nil
+}
+
 func (s *Source) ConnectionURL() db.ConnectionURL {
 	return s.connURL
 }
 
-// SetConnMaxLifetime is not supported.
+// SetConnMaxLifetime is not supported, use the maxIdleTimeMS option of the
+// connection URL instead.
 func (s *Source) SetConnMaxLifetime(time.Duration) {
 	s.Settings.SetConnMaxLifetime(time.Duration(0))
 }
 
-// SetMaxIdleConns is not supported.
+// SetMaxIdleConns is not supported, use the minPoolSize option of the
+// connection URL instead.
 func (s *Source) SetMaxIdleConns(int) {
 	s.Settings.SetMaxIdleConns(0)
 }
 
-// SetMaxOpenConns is not supported.
+// SetMaxOpenConns is not supported, use the maxPoolSize option of the
+// connection URL instead.
 func (s *Source) SetMaxOpenConns(int) {
 	s.Settings.SetMaxOpenConns(0)
 }
@@ -96,20 +131,39 @@ func (s *Source) Open(connURL db.ConnectionURL) error {
 	return s.open()
 }
 
-// Clone returns a cloned db.Database session.
+// Clone returns a cloned db.Database session, the clone shares the
+// connection pool of the original session.
 func (s *Source) Clone() (db.Database, error) {
-	newSession := s.session.Copy()
+	return s.clone(), nil
+}
+
+// WithContext returns a copy of the session that runs its operations within
+// the given context, the copy shares the connection pool of the original
+// session.
+func (s *Source) WithContext(ctx context.Context) db.Database {
+	clone := s.clone()
+	clone.ctx = ctx
+	return clone
+}
+
+// Context returns the context the session runs its operations within.
+func (s *Source) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+func (s *Source) clone() *Source {
 	clone := &Source{
 		Settings: db.NewSettings(),
 
-		name:        s.name,
-		connURL:     s.connURL,
-		session:     newSession,
-		database:    newSession.DB(s.database.Name),
-		version:     s.version,
-		collections: map[string]*Collection{},
+		ctx:     s.ctx,
+		connURL: s.connURL,
+		shared:  true,
 	}
-	return clone, nil
+	clone.bind(s.client, s.name)
+	return clone
 }
 
 // NewTransaction should support transactions, but it doesn't as MongoDB
@@ -121,7 +175,7 @@ func (s *Source) NewTransaction() (db.Tx, error) {
 // Ping checks whether a connection to the database is still alive by pinging
 // it, establishing a connection if necessary.
 func (s *Source) Ping() error {
-	return s.session.Ping()
+	return s.client.Ping(s.Context(), nil)
 }
 
 func (s *Source) ClearCache() {
@@ -130,28 +184,58 @@ func (s *Source) ClearCache() {
 	s.collections = make(map[string]*Collection)
 }
 
-// Driver returns the underlying *mgo.Session instance.
+// Driver returns the underlying *mongo.Client instance.
 func (s *Source) Driver() interface{} {
-	return s.session
+	return s.client
+}
+
+func (s *Source) bind(client *mongo.Client, name string) {
+	s.client = client
+	s.name = name
+	s.database = client.Database(name)
+	s.collections = map[string]*Collection{}
 }
 
 func (s *Source) open() error {
-	var err error
+	connURL, err := ParseURL(s.connURL.String())
+	if err != nil {
+		return err
+	}
+	if connURL.Database == "" {
+		return errMissingDatabaseName
+	}
+
+	opts := options.Client().
+		ApplyURI(connURL.String()).
+		SetConnectTimeout(connTimeout).
+		SetServerSelectionTimeout(connTimeout).
+		SetBSONOptions(bsonOptions)
+
+	ctx, cancel := context.WithTimeout(s.Context(), connTimeout)
+	defer cancel()
 
-	if s.session, err = mgo.DialWithTimeout(s.connURL.String(), connTimeout); err != nil {
+	client, err := mongo.Connect(opts)
+	if err != nil {
 		return err
 	}
 
-	s.collections = map[string]*Collection{}
-	s.database = s.session.DB("")
+	// Connect does not wait for the servers, pinging makes Open fail on
+	// unreachable servers or wrong credentials.
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+		return err
+	}
+
+	s.bind(client, connURL.Database)
 
 	return nil
 }
 
-// Close terminates the current database session.
+// Close terminates the current database session, sessions that share their
+// client with others leave it connected.
 func (s *Source) Close() error {
-	if s.session != nil {
-		s.session.Close()
+	if s.client != nil && !s.shared {
+		return s.client.Disconnect(s.Context())
 	}
 	return nil
 }
@@ -161,7 +245,7 @@ func (s *Source) Collections() (cols []string, err error) {
 	var rawcols []string
 	var col string
 
-	if rawcols, err = s.database.CollectionNames(); err != nil {
+	if rawcols, err = s.database.ListCollectionNames(s.Context(), bson.M{}); err != nil {
 		return nil, err
 	}
 
@@ -187,36 +271,10 @@ func (s *Source) Collection(name string) db.Collection {
 	if col, ok = s.collections[name]; !ok {
 		col = &Collection{
 			parent:     s,
-			collection: s.database.C(name),
+			collection: s.database.Collection(name),
 		}
 		s.collections[name] = col
 	}
 
 	return col
 }
-
-func (s *Source) versionAtLeast(version ...int) bool {
-	// only fetch this once - it makes a db call
-	if len(s.version) == 0 {
-		buildInfo, err := s.database.Session.BuildInfo()
-		if err != nil {
-			return false
-		}
-		s.version = buildInfo.VersionArray
-	}
-
-	// Check major version first
-	if s.version[0] > version[0] {
-		return true
-	}
-
-	for i := range version {
-		if i == len(s.version) {
-			return false
-		}
-		if s.version[i] < version[i] {
-			return false
-		}
-	}
-	return true
-}
@@ -23,6 +23,7 @@
 package mongo
 
 import (
+	"context"
 	"math/rand"
 	"os"
 	"reflect"
@@ -30,7 +31,7 @@ import (
 	"testing"
 	"time"
 
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"upper.io/db.v3"
 )
 
@@ -218,12 +219,12 @@ func TestInsert(t *testing.T) {
 		t.Fatalf("Expecting an ID.")
 	}
 
-	if _, ok := id.(bson.ObjectId); ok != true {
-		t.Fatalf("Expecting a bson.ObjectId.")
+	if _, ok := id.(bson.ObjectID); ok != true {
+		t.Fatalf("Expecting a bson.ObjectID.")
 	}
 
-	if id.(bson.ObjectId).Valid() != true {
-		t.Fatalf("Expecting a valid bson.ObjectId.")
+	if id.(bson.ObjectID).IsZero() {
+		t.Fatalf("Expecting a valid bson.ObjectID.")
 	}
 
 	// Inserting a struct.
@@ -237,12 +238,12 @@ func TestInsert(t *testing.T) {
 		t.Fatalf("Expecting an ID.")
 	}
 
-	if _, ok := id.(bson.ObjectId); ok != true {
-		t.Fatalf("Expecting a bson.ObjectId.")
+	if _, ok := id.(bson.ObjectID); ok != true {
+		t.Fatalf("Expecting a bson.ObjectID.")
 	}
 
-	if id.(bson.ObjectId).Valid() != true {
-		t.Fatalf("Expecting a valid bson.ObjectId.")
+	if id.(bson.ObjectID).IsZero() {
+		t.Fatalf("Expecting a valid bson.ObjectID.")
 	}
 
 	// Inserting a struct (using tags to specify the field name).
@@ -256,12 +257,12 @@ func TestInsert(t *testing.T) {
 		t.Fatalf("Expecting an ID.")
 	}
 
-	if _, ok := id.(bson.ObjectId); ok != true {
-		t.Fatalf("Expecting a bson.ObjectId.")
+	if _, ok := id.(bson.ObjectID); ok != true {
+		t.Fatalf("Expecting a bson.ObjectID.")
 	}
 
-	if id.(bson.ObjectId).Valid() != true {
-		t.Fatalf("Expecting a valid bson.ObjectId.")
+	if id.(bson.ObjectID).IsZero() {
+		t.Fatalf("Expecting a valid bson.ObjectID.")
 	}
 
 	// Inserting a pointer to a struct
@@ -275,12 +276,12 @@ func TestInsert(t *testing.T) {
 		t.Fatalf("Expecting an ID.")
 	}
 
-	if _, ok := id.(bson.ObjectId); ok != true {
-		t.Fatalf("Expecting a bson.ObjectId.")
+	if _, ok := id.(bson.ObjectID); ok != true {
+		t.Fatalf("Expecting a bson.ObjectID.")
 	}
 
-	if id.(bson.ObjectId).Valid() != true {
-		t.Fatalf("Expecting a valid bson.ObjectId.")
+	if id.(bson.ObjectID).IsZero() {
+		t.Fatalf("Expecting a valid bson.ObjectID.")
 	}
 
 	// Inserting a pointer to a map
@@ -292,12 +293,12 @@ func TestInsert(t *testing.T) {
 		t.Fatalf("Expecting an ID.")
 	}
 
-	if _, ok := id.(bson.ObjectId); ok != true {
-		t.Fatalf("Expecting a bson.ObjectId.")
+	if _, ok := id.(bson.ObjectID); ok != true {
+		t.Fatalf("Expecting a bson.ObjectID.")
 	}
 
-	if id.(bson.ObjectId).Valid() != true {
-		t.Fatalf("Expecting a valid bson.ObjectId.")
+	if id.(bson.ObjectID).IsZero() {
+		t.Fatalf("Expecting a valid bson.ObjectID.")
 	}
 
 	var total uint64
@@ -448,12 +449,12 @@ func TestResultFetch(t *testing.T) {
 		if rowM["_id"] == nil {
 			t.Fatalf("Expecting an ID.")
 		}
-		if _, ok := rowM["_id"].(bson.ObjectId); ok != true {
-			t.Fatalf("Expecting a bson.ObjectId.")
+		if _, ok := rowM["_id"].(bson.ObjectID); ok != true {
+			t.Fatalf("Expecting a bson.ObjectID.")
 		}
 
-		if rowM["_id"].(bson.ObjectId).Valid() != true {
-			t.Fatalf("Expecting a valid bson.ObjectId.")
+		if rowM["_id"].(bson.ObjectID).IsZero() {
+			t.Fatalf("Expecting a valid bson.ObjectID.")
 		}
 		if name, ok := rowM["name"].(string); !ok || name == "" {
 			t.Fatalf("Expecting a name.")
@@ -464,14 +465,14 @@ func TestResultFetch(t *testing.T) {
 
 	// Testing struct
 	rowS := struct {
-		ID   bson.ObjectId `bson:"_id"`
+		ID   bson.ObjectID `bson:"_id"`
 		Name string        `bson:"name"`
 	}{}
 
 	res = artist.Find()
 
 	for res.Next(&rowS) {
-		if rowS.ID.Valid() == false {
+		if rowS.ID.IsZero() {
 			t.Fatalf("Expecting a not null ID.")
 		}
 		if rowS.Name == "" {
@@ -483,14 +484,14 @@ func TestResultFetch(t *testing.T) {
 
 	// Testing tagged struct
 	rowT := struct {
-		Value1 bson.ObjectId `bson:"_id"`
+		Value1 bson.ObjectID `bson:"_id"`
 		Value2 string        `bson:"name"`
 	}{}
 
 	res = artist.Find()
 
 	for res.Next(&rowT) {
-		if rowT.Value1.Valid() == false {
+		if rowT.Value1.IsZero() {
 			t.Fatalf("Expecting a not null ID.")
 		}
 		if rowT.Value2 == "" {
@@ -520,7 +521,7 @@ func TestResultFetch(t *testing.T) {
 	res = artist.Find()
 
 	allRowsS := []struct {
-		ID   bson.ObjectId `bson:"_id"`
+		ID   bson.ObjectID `bson:"_id"`
 		Name string
 	}{}
 	err = res.All(&allRowsS)
@@ -530,7 +531,7 @@ func TestResultFetch(t *testing.T) {
 	}
 
 	for _, singleRowS := range allRowsS {
-		if singleRowS.ID.Valid() == false {
+		if singleRowS.ID.IsZero() {
 			t.Fatalf("Expecting a not null ID.")
 		}
 	}
@@ -539,7 +540,7 @@ func TestResultFetch(t *testing.T) {
 	res = artist.Find()
 
 	allRowsT := []struct {
-		Value1 bson.ObjectId `bson:"_id"`
+		Value1 bson.ObjectID `bson:"_id"`
 		Value2 string        `bson:"name"`
 	}{}
 	err = res.All(&allRowsT)
@@ -549,7 +550,7 @@ func TestResultFetch(t *testing.T) {
 	}
 
 	for _, singleRowT := range allRowsT {
-		if singleRowT.Value1.Valid() == false {
+		if singleRowT.Value1.IsZero() {
 			t.Fatalf("Expecting a not null ID.")
 		}
 	}
@@ -574,7 +575,7 @@ func TestUpdate(t *testing.T) {
 
 	// Value
 	value := struct {
-		ID   bson.ObjectId `bson:"_id"`
+		ID   bson.ObjectID `bson:"_id"`
 		Name string
 	}{}
 
@@ -705,7 +706,7 @@ func TestDelete(t *testing.T) {
 	res := artist.Find(db.Cond{"_id $ne": nil}).Limit(1)
 
 	var first struct {
-		ID bson.ObjectId `bson:"_id"`
+		ID bson.ObjectID `bson:"_id"`
 	}
 
 	err = res.One(&first)
@@ -772,3 +773,46 @@ func TestDataTypes(t *testing.T) {
 		t.Errorf("Struct is different.")
 	}
 }
+
+// Operations must run within the context of the session or the one given to
+// the *Context methods.
+func TestContext(t *testing.T) {
+	sess, err := Open(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sess.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	artist := sess.Collection("artist")
+
+	if _, err = artist.Find().CountContext(ctx); err == nil {
+		t.Fatalf("Expecting an error.")
+	}
+
+	var item map[string]interface{}
+	if err = artist.Find().OneContext(ctx, &item); err == nil {
+		t.Fatalf("Expecting an error.")
+	}
+
+	canceled := sess.(*Source).WithContext(ctx)
+
+	if err = canceled.Ping(); err == nil {
+		t.Fatalf("Expecting an error.")
+	}
+
+	if _, err = canceled.Collection("artist").Find().Count(); err == nil {
+		t.Fatalf("Expecting an error.")
+	}
+
+	// Closing the copy leaves the original session connected.
+	if err = canceled.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = sess.Ping(); err != nil {
+		t.Fatal(err)
+	}
+}
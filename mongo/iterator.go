@@ -0,0 +1,307 @@
+package mongo
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// iterator walks over the documents of a cursor, documents are decoded like
+// the ones returned by a db.Result, Scan reads their fields in order.
+type iterator struct {
+	ctx    context.Context
+	cursor *mongo.Cursor
+	err    error
+}
+
+var _ = sqlbuilder.Iterator(&iterator{})
+
+func (iter *iterator) setErr(err error) error {
+	if err != nil {
+		iter.err = err
+	}
+	return err
+}
+
+// advance moves the cursor to the next document, it returns db.ErrNoMoreRows
+// after the last one.
+func (iter *iterator) advance() error {
+	if iter.err != nil {
+		return iter.err
+	}
+	if iter.cursor == nil {
+		return db.ErrNoMoreRows
+	}
+	if !iter.cursor.Next(iter.ctx) {
+		defer iter.Close()
+		if err := iter.cursor.Err(); err != nil {
+			return iter.setErr(err)
+		}
+		return db.ErrNoMoreRows
+	}
+	return nil
+}
+
+func (iter *iterator) All(dst interface{}) error {
+	if iter.err != nil {
+		return iter.err
+	}
+	if iter.cursor == nil {
+		return db.ErrNoMoreRows
+	}
+	defer iter.Close()
+	return iter.setErr(iter.cursor.All(iter.ctx, dst))
+}
+
+func (iter *iterator) One(dst interface{}) error {
+	defer iter.Close()
+	if err := iter.advance(); err != nil {
+		return err
+	}
+	return iter.setErr(iter.cursor.Decode(dst))
+}
+
+func (iter *iterator) Scan(dst ...interface{}) error {
+	if iter.err != nil {
+		return iter.err
+	}
+	if iter.cursor == nil {
+		return db.ErrNoMoreRows
+	}
+	elements, err := iter.cursor.Current.Elements()
+	if err != nil {
+		return iter.setErr(err)
+	}
+	if len(dst) > len(elements) {
+		return iter.setErr(fmt.Errorf("Expecting at most %d destinations, got %d.", len(elements), len(dst)))
+	}
+	for i := range dst {
+		if err := elements[i].Value().Unmarshal(dst[i]); err != nil {
+			return iter.setErr(err)
+		}
+	}
+	return nil
+}
+
+func (iter *iterator) NextScan(dst ...interface{}) error {
+	if err := iter.advance(); err != nil {
+		return err
+	}
+	return iter.Scan(dst...)
+}
+
+func (iter *iterator) ScanOne(dst ...interface{}) error {
+	defer iter.Close()
+	return iter.NextScan(dst...)
+}
+
+// EncodeJSON writes the remaining documents to w as a JSON array, documents
+// are encoded as relaxed extended JSON.
+func (iter *iterator) EncodeJSON(w io.Writer) error {
+	if iter.err != nil {
+		return iter.err
+	}
+	if iter.cursor == nil {
+		return db.ErrNoMoreRows
+	}
+	defer iter.Close()
+
+	buf := bufio.NewWriter(w)
+	buf.WriteByte('[')
+	for n := 0; ; n++ {
+		err := iter.advance()
+		if err == db.ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		doc, err := bson.MarshalExtJSON(iter.cursor.Current, false, false)
+		if err != nil {
+			return iter.setErr(err)
+		}
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(doc)
+	}
+	buf.WriteByte(']')
+	return buf.Flush()
+}
+
+// WriteCSV writes the remaining documents to w as CSV records, the columns
+// are the fields of the first document.
+func (iter *iterator) WriteCSV(w io.Writer, opts *sqlbuilder.CSVOptions) error {
+	if iter.err != nil {
+		return iter.err
+	}
+	if iter.cursor == nil {
+		return db.ErrNoMoreRows
+	}
+	defer iter.Close()
+
+	if opts == nil {
+		opts = &sqlbuilder.CSVOptions{}
+	}
+
+	cw := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		cw.Comma = opts.Delimiter
+	}
+
+	var columns []string
+	for {
+		err := iter.advance()
+		if err == db.ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if columns == nil {
+			elements, err := iter.cursor.Current.Elements()
+			if err != nil {
+				return iter.setErr(err)
+			}
+			columns = make([]string, len(elements))
+			for i := range elements {
+				columns[i] = elements[i].Key()
+			}
+			if opts.Header {
+				if err := cw.Write(columns); err != nil {
+					return iter.setErr(err)
+				}
+			}
+		}
+
+		record := make([]string, len(columns))
+		for i := range columns {
+			record[i] = opts.FormatField(csvValue(iter.cursor.Current.Lookup(columns[i])))
+		}
+		if err := cw.Write(record); err != nil {
+			return iter.setErr(err)
+		}
+	}
+
+	cw.Flush()
+	return iter.setErr(cw.Error())
+}
+
+// csvValue converts a BSON value into one of the types CSVOptions.FormatField
+// knows, missing and null values are converted into nil.
+func csvValue(v bson.RawValue) interface{} {
+	switch v.Type {
+	case 0, bson.TypeNull, bson.TypeUndefined:
+		return nil
+	case bson.TypeString:
+		return v.StringValue()
+	case bson.TypeBoolean:
+		return v.Boolean()
+	case bson.TypeInt32:
+		return int64(v.Int32())
+	case bson.TypeInt64:
+		return v.Int64()
+	case bson.TypeDouble:
+		return v.Double()
+	case bson.TypeDateTime:
+		return v.Time()
+	case bson.TypeObjectID:
+		return v.ObjectID().Hex()
+	}
+	return v.String()
+}
+
+func (iter *iterator) Chunk(size int, dst interface{}, fn func() error) error {
+	if iter.err != nil {
+		return iter.err
+	}
+	defer iter.Close()
+
+	if size < 1 {
+		return sqlbuilder.ErrInvalidChunkSize
+	}
+
+	dstv := reflect.ValueOf(dst)
+	if dstv.Kind() != reflect.Ptr || dstv.IsNil() {
+		return sqlbuilder.ErrExpectingPointer
+	}
+	if dstv.Elem().Kind() != reflect.Slice {
+		return sqlbuilder.ErrExpectingSlicePointer
+	}
+
+	sliceT := dstv.Elem().Type()
+	itemT := sliceT.Elem()
+
+	// A new slice is used for every chunk, so fn can keep the previous ones.
+	slicev := reflect.MakeSlice(sliceT, 0, size)
+	for {
+		err := iter.advance()
+		if err == db.ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		item := reflect.New(itemT)
+		if err := iter.cursor.Decode(item.Interface()); err != nil {
+			return iter.setErr(err)
+		}
+		slicev = reflect.Append(slicev, item.Elem())
+
+		if slicev.Len() == size {
+			dstv.Elem().Set(slicev)
+			if err := fn(); err != nil {
+				return err
+			}
+			slicev = reflect.MakeSlice(sliceT, 0, size)
+		}
+	}
+
+	if slicev.Len() > 0 {
+		dstv.Elem().Set(slicev)
+		return fn()
+	}
+	return nil
+}
+
+func (iter *iterator) Next(dst ...interface{}) bool {
+	if err := iter.advance(); err != nil {
+		return false
+	}
+	switch len(dst) {
+	case 0:
+		return true
+	case 1:
+		if err := iter.cursor.Decode(dst[0]); err != nil {
+			iter.setErr(err)
+			return false
+		}
+		return true
+	}
+	iter.setErr(errors.New("Next does not currently supports more than one parameters"))
+	return false
+}
+
+func (iter *iterator) Err() error {
+	return iter.err
+}
+
+func (iter *iterator) Close() error {
+	if iter.cursor != nil {
+		err := iter.cursor.Close(context.Background())
+		iter.cursor = nil
+		return err
+	}
+	return nil
+}
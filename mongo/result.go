@@ -22,6 +22,7 @@
 package mongo
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -30,8 +31,9 @@ import (
 
 	"encoding/json"
 
-	"gopkg.in/mgo.v2"
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"upper.io/db.v3"
 )
 
@@ -39,9 +41,15 @@ import (
 type result struct {
 	c           *Collection
 	queryChunks *chunks
-	iter        *mgo.Iter
+	cursor      *mongo.Cursor
 	errMu       sync.RWMutex
 	err         error
+	preload     []string
+
+	countMu    sync.Mutex
+	cacheCount bool
+	count      uint64
+	counted    bool
 }
 
 var (
@@ -65,14 +73,21 @@ func (r *result) Err() error {
 	return r.err
 }
 
-// setCursor creates a *mgo.Iter we can use in Next(), All() or One().
-func (r *result) setCursor() error {
-	if r.iter == nil {
-		q, err := r.query()
+// setCursor creates a *mongo.Cursor we can use in Next(), All() or One().
+func (r *result) setCursor(ctx context.Context) error {
+	if len(r.preload) > 0 {
+		return db.ErrUnsupported
+	}
+	if r.cursor == nil {
+		opts, err := r.findOptions()
+		if err != nil {
+			return err
+		}
+		cursor, err := r.c.collection.Find(ctx, r.queryChunks.Conditions, opts)
 		if err != nil {
 			return err
 		}
-		r.iter = q.Iter()
+		r.cursor = cursor
 	}
 	return nil
 }
@@ -81,6 +96,7 @@ func (r *result) And(terms ...interface{}) db.Result {
 	if r.queryChunks.Conditions == nil {
 		return r.Where(terms)
 	}
+	r.forgetCount()
 	r.queryChunks.Conditions = map[string]interface{}{
 		"$and": []interface{}{
 			r.queryChunks.Conditions,
@@ -92,6 +108,7 @@ func (r *result) And(terms ...interface{}) db.Result {
 
 func (r *result) Where(terms ...interface{}) db.Result {
 	r.queryChunks.Conditions = r.c.compileQuery(terms...)
+	r.forgetCount()
 	return r
 }
 
@@ -136,11 +153,15 @@ func (r *result) Select(fields ...interface{}) db.Result {
 }
 
 // All dumps all results into a pointer to an slice of structs or maps.
-func (r *result) All(dst interface{}) (err error) {
+func (r *result) All(dst interface{}) error {
+	return r.AllContext(r.c.parent.Context(), dst)
+}
 
+// AllContext is like All, but the query runs within the given context.
+func (r *result) AllContext(ctx context.Context, dst interface{}) (err error) {
 	if r.c.parent.LoggingEnabled() {
 		defer func(start time.Time) {
-			r.c.parent.Logger().Log(&db.QueryStatus{
+			db.LogQuery(ctx, r.c.parent.Logger(), &db.QueryStatus{
 				Query: fmt.Sprintf("find(%s)", mustJSON(r.queryChunks.Conditions)),
 				Err:   err,
 				Start: start,
@@ -149,20 +170,20 @@ func (r *result) All(dst interface{}) (err error) {
 		}(time.Now())
 	}
 
-	err = r.setCursor()
+	err = r.setCursor(ctx)
 
 	if err != nil {
 		return err
 	}
 
-	err = r.iter.All(dst)
+	defer r.Close()
+
+	err = r.cursor.All(ctx, dst)
 
 	if err != nil {
 		return err
 	}
 
-	r.Close()
-
 	return nil
 }
 
@@ -170,14 +191,33 @@ func (r *result) All(dst interface{}) (err error) {
 // or columns.
 func (r *result) Group(fields ...interface{}) db.Result {
 	r.queryChunks.GroupBy = fields
+	r.forgetCount()
+	return r
+}
+
+// Preload is not supported by the MongoDB adapter, fetching items from a
+// result set with relations to preload fails with db.ErrUnsupported.
+func (r *result) Preload(relations ...string) db.Result {
+	r.preload = append(r.preload, relations...)
+	return r
+}
+
+// Unscoped has no effect on the MongoDB adapter, soft deletes are not
+// supported.
+func (r *result) Unscoped() db.Result {
 	return r
 }
 
 // One fetches only one result from the resultset.
-func (r *result) One(dst interface{}) (err error) {
+func (r *result) One(dst interface{}) error {
+	return r.OneContext(r.c.parent.Context(), dst)
+}
+
+// OneContext is like One, but the query runs within the given context.
+func (r *result) OneContext(ctx context.Context, dst interface{}) (err error) {
 	if r.c.parent.LoggingEnabled() {
 		defer func(start time.Time) {
-			r.c.parent.Logger().Log(&db.QueryStatus{
+			db.LogQuery(ctx, r.c.parent.Logger(), &db.QueryStatus{
 				Query: fmt.Sprintf("findOne(%s)", mustJSON(r.queryChunks.Conditions)),
 				Err:   err,
 				Start: start,
@@ -187,20 +227,31 @@ func (r *result) One(dst interface{}) (err error) {
 	}
 
 	defer r.Close()
-	if !r.Next(dst) {
-		return r.Err()
+	if !r.next(ctx, dst) {
+		if err := r.Err(); err != nil {
+			return err
+		}
+		return db.ErrNoMoreRows
 	}
 	return nil
 }
 
 // Next fetches the next result from the resultset.
 func (r *result) Next(dst interface{}) bool {
-	err := r.setCursor()
+	return r.next(r.c.parent.Context(), dst)
+}
+
+func (r *result) next(ctx context.Context, dst interface{}) bool {
+	err := r.setCursor(ctx)
 	if err != nil {
 		r.setErr(err)
 		return false
 	}
-	if !r.iter.Next(dst) {
+	if !r.cursor.Next(ctx) {
+		r.setErr(r.cursor.Err())
+		return false
+	}
+	if err := r.cursor.Decode(dst); err != nil {
 		r.setErr(err)
 		return false
 	}
@@ -208,10 +259,16 @@ func (r *result) Next(dst interface{}) bool {
 }
 
 // Delete remove the matching items from the collection.
-func (r *result) Delete() (err error) {
+func (r *result) Delete() error {
+	return r.DeleteContext(r.c.parent.Context())
+}
+
+// DeleteContext is like Delete, but the operation runs within the given
+// context.
+func (r *result) DeleteContext(ctx context.Context) (err error) {
 	if r.c.parent.LoggingEnabled() {
 		defer func(start time.Time) {
-			r.c.parent.Logger().Log(&db.QueryStatus{
+			db.LogQuery(ctx, r.c.parent.Logger(), &db.QueryStatus{
 				Query: fmt.Sprintf("remove(%s)", mustJSON(r.queryChunks.Conditions)),
 				Err:   err,
 				Start: start,
@@ -220,7 +277,7 @@ func (r *result) Delete() (err error) {
 		}(time.Now())
 	}
 
-	_, err = r.c.collection.RemoveAll(r.queryChunks.Conditions)
+	_, err = r.c.collection.DeleteMany(ctx, r.queryChunks.Conditions)
 	if err != nil {
 		return err
 	}
@@ -230,21 +287,27 @@ func (r *result) Delete() (err error) {
 // Close closes the result set.
 func (r *result) Close() error {
 	var err error
-	if r.iter != nil {
-		err = r.iter.Close()
-		r.iter = nil
+	if r.cursor != nil {
+		err = r.cursor.Close(context.Background())
+		r.cursor = nil
 	}
 	return err
 }
 
 // Update modified matching items from the collection with values of the given
 // map or struct.
-func (r *result) Update(src interface{}) (err error) {
+func (r *result) Update(src interface{}) error {
+	return r.UpdateContext(r.c.parent.Context(), src)
+}
+
+// UpdateContext is like Update, but the operation runs within the given
+// context.
+func (r *result) UpdateContext(ctx context.Context, src interface{}) (err error) {
 	updateSet := map[string]interface{}{"$set": src}
 
 	if r.c.parent.LoggingEnabled() {
 		defer func(start time.Time) {
-			r.c.parent.Logger().Log(&db.QueryStatus{
+			db.LogQuery(ctx, r.c.parent.Logger(), &db.QueryStatus{
 				Query: fmt.Sprintf("update(%s, %s)", mustJSON(r.queryChunks.Conditions), mustJSON(updateSet)),
 				Err:   err,
 				Start: start,
@@ -253,29 +316,27 @@ func (r *result) Update(src interface{}) (err error) {
 		}(time.Now())
 	}
 
-	_, err = r.c.collection.UpdateAll(r.queryChunks.Conditions, updateSet)
+	_, err = r.c.collection.UpdateMany(ctx, r.queryChunks.Conditions, updateSet)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// query executes a mgo query.
-func (r *result) query() (*mgo.Query, error) {
-	var err error
-
-	q := r.c.collection.Find(r.queryChunks.Conditions)
+// findOptions converts the chunks of the result into options for Find.
+func (r *result) findOptions() (*options.FindOptionsBuilder, error) {
+	opts := options.Find()
 
 	if len(r.queryChunks.GroupBy) > 0 {
 		return nil, db.ErrUnsupported
 	}
 
 	if r.queryChunks.Offset > 0 {
-		q = q.Skip(r.queryChunks.Offset)
+		opts.SetSkip(int64(r.queryChunks.Offset))
 	}
 
 	if r.queryChunks.Limit > 0 {
-		q = q.Limit(r.queryChunks.Limit)
+		opts.SetLimit(int64(r.queryChunks.Limit))
 	}
 
 	if len(r.queryChunks.Fields) > 0 {
@@ -287,22 +348,72 @@ func (r *result) query() (*mgo.Query, error) {
 			selectedFields[field] = true
 		}
 		if len(selectedFields) > 0 {
-			q = q.Select(selectedFields)
+			opts.SetProjection(selectedFields)
 		}
 	}
 
 	if len(r.queryChunks.Sort) > 0 {
-		q.Sort(r.queryChunks.Sort...)
+		sort, err := sortDocument(r.queryChunks.Sort)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetSort(sort)
 	}
 
-	return q, err
+	return opts, nil
+}
+
+// sortDocument converts field names like "-name" into a sort document.
+func sortDocument(fields []string) (bson.D, error) {
+	sort := make(bson.D, 0, len(fields))
+	for _, field := range fields {
+		order := 1
+		switch {
+		case strings.HasPrefix(field, "-"):
+			order, field = -1, field[1:]
+		case strings.HasPrefix(field, "+"):
+			field = field[1:]
+		}
+		if field == "" {
+			return nil, fmt.Errorf(errUnknownSortValue.Error(), field)
+		}
+		sort = append(sort, bson.E{Key: field, Value: order})
+	}
+	return sort, nil
 }
 
 // Count counts matching elements.
-func (r *result) Count() (total uint64, err error) {
+func (r *result) Count() (uint64, error) {
+	return r.CountContext(r.c.parent.Context())
+}
+
+// CacheCount makes the result set remember the number of items the first
+// successful Count() call returns, until its conditions change.
+func (r *result) CacheCount() db.Result {
+	r.countMu.Lock()
+	r.cacheCount = true
+	r.countMu.Unlock()
+	return r
+}
+
+func (r *result) forgetCount() {
+	r.countMu.Lock()
+	r.counted = false
+	r.countMu.Unlock()
+}
+
+// CountContext is like Count, but the query runs within the given context.
+func (r *result) CountContext(ctx context.Context) (total uint64, err error) {
+	r.countMu.Lock()
+	defer r.countMu.Unlock()
+
+	if r.cacheCount && r.counted {
+		return r.count, nil
+	}
+
 	if r.c.parent.LoggingEnabled() {
 		defer func(start time.Time) {
-			r.c.parent.Logger().Log(&db.QueryStatus{
+			db.LogQuery(ctx, r.c.parent.Logger(), &db.QueryStatus{
 				Query: fmt.Sprintf("find(%s).count()", mustJSON(r.queryChunks.Conditions)),
 				Err:   err,
 				Start: start,
@@ -311,14 +422,39 @@ func (r *result) Count() (total uint64, err error) {
 		}(time.Now())
 	}
 
-	q := r.c.collection.Find(r.queryChunks.Conditions)
-	var c int
-	c, err = q.Count()
+	var c int64
+	c, err = r.c.collection.CountDocuments(ctx, r.queryChunks.Conditions)
+	if err == nil && r.cacheCount {
+		r.count, r.counted = uint64(c), true
+	}
 	return uint64(c), err
 }
 
+// Exists returns true if at least one document matches the conditions.
+func (r *result) Exists() (bool, error) {
+	return r.ExistsContext(r.c.parent.Context())
+}
+
+// ExistsContext is like Exists, but the query runs within the given context.
+func (r *result) ExistsContext(ctx context.Context) (exists bool, err error) {
+	if r.c.parent.LoggingEnabled() {
+		defer func(start time.Time) {
+			db.LogQuery(ctx, r.c.parent.Logger(), &db.QueryStatus{
+				Query: fmt.Sprintf("find(%s).limit(1).count()", mustJSON(r.queryChunks.Conditions)),
+				Err:   err,
+				Start: start,
+				End:   time.Now(),
+			})
+		}(time.Now())
+	}
+
+	var c int64
+	c, err = r.c.collection.CountDocuments(ctx, r.queryChunks.Conditions, options.Count().SetLimit(1))
+	return c > 0, err
+}
+
 func (r *result) debugQuery(action string) string {
-	query := fmt.Sprintf("db.%s.%s", r.c.collection.Name, action)
+	query := fmt.Sprintf("db.%s.%s", r.c.collection.Name(), action)
 
 	if r.queryChunks.Limit > 0 {
 		query = fmt.Sprintf("%s.limit(%d)", query, r.queryChunks.Limit)
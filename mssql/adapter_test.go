@@ -25,7 +25,9 @@ package mssql
 import (
 	"database/sql"
 	"os"
+	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"upper.io/db.v3/lib/sqlbuilder"
 )
 
@@ -124,6 +126,47 @@ func tearUp() error {
 	return nil
 }
 
+func TestJSONTagOption(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE json_payloads (id BIGINT PRIMARY KEY IDENTITY(1,1), payload NVARCHAR(MAX))`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE json_payloads`)
+
+	type payload struct {
+		Status string   `json:"status"`
+		Tags   []string `json:"tags"`
+	}
+
+	type item struct {
+		ID      int64   `db:"id,omitempty"`
+		Payload payload `db:"payload,json"`
+	}
+
+	col := sess.Collection("json_payloads")
+
+	in := item{Payload: payload{Status: "active", Tags: []string{"go", "sql"}}}
+	assert.NoError(t, col.InsertReturning(&in))
+
+	var raw string
+	row, err := sess.QueryRow(`SELECT payload FROM json_payloads WHERE id = ?`, in.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, row.Scan(&raw))
+	assert.Equal(t, `{"status":"active","tags":["go","sql"]}`, raw)
+
+	var out item
+	assert.NoError(t, col.Find(in.ID).One(&out))
+	assert.Equal(t, in, out)
+
+	out.Payload.Status = "inactive"
+	assert.NoError(t, col.Find(in.ID).Update(out))
+
+	var updated item
+	assert.NoError(t, col.Find(in.ID).One(&updated))
+	assert.Equal(t, out, updated)
+}
+
 func cleanUpCheck(sess sqlbuilder.Database) (err error) {
 	// TODO: Check the number of prepared statements.
 	return nil
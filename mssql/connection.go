@@ -22,11 +22,22 @@
 package mssql
 
 import (
+	"database/sql"
 	"errors"
 	"net/url"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter"
 )
 
 // ConnectionURL implements a MSSQL connection struct.
+//
+// OnConnect is called on every new connection of the pool before it's used,
+// like to set session options:
+//
+//	settings.OnConnect = func(conn db.Conn) error {
+//		return conn.Exec("SET LOCK_TIMEOUT 5000")
+//	}
 type ConnectionURL struct {
 	User     string
 	Password string
@@ -34,6 +45,19 @@ type ConnectionURL struct {
 	Host     string
 	Socket   string
 	Options  map[string]string
+
+	OnConnect func(db.Conn) error
+}
+
+// openDB opens a pool of connections to the server of connURL.
+func openDB(connURL db.ConnectionURL) (*sql.DB, error) {
+	switch c := connURL.(type) {
+	case ConnectionURL:
+		return sqladapter.OpenDB("mssql", c.String(), nil, c.OnConnect)
+	case *ConnectionURL:
+		return sqladapter.OpenDB("mssql", c.String(), nil, c.OnConnect)
+	}
+	return sql.Open("mssql", connURL.String())
 }
 
 func (c ConnectionURL) String() (s string) {
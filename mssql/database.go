@@ -26,12 +26,12 @@ package mssql
 
 import (
 	"context"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 
-	"database/sql"
-
-	_ "github.com/denisenkom/go-mssqldb" // MSSQL driver
+	mssqldriver "github.com/denisenkom/go-mssqldb" // MSSQL driver
 	"upper.io/db.v3"
 	"upper.io/db.v3/internal/sqladapter"
 	"upper.io/db.v3/internal/sqladapter/compat"
@@ -93,6 +93,9 @@ func (d *database) Collections() (collections []string, err error) {
 		From(`information_schema.tables`).
 		Where(`table_type`, `BASE TABLE`).
 		And(`table_catalog`, d.BaseDatabase.Name())
+	if schema := d.Schema(); schema != "" {
+		q = q.And(`table_schema`, schema)
+	}
 
 	iter := q.Iterator()
 	defer iter.Close()
@@ -117,7 +120,7 @@ func (d *database) open() error {
 	d.SQLBuilder = sqlbuilder.WithSession(d.BaseDatabase, template)
 
 	connFn := func() error {
-		sess, err := sql.Open("mssql", d.ConnectionURL().String())
+		sess, err := openDB(d.ConnectionURL())
 		if err == nil {
 			sess.SetConnMaxLifetime(db.DefaultSettings.ConnMaxLifetime())
 			sess.SetMaxIdleConns(db.DefaultSettings.MaxIdleConns())
@@ -161,8 +164,9 @@ func (d *database) CompileStatement(stmt *exql.Statement, args []interface{}) (s
 	return sqlbuilder.Preprocess(compiled, args)
 }
 
-// Err allows sqladapter to translate specific MySQL string errors into custom
-// error values.
+// Err allows sqladapter to translate specific MSSQL string errors into custom
+// error values, constraint violations and deadlocks are wrapped into a
+// *db.Error.
 func (d *database) Err(err error) error {
 	if err != nil {
 		// This error is not exported so we have to check it by its string value.
@@ -170,10 +174,54 @@ func (d *database) Err(err error) error {
 		if strings.Contains(s, `many connections`) {
 			return db.ErrTooManyClients
 		}
+		if e, ok := err.(mssqldriver.Error); ok {
+			return classifyErr(e)
+		}
 	}
 	return err
 }
 
+var (
+	errUniqueConstraint = regexp.MustCompile(`^Violation of (?:PRIMARY|UNIQUE) KEY constraint '([^']+)'.*object '([^']+)'`)
+	errUniqueIndex      = regexp.MustCompile(`object '([^']+)' with unique index '([^']+)'`)
+	errConflict         = regexp.MustCompile(`conflicted with the (FOREIGN KEY|REFERENCE|CHECK) constraint "([^"]+)".*?(?:table "([^"]+)")?(?:, column '([^']+)')?\.?$`)
+)
+
+// classifyErr wraps constraint violations and deadlocks into a *db.Error,
+// SQL Server only reports the names of the constraint and the columns involved
+// as part of the error message.
+func classifyErr(e mssqldriver.Error) error {
+	switch e.Number {
+	case 2627: // Violation of PRIMARY KEY or UNIQUE KEY constraint.
+		dbErr := &db.Error{Kind: db.ErrUniqueViolation, Err: e}
+		if m := errUniqueConstraint.FindStringSubmatch(e.Message); m != nil {
+			dbErr.Constraint, dbErr.Table = m[1], m[2]
+		}
+		return dbErr
+	case 2601: // Duplicate key row in a unique index.
+		dbErr := &db.Error{Kind: db.ErrUniqueViolation, Err: e}
+		if m := errUniqueIndex.FindStringSubmatch(e.Message); m != nil {
+			dbErr.Table, dbErr.Constraint = m[1], m[2]
+		}
+		return dbErr
+	case 547: // Statement conflicted with a FOREIGN KEY or CHECK constraint.
+		dbErr := &db.Error{Kind: db.ErrForeignKeyViolation, Err: e}
+		if m := errConflict.FindStringSubmatch(e.Message); m != nil {
+			if m[1] == "CHECK" {
+				dbErr.Kind = db.ErrCheckViolation
+			}
+			dbErr.Constraint, dbErr.Table = m[2], m[3]
+			if m[4] != "" {
+				dbErr.Columns = []string{m[4]}
+			}
+		}
+		return dbErr
+	case 1205: // Deadlock victim.
+		return &db.Error{Kind: db.ErrSerializationFailure, Err: e}
+	}
+	return e
+}
+
 // NewCollection creates a db.Collection by name.
 func (d *database) NewCollection(name string) db.Collection {
 	return newTable(d, name)
@@ -231,10 +279,15 @@ func (d *database) LookupName() (string, error) {
 // TableExists returns an error if the given table name does not exist on the
 // database.
 func (d *database) TableExists(name string) error {
+	schema, table := sqladapter.SplitTableName(name)
+	if schema == "" {
+		schema = d.BaseDatabase.Name()
+	}
+
 	q := d.Select(`table_name`).
 		From(`information_schema.tables`).
-		Where(`table_schema`, d.BaseDatabase.Name()).
-		And(`table_name`, name)
+		Where(`table_schema`, schema).
+		And(`table_name`, table)
 
 	iter := q.Iterator()
 	defer iter.Close()
@@ -251,6 +304,8 @@ func (d *database) TableExists(name string) error {
 
 // PrimaryKeys returns the names of all the primary keys on the table.
 func (d *database) PrimaryKeys(tableName string) ([]string, error) {
+	schema, table := sqladapter.SplitTableName(tableName)
+
 	q := d.Select(`k.column_name`).
 		From(
 			`information_schema.table_constraints AS t`,
@@ -259,8 +314,11 @@ func (d *database) PrimaryKeys(tableName string) ([]string, error) {
 		Where(`k.constraint_name = t.constraint_name`).
 		And(`k.table_name = t.table_name`).
 		And(`t.constraint_type = ?`, `PRIMARY KEY`).
-		And(`t.table_name = ?`, tableName).
+		And(`t.table_name = ?`, table).
 		OrderBy(`k.ordinal_position`)
+	if schema != "" {
+		q = q.And(`t.table_schema = ?`, schema)
+	}
 
 	iter := q.Iterator()
 	defer iter.Close()
@@ -278,8 +336,97 @@ func (d *database) PrimaryKeys(tableName string) ([]string, error) {
 	return pk, nil
 }
 
+// Indexes returns the names of the indexes of the table.
+func (d *database) Indexes(tableName string) ([]string, error) {
+	q := d.Select(`name`).
+		From(`sys.indexes`).
+		Where(`object_id = OBJECT_ID(?) AND name IS NOT NULL`, tableName)
+
+	iter := q.Iterator()
+	defer iter.Close()
+
+	indexes := []string{}
+
+	for iter.Next() {
+		var name string
+		if err := iter.Scan(&name); err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, name)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
+
+// ReferencedTables returns the names of the tables the foreign keys of the
+// table point to.
+func (d *database) ReferencedTables(tableName string) ([]string, error) {
+	q := d.Select().Distinct(db.Raw(`OBJECT_NAME(referenced_object_id)`)).
+		From(`sys.foreign_keys`).
+		Where(`parent_object_id = OBJECT_ID(?)`, tableName)
+
+	iter := q.Iterator()
+	defer iter.Close()
+
+	tables := []string{}
+
+	for iter.Next() {
+		var name string
+		if err := iter.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
+// SupportsReturning returns true, InsertReturning and UpdateReturning read the
+// new values of items with OUTPUT clauses.
+func (d *database) SupportsReturning() bool {
+	return true
+}
+
+// SavepointStatements returns the statements that create a savepoint and roll
+// back to it, savepoints are released with the transaction.
+func (d *database) SavepointStatements(name string) (string, string, string) {
+	return "SAVE TRANSACTION " + name, "ROLLBACK TRANSACTION " + name, ""
+}
+
 // WithContext creates a copy of the session on the given context.
 func (d *database) WithContext(ctx context.Context) sqlbuilder.Database {
 	newDB, _ := d.clone(ctx, false)
 	return newDB
 }
+
+// WithSchema returns a session whose collections belong to the given schema.
+func (d *database) WithSchema(schema string) (sqlbuilder.Database, error) {
+	sess, err := d.SchemaSession(schema, func() (sqladapter.Database, error) {
+		clone, err := d.clone(d.Context(), false)
+		if err != nil {
+			return nil, err
+		}
+		return clone, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sess.(*database), nil
+}
+
+// WithRecordCache returns a copy of the session whose primary key lookups,
+// like col.Find(id).One(&item), read items from the given store.
+func (d *database) WithRecordCache(store db.QueryCache, ttl time.Duration) (sqlbuilder.Database, error) {
+	clone, err := d.clone(d.Context(), false)
+	if err != nil {
+		return nil, err
+	}
+	clone.SetRecordCache(store, ttl)
+	return clone, nil
+}
@@ -0,0 +1,50 @@
+package mssql
+
+import (
+	"testing"
+
+	mssqldriver "github.com/denisenkom/go-mssqldb"
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+)
+
+func TestErr(t *testing.T) {
+	d := &database{}
+
+	{
+		err := d.Err(mssqldriver.Error{Number: 2627, Message: "Violation of UNIQUE KEY constraint 'artist_name_key'. Cannot insert duplicate key in object 'dbo.artist'. The duplicate key value is (Ozzy)."})
+		if assert.IsType(t, &db.Error{}, err) {
+			dbErr := err.(*db.Error)
+			assert.Equal(t, db.ErrUniqueViolation, dbErr.Kind)
+			assert.Equal(t, "dbo.artist", dbErr.Table)
+			assert.Equal(t, "artist_name_key", dbErr.Constraint)
+		}
+
+		err = d.Err(mssqldriver.Error{Number: 2601, Message: "Cannot insert duplicate key row in object 'dbo.artist' with unique index 'artist_name_idx'. The duplicate key value is (Ozzy)."})
+		assert.Equal(t, db.ErrUniqueViolation, db.ErrorKind(err))
+		assert.Equal(t, "dbo.artist", err.(*db.Error).Table)
+		assert.Equal(t, "artist_name_idx", err.(*db.Error).Constraint)
+	}
+
+	{
+		err := d.Err(mssqldriver.Error{Number: 547, Message: `The INSERT statement conflicted with the FOREIGN KEY constraint "publication_author_fk". The conflict occurred in database "upperio", table "dbo.artist", column 'id'.`})
+		if assert.IsType(t, &db.Error{}, err) {
+			dbErr := err.(*db.Error)
+			assert.Equal(t, db.ErrForeignKeyViolation, dbErr.Kind)
+			assert.Equal(t, "dbo.artist", dbErr.Table)
+			assert.Equal(t, "publication_author_fk", dbErr.Constraint)
+			assert.Equal(t, []string{"id"}, dbErr.Columns)
+		}
+
+		err = d.Err(mssqldriver.Error{Number: 547, Message: `The INSERT statement conflicted with the CHECK constraint "artist_name_check". The conflict occurred in database "upperio", table "dbo.artist", column 'name'.`})
+		assert.Equal(t, db.ErrCheckViolation, db.ErrorKind(err))
+		assert.Equal(t, "artist_name_check", err.(*db.Error).Constraint)
+		assert.Equal(t, []string{"name"}, err.(*db.Error).Columns)
+	}
+
+	assert.Equal(t, db.ErrSerializationFailure, db.ErrorKind(d.Err(mssqldriver.Error{Number: 1205, Message: "Transaction was deadlocked"})))
+
+	syntaxErr := mssqldriver.Error{Number: 102, Message: "Incorrect syntax"}
+	assert.Equal(t, syntaxErr, d.Err(syntaxErr))
+	assert.Nil(t, d.Err(nil))
+}
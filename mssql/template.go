@@ -42,9 +42,17 @@ const (
 	adapterClauseGroup         = `({{.}})`
 	adapterClauseOperator      = ` {{.}} `
 	adapterColumnValue         = `{{.Column}} {{.Operator}} {{.Value}}`
+	adapterILikeLayout         = `LOWER({{.Column}}) {{.Operator}} LOWER({{.Value}})`
+	adapterMatchLayout         = `FREETEXT({{.Column}}, {{.Value}})`
 	adapterTableAliasLayout    = `{{.Name}}{{if .Alias}} AS {{.Alias}}{{end}}`
 	adapterColumnAliasLayout   = `{{.Name}}{{if .Alias}} AS {{.Alias}}{{end}}`
 	adapterSortByColumnLayout  = `{{.Column}} {{.Order}}`
+	adapterWithLayout          = `WITH {{.Clauses}}`
+	adapterLockLayout          = `WITH ({{if .Share}}HOLDLOCK{{else}}UPDLOCK{{end}}, ROWLOCK{{if .SkipLocked}}, READPAST{{else if .NoWait}}, NOWAIT{{end}})`
+	adapterRollupLayout        = `ROLLUP({{.}})`
+	adapterCubeLayout          = `CUBE({{.}})`
+	adapterGroupingSetsLayout  = `GROUPING SETS ({{.}})`
+	adapterValuesListLayout    = `(VALUES {{range $i, $r := .Rows}}{{if $i}}, {{end}}({{$r}}){{end}}){{if .Name}} AS {{.Name}}{{if .Columns}} ({{range $i, $c := .Columns}}{{if $i}}, {{end}}{{$c.Name}}{{end}}){{end}}{{end}}`
 
 	adapterOrderByLayout = `{{if .SortColumns}}ORDER BY {{.SortColumns}}{{end}}`
 
@@ -105,6 +113,9 @@ const (
 
 				{{if .Table}}
 					FROM {{.Table}}
+					{{if .Lock}}
+						{{.Lock}}
+					{{end}}
 				{{end}}
 
 				{{.Joins}}
@@ -122,12 +133,37 @@ const (
 	adapterDeleteLayout = `
     DELETE
       FROM {{.Table}}
+    {{if .Returning}}
+      OUTPUT {{range $i, $c := .ReturningColumns}}{{if $i}}, {{end}}{{if $c.Source}}{{$c.Source}}{{else}}DELETED{{end}}.{{$c.Name}}{{end}}
+    {{end}}
+      {{.Where}}
+  `
+	adapterDeleteUsingLayout = `
+    DELETE
+      FROM {{.Table}}
+    {{if .Returning}}
+      OUTPUT {{range $i, $c := .ReturningColumns}}{{if $i}}, {{end}}{{if $c.Source}}{{$c.Source}}{{else}}DELETED{{end}}.{{$c.Name}}{{end}}
+    {{end}}
+    FROM {{.Sources}}
       {{.Where}}
   `
 	adapterUpdateLayout = `
     UPDATE
       {{.Table}}
     SET {{.ColumnValues}}
+    {{if .Returning}}
+      OUTPUT {{range $i, $c := .ReturningColumns}}{{if $i}}, {{end}}{{if $c.Source}}{{$c.Source}}{{else}}INSERTED{{end}}.{{$c.Name}}{{end}}
+    {{end}}
+      {{ .Where }}
+  `
+	adapterUpdateFromLayout = `
+    UPDATE
+      {{.Table}}
+    SET {{.ColumnValues}}
+    {{if .Returning}}
+      OUTPUT {{range $i, $c := .ReturningColumns}}{{if $i}}, {{end}}{{if $c.Source}}{{$c.Source}}{{else}}INSERTED{{end}}.{{$c.Name}}{{end}}
+    {{end}}
+    FROM {{.Sources}}
       {{ .Where }}
   `
 
@@ -141,14 +177,36 @@ const (
 	adapterInsertLayout = `
     INSERT INTO {{.Table}}
       {{if .Columns }}({{.Columns}}){{end}}
+    {{if .Returning}}
+      OUTPUT {{range $i, $c := .ReturningColumns}}{{if $i}}, {{end}}{{if $c.Source}}{{$c.Source}}{{else}}INSERTED{{end}}.{{$c.Name}}{{end}}
+    {{end}}
     VALUES
     {{if .Values}}
       {{.Values}}
     {{else}}
       (DEFAULT)
     {{end}}
+  `
+
+	adapterMergeLayout = `
+    MERGE INTO {{.Table}} WITH (HOLDLOCK)
+    USING {{.Sources}}
+      {{.On}}
+      {{.MergeActions}}
     {{if .Returning}}
-      RETURNING {{.Returning}}
+      OUTPUT {{range $i, $c := .ReturningColumns}}{{if $i}}, {{end}}{{if $c.Source}}{{$c.Source}}{{else}}INSERTED{{end}}.{{$c.Name}}{{end}}
+    {{end}}
+    ;
+  `
+
+	adapterMergeActionLayout = `
+    WHEN {{if not .Matched}}NOT {{end}}MATCHED THEN
+    {{if .Delete}}
+      DELETE
+    {{else if .Matched}}
+      UPDATE SET {{.ColumnValues}}
+    {{else}}
+      INSERT ({{.Columns}}) VALUES {{.Values}}
     {{end}}
   `
 
@@ -171,10 +229,40 @@ const (
   `
 )
 
+const (
+	adapterCreateTableLayout      = `{{if .IfNotExists}}IF OBJECT_ID(N'{{.Table}}', N'U') IS NULL {{end}}CREATE TABLE {{.Table}} ({{.Definitions}}{{if .PrimaryKey}}, PRIMARY KEY ({{.PrimaryKey}}){{end}})`
+	adapterCreateIndexLayout      = `CREATE {{if .Unique}}UNIQUE {{end}}INDEX {{.Index}} ON {{.Table}} ({{.Columns}})`
+	adapterAddColumnLayout        = `ALTER TABLE {{.Table}} ADD {{.Column}}`
+	adapterDropColumnLayout       = `ALTER TABLE {{.Table}} DROP COLUMN {{.Column}}`
+	adapterRenameColumnLayout     = `EXEC sp_rename '{{.Table}}.{{.Column}}', '{{.NewName}}', 'COLUMN'`
+	adapterColumnDefinitionLayout = `{{.Name}} {{.Type}}{{if .AutoIncrement}} IDENTITY(1,1){{end}}{{if .NotNull}} NOT NULL{{end}}{{if .Default}} DEFAULT {{.Default}}{{end}}{{if .PrimaryKey}} PRIMARY KEY{{end}}{{if .Unique}} UNIQUE{{end}}`
+	adapterTrueKeyword            = `1`
+	adapterFalseKeyword           = `0`
+)
+
+var adapterColumnTypes = map[string]string{
+	"boolean":   `BIT`,
+	"smallint":  `SMALLINT`,
+	"integer":   `INT`,
+	"bigint":    `BIGINT`,
+	"float":     `FLOAT`,
+	"decimal":   `DECIMAL{{if .Size}}({{.Size}}, {{.Scale}}){{end}}`,
+	"string":    `NVARCHAR({{.Size}})`,
+	"text":      `NVARCHAR(MAX)`,
+	"bytes":     `VARBINARY(MAX)`,
+	"date":      `DATE`,
+	"timestamp": `DATETIME2`,
+	"interval":  `NVARCHAR(64)`,
+	"json":      `NVARCHAR(MAX)`,
+	"uuid":      `UNIQUEIDENTIFIER`,
+	"enum":      `NVARCHAR({{.Size}}) CHECK ({{.Name}} IN ({{.Values}}))`,
+}
+
 var template = &exql.Template{
 	ColumnSeparator:     adapterColumnSeparator,
 	IdentifierSeparator: adapterIdentifierSeparator,
 	IdentifierQuote:     adapterIdentifierQuote,
+	ILikeLayout:         adapterILikeLayout,
 	ValueSeparator:      adapterValueSeparator,
 	ValueQuote:          adapterValueQuote,
 	AndKeyword:          adapterAndKeyword,
@@ -191,18 +279,39 @@ var template = &exql.Template{
 	ColumnAliasLayout:   adapterColumnAliasLayout,
 	SortByColumnLayout:  adapterSortByColumnLayout,
 	WhereLayout:         adapterWhereLayout,
+	WithLayout:          adapterWithLayout,
 	JoinLayout:          adapterJoinLayout,
+	LockLayout:          adapterLockLayout,
 	OnLayout:            adapterOnLayout,
 	UsingLayout:         adapterUsingLayout,
+	ValuesListLayout:    adapterValuesListLayout,
 	OrderByLayout:       adapterOrderByLayout,
+	MatchLayout:         adapterMatchLayout,
 	InsertLayout:        adapterInsertLayout,
+	MergeLayout:         adapterMergeLayout,
+	MergeActionLayout:   adapterMergeActionLayout,
 	SelectLayout:        adapterSelectLayout,
 	UpdateLayout:        adapterUpdateLayout,
+	UpdateFromLayout:    adapterUpdateFromLayout,
 	DeleteLayout:        adapterDeleteLayout,
+	DeleteUsingLayout:   adapterDeleteUsingLayout,
 	TruncateLayout:      adapterTruncateLayout,
 	DropDatabaseLayout:  adapterDropDatabaseLayout,
 	DropTableLayout:     adapterDropTableLayout,
 	CountLayout:         adapterSelectCountLayout,
 	GroupByLayout:       adapterGroupByLayout,
-	Cache:               cache.NewCache(),
+	RollupLayout:        adapterRollupLayout,
+	CubeLayout:          adapterCubeLayout,
+	GroupingSetsLayout:  adapterGroupingSetsLayout,
+
+	CreateTableLayout:      adapterCreateTableLayout,
+	CreateIndexLayout:      adapterCreateIndexLayout,
+	AddColumnLayout:        adapterAddColumnLayout,
+	DropColumnLayout:       adapterDropColumnLayout,
+	RenameColumnLayout:     adapterRenameColumnLayout,
+	ColumnDefinitionLayout: adapterColumnDefinitionLayout,
+	ColumnTypes:            adapterColumnTypes,
+	TrueKeyword:            adapterTrueKeyword,
+	FalseKeyword:           adapterFalseKeyword,
+	Cache:                  cache.NewCache(),
 }
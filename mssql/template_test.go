@@ -144,6 +144,31 @@ func TestTemplateSelect(t *testing.T) {
 		"SELECT DATE()",
 		b.Select(db.Raw("DATE()")).String(),
 	)
+
+	assert.Equal(
+		"SELECT * FROM [jobs] WITH (UPDLOCK, ROWLOCK, READPAST) WHERE ([status] = $1)",
+		b.SelectFrom("jobs").Where("status", "pending").ForUpdate().SkipLocked().String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM [jobs] WITH (HOLDLOCK, ROWLOCK, NOWAIT)",
+		b.SelectFrom("jobs").ForShare().NoWait().String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM [sales] GROUP BY CUBE([country], [product])",
+		b.SelectFrom("sales").GroupBy(db.Cube("country", "product")).String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM [books] WHERE ((([author_id] = $1 AND [title] = $2) OR ([author_id] = $3 AND [title] = $4)))",
+		b.SelectFrom("books").Where(db.In([]string{"author_id", "title"}, [][]interface{}{{1, "Hamlet"}, {2, "Ulysses"}})).String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM [books] WHERE (NOT (([author_id] = $1 AND [title] = $2)))",
+		b.SelectFrom("books").Where(db.Cond{"(author_id, title) NOT IN": [][]interface{}{{1, "Hamlet"}}}).String(),
+	)
 }
 
 func TestTemplateInsert(t *testing.T) {
@@ -165,10 +190,20 @@ func TestTemplateInsert(t *testing.T) {
 	)
 
 	assert.Equal(
-		"INSERT INTO [artist] ([id], [name]) VALUES ($1, $2) RETURNING [id]",
+		"INSERT INTO [artist] ([id], [name]) OUTPUT INSERTED.[id] VALUES ($1, $2)",
 		b.InsertInto("artist").Values(map[string]string{"id": "12", "name": "Chavela Vargas"}).Returning("id").String(),
 	)
 
+	assert.Equal(
+		"INSERT INTO [artist] ([id], [name]) OUTPUT INSERTED.[id], INSERTED.[name] VALUES ($1, $2)",
+		b.InsertInto("artist").Values(map[string]string{"id": "12", "name": "Chavela Vargas"}).Output("id", "inserted.name").String(),
+	)
+
+	assert.Equal(
+		"INSERT INTO [artist] ([name]) OUTPUT INSERTED.* VALUES ($1)",
+		b.InsertInto("artist").Values(map[string]string{"name": "Chavela Vargas"}).Returning("*").String(),
+	)
+
 	assert.Equal(
 		"INSERT INTO [artist] ([id], [name]) VALUES ($1, $2)",
 		b.InsertInto("artist").Values(map[string]interface{}{"name": "Chavela Vargas", "id": 12}).String(),
@@ -202,6 +237,21 @@ func TestTemplateUpdate(t *testing.T) {
 		b.Update("artist").Set("name = ?", "Artist").Where("id <", 5).String(),
 	)
 
+	assert.Equal(
+		"UPDATE [artist] SET [name] = $1 OUTPUT INSERTED.[id], INSERTED.[name] WHERE ([id] < $2)",
+		b.Update("artist").Set("name = ?", "Artist").Where("id <", 5).Returning("id", "name").String(),
+	)
+
+	assert.Equal(
+		"UPDATE [artist] SET [name] = $1 OUTPUT DELETED.[name], INSERTED.[name] WHERE ([id] = $2)",
+		b.Update("artist").Set("name = ?", "Artist").Where("id", 5).Output("DELETED.name", "INSERTED.name").String(),
+	)
+
+	assert.Equal(
+		"UPDATE [artist] SET [name] = $1 OUTPUT INSERTED.* WHERE ([id] = $2)",
+		b.Update("artist").Set("name = ?", "Artist").Where("id", 5).Returning("*").String(),
+	)
+
 	assert.Equal(
 		"UPDATE [artist] SET [name] = $1 WHERE ([id] < $2)",
 		b.Update("artist").Set(map[string]string{"name": "Artist"}).Where(db.Cond{"id <": 5}).String(),
@@ -228,6 +278,11 @@ func TestTemplateUpdate(t *testing.T) {
 			"id = id + ?", 10,
 		).Where("id > ?", 0).String(),
 	)
+
+	assert.Equal(
+		"UPDATE [products] SET [price] = prices.amount OUTPUT INSERTED.[id] FROM [prices] WHERE (products.id = prices.product_id)",
+		b.Update("products").Set("price = prices.amount").From("prices").Where("products.id = prices.product_id").Returning("id").String(),
+	)
 }
 
 func TestTemplateDelete(t *testing.T) {
@@ -243,4 +298,118 @@ func TestTemplateDelete(t *testing.T) {
 		"DELETE FROM [artist] WHERE (id > 5)",
 		b.DeleteFrom("artist").Where("id > 5").String(),
 	)
+
+	assert.Equal(
+		"DELETE FROM [artist] OUTPUT DELETED.[id] WHERE (id > 5)",
+		b.DeleteFrom("artist").Where("id > 5").Returning("id").String(),
+	)
+
+	assert.Equal(
+		"DELETE FROM [artist] OUTPUT DELETED.[id], DELETED.[name] WHERE (id > 5)",
+		b.DeleteFrom("artist").Where("id > 5").Output("id", "DELETED.name").String(),
+	)
+
+	assert.Equal(
+		"DELETE FROM [orders] FROM [customers] WHERE (orders.customer_id = customers.id AND customers.banned = $1)",
+		b.DeleteFrom("orders").Using("customers").Where("orders.customer_id = customers.id AND customers.banned = ?", true).String(),
+	)
+}
+
+func TestTemplateMerge(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	src := db.Values([][]interface{}{{1, 5}}).As("src(id, qty)")
+
+	assert.Equal(
+		"MERGE INTO [inventory] WITH (HOLDLOCK) USING (VALUES ($1, $2)) AS [src] ([id], [qty]) ON (inventory.id = src.id) WHEN MATCHED THEN UPDATE SET [qty] = inventory.qty + src.qty WHEN NOT MATCHED THEN INSERT ([id], [qty]) VALUES (src.id, src.qty) ;",
+		b.MergeInto("inventory").
+			Using(src).
+			On("inventory.id = src.id").
+			WhenMatchedUpdate("qty = inventory.qty + src.qty").
+			WhenNotMatchedInsert(map[string]interface{}{
+				"id":  db.Raw("src.id"),
+				"qty": db.Raw("src.qty"),
+			}).
+			String(),
+	)
+
+	{
+		q := b.MergeInto("inventory").
+			Using("incoming").
+			On(db.Cond{"inventory.id": db.Raw("incoming.id")}).
+			WhenMatchedUpdate("qty", 10).
+			WhenNotMatchedInsert(map[string]interface{}{"id": 3, "qty": 10}).
+			Output("INSERTED.id", "DELETED.qty")
+
+		assert.Equal(
+			"MERGE INTO [inventory] WITH (HOLDLOCK) USING [incoming] ON ([inventory].[id] = incoming.id) WHEN MATCHED THEN UPDATE SET [qty] = $1 WHEN NOT MATCHED THEN INSERT ([id], [qty]) VALUES ($2, $3) OUTPUT INSERTED.[id], DELETED.[qty] ;",
+			q.String(),
+		)
+		assert.Equal([]interface{}{10, 3, 10}, q.Arguments())
+	}
+
+	assert.Equal(
+		"MERGE INTO [sessions] WITH (HOLDLOCK) USING [expired] ON (sessions.id = expired.id AND sessions.user_id = $1) WHEN MATCHED THEN DELETE ;",
+		b.MergeInto("sessions").
+			Using("expired").
+			On("sessions.id = expired.id AND sessions.user_id = ?", 7).
+			WhenMatchedDelete().
+			String(),
+	)
+}
+
+func TestTemplateMatch(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		`SELECT * FROM [docs] WHERE (FREETEXT([body], $1))`,
+		b.SelectFrom("docs").Where(db.Match("body", "quick fox")).String(),
+	)
+
+	_, err := b.SelectFrom("docs").OrderBy(db.Rank("body", "quick fox")).(interface {
+		Compile() (string, error)
+	}).Compile()
+	assert.Error(err)
+}
+
+func TestTemplateDDL(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		"CREATE TABLE [books] ([id] BIGINT IDENTITY(1,1) PRIMARY KEY, [title] NVARCHAR(255) NOT NULL, [body] NVARCHAR(MAX), [public] BIT NOT NULL DEFAULT 1, [created_at] DATETIME2)",
+		b.CreateTable("books").
+			Columns(
+				sqlbuilder.Column{Name: "id", Type: sqlbuilder.BigInt, AutoIncrement: true},
+				sqlbuilder.Column{Name: "title", Type: sqlbuilder.String, NotNull: true},
+				sqlbuilder.Column{Name: "body", Type: sqlbuilder.Text},
+				sqlbuilder.Column{Name: "public", Type: sqlbuilder.Boolean, NotNull: true, Default: true},
+				sqlbuilder.Column{Name: "created_at", Type: sqlbuilder.Timestamp},
+			).
+			PrimaryKey("id").
+			String(),
+	)
+
+	assert.Equal(
+		"CREATE TABLE [tags] ([book_id] BIGINT NOT NULL, [tag] NVARCHAR(32) NOT NULL, PRIMARY KEY ([book_id], [tag]))",
+		b.CreateTable("tags").
+			Columns(
+				sqlbuilder.Column{Name: "book_id", Type: sqlbuilder.BigInt, NotNull: true},
+				sqlbuilder.Column{Name: "tag", Type: sqlbuilder.String, Size: 32, NotNull: true},
+			).
+			PrimaryKey("book_id", "tag").
+			String(),
+	)
+
+	assert.Equal(
+		"CREATE UNIQUE INDEX [books_title_idx] ON [books] ([title])",
+		b.CreateIndex("books_title_idx").Unique().On("books", "title").String(),
+	)
+
+	assert.Equal(
+		"ALTER TABLE [books] ADD [isbn] NVARCHAR(13); ALTER TABLE [books] DROP COLUMN [body]",
+		b.AlterTable("books").AddColumn(sqlbuilder.Column{Name: "isbn", Type: sqlbuilder.String, Size: 13}).DropColumn("body").String(),
+	)
 }
@@ -23,6 +23,7 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -30,6 +31,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
 	"upper.io/db.v3/internal/sqladapter"
 	"upper.io/db.v3/lib/sqlbuilder"
 )
@@ -209,6 +211,173 @@ func TestInsertReturningCompositeKey_Issue383(t *testing.T) {
 	assert.Equal(t, "E10ADC3949BA59ABBE56E057F20F883E", a.LoginPassWord)
 }
 
+func TestJSONTagOption(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE IF NOT EXISTS json_payloads (id BIGINT AUTO_INCREMENT PRIMARY KEY, payload TEXT)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE json_payloads`)
+
+	type payload struct {
+		Status string   `json:"status"`
+		Tags   []string `json:"tags"`
+	}
+
+	type item struct {
+		ID      int64   `db:"id,omitempty"`
+		Payload payload `db:"payload,json"`
+	}
+
+	col := sess.Collection("json_payloads")
+
+	in := item{Payload: payload{Status: "active", Tags: []string{"go", "sql"}}}
+	assert.NoError(t, col.InsertReturning(&in))
+
+	var raw string
+	row, err := sess.QueryRow(`SELECT payload FROM json_payloads WHERE id = ?`, in.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, row.Scan(&raw))
+	assert.Equal(t, `{"status":"active","tags":["go","sql"]}`, raw)
+
+	var out item
+	assert.NoError(t, col.Find(in.ID).One(&out))
+	assert.Equal(t, in, out)
+
+	out.Payload.Status = "inactive"
+	assert.NoError(t, col.Find(in.ID).Update(out))
+
+	var updated item
+	assert.NoError(t, col.Find(in.ID).One(&updated))
+	assert.Equal(t, out, updated)
+}
+
+func TestDistributedTx(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE IF NOT EXISTS distributed_items (id BIGINT AUTO_INCREMENT PRIMARY KEY, name TEXT) ENGINE=InnoDB`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE distributed_items`)
+
+	dsess := sess.(sqlbuilder.DistributedTxSession)
+	ctx := context.Background()
+
+	_, err = dsess.NewDistributedTx(ctx, "it's")
+	assert.Equal(t, sqlbuilder.ErrInvalidGID, err)
+
+	tx, err := dsess.NewDistributedTx(ctx, "upper-test-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "upper-test-1", tx.GID())
+
+	_, err = tx.Collection("distributed_items").Insert(map[string]interface{}{"name": "prepared"})
+	assert.NoError(t, err)
+
+	err = tx.PrepareCommit()
+	assert.NoError(t, err)
+
+	gids, err := dsess.PreparedTransactions()
+	assert.NoError(t, err)
+	assert.Contains(t, gids, "upper-test-1")
+
+	assert.NoError(t, tx.Commit())
+
+	gids, err = dsess.PreparedTransactions()
+	assert.NoError(t, err)
+	assert.NotContains(t, gids, "upper-test-1")
+
+	count, err := sess.Collection("distributed_items").Find().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	tx, err = dsess.NewDistributedTx(ctx, "upper-test-2")
+	assert.NoError(t, err)
+	_, err = tx.Collection("distributed_items").Insert(map[string]interface{}{"name": "rolled back"})
+	assert.NoError(t, err)
+	assert.NoError(t, tx.PrepareCommit())
+	assert.NoError(t, tx.Rollback())
+
+	count, err = sess.Collection("distributed_items").Find().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+}
+
+func TestIntervalTagOption(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	type jobT struct {
+		ID        int64          `db:"id,pk,omitempty"`
+		Timeout   time.Duration  `db:"timeout,interval"`
+		Retry     *time.Duration `db:"retry,interval"`
+		CreatedAt time.Time      `db:"created_at"`
+	}
+
+	_, err := sess.CreateTable("interval_jobs").FromStruct(jobT{}).Exec()
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE interval_jobs`)
+
+	col := sess.Collection("interval_jobs")
+
+	job := jobT{Timeout: -(838*time.Hour + 59*time.Minute + 59*time.Second + 500*time.Millisecond), CreatedAt: time.Now().Add(-48 * time.Hour)}
+	assert.NoError(t, col.InsertReturning(&job))
+
+	var raw string
+	row, err := sess.QueryRow(`SELECT timeout FROM interval_jobs WHERE id = ?`, job.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, row.Scan(&raw))
+	assert.Equal(t, "-838:59:59.500000", raw)
+
+	var stored jobT
+	assert.NoError(t, col.Find(job.ID).One(&stored))
+	assert.Equal(t, job.Timeout, stored.Timeout)
+	assert.Nil(t, stored.Retry)
+
+	count, err := col.Find(OlderThan("created_at", 24*time.Hour)).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	count, err = col.Find(WithinLast("created_at", "P1D")).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), count)
+}
+
+func TestEnumTagOption(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	type ticketT struct {
+		ID       int64   `db:"id,pk,omitempty"`
+		Status   string  `db:"status,enum=open|closed"`
+		Priority *uint16 `db:"priority,enum=low|normal|high"`
+	}
+
+	_, err := sess.CreateTable("enum_tickets").FromStruct(ticketT{}).Exec()
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE enum_tickets`)
+
+	col := sess.Collection("enum_tickets")
+
+	high := uint16(2)
+	tickets := []ticketT{{Status: "open", Priority: &high}, {Status: "closed"}}
+	for i := range tickets {
+		assert.NoError(t, col.InsertReturning(&tickets[i]))
+	}
+
+	var stored []ticketT
+	assert.NoError(t, col.Find().OrderBy("id").All(&stored))
+	assert.Equal(t, tickets, stored)
+
+	var raw string
+	row, err := sess.QueryRow(`SELECT priority FROM enum_tickets WHERE id = ?`, tickets[0].ID)
+	assert.NoError(t, err)
+	assert.NoError(t, row.Scan(&raw))
+	assert.Equal(t, "high", raw)
+
+	_, err = col.Insert(ticketT{Status: "pending"})
+	assert.IsType(t, &db.EnumError{}, err)
+}
+
 func cleanUpCheck(sess sqlbuilder.Database) (err error) {
 	var stats map[string]int
 
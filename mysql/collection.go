@@ -62,7 +62,11 @@ func (t *table) Database() sqladapter.Database {
 
 // Insert inserts an item (map or struct) into the collection.
 func (t *table) Insert(item interface{}) (interface{}, error) {
-	columnNames, columnValues, err := sqlbuilder.Map(item, nil)
+	return sqladapter.InsertWithHooks(t.d, item, t.insert)
+}
+
+func (t *table) insert(item interface{}) (interface{}, error) {
+	columnNames, columnValues, err := sqlbuilder.Map(item, &sqlbuilder.MapOptions{Mapper: t.d.Mapper(), Codec: t.d.Codec})
 	if err != nil {
 		return nil, err
 	}
@@ -22,11 +22,15 @@
 package mysql
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"net"
 	"net/url"
 	"strings"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter"
 )
 
 // From https://github.com/go-sql-driver/mysql/blob/master/utils.go
@@ -47,6 +51,16 @@ type config struct {
 }
 
 // ConnectionURL implements a MySQL connection struct.
+//
+// Options that are not known by the driver are set as system variables on
+// every new connection of the pool, like "sql_mode" or "lock_wait_timeout".
+// TimeZone sets the time_zone variable and OnConnect is called next, for any
+// other setup:
+//
+//	settings.TimeZone = "+00:00"
+//	settings.OnConnect = func(conn db.Conn) error {
+//		return conn.Exec("SET @tenant_id = ?", 1)
+//	}
 type ConnectionURL struct {
 	User     string
 	Password string
@@ -54,6 +68,38 @@ type ConnectionURL struct {
 	Host     string
 	Socket   string
 	Options  map[string]string
+
+	TimeZone string
+
+	OnConnect func(db.Conn) error
+}
+
+// setup returns the function that sets up every new connection of the pool
+// of c.
+func (c ConnectionURL) setup() func(db.Conn) error {
+	if c.TimeZone == "" {
+		return c.OnConnect
+	}
+	return func(conn db.Conn) error {
+		if err := conn.Exec("SET time_zone = ?", c.TimeZone); err != nil {
+			return err
+		}
+		if c.OnConnect != nil {
+			return c.OnConnect(conn)
+		}
+		return nil
+	}
+}
+
+// openDB opens a pool of connections to the server of connURL.
+func openDB(connURL db.ConnectionURL) (*sql.DB, error) {
+	switch c := connURL.(type) {
+	case ConnectionURL:
+		return sqladapter.OpenDB("mysql", c.String(), nil, c.setup())
+	case *ConnectionURL:
+		return sqladapter.OpenDB("mysql", c.String(), nil, c.setup())
+	}
+	return sql.Open("mysql", connURL.String())
 }
 
 func (c ConnectionURL) String() (s string) {
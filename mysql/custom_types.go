@@ -0,0 +1,94 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package mysql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// JSON represents a MySQL's JSON column, the wrapped value is encoded to and
+// decoded from JSON:
+//
+//	type Order struct {
+//		ID   int64      `db:"id,omitempty"`
+//		Data mysql.JSON `db:"data"`
+//	}
+//
+// A json.RawMessage value is sent to the database as is. Struct fields of any
+// type can also be mapped to JSON columns with the json tag option:
+//
+//	Data map[string]interface{} `db:"data,json"`
+//
+// Use JSON path operators to match values within JSON columns:
+//
+//	db.Cond{"data->>'$.status'": "shipped"}
+//	db.Cond{"JSON_LENGTH(data, '$.items') >": 2}
+type JSON struct {
+	V interface{}
+}
+
+// Scan implements the sql.Scanner interface.
+func (j *JSON) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		j.V = nil
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return errors.New("Scan source was not []bytes")
+	}
+
+	v := JSON{}
+	if err := json.Unmarshal(b, &v.V); err != nil {
+		return err
+	}
+	*j = v
+	return nil
+}
+
+// Value implements the driver.Valuer interface. Values are sent as text,
+// MySQL rejects binary strings for JSON columns.
+func (j JSON) Value() (driver.Value, error) {
+	if j.V == nil {
+		return nil, nil
+	}
+	if v, ok := j.V.(json.RawMessage); ok {
+		return string(v), nil
+	}
+	b, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+var (
+	_ sql.Scanner   = &JSON{}
+	_ driver.Valuer = JSON{}
+)
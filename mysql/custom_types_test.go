@@ -0,0 +1,42 @@
+package mysql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSON(t *testing.T) {
+	{
+		var j JSON
+		assert.NoError(t, j.Scan([]byte(`{"status":"active","items":[1,2]}`)))
+		assert.Equal(t, map[string]interface{}{
+			"status": "active",
+			"items":  []interface{}{float64(1), float64(2)},
+		}, j.V)
+
+		assert.NoError(t, j.Scan(`"text"`))
+		assert.Equal(t, "text", j.V)
+
+		assert.NoError(t, j.Scan(nil))
+		assert.Nil(t, j.V)
+
+		assert.Error(t, j.Scan(42))
+		assert.Error(t, j.Scan([]byte(`{`)))
+	}
+
+	{
+		v, err := JSON{V: map[string]interface{}{"status": "active"}}.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, `{"status":"active"}`, v)
+
+		v, err = JSON{V: json.RawMessage(`{"raw": true}`)}.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, `{"raw": true}`, v)
+
+		v, err = JSON{}.Value()
+		assert.NoError(t, err)
+		assert.Nil(t, v)
+	}
+}
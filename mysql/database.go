@@ -26,12 +26,12 @@ package mysql
 
 import (
 	"context"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 
-	"database/sql"
-
-	_ "github.com/go-sql-driver/mysql" // MySQL driver.
+	mysqldriver "github.com/go-sql-driver/mysql" // MySQL driver.
 	"upper.io/db.v3"
 	"upper.io/db.v3/internal/sqladapter"
 	"upper.io/db.v3/internal/sqladapter/compat"
@@ -91,7 +91,7 @@ func (d *database) NewTx(ctx context.Context) (sqlbuilder.Tx, error) {
 func (d *database) Collections() (collections []string, err error) {
 	q := d.Select("table_name").
 		From("information_schema.tables").
-		Where("table_schema = ?", d.BaseDatabase.Name())
+		Where("table_schema = ?", d.schemaName(""))
 
 	iter := q.Iterator()
 	defer iter.Close()
@@ -116,7 +116,7 @@ func (d *database) open() error {
 	d.SQLBuilder = sqlbuilder.WithSession(d.BaseDatabase, template)
 
 	connFn := func() error {
-		sess, err := sql.Open("mysql", d.ConnectionURL().String())
+		sess, err := openDB(d.ConnectionURL())
 		if err == nil {
 			sess.SetConnMaxLifetime(db.DefaultSettings.ConnMaxLifetime())
 			sess.SetMaxIdleConns(db.DefaultSettings.MaxIdleConns())
@@ -161,7 +161,8 @@ func (d *database) CompileStatement(stmt *exql.Statement, args []interface{}) (s
 }
 
 // Err allows sqladapter to translate specific MySQL string errors into custom
-// error values.
+// error values, constraint violations and deadlocks are wrapped into a
+// *db.Error.
 func (d *database) Err(err error) error {
 	if err != nil {
 		// This error is not exported so we have to check it by its string value.
@@ -169,10 +170,58 @@ func (d *database) Err(err error) error {
 		if strings.Contains(s, `many connections`) {
 			return db.ErrTooManyClients
 		}
+		if e, ok := err.(*mysqldriver.MySQLError); ok {
+			return classifyErr(e)
+		}
 	}
 	return err
 }
 
+var (
+	errDuplicateKey    = regexp.MustCompile("for key '([^']+)'")
+	errForeignKey      = regexp.MustCompile("^[^(]+\\((`[^`]+`\\.)?`([^`]+)`, CONSTRAINT `([^`]+)` FOREIGN KEY \\(([^)]+)\\)")
+	errCheckConstraint = regexp.MustCompile("^Check constraint '([^']+)'")
+)
+
+// classifyErr wraps constraint violations and deadlocks into a *db.Error,
+// MySQL only reports the names of the constraint and the columns involved as
+// part of the error message.
+func classifyErr(e *mysqldriver.MySQLError) error {
+	switch e.Number {
+	case 1062: // ER_DUP_ENTRY
+		dbErr := &db.Error{Kind: db.ErrUniqueViolation, Err: e}
+		if m := errDuplicateKey.FindStringSubmatch(e.Message); m != nil {
+			// MySQL 8.0 prefixes the name of the key with the name of the table.
+			if i := strings.LastIndex(m[1], "."); i >= 0 {
+				dbErr.Table, dbErr.Constraint = m[1][:i], m[1][i+1:]
+			} else {
+				dbErr.Constraint = m[1]
+			}
+		}
+		return dbErr
+	case 1216, 1217, 1451, 1452: // ER_NO_REFERENCED_ROW, ER_ROW_IS_REFERENCED
+		dbErr := &db.Error{Kind: db.ErrForeignKeyViolation, Err: e}
+		if m := errForeignKey.FindStringSubmatch(e.Message); m != nil {
+			dbErr.Table, dbErr.Constraint = m[2], m[3]
+			for _, column := range strings.Split(m[4], ",") {
+				dbErr.Columns = append(dbErr.Columns, strings.Trim(strings.TrimSpace(column), "`"))
+			}
+		}
+		return dbErr
+	case 3819: // ER_CHECK_CONSTRAINT_VIOLATED
+		dbErr := &db.Error{Kind: db.ErrCheckViolation, Err: e}
+		if m := errCheckConstraint.FindStringSubmatch(e.Message); m != nil {
+			dbErr.Constraint = m[1]
+		}
+		return dbErr
+	case 1205, 1213: // ER_LOCK_WAIT_TIMEOUT, ER_LOCK_DEADLOCK
+		// Retrying the whole transaction is safe for both, it's rolled back
+		// before being retried.
+		return &db.Error{Kind: db.ErrSerializationFailure, Err: e}
+	}
+	return e
+}
+
 // NewCollection creates a db.Collection by name.
 func (d *database) NewCollection(name string) db.Collection {
 	return newTable(d, name)
@@ -230,9 +279,11 @@ func (d *database) LookupName() (string, error) {
 // TableExists returns an error if the given table name does not exist on the
 // database.
 func (d *database) TableExists(name string) error {
+	schema, table := sqladapter.SplitTableName(name)
+
 	q := d.Select("table_name").
 		From("information_schema.tables").
-		Where("table_schema = ? AND table_name = ?", d.BaseDatabase.Name(), name)
+		Where("table_schema = ? AND table_name = ?", d.schemaName(schema), table)
 
 	iter := q.Iterator()
 	defer iter.Close()
@@ -247,8 +298,22 @@ func (d *database) TableExists(name string) error {
 	return db.ErrCollectionDoesNotExist
 }
 
+// schemaName returns the given database name, or the one of the session if
+// it's empty.
+func (d *database) schemaName(name string) string {
+	if name != "" {
+		return name
+	}
+	if name = d.Schema(); name != "" {
+		return name
+	}
+	return d.BaseDatabase.Name()
+}
+
 // PrimaryKeys returns the names of all the primary keys on the table.
 func (d *database) PrimaryKeys(tableName string) ([]string, error) {
+	schema, table := sqladapter.SplitTableName(tableName)
+
 	q := d.Select("k.column_name").
 		From("information_schema.table_constraints AS t").
 		Join("information_schema.key_column_usage AS k").
@@ -257,7 +322,7 @@ func (d *database) PrimaryKeys(tableName string) ([]string, error) {
 			t.constraint_type = 'primary key'
 			AND t.table_schema = ?
 			AND t.table_name = ?
-		`, d.BaseDatabase.Name(), tableName).
+		`, d.schemaName(schema), table).
 		OrderBy("k.ordinal_position")
 
 	iter := q.Iterator()
@@ -276,8 +341,89 @@ func (d *database) PrimaryKeys(tableName string) ([]string, error) {
 	return pk, nil
 }
 
+// Indexes returns the names of the indexes of the table.
+func (d *database) Indexes(tableName string) ([]string, error) {
+	schema, table := sqladapter.SplitTableName(tableName)
+
+	q := d.Select().Distinct("index_name").
+		From("information_schema.statistics").
+		Where("table_schema = ? AND table_name = ?", d.schemaName(schema), table)
+
+	iter := q.Iterator()
+	defer iter.Close()
+
+	indexes := []string{}
+
+	for iter.Next() {
+		var name string
+		if err := iter.Scan(&name); err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, name)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
+
+// ReferencedTables returns the names of the tables the foreign keys of the
+// table point to.
+func (d *database) ReferencedTables(tableName string) ([]string, error) {
+	schema, table := sqladapter.SplitTableName(tableName)
+
+	q := d.Select().Distinct("referenced_table_name").
+		From("information_schema.key_column_usage").
+		Where("table_schema = ? AND table_name = ? AND referenced_table_name IS NOT NULL", d.schemaName(schema), table)
+
+	iter := q.Iterator()
+	defer iter.Close()
+
+	tables := []string{}
+
+	for iter.Next() {
+		var name string
+		if err := iter.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
 // WithContext creates a copy of the session on the given context.
 func (d *database) WithContext(ctx context.Context) sqlbuilder.Database {
 	newDB, _ := d.clone(ctx, false)
 	return newDB
 }
+
+// WithSchema returns a session whose collections belong to the given database.
+func (d *database) WithSchema(schema string) (sqlbuilder.Database, error) {
+	sess, err := d.SchemaSession(schema, func() (sqladapter.Database, error) {
+		clone, err := d.clone(d.Context(), false)
+		if err != nil {
+			return nil, err
+		}
+		return clone, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sess.(*database), nil
+}
+
+// WithRecordCache returns a copy of the session whose primary key lookups,
+// like col.Find(id).One(&item), read items from the given store.
+func (d *database) WithRecordCache(store db.QueryCache, ttl time.Duration) (sqlbuilder.Database, error) {
+	clone, err := d.clone(d.Context(), false)
+	if err != nil {
+		return nil, err
+	}
+	clone.SetRecordCache(store, ttl)
+	return clone, nil
+}
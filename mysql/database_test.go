@@ -0,0 +1,60 @@
+package mysql
+
+import (
+	"errors"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+)
+
+func TestErr(t *testing.T) {
+	d := &database{}
+
+	{
+		err := d.Err(&mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'Ozzy' for key 'artist.artist_name_key'"})
+		if assert.IsType(t, &db.Error{}, err) {
+			dbErr := err.(*db.Error)
+			assert.Equal(t, db.ErrUniqueViolation, dbErr.Kind)
+			assert.Equal(t, "artist", dbErr.Table)
+			assert.Equal(t, "artist_name_key", dbErr.Constraint)
+		}
+
+		err = d.Err(&mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'PRIMARY'"})
+		assert.Equal(t, db.ErrUniqueViolation, db.ErrorKind(err))
+		assert.Equal(t, "", err.(*db.Error).Table)
+		assert.Equal(t, "PRIMARY", err.(*db.Error).Constraint)
+	}
+
+	{
+		err := d.Err(&mysqldriver.MySQLError{
+			Number:  1452,
+			Message: "Cannot add or update a child row: a foreign key constraint fails (`upperio`.`publication`, CONSTRAINT `publication_author_fk` FOREIGN KEY (`author_id`) REFERENCES `artist` (`id`))",
+		})
+		if assert.IsType(t, &db.Error{}, err) {
+			dbErr := err.(*db.Error)
+			assert.Equal(t, db.ErrForeignKeyViolation, dbErr.Kind)
+			assert.Equal(t, "publication", dbErr.Table)
+			assert.Equal(t, "publication_author_fk", dbErr.Constraint)
+			assert.Equal(t, []string{"author_id"}, dbErr.Columns)
+		}
+	}
+
+	{
+		err := d.Err(&mysqldriver.MySQLError{Number: 3819, Message: "Check constraint 'artist_chk_1' is violated."})
+		assert.Equal(t, db.ErrCheckViolation, db.ErrorKind(err))
+		assert.Equal(t, "artist_chk_1", err.(*db.Error).Constraint)
+	}
+
+	assert.Equal(t, db.ErrSerializationFailure, db.ErrorKind(d.Err(&mysqldriver.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"})))
+	assert.Equal(t, db.ErrSerializationFailure, db.ErrorKind(d.Err(&mysqldriver.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded; try restarting transaction"})))
+	// Errors are matched by their number, not by their message.
+	assert.Equal(t, db.ErrSerializationFailure, db.ErrorKind(d.Err(&mysqldriver.MySQLError{Number: 1213})))
+	assert.NotEqual(t, db.ErrSerializationFailure, db.ErrorKind(d.Err(errors.New("Error 1213: Deadlock found when trying to get lock"))))
+	assert.Equal(t, db.ErrTooManyClients, d.Err(&mysqldriver.MySQLError{Number: 1040, Message: "Too many connections"}))
+
+	syntaxErr := &mysqldriver.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax"}
+	assert.Equal(t, syntaxErr, d.Err(syntaxErr))
+	assert.Nil(t, d.Err(nil))
+}
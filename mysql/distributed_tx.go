@@ -0,0 +1,222 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"upper.io/db.v3/internal/sqladapter"
+	"upper.io/db.v3/internal/sqladapter/compat"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// distributedTx is an XA transaction. XA transactions are bound to the
+// connection they were started on until they're finished, so the connection
+// of a regular transaction is used and kept until Commit or Rollback.
+type distributedTx struct {
+	*tx
+
+	sess *database
+	gid  string
+
+	mu       sync.Mutex
+	prepared bool
+
+	hooks sqladapter.TxHooks
+}
+
+var (
+	_ = sqlbuilder.DistributedTx(&distributedTx{})
+	_ = sqlbuilder.DistributedTxSession(&database{})
+)
+
+// NewDistributedTx begins an XA transaction that takes part in a two-phase
+// commit, see sqlbuilder.DistributedTx.
+func (d *database) NewDistributedTx(ctx context.Context, gid string) (sqlbuilder.DistributedTx, error) {
+	if !sqladapter.ValidGID(gid) {
+		return nil, sqlbuilder.ErrInvalidGID
+	}
+	t, err := d.NewTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dtx := &distributedTx{tx: t.(*tx), sess: d, gid: gid}
+
+	// XA transactions can't be started within a local transaction, the one
+	// that was just started only holds the connection.
+	if err := dtx.exec("COMMIT", "XA START '"+gid+"'"); err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	return dtx, nil
+}
+
+// PreparedTransactions returns the identifiers of the XA transactions that
+// were prepared but not finished yet, as listed by XA RECOVER.
+func (d *database) PreparedTransactions() ([]string, error) {
+	rows, err := compat.QueryContext(d.Session(), d.Context(), `XA RECOVER`, nil)
+	if err != nil {
+		return nil, d.Err(err)
+	}
+	defer rows.Close()
+
+	gids := []string{}
+	for rows.Next() {
+		var (
+			formatID, gtridLength, bqualLength int
+			data                               []byte
+		)
+		if err := rows.Scan(&formatID, &gtridLength, &bqualLength, &data); err != nil {
+			return nil, err
+		}
+		if gtridLength > len(data) {
+			gtridLength = len(data)
+		}
+		gids = append(gids, string(data[:gtridLength]))
+	}
+	return gids, rows.Err()
+}
+
+// CommitPrepared commits the prepared XA transaction identified by gid, the
+// transaction must not be bound to the connection of another session.
+func (d *database) CommitPrepared(gid string) error {
+	return d.finishPrepared("XA COMMIT", gid)
+}
+
+// RollbackPrepared rolls back the prepared XA transaction identified by gid,
+// the transaction must not be bound to the connection of another session.
+func (d *database) RollbackPrepared(gid string) error {
+	return d.finishPrepared("XA ROLLBACK", gid)
+}
+
+func (d *database) finishPrepared(stmt string, gid string) error {
+	if !sqladapter.ValidGID(gid) {
+		return sqlbuilder.ErrInvalidGID
+	}
+	_, err := compat.ExecContext(d.Session(), d.Context(), stmt+" '"+gid+"'", nil)
+	return d.Err(err)
+}
+
+// exec runs the given XA statements as they are on the connection of the
+// transaction, XA statements can't be prepared.
+func (t *distributedTx) exec(stmts ...string) error {
+	sqlTx := t.Driver().(*sql.Tx)
+	for _, stmt := range stmts {
+		if _, err := compat.ExecContext(sqlTx, t.Context(), stmt, nil); err != nil {
+			return t.sess.Err(err)
+		}
+	}
+	return nil
+}
+
+func (t *distributedTx) GID() string {
+	return t.gid
+}
+
+// OnCommit registers a function that is called once the transaction is
+// committed, with or without being prepared.
+func (t *distributedTx) OnCommit(fn func()) {
+	t.hooks.OnCommit(fn)
+}
+
+// OnRollback registers a function that is called once the transaction is
+// rolled back.
+func (t *distributedTx) OnRollback(fn func(err error)) {
+	t.hooks.OnRollback(fn)
+}
+
+// finish calls the functions registered with OnCommit or OnRollback once the
+// outcome of the transaction is known, a prepared transaction that failed to
+// be finished is still prepared.
+func (t *distributedTx) finish(committing bool, err error) {
+	switch {
+	case committing && err == nil:
+		t.hooks.RunOnCommit()
+	case committing && !t.prepared:
+		t.hooks.RunOnRollback(err)
+	case !committing && (err == nil || !t.prepared):
+		t.hooks.RunOnRollback(nil)
+	}
+}
+
+func (t *distributedTx) PrepareCommit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.prepared {
+		return sql.ErrTxDone
+	}
+
+	if err := t.exec("XA END '"+t.gid+"'", "XA PREPARE '"+t.gid+"'"); err != nil {
+		return err
+	}
+	t.prepared = true
+	return nil
+}
+
+func (t *distributedTx) Commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var err error
+	if t.prepared {
+		err = t.exec("XA COMMIT '" + t.gid + "'")
+	} else {
+		err = t.exec("XA END '"+t.gid+"'", "XA COMMIT '"+t.gid+"' ONE PHASE")
+	}
+	if err != nil {
+		_ = t.tx.Rollback()
+		t.finish(true, err)
+		return err
+	}
+
+	// The connection is not within a transaction anymore, committing only
+	// releases it.
+	err = t.tx.Commit()
+	t.finish(true, nil)
+	return err
+}
+
+func (t *distributedTx) Rollback() (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	defer func() {
+		t.finish(false, err)
+	}()
+
+	if !t.prepared {
+		// XA END fails if the transaction was already ended by an error, it
+		// can be rolled back anyway.
+		_ = t.exec("XA END '" + t.gid + "'")
+	}
+	err = t.exec("XA ROLLBACK '" + t.gid + "'")
+
+	if rollbackErr := t.tx.Rollback(); err == nil {
+		err = rollbackErr
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package mysql
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+var (
+	planTable       = regexp.MustCompile(`\bon (\w+)`)
+	planAttrsStart  = regexp.MustCompile(`  \((cost|actual|rows)[ =]`)
+	planAttrsGroups = regexp.MustCompile(`\(([^()]*)\)`)
+)
+
+// Explain wraps a query into an EXPLAIN statement that returns its plan in
+// the tree format, which requires MySQL 8.0.16 or later (8.0.18 for EXPLAIN
+// ANALYZE).
+func (d *database) Explain(query string, analyze bool) (string, error) {
+	if analyze {
+		return "EXPLAIN ANALYZE " + query, nil
+	}
+	return "EXPLAIN FORMAT=TREE " + query, nil
+}
+
+// ScanQueryPlan reads the plan returned by an EXPLAIN statement.
+func (d *database) ScanQueryPlan(rows *sql.Rows) (*sqlbuilder.QueryPlan, error) {
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return parseQueryPlan(strings.Join(lines, "\n")), nil
+}
+
+// parseQueryPlan parses a plan in the tree format, where each step is on its
+// own line, indented under the step it feeds rows to:
+//
+//	-> Filter: (artist.id = 1)  (cost=0.35 rows=1)
+//	    -> Table scan on artist  (cost=0.35 rows=1)
+func parseQueryPlan(raw string) *sqlbuilder.QueryPlan {
+	plan := &sqlbuilder.QueryPlan{Raw: raw}
+
+	var parents []*sqlbuilder.PlanNode
+	for _, line := range strings.Split(raw, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if !strings.HasPrefix(trimmed, "->") {
+			continue
+		}
+		depth := (len(line) - len(trimmed)) / 4
+
+		node := planNode(strings.TrimSpace(strings.TrimPrefix(trimmed, "->")))
+
+		if depth > len(parents) {
+			depth = len(parents)
+		}
+		parents = append(parents[:depth], node)
+
+		if depth == 0 {
+			plan.Nodes = append(plan.Nodes, node)
+		} else {
+			parents[depth-1].Children = append(parents[depth-1].Children, node)
+		}
+	}
+
+	return plan
+}
+
+func planNode(step string) *sqlbuilder.PlanNode {
+	node := &sqlbuilder.PlanNode{Attributes: map[string]interface{}{}}
+
+	if loc := planAttrsStart.FindStringIndex(step); loc != nil {
+		for _, group := range planAttrsGroups.FindAllStringSubmatch(step[loc[0]:], -1) {
+			if strings.HasPrefix(group[1], "actual ") {
+				node.Attributes["actual"] = strings.TrimPrefix(group[1], "actual ")
+			} else {
+				node.Attributes["estimate"] = group[1]
+			}
+		}
+		step = step[:loc[0]]
+	}
+
+	node.Operation = step
+	if m := planTable.FindStringSubmatch(step); m != nil {
+		node.Table = m[1]
+	}
+
+	return node
+}
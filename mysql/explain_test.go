@@ -0,0 +1,38 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryPlan(t *testing.T) {
+	raw := "-> Nested loop inner join  (cost=0.70 rows=1) (actual time=0.031..0.035 rows=1 loops=1)\n" +
+		"    -> Filter: (a.id = 1)  (cost=0.35 rows=1) (actual time=0.020..0.022 rows=1 loops=1)\n" +
+		"        -> Table scan on a  (cost=0.35 rows=1) (actual time=0.018..0.020 rows=1 loops=1)\n" +
+		"    -> Single-row index lookup on p using PRIMARY (id=a.id)  (cost=0.35 rows=1) (actual time=0.009..0.009 rows=1 loops=1)\n"
+
+	plan := parseQueryPlan(raw)
+	assert.Equal(t, raw, plan.Raw)
+
+	if assert.Equal(t, 1, len(plan.Nodes)) {
+		root := plan.Nodes[0]
+		assert.Equal(t, "Nested loop inner join", root.Operation)
+		assert.Equal(t, "", root.Table)
+		assert.Equal(t, "cost=0.70 rows=1", root.Attributes["estimate"])
+		assert.Equal(t, "time=0.031..0.035 rows=1 loops=1", root.Attributes["actual"])
+
+		if assert.Equal(t, 2, len(root.Children)) {
+			assert.Equal(t, "Filter: (a.id = 1)", root.Children[0].Operation)
+			assert.Equal(t, "a", root.Children[0].Children[0].Table)
+			assert.Equal(t, "Single-row index lookup on p using PRIMARY (id=a.id)", root.Children[1].Operation)
+			assert.Equal(t, "p", root.Children[1].Table)
+		}
+	}
+
+	plan = parseQueryPlan("-> Table scan on artist  (cost=0.35 rows=1)")
+	if assert.Equal(t, 1, len(plan.Nodes)) {
+		assert.Equal(t, "artist", plan.Nodes[0].Table)
+		assert.Nil(t, plan.Nodes[0].Attributes["actual"])
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package mysql
+
+import (
+	"strings"
+	"time"
+
+	"upper.io/db.v3"
+)
+
+// The helpers below do interval arithmetic with DATETIME and TIMESTAMP
+// columns, their interval can be a time.Duration, a db.Interval or a string
+// db.ParseInterval accepts, like "P1D". Other values, like numbers, are taken
+// as seconds.
+
+// Ago returns an expression with the current time minus interval.
+//
+// Example:
+//
+//	// `created_at` > DATE_SUB(NOW(6), INTERVAL ? MICROSECOND)
+//	db.Cond{"created_at >": mysql.Ago(24 * time.Hour)}
+func Ago(interval interface{}) db.RawValue {
+	return intervalExpression("DATE_SUB", "NOW(6)", interval)
+}
+
+// FromNow returns an expression with the current time plus interval.
+func FromNow(interval interface{}) db.RawValue {
+	return intervalExpression("DATE_ADD", "NOW(6)", interval)
+}
+
+// AddInterval returns an expression with the value of the given column plus
+// interval.
+//
+// Example:
+//
+//	// `expires_at` < DATE_ADD(`created_at`, INTERVAL ? MONTH)
+//	db.Cond{"expires_at <": mysql.AddInterval("created_at", db.Interval{Months: 1})}
+func AddInterval(column string, interval interface{}) db.RawValue {
+	return intervalExpression("DATE_ADD", quoteIdentifier(column), interval)
+}
+
+// SubInterval returns an expression with the value of the given column minus
+// interval.
+func SubInterval(column string, interval interface{}) db.RawValue {
+	return intervalExpression("DATE_SUB", quoteIdentifier(column), interval)
+}
+
+// WithinLast creates a condition that matches rows where the time of the
+// given column is not older than interval.
+//
+// Example:
+//
+//	// `last_seen_at` >= DATE_SUB(NOW(6), INTERVAL ? MICROSECOND)
+//	mysql.WithinLast("last_seen_at", 5*time.Minute)
+func WithinLast(column string, interval interface{}) db.Cond {
+	return db.Cond{db.Comparison{Column: column, Operator: ">="}: Ago(interval)}
+}
+
+// OlderThan creates a condition that matches rows where the time of the given
+// column is older than interval.
+func OlderThan(column string, interval interface{}) db.Cond {
+	return db.Cond{db.Comparison{Column: column, Operator: "<"}: Ago(interval)}
+}
+
+// intervalExpression applies fn, DATE_ADD or DATE_SUB, to expr once for each
+// component of interval, as MySQL intervals have a single unit.
+func intervalExpression(fn string, expr string, interval interface{}) db.RawValue {
+	var i db.Interval
+	switch v := interval.(type) {
+	case time.Duration:
+		i = db.Interval{Duration: v}
+	case db.Interval:
+		i = v
+	case *db.Interval:
+		i = *v
+	case string:
+		var err error
+		if i, err = db.ParseInterval(v); err != nil {
+			return db.Raw(fn+"("+expr+", INTERVAL ? SECOND)", v)
+		}
+	default:
+		return db.Raw(fn+"("+expr+", INTERVAL ? SECOND)", v)
+	}
+
+	var args []interface{}
+	for _, c := range []struct {
+		n    int64
+		unit string
+	}{
+		{int64(i.Months), "MONTH"},
+		{int64(i.Days), "DAY"},
+		{int64(i.Duration / time.Microsecond), "MICROSECOND"},
+	} {
+		if c.n != 0 || len(args) == 0 && c.unit == "MICROSECOND" {
+			expr = fn + "(" + expr + ", INTERVAL ? " + c.unit + ")"
+			args = append(args, c.n)
+		}
+	}
+	return db.Raw(expr, args...)
+}
+
+// quoteIdentifier quotes a column name, like `sessions`.`created_at`.
+func quoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i := range parts {
+		parts[i] = "`" + strings.Replace(parts[i], "`", "``", -1) + "`"
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,29 @@
+package mysql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+func TestIntervalConditions(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+
+	q := b.SelectFrom("sessions").Where(
+		WithinLast("last_seen_at", 5*time.Minute),
+		OlderThan("created_at", "P30D"),
+		db.Cond{"expires_at <": AddInterval("sessions.created_at", db.Interval{Months: 1, Days: 2, Duration: time.Second})},
+		db.Cond{"starts_at >": SubInterval("ends_at", 90)},
+		db.Cond{"renews_at <": FromNow(db.Interval{})},
+	)
+	assert.Equal(t,
+		"SELECT * FROM `sessions` WHERE (`last_seen_at` >= DATE_SUB(NOW(6), INTERVAL $1 MICROSECOND) AND `created_at` < DATE_SUB(NOW(6), INTERVAL $2 DAY) AND `expires_at` < DATE_ADD(DATE_ADD(DATE_ADD(`sessions`.`created_at`, INTERVAL $3 MONTH), INTERVAL $4 DAY), INTERVAL $5 MICROSECOND) AND `starts_at` > DATE_SUB(`ends_at`, INTERVAL $6 SECOND) AND `renews_at` < DATE_ADD(NOW(6), INTERVAL $7 MICROSECOND))",
+		q.String(),
+	)
+	assert.Equal(t, []interface{}{
+		int64(300000000), int64(30), int64(1), int64(2), int64(1000000), 90, int64(0),
+	}, q.Arguments())
+}
@@ -42,9 +42,20 @@ const (
 	adapterClauseGroup         = `({{.}})`
 	adapterClauseOperator      = ` {{.}} `
 	adapterColumnValue         = `{{.Column}} {{.Operator}} {{.Value}}`
+	adapterILikeLayout         = `LOWER({{.Column}}) {{.Operator}} LOWER({{.Value}})`
+	adapterMatchLayout         = `MATCH ({{.Column}}) AGAINST ({{.Value}} IN NATURAL LANGUAGE MODE)`
+	adapterRankLayout          = `MATCH ({{.Column}}) AGAINST ({{.Query}} IN NATURAL LANGUAGE MODE)`
 	adapterTableAliasLayout    = `{{.Name}}{{if .Alias}} AS {{.Alias}}{{end}}`
 	adapterColumnAliasLayout   = `{{.Name}}{{if .Alias}} AS {{.Alias}}{{end}}`
 	adapterSortByColumnLayout  = `{{.Column}} {{.Order}}`
+	adapterWithLayout          = `WITH {{if .Recursive}}RECURSIVE {{end}}{{.Clauses}}`
+	adapterOnConflictLayout    = `ON DUPLICATE KEY UPDATE {{if .ColumnValues}}{{.ColumnValues}}{{else}}{{.Column}} = {{.Column}}{{end}}`
+	adapterExcludedLayout      = `VALUES({{.}})`
+	adapterLockLayout          = `FOR {{if .Share}}SHARE{{else}}UPDATE{{end}}{{if .SkipLocked}} SKIP LOCKED{{else if .NoWait}} NOWAIT{{end}}`
+	adapterTimeoutLayout       = `/*+ MAX_EXECUTION_TIME({{.Milliseconds}}) */`
+	adapterRollupLayout        = `{{.}} WITH ROLLUP`
+	adapterTupleInLayout       = `({{.Columns}}) {{if .Negate}}NOT {{end}}IN ({{.Values}})`
+	adapterValuesListLayout    = `(VALUES {{range $i, $r := .Rows}}{{if $i}}, {{end}}ROW({{$r}}){{end}}){{if .Name}} AS {{.Name}}{{if .Columns}} ({{range $i, $c := .Columns}}{{if $i}}, {{end}}{{$c.Name}}{{end}}){{end}}{{end}}`
 
 	adapterOrderByLayout = `
     {{if .SortColumns}}
@@ -88,6 +99,10 @@ const (
 
 	adapterSelectLayout = `
     SELECT
+      {{if .Timeout}}
+        {{.Timeout}}
+      {{end}}
+
       {{if .Distinct}}
         DISTINCT
       {{end}}
@@ -117,11 +132,21 @@ const (
       {{if .Offset}}
         OFFSET {{.Offset}}
       {{end}}
+
+      {{if .Lock}}
+        {{.Lock}}
+      {{end}}
   `
 	adapterDeleteLayout = `
     DELETE
       FROM {{.Table}}
       {{.Where}}
+  `
+	adapterDeleteUsingLayout = `
+    DELETE
+      FROM {{.Table}}
+    USING {{.Table}}, {{.Sources}}
+      {{.Where}}
   `
 	adapterUpdateLayout = `
     UPDATE
@@ -129,6 +154,12 @@ const (
     SET {{.ColumnValues}}
       {{ .Where }}
   `
+	adapterUpdateFromLayout = `
+    UPDATE
+      {{.Table}}, {{.Sources}}
+    SET {{.ColumnValues}}
+      {{ .Where }}
+  `
 
 	adapterSelectCountLayout = `
     SELECT
@@ -146,6 +177,7 @@ const (
     {{else}}
       ()
     {{end}}
+    {{.OnConflict}}
     {{if .Returning}}
       RETURNING {{.Returning}}
     {{end}}
@@ -170,10 +202,40 @@ const (
   `
 )
 
+const (
+	adapterCreateTableLayout      = `CREATE TABLE {{if .IfNotExists}}IF NOT EXISTS {{end}}{{.Table}} ({{.Definitions}}{{if .PrimaryKey}}, PRIMARY KEY ({{.PrimaryKey}}){{end}})`
+	adapterCreateIndexLayout      = `CREATE {{if .Unique}}UNIQUE {{end}}INDEX {{.Index}} ON {{.Table}} ({{.Columns}})`
+	adapterAddColumnLayout        = `ALTER TABLE {{.Table}} ADD COLUMN {{.Column}}`
+	adapterDropColumnLayout       = `ALTER TABLE {{.Table}} DROP COLUMN {{.Column}}`
+	adapterRenameColumnLayout     = `ALTER TABLE {{.Table}} RENAME COLUMN {{.Column}} TO {{.NewColumn}}`
+	adapterColumnDefinitionLayout = `{{.Name}} {{.Type}}{{if .NotNull}} NOT NULL{{end}}{{if .AutoIncrement}} AUTO_INCREMENT{{end}}{{if .Default}} DEFAULT {{.Default}}{{end}}{{if .PrimaryKey}} PRIMARY KEY{{end}}{{if .Unique}} UNIQUE{{end}}`
+	adapterTrueKeyword            = `TRUE`
+	adapterFalseKeyword           = `FALSE`
+)
+
+var adapterColumnTypes = map[string]string{
+	"boolean":   `BOOLEAN`,
+	"smallint":  `SMALLINT`,
+	"integer":   `INT`,
+	"bigint":    `BIGINT`,
+	"float":     `DOUBLE`,
+	"decimal":   `DECIMAL{{if .Size}}({{.Size}}, {{.Scale}}){{end}}`,
+	"string":    `VARCHAR({{.Size}})`,
+	"text":      `LONGTEXT`,
+	"bytes":     `LONGBLOB`,
+	"date":      `DATE`,
+	"timestamp": `DATETIME(6)`,
+	"interval":  `TIME(6)`,
+	"json":      `JSON`,
+	"uuid":      `CHAR(36)`,
+	"enum":      `ENUM({{.Values}})`,
+}
+
 var template = &exql.Template{
 	ColumnSeparator:     adapterColumnSeparator,
 	IdentifierSeparator: adapterIdentifierSeparator,
 	IdentifierQuote:     adapterIdentifierQuote,
+	ILikeLayout:         adapterILikeLayout,
 	ValueSeparator:      adapterValueSeparator,
 	ValueQuote:          adapterValueQuote,
 	AndKeyword:          adapterAndKeyword,
@@ -187,21 +249,43 @@ var template = &exql.Template{
 	ClauseOperator:      adapterClauseOperator,
 	ColumnValue:         adapterColumnValue,
 	TableAliasLayout:    adapterTableAliasLayout,
+	TimeoutLayout:       adapterTimeoutLayout,
 	ColumnAliasLayout:   adapterColumnAliasLayout,
 	SortByColumnLayout:  adapterSortByColumnLayout,
 	WhereLayout:         adapterWhereLayout,
+	WithLayout:          adapterWithLayout,
+	OnConflictLayout:    adapterOnConflictLayout,
+	ExcludedLayout:      adapterExcludedLayout,
 	JoinLayout:          adapterJoinLayout,
+	LockLayout:          adapterLockLayout,
 	OnLayout:            adapterOnLayout,
 	UsingLayout:         adapterUsingLayout,
+	ValuesListLayout:    adapterValuesListLayout,
 	OrderByLayout:       adapterOrderByLayout,
+	MatchLayout:         adapterMatchLayout,
+	RankLayout:          adapterRankLayout,
 	InsertLayout:        adapterInsertLayout,
 	SelectLayout:        adapterSelectLayout,
 	UpdateLayout:        adapterUpdateLayout,
+	UpdateFromLayout:    adapterUpdateFromLayout,
 	DeleteLayout:        adapterDeleteLayout,
+	DeleteUsingLayout:   adapterDeleteUsingLayout,
 	TruncateLayout:      adapterTruncateLayout,
+	TupleInLayout:       adapterTupleInLayout,
 	DropDatabaseLayout:  adapterDropDatabaseLayout,
 	DropTableLayout:     adapterDropTableLayout,
 	CountLayout:         adapterSelectCountLayout,
 	GroupByLayout:       adapterGroupByLayout,
-	Cache:               cache.NewCache(),
+	RollupLayout:        adapterRollupLayout,
+
+	CreateTableLayout:      adapterCreateTableLayout,
+	CreateIndexLayout:      adapterCreateIndexLayout,
+	AddColumnLayout:        adapterAddColumnLayout,
+	DropColumnLayout:       adapterDropColumnLayout,
+	RenameColumnLayout:     adapterRenameColumnLayout,
+	ColumnDefinitionLayout: adapterColumnDefinitionLayout,
+	ColumnTypes:            adapterColumnTypes,
+	TrueKeyword:            adapterTrueKeyword,
+	FalseKeyword:           adapterFalseKeyword,
+	Cache:                  cache.NewCache(),
 }
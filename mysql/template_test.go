@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"upper.io/db.v3"
@@ -144,6 +145,77 @@ func TestTemplateSelect(t *testing.T) {
 		"SELECT DATE()",
 		b.Select(db.Raw("DATE()")).String(),
 	)
+
+	{
+		sel := b.SelectFrom("publication").DistinctOn("artist_id")
+		_, err := sel.(interface {
+			Compile() (string, error)
+		}).Compile()
+		assert.Error(err)
+	}
+
+	assert.Equal(
+		"SELECT * FROM `jobs` WHERE (`status` = $1) LIMIT 1 FOR UPDATE SKIP LOCKED",
+		b.SelectFrom("jobs").Where("status", "pending").Limit(1).ForUpdate().SkipLocked().String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM `jobs` FOR SHARE NOWAIT",
+		b.SelectFrom("jobs").ForShare().NoWait().String(),
+	)
+
+	assert.Equal(
+		"SELECT /*+ MAX_EXECUTION_TIME(1500) */ * FROM `artist` WHERE (`id` = $1)",
+		b.SelectFrom("artist").Where("id", 1).Timeout(1500*time.Millisecond).String(),
+	)
+
+	assert.Equal(
+		"UPDATE `artist` SET `name` = $1",
+		b.Update("artist").Set("name", "Artist").Timeout(time.Second).String(),
+	)
+
+	assert.Equal(
+		"SELECT `year`, `month`, SUM(amount) FROM `sales` GROUP BY `year`, `month` WITH ROLLUP",
+		b.Select("year", "month", db.Raw("SUM(amount)")).From("sales").GroupBy(db.Rollup("year", "month")).String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM `orders` WHERE (`data`->>'$.status' = $1)",
+		b.SelectFrom("orders").Where(db.Cond{"data->>'$.status'": "shipped"}).String(),
+	)
+
+	assert.Equal(
+		"SELECT `data`->'$.items[0]' AS `item` FROM `orders` WHERE (JSON_LENGTH(data, '$.items') > $1)",
+		b.Select("data->'$.items[0]' AS item").From("orders").Where(db.Cond{"JSON_LENGTH(data, '$.items') >": 2}).String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM (VALUES ROW($1, $2), ROW($3, $4)) AS `v` (`id`, `title`)",
+		b.SelectFrom(db.Values([][]interface{}{{1, "Hamlet"}, {2, "Ulysses"}}).As("v(id, title)")).String(),
+	)
+
+	{
+		sel := b.SelectFrom("sales").GroupBy(db.Cube("country", "product"))
+		_, err := sel.(interface {
+			Compile() (string, error)
+		}).Compile()
+		assert.Error(err)
+	}
+
+	assert.Equal(
+		"SELECT * FROM `artist` WHERE (LOWER(`name`) LIKE LOWER($1) AND `deleted_at` IS NULL)",
+		b.SelectFrom("artist").Where(db.ILike("name", "%smith%"), db.IsNull("deleted_at")).String(),
+	)
+}
+
+func TestTemplateWindow(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		"SELECT `id`, ROW_NUMBER() OVER (PARTITION BY `category_id` ORDER BY `price` DESC) AS `rank` FROM `products`",
+		b.Select("id", sqlbuilder.RowNumber().PartitionBy("category_id").OrderBy("-price").As("rank")).From("products").String(),
+	)
 }
 
 func TestTemplateInsert(t *testing.T) {
@@ -186,6 +258,71 @@ func TestTemplateInsert(t *testing.T) {
 		"INSERT INTO `artist` (`name`, `id`) VALUES ($1, $2)",
 		b.InsertInto("artist").Columns("name", "id").Values("Chavela Vargas", 12).String(),
 	)
+
+	assert.Equal(
+		"INSERT INTO `artist` (`id`, `name`) VALUES ($1, $2) ON DUPLICATE KEY UPDATE `id` = `id`",
+		b.InsertInto("artist").Columns("id", "name").Values(12, "Chavela Vargas").OnConflict("id").DoNothing().String(),
+	)
+
+	assert.Equal(
+		"INSERT INTO `artist` (`id`, `name`) VALUES ($1, $2) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+		b.InsertInto("artist").Columns("id", "name").Values(12, "Chavela Vargas").
+			OnConflict().DoUpdate(map[string]interface{}{"name": sqlbuilder.Excluded("name")}).String(),
+	)
+
+	{
+		q := b.InsertInto("artist").Columns("id", "name").
+			Values(12, "Chavela Vargas").
+			Values(13, "Lila Downs").
+			OnDuplicateKeyUpdate(map[string]interface{}{
+				"name":    sqlbuilder.Excluded("name"),
+				"updated": db.Raw("NOW()"),
+			})
+		assert.Equal(
+			"INSERT INTO `artist` (`id`, `name`) VALUES ($1, $2), ($3, $4) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `updated` = NOW()",
+			q.String(),
+		)
+		assert.Equal([]interface{}{12, "Chavela Vargas", 13, "Lila Downs"}, q.Arguments())
+	}
+
+	{
+		type artist struct {
+			ID   int    `db:"id"`
+			Name string `db:"name"`
+		}
+		q := b.InsertInto("artist").
+			Values(artist{12, "Chavela Vargas"}).
+			Values(artist{13, "Lila Downs"}).
+			OnDuplicateKeyUpdate(nil)
+		assert.Equal(
+			"INSERT INTO `artist` (`id`, `name`) VALUES ($1, $2), ($3, $4) ON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `name` = VALUES(`name`)",
+			q.String(),
+		)
+		assert.Equal([]interface{}{12, "Chavela Vargas", 13, "Lila Downs"}, q.Arguments())
+	}
+
+	{
+		ins := b.InsertInto("artist").OnDuplicateKeyUpdate(nil)
+		_, err := ins.(interface {
+			Compile() (string, error)
+		}).Compile()
+		assert.Error(err)
+	}
+
+	{
+		// MySQL needs a column to ignore conflicts and can't filter updates.
+		for _, ins := range []sqlbuilder.Inserter{
+			b.InsertInto("artist").Values(12, "Chavela Vargas").OnConflict().DoNothing(),
+			b.InsertInto("artist").Columns("id", "name").Values(12, "Chavela Vargas").
+				OnConflict("id").DoUpdate(map[string]interface{}{"name": sqlbuilder.Excluded("name")}).
+				DoUpdateWhere("name <> ?", "Lila Downs"),
+		} {
+			_, err := ins.(interface {
+				Compile() (string, error)
+			}).Compile()
+			assert.Error(err)
+		}
+	}
 }
 
 func TestTemplateUpdate(t *testing.T) {
@@ -228,6 +365,31 @@ func TestTemplateUpdate(t *testing.T) {
 			"id = id + ?", 10,
 		).Where("id > ?", 0).String(),
 	)
+
+	assert.Equal(
+		"UPDATE `products`, `prices` SET `price` = prices.amount WHERE (products.id = prices.product_id)",
+		b.Update("products").Set("price = prices.amount").From("prices").Where("products.id = prices.product_id").String(),
+	)
+
+	{
+		q := b.Update("products").
+			Set("name", "Tortilla").
+			From(db.Values([][]interface{}{{1, 10}}).As("v(id, price)")).
+			Where("products.id = v.id AND v.price < ?", 15)
+
+		assert.Equal(
+			"UPDATE `products`, (VALUES ROW($1, $2)) AS `v` (`id`, `price`) SET `name` = $3 WHERE (products.id = v.id AND v.price < $4)",
+			q.String(),
+		)
+		assert.Equal([]interface{}{1, 10, "Tortilla", 15}, q.Arguments())
+	}
+
+	{
+		_, err := b.Update("artist").Set("name", "Artist").Where(db.Cond{"id": 1}).Returning("id").(interface {
+			Compile() (string, error)
+		}).Compile()
+		assert.Error(err)
+	}
 }
 
 func TestTemplateDelete(t *testing.T) {
@@ -243,4 +405,80 @@ func TestTemplateDelete(t *testing.T) {
 		"DELETE FROM `artist` WHERE (id > 5)",
 		b.DeleteFrom("artist").Where("id > 5").String(),
 	)
+
+	assert.Equal(
+		"DELETE FROM `orders` USING `orders`, `customers` WHERE (orders.customer_id = customers.id AND customers.banned = $1)",
+		b.DeleteFrom("orders").Using("customers").Where("orders.customer_id = customers.id AND customers.banned = ?", true).String(),
+	)
+
+	{
+		_, err := b.DeleteFrom("artist").Where("id > 5").Returning("id").(interface {
+			Compile() (string, error)
+		}).Compile()
+		assert.Error(err)
+	}
+}
+
+func TestTemplateMatch(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	q := b.SelectFrom("docs").
+		Where(db.Match("body", "quick fox")).
+		OrderBy(db.Rank("body", "quick fox"))
+	assert.Equal(
+		"SELECT * FROM `docs` WHERE (MATCH (`body`) AGAINST ($1 IN NATURAL LANGUAGE MODE)) ORDER BY MATCH (`body`) AGAINST ($2 IN NATURAL LANGUAGE MODE) DESC",
+		q.String(),
+	)
+	assert.Equal([]interface{}{"quick fox", "quick fox"}, q.Arguments())
+}
+
+func TestTemplateDDL(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		"CREATE TABLE `books` (`id` BIGINT AUTO_INCREMENT PRIMARY KEY, `title` VARCHAR(255) NOT NULL, `body` LONGTEXT, `public` BOOLEAN NOT NULL DEFAULT TRUE, `created_at` DATETIME(6))",
+		b.CreateTable("books").
+			Columns(
+				sqlbuilder.Column{Name: "id", Type: sqlbuilder.BigInt, AutoIncrement: true},
+				sqlbuilder.Column{Name: "title", Type: sqlbuilder.String, NotNull: true},
+				sqlbuilder.Column{Name: "body", Type: sqlbuilder.Text},
+				sqlbuilder.Column{Name: "public", Type: sqlbuilder.Boolean, NotNull: true, Default: true},
+				sqlbuilder.Column{Name: "created_at", Type: sqlbuilder.Timestamp},
+			).
+			PrimaryKey("id").
+			String(),
+	)
+
+	assert.Equal(
+		"CREATE TABLE `tags` (`book_id` BIGINT NOT NULL, `tag` VARCHAR(32) NOT NULL, PRIMARY KEY (`book_id`, `tag`))",
+		b.CreateTable("tags").
+			Columns(
+				sqlbuilder.Column{Name: "book_id", Type: sqlbuilder.BigInt, NotNull: true},
+				sqlbuilder.Column{Name: "tag", Type: sqlbuilder.String, Size: 32, NotNull: true},
+			).
+			PrimaryKey("book_id", "tag").
+			String(),
+	)
+
+	assert.Equal(
+		"CREATE TABLE `jobs` (`status` ENUM('queued', 'it''s done') NOT NULL, `timeout` TIME(6))",
+		b.CreateTable("jobs").
+			Columns(
+				sqlbuilder.Column{Name: "status", Type: sqlbuilder.Enum, Values: []string{"queued", "it's done"}, NotNull: true},
+				sqlbuilder.Column{Name: "timeout", Type: sqlbuilder.Interval},
+			).
+			String(),
+	)
+
+	assert.Equal(
+		"CREATE UNIQUE INDEX `books_title_idx` ON `books` (`title`)",
+		b.CreateIndex("books_title_idx").Unique().On("books", "title").String(),
+	)
+
+	assert.Equal(
+		"ALTER TABLE `books` ADD COLUMN `isbn` VARCHAR(13); ALTER TABLE `books` DROP COLUMN `body`",
+		b.AlterTable("books").AddColumn(sqlbuilder.Column{Name: "isbn", Type: sqlbuilder.String, Size: 13}).DropColumn("body").String(),
+	)
 }
@@ -0,0 +1,202 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"upper.io/db.v3/lib/reflectx"
+)
+
+// namedMapper matches struct fields the same way sessions do when they have
+// no naming strategy, only by their `db` tag.
+var namedMapper = reflectx.NewMapper("db")
+
+// BindNamed replaces named parameters in the given query (like ":name" or
+// ":user_id") with "?" placeholders and returns the values they point to in
+// order of appearance.
+//
+// Named parameters are only bound when args holds a single map with string
+// keys or a single struct (or pointer to struct), struct fields are matched
+// by their `db` tag:
+//
+//	q, args, err := db.BindNamed(
+//		"SELECT * FROM people WHERE name = :name AND age > :age",
+//		map[string]interface{}{"name": "Hayao", "age": 30},
+//	)
+//
+// Queries that already use "?" placeholders, or that receive any other kind
+// of arguments, are returned unchanged. Casts (::), comments and text within
+// quotes are left untouched.
+func BindNamed(query string, args ...interface{}) (string, []interface{}, error) {
+	return BindNamedWithMapper(namedMapper, query, args...)
+}
+
+// BindNamedWithMapper is like BindNamed, but matches struct fields with the
+// given mapper, like the one of a session that has a naming strategy.
+func BindNamedWithMapper(m *reflectx.Mapper, query string, args ...interface{}) (string, []interface{}, error) {
+	if len(args) != 1 {
+		return query, args, nil
+	}
+
+	src, ok := namedSource(args[0])
+	if !ok {
+		return query, args, nil
+	}
+
+	names, bound := scanNamed(query)
+	if len(names) == 0 {
+		return query, args, nil
+	}
+
+	values := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		v, ok := namedValue(m, src, name)
+		if !ok {
+			return "", nil, fmt.Errorf("upper: missing value for named parameter %q", name)
+		}
+		values = append(values, v)
+	}
+
+	return bound, values, nil
+}
+
+func namedSource(arg interface{}) (reflect.Value, bool) {
+	switch arg.(type) {
+	case driver.Valuer, time.Time, *time.Time:
+		return reflect.Value{}, false
+	}
+
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		return v, v.Type().Key().Kind() == reflect.String
+	case reflect.Struct:
+		return v, true
+	}
+	return reflect.Value{}, false
+}
+
+func namedValue(m *reflectx.Mapper, src reflect.Value, name string) (interface{}, bool) {
+	if src.Kind() == reflect.Map {
+		v := src.MapIndex(reflect.ValueOf(name).Convert(src.Type().Key()))
+		if !v.IsValid() {
+			return nil, false
+		}
+		return v.Interface(), true
+	}
+
+	fi, ok := m.TypeMap(src.Type()).Names[name]
+	if !ok {
+		return nil, false
+	}
+	return reflectx.FieldByIndexesReadOnly(src, fi.Index).Interface(), true
+}
+
+func isNamedChar(c byte, first bool) bool {
+	if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+		return true
+	}
+	return !first && c >= '0' && c <= '9'
+}
+
+// scanNamed returns the names of the parameters found in query along with the
+// query rewritten to use "?" placeholders. No names are returned if query
+// contains "?" placeholders already. Quoted text and comments, either "--" to
+// the end of the line or within "/*" and "*/", are copied as they are.
+func scanNamed(query string) ([]string, string) {
+	var names []string
+	out := make([]byte, 0, len(query))
+
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			out = append(out, c)
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '-':
+			if i+1 < len(query) && query[i+1] == '-' {
+				j := strings.IndexByte(query[i:], '\n')
+				if j < 0 {
+					j = len(query) - i
+				}
+				out = append(out, query[i:i+j]...)
+				i += j - 1
+				continue
+			}
+		case '/':
+			if i+1 < len(query) && query[i+1] == '*' {
+				j := strings.Index(query[i+2:], "*/")
+				if j < 0 {
+					j = len(query) - i
+				} else {
+					j += 4
+				}
+				out = append(out, query[i:i+j]...)
+				i += j - 1
+				continue
+			}
+		case '?':
+			return nil, query
+		case ':':
+			if i+1 < len(query) && query[i+1] == ':' {
+				out = append(out, "::"...)
+				i++
+				continue
+			}
+			if i+1 < len(query) && isNamedChar(query[i+1], true) {
+				j := i + 1
+				for j < len(query) && isNamedChar(query[j], false) {
+					j++
+				}
+				names = append(names, query[i+1:j])
+				out = append(out, '?')
+				i = j - 1
+				continue
+			}
+		}
+
+		out = append(out, c)
+	}
+
+	return names, string(out)
+}
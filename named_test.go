@@ -0,0 +1,119 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"upper.io/db.v3/lib/reflectx"
+)
+
+func TestBindNamed(t *testing.T) {
+	type person struct {
+		Name string `db:"name"`
+		Age  int    `db:"age,omitempty"`
+	}
+
+	testCases := []struct {
+		query string
+		args  []interface{}
+
+		expectedQuery string
+		expectedArgs  []interface{}
+	}{
+		{
+			"SELECT * FROM people WHERE name = :name AND age > :age OR nickname = :name",
+			[]interface{}{map[string]interface{}{"name": "Hayao", "age": 30}},
+			"SELECT * FROM people WHERE name = ? AND age > ? OR nickname = ?",
+			[]interface{}{"Hayao", 30, "Hayao"},
+		},
+		{
+			"UPDATE people SET age = :age WHERE name = :name",
+			[]interface{}{&person{Name: "Hayao", Age: 31}},
+			"UPDATE people SET age = ? WHERE name = ?",
+			[]interface{}{31, "Hayao"},
+		},
+		{
+			"SELECT id::text, ':name' FROM people WHERE name = :name",
+			[]interface{}{person{Name: "Hayao"}},
+			"SELECT id::text, ':name' FROM people WHERE name = ?",
+			[]interface{}{"Hayao"},
+		},
+		{
+			"SELECT * FROM people WHERE data @> ?",
+			[]interface{}{map[string]interface{}{"name": "Hayao"}},
+			"SELECT * FROM people WHERE data @> ?",
+			[]interface{}{map[string]interface{}{"name": "Hayao"}},
+		},
+		{
+			"SELECT * FROM people -- :age? is ignored\nWHERE name = :name",
+			[]interface{}{person{Name: "Hayao"}},
+			"SELECT * FROM people -- :age? is ignored\nWHERE name = ?",
+			[]interface{}{"Hayao"},
+		},
+		{
+			"SELECT * FROM people /* WHERE age > :age? */ WHERE name = :name /* :age",
+			[]interface{}{person{Name: "Hayao"}},
+			"SELECT * FROM people /* WHERE age > :age? */ WHERE name = ? /* :age",
+			[]interface{}{"Hayao"},
+		},
+		{
+			"SELECT * FROM people WHERE name = :name",
+			[]interface{}{"Hayao"},
+			"SELECT * FROM people WHERE name = :name",
+			[]interface{}{"Hayao"},
+		},
+	}
+
+	for _, test := range testCases {
+		query, args, err := BindNamed(test.query, test.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != test.expectedQuery {
+			t.Fatalf("Got: %v, Expecting: %v", query, test.expectedQuery)
+		}
+		if !reflect.DeepEqual(args, test.expectedArgs) {
+			t.Fatalf("Got: %v, Expecting: %v", args, test.expectedArgs)
+		}
+	}
+
+	if _, _, err := BindNamed("SELECT :missing", map[string]interface{}{}); err == nil {
+		t.Fatal("Expecting an error")
+	}
+}
+
+func TestBindNamedWithMapper(t *testing.T) {
+	type person struct {
+		FirstName string
+	}
+
+	if _, _, err := BindNamed("SELECT :first_name", person{FirstName: "Hayao"}); err == nil {
+		t.Fatal("Expecting untagged fields to be unmapped")
+	}
+
+	m := reflectx.NewMapperFunc("db", SnakeCase)
+	query, args, err := BindNamedWithMapper(m, "SELECT :first_name", person{FirstName: "Hayao"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "SELECT ?" || !reflect.DeepEqual(args, []interface{}{"Hayao"}) {
+		t.Fatalf("Got: %v %v, Expecting: %v %v", query, args, "SELECT ?", []interface{}{"Hayao"})
+	}
+}
+
+func TestRawNamed(t *testing.T) {
+	r := Raw("name = :name", map[string]string{"name": "Hayao"})
+	if r.Raw() != "name = ?" {
+		t.Fatalf("Got: %v, Expecting: %v", r.Raw(), "name = ?")
+	}
+	if !reflect.DeepEqual(r.Arguments(), []interface{}{"Hayao"}) {
+		t.Fatalf("Got: %v, Expecting: %v", r.Arguments(), []interface{}{"Hayao"})
+	}
+
+	r = Raw("name = :name", map[string]string{})
+	if err := r.(interface {
+		Err() error
+	}).Err(); err == nil {
+		t.Fatal("Expecting an error for a missing named parameter")
+	}
+}
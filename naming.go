@@ -0,0 +1,73 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"unicode"
+)
+
+// NamingStrategy maps the name of a struct field that has no `db` tag to the
+// name of a column, see Settings.SetNamingStrategy.
+type NamingStrategy func(fieldName string) string
+
+// SnakeCase is a NamingStrategy that maps field names like "UserID" or
+// "HTTPStatus" into "user_id" and "http_status".
+func SnakeCase(fieldName string) string {
+	runes := []rune(fieldName)
+	out := make([]rune, 0, len(runes)+4)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) && runes[i-1] != '_' {
+				out = append(out, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+// CamelCase is a NamingStrategy that maps field names like "UserID" or
+// "HTTPStatus" into "userID" and "httpStatus".
+func CamelCase(fieldName string) string {
+	runes := []rune(fieldName)
+	for i, r := range runes {
+		if !unicode.IsUpper(r) {
+			break
+		}
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			// First letter of the next word, like the "S" in "HTTPStatus".
+			break
+		}
+		runes[i] = unicode.ToLower(r)
+	}
+	return string(runes)
+}
+
+// TableNamer is implemented by structs that set the name of their table, the
+// ones that don't implement it are mapped to a table named after their type
+// with the naming strategy of the session, or in snake_case if it has none,
+// like "OrderItem" to "order_item".
+// See sqlbuilder.Database.AutoMigrate.
+type TableNamer interface {
+	TableName() string
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestNamingStrategies(t *testing.T) {
+	testCases := []struct {
+		in    string
+		snake string
+		camel string
+	}{
+		{"ID", "id", "id"},
+		{"Name", "name", "name"},
+		{"UserID", "user_id", "userID"},
+		{"OrderItems", "order_items", "orderItems"},
+		{"HTTPStatus", "http_status", "httpStatus"},
+		{"Address2", "address2", "address2"},
+		{"already_snake", "already_snake", "already_snake"},
+	}
+
+	for _, tc := range testCases {
+		if out := SnakeCase(tc.in); out != tc.snake {
+			t.Fatalf("Got: %v, Expecting: %v", out, tc.snake)
+		}
+		if out := CamelCase(tc.in); out != tc.camel {
+			t.Fatalf("Got: %v, Expecting: %v", out, tc.camel)
+		}
+	}
+}
+
+func TestNamingStrategySettings(t *testing.T) {
+	s := NewSettings()
+
+	if s.NamingStrategy() != nil {
+		t.Fatal("Expecting no default naming strategy")
+	}
+
+	s.SetNamingStrategy(SnakeCase)
+	if out := s.NamingStrategy()("UserID"); out != "user_id" {
+		t.Fatalf("Got: %v, Expecting: %v", out, "user_id")
+	}
+
+	s.SetNamingStrategy(CamelCase)
+	if out := s.NamingStrategy()("UserID"); out != "userID" {
+		t.Fatalf("Got: %v, Expecting: %v", out, "userID")
+	}
+
+	s.SetNamingStrategy(nil)
+	if s.NamingStrategy() != nil {
+		t.Fatal("Expecting naming strategy to be disabled")
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package db
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var jsonNull = []byte("null")
+
+// NullTime is a time.Time that may be NULL, like sql.NullTime, which is
+// encoded as null in JSON when it's not valid.
+type NullTime struct {
+	Time  time.Time
+	Valid bool
+}
+
+// NewNullTime returns a valid NullTime with the given time.
+func NewNullTime(t time.Time) NullTime {
+	return NullTime{Time: t, Valid: true}
+}
+
+// NullTimeFromPtr returns a NullTime that is valid if p is not nil.
+func NullTimeFromPtr(p *time.Time) NullTime {
+	if p == nil {
+		return NullTime{}
+	}
+	return NewNullTime(*p)
+}
+
+// Ptr returns a pointer to the time, or nil if it's not valid.
+func (n NullTime) Ptr() *time.Time {
+	if !n.Valid {
+		return nil
+	}
+	t := n.Time
+	return &t
+}
+
+// Scan implements the sql.Scanner interface.
+func (n *NullTime) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*n = NullTime{}
+		return nil
+	case time.Time:
+		*n = NewNullTime(src)
+		return nil
+	}
+	return fmt.Errorf("Cannot scan %T into NullTime", src)
+}
+
+// Value implements the driver.Valuer interface.
+func (n NullTime) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Time, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (n NullTime) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	return n.Time.MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (n *NullTime) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, jsonNull) {
+		*n = NullTime{}
+		return nil
+	}
+	if err := n.Time.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// NullJSON is a JSON document that may be NULL, meant for json and jsonb
+// columns whose documents are passed through as they are. It's encoded as
+// the document itself in JSON, or as null when it's not valid.
+type NullJSON struct {
+	JSON  json.RawMessage
+	Valid bool
+}
+
+// NewNullJSON returns a valid NullJSON with the JSON encoding of v.
+func NewNullJSON(v interface{}) (NullJSON, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return NullJSON{}, err
+	}
+	return NullJSON{JSON: buf, Valid: true}, nil
+}
+
+// Unmarshal decodes the document into v, which is left as it is when the
+// document is not valid.
+func (n NullJSON) Unmarshal(v interface{}) error {
+	if !n.Valid {
+		return nil
+	}
+	return json.Unmarshal(n.JSON, v)
+}
+
+// Scan implements the sql.Scanner interface.
+func (n *NullJSON) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*n = NullJSON{}
+		return nil
+	case []byte:
+		// The driver may reuse src.
+		*n = NullJSON{JSON: append(json.RawMessage(nil), src...), Valid: true}
+		return nil
+	case string:
+		*n = NullJSON{JSON: json.RawMessage(src), Valid: true}
+		return nil
+	}
+	return fmt.Errorf("Cannot scan %T into NullJSON", src)
+}
+
+// Value implements the driver.Valuer interface.
+func (n NullJSON) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	if !json.Valid(n.JSON) {
+		return nil, fmt.Errorf("Invalid JSON document %q", n.JSON)
+	}
+	return string(n.JSON), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (n NullJSON) MarshalJSON() ([]byte, error) {
+	if !n.Valid || len(n.JSON) == 0 {
+		return jsonNull, nil
+	}
+	return n.JSON, nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (n *NullJSON) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, jsonNull) {
+		*n = NullJSON{}
+		return nil
+	}
+	*n = NullJSON{JSON: append(json.RawMessage(nil), data...), Valid: true}
+	return nil
+}
+
+// NullStringSlice is a list of strings that may be NULL, meant for
+// PostgreSQL's text[] and varchar[] columns, which tells a NULL array apart
+// from an empty one. It's encoded as a JSON array, or as null when it's not
+// valid.
+type NullStringSlice struct {
+	Strings []string
+	Valid   bool
+}
+
+// NewNullStringSlice returns a valid NullStringSlice with the given strings.
+func NewNullStringSlice(s []string) NullStringSlice {
+	return NullStringSlice{Strings: s, Valid: true}
+}
+
+// Scan implements the sql.Scanner interface, arrays with NULL elements can't
+// be scanned.
+func (n *NullStringSlice) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*n = NullStringSlice{}
+		return nil
+	case []byte:
+		return n.Scan(string(src))
+	case string:
+		s, err := parseStringArray(src)
+		if err != nil {
+			return err
+		}
+		*n = NewNullStringSlice(s)
+		return nil
+	}
+	return fmt.Errorf("Cannot scan %T into NullStringSlice", src)
+}
+
+// parseStringArray parses a one-dimensional array literal, like
+// {a,"b c","d\"e"}.
+func parseStringArray(s string) ([]string, error) {
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("Invalid array %q", s)
+	}
+	body := s[1 : len(s)-1]
+
+	elems := []string{}
+	if body == "" {
+		return elems, nil
+	}
+	for {
+		var elem string
+		if strings.HasPrefix(body, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(body) && body[i] != '"'; i++ {
+				if body[i] == '\\' {
+					i++
+					if i == len(body) {
+						break
+					}
+				}
+				b.WriteByte(body[i])
+			}
+			if i >= len(body) {
+				return nil, fmt.Errorf("Invalid array %q", s)
+			}
+			elem, body = b.String(), body[i+1:]
+		} else {
+			i := strings.IndexByte(body, ',')
+			if i < 0 {
+				i = len(body)
+			}
+			elem, body = body[:i], body[i:]
+			if elem == "NULL" {
+				return nil, fmt.Errorf("Cannot scan array %q with NULL elements into NullStringSlice", s)
+			}
+			if elem == "" || strings.ContainsAny(elem, `{}"\`) {
+				return nil, fmt.Errorf("Invalid array %q", s)
+			}
+		}
+		elems = append(elems, elem)
+
+		if body == "" {
+			return elems, nil
+		}
+		if body[0] != ',' {
+			return nil, fmt.Errorf("Invalid array %q", s)
+		}
+		body = body[1:]
+	}
+}
+
+// Value implements the driver.Valuer interface.
+func (n NullStringSlice) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, s := range n.Strings {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('"')
+		for j := 0; j < len(s); j++ {
+			if s[j] == '"' || s[j] == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(s[j])
+		}
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String(), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (n NullStringSlice) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	if n.Strings == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(n.Strings)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (n *NullStringSlice) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, jsonNull) {
+		*n = NullStringSlice{}
+		return nil
+	}
+	var s []string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*n = NewNullStringSlice(s)
+	return nil
+}
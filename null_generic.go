@@ -0,0 +1,113 @@
+//go:build go1.22
+// +build go1.22
+
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package db
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// Null is a value of type T that may be NULL, like sql.Null, which is encoded
+// as null in JSON when it's not valid, so models can be returned by APIs
+// without pointers or sql.Null* structures:
+//
+//	type User struct {
+//		ID       int64           `db:"id,omitempty" json:"id"`
+//		Nickname db.Null[string] `db:"nickname" json:"nickname"`
+//		Age      db.Null[int64]  `db:"age" json:"age"`
+//	}
+//
+// Values are scanned and converted like database/sql does for T, and
+// FromStruct creates nullable columns of the type of T for them.
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+// NewNull returns a valid Null with the given value.
+func NewNull[T any](v T) Null[T] {
+	return Null[T]{V: v, Valid: true}
+}
+
+// NullFromPtr returns a Null that is valid if p is not nil.
+func NullFromPtr[T any](p *T) Null[T] {
+	if p == nil {
+		return Null[T]{}
+	}
+	return NewNull(*p)
+}
+
+// Ptr returns a pointer to the value, or nil if it's not valid.
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	v := n.V
+	return &v
+}
+
+// ValueOr returns the value, or def if it's not valid.
+func (n Null[T]) ValueOr(def T) T {
+	if !n.Valid {
+		return def
+	}
+	return n.V
+}
+
+// Scan implements the sql.Scanner interface.
+func (n *Null[T]) Scan(src interface{}) error {
+	return (*sql.Null[T])(n).Scan(src)
+}
+
+// Value implements the driver.Valuer interface.
+func (n Null[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(n.V)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	return json.Marshal(n.V)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (n *Null[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, jsonNull) {
+		*n = Null[T]{}
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*n = NewNull(v)
+	return nil
+}
@@ -0,0 +1,99 @@
+//go:build go1.22
+// +build go1.22
+
+package db
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNull(t *testing.T) {
+	{
+		var n Null[string]
+		if err := n.Scan([]byte("Joe")); err != nil {
+			t.Fatal(err)
+		}
+		if n != NewNull("Joe") {
+			t.Fatalf("Got: %v, Expecting: %v", n, NewNull("Joe"))
+		}
+		if v, err := n.Value(); err != nil || v != "Joe" {
+			t.Fatalf("Got: %v (%v), Expecting: Joe", v, err)
+		}
+		if err := n.Scan(nil); err != nil || n.Valid || n.V != "" {
+			t.Fatalf("Unexpected value %v (%v)", n, err)
+		}
+		if v, err := n.Value(); err != nil || v != nil {
+			t.Fatalf("Got: %v (%v), Expecting: nil", v, err)
+		}
+	}
+
+	{
+		// Values are converted like database/sql does.
+		var n Null[int32]
+		if err := n.Scan(int64(42)); err != nil {
+			t.Fatal(err)
+		}
+		if n.V != 42 {
+			t.Fatalf("Got: %v, Expecting: 42", n.V)
+		}
+		if err := n.Scan("x"); err == nil {
+			t.Fatal("Expecting an error")
+		}
+		if v, err := NewNull(int32(7)).Value(); err != nil || v != int64(7) {
+			t.Fatalf("Got: %#v (%v), Expecting: 7", v, err)
+		}
+	}
+
+	{
+		var n Null[Decimal]
+		if err := n.Scan([]byte("19.99")); err != nil {
+			t.Fatal(err)
+		}
+		if v, err := n.Value(); err != nil || v != "19.99" {
+			t.Fatalf("Got: %v (%v), Expecting: 19.99", v, err)
+		}
+	}
+
+	{
+		age := 30
+		if p := NullFromPtr(&age).Ptr(); p == nil || *p != 30 || p == &age {
+			t.Fatalf("Unexpected pointer %v", p)
+		}
+		if NullFromPtr[int](nil).Ptr() != nil {
+			t.Fatal("Expecting nil")
+		}
+		if v := (Null[int]{}).ValueOr(18); v != 18 {
+			t.Fatalf("Got: %v, Expecting: 18", v)
+		}
+		if v := NewNull(30).ValueOr(18); v != 30 {
+			t.Fatalf("Got: %v, Expecting: 30", v)
+		}
+	}
+
+	type user struct {
+		Nickname Null[string]    `json:"nickname"`
+		Age      Null[int64]     `json:"age"`
+		Seen     Null[time.Time] `json:"seen"`
+	}
+
+	buf, err := json.Marshal(user{Nickname: NewNull("joe")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != `{"nickname":"joe","age":null,"seen":null}` {
+		t.Fatalf("Unexpected JSON %s", buf)
+	}
+
+	u := user{Age: NewNull(int64(1))}
+	if err := json.Unmarshal([]byte(`{"nickname": null, "age": 30, "seen": "2024-03-01T10:30:00Z"}`), &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Nickname.Valid || u.Age != NewNull(int64(30)) || !u.Seen.Valid || u.Seen.V.Year() != 2024 {
+		t.Fatalf("Unexpected user %v", u)
+	}
+	if err := json.Unmarshal([]byte(`{"age": "thirty"}`), &u); err == nil {
+		t.Fatal("Expecting an error")
+	}
+}
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNullTime(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 10, 30, 0, 0, time.UTC)
+
+	var n NullTime
+	if err := n.Scan(now); err != nil {
+		t.Fatal(err)
+	}
+	if n != NewNullTime(now) {
+		t.Fatalf("Got: %v, Expecting: %v", n, NewNullTime(now))
+	}
+	if p := n.Ptr(); p == nil || !p.Equal(now) {
+		t.Fatalf("Unexpected pointer %v", p)
+	}
+	if v, err := n.Value(); err != nil || v != now {
+		t.Fatalf("Got: %v (%v), Expecting: %v", v, err, now)
+	}
+
+	if err := n.Scan(nil); err != nil {
+		t.Fatal(err)
+	}
+	if n.Valid || n.Ptr() != nil {
+		t.Fatalf("Unexpected time %v", n)
+	}
+	if v, err := n.Value(); err != nil || v != nil {
+		t.Fatalf("Got: %v (%v), Expecting: nil", v, err)
+	}
+	if err := n.Scan("2024-03-01"); err == nil {
+		t.Fatal("Expecting an error")
+	}
+
+	if NullTimeFromPtr(nil).Valid || NullTimeFromPtr(&now) != NewNullTime(now) {
+		t.Fatal("Unexpected NullTime from pointer")
+	}
+
+	type event struct {
+		Starts NullTime `json:"starts"`
+		Ends   NullTime `json:"ends"`
+	}
+	buf, err := json.Marshal(event{Starts: NewNullTime(now)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != `{"starts":"2024-03-01T10:30:00Z","ends":null}` {
+		t.Fatalf("Unexpected JSON %s", buf)
+	}
+
+	e := event{Ends: NewNullTime(now)}
+	if err := json.Unmarshal(buf, &e); err != nil {
+		t.Fatal(err)
+	}
+	if !e.Starts.Valid || !e.Starts.Time.Equal(now) || e.Ends.Valid {
+		t.Fatalf("Unexpected event %v", e)
+	}
+	if err := json.Unmarshal([]byte(`{"starts": "tomorrow"}`), &e); err == nil {
+		t.Fatal("Expecting an error")
+	}
+}
+
+func TestNullJSON(t *testing.T) {
+	n, err := NewNullJSON(map[string]interface{}{"theme": "dark"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := n.Value(); err != nil || v != `{"theme":"dark"}` {
+		t.Fatalf("Got: %v (%v)", v, err)
+	}
+
+	src := []byte(`{"theme": "light"}`)
+	if err := n.Scan(src); err != nil {
+		t.Fatal(err)
+	}
+	copy(src, "xxxxxxxxxx")
+	var settings struct {
+		Theme string `json:"theme"`
+	}
+	if err := n.Unmarshal(&settings); err != nil {
+		t.Fatal(err)
+	}
+	if settings.Theme != "light" {
+		t.Fatalf("Got: %v, Expecting: light", settings.Theme)
+	}
+
+	if err := n.Scan(nil); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := n.Value(); err != nil || v != nil {
+		t.Fatalf("Got: %v (%v), Expecting: nil", v, err)
+	}
+	if err := n.Unmarshal(&settings); err != nil || settings.Theme != "light" {
+		t.Fatalf("Unexpected settings %v (%v)", settings, err)
+	}
+
+	if _, err := (NullJSON{JSON: json.RawMessage(`{`), Valid: true}).Value(); err == nil {
+		t.Fatal("Expecting an error")
+	}
+
+	type user struct {
+		Settings NullJSON `json:"settings"`
+		Extra    NullJSON `json:"extra"`
+	}
+	var u user
+	if err := json.Unmarshal([]byte(`{"settings": {"theme": "dark"}, "extra": null}`), &u); err != nil {
+		t.Fatal(err)
+	}
+	if !u.Settings.Valid || u.Extra.Valid {
+		t.Fatalf("Unexpected user %v", u)
+	}
+	buf, err := json.Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != `{"settings":{"theme":"dark"},"extra":null}` {
+		t.Fatalf("Unexpected JSON %s", buf)
+	}
+}
+
+func TestNullStringSlice(t *testing.T) {
+	testCases := []struct {
+		in       string
+		expected []string
+	}{
+		{`{}`, []string{}},
+		{`{a}`, []string{"a"}},
+		{`{a,"b c",""}`, []string{"a", "b c", ""}},
+		{`{"d\"e","f\\g",h}`, []string{`d"e`, `f\g`, "h"}},
+		{`{"{x}","a,b"}`, []string{"{x}", "a,b"}},
+	}
+	for _, test := range testCases {
+		var n NullStringSlice
+		if err := n.Scan([]byte(test.in)); err != nil {
+			t.Fatal(err)
+		}
+		if !n.Valid || !reflect.DeepEqual(n.Strings, test.expected) {
+			t.Fatalf("%s: Got: %#v, Expecting: %#v", test.in, n.Strings, test.expected)
+		}
+
+		// Values are read back as they were written.
+		v, err := n.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n2 NullStringSlice
+		if err := n2.Scan(v); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(n, n2) {
+			t.Fatalf("%s: Got: %#v, Expecting: %#v", v, n2, n)
+		}
+	}
+
+	for _, s := range []string{``, `{`, `a,b`, `{a,NULL}`, `{"a}`, `{a,}`, `{"a"b}`, `{{a},{b}}`} {
+		var n NullStringSlice
+		if err := n.Scan(s); err == nil {
+			t.Fatalf("Expecting an error for %q", s)
+		}
+	}
+
+	var n NullStringSlice
+	if err := n.Scan(nil); err != nil || n.Valid {
+		t.Fatalf("Unexpected slice %v (%v)", n, err)
+	}
+	if v, err := n.Value(); err != nil || v != nil {
+		t.Fatalf("Got: %v (%v), Expecting: nil", v, err)
+	}
+	if v, _ := NewNullStringSlice(nil).Value(); v != "{}" {
+		t.Fatalf("Got: %v, Expecting: {}", v)
+	}
+
+	type post struct {
+		Tags   NullStringSlice `json:"tags"`
+		Labels NullStringSlice `json:"labels"`
+		Empty  NullStringSlice `json:"empty"`
+	}
+	buf, err := json.Marshal(post{Tags: NewNullStringSlice([]string{"go"}), Empty: NewNullStringSlice(nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != `{"tags":["go"],"labels":null,"empty":[]}` {
+		t.Fatalf("Unexpected JSON %s", buf)
+	}
+	var p post
+	if err := json.Unmarshal(buf, &p); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Tags, NewNullStringSlice([]string{"go"})) || p.Labels.Valid || !p.Empty.Valid {
+		t.Fatalf("Unexpected post %v", p)
+	}
+}
@@ -23,12 +23,16 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"math/rand"
+	"net"
 	"os"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
@@ -597,6 +601,149 @@ func TestMaxOpenConns_Issue340(t *testing.T) {
 	sess.SetMaxOpenConns(0)
 }
 
+func TestListenNotify(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events, err := sess.(Database).Listen(ctx, "upper_events")
+	assert.NoError(t, err)
+
+	err = sess.(Database).Notify("upper_events", "hello")
+	assert.NoError(t, err)
+
+	select {
+	case n := <-events:
+		assert.Equal(t, "upper_events", n.Channel)
+		assert.Equal(t, "hello", n.Payload)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expecting a notification")
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expecting channel to be closed")
+	}
+}
+
+func TestAdvisoryLock(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	ctx := context.Background()
+
+	lock, err := sess.(Database).AdvisoryLock(ctx, 370)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(370), lock.Key())
+
+	{
+		lock, ok, err := sess.(Database).TryAdvisoryLock(ctx, 370)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.Nil(t, lock)
+	}
+
+	err = lock.Unlock()
+	assert.NoError(t, err)
+
+	{
+		lock, ok, err := sess.(Database).TryAdvisoryLock(ctx, 370)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.NoError(t, lock.Unlock())
+	}
+
+	// Unlocking twice has no effect.
+	assert.NoError(t, lock.Unlock())
+}
+
+func TestDistributedTx(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE IF NOT EXISTS distributed_items (id SERIAL PRIMARY KEY, name TEXT)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE distributed_items`)
+
+	dsess := sess.(Database)
+	ctx := context.Background()
+
+	_, err = dsess.NewDistributedTx(ctx, "it's")
+	assert.Equal(t, sqlbuilder.ErrInvalidGID, err)
+
+	tx, err := dsess.NewDistributedTx(ctx, "upper-test-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "upper-test-1", tx.GID())
+
+	_, err = tx.Collection("distributed_items").Insert(map[string]interface{}{"name": "prepared"})
+	assert.NoError(t, err)
+
+	err = tx.PrepareCommit()
+	if err != nil && strings.Contains(err.Error(), "prepared transactions are disabled") {
+		_ = tx.Rollback()
+		t.Skip("Prepared transactions are disabled, see max_prepared_transactions.")
+	}
+	assert.NoError(t, err)
+
+	gids, err := dsess.PreparedTransactions()
+	assert.NoError(t, err)
+	assert.Contains(t, gids, "upper-test-1")
+
+	assert.NoError(t, tx.Commit())
+
+	gids, err = dsess.PreparedTransactions()
+	assert.NoError(t, err)
+	assert.NotContains(t, gids, "upper-test-1")
+
+	count, err := sess.Collection("distributed_items").Find().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	tx, err = dsess.NewDistributedTx(ctx, "upper-test-2")
+	assert.NoError(t, err)
+	_, err = tx.Collection("distributed_items").Insert(map[string]interface{}{"name": "rolled back"})
+	assert.NoError(t, err)
+	assert.NoError(t, tx.PrepareCommit())
+	assert.NoError(t, tx.Rollback())
+
+	count, err = sess.Collection("distributed_items").Find().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+}
+
+func TestOpenCluster(t *testing.T) {
+	// The same server plays both roles, what matters is where queries go.
+	sess, err := OpenCluster(settings, settings, settings)
+	assert.NoError(t, err)
+	defer sess.Close()
+
+	replicas := sess.(sqladapter.Database).Replicas()
+	assert.Equal(t, 2, len(replicas))
+
+	for i := 0; i < 4; i++ {
+		_, err := sess.Collection("artist").Find().Count()
+		assert.NoError(t, err)
+	}
+	for _, replica := range replicas {
+		assert.NotZero(t, replica.Stats().OpenConnections)
+	}
+
+	primary := sess.Primary()
+	_, err = primary.Collection("artist").Find().Count()
+	assert.NoError(t, err)
+
+	err = sess.Tx(context.Background(), func(tx sqlbuilder.Tx) error {
+		_, err := tx.Collection("artist").Find().Count()
+		return err
+	})
+	assert.NoError(t, err)
+}
+
 func TestUUIDInsert_Issue370(t *testing.T) {
 	sess := mustOpen()
 	defer sess.Close()
@@ -730,3 +877,297 @@ func cleanUpCheck(sess sqlbuilder.Database) (err error) {
 	}
 	return nil
 }
+
+func TestNetworkTypes(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE network_hosts (id SERIAL PRIMARY KEY, addr INET, subnet CIDR, mac MACADDR)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE network_hosts`)
+
+	type hostT struct {
+		ID     int64   `db:"id,omitempty"`
+		Addr   Inet    `db:"addr"`
+		Subnet CIDR    `db:"subnet"`
+		MAC    MACAddr `db:"mac"`
+	}
+
+	addr, err := ParseInet("10.1.2.3/16")
+	assert.NoError(t, err)
+	subnet, err := ParseCIDR("10.1.0.0/16")
+	assert.NoError(t, err)
+	mac, err := net.ParseMAC("08:00:2b:01:02:03")
+	assert.NoError(t, err)
+
+	col := sess.Collection("network_hosts")
+
+	host := hostT{Addr: addr, Subnet: subnet, MAC: MACAddr(mac)}
+	assert.NoError(t, col.InsertReturning(&host))
+
+	_, err = col.Insert(hostT{})
+	assert.NoError(t, err)
+
+	var hosts []hostT
+	assert.NoError(t, col.Find(NetworkContainedBy("addr", "10.0.0.0/8")).All(&hosts))
+	if assert.Len(t, hosts, 1) {
+		assert.Equal(t, "10.1.2.3/16", hosts[0].Addr.String())
+		assert.Equal(t, "10.1.0.0/16", hosts[0].Subnet.String())
+		assert.Equal(t, "08:00:2b:01:02:03", hosts[0].MAC.String())
+	}
+
+	count, err := col.Find(NetworkContains("subnet", net.ParseIP("10.1.200.1"))).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	count, err = col.Find(db.Cond{"addr <<": "192.168.0.0/16"}).Count()
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	hosts = nil
+	assert.NoError(t, col.Find(db.IsNull("addr")).All(&hosts))
+	if assert.Len(t, hosts, 1) {
+		assert.Nil(t, hosts[0].Addr.IP)
+		assert.Nil(t, hosts[0].MAC)
+	}
+}
+
+func TestGeometricTypes(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE geometric_shapes (id SERIAL PRIMARY KEY, location POINT, bounds BOX, area POLYGON)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE geometric_shapes`)
+
+	type shapeT struct {
+		ID       int64   `db:"id,omitempty"`
+		Location *Point  `db:"location"`
+		Bounds   Box     `db:"bounds"`
+		Area     Polygon `db:"area"`
+	}
+
+	col := sess.Collection("geometric_shapes")
+
+	shape := shapeT{
+		Location: &Point{1.5, 2.5},
+		Bounds:   Box{Min: Point{0, 0}, Max: Point{3, 4}},
+		Area:     Polygon{{0, 0}, {0, 4}, {3, 4}, {3, 0}},
+	}
+	assert.NoError(t, col.InsertReturning(&shape))
+
+	var stored shapeT
+	assert.NoError(t, col.Find(db.Cond{"bounds @>": db.Raw("?::point", Point{1, 1})}).One(&stored))
+	assert.Equal(t, shape, stored)
+
+	count, err := col.Find(db.Cond{"area @>": db.Raw("?::point", Point{5, 5})}).Count()
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	_, err = col.Insert(shapeT{})
+	assert.NoError(t, err)
+
+	stored = shapeT{}
+	assert.NoError(t, col.Find(db.IsNull("location")).One(&stored))
+	assert.Nil(t, stored.Location)
+	assert.Nil(t, stored.Area)
+}
+
+func TestDecimal(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	type accountT struct {
+		ID      int64       `db:"id,pk,omitempty"`
+		Balance db.Decimal  `db:"balance,precision=38,scale=18"`
+		Limit   *db.Decimal `db:"credit_limit"`
+	}
+
+	_, err := sess.CreateTable("decimal_accounts").FromStruct(accountT{}).Exec()
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE decimal_accounts`)
+
+	balance, err := db.ParseDecimal("12345678901234567890.123456789012345678")
+	assert.NoError(t, err)
+
+	col := sess.Collection("decimal_accounts")
+
+	account := accountT{Balance: balance}
+	assert.NoError(t, col.InsertReturning(&account))
+
+	var stored accountT
+	assert.NoError(t, col.Find(account.ID).One(&stored))
+	assert.Equal(t, balance.String(), stored.Balance.String())
+	assert.Nil(t, stored.Limit)
+
+	deposit := db.NewDecimal(1, 18)
+	assert.NoError(t, col.Find(account.ID).Update(map[string]interface{}{"balance": stored.Balance.Add(deposit)}))
+
+	count, err := col.Find(db.Cond{"balance >": balance}).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+}
+
+func TestInterval(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	type planT struct {
+		ID        int64          `db:"id,pk,omitempty"`
+		Period    db.Interval    `db:"period"`
+		Timeout   time.Duration  `db:"timeout,interval"`
+		Retry     *time.Duration `db:"retry,interval"`
+		CreatedAt time.Time      `db:"created_at"`
+	}
+
+	_, err := sess.CreateTable("interval_plans").FromStruct(planT{}).Exec()
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE interval_plans`)
+
+	col := sess.Collection("interval_plans")
+
+	retry := 90 * time.Second
+	plan := planT{
+		Period:    db.Interval{Months: 14, Days: -3, Duration: 4*time.Hour + 500*time.Microsecond},
+		Timeout:   36 * time.Hour,
+		Retry:     &retry,
+		CreatedAt: time.Now().Add(-48 * time.Hour),
+	}
+	assert.NoError(t, col.InsertReturning(&plan))
+
+	var stored planT
+	assert.NoError(t, col.Find(plan.ID).One(&stored))
+	assert.Equal(t, plan.Period, stored.Period)
+	assert.Equal(t, plan.Timeout, stored.Timeout)
+	assert.Equal(t, plan.Retry, stored.Retry)
+
+	count, err := col.Find(OlderThan("created_at", 24*time.Hour)).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	count, err = col.Find(WithinLast("created_at", "1 day")).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), count)
+
+	count, err = col.Find(db.Cond{"created_at <": SubInterval("created_at", db.Interval{Months: -1})}).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	// A month can't be read into a time.Duration.
+	_, err = sess.Exec(`UPDATE interval_plans SET timeout = '1 mon'`)
+	assert.NoError(t, err)
+	assert.Error(t, col.Find(plan.ID).One(&stored))
+}
+
+func TestNullTypes(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	type profileT struct {
+		ID       int64              `db:"id,pk,omitempty"`
+		Birthday db.NullTime        `db:"birthday"`
+		Settings db.NullJSON        `db:"settings"`
+		Tags     db.NullStringSlice `db:"tags"`
+	}
+
+	_, err := sess.CreateTable("null_profiles").FromStruct(profileT{}).Exec()
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE null_profiles`)
+
+	col := sess.Collection("null_profiles")
+
+	settings, err := db.NewNullJSON(map[string]string{"theme": "dark"})
+	assert.NoError(t, err)
+
+	profiles := []profileT{
+		{},
+		{
+			Birthday: db.NewNullTime(time.Date(1990, time.May, 4, 0, 0, 0, 0, time.UTC)),
+			Settings: settings,
+			Tags:     db.NewNullStringSlice([]string{"a", `"quoted", with comma`, ""}),
+		},
+		{Tags: db.NewNullStringSlice([]string{})},
+	}
+	for i := range profiles {
+		assert.NoError(t, col.InsertReturning(&profiles[i]))
+	}
+
+	var stored []profileT
+	assert.NoError(t, col.Find().OrderBy("id").All(&stored))
+	if assert.Len(t, stored, 3) {
+		assert.Equal(t, profileT{ID: profiles[0].ID}, stored[0])
+
+		assert.True(t, stored[1].Birthday.Time.Equal(profiles[1].Birthday.Time))
+		var settings map[string]string
+		assert.NoError(t, stored[1].Settings.Unmarshal(&settings))
+		assert.Equal(t, map[string]string{"theme": "dark"}, settings)
+		assert.Equal(t, profiles[1].Tags, stored[1].Tags)
+
+		assert.Equal(t, db.NewNullStringSlice([]string{}), stored[2].Tags)
+	}
+
+	count, err := col.Find(db.IsNull("tags")).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+}
+
+type orderStatusT string
+
+func (orderStatusT) EnumValues() []string {
+	return []string{"pending", "paid", "shipped"}
+}
+
+func TestEnum(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	assert.NoError(t, DropEnum(sess, "order_status"))
+	assert.NoError(t, CreateEnum(sess, "order_status", "pending", "paid"))
+	defer DropEnum(sess, "order_status")
+
+	type orderT struct {
+		ID       int64        `db:"id,pk,omitempty"`
+		Status   orderStatusT `db:"status"`
+		Priority int          `db:"priority,enum=low|high"`
+	}
+
+	_, err := sess.CreateTable("enum_orders").
+		FromStruct(orderT{}).
+		Columns(sqlbuilder.Column{Name: "status", Type: "order_status", NotNull: true}).
+		Exec()
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE enum_orders`)
+
+	col := sess.Collection("enum_orders")
+
+	order := orderT{Status: "paid", Priority: 1}
+	assert.NoError(t, col.InsertReturning(&order))
+
+	var stored orderT
+	assert.NoError(t, col.Find(order.ID).One(&stored))
+	assert.Equal(t, order, stored)
+
+	// The Go type knows "shipped", but the database type doesn't yet.
+	err = col.Find(order.ID).Update(orderT{ID: order.ID, Status: "shipped"})
+	assert.Error(t, err)
+
+	assert.NoError(t, AddEnumValue(sess, "order_status", "shipped"))
+	assert.NoError(t, AddEnumValue(sess, "order_status", "shipped"))
+
+	values, err := EnumValues(sess, "order_status")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pending", "paid", "shipped"}, values)
+
+	assert.NoError(t, col.Find(order.ID).Update(orderT{ID: order.ID, Status: "shipped"}))
+
+	// Values the Go type doesn't know are rejected on both ends.
+	_, err = col.Insert(orderT{Status: "refunded"})
+	assert.IsType(t, &db.EnumError{}, err)
+
+	assert.NoError(t, AddEnumValue(sess, "order_status", "refunded"))
+	_, err = sess.Exec(`UPDATE enum_orders SET status = 'refunded'`)
+	assert.NoError(t, err)
+
+	err = col.Find(order.ID).One(&stored)
+	assert.Equal(t, &db.EnumError{Column: "status", Value: "refunded", Values: orderStatusT("").EnumValues()}, err)
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"upper.io/db.v3/internal/sqladapter/compat"
+)
+
+// AdvisoryLock is a guard for an exclusive PostgreSQL advisory lock, the lock
+// is held until Unlock is called or until the context that was used to
+// acquire it is done.
+//
+// The lock is acquired with pg_advisory_xact_lock within a transaction that
+// runs on a dedicated connection, this makes sure the lock is released if
+// the connection is lost or if the program exits without calling Unlock.
+type AdvisoryLock struct {
+	key int64
+
+	mu    sync.Mutex
+	sqlTx *sql.Tx
+}
+
+// Key returns the key of the lock.
+func (l *AdvisoryLock) Key() int64 {
+	return l.key
+}
+
+// Unlock releases the lock. Calling Unlock on a lock that was already
+// released has no effect.
+func (l *AdvisoryLock) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.sqlTx == nil {
+		return nil
+	}
+
+	err := l.sqlTx.Rollback()
+	l.sqlTx = nil
+	if err == sql.ErrTxDone {
+		// The transaction was already rolled back because the context was
+		// done.
+		return nil
+	}
+	return err
+}
+
+// AdvisoryLock blocks until the advisory lock identified by key is acquired
+// or until ctx is done.
+func (d *database) AdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, error) {
+	lock, _, err := d.advisoryLock(ctx, key, true)
+	return lock, err
+}
+
+// TryAdvisoryLock attempts to acquire the advisory lock identified by key
+// without waiting, the returned bool is false if the lock is being held by
+// another session.
+func (d *database) TryAdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, bool, error) {
+	return d.advisoryLock(ctx, key, false)
+}
+
+func (d *database) advisoryLock(ctx context.Context, key int64, wait bool) (*AdvisoryLock, bool, error) {
+	if ctx == nil {
+		ctx = d.Context()
+	}
+
+	sqlTx, err := compat.BeginTx(d.Session(), ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	acquired := true
+	if wait {
+		_, err = compat.ExecContext(sqlTx, ctx, `SELECT pg_advisory_xact_lock($1)`, []interface{}{key})
+	} else {
+		err = compat.QueryRowContext(sqlTx, ctx, `SELECT pg_try_advisory_xact_lock($1)`, []interface{}{key}).Scan(&acquired)
+	}
+	if err != nil {
+		_ = sqlTx.Rollback()
+		return nil, false, err
+	}
+
+	if !acquired {
+		_ = sqlTx.Rollback()
+		return nil, false, nil
+	}
+
+	return &AdvisoryLock{key: key, sqlTx: sqlTx}, true, nil
+}
@@ -23,9 +23,12 @@ package postgresql
 
 import (
 	"database/sql"
+	"strings"
 
+	"github.com/lib/pq"
 	"upper.io/db.v3"
 	"upper.io/db.v3/internal/sqladapter"
+	"upper.io/db.v3/internal/sqladapter/compat"
 )
 
 // collection is the actual implementation of a collection.
@@ -61,6 +64,10 @@ func (c *collection) Database() sqladapter.Database {
 
 // Insert inserts an item (map or struct) into the collection.
 func (c *collection) Insert(item interface{}) (interface{}, error) {
+	return sqladapter.InsertWithHooks(c.d, item, c.insert)
+}
+
+func (c *collection) insert(item interface{}) (interface{}, error) {
 	var err error
 
 	pKey := c.BaseCollection.PrimaryKeys()
@@ -98,3 +105,45 @@ func (c *collection) Insert(item interface{}) (interface{}, error) {
 	// This was a compound key and no interface matched it, let's return a map.
 	return keyMap, nil
 }
+
+// BulkLoad loads the given rows into the table using COPY FROM STDIN, it's
+// used by BulkInsert.
+func (c *collection) BulkLoad(columns []string, rows [][]interface{}) error {
+	copyIn := pq.CopyIn(c.Name(), columns...)
+	if chunks := strings.SplitN(c.Name(), ".", 2); len(chunks) == 2 {
+		copyIn = pq.CopyInSchema(chunks[0], chunks[1], columns...)
+	}
+
+	load := func(sqlTx *sql.Tx) error {
+		stmt, err := sqlTx.Prepare(copyIn)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for i := range rows {
+			if _, err := stmt.Exec(rows[i]...); err != nil {
+				return err
+			}
+		}
+
+		// Flushes buffered rows.
+		_, err = stmt.Exec()
+		return err
+	}
+
+	if sqlTx, ok := c.d.Driver().(*sql.Tx); ok {
+		// Already within a transaction.
+		return load(sqlTx)
+	}
+
+	sqlTx, err := compat.BeginTx(c.d.Session(), c.d.Context(), nil)
+	if err != nil {
+		return err
+	}
+	if err := load(sqlTx); err != nil {
+		_ = sqlTx.Rollback()
+		return err
+	}
+	return sqlTx.Commit()
+}
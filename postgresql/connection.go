@@ -22,12 +22,15 @@
 package postgresql
 
 import (
+	"database/sql"
 	"fmt"
 	"net"
 	"strings"
 	"unicode"
 
 	"github.com/lib/pq"
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter"
 )
 
 // scanner implements a tokenizer for libpq-style option strings.
@@ -93,6 +96,16 @@ const connectionScheme = `postgres`
 //
 // If you already have a valid DSN, you can use ParseURL to convert it into
 // a ConnectionURL before passing it to Open.
+//
+// SearchPath, TimeZone and ApplicationName are set with SET statements on
+// every new connection of the pool, unlike the options of the same name they
+// work behind connection poolers that reject startup parameters, like
+// PgBouncer. OnConnect is called next, for any other setup:
+//
+//   settings.SearchPath = []string{"tenant_1", "public"}
+//   settings.OnConnect = func(conn db.Conn) error {
+//     return conn.Exec("SELECT set_config('app.tenant_id', $1, false)", "1")
+//   }
 type ConnectionURL struct {
 	User     string
 	Password string
@@ -100,6 +113,45 @@ type ConnectionURL struct {
 	Socket   string
 	Database string
 	Options  map[string]string
+
+	SearchPath      []string
+	TimeZone        string
+	ApplicationName string
+
+	OnConnect func(db.Conn) error
+}
+
+// setup returns the statements and the function that set up every new
+// connection of the pool of c.
+func (c ConnectionURL) setup() ([]string, func(db.Conn) error) {
+	statements := []string{}
+	if len(c.SearchPath) > 0 {
+		schemas := make([]string, len(c.SearchPath))
+		for i := range c.SearchPath {
+			schemas[i] = pq.QuoteIdentifier(c.SearchPath[i])
+		}
+		statements = append(statements, "SET search_path TO "+strings.Join(schemas, ", "))
+	}
+	if c.TimeZone != "" {
+		statements = append(statements, "SET TIME ZONE "+pq.QuoteLiteral(c.TimeZone))
+	}
+	if c.ApplicationName != "" {
+		statements = append(statements, "SET application_name TO "+pq.QuoteLiteral(c.ApplicationName))
+	}
+	return statements, c.OnConnect
+}
+
+// openDB opens a pool of connections to the server of connURL.
+func openDB(connURL db.ConnectionURL) (*sql.DB, error) {
+	switch c := connURL.(type) {
+	case ConnectionURL:
+		statements, onConnect := c.setup()
+		return sqladapter.OpenDB(sqlDriver, c.String(), statements, onConnect)
+	case *ConnectionURL:
+		statements, onConnect := c.setup()
+		return sqladapter.OpenDB(sqlDriver, c.String(), statements, onConnect)
+	}
+	return sql.Open(sqlDriver, connURL.String())
 }
 
 var escaper = strings.NewReplacer(` `, `\ `, `'`, `\'`, `\`, `\\`)
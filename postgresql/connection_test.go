@@ -204,3 +204,31 @@ func TestParseConnectionURL(t *testing.T) {
 		t.Fatal("Failed to parse timezone.")
 	}
 }
+
+func TestConnectionURLSetup(t *testing.T) {
+	c := ConnectionURL{}
+
+	if statements, onConnect := c.setup(); len(statements) != 0 || onConnect != nil {
+		t.Fatal("Expecting no setup by default.")
+	}
+
+	c.SearchPath = []string{"tenant 1", "public"}
+	c.TimeZone = "UTC"
+	c.ApplicationName = "O'Reilly"
+
+	expected := []string{
+		`SET search_path TO "tenant 1", "public"`,
+		`SET TIME ZONE 'UTC'`,
+		`SET application_name TO 'O''Reilly'`,
+	}
+
+	statements, _ := c.setup()
+	if len(statements) != len(expected) {
+		t.Fatalf("Got: %v, Expecting: %v", statements, expected)
+	}
+	for i := range expected {
+		if statements[i] != expected[i] {
+			t.Fatalf("Got: %v, Expecting: %v", statements[i], expected[i])
+		}
+	}
+}
@@ -22,12 +22,19 @@
 package postgresql
 
 import (
+	"bytes"
+	"database/sql"
 	"database/sql/driver"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -265,3 +272,728 @@ func (a Int64Array) Value() (driver.Value, error) {
 
 	return []byte{'{', '}'}, nil
 }
+
+// Float64Array represents a PostgreSQL's double precision array. NaN,
+// Infinity and scientific notation are supported, NULL elements can't be
+// represented and produce an error, use GenericArray with []*float64 instead.
+type Float64Array []float64
+
+// Scan implements the sql.Scanner interface.
+func (a *Float64Array) Scan(src interface{}) error {
+	elems, err := scanOneDimensionalArray(src)
+	if err != nil {
+		return err
+	}
+	if elems == nil {
+		*a = nil
+		return nil
+	}
+
+	results := make([]float64, len(elems))
+	for i := range elems {
+		if elems[i] == nil {
+			return fmt.Errorf("Cannot scan NULL element at index %d into Float64Array", i)
+		}
+		if results[i], err = strconv.ParseFloat(string(elems[i]), 64); err != nil {
+			return err
+		}
+	}
+	*a = Float64Array(results)
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (a Float64Array) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	b := make([]byte, 1, 1+2*len(a))
+	b[0] = '{'
+	for i := range a {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendArrayFloat(b, a[i], 64)
+	}
+	return append(b, '}'), nil
+}
+
+// BoolArray represents a PostgreSQL's boolean array. NULL elements can't be
+// represented and produce an error, use GenericArray with []*bool instead.
+type BoolArray []bool
+
+// Scan implements the sql.Scanner interface.
+func (a *BoolArray) Scan(src interface{}) error {
+	elems, err := scanOneDimensionalArray(src)
+	if err != nil {
+		return err
+	}
+	if elems == nil {
+		*a = nil
+		return nil
+	}
+
+	results := make([]bool, len(elems))
+	for i := range elems {
+		switch string(elems[i]) {
+		case "t", "true":
+			results[i] = true
+		case "f", "false":
+			results[i] = false
+		default:
+			if elems[i] == nil {
+				return fmt.Errorf("Cannot scan NULL element at index %d into BoolArray", i)
+			}
+			return fmt.Errorf("Cannot parse %q as bool", elems[i])
+		}
+	}
+	*a = BoolArray(results)
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (a BoolArray) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	// There will be two curly brackets, N bytes of values and N-1 bytes of
+	// delimiters.
+	b := make([]byte, 1, 1+2*len(a))
+	b[0] = '{'
+	for i := range a {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if a[i] {
+			b = append(b, 't')
+		} else {
+			b = append(b, 'f')
+		}
+	}
+	return append(b, '}'), nil
+}
+
+// scanOneDimensionalArray returns the elements of a one-dimensional array, a
+// nil slice is returned if src is NULL.
+func scanOneDimensionalArray(src interface{}) ([][]byte, error) {
+	var b []byte
+	switch src := src.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		b = src
+	case string:
+		b = []byte(src)
+	default:
+		return nil, fmt.Errorf("Cannot scan %T into an array", src)
+	}
+
+	dims, elems, err := parseArray(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(dims) > 1 {
+		return nil, fmt.Errorf("Expecting a one-dimensional array, got %d dimensions", len(dims))
+	}
+	if elems == nil {
+		elems = [][]byte{}
+	}
+	return elems, nil
+}
+
+// HStore represents a PostgreSQL's hstore column. NULL values are represented
+// by nil pointers.
+type HStore map[string]*string
+
+// Scan implements the sql.Scanner interface.
+func (h *HStore) Scan(src interface{}) error {
+	var b []byte
+	switch src := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		b = src
+	case string:
+		b = []byte(src)
+	default:
+		return fmt.Errorf("Cannot scan %T into HStore", src)
+	}
+
+	results := HStore{}
+
+	for i := 0; ; {
+		for i < len(b) && (b[i] == ' ' || b[i] == ',') {
+			i++
+		}
+		if i >= len(b) {
+			break
+		}
+
+		key, n, err := parseHStoreString(b, i)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			return fmt.Errorf("Unexpected NULL key at position %d of %q", i, b)
+		}
+		i = n
+
+		for i < len(b) && b[i] == ' ' {
+			i++
+		}
+		if !bytes.HasPrefix(b[i:], []byte("=>")) {
+			return fmt.Errorf("Expecting => at position %d of %q", i, b)
+		}
+		i += 2
+		for i < len(b) && b[i] == ' ' {
+			i++
+		}
+
+		value, n, err := parseHStoreString(b, i)
+		if err != nil {
+			return err
+		}
+		i = n
+
+		results[*key] = value
+	}
+
+	*h = results
+	return nil
+}
+
+// parseHStoreString parses a quoted string or NULL starting at position i and
+// returns it along with the position right after it.
+func parseHStoreString(b []byte, i int) (*string, int, error) {
+	if bytes.HasPrefix(b[i:], []byte("NULL")) {
+		return nil, i + 4, nil
+	}
+	if i >= len(b) || b[i] != '"' {
+		return nil, 0, fmt.Errorf("Expecting \" at position %d of %q", i, b)
+	}
+
+	var buf []byte
+	for i++; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+			if i < len(b) {
+				buf = append(buf, b[i])
+			}
+		case '"':
+			s := string(buf)
+			return &s, i + 1, nil
+		default:
+			buf = append(buf, b[i])
+		}
+	}
+
+	return nil, 0, fmt.Errorf("Unterminated string in %q", b)
+}
+
+// Value implements the driver.Valuer interface.
+func (h HStore) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b := []byte{}
+	for i, k := range keys {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendArrayQuotedString(b, k)
+		b = append(b, "=>"...)
+		if h[k] == nil {
+			b = append(b, "NULL"...)
+		} else {
+			b = appendArrayQuotedString(b, *h[k])
+		}
+	}
+	return b, nil
+}
+
+// UUID represents a PostgreSQL's uuid column. Use *UUID for nullable columns.
+//
+// UUID types from other packages, like github.com/google/uuid and
+// github.com/satori/go.uuid, implement sql.Scanner and driver.Valuer too and
+// can be used directly on struct fields.
+type UUID [16]byte
+
+// ParseUUID parses a UUID in its canonical form
+// ("xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"), with or without hyphens and
+// curly braces.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+
+	if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+		s = s[1 : len(s)-1]
+	}
+	if len(s) == 36 {
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return u, fmt.Errorf("Invalid UUID %q", s)
+		}
+		s = s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	}
+	if len(s) != 32 {
+		return u, fmt.Errorf("Invalid UUID %q", s)
+	}
+
+	if _, err := hex.Decode(u[:], []byte(s)); err != nil {
+		return u, fmt.Errorf("Invalid UUID %q: %v", s, err)
+	}
+	return u, nil
+}
+
+// String returns the canonical representation of the UUID.
+func (u UUID) String() string {
+	b := make([]byte, 36)
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b)
+}
+
+// IsZero returns true if all the bytes of the UUID are zero.
+func (u UUID) IsZero() bool {
+	return u == UUID{}
+}
+
+// Scan implements the sql.Scanner interface.
+func (u *UUID) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*u = UUID{}
+		return nil
+	case []byte:
+		if len(src) == 16 {
+			copy(u[:], src)
+			return nil
+		}
+		return u.Scan(string(src))
+	case string:
+		v, err := ParseUUID(src)
+		if err != nil {
+			return err
+		}
+		*u = v
+		return nil
+	}
+	return fmt.Errorf("Cannot scan %T into UUID", src)
+}
+
+// Value implements the driver.Valuer interface.
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+// GenericArray represents a PostgreSQL's array of any type. A must be a
+// pointer to a slice when scanning and a slice or an array when valuing.
+// Elements can be strings, booleans, integers, floats, []byte, time.Time,
+// types that implement sql.Scanner and driver.Valuer (like UUIDs) and nested
+// slices for multi-dimensional arrays. NULL elements are scanned into nil
+// pointers.
+//
+//   var prices []float64
+//   err := row.Scan(GenericArray{&prices})
+type GenericArray struct {
+	A interface{}
+}
+
+// Scan implements the sql.Scanner interface.
+func (a GenericArray) Scan(src interface{}) error {
+	dpv := reflect.ValueOf(a.A)
+	if dpv.Kind() != reflect.Ptr || dpv.IsNil() {
+		return fmt.Errorf("Destination %T is not a non-nil pointer", a.A)
+	}
+
+	dv := dpv.Elem()
+	if dv.Kind() != reflect.Slice {
+		return fmt.Errorf("Destination %T is not a pointer to a slice", a.A)
+	}
+
+	var b []byte
+	switch src := src.(type) {
+	case nil:
+		dv.Set(reflect.Zero(dv.Type()))
+		return nil
+	case []byte:
+		b = src
+	case string:
+		b = []byte(src)
+	default:
+		return fmt.Errorf("Cannot scan %T into %T", src, a.A)
+	}
+
+	dims, elems, err := parseArray(b)
+	if err != nil {
+		return err
+	}
+
+	if len(dims) == 0 {
+		dv.Set(reflect.MakeSlice(dv.Type(), 0, 0))
+		return nil
+	}
+
+	dt := dv.Type()
+	for i := 1; i < len(dims); i++ {
+		dt = dt.Elem()
+		if dt.Kind() != reflect.Slice {
+			return fmt.Errorf("Cannot scan a %d-dimensional array into %T", len(dims), a.A)
+		}
+	}
+
+	return scanArray(dv, dims, elems)
+}
+
+// Value implements the driver.Valuer interface.
+func (a GenericArray) Value() (driver.Value, error) {
+	if a.A == nil {
+		return nil, nil
+	}
+
+	rv := reflect.ValueOf(a.A)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	case reflect.Array:
+	default:
+		return nil, fmt.Errorf("Cannot convert %T into an array", a.A)
+	}
+
+	return appendArray([]byte{}, rv)
+}
+
+// parseArray parses the text representation of a PostgreSQL's array into its
+// dimensions and a flat list of elements, NULL elements are returned as nil.
+func parseArray(src []byte) (dims []int, elems [][]byte, err error) {
+	var depth, i int
+
+	if len(src) < 1 || src[0] != '{' {
+		return nil, nil, fmt.Errorf("Expecting { at position 0 of %q", src)
+	}
+
+open:
+	for i < len(src) {
+		switch src[i] {
+		case '{':
+			depth++
+			i++
+		case '}':
+			elems = elems[:0]
+			goto close
+		default:
+			break open
+		}
+	}
+	dims = make([]int, i)
+
+element:
+	for i < len(src) {
+		switch src[i] {
+		case '{':
+			if depth == len(dims) {
+				break element
+			}
+			depth++
+			dims[depth-1] = 0
+			i++
+		case '"':
+			elem, escape := []byte{}, false
+			for i++; i < len(src); i++ {
+				if escape {
+					elem = append(elem, src[i])
+					escape = false
+					continue
+				}
+				switch src[i] {
+				case '\\':
+					escape = true
+				case '"':
+					elems = append(elems, elem)
+					i++
+					break element
+				default:
+					elem = append(elem, src[i])
+				}
+			}
+		default:
+			for start := i; i < len(src); i++ {
+				if src[i] == ',' || src[i] == '}' {
+					elem := src[start:i]
+					if len(elem) == 0 {
+						return nil, nil, fmt.Errorf("Unexpected %q at position %d of %q", src[i], i, src)
+					}
+					if bytes.Equal(elem, []byte("NULL")) {
+						elem = nil
+					}
+					elems = append(elems, elem)
+					break element
+				}
+			}
+		}
+	}
+
+	for i < len(src) {
+		if src[i] == ',' && depth > 0 {
+			dims[depth-1]++
+			i++
+			goto element
+		} else if src[i] == '}' && depth > 0 {
+			dims[depth-1]++
+			depth--
+			i++
+		} else {
+			return nil, nil, fmt.Errorf("Unexpected %q at position %d of %q", src[i], i, src)
+		}
+	}
+
+close:
+	for i < len(src) {
+		if src[i] == '}' && depth > 0 {
+			depth--
+			i++
+		} else {
+			return nil, nil, fmt.Errorf("Unexpected %q at position %d of %q", src[i], i, src)
+		}
+	}
+	if depth > 0 {
+		return nil, nil, fmt.Errorf("Array %q is not terminated", src)
+	}
+
+	for _, d := range dims {
+		if d == 0 || len(elems)%d != 0 {
+			return nil, nil, fmt.Errorf("Array %q has inconsistent dimensions", src)
+		}
+	}
+
+	return dims, elems, nil
+}
+
+func scanArray(dv reflect.Value, dims []int, elems [][]byte) error {
+	n := dims[0]
+	values := reflect.MakeSlice(dv.Type(), n, n)
+
+	if len(dims) == 1 {
+		if len(elems) != n {
+			return fmt.Errorf("Expecting %d array elements, got %d", n, len(elems))
+		}
+		for i := 0; i < n; i++ {
+			if err := scanArrayElement(values.Index(i), elems[i]); err != nil {
+				return err
+			}
+		}
+	} else {
+		stride := len(elems) / n
+		for i := 0; i < n; i++ {
+			if err := scanArray(values.Index(i), dims[1:], elems[i*stride:(i+1)*stride]); err != nil {
+				return err
+			}
+		}
+	}
+
+	dv.Set(values)
+	return nil
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+
+	arrayTimeLayouts = []string{
+		"2006-01-02 15:04:05.999999999Z07:00:00",
+		"2006-01-02 15:04:05.999999999Z07:00",
+		"2006-01-02 15:04:05.999999999Z07",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02",
+	}
+)
+
+func scanArrayElement(v reflect.Value, elem []byte) error {
+	if v.Kind() == reflect.Ptr {
+		if elem == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		pv := reflect.New(v.Type().Elem())
+		if err := scanArrayElement(pv.Elem(), elem); err != nil {
+			return err
+		}
+		v.Set(pv)
+		return nil
+	}
+
+	if scanner, ok := v.Addr().Interface().(sql.Scanner); ok {
+		if elem == nil {
+			return scanner.Scan(nil)
+		}
+		return scanner.Scan(elem)
+	}
+
+	if elem == nil {
+		return fmt.Errorf("Cannot scan NULL into an element of type %v, use a pointer instead", v.Type())
+	}
+
+	s := string(elem)
+
+	if v.Type() == timeType {
+		for _, layout := range arrayTimeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				v.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("Cannot parse %q as time", s)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		switch s {
+		case "t", "true":
+			v.SetBool(true)
+		case "f", "false":
+			v.SetBool(false)
+		default:
+			return fmt.Errorf("Cannot parse %q as bool", s)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("Cannot scan %q into %v", s, v.Type())
+		}
+		if !strings.HasPrefix(s, `\x`) {
+			return fmt.Errorf("Expecting bytea in hex format, got %q", s)
+		}
+		b, err := hex.DecodeString(s[2:])
+		if err != nil {
+			return err
+		}
+		v.SetBytes(b)
+	default:
+		return fmt.Errorf("Cannot scan %q into %v", s, v.Type())
+	}
+
+	return nil
+}
+
+func appendArray(b []byte, rv reflect.Value) ([]byte, error) {
+	var err error
+
+	b = append(b, '{')
+	for i := 0; i < rv.Len(); i++ {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if b, err = appendArrayElement(b, rv.Index(i)); err != nil {
+			return nil, err
+		}
+	}
+	return append(b, '}'), nil
+}
+
+func appendArrayElement(b []byte, v reflect.Value) ([]byte, error) {
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return append(b, "NULL"...), nil
+		}
+		v = v.Elem()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		return appendArrayQuotedString(b, t.Format("2006-01-02 15:04:05.999999999Z07:00")), nil
+	}
+
+	if valuer, ok := v.Interface().(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+		switch value := value.(type) {
+		case nil:
+			return append(b, "NULL"...), nil
+		case []byte:
+			// Valuers, like JSONB, use []byte for their text representation.
+			return appendArrayQuotedString(b, string(value)), nil
+		}
+		v = reflect.ValueOf(value)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return appendArrayQuotedString(b, v.String()), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 't'), nil
+		}
+		return append(b, 'f'), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(b, v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.AppendUint(b, v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return appendArrayFloat(b, v.Float(), v.Type().Bits()), nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(data), v)
+			return appendArrayQuotedString(b, `\x`+hex.EncodeToString(data)), nil
+		}
+		return appendArray(b, v)
+	}
+
+	return nil, fmt.Errorf("Cannot convert %v into an array element", v.Type())
+}
+
+func appendArrayFloat(b []byte, f float64, bits int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(b, "NaN"...)
+	case math.IsInf(f, 1):
+		return append(b, "Infinity"...)
+	case math.IsInf(f, -1):
+		return append(b, "-Infinity"...)
+	}
+	return strconv.AppendFloat(b, f, 'g', -1, bits)
+}
@@ -0,0 +1,242 @@
+package postgresql
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenericArrayScan(t *testing.T) {
+	{
+		var a []float64
+		assert.NoError(t, GenericArray{&a}.Scan([]byte(`{1.5,-2,3e-07,NaN}`)))
+		assert.Equal(t, 4, len(a))
+		assert.Equal(t, []float64{1.5, -2, 3e-07}, a[:3])
+		assert.True(t, math.IsNaN(a[3]))
+	}
+
+	{
+		var a []bool
+		assert.NoError(t, GenericArray{&a}.Scan([]byte(`{t,f,t}`)))
+		assert.Equal(t, []bool{true, false, true}, a)
+	}
+
+	{
+		var a []*string
+		assert.NoError(t, GenericArray{&a}.Scan([]byte(`{"a,b",NULL,"c\"d","NULL"}`)))
+		assert.Equal(t, 4, len(a))
+		assert.Equal(t, "a,b", *a[0])
+		assert.Nil(t, a[1])
+		assert.Equal(t, `c"d`, *a[2])
+		assert.Equal(t, "NULL", *a[3])
+	}
+
+	{
+		var a [][]int
+		assert.NoError(t, GenericArray{&a}.Scan([]byte(`{{1,2,3},{4,5,6}}`)))
+		assert.Equal(t, [][]int{{1, 2, 3}, {4, 5, 6}}, a)
+	}
+
+	{
+		var a []time.Time
+		assert.NoError(t, GenericArray{&a}.Scan([]byte(`{"2018-03-04 05:06:07.5+00","2018-03-04"}`)))
+		assert.Equal(t, 2, len(a))
+		assert.True(t, time.Date(2018, 3, 4, 5, 6, 7, 500000000, time.UTC).Equal(a[0]))
+		assert.True(t, time.Date(2018, 3, 4, 0, 0, 0, 0, time.UTC).Equal(a[1]))
+	}
+
+	{
+		var a [][]byte
+		assert.NoError(t, GenericArray{&a}.Scan([]byte(`{"\\x0102ff"}`)))
+		assert.Equal(t, [][]byte{{1, 2, 255}}, a)
+	}
+
+	{
+		a := []int{1}
+		assert.NoError(t, GenericArray{&a}.Scan([]byte(`{}`)))
+		assert.Equal(t, []int{}, a)
+
+		assert.NoError(t, GenericArray{&a}.Scan(nil))
+		assert.Nil(t, a)
+	}
+
+	{
+		var a []int
+		assert.Error(t, GenericArray{&a}.Scan([]byte(`{1,NULL}`)))
+		assert.Error(t, GenericArray{&a}.Scan([]byte(`{{1,2},{3}}`)))
+		assert.Error(t, GenericArray{&a}.Scan([]byte(`{1,2`)))
+		assert.Error(t, GenericArray{a}.Scan([]byte(`{1,2}`)))
+	}
+}
+
+func TestGenericArrayValue(t *testing.T) {
+	s := "b"
+
+	tests := []struct {
+		in  interface{}
+		out interface{}
+	}{
+		{nil, nil},
+		{[]int(nil), nil},
+		{[]int{}, []byte(`{}`)},
+		{[]int64{1, -2}, []byte(`{1,-2}`)},
+		{[]float64{1.5, math.NaN(), math.Inf(-1)}, []byte(`{1.5,NaN,-Infinity}`)},
+		{[]bool{true, false}, []byte(`{t,f}`)},
+		{[]*string{nil, &s}, []byte(`{NULL,"b"}`)},
+		{[]string{`a"b`, `c\d`}, []byte(`{"a\"b","c\\d"}`)},
+		{[][]int{{1, 2}, {3, 4}}, []byte(`{{1,2},{3,4}}`)},
+		{[2]int{5, 6}, []byte(`{5,6}`)},
+		{[][]byte{{1, 255}}, []byte(`{"\\x01ff"}`)},
+		{[]time.Time{time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC)}, []byte(`{"2018-03-04 05:06:07Z"}`)},
+		{[]JSONB{{V: map[string]int{"a": 1}}}, []byte(`{"{\"a\":1}"}`)},
+	}
+
+	for _, test := range tests {
+		v, err := GenericArray{test.in}.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, test.out, v)
+	}
+
+	_, err := GenericArray{1}.Value()
+	assert.Error(t, err)
+}
+
+func TestFloat64Array(t *testing.T) {
+	var a Float64Array
+
+	assert.NoError(t, a.Scan([]byte(`{1.5,-2.25e+10,3E-7,NaN,Infinity,-Infinity}`)))
+	assert.Equal(t, 6, len(a))
+	assert.Equal(t, Float64Array{1.5, -2.25e+10, 3e-7}, a[:3])
+	assert.True(t, math.IsNaN(a[3]))
+	assert.True(t, math.IsInf(a[4], 1))
+	assert.True(t, math.IsInf(a[5], -1))
+
+	assert.NoError(t, a.Scan([]byte(`{}`)))
+	assert.Equal(t, Float64Array{}, a)
+
+	assert.NoError(t, a.Scan(nil))
+	assert.Nil(t, a)
+
+	assert.Error(t, a.Scan([]byte(`{1,NULL}`)))
+	assert.Error(t, a.Scan([]byte(`{1,foo}`)))
+
+	v, err := Float64Array{1.5, -2.25e+10, math.NaN(), math.Inf(1)}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`{1.5,-2.25e+10,NaN,Infinity}`), v)
+
+	v, err = Float64Array(nil).Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+}
+
+func TestBoolArray(t *testing.T) {
+	var a BoolArray
+
+	assert.NoError(t, a.Scan([]byte(`{t,f,true,false}`)))
+	assert.Equal(t, BoolArray{true, false, true, false}, a)
+
+	assert.NoError(t, a.Scan([]byte(`{}`)))
+	assert.Equal(t, BoolArray{}, a)
+
+	assert.NoError(t, a.Scan(nil))
+	assert.Nil(t, a)
+
+	assert.Error(t, a.Scan([]byte(`{t,NULL}`)))
+	assert.Error(t, a.Scan([]byte(`{{t},{f}}`)))
+
+	v, err := BoolArray{true, false}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`{t,f}`), v)
+
+	v, err = BoolArray(nil).Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+}
+
+func TestHStore(t *testing.T) {
+	str := func(s string) *string {
+		return &s
+	}
+
+	{
+		var h HStore
+		assert.NoError(t, h.Scan([]byte(`"a"=>"1", "b"=>NULL, "c, d"=>"say \"hi\"", "e\\f"=>""`)))
+		assert.Equal(t, HStore{
+			"a":    str("1"),
+			"b":    nil,
+			"c, d": str(`say "hi"`),
+			`e\f`:  str(""),
+		}, h)
+
+		assert.NoError(t, h.Scan([]byte(``)))
+		assert.Equal(t, HStore{}, h)
+
+		assert.NoError(t, h.Scan(nil))
+		assert.Nil(t, h)
+
+		assert.Error(t, h.Scan([]byte(`"a"=`)))
+		assert.Error(t, h.Scan([]byte(`"a"=>"b`)))
+		assert.Error(t, h.Scan([]byte(`NULL=>"b"`)))
+	}
+
+	{
+		v, err := HStore{
+			"a":    str("1"),
+			"b":    nil,
+			"c, d": str(`say "hi"`),
+			`e\f`:  str(""),
+		}.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, []byte(`"a"=>"1","b"=>NULL,"c, d"=>"say \"hi\"","e\\f"=>""`), v)
+
+		var h HStore
+		assert.NoError(t, h.Scan(v))
+		assert.Equal(t, 4, len(h))
+
+		v, err = HStore(nil).Value()
+		assert.NoError(t, err)
+		assert.Nil(t, v)
+	}
+}
+
+func TestUUID(t *testing.T) {
+	expected := UUID{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+	for _, s := range []string{
+		"6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		"{6ba7b810-9dad-11d1-80b4-00c04fd430c8}",
+		"6ba7b8109dad11d180b400c04fd430c8",
+		"6BA7B810-9DAD-11D1-80B4-00C04FD430C8",
+	} {
+		u, err := ParseUUID(s)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, u)
+	}
+
+	for _, s := range []string{"", "6ba7b810-9dad-11d1-80b4", "6ba7b810x9dadx11d1x80b4x00c04fd430c8", "zba7b810-9dad-11d1-80b4-00c04fd430c8"} {
+		_, err := ParseUUID(s)
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", expected.String())
+
+	{
+		var u UUID
+		assert.NoError(t, u.Scan([]byte("6ba7b810-9dad-11d1-80b4-00c04fd430c8")))
+		assert.Equal(t, expected, u)
+
+		assert.NoError(t, u.Scan(expected[:]))
+		assert.Equal(t, expected, u)
+
+		assert.NoError(t, u.Scan(nil))
+		assert.True(t, u.IsZero())
+
+		assert.Error(t, u.Scan(1))
+	}
+
+	v, err := expected.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", v)
+}
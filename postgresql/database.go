@@ -26,12 +26,12 @@ package postgresql
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
-	_ "github.com/lib/pq" // PostgreSQL driver.
+	"github.com/lib/pq" // PostgreSQL driver.
 	"upper.io/db.v3"
 	"upper.io/db.v3/internal/sqladapter"
 	"upper.io/db.v3/internal/sqladapter/compat"
@@ -45,13 +45,15 @@ type database struct {
 
 	sqlbuilder.SQLBuilder
 
-	connURL db.ConnectionURL
-	mu      sync.Mutex
+	connURL  db.ConnectionURL
+	listener *listener
+	mu       sync.Mutex
 }
 
 var (
 	_ = sqlbuilder.Database(&database{})
 	_ = sqladapter.Database(&database{})
+	_ = Database(&database{})
 )
 
 // newDatabase creates a new *database session for internal use.
@@ -89,9 +91,14 @@ func (d *database) NewTx(ctx context.Context) (sqlbuilder.Tx, error) {
 
 // Collections returns a list of non-system tables from the database.
 func (d *database) Collections() (collections []string, err error) {
+	schema := d.Schema()
+	if schema == "" {
+		schema = "public"
+	}
+
 	q := d.Select("table_name").
 		From("information_schema.tables").
-		Where("table_schema = ?", "public")
+		Where("table_schema = ?", schema)
 
 	iter := q.Iterator()
 	defer iter.Close()
@@ -116,7 +123,7 @@ func (d *database) open() error {
 	d.SQLBuilder = sqlbuilder.WithSession(d.BaseDatabase, template)
 
 	connFn := func() error {
-		sess, err := sql.Open("postgres", d.ConnectionURL().String())
+		sess, err := openDB(d.ConnectionURL())
 		if err == nil {
 			sess.SetConnMaxLifetime(db.DefaultSettings.ConnMaxLifetime())
 			sess.SetMaxIdleConns(db.DefaultSettings.MaxIdleConns())
@@ -130,12 +137,15 @@ func (d *database) open() error {
 		return err
 	}
 
+	d.listener = newListener(d.ConnectionURL().String())
+
 	return nil
 }
 
 // Clone creates a copy of the database session on the given context.
 func (d *database) clone(ctx context.Context, checkConn bool) (*database, error) {
 	clone := newDatabase(d.connURL)
+	clone.listener = d.listener
 
 	var err error
 	clone.BaseDatabase, err = d.NewClone(clone, checkConn)
@@ -150,6 +160,16 @@ func (d *database) clone(ctx context.Context, checkConn bool) (*database, error)
 	return clone, nil
 }
 
+// Primary returns a session that ignores the read replicas of d.
+func (d *database) Primary() Database {
+	clone, err := d.clone(d.Context(), false)
+	if err != nil {
+		return d
+	}
+	clone.ForcePrimary(true)
+	return clone
+}
+
 // CompileStatement compiles a *exql.Statement into arguments that sql/database
 // accepts.
 func (d *database) CompileStatement(stmt *exql.Statement, args []interface{}) (string, []interface{}) {
@@ -162,7 +182,8 @@ func (d *database) CompileStatement(stmt *exql.Statement, args []interface{}) (s
 }
 
 // Err allows sqladapter to translate specific PostgreSQL string errors into
-// custom error values.
+// custom error values, constraint violations and serialization failures are
+// wrapped into a *db.Error.
 func (d *database) Err(err error) error {
 	if err != nil {
 		s := err.Error()
@@ -170,10 +191,60 @@ func (d *database) Err(err error) error {
 		if strings.Contains(s, `too many clients`) || strings.Contains(s, `remaining connection slots are reserved`) || strings.Contains(s, `too many open`) {
 			return db.ErrTooManyClients
 		}
+		if e, ok := err.(*pq.Error); ok {
+			if kind := errKinds[e.Code]; kind != nil {
+				return &db.Error{
+					Kind:       kind,
+					Table:      e.Table,
+					Constraint: e.Constraint,
+					Columns:    errColumns(e),
+					Err:        err,
+				}
+			}
+		}
 	}
 	return err
 }
 
+// IsStaleStatement returns true if err means that a prepared statement must
+// be prepared again because the result type of its cached plan changed, like
+// after altering the columns of a table it selects from.
+func (d *database) IsStaleStatement(err error) bool {
+	if e, ok := err.(*pq.Error); ok {
+		return e.Code == "0A000" && strings.Contains(e.Message, "cached plan must not change result type")
+	}
+	return false
+}
+
+// errKinds maps SQLSTATE codes to the kinds of db.Error.
+var errKinds = map[pq.ErrorCode]error{
+	"23505": db.ErrUniqueViolation,      // unique_violation
+	"23503": db.ErrForeignKeyViolation,  // foreign_key_violation
+	"23514": db.ErrCheckViolation,       // check_violation
+	"40001": db.ErrSerializationFailure, // serialization_failure
+	"40P01": db.ErrSerializationFailure, // deadlock_detected
+}
+
+// errColumns returns the columns involved in a constraint violation, which
+// PostgreSQL reports on the detail message as "Key (a, b)=(...)".
+func errColumns(e *pq.Error) []string {
+	if e.Column != "" {
+		return []string{e.Column}
+	}
+	if !strings.HasPrefix(e.Detail, "Key (") {
+		return nil
+	}
+	end := strings.Index(e.Detail, ")=(")
+	if end < 0 {
+		return nil
+	}
+	columns := strings.Split(e.Detail[len("Key ("):end], ",")
+	for i := range columns {
+		columns[i] = strings.Trim(strings.TrimSpace(columns[i]), `"`)
+	}
+	return columns
+}
+
 // NewCollection creates a db.Collection by name.
 func (d *database) NewCollection(name string) db.Collection {
 	return newCollection(d, name)
@@ -231,9 +302,14 @@ func (d *database) LookupName() (string, error) {
 // TableExists returns an error if the given table name does not exist on the
 // database.
 func (d *database) TableExists(name string) error {
+	schema, table := sqladapter.SplitTableName(name)
+
 	q := d.Select("table_name").
 		From("information_schema.tables").
-		Where("table_catalog = ? AND table_name = ?", d.BaseDatabase.Name(), name)
+		Where("table_catalog = ? AND table_name = ?", d.BaseDatabase.Name(), table)
+	if schema != "" {
+		q = q.And("table_schema = ?", schema)
+	}
 
 	iter := q.Iterator()
 	defer iter.Close()
@@ -289,8 +365,100 @@ func (d *database) PrimaryKeys(tableName string) ([]string, error) {
 	return pk, nil
 }
 
+// Indexes returns the names of the indexes of the table.
+func (d *database) Indexes(tableName string) ([]string, error) {
+	schema, table := sqladapter.SplitTableName(tableName)
+
+	q := d.Select("indexname").
+		From("pg_indexes").
+		Where("tablename = ?", table)
+	if schema != "" {
+		q = q.And("schemaname = ?", schema)
+	}
+
+	iter := q.Iterator()
+	defer iter.Close()
+
+	indexes := []string{}
+
+	for iter.Next() {
+		var name string
+		if err := iter.Scan(&name); err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, name)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
+
+// ReferencedTables returns the names of the tables the foreign keys of the
+// table point to.
+func (d *database) ReferencedTables(tableName string) ([]string, error) {
+	q := d.Select().Distinct("pg_class.relname").
+		From("pg_constraint", "pg_class").
+		Where(`
+			pg_constraint.conrelid = '` + quotedTableName(tableName) + `'::regclass
+			AND pg_constraint.contype = 'f'
+			AND pg_class.oid = pg_constraint.confrelid
+		`)
+
+	iter := q.Iterator()
+	defer iter.Close()
+
+	tables := []string{}
+
+	for iter.Next() {
+		var name string
+		if err := iter.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
+// SupportsReturning returns true, InsertReturning and UpdateReturning read the
+// new values of items with RETURNING clauses.
+func (d *database) SupportsReturning() bool {
+	return true
+}
+
 // WithContext creates a copy of the session on the given context.
 func (d *database) WithContext(ctx context.Context) sqlbuilder.Database {
 	newDB, _ := d.clone(ctx, false)
 	return newDB
 }
+
+// WithSchema returns a session whose collections belong to the given schema.
+func (d *database) WithSchema(schema string) (sqlbuilder.Database, error) {
+	sess, err := d.SchemaSession(schema, func() (sqladapter.Database, error) {
+		clone, err := d.clone(d.Context(), false)
+		if err != nil {
+			return nil, err
+		}
+		return clone, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sess.(*database), nil
+}
+
+// WithRecordCache returns a copy of the session whose primary key lookups,
+// like col.Find(id).One(&item), read items from the given store.
+func (d *database) WithRecordCache(store db.QueryCache, ttl time.Duration) (sqlbuilder.Database, error) {
+	clone, err := d.clone(d.Context(), false)
+	if err != nil {
+		return nil, err
+	}
+	clone.SetRecordCache(store, ttl)
+	return clone, nil
+}
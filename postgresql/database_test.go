@@ -0,0 +1,56 @@
+package postgresql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+)
+
+func TestErr(t *testing.T) {
+	d := &database{}
+
+	{
+		err := d.Err(&pq.Error{
+			Code:       "23505",
+			Table:      "artist",
+			Constraint: "artist_name_key",
+			Detail:     `Key (name, "group")=(Ozzy, Sabbath) already exists.`,
+		})
+		if assert.IsType(t, &db.Error{}, err) {
+			dbErr := err.(*db.Error)
+			assert.Equal(t, db.ErrUniqueViolation, dbErr.Kind)
+			assert.Equal(t, "artist", dbErr.Table)
+			assert.Equal(t, "artist_name_key", dbErr.Constraint)
+			assert.Equal(t, []string{"name", "group"}, dbErr.Columns)
+		}
+	}
+
+	{
+		err := d.Err(&pq.Error{
+			Code:       "23503",
+			Table:      "publication",
+			Constraint: "publication_author_id_fkey",
+			Detail:     `Key (author_id)=(99) is not present in table "artist".`,
+		})
+		assert.Equal(t, db.ErrForeignKeyViolation, db.ErrorKind(err))
+		assert.Equal(t, []string{"author_id"}, err.(*db.Error).Columns)
+	}
+
+	{
+		err := d.Err(&pq.Error{Code: "23514", Table: "artist", Constraint: "artist_name_check"})
+		assert.Equal(t, db.ErrCheckViolation, db.ErrorKind(err))
+		assert.Nil(t, err.(*db.Error).Columns)
+	}
+
+	assert.Equal(t, db.ErrSerializationFailure, db.ErrorKind(d.Err(&pq.Error{Code: "40001"})))
+	assert.Equal(t, db.ErrSerializationFailure, db.ErrorKind(d.Err(&pq.Error{Code: "40P01"})))
+
+	assert.Equal(t, db.ErrTooManyClients, d.Err(errors.New("pq: sorry, too many clients already")))
+
+	syntaxErr := &pq.Error{Code: "42601"}
+	assert.Equal(t, syntaxErr, d.Err(syntaxErr))
+	assert.Nil(t, d.Err(nil))
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"upper.io/db.v3/internal/sqladapter"
+	"upper.io/db.v3/internal/sqladapter/compat"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// distributedTx is a transaction that is prepared with PREPARE TRANSACTION,
+// once prepared it's not bound to a connection anymore and it's finished with
+// COMMIT PREPARED or ROLLBACK PREPARED on the session it was created from.
+type distributedTx struct {
+	*tx
+
+	sess *database
+	gid  string
+
+	mu       sync.Mutex
+	prepared bool
+
+	hooks sqladapter.TxHooks
+}
+
+var (
+	_ = sqlbuilder.DistributedTx(&distributedTx{})
+	_ = sqlbuilder.DistributedTxSession(&database{})
+)
+
+// NewDistributedTx begins a transaction that takes part in a two-phase
+// commit, see sqlbuilder.DistributedTx. The server must allow prepared
+// transactions, see the max_prepared_transactions setting.
+func (d *database) NewDistributedTx(ctx context.Context, gid string) (sqlbuilder.DistributedTx, error) {
+	if !sqladapter.ValidGID(gid) {
+		return nil, sqlbuilder.ErrInvalidGID
+	}
+	t, err := d.NewTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &distributedTx{tx: t.(*tx), sess: d, gid: gid}, nil
+}
+
+// PreparedTransactions returns the identifiers of the transactions of the
+// current database that were prepared but not finished yet.
+func (d *database) PreparedTransactions() ([]string, error) {
+	rows, err := compat.QueryContext(d.Session(), d.Context(), `SELECT gid FROM pg_prepared_xacts WHERE database = CURRENT_DATABASE() ORDER BY prepared`, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	gids := []string{}
+	for rows.Next() {
+		var gid string
+		if err := rows.Scan(&gid); err != nil {
+			return nil, err
+		}
+		gids = append(gids, gid)
+	}
+	return gids, rows.Err()
+}
+
+// CommitPrepared commits the prepared transaction identified by gid.
+func (d *database) CommitPrepared(gid string) error {
+	return d.finishPrepared("COMMIT PREPARED", gid)
+}
+
+// RollbackPrepared rolls back the prepared transaction identified by gid.
+func (d *database) RollbackPrepared(gid string) error {
+	return d.finishPrepared("ROLLBACK PREPARED", gid)
+}
+
+func (d *database) finishPrepared(stmt string, gid string) error {
+	if !sqladapter.ValidGID(gid) {
+		return sqlbuilder.ErrInvalidGID
+	}
+	// Prepared transactions can't be finished within a transaction block, so
+	// the statement is sent as is instead of being prepared.
+	_, err := compat.ExecContext(d.Session(), d.Context(), stmt+" '"+gid+"'", nil)
+	return d.Err(err)
+}
+
+func (t *distributedTx) GID() string {
+	return t.gid
+}
+
+// OnCommit registers a function that is called once the transaction is
+// committed, with or without being prepared.
+func (t *distributedTx) OnCommit(fn func()) {
+	t.hooks.OnCommit(fn)
+}
+
+// OnRollback registers a function that is called once the transaction is
+// rolled back.
+func (t *distributedTx) OnRollback(fn func(err error)) {
+	t.hooks.OnRollback(fn)
+}
+
+// finish calls the functions registered with OnCommit or OnRollback once the
+// outcome of the transaction is known, a prepared transaction that failed to
+// be finished is still prepared.
+func (t *distributedTx) finish(committing bool, err error) {
+	switch {
+	case committing && err == nil:
+		t.hooks.RunOnCommit()
+	case committing && !t.prepared:
+		t.hooks.RunOnRollback(err)
+	case !committing && (err == nil || !t.prepared):
+		t.hooks.RunOnRollback(nil)
+	}
+}
+
+func (t *distributedTx) PrepareCommit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.prepared {
+		return sql.ErrTxDone
+	}
+
+	sqlTx := t.Driver().(*sql.Tx)
+	if _, err := compat.ExecContext(sqlTx, t.Context(), "PREPARE TRANSACTION '"+t.gid+"'", nil); err != nil {
+		return t.sess.Err(err)
+	}
+	t.prepared = true
+
+	// The connection is not within a transaction block anymore, committing
+	// only releases it.
+	return t.tx.Commit()
+}
+
+func (t *distributedTx) Commit() (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	defer func() {
+		t.finish(true, err)
+	}()
+
+	if !t.prepared {
+		return t.tx.Commit()
+	}
+	return t.sess.CommitPrepared(t.gid)
+}
+
+func (t *distributedTx) Rollback() (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	defer func() {
+		t.finish(false, err)
+	}()
+
+	if !t.prepared {
+		return t.tx.Rollback()
+	}
+	return t.sess.RollbackPrepared(t.gid)
+}
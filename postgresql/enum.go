@@ -0,0 +1,92 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package postgresql
+
+import (
+	"strings"
+
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// The helpers below manage enum types. Columns of an enum type can be used
+// instead of generic sqlbuilder.Enum columns, which check their values with a
+// constraint, so values can be added later without altering tables:
+//
+//	err := postgresql.CreateEnum(sess, "order_status", OrderStatus("").EnumValues()...)
+//	...
+//	_, err = sess.CreateTable("orders").
+//		FromStruct(Order{}).
+//		Columns(sqlbuilder.Column{Name: "status", Type: "order_status", NotNull: true}).
+//		Exec()
+//
+// Type names are quoted, like the names of tables, and sess can be a session
+// or a transaction, though values added to an enum can't be used within the
+// transaction that added them.
+
+// CreateEnum creates an enum type with the given values.
+func CreateEnum(sess sqlbuilder.SQLBuilder, name string, values ...string) error {
+	_, err := sess.Exec("CREATE TYPE " + quoteIdentifier(name) + " AS ENUM (" + enumLiterals(values) + ")")
+	return err
+}
+
+// AddEnumValue adds a value to the end of an enum type, values that the type
+// already has are skipped.
+func AddEnumValue(sess sqlbuilder.SQLBuilder, name string, value string) error {
+	_, err := sess.Exec("ALTER TYPE " + quoteIdentifier(name) + " ADD VALUE IF NOT EXISTS " + enumLiterals([]string{value}))
+	return err
+}
+
+// RenameEnumValue renames a value of an enum type, which requires PostgreSQL
+// 10 or later.
+func RenameEnumValue(sess sqlbuilder.SQLBuilder, name string, value string, newValue string) error {
+	_, err := sess.Exec("ALTER TYPE " + quoteIdentifier(name) + " RENAME VALUE " + enumLiterals([]string{value}) + " TO " + enumLiterals([]string{newValue}))
+	return err
+}
+
+// DropEnum drops an enum type if it exists.
+func DropEnum(sess sqlbuilder.SQLBuilder, name string) error {
+	_, err := sess.Exec("DROP TYPE IF EXISTS " + quoteIdentifier(name))
+	return err
+}
+
+// EnumValues returns the values of an enum type in their order.
+func EnumValues(sess sqlbuilder.SQLBuilder, name string) ([]string, error) {
+	var values []string
+	err := sess.Select("enumlabel").
+		From("pg_enum").
+		Where("enumtypid = ?::regtype", quoteIdentifier(name)).
+		OrderBy("enumsortorder").
+		All(&values)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// enumLiterals quotes values as string literals, DDL statements can't take
+// placeholders.
+func enumLiterals(values []string) string {
+	literals := make([]string, len(values))
+	for i := range values {
+		literals[i] = "'" + strings.Replace(values[i], "'", "''", -1) + "'"
+	}
+	return strings.Join(literals, ", ")
+}
@@ -0,0 +1,27 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+func TestEnumColumns(t *testing.T) {
+	assert.Equal(t, `'active', 'it''s'`, enumLiterals([]string{"active", "it's"}))
+
+	b := sqlbuilder.WithTemplate(template)
+
+	type order struct {
+		Status string `db:"status,enum=pending|paid"`
+	}
+
+	assert.Equal(t,
+		`CREATE TABLE "orders" ("status" VARCHAR(7) CHECK ("status" IN ('pending', 'paid')) NOT NULL)`,
+		b.CreateTable("orders").FromStruct(order{}).String(),
+	)
+	assert.Equal(t,
+		`CREATE TABLE "orders" ("status" order_status NOT NULL)`,
+		b.CreateTable("orders").FromStruct(order{}).Columns(sqlbuilder.Column{Name: "status", Type: "order_status", NotNull: true}).String(),
+	)
+}
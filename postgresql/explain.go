@@ -0,0 +1,102 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package postgresql
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// Explain wraps a query into an EXPLAIN statement that returns its plan in
+// JSON format.
+func (d *database) Explain(query string, analyze bool) (string, error) {
+	if analyze {
+		return "EXPLAIN (ANALYZE, FORMAT JSON) " + query, nil
+	}
+	return "EXPLAIN (FORMAT JSON) " + query, nil
+}
+
+// ScanQueryPlan reads the JSON plan returned by an EXPLAIN statement.
+func (d *database) ScanQueryPlan(rows *sql.Rows) (*sqlbuilder.QueryPlan, error) {
+	var raw []byte
+	for rows.Next() {
+		var chunk []byte
+		if err := rows.Scan(&chunk); err != nil {
+			return nil, err
+		}
+		raw = append(raw, chunk...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return parseQueryPlan(raw)
+}
+
+// parseQueryPlan parses a plan in the format returned by EXPLAIN (FORMAT
+// JSON), properties of the whole plan, like "Execution Time", are added to
+// the attributes of its top level node.
+func parseQueryPlan(raw []byte) (*sqlbuilder.QueryPlan, error) {
+	var plans []map[string]interface{}
+	if err := json.Unmarshal(raw, &plans); err != nil {
+		return nil, err
+	}
+
+	plan := &sqlbuilder.QueryPlan{Raw: string(raw)}
+	for _, p := range plans {
+		root, ok := p["Plan"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		node := planNode(root)
+		for k, v := range p {
+			if k != "Plan" {
+				node.Attributes[k] = v
+			}
+		}
+		plan.Nodes = append(plan.Nodes, node)
+	}
+	return plan, nil
+}
+
+func planNode(m map[string]interface{}) *sqlbuilder.PlanNode {
+	node := &sqlbuilder.PlanNode{Attributes: map[string]interface{}{}}
+	for k, v := range m {
+		switch k {
+		case "Node Type":
+			node.Operation, _ = v.(string)
+		case "Relation Name":
+			node.Table, _ = v.(string)
+		case "Plans":
+			children, _ := v.([]interface{})
+			for i := range children {
+				if child, ok := children[i].(map[string]interface{}); ok {
+					node.Children = append(node.Children, planNode(child))
+				}
+			}
+		default:
+			node.Attributes[k] = v
+		}
+	}
+	return node
+}
@@ -0,0 +1,48 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryPlan(t *testing.T) {
+	raw := `[
+  {
+    "Plan": {
+      "Node Type": "Hash Join",
+      "Join Type": "Inner",
+      "Total Cost": 58.69,
+      "Plans": [
+        {"Node Type": "Seq Scan", "Relation Name": "publication", "Alias": "p"},
+        {"Node Type": "Hash", "Plans": [{"Node Type": "Seq Scan", "Relation Name": "artist", "Alias": "a"}]}
+      ]
+    },
+    "Execution Time": 0.042
+  }
+]`
+
+	plan, err := parseQueryPlan([]byte(raw))
+	assert.NoError(t, err)
+	assert.Equal(t, raw, plan.Raw)
+
+	if assert.Equal(t, 1, len(plan.Nodes)) {
+		root := plan.Nodes[0]
+		assert.Equal(t, "Hash Join", root.Operation)
+		assert.Equal(t, "Inner", root.Attributes["Join Type"])
+		assert.Equal(t, 58.69, root.Attributes["Total Cost"])
+		assert.Equal(t, 0.042, root.Attributes["Execution Time"])
+		assert.Nil(t, root.Attributes["Plans"])
+
+		if assert.Equal(t, 2, len(root.Children)) {
+			assert.Equal(t, "Seq Scan", root.Children[0].Operation)
+			assert.Equal(t, "publication", root.Children[0].Table)
+			assert.Equal(t, "artist", root.Children[1].Children[0].Table)
+		}
+	}
+
+	assert.Equal(t, "Hash Join\n  Seq Scan on publication\n  Hash\n    Seq Scan on artist\n", plan.String())
+
+	_, err = parseQueryPlan([]byte(`not json`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package postgresql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Point represents a PostgreSQL's point column. Use *Point for nullable
+// columns.
+//
+// Geometric operators can be used with string keys, values need a cast when
+// the operator is defined for many types:
+//
+//	db.Cond{"area @>": db.Raw("?::point", postgresql.Point{X: 1, Y: 2})}
+//	db.Cond{"location <-> point(0, 0) <": 10}
+type Point struct {
+	X, Y float64
+}
+
+// String returns the point in the format PostgreSQL uses, like "(1,2)".
+func (p Point) String() string {
+	return string(appendPoint(nil, p))
+}
+
+// Scan implements the sql.Scanner interface.
+func (p *Point) Scan(src interface{}) error {
+	b, err := geometricBytes(src, "Point")
+	if err != nil || b == nil {
+		*p = Point{}
+		return err
+	}
+	points, err := parsePoints(b)
+	if err != nil {
+		return err
+	}
+	if len(points) != 1 {
+		return fmt.Errorf("Invalid point %q", b)
+	}
+	*p = points[0]
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (p Point) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+// Box represents a PostgreSQL's box column, a rectangle given by two of its
+// opposite corners. Use *Box for nullable columns.
+type Box struct {
+	Min, Max Point
+}
+
+// String returns the box in the format PostgreSQL uses, which starts with the
+// upper right corner, like "(3,4),(1,2)".
+func (b Box) String() string {
+	buf := appendPoint(nil, b.Max)
+	buf = append(buf, ',')
+	return string(appendPoint(buf, b.Min))
+}
+
+// Scan implements the sql.Scanner interface.
+func (b *Box) Scan(src interface{}) error {
+	buf, err := geometricBytes(src, "Box")
+	if err != nil || buf == nil {
+		*b = Box{}
+		return err
+	}
+	points, err := parsePoints(buf)
+	if err != nil {
+		return err
+	}
+	if len(points) != 2 {
+		return fmt.Errorf("Invalid box %q", buf)
+	}
+	*b = Box{
+		Min: Point{minFloat(points[0].X, points[1].X), minFloat(points[0].Y, points[1].Y)},
+		Max: Point{maxFloat(points[0].X, points[1].X), maxFloat(points[0].Y, points[1].Y)},
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (b Box) Value() (driver.Value, error) {
+	return b.String(), nil
+}
+
+// Polygon represents a PostgreSQL's polygon column, given by its vertices. A
+// nil Polygon is stored as NULL.
+type Polygon []Point
+
+// String returns the polygon in the format PostgreSQL uses, like
+// "((0,0),(1,1),(1,0))".
+func (p Polygon) String() string {
+	buf := []byte{'('}
+	for i := range p {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendPoint(buf, p[i])
+	}
+	return string(append(buf, ')'))
+}
+
+// Scan implements the sql.Scanner interface.
+func (p *Polygon) Scan(src interface{}) error {
+	b, err := geometricBytes(src, "Polygon")
+	if err != nil || b == nil {
+		*p = nil
+		return err
+	}
+	points, err := parsePoints(b)
+	if err != nil {
+		return err
+	}
+	*p = Polygon(points)
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (p Polygon) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return p.String(), nil
+}
+
+func geometricBytes(src interface{}, name string) ([]byte, error) {
+	switch src := src.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return src, nil
+	case string:
+		return []byte(src), nil
+	}
+	return nil, fmt.Errorf("Cannot scan %T into %s", src, name)
+}
+
+// parsePoints reads the points of the text representation of a geometric
+// type, points are pairs of numbers and any parentheses around them are
+// ignored, like in "((0,0),(1,1))" or "(0,0),(1,1)".
+func parsePoints(src []byte) ([]Point, error) {
+	fields := strings.FieldsFunc(string(src), func(r rune) bool {
+		return r == '(' || r == ')' || r == '[' || r == ']' || r == ',' || r == ' '
+	})
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("Invalid geometric value %q", src)
+	}
+
+	points := make([]Point, len(fields)/2)
+	for i := range points {
+		x, err := strconv.ParseFloat(fields[2*i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid geometric value %q", src)
+		}
+		y, err := strconv.ParseFloat(fields[2*i+1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid geometric value %q", src)
+		}
+		points[i] = Point{x, y}
+	}
+	return points, nil
+}
+
+func appendPoint(b []byte, p Point) []byte {
+	b = append(b, '(')
+	b = strconv.AppendFloat(b, p.X, 'g', -1, 64)
+	b = append(b, ',')
+	b = strconv.AppendFloat(b, p.Y, 'g', -1, 64)
+	return append(b, ')')
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,62 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoint(t *testing.T) {
+	var p Point
+	assert.NoError(t, p.Scan([]byte("(1.5,-2)")))
+	assert.Equal(t, Point{1.5, -2}, p)
+	assert.Equal(t, "(1.5,-2)", p.String())
+
+	v, err := p.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "(1.5,-2)", v)
+
+	assert.NoError(t, p.Scan("(1e+20,0.1)"))
+	assert.Equal(t, Point{1e20, 0.1}, p)
+
+	assert.NoError(t, p.Scan(nil))
+	assert.Equal(t, Point{}, p)
+
+	assert.Error(t, p.Scan("(1,2),(3,4)"))
+	assert.Error(t, p.Scan("(1,x)"))
+	assert.Error(t, p.Scan(1))
+}
+
+func TestBox(t *testing.T) {
+	var b Box
+	assert.NoError(t, b.Scan([]byte("(3,4),(1,2)")))
+	assert.Equal(t, Box{Min: Point{1, 2}, Max: Point{3, 4}}, b)
+
+	assert.NoError(t, b.Scan("(1,4),(3,2)"))
+	assert.Equal(t, Box{Min: Point{1, 2}, Max: Point{3, 4}}, b)
+
+	v, err := b.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "(3,4),(1,2)", v)
+
+	assert.Error(t, b.Scan("(1,2)"))
+}
+
+func TestPolygon(t *testing.T) {
+	var p Polygon
+	assert.NoError(t, p.Scan([]byte("((0,0),(1,1),(1,0))")))
+	assert.Equal(t, Polygon{{0, 0}, {1, 1}, {1, 0}}, p)
+
+	v, err := p.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "((0,0),(1,1),(1,0))", v)
+
+	assert.NoError(t, p.Scan(nil))
+	assert.Nil(t, p)
+
+	v, err = p.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	assert.Error(t, p.Scan("((0,0),(1))"))
+}
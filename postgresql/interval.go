@@ -0,0 +1,99 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package postgresql
+
+import (
+	"strings"
+	"time"
+
+	"upper.io/db.v3"
+)
+
+// The helpers below do interval arithmetic with timestamp columns, their
+// interval can be a time.Duration, a db.Interval or a string PostgreSQL can
+// cast into an interval, like "1 day".
+
+// Ago returns an expression with the current time minus interval.
+//
+// Example:
+//
+//	// "created_at" > NOW() - $1::interval
+//	db.Cond{"created_at >": postgresql.Ago(24 * time.Hour)}
+func Ago(interval interface{}) db.RawValue {
+	return db.Raw("NOW() - ?::interval", intervalValue(interval))
+}
+
+// FromNow returns an expression with the current time plus interval.
+func FromNow(interval interface{}) db.RawValue {
+	return db.Raw("NOW() + ?::interval", intervalValue(interval))
+}
+
+// AddInterval returns an expression with the value of the given column plus
+// interval.
+//
+// Example:
+//
+//	// "expires_at" < "created_at" + $1::interval
+//	db.Cond{"expires_at <": postgresql.AddInterval("created_at", db.Interval{Months: 1})}
+func AddInterval(column string, interval interface{}) db.RawValue {
+	return db.Raw(quoteIdentifier(column)+" + ?::interval", intervalValue(interval))
+}
+
+// SubInterval returns an expression with the value of the given column minus
+// interval.
+func SubInterval(column string, interval interface{}) db.RawValue {
+	return db.Raw(quoteIdentifier(column)+" - ?::interval", intervalValue(interval))
+}
+
+// WithinLast creates a condition that matches rows where the time of the
+// given column is not older than interval.
+//
+// Example:
+//
+//	// "last_seen_at" >= NOW() - $1::interval
+//	postgresql.WithinLast("last_seen_at", 5*time.Minute)
+func WithinLast(column string, interval interface{}) db.Cond {
+	return db.Cond{db.Comparison{Column: column, Operator: ">="}: Ago(interval)}
+}
+
+// OlderThan creates a condition that matches rows where the time of the given
+// column is older than interval.
+func OlderThan(column string, interval interface{}) db.Cond {
+	return db.Cond{db.Comparison{Column: column, Operator: "<"}: Ago(interval)}
+}
+
+// intervalValue converts durations into intervals, which the driver sends in
+// a format PostgreSQL understands.
+func intervalValue(v interface{}) interface{} {
+	if d, ok := v.(time.Duration); ok {
+		return db.Interval{Duration: d}
+	}
+	return v
+}
+
+// quoteIdentifier quotes a column name, like "sessions.created_at".
+func quoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i := range parts {
+		parts[i] = `"` + strings.Replace(parts[i], `"`, `""`, -1) + `"`
+	}
+	return strings.Join(parts, ".")
+}
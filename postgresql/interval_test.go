@@ -0,0 +1,33 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+func TestIntervalConditions(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+
+	q := b.SelectFrom("sessions").Where(
+		WithinLast("last_seen_at", 5*time.Minute),
+		OlderThan("created_at", "30 days"),
+		db.Cond{"expires_at <": AddInterval("sessions.created_at", db.Interval{Months: 1})},
+		db.Cond{"starts_at >": SubInterval("ends_at", time.Hour)},
+		db.Cond{"renews_at <": FromNow(db.Interval{Days: 7})},
+	)
+	assert.Equal(t,
+		`SELECT * FROM "sessions" WHERE ("last_seen_at" >= NOW() - $1::interval AND "created_at" < NOW() - $2::interval AND "expires_at" < "sessions"."created_at" + $3::interval AND "starts_at" > "ends_at" - $4::interval AND "renews_at" < NOW() + $5::interval)`,
+		q.String(),
+	)
+	assert.Equal(t, []interface{}{
+		db.Interval{Duration: 5 * time.Minute},
+		"30 days",
+		db.Interval{Months: 1},
+		db.Interval{Duration: time.Hour},
+		db.Interval{Days: 7},
+	}, q.Arguments())
+}
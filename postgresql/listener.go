@@ -0,0 +1,237 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"upper.io/db.v3"
+)
+
+const (
+	listenerMinReconnectInterval = 10 * time.Second
+	listenerMaxReconnectInterval = time.Minute
+)
+
+var errListenerClosed = errors.New("upper: listener is closed")
+
+// Notification represents a message that was sent to a channel by NOTIFY.
+type Notification struct {
+	// Channel is the name of the channel the notification was sent to.
+	Channel string
+	// Payload is the optional payload that was sent along the notification.
+	Payload string
+	// PID is the process ID of the server backend that sent the notification.
+	PID int
+}
+
+type subscription struct {
+	ctx     context.Context
+	channel string
+	ch      chan Notification
+}
+
+// listener maintains a dedicated connection for LISTEN and fans out
+// notifications to subscribers. The underlying pq.Listener reconnects
+// automatically and subscribes again to all active channels.
+type listener struct {
+	dsn string
+
+	mu     sync.Mutex
+	pql    *pq.Listener
+	subs   map[string][]*subscription
+	closed bool
+
+	unsubscribe chan *subscription
+	done        chan struct{}
+}
+
+func newListener(dsn string) *listener {
+	return &listener{
+		dsn:         dsn,
+		subs:        make(map[string][]*subscription),
+		unsubscribe: make(chan *subscription),
+		done:        make(chan struct{}),
+	}
+}
+
+// Listen subscribes to the given channel, notifications are delivered on the
+// returned Go channel until ctx is done.
+func (l *listener) Listen(ctx context.Context, channel string) (<-chan Notification, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil, errListenerClosed
+	}
+
+	if l.pql == nil {
+		l.pql = pq.NewListener(l.dsn, listenerMinReconnectInterval, listenerMaxReconnectInterval, nil)
+		go l.dispatch(l.pql.NotificationChannel())
+	}
+
+	if len(l.subs[channel]) == 0 {
+		if err := l.pql.Listen(channel); err != nil && err != pq.ErrChannelAlreadyOpen {
+			return nil, err
+		}
+	}
+
+	s := &subscription{
+		ctx:     ctx,
+		channel: channel,
+		ch:      make(chan Notification, 16),
+	}
+	l.subs[channel] = append(l.subs[channel], s)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			select {
+			case l.unsubscribe <- s:
+			case <-l.done:
+			}
+		case <-l.done:
+		}
+	}()
+
+	return s.ch, nil
+}
+
+// dispatch delivers notifications to subscribers, it is the only goroutine
+// that sends on or closes subscriber channels.
+func (l *listener) dispatch(in <-chan *pq.Notification) {
+	defer func() {
+		l.mu.Lock()
+		for channel := range l.subs {
+			for _, s := range l.subs[channel] {
+				close(s.ch)
+			}
+		}
+		l.subs = nil
+		l.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case n, ok := <-in:
+			if !ok {
+				return
+			}
+			if n == nil {
+				// The connection was lost and reestablished, notifications sent
+				// in the meantime were lost.
+				continue
+			}
+
+			l.mu.Lock()
+			subs := append([]*subscription(nil), l.subs[n.Channel]...)
+			l.mu.Unlock()
+
+			notification := Notification{
+				Channel: n.Channel,
+				Payload: n.Extra,
+				PID:     n.BePid,
+			}
+			for _, s := range subs {
+				select {
+				case s.ch <- notification:
+				case <-s.ctx.Done():
+				}
+			}
+		case s := <-l.unsubscribe:
+			l.remove(s)
+		}
+	}
+}
+
+func (l *listener) remove(s *subscription) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	subs := l.subs[s.channel]
+	for i := range subs {
+		if subs[i] == s {
+			l.subs[s.channel] = append(subs[:i], subs[i+1:]...)
+			close(s.ch)
+			break
+		}
+	}
+
+	if len(l.subs[s.channel]) == 0 {
+		delete(l.subs, s.channel)
+		_ = l.pql.Unlisten(s.channel)
+	}
+}
+
+// Close closes the dedicated connection, all subscriber channels are closed
+// as well.
+func (l *listener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	close(l.done)
+
+	if l.pql == nil {
+		return nil
+	}
+	return l.pql.Close()
+}
+
+// Listen subscribes the session to the given channel using a dedicated
+// connection and returns a Go channel that receives notifications until ctx
+// is done or the session is closed. The dedicated connection is reestablished
+// automatically if lost, note that notifications sent while disconnected are
+// not recovered.
+func (d *database) Listen(ctx context.Context, channel string) (<-chan Notification, error) {
+	if d.listener == nil {
+		return nil, db.ErrMissingConnURL
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return d.listener.Listen(ctx, channel)
+}
+
+// Notify sends a notification with the given payload to all the listeners of
+// the given channel.
+func (d *database) Notify(channel string, payload string) error {
+	_, err := d.Exec(`SELECT pg_notify(?, ?)`, channel, payload)
+	return err
+}
+
+// CleanUp closes the connection that is used for LISTEN, if any. Sessions
+// that are bound to a transaction share the listener with their parent
+// session and leave it open.
+func (d *database) CleanUp() error {
+	if d.listener == nil || d.Transaction() != nil {
+		return nil
+	}
+	return d.listener.Close()
+}
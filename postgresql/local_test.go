@@ -113,6 +113,35 @@ func TestIssue210(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestPreparedStatementsStalePlan(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	sess.SetPreparedStatementCache(true)
+
+	_, err := sess.Exec(`DROP TABLE IF EXISTS stale_plan`)
+	assert.NoError(t, err)
+
+	_, err = sess.Exec(`CREATE TABLE stale_plan (id serial primary key, name text)`)
+	assert.NoError(t, err)
+
+	_, err = sess.Collection("stale_plan").Insert(map[string]string{"name": "Hamlet"})
+	assert.NoError(t, err)
+
+	var rows []map[string]interface{}
+	err = sess.Collection("stale_plan").Find().All(&rows)
+	assert.NoError(t, err)
+	assert.Len(t, rows[0], 2)
+
+	// The cached plan of "SELECT * FROM stale_plan" is no longer valid.
+	_, err = sess.Exec(`ALTER TABLE stale_plan ADD COLUMN year integer`)
+	assert.NoError(t, err)
+
+	err = sess.Collection("stale_plan").Find().All(&rows)
+	assert.NoError(t, err)
+	assert.Len(t, rows[0], 3)
+}
+
 func TestPreparedStatements(t *testing.T) {
 	sess := mustOpen()
 	defer sess.Close()
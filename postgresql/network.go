@@ -0,0 +1,263 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package postgresql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"upper.io/db.v3"
+)
+
+// Inet represents a PostgreSQL's inet column, which holds a host address and,
+// optionally, the netmask of its network, like 192.168.0.5/24. The zero value
+// is stored as NULL.
+//
+// Addresses can be compared with the network operators, see NetworkContains
+// and NetworkContainedBy, or with string keys:
+//
+//	db.Cond{"addr <<": "10.0.0.0/8"}
+type Inet net.IPNet
+
+// ParseInet parses an address with an optional netmask, like "10.0.0.1",
+// "192.168.0.5/24" or "2001:db8::1/64".
+func ParseInet(s string) (Inet, error) {
+	if strings.Contains(s, "/") {
+		ip, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return Inet{}, fmt.Errorf("Invalid inet %q", s)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		return Inet{IP: ip, Mask: ipNet.Mask}, nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return Inet{}, fmt.Errorf("Invalid inet %q", s)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return Inet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return Inet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// String returns the address in the format PostgreSQL uses, the netmask is
+// omitted when it covers the whole address.
+func (i Inet) String() string {
+	if i.IP == nil {
+		return ""
+	}
+	ones, bits := i.Mask.Size()
+	if i.Mask == nil || ones == bits {
+		return i.IP.String()
+	}
+	return i.IP.String() + "/" + strconv.Itoa(ones)
+}
+
+// Network returns the network of the address.
+func (i Inet) Network() *net.IPNet {
+	return &net.IPNet{IP: i.IP.Mask(i.Mask), Mask: i.Mask}
+}
+
+// Scan implements the sql.Scanner interface.
+func (i *Inet) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*i = Inet{}
+		return nil
+	case []byte:
+		return i.Scan(string(src))
+	case string:
+		v, err := ParseInet(src)
+		if err != nil {
+			return err
+		}
+		*i = v
+		return nil
+	}
+	return fmt.Errorf("Cannot scan %T into Inet", src)
+}
+
+// Value implements the driver.Valuer interface.
+func (i Inet) Value() (driver.Value, error) {
+	if i.IP == nil {
+		return nil, nil
+	}
+	return i.String(), nil
+}
+
+// CIDR represents a PostgreSQL's cidr column, which holds a network, like
+// 10.0.0.0/8. The zero value is stored as NULL.
+type CIDR net.IPNet
+
+// ParseCIDR parses a network, like "10.0.0.0/8" or "2001:db8::/32". Bits of
+// the address that are not part of the network are cleared.
+func ParseCIDR(s string) (CIDR, error) {
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		return CIDR{}, fmt.Errorf("Invalid cidr %q", s)
+	}
+	return CIDR(*ipNet), nil
+}
+
+// String returns the network in CIDR notation.
+func (c CIDR) String() string {
+	if c.IP == nil {
+		return ""
+	}
+	return (*net.IPNet)(&c).String()
+}
+
+// Contains reports whether the network includes the given address.
+func (c CIDR) Contains(ip net.IP) bool {
+	return (*net.IPNet)(&c).Contains(ip)
+}
+
+// Scan implements the sql.Scanner interface.
+func (c *CIDR) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*c = CIDR{}
+		return nil
+	case []byte:
+		return c.Scan(string(src))
+	case string:
+		v, err := ParseCIDR(src)
+		if err != nil {
+			return err
+		}
+		*c = v
+		return nil
+	}
+	return fmt.Errorf("Cannot scan %T into CIDR", src)
+}
+
+// Value implements the driver.Valuer interface.
+func (c CIDR) Value() (driver.Value, error) {
+	if c.IP == nil {
+		return nil, nil
+	}
+	return c.String(), nil
+}
+
+// MACAddr represents a PostgreSQL's macaddr or macaddr8 column. The zero
+// value is stored as NULL.
+type MACAddr net.HardwareAddr
+
+// String returns the address in the format PostgreSQL uses, like
+// "08:00:2b:01:02:03".
+func (m MACAddr) String() string {
+	return net.HardwareAddr(m).String()
+}
+
+// Scan implements the sql.Scanner interface.
+func (m *MACAddr) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return m.Scan(string(src))
+	case string:
+		v, err := net.ParseMAC(src)
+		if err != nil {
+			return fmt.Errorf("Invalid macaddr %q", src)
+		}
+		*m = MACAddr(v)
+		return nil
+	}
+	return fmt.Errorf("Cannot scan %T into MACAddr", src)
+}
+
+// Value implements the driver.Valuer interface.
+func (m MACAddr) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return m.String(), nil
+}
+
+// NetworkContains creates a condition that matches rows where the network of
+// the given column strictly contains value, which can be an address or a
+// network.
+//
+// Example:
+//
+//	// "subnet" >> '10.1.2.3'
+//	postgresql.NetworkContains("subnet", "10.1.2.3")
+func NetworkContains(column string, value interface{}) db.Cond {
+	return db.Cond{db.Comparison{Column: column, Operator: ">>"}: networkValue(value)}
+}
+
+// NetworkContainsOrEquals is like NetworkContains, but it also matches rows
+// where the column equals value.
+func NetworkContainsOrEquals(column string, value interface{}) db.Cond {
+	return db.Cond{db.Comparison{Column: column, Operator: ">>="}: networkValue(value)}
+}
+
+// NetworkContainedBy creates a condition that matches rows where the address
+// or network of the given column is strictly contained by the given network.
+//
+// Example:
+//
+//	// "addr" << '10.0.0.0/8'
+//	postgresql.NetworkContainedBy("addr", "10.0.0.0/8")
+func NetworkContainedBy(column string, network interface{}) db.Cond {
+	return db.Cond{db.Comparison{Column: column, Operator: "<<"}: networkValue(network)}
+}
+
+// NetworkContainedByOrEquals is like NetworkContainedBy, but it also matches
+// rows where the column equals network.
+func NetworkContainedByOrEquals(column string, network interface{}) db.Cond {
+	return db.Cond{db.Comparison{Column: column, Operator: "<<="}: networkValue(network)}
+}
+
+// NetworkOverlaps creates a condition that matches rows where the network of
+// the given column contains or is contained by the given network.
+func NetworkOverlaps(column string, network interface{}) db.Cond {
+	return db.Cond{db.Comparison{Column: column, Operator: "&&"}: networkValue(network)}
+}
+
+// networkValue converts the types of the net package into values the driver
+// can send.
+func networkValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case net.IP:
+		ip, err := ParseInet(v.String())
+		if err != nil {
+			return v
+		}
+		return ip
+	case *net.IPNet:
+		return CIDR{IP: v.IP.Mask(v.Mask), Mask: v.Mask}
+	case net.IPNet:
+		return CIDR{IP: v.IP.Mask(v.Mask), Mask: v.Mask}
+	case net.HardwareAddr:
+		return MACAddr(v)
+	}
+	return v
+}
@@ -0,0 +1,137 @@
+package postgresql
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+func TestInet(t *testing.T) {
+	for _, s := range []string{"10.0.0.1", "192.168.0.5/24", "2001:db8::1", "2001:db8::1/64"} {
+		v, err := ParseInet(s)
+		assert.NoError(t, err)
+		assert.Equal(t, s, v.String())
+	}
+
+	for _, s := range []string{"", "10.0.0", "10.0.0.1/33", "host"} {
+		_, err := ParseInet(s)
+		assert.Error(t, err)
+	}
+
+	{
+		v, err := ParseInet("192.168.0.5/24")
+		assert.NoError(t, err)
+		assert.Equal(t, net.IP{192, 168, 0, 5}, v.IP)
+		assert.Equal(t, "192.168.0.0/24", v.Network().String())
+	}
+
+	{
+		var v Inet
+		assert.NoError(t, v.Scan([]byte("10.1.2.3/8")))
+		assert.Equal(t, "10.1.2.3/8", v.String())
+
+		value, err := v.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, "10.1.2.3/8", value)
+
+		assert.NoError(t, v.Scan(nil))
+		assert.Nil(t, v.IP)
+
+		value, err = v.Value()
+		assert.NoError(t, err)
+		assert.Nil(t, value)
+
+		assert.Error(t, v.Scan(1))
+		assert.Error(t, v.Scan("10.1.2"))
+	}
+}
+
+func TestCIDR(t *testing.T) {
+	for _, s := range []string{"10.0.0.0/8", "192.168.0.0/24", "10.0.0.1/32", "2001:db8::/32"} {
+		v, err := ParseCIDR(s)
+		assert.NoError(t, err)
+		assert.Equal(t, s, v.String())
+	}
+
+	{
+		v, err := ParseCIDR("192.168.0.5/24")
+		assert.NoError(t, err)
+		assert.Equal(t, "192.168.0.0/24", v.String())
+		assert.True(t, v.Contains(net.ParseIP("192.168.0.200")))
+		assert.False(t, v.Contains(net.ParseIP("192.168.1.1")))
+	}
+
+	for _, s := range []string{"", "10.0.0.1", "10.0.0.0/40"} {
+		_, err := ParseCIDR(s)
+		assert.Error(t, err)
+	}
+
+	{
+		var v CIDR
+		assert.NoError(t, v.Scan([]byte("10.0.0.0/8")))
+
+		value, err := v.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, "10.0.0.0/8", value)
+
+		assert.NoError(t, v.Scan(nil))
+		value, err = v.Value()
+		assert.NoError(t, err)
+		assert.Nil(t, value)
+
+		assert.Error(t, v.Scan(1))
+	}
+}
+
+func TestMACAddr(t *testing.T) {
+	var v MACAddr
+	assert.NoError(t, v.Scan([]byte("08:00:2b:01:02:03")))
+	assert.Equal(t, MACAddr{0x08, 0x00, 0x2b, 0x01, 0x02, 0x03}, v)
+
+	assert.NoError(t, v.Scan("08-00-2B-01-02-03"))
+	assert.Equal(t, "08:00:2b:01:02:03", v.String())
+
+	assert.NoError(t, v.Scan("08:00:2b:01:02:03:04:05"))
+	assert.Equal(t, "08:00:2b:01:02:03:04:05", v.String())
+
+	value, err := v.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "08:00:2b:01:02:03:04:05", value)
+
+	assert.NoError(t, v.Scan(nil))
+	value, err = v.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	assert.Error(t, v.Scan("08:00:2b"))
+	assert.Error(t, v.Scan(1))
+}
+
+func TestNetworkConditions(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+
+	_, ipNet, _ := net.ParseCIDR("10.0.0.0/8")
+
+	q := b.SelectFrom("hosts").Where(
+		NetworkContainedBy("addr", ipNet),
+		NetworkContainedByOrEquals("addr", "10.0.0.0/8"),
+		NetworkContains("subnet", net.ParseIP("10.1.2.3")),
+		NetworkContainsOrEquals("subnet", "10.1.2.3"),
+		NetworkOverlaps("subnet", "10.1.0.0/16"),
+		db.Cond{"addr <<": "172.16.0.0/12"},
+	)
+	assert.Equal(t,
+		`SELECT * FROM "hosts" WHERE ("addr" << $1 AND "addr" <<= $2 AND "subnet" >> $3 AND "subnet" >>= $4 AND "subnet" && $5 AND "addr" << $6)`,
+		q.String(),
+	)
+
+	args := q.Arguments()
+	if assert.Len(t, args, 6) {
+		assert.Equal(t, "10.0.0.0/8", args[0].(CIDR).String())
+		assert.Equal(t, "10.1.2.3", args[2].(Inet).String())
+		assert.Equal(t, "172.16.0.0/12", args[5])
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package postgis adds the geometry and geography types of the PostGIS
+// extension to the postgresql adapter, along with conditions for the most
+// common spatial functions:
+//
+//	type place struct {
+//		ID       int64             `db:"id,omitempty"`
+//		Location postgis.Geography `db:"location"`
+//	}
+//
+//	here := postgis.Geography{SRID: 4326, Shape: postgis.Point{X: -99.13, Y: 19.43}}
+//	err := sess.Collection("places").Find(postgis.DWithin("location", here, 1000)).All(&places)
+//
+// Values are sent to the database as hex-encoded EWKB, which is also the
+// format PostGIS returns them in. WKT and WKB can be read and written with
+// ParseWKT, ParseWKB and the WKT and WKB methods of Geometry. Only 2D shapes
+// are supported.
+package postgis
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+
+	"upper.io/db.v3"
+)
+
+// Shape is one of Point, LineString, Polygon, MultiPoint, MultiLineString,
+// MultiPolygon or GeometryCollection.
+type Shape interface {
+	wkbType() uint32
+}
+
+// WKB geometry type codes.
+const (
+	wkbPoint              = 1
+	wkbLineString         = 2
+	wkbPolygon            = 3
+	wkbMultiPoint         = 4
+	wkbMultiLineString    = 5
+	wkbMultiPolygon       = 6
+	wkbGeometryCollection = 7
+)
+
+// Point is a single location, a Point with NaN coordinates is empty.
+type Point struct {
+	X, Y float64
+}
+
+// LineString is a sequence of points.
+type LineString []Point
+
+// Polygon is made of an exterior ring, followed by the rings of its holes if
+// any. Rings are closed, their first and last points are the same.
+type Polygon []LineString
+
+// MultiPoint is a collection of points.
+type MultiPoint []Point
+
+// MultiLineString is a collection of line strings.
+type MultiLineString []LineString
+
+// MultiPolygon is a collection of polygons.
+type MultiPolygon []Polygon
+
+// GeometryCollection is a collection of shapes of any type.
+type GeometryCollection []Shape
+
+func (Point) wkbType() uint32              { return wkbPoint }
+func (LineString) wkbType() uint32         { return wkbLineString }
+func (Polygon) wkbType() uint32            { return wkbPolygon }
+func (MultiPoint) wkbType() uint32         { return wkbMultiPoint }
+func (MultiLineString) wkbType() uint32    { return wkbMultiLineString }
+func (MultiPolygon) wkbType() uint32       { return wkbMultiPolygon }
+func (GeometryCollection) wkbType() uint32 { return wkbGeometryCollection }
+
+// IsEmpty returns true if the point has no coordinates.
+func (p Point) IsEmpty() bool {
+	return math.IsNaN(p.X) && math.IsNaN(p.Y)
+}
+
+// EmptyPoint returns an empty point.
+func EmptyPoint() Point {
+	return Point{math.NaN(), math.NaN()}
+}
+
+// Geometry represents a PostGIS geometry column, a shape along with the
+// spatial reference system its coordinates are in, like 4326 for longitude
+// and latitude (WGS 84). A Geometry without a Shape is stored as NULL.
+type Geometry struct {
+	SRID  int
+	Shape Shape
+}
+
+// WKT returns the shape in Well-Known Text, like "POINT(1 2)".
+func (g Geometry) WKT() string {
+	if g.Shape == nil {
+		return ""
+	}
+	return string(appendWKT(nil, g.Shape))
+}
+
+// String returns the geometry in Extended Well-Known Text, which starts with
+// its SRID, like "SRID=4326;POINT(1 2)".
+func (g Geometry) String() string {
+	if g.SRID == 0 {
+		return g.WKT()
+	}
+	return fmt.Sprintf("SRID=%d;%s", g.SRID, g.WKT())
+}
+
+// WKB returns the geometry in Extended Well-Known Binary, which includes its
+// SRID unless it's zero, in which case the result is plain WKB.
+func (g Geometry) WKB() []byte {
+	if g.Shape == nil {
+		return nil
+	}
+	return appendWKB(nil, g.Shape, g.SRID)
+}
+
+// Scan implements the sql.Scanner interface. Geometries are read from EWKB,
+// either raw or hex-encoded, or from EWKT.
+func (g *Geometry) Scan(src interface{}) error {
+	var b []byte
+	switch src := src.(type) {
+	case nil:
+		*g = Geometry{}
+		return nil
+	case []byte:
+		b = src
+	case string:
+		b = []byte(src)
+	default:
+		return fmt.Errorf("Cannot scan %T into Geometry", src)
+	}
+
+	var v Geometry
+	var err error
+	switch {
+	case len(b) > 0 && (b[0] == 0 || b[0] == 1):
+		v, err = ParseWKB(b)
+	case isHex(b):
+		buf := make([]byte, len(b)/2)
+		if _, err = hex.Decode(buf, b); err == nil {
+			v, err = ParseWKB(buf)
+		}
+	default:
+		v, err = ParseWKT(string(b))
+	}
+	if err != nil {
+		return err
+	}
+	*g = v
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (g Geometry) Value() (driver.Value, error) {
+	if g.Shape == nil {
+		return nil, nil
+	}
+	return strings.ToUpper(hex.EncodeToString(g.WKB())), nil
+}
+
+// Geography represents a PostGIS geography column, its coordinates are
+// longitudes and latitudes and distances are measured in meters. PostGIS uses
+// SRID 4326 when it's zero.
+type Geography Geometry
+
+// WKT returns the shape in Well-Known Text, see Geometry.WKT.
+func (g Geography) WKT() string {
+	return Geometry(g).WKT()
+}
+
+// String returns the geography in Extended Well-Known Text, see
+// Geometry.String.
+func (g Geography) String() string {
+	return Geometry(g).String()
+}
+
+// WKB returns the geography in Extended Well-Known Binary, see Geometry.WKB.
+func (g Geography) WKB() []byte {
+	return Geometry(g).WKB()
+}
+
+// Scan implements the sql.Scanner interface.
+func (g *Geography) Scan(src interface{}) error {
+	return (*Geometry)(g).Scan(src)
+}
+
+// Value implements the driver.Valuer interface.
+func (g Geography) Value() (driver.Value, error) {
+	return Geometry(g).Value()
+}
+
+// DWithin creates a condition that matches rows where the shape of the given
+// column is within distance of value, which is a Geometry, a Geography or a
+// Shape. Distances are in the units of the SRID for geometries and in meters
+// for geographies.
+//
+// Example:
+//
+//	// ST_DWithin("location", $1::geography, $2)
+//	postgis.DWithin("location", here, 1000)
+func DWithin(column string, value interface{}, distance float64) db.RawValue {
+	placeholder, arg := spatialArgument(value)
+	return db.Raw("ST_DWithin("+quoteIdentifier(column)+", "+placeholder+", ?)", arg, distance)
+}
+
+// Contains creates a condition that matches rows where the shape of the given
+// column contains value, see DWithin.
+//
+// Example:
+//
+//	// ST_Contains("area", $1::geometry)
+//	postgis.Contains("area", postgis.Geometry{SRID: 4326, Shape: postgis.Point{X: 1, Y: 2}})
+func Contains(column string, value interface{}) db.RawValue {
+	return spatialCondition("ST_Contains", column, value)
+}
+
+// Within creates a condition that matches rows where the shape of the given
+// column is within value, see DWithin.
+func Within(column string, value interface{}) db.RawValue {
+	return spatialCondition("ST_Within", column, value)
+}
+
+// Intersects creates a condition that matches rows where the shape of the
+// given column intersects value, see DWithin.
+func Intersects(column string, value interface{}) db.RawValue {
+	return spatialCondition("ST_Intersects", column, value)
+}
+
+// Distance returns an expression with the distance between the shape of the
+// given column and value, which can be used to sort results:
+//
+//	sess.SelectFrom("places").OrderBy(postgis.Distance("location", here)).Limit(10)
+func Distance(column string, value interface{}) db.RawValue {
+	return spatialCondition("ST_Distance", column, value)
+}
+
+func spatialCondition(fn string, column string, value interface{}) db.RawValue {
+	placeholder, arg := spatialArgument(value)
+	return db.Raw(fn+"("+quoteIdentifier(column)+", "+placeholder+")", arg)
+}
+
+// spatialArgument returns the placeholder and argument of a value, values are
+// cast to their type as spatial functions are defined for both geometries and
+// geographies.
+func spatialArgument(value interface{}) (string, interface{}) {
+	switch v := value.(type) {
+	case Geometry, *Geometry:
+		return "?::geometry", v
+	case Geography, *Geography:
+		return "?::geography", v
+	case Shape:
+		return "?::geometry", Geometry{Shape: v}
+	}
+	return "?", value
+}
+
+// quoteIdentifier quotes a column name, like "places.location".
+func quoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i := range parts {
+		parts[i] = `"` + strings.Replace(parts[i], `"`, `""`, -1) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+func isHex(b []byte) bool {
+	if len(b)%2 != 0 {
+		return false
+	}
+	for _, c := range b {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return len(b) > 0
+}
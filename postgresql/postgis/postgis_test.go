@@ -0,0 +1,76 @@
+package postgis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+)
+
+func TestGeometryScan(t *testing.T) {
+	expected := Geometry{SRID: 4326, Shape: Point{1, 2}}
+
+	for _, src := range []interface{}{
+		[]byte("0101000020E6100000000000000000F03F0000000000000040"),
+		"0101000020e6100000000000000000f03f0000000000000040",
+		expected.WKB(),
+		"SRID=4326;POINT(1 2)",
+	} {
+		var g Geometry
+		assert.NoError(t, g.Scan(src))
+		assert.Equal(t, expected, g)
+	}
+
+	{
+		g := expected
+		assert.NoError(t, g.Scan(nil))
+		assert.Nil(t, g.Shape)
+
+		v, err := g.Value()
+		assert.NoError(t, err)
+		assert.Nil(t, v)
+	}
+
+	{
+		var g Geometry
+		assert.Error(t, g.Scan(1))
+		assert.Error(t, g.Scan("CIRCLE(1 2)"))
+	}
+
+	v, err := expected.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "0101000020E6100000000000000000F03F0000000000000040", v)
+}
+
+func TestGeographyScan(t *testing.T) {
+	var g Geography
+	assert.NoError(t, g.Scan("0101000020E6100000000000000000F03F0000000000000040"))
+	assert.Equal(t, Geography{SRID: 4326, Shape: Point{1, 2}}, g)
+	assert.Equal(t, "SRID=4326;POINT(1 2)", g.String())
+
+	v, err := g.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "0101000020E6100000000000000000F03F0000000000000040", v)
+}
+
+func TestConditions(t *testing.T) {
+	here := Geography{SRID: 4326, Shape: Point{-99.13, 19.43}}
+	area := Geometry{SRID: 4326, Shape: Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}}
+
+	testCases := []struct {
+		cond db.RawValue
+		raw  string
+		args []interface{}
+	}{
+		{DWithin("location", here, 1000), `ST_DWithin("location", ?::geography, ?)`, []interface{}{here, 1000.0}},
+		{Contains("places.area", Point{1, 2}), `ST_Contains("places"."area", ?::geometry)`, []interface{}{Geometry{Shape: Point{1, 2}}}},
+		{Within("location", area), `ST_Within("location", ?::geometry)`, []interface{}{area}},
+		{Intersects("area", "SRID=4326;POINT(1 2)"), `ST_Intersects("area", ?)`, []interface{}{"SRID=4326;POINT(1 2)"}},
+		{Distance("location", &here), `ST_Distance("location", ?::geography)`, []interface{}{&here}},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.raw, tc.cond.Raw())
+		assert.Equal(t, tc.args, tc.cond.Arguments())
+	}
+}
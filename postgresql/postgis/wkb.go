@@ -0,0 +1,305 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package postgis
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// EWKB flags of the type code.
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// ErrUnsupportedDimensions is returned when reading shapes with Z or M
+// coordinates.
+var ErrUnsupportedDimensions = errors.New(`upper: only 2D geometries are supported`)
+
+// ParseWKB reads a geometry from Well-Known Binary or from PostGIS' Extended
+// Well-Known Binary, which can include the SRID.
+func ParseWKB(b []byte) (Geometry, error) {
+	r := &wkbReader{b: b}
+	shape, srid, err := r.geometry()
+	if err != nil {
+		return Geometry{}, err
+	}
+	if r.pos != len(b) {
+		return Geometry{}, fmt.Errorf("Unexpected %d bytes after WKB geometry", len(b)-r.pos)
+	}
+	return Geometry{SRID: srid, Shape: shape}, nil
+}
+
+type wkbReader struct {
+	b     []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+func (r *wkbReader) uint32() (uint32, error) {
+	if r.pos+4 > len(r.b) {
+		return 0, errors.New("Unexpected end of WKB geometry")
+	}
+	v := r.order.Uint32(r.b[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+func (r *wkbReader) float64() (float64, error) {
+	if r.pos+8 > len(r.b) {
+		return 0, errors.New("Unexpected end of WKB geometry")
+	}
+	v := math.Float64frombits(r.order.Uint64(r.b[r.pos:]))
+	r.pos += 8
+	return v, nil
+}
+
+// count reads the number of elements of a collection, which is checked
+// against the bytes that are left so corrupt input can't allocate too much.
+func (r *wkbReader) count(minSize int) (int, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return 0, err
+	}
+	if int64(n)*int64(minSize) > int64(len(r.b)-r.pos) {
+		return 0, errors.New("Unexpected end of WKB geometry")
+	}
+	return int(n), nil
+}
+
+func (r *wkbReader) geometry() (Shape, int, error) {
+	if r.pos >= len(r.b) {
+		return nil, 0, errors.New("Unexpected end of WKB geometry")
+	}
+	switch r.b[r.pos] {
+	case 0:
+		r.order = binary.BigEndian
+	case 1:
+		r.order = binary.LittleEndian
+	default:
+		return nil, 0, fmt.Errorf("Invalid WKB byte order %d", r.b[r.pos])
+	}
+	r.pos++
+
+	code, err := r.uint32()
+	if err != nil {
+		return nil, 0, err
+	}
+	if code&(ewkbZ|ewkbM) != 0 || code&0xffff > 1000 {
+		return nil, 0, ErrUnsupportedDimensions
+	}
+
+	var srid int
+	if code&ewkbSRID != 0 {
+		v, err := r.uint32()
+		if err != nil {
+			return nil, 0, err
+		}
+		srid = int(int32(v))
+	}
+
+	shape, err := r.shape(code & 0xffff)
+	return shape, srid, err
+}
+
+func (r *wkbReader) shape(code uint32) (Shape, error) {
+	switch code {
+	case wkbPoint:
+		return r.point()
+	case wkbLineString:
+		return r.points()
+	case wkbPolygon:
+		return r.polygon()
+	case wkbMultiPoint, wkbMultiLineString, wkbMultiPolygon, wkbGeometryCollection:
+		n, err := r.count(9)
+		if err != nil {
+			return nil, err
+		}
+		shapes := make([]Shape, n)
+		for i := range shapes {
+			if shapes[i], _, err = r.geometry(); err != nil {
+				return nil, err
+			}
+		}
+		return collection(code, shapes)
+	}
+	return nil, fmt.Errorf("Unsupported WKB geometry type %d", code)
+}
+
+func (r *wkbReader) point() (Point, error) {
+	x, err := r.float64()
+	if err != nil {
+		return Point{}, err
+	}
+	y, err := r.float64()
+	if err != nil {
+		return Point{}, err
+	}
+	return Point{x, y}, nil
+}
+
+func (r *wkbReader) points() (LineString, error) {
+	n, err := r.count(16)
+	if err != nil {
+		return nil, err
+	}
+	points := make(LineString, n)
+	for i := range points {
+		if points[i], err = r.point(); err != nil {
+			return nil, err
+		}
+	}
+	return points, nil
+}
+
+func (r *wkbReader) polygon() (Polygon, error) {
+	n, err := r.count(4)
+	if err != nil {
+		return nil, err
+	}
+	rings := make(Polygon, n)
+	for i := range rings {
+		if rings[i], err = r.points(); err != nil {
+			return nil, err
+		}
+	}
+	return rings, nil
+}
+
+// collection converts the shapes of a multi geometry into its type, it fails
+// if they are not of the type the collection expects.
+func collection(code uint32, shapes []Shape) (Shape, error) {
+	switch code {
+	case wkbMultiPoint:
+		c := make(MultiPoint, len(shapes))
+		for i, s := range shapes {
+			p, ok := s.(Point)
+			if !ok {
+				return nil, fmt.Errorf("Unexpected %T in MultiPoint", s)
+			}
+			c[i] = p
+		}
+		return c, nil
+	case wkbMultiLineString:
+		c := make(MultiLineString, len(shapes))
+		for i, s := range shapes {
+			l, ok := s.(LineString)
+			if !ok {
+				return nil, fmt.Errorf("Unexpected %T in MultiLineString", s)
+			}
+			c[i] = l
+		}
+		return c, nil
+	case wkbMultiPolygon:
+		c := make(MultiPolygon, len(shapes))
+		for i, s := range shapes {
+			p, ok := s.(Polygon)
+			if !ok {
+				return nil, fmt.Errorf("Unexpected %T in MultiPolygon", s)
+			}
+			c[i] = p
+		}
+		return c, nil
+	}
+	return GeometryCollection(shapes), nil
+}
+
+// appendWKB appends the little-endian EWKB representation of a shape to b,
+// the SRID is only written when it's not zero.
+func appendWKB(b []byte, s Shape, srid int) []byte {
+	code := s.wkbType()
+	if srid != 0 {
+		code |= ewkbSRID
+	}
+	b = append(b, 1)
+	b = appendUint32(b, code)
+	if srid != 0 {
+		b = appendUint32(b, uint32(srid))
+	}
+
+	switch s := s.(type) {
+	case Point:
+		b = appendWKBPoint(b, s)
+	case LineString:
+		b = appendWKBPoints(b, s)
+	case Polygon:
+		b = appendWKBPolygon(b, s)
+	case MultiPoint:
+		b = appendUint32(b, uint32(len(s)))
+		for i := range s {
+			b = appendWKB(b, s[i], 0)
+		}
+	case MultiLineString:
+		b = appendUint32(b, uint32(len(s)))
+		for i := range s {
+			b = appendWKB(b, s[i], 0)
+		}
+	case MultiPolygon:
+		b = appendUint32(b, uint32(len(s)))
+		for i := range s {
+			b = appendWKB(b, s[i], 0)
+		}
+	case GeometryCollection:
+		b = appendUint32(b, uint32(len(s)))
+		for i := range s {
+			b = appendWKB(b, s[i], 0)
+		}
+	}
+	return b
+}
+
+func appendWKBPoint(b []byte, p Point) []byte {
+	b = appendUint64(b, math.Float64bits(p.X))
+	return appendUint64(b, math.Float64bits(p.Y))
+}
+
+func appendWKBPoints(b []byte, points []Point) []byte {
+	b = appendUint32(b, uint32(len(points)))
+	for i := range points {
+		b = appendWKBPoint(b, points[i])
+	}
+	return b
+}
+
+func appendWKBPolygon(b []byte, p Polygon) []byte {
+	b = appendUint32(b, uint32(len(p)))
+	for i := range p {
+		b = appendWKBPoints(b, p[i])
+	}
+	return b
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
@@ -0,0 +1,72 @@
+package postgis
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWKB(t *testing.T) {
+	{
+		// SELECT 'SRID=4326;POINT(1 2)'::geometry
+		b, _ := hex.DecodeString("0101000020E6100000000000000000F03F0000000000000040")
+		g, err := ParseWKB(b)
+		assert.NoError(t, err)
+		assert.Equal(t, Geometry{SRID: 4326, Shape: Point{1, 2}}, g)
+	}
+
+	{
+		// Big-endian WKB of POINT(1 2), without SRID.
+		b, _ := hex.DecodeString("00000000013FF00000000000004000000000000000")
+		g, err := ParseWKB(b)
+		assert.NoError(t, err)
+		assert.Equal(t, Geometry{Shape: Point{1, 2}}, g)
+	}
+
+	{
+		// SELECT 'POINT Z(1 2 3)'::geometry
+		b, _ := hex.DecodeString("0101000080000000000000F03F00000000000000400000000000000840")
+		_, err := ParseWKB(b)
+		assert.Equal(t, ErrUnsupportedDimensions, err)
+	}
+
+	for _, s := range []string{"", "02", "0101000000000000000000F03F", "01010000000000000000000F03F000000000000004000"} {
+		b, _ := hex.DecodeString(s)
+		_, err := ParseWKB(b)
+		assert.Error(t, err, s)
+	}
+
+	{
+		// A collection that claims to have more elements than bytes.
+		b, _ := hex.DecodeString("0104000000FFFFFFFF")
+		_, err := ParseWKB(b)
+		assert.Error(t, err)
+	}
+}
+
+func TestWKBRoundTrip(t *testing.T) {
+	ring := LineString{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}}
+	hole := LineString{{1, 1}, {2, 1}, {2, 2}, {1, 1}}
+
+	for _, g := range []Geometry{
+		{Shape: Point{-99.13, 19.43}},
+		{SRID: 4326, Shape: LineString{{0, 0}, {1, 1}, {2, 0}}},
+		{SRID: 3857, Shape: Polygon{ring, hole}},
+		{Shape: MultiPoint{{0, 0}, {1, 1}}},
+		{Shape: MultiLineString{{{0, 0}, {1, 1}}, {{2, 2}, {3, 3}}}},
+		{SRID: 4326, Shape: MultiPolygon{{ring}, {ring, hole}}},
+		{Shape: GeometryCollection{Point{1, 2}, LineString{{0, 0}, {1, 1}}, MultiPoint{{3, 4}}}},
+		{Shape: LineString{}},
+	} {
+		v, err := ParseWKB(g.WKB())
+		assert.NoError(t, err)
+		assert.Equal(t, g, v)
+	}
+
+	assert.Equal(t,
+		"0101000020e6100000000000000000f03f0000000000000040",
+		hex.EncodeToString(Geometry{SRID: 4326, Shape: Point{1, 2}}.WKB()),
+	)
+	assert.Nil(t, Geometry{}.WKB())
+}
@@ -0,0 +1,371 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package postgis
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseWKT reads a geometry from Well-Known Text, like "POINT(1 2)", or from
+// PostGIS' Extended Well-Known Text, which starts with the SRID, like
+// "SRID=4326;POINT(1 2)".
+func ParseWKT(s string) (Geometry, error) {
+	var g Geometry
+
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(strings.ToUpper(s), "SRID=") {
+		i := strings.Index(s, ";")
+		if i < 0 {
+			return Geometry{}, fmt.Errorf("Invalid EWKT %q", s)
+		}
+		srid, err := strconv.Atoi(s[5:i])
+		if err != nil {
+			return Geometry{}, fmt.Errorf("Invalid EWKT %q", s)
+		}
+		g.SRID, s = srid, s[i+1:]
+	}
+
+	p := &wktParser{tokens: wktTokens(s)}
+	shape, err := p.shape()
+	if err == ErrUnsupportedDimensions {
+		return Geometry{}, err
+	}
+	if err != nil {
+		return Geometry{}, fmt.Errorf("Invalid WKT %q: %v", s, err)
+	}
+	if p.pos != len(p.tokens) {
+		return Geometry{}, fmt.Errorf("Invalid WKT %q: unexpected %q", s, p.tokens[p.pos])
+	}
+	g.Shape = shape
+	return g, nil
+}
+
+// wktTokens splits WKT into words, numbers and punctuation.
+func wktTokens(s string) []string {
+	var tokens []string
+	start := -1
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '(', ')', ',', ' ', '\t', '\r', '\n':
+			if start >= 0 {
+				tokens = append(tokens, s[start:i])
+				start = -1
+			}
+			if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+				tokens = append(tokens, string(c))
+			}
+		default:
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+type wktParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *wktParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *wktParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *wktParser) expect(token string) error {
+	if t := p.next(); t != token {
+		if t == "" {
+			return fmt.Errorf("expecting %q", token)
+		}
+		return fmt.Errorf("expecting %q, got %q", token, t)
+	}
+	return nil
+}
+
+// empty consumes the EMPTY keyword if it's next.
+func (p *wktParser) empty() bool {
+	if strings.ToUpper(p.peek()) == "EMPTY" {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// list parses a parenthesized, comma-separated list, calling fn for each of
+// its elements.
+func (p *wktParser) list(fn func() error) error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for {
+		if err := fn(); err != nil {
+			return err
+		}
+		if p.peek() != "," {
+			break
+		}
+		p.pos++
+	}
+	return p.expect(")")
+}
+
+func (p *wktParser) shape() (Shape, error) {
+	kind := strings.ToUpper(p.next())
+	switch strings.ToUpper(p.peek()) {
+	case "Z", "M", "ZM":
+		return nil, ErrUnsupportedDimensions
+	}
+
+	switch kind {
+	case "POINT":
+		if p.empty() {
+			return EmptyPoint(), nil
+		}
+		var pt Point
+		err := p.list(func() (err error) {
+			pt, err = p.coordinates()
+			return err
+		})
+		return pt, err
+	case "LINESTRING":
+		if p.empty() {
+			return LineString{}, nil
+		}
+		return p.points()
+	case "POLYGON":
+		if p.empty() {
+			return Polygon{}, nil
+		}
+		return p.polygon()
+	case "MULTIPOINT":
+		c := MultiPoint{}
+		if p.empty() {
+			return c, nil
+		}
+		err := p.list(func() error {
+			// Points can be written with or without parentheses.
+			if p.peek() == "(" {
+				return p.list(func() error {
+					pt, err := p.coordinates()
+					c = append(c, pt)
+					return err
+				})
+			}
+			pt, err := p.coordinates()
+			c = append(c, pt)
+			return err
+		})
+		return c, err
+	case "MULTILINESTRING":
+		c := MultiLineString{}
+		if p.empty() {
+			return c, nil
+		}
+		err := p.list(func() error {
+			l, err := p.points()
+			c = append(c, l)
+			return err
+		})
+		return c, err
+	case "MULTIPOLYGON":
+		c := MultiPolygon{}
+		if p.empty() {
+			return c, nil
+		}
+		err := p.list(func() error {
+			pg, err := p.polygon()
+			c = append(c, pg)
+			return err
+		})
+		return c, err
+	case "GEOMETRYCOLLECTION":
+		c := GeometryCollection{}
+		if p.empty() {
+			return c, nil
+		}
+		err := p.list(func() error {
+			s, err := p.shape()
+			c = append(c, s)
+			return err
+		})
+		return c, err
+	case "":
+		return nil, errors.New("missing geometry type")
+	}
+	return nil, fmt.Errorf("unsupported geometry type %q", kind)
+}
+
+func (p *wktParser) coordinates() (Point, error) {
+	x, err := strconv.ParseFloat(p.next(), 64)
+	if err != nil {
+		return Point{}, errors.New("expecting a coordinate")
+	}
+	y, err := strconv.ParseFloat(p.next(), 64)
+	if err != nil {
+		return Point{}, errors.New("expecting a coordinate")
+	}
+	return Point{x, y}, nil
+}
+
+func (p *wktParser) points() (LineString, error) {
+	l := LineString{}
+	err := p.list(func() error {
+		pt, err := p.coordinates()
+		l = append(l, pt)
+		return err
+	})
+	return l, err
+}
+
+func (p *wktParser) polygon() (Polygon, error) {
+	pg := Polygon{}
+	err := p.list(func() error {
+		l, err := p.points()
+		pg = append(pg, l)
+		return err
+	})
+	return pg, err
+}
+
+// appendWKT appends the WKT representation of a shape to b.
+func appendWKT(b []byte, s Shape) []byte {
+	switch s := s.(type) {
+	case Point:
+		b = append(b, "POINT"...)
+		if s.IsEmpty() {
+			return append(b, " EMPTY"...)
+		}
+		b = append(b, '(')
+		b = appendWKTPoint(b, s)
+		return append(b, ')')
+	case LineString:
+		b = append(b, "LINESTRING"...)
+		if len(s) == 0 {
+			return append(b, " EMPTY"...)
+		}
+		return appendWKTPoints(b, s)
+	case Polygon:
+		b = append(b, "POLYGON"...)
+		if len(s) == 0 {
+			return append(b, " EMPTY"...)
+		}
+		return appendWKTPolygon(b, s)
+	case MultiPoint:
+		b = append(b, "MULTIPOINT"...)
+		if len(s) == 0 {
+			return append(b, " EMPTY"...)
+		}
+		b = append(b, '(')
+		for i := range s {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = append(b, '(')
+			b = appendWKTPoint(b, s[i])
+			b = append(b, ')')
+		}
+		return append(b, ')')
+	case MultiLineString:
+		b = append(b, "MULTILINESTRING"...)
+		if len(s) == 0 {
+			return append(b, " EMPTY"...)
+		}
+		b = append(b, '(')
+		for i := range s {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendWKTPoints(b, s[i])
+		}
+		return append(b, ')')
+	case MultiPolygon:
+		b = append(b, "MULTIPOLYGON"...)
+		if len(s) == 0 {
+			return append(b, " EMPTY"...)
+		}
+		b = append(b, '(')
+		for i := range s {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendWKTPolygon(b, s[i])
+		}
+		return append(b, ')')
+	case GeometryCollection:
+		b = append(b, "GEOMETRYCOLLECTION"...)
+		if len(s) == 0 {
+			return append(b, " EMPTY"...)
+		}
+		b = append(b, '(')
+		for i := range s {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendWKT(b, s[i])
+		}
+		return append(b, ')')
+	}
+	return b
+}
+
+func appendWKTPoint(b []byte, p Point) []byte {
+	b = strconv.AppendFloat(b, p.X, 'g', -1, 64)
+	b = append(b, ' ')
+	return strconv.AppendFloat(b, p.Y, 'g', -1, 64)
+}
+
+func appendWKTPoints(b []byte, points []Point) []byte {
+	b = append(b, '(')
+	for i := range points {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendWKTPoint(b, points[i])
+	}
+	return append(b, ')')
+}
+
+func appendWKTPolygon(b []byte, p Polygon) []byte {
+	b = append(b, '(')
+	for i := range p {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendWKTPoints(b, p[i])
+	}
+	return append(b, ')')
+}
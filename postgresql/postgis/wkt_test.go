@@ -0,0 +1,57 @@
+package postgis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWKT(t *testing.T) {
+	testCases := []struct {
+		in    string
+		out   string
+		shape Shape
+	}{
+		{"POINT(1 2)", "POINT(1 2)", Point{1, 2}},
+		{" point ( -1.5  2e3 ) ", "POINT(-1.5 2000)", Point{-1.5, 2000}},
+		{"LINESTRING(0 0,1 1)", "LINESTRING(0 0,1 1)", LineString{{0, 0}, {1, 1}}},
+		{"POLYGON((0 0,1 0,1 1,0 0),(0.2 0.1,0.3 0.1,0.3 0.2,0.2 0.1))", "POLYGON((0 0,1 0,1 1,0 0),(0.2 0.1,0.3 0.1,0.3 0.2,0.2 0.1))", Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}, {{0.2, 0.1}, {0.3, 0.1}, {0.3, 0.2}, {0.2, 0.1}}}},
+		{"MULTIPOINT(0 0, 1 1)", "MULTIPOINT((0 0),(1 1))", MultiPoint{{0, 0}, {1, 1}}},
+		{"MULTIPOINT((0 0),(1 1))", "MULTIPOINT((0 0),(1 1))", MultiPoint{{0, 0}, {1, 1}}},
+		{"MULTILINESTRING((0 0,1 1),(2 2,3 3))", "MULTILINESTRING((0 0,1 1),(2 2,3 3))", MultiLineString{{{0, 0}, {1, 1}}, {{2, 2}, {3, 3}}}},
+		{"MULTIPOLYGON(((0 0,1 0,1 1,0 0)))", "MULTIPOLYGON(((0 0,1 0,1 1,0 0)))", MultiPolygon{{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}}},
+		{"GEOMETRYCOLLECTION(POINT(1 2),LINESTRING(0 0,1 1))", "GEOMETRYCOLLECTION(POINT(1 2),LINESTRING(0 0,1 1))", GeometryCollection{Point{1, 2}, LineString{{0, 0}, {1, 1}}}},
+		{"LINESTRING EMPTY", "LINESTRING EMPTY", LineString{}},
+		{"GEOMETRYCOLLECTION EMPTY", "GEOMETRYCOLLECTION EMPTY", GeometryCollection{}},
+	}
+
+	for _, tc := range testCases {
+		g, err := ParseWKT(tc.in)
+		if assert.NoError(t, err, tc.in) {
+			assert.Equal(t, tc.shape, g.Shape, tc.in)
+			assert.Equal(t, tc.out, g.WKT(), tc.in)
+		}
+	}
+
+	{
+		g, err := ParseWKT("POINT EMPTY")
+		assert.NoError(t, err)
+		assert.True(t, g.Shape.(Point).IsEmpty())
+		assert.Equal(t, "POINT EMPTY", g.WKT())
+	}
+
+	{
+		g, err := ParseWKT("SRID=4326;POINT(-99.13 19.43)")
+		assert.NoError(t, err)
+		assert.Equal(t, Geometry{SRID: 4326, Shape: Point{-99.13, 19.43}}, g)
+		assert.Equal(t, "SRID=4326;POINT(-99.13 19.43)", g.String())
+	}
+
+	for _, s := range []string{"", "POINT", "POINT(1)", "POINT(1 2", "POINT(1 2) 3", "CIRCLE(1 2)", "SRID=x;POINT(1 2)", "SRID=4326POINT(1 2)", "LINESTRING(0 0,)"} {
+		_, err := ParseWKT(s)
+		assert.Error(t, err, s)
+	}
+
+	_, err := ParseWKT("POINT Z(1 2 3)")
+	assert.Equal(t, ErrUnsupportedDimensions, err)
+}
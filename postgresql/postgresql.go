@@ -22,6 +22,7 @@
 package postgresql // import "upper.io/db.v3/postgresql"
 
 import (
+	"context"
 	"database/sql"
 
 	"upper.io/db.v3"
@@ -35,6 +36,42 @@ const sqlDriver = `postgres`
 // Adapter is the unique name that you can use to refer to this adapter.
 const Adapter = `postgresql`
 
+// Database represents a PostgreSQL database session. The sessions returned by
+// Open and New implement it and can be asserted to use PostgreSQL-specific
+// features:
+//
+//   sess, err := postgresql.Open(settings)
+//   ...
+//   events, err := sess.(postgresql.Database).Listen(ctx, "events")
+type Database interface {
+	sqlbuilder.Database
+
+	// Listen subscribes to the given channel and returns a Go channel that
+	// receives notifications until ctx is done.
+	Listen(ctx context.Context, channel string) (<-chan Notification, error)
+
+	// Notify sends a notification to the given channel.
+	Notify(channel string, payload string) error
+
+	// AdvisoryLock blocks until the advisory lock identified by key is
+	// acquired.
+	AdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, error)
+
+	// TryAdvisoryLock attempts to acquire the advisory lock identified by key
+	// without waiting.
+	TryAdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, bool, error)
+
+	// Two-phase commits with PREPARE TRANSACTION, see
+	// sqlbuilder.DistributedTx.
+	sqlbuilder.DistributedTxSession
+
+	// Primary returns a session that sends all queries to the primary server,
+	// even if the session has read replicas (see OpenCluster). The returned
+	// session shares its connections with the original one and must not be
+	// closed.
+	Primary() Database
+}
+
 func init() {
 	sqlbuilder.RegisterAdapter(Adapter, &sqlbuilder.AdapterFuncMap{
 		New:   New,
@@ -56,6 +93,46 @@ func Open(settings db.ConnectionURL) (sqlbuilder.Database, error) {
 	return d, nil
 }
 
+// OpenCluster opens a session with a primary PostgreSQL server and any number
+// of read replicas. SELECT statements that are built with the query builder,
+// don't lock rows and run outside of transactions are sent to the replicas,
+// balanced by the ReplicaPolicy setting, everything else goes to the primary
+// server.
+//
+//   sess, err := postgresql.OpenCluster(primary, replica1, replica2)
+//   ...
+//   err = sess.Primary().Collection("accounts").Find(id).One(&account)
+func OpenCluster(primary db.ConnectionURL, replicas ...db.ConnectionURL) (Database, error) {
+	d := newDatabase(primary)
+	if err := d.Open(primary); err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*sql.DB, 0, len(replicas))
+	cancel := func() {
+		for _, sess := range sessions {
+			sess.Close()
+		}
+		d.Close()
+	}
+
+	for _, replica := range replicas {
+		sess, err := openDB(replica)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		sessions = append(sessions, sess)
+	}
+
+	if err := d.BaseDatabase.BindReplicas(sessions...); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return d, nil
+}
+
 // NewTx wraps a regular *sql.Tx transaction and returns a new upper-db
 // transaction backed by it.
 func NewTx(sqlTx *sql.Tx) (sqlbuilder.Tx, error) {
@@ -0,0 +1,46 @@
+//go:build go1.10
+// +build go1.10
+
+package postgresql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+func TestRecorder(t *testing.T) {
+	rec := sqlbuilder.NewRecorder()
+
+	sess, err := New(rec.DB())
+	assert.NoError(t, err)
+	defer sess.Close()
+
+	rec.Reset()
+
+	_, err = sess.InsertInto("artist").Values(map[string]interface{}{"name": "Chavela"}).Exec()
+	assert.NoError(t, err)
+
+	_, err = sess.Update("artist").Set("name", "Chavela Vargas").Where("id", 1).Exec()
+	assert.NoError(t, err)
+
+	var artist struct {
+		Name string `db:"name"`
+	}
+	err = sess.SelectFrom("artist").Where(db.Cond{"id": 1}).One(&artist)
+	assert.Equal(t, db.ErrNoMoreRows, err)
+
+	statements := rec.Statements()
+	for i := range statements {
+		statements[i].Query = strings.Join(strings.Fields(statements[i].Query), " ")
+	}
+
+	assert.Equal(t, []sqlbuilder.RecordedStatement{
+		{Query: `INSERT INTO "artist" ("name") VALUES ($1)`, Args: []interface{}{"Chavela"}},
+		{Query: `UPDATE "artist" SET "name" = $1 WHERE ("id" = $2)`, Args: []interface{}{"Chavela Vargas", 1}},
+		{Query: `SELECT * FROM "artist" WHERE ("id" = $1)`, Args: []interface{}{1}},
+	}, statements)
+}
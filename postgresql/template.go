@@ -42,9 +42,24 @@ const (
 	adapterClauseGroup         = `({{.}})`
 	adapterClauseOperator      = ` {{.}} `
 	adapterColumnValue         = `{{.Column}} {{.Operator}} {{.Value}}`
+	adapterMatchLayout         = `to_tsvector({{.Column}}) @@ plainto_tsquery({{.Value}})`
+	adapterRankLayout          = `ts_rank(to_tsvector({{.Column}}), plainto_tsquery({{.Query}}))`
 	adapterTableAliasLayout    = `{{.Name}}{{if .Alias}} AS {{.Alias}}{{end}}`
 	adapterColumnAliasLayout   = `{{.Name}}{{if .Alias}} AS {{.Alias}}{{end}}`
 	adapterSortByColumnLayout  = `{{.Column}} {{.Order}}`
+	adapterWithLayout          = `WITH {{if .Recursive}}RECURSIVE {{end}}{{.Clauses}}`
+	adapterOnConflictLayout    = `ON CONFLICT {{if .Columns}}({{.Columns}}) {{end}}{{if .ColumnValues}}DO UPDATE SET {{.ColumnValues}} {{.Where}}{{else}}DO NOTHING{{end}}`
+	adapterExcludedLayout      = `EXCLUDED.{{.}}`
+	adapterDistinctOnLayout    = `DISTINCT ON ({{.}})`
+	adapterLockLayout          = `FOR {{if .Share}}SHARE{{else}}UPDATE{{end}}{{if .SkipLocked}} SKIP LOCKED{{else if .NoWait}} NOWAIT{{end}}`
+	adapterDeclareCursorLayout = `DECLARE {{.Name}} NO SCROLL CURSOR FOR {{.Query}}`
+	adapterFetchCursorLayout   = `FETCH FORWARD {{.FetchSize}} FROM {{.Name}}`
+	adapterCloseCursorLayout   = `CLOSE {{.Name}}`
+	adapterRollupLayout        = `ROLLUP({{.}})`
+	adapterCubeLayout          = `CUBE({{.}})`
+	adapterGroupingSetsLayout  = `GROUPING SETS ({{.}})`
+	adapterTupleInLayout       = `({{.Columns}}) {{if .Negate}}NOT {{end}}IN ({{.Values}})`
+	adapterValuesListLayout    = `(VALUES {{range $i, $r := .Rows}}{{if $i}}, {{end}}({{$r}}){{end}}){{if .Name}} AS {{.Name}}{{if .Columns}} ({{range $i, $c := .Columns}}{{if $i}}, {{end}}{{$c.Name}}{{end}}){{end}}{{end}}`
 
 	adapterOrderByLayout = `
     {{if .SortColumns}}
@@ -88,7 +103,9 @@ const (
 
 	adapterSelectLayout = `
     SELECT
-      {{if .Distinct}}
+      {{if .DistinctOn}}
+        {{.DistinctOn}}
+      {{else if .Distinct}}
         DISTINCT
       {{end}}
 
@@ -120,17 +137,46 @@ const (
         {{end}}
         OFFSET {{.Offset}}
       {{end}}
+
+      {{if .Lock}}
+        {{.Lock}}
+      {{end}}
   `
 	adapterDeleteLayout = `
     DELETE
       FROM {{.Table}}
       {{.Where}}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
+  `
+	adapterDeleteUsingLayout = `
+    DELETE
+      FROM {{.Table}}
+    USING {{.Sources}}
+      {{.Where}}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
   `
 	adapterUpdateLayout = `
     UPDATE
       {{.Table}}
     SET {{.ColumnValues}}
       {{ .Where }}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
+  `
+	adapterUpdateFromLayout = `
+    UPDATE
+      {{.Table}}
+    SET {{.ColumnValues}}
+    FROM {{.Sources}}
+      {{ .Where }}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
   `
 
 	adapterSelectCountLayout = `
@@ -149,6 +195,7 @@ const (
     {{else}}
       (default)
     {{end}}
+    {{.OnConflict}}
     {{if .Returning}}
       RETURNING {{.Returning}}
     {{end}}
@@ -173,6 +220,35 @@ const (
   `
 )
 
+const (
+	adapterCreateTableLayout      = `CREATE TABLE {{if .IfNotExists}}IF NOT EXISTS {{end}}{{.Table}} ({{.Definitions}}{{if .PrimaryKey}}, PRIMARY KEY ({{.PrimaryKey}}){{end}})`
+	adapterCreateIndexLayout      = `CREATE {{if .Unique}}UNIQUE {{end}}INDEX {{.Index}} ON {{.Table}} ({{.Columns}})`
+	adapterAddColumnLayout        = `ALTER TABLE {{.Table}} ADD COLUMN {{.Column}}`
+	adapterDropColumnLayout       = `ALTER TABLE {{.Table}} DROP COLUMN {{.Column}}`
+	adapterRenameColumnLayout     = `ALTER TABLE {{.Table}} RENAME COLUMN {{.Column}} TO {{.NewColumn}}`
+	adapterColumnDefinitionLayout = `{{.Name}} {{.Type}}{{if .NotNull}} NOT NULL{{end}}{{if .Default}} DEFAULT {{.Default}}{{end}}{{if .PrimaryKey}} PRIMARY KEY{{end}}{{if .Unique}} UNIQUE{{end}}`
+	adapterTrueKeyword            = `TRUE`
+	adapterFalseKeyword           = `FALSE`
+)
+
+var adapterColumnTypes = map[string]string{
+	"boolean":   `BOOLEAN`,
+	"smallint":  `{{if .AutoIncrement}}SMALLSERIAL{{else}}SMALLINT{{end}}`,
+	"integer":   `{{if .AutoIncrement}}SERIAL{{else}}INTEGER{{end}}`,
+	"bigint":    `{{if .AutoIncrement}}BIGSERIAL{{else}}BIGINT{{end}}`,
+	"float":     `DOUBLE PRECISION`,
+	"decimal":   `NUMERIC{{if .Size}}({{.Size}}, {{.Scale}}){{end}}`,
+	"string":    `VARCHAR({{.Size}})`,
+	"text":      `TEXT`,
+	"bytes":     `BYTEA`,
+	"date":      `DATE`,
+	"timestamp": `TIMESTAMP WITH TIME ZONE`,
+	"interval":  `INTERVAL`,
+	"json":      `JSONB`,
+	"uuid":      `UUID`,
+	"enum":      `VARCHAR({{.Size}}) CHECK ({{.Name}} IN ({{.Values}}))`,
+}
+
 var template = &exql.Template{
 	ColumnSeparator:     adapterColumnSeparator,
 	IdentifierSeparator: adapterIdentifierSeparator,
@@ -193,18 +269,45 @@ var template = &exql.Template{
 	ColumnAliasLayout:   adapterColumnAliasLayout,
 	SortByColumnLayout:  adapterSortByColumnLayout,
 	WhereLayout:         adapterWhereLayout,
+	WithLayout:          adapterWithLayout,
+	OnConflictLayout:    adapterOnConflictLayout,
+	ExcludedLayout:      adapterExcludedLayout,
+	DistinctOnLayout:    adapterDistinctOnLayout,
 	JoinLayout:          adapterJoinLayout,
+	LockLayout:          adapterLockLayout,
+	DeclareCursorLayout: adapterDeclareCursorLayout,
+	FetchCursorLayout:   adapterFetchCursorLayout,
+	CloseCursorLayout:   adapterCloseCursorLayout,
 	OnLayout:            adapterOnLayout,
 	UsingLayout:         adapterUsingLayout,
+	ValuesListLayout:    adapterValuesListLayout,
 	OrderByLayout:       adapterOrderByLayout,
+	MatchLayout:         adapterMatchLayout,
+	RankLayout:          adapterRankLayout,
 	InsertLayout:        adapterInsertLayout,
 	SelectLayout:        adapterSelectLayout,
 	UpdateLayout:        adapterUpdateLayout,
+	UpdateFromLayout:    adapterUpdateFromLayout,
 	DeleteLayout:        adapterDeleteLayout,
+	DeleteUsingLayout:   adapterDeleteUsingLayout,
 	TruncateLayout:      adapterTruncateLayout,
+	TupleInLayout:       adapterTupleInLayout,
 	DropDatabaseLayout:  adapterDropDatabaseLayout,
 	DropTableLayout:     adapterDropTableLayout,
 	CountLayout:         adapterSelectCountLayout,
 	GroupByLayout:       adapterGroupByLayout,
-	Cache:               cache.NewCache(),
+	RollupLayout:        adapterRollupLayout,
+	CubeLayout:          adapterCubeLayout,
+	GroupingSetsLayout:  adapterGroupingSetsLayout,
+
+	CreateTableLayout:      adapterCreateTableLayout,
+	CreateIndexLayout:      adapterCreateIndexLayout,
+	AddColumnLayout:        adapterAddColumnLayout,
+	DropColumnLayout:       adapterDropColumnLayout,
+	RenameColumnLayout:     adapterRenameColumnLayout,
+	ColumnDefinitionLayout: adapterColumnDefinitionLayout,
+	ColumnTypes:            adapterColumnTypes,
+	TrueKeyword:            adapterTrueKeyword,
+	FalseKeyword:           adapterFalseKeyword,
+	Cache:                  cache.NewCache(),
 }
@@ -145,6 +145,31 @@ func TestTemplateSelect(t *testing.T) {
 		`SELECT DATE()`,
 		b.Select(db.Raw("DATE()")).String(),
 	)
+
+	assert.Equal(
+		`SELECT DISTINCT ON ("artist_id") "artist_id", "title" FROM "publication" ORDER BY "artist_id" ASC, "id" DESC`,
+		b.Select("artist_id", "title").DistinctOn("artist_id").From("publication").OrderBy("artist_id", "-id").String(),
+	)
+
+	assert.Equal(
+		`SELECT "artist_id", COUNT(*) FROM "publication" GROUP BY GROUPING SETS (("artist_id"), ())`,
+		b.Select("artist_id", db.Raw("COUNT(*)")).From("publication").GroupBy(db.GroupingSets([]interface{}{"artist_id"}, []interface{}{})).String(),
+	)
+
+	{
+		q := b.SelectFrom("artist").Where(db.Cond{
+			"data @>":         JSONB{map[string]interface{}{"status": "active"}},
+			"data->'tags' @>": JSONB{[]string{"rock"}},
+		})
+		assert.Equal(
+			`SELECT * FROM "artist" WHERE ("data" @> $1 AND "data"->'tags' @> $2)`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{JSONB{map[string]interface{}{"status": "active"}}, JSONB{[]string{"rock"}}},
+			q.Arguments(),
+		)
+	}
 }
 
 func TestTemplateInsert(t *testing.T) {
@@ -187,6 +212,18 @@ func TestTemplateInsert(t *testing.T) {
 		`INSERT INTO "artist" ("name", "id") VALUES ($1, $2)`,
 		b.InsertInto("artist").Columns("name", "id").Values("Chavela Vargas", 12).String(),
 	)
+
+	assert.Equal(
+		`INSERT INTO "artist" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO NOTHING`,
+		b.InsertInto("artist").Columns("id", "name").Values(12, "Chavela Vargas").OnConflict("id").DoNothing().String(),
+	)
+
+	assert.Equal(
+		`INSERT INTO "artist" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name" WHERE ("artist"."name" != $3)`,
+		b.InsertInto("artist").Columns("id", "name").Values(12, "Chavela Vargas").
+			OnConflict("id").DoUpdate(map[string]interface{}{"name": sqlbuilder.Excluded("name")}).
+			DoUpdateWhere(db.Cond{"artist.name !=": "Alondra de la Parra"}).String(),
+	)
 }
 
 func TestTemplateUpdate(t *testing.T) {
@@ -203,6 +240,16 @@ func TestTemplateUpdate(t *testing.T) {
 		b.Update("artist").Set("name = ?", "Artist").Where("id <", 5).String(),
 	)
 
+	assert.Equal(
+		`UPDATE "artist" SET "name" = $1 WHERE ("id" < $2) RETURNING "id", "name"`,
+		b.Update("artist").Set("name = ?", "Artist").Where("id <", 5).Returning("id", "name").String(),
+	)
+
+	assert.Equal(
+		`UPDATE "artist" SET "name" = $1 WHERE ("id" = $2) RETURNING *`,
+		b.Update("artist").Set("name = ?", "Artist").Where("id", 5).Returning("*").String(),
+	)
+
 	assert.Equal(
 		`UPDATE "artist" SET "name" = $1 WHERE ("id" < $2)`,
 		b.Update("artist").Set(map[string]string{"name": "Artist"}).Where(db.Cond{"id <": 5}).String(),
@@ -244,4 +291,64 @@ func TestTemplateDelete(t *testing.T) {
 		`DELETE FROM "artist" WHERE (id > 5)`,
 		b.DeleteFrom("artist").Where("id > 5").String(),
 	)
+
+	assert.Equal(
+		`DELETE FROM "artist" WHERE (id > 5) RETURNING "id"`,
+		b.DeleteFrom("artist").Where("id > 5").Returning("id").String(),
+	)
+}
+
+func TestTemplateMatch(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	q := b.Select("id", db.Rank("body", "quick fox").As("score")).
+		From("docs").
+		Where(db.Match("body", "quick fox")).
+		OrderBy(db.Rank("body", "quick fox"))
+	assert.Equal(
+		`SELECT "id", ts_rank(to_tsvector("body"), plainto_tsquery($1)) AS "score" FROM "docs" WHERE (to_tsvector("body") @@ plainto_tsquery($2)) ORDER BY ts_rank(to_tsvector("body"), plainto_tsquery($3)) DESC`,
+		q.String(),
+	)
+	assert.Equal([]interface{}{"quick fox", "quick fox", "quick fox"}, q.Arguments())
+}
+
+func TestTemplateDDL(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		`CREATE TABLE "books" ("id" BIGSERIAL PRIMARY KEY, "title" VARCHAR(255) NOT NULL, "body" TEXT, "public" BOOLEAN NOT NULL DEFAULT TRUE, "created_at" TIMESTAMP WITH TIME ZONE)`,
+		b.CreateTable("books").
+			Columns(
+				sqlbuilder.Column{Name: "id", Type: sqlbuilder.BigInt, AutoIncrement: true},
+				sqlbuilder.Column{Name: "title", Type: sqlbuilder.String, NotNull: true},
+				sqlbuilder.Column{Name: "body", Type: sqlbuilder.Text},
+				sqlbuilder.Column{Name: "public", Type: sqlbuilder.Boolean, NotNull: true, Default: true},
+				sqlbuilder.Column{Name: "created_at", Type: sqlbuilder.Timestamp},
+			).
+			PrimaryKey("id").
+			String(),
+	)
+
+	assert.Equal(
+		`CREATE TABLE "tags" ("book_id" BIGINT NOT NULL, "tag" VARCHAR(32) NOT NULL, PRIMARY KEY ("book_id", "tag"))`,
+		b.CreateTable("tags").
+			Columns(
+				sqlbuilder.Column{Name: "book_id", Type: sqlbuilder.BigInt, NotNull: true},
+				sqlbuilder.Column{Name: "tag", Type: sqlbuilder.String, Size: 32, NotNull: true},
+			).
+			PrimaryKey("book_id", "tag").
+			String(),
+	)
+
+	assert.Equal(
+		`CREATE UNIQUE INDEX "books_title_idx" ON "books" ("title")`,
+		b.CreateIndex("books_title_idx").Unique().On("books", "title").String(),
+	)
+
+	assert.Equal(
+		`ALTER TABLE "books" ADD COLUMN "isbn" VARCHAR(13); ALTER TABLE "books" DROP COLUMN "body"`,
+		b.AlterTable("books").AddColumn(sqlbuilder.Column{Name: "isbn", Type: sqlbuilder.String, Size: 13}).DropColumn("body").String(),
+	)
 }
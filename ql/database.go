@@ -30,6 +30,7 @@ import (
 	"errors"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	_ "github.com/cznic/ql/driver" // QL driver
 	"upper.io/db.v3"
@@ -216,7 +217,7 @@ func (d *database) CompileStatement(stmt *exql.Statement, args []interface{}) (s
 		panic(err.Error())
 	}
 	query, args := sqlbuilder.Preprocess(compiled, args)
-	return sqladapter.ReplaceWithDollarSign(query), args
+	return sqladapter.ReplaceWithDollarSign(query), sqladapter.DecimalsToRats(args)
 }
 
 // Err allows sqladapter to translate some known errors into generic errors.
@@ -325,3 +326,19 @@ func (d *database) WithContext(ctx context.Context) sqlbuilder.Database {
 	newDB, _ := d.clone(ctx, false)
 	return newDB
 }
+
+// WithSchema is not supported by QL.
+func (d *database) WithSchema(schema string) (sqlbuilder.Database, error) {
+	return nil, db.ErrUnsupported
+}
+
+// WithRecordCache returns a copy of the session whose primary key lookups,
+// like col.Find(id).One(&item), read items from the given store.
+func (d *database) WithRecordCache(store db.QueryCache, ttl time.Duration) (sqlbuilder.Database, error) {
+	clone, err := d.clone(d.Context(), false)
+	if err != nil {
+		return nil, err
+	}
+	clone.SetRecordCache(store, ttl)
+	return clone, nil
+}
@@ -171,6 +171,34 @@ const (
   `
 )
 
+const (
+	adapterCreateTableLayout      = `CREATE TABLE {{if .IfNotExists}}IF NOT EXISTS {{end}}{{.Table}} ({{.Definitions}})`
+	adapterCreateIndexLayout      = `CREATE {{if .Unique}}UNIQUE {{end}}INDEX {{.Index}} ON {{.Table}} ({{.Columns}})`
+	adapterAddColumnLayout        = `ALTER TABLE {{.Table}} ADD {{.Column}}`
+	adapterDropColumnLayout       = `ALTER TABLE {{.Table}} DROP COLUMN {{.Column}}`
+	adapterColumnDefinitionLayout = `{{.Name}} {{.Type}}{{if .NotNull}} NOT NULL{{end}}{{if .Default}} DEFAULT {{.Default}}{{end}}`
+	adapterTrueKeyword            = `true`
+	adapterFalseKeyword           = `false`
+)
+
+// QL has no primary keys, unique columns or auto incremented values, rows
+// are identified by id(), and columns can't be renamed.
+var adapterColumnTypes = map[string]string{
+	"boolean":   `bool`,
+	"smallint":  `int16`,
+	"integer":   `int32`,
+	"bigint":    `int64`,
+	"float":     `float64`,
+	"decimal":   `bigrat`,
+	"string":    `string`,
+	"text":      `string`,
+	"bytes":     `blob`,
+	"date":      `time`,
+	"timestamp": `time`,
+	"json":      `string`,
+	"uuid":      `string`,
+}
+
 var template = &exql.Template{
 	ColumnSeparator:     adapterColumnSeparator,
 	IdentifierSeparator: adapterIdentifierSeparator,
@@ -204,5 +232,14 @@ var template = &exql.Template{
 	DropTableLayout:     adapterDropTableLayout,
 	CountLayout:         adapterSelectCountLayout,
 	GroupByLayout:       adapterGroupByLayout,
-	Cache:               cache.NewCache(),
+
+	CreateTableLayout:      adapterCreateTableLayout,
+	CreateIndexLayout:      adapterCreateIndexLayout,
+	AddColumnLayout:        adapterAddColumnLayout,
+	DropColumnLayout:       adapterDropColumnLayout,
+	ColumnDefinitionLayout: adapterColumnDefinitionLayout,
+	ColumnTypes:            adapterColumnTypes,
+	TrueKeyword:            adapterTrueKeyword,
+	FalseKeyword:           adapterFalseKeyword,
+	Cache:                  cache.NewCache(),
 }
@@ -0,0 +1,129 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// QueryCache stores the results of the queries marked with Cache, like
+// sess.SelectFrom("books").Cache(time.Minute).All(&books), see
+// Settings.SetQueryCache. Values are opaque and keys are namespaced with the
+// "upper:" prefix, so stores can be shared with other data and among processes,
+// like a Redis database that every instance of an application uses.
+//
+// Stores are best-effort: errors are expected to be handled (or ignored) by
+// the store, a failed Get is a cache miss.
+type QueryCache interface {
+	// Get returns the value stored under key, if any and not expired.
+	Get(key string) ([]byte, bool)
+
+	// Set stores value under key for the given time, zero means no
+	// expiration.
+	Set(key string, value []byte, ttl time.Duration)
+
+	// Delete removes the value stored under key, if any.
+	Delete(key string)
+}
+
+type memoryQueryCacheEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+type memoryQueryCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	lru      *list.List
+}
+
+// NewMemoryQueryCache returns an in-memory QueryCache that holds up to
+// capacity values, the least recently used ones are evicted when the limit is
+// exceeded.
+func NewMemoryQueryCache(capacity int) QueryCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &memoryQueryCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (c *memoryQueryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := e.Value.(*memoryQueryCacheEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.remove(e)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(e)
+	return entry.value, true
+}
+
+func (c *memoryQueryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &memoryQueryCacheEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.capacity {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *memoryQueryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+}
+
+func (c *memoryQueryCache) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.entries, e.Value.(*memoryQueryCacheEntry).key)
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryQueryCache(t *testing.T) {
+	cache := NewMemoryQueryCache(2)
+
+	cache.Set("a", []byte("1"), 0)
+	cache.Set("b", []byte("2"), 0)
+
+	if v, ok := cache.Get("a"); !ok || string(v) != "1" {
+		t.Fatalf("Got: %q, %v, Expecting: %q", v, ok, "1")
+	}
+
+	// "b" is the least recently used value.
+	cache.Set("c", []byte("3"), 0)
+	if _, ok := cache.Get("b"); ok {
+		t.Fatal("Expecting b to be evicted")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("Expecting a to be kept")
+	}
+
+	cache.Delete("a")
+	if _, ok := cache.Get("a"); ok {
+		t.Fatal("Expecting a to be deleted")
+	}
+
+	cache.Set("d", []byte("4"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := cache.Get("d"); ok {
+		t.Fatal("Expecting d to be expired")
+	}
+}
+
+func TestQueryCacheSettings(t *testing.T) {
+	s := NewSettings()
+	if s.QueryCache() != nil {
+		t.Fatal("Expecting no query cache by default")
+	}
+
+	cache := NewMemoryQueryCache(10)
+	s.SetQueryCache(cache)
+	if s.QueryCache() != cache {
+		t.Fatal("Expecting the query cache to be set")
+	}
+}
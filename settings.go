@@ -34,11 +34,24 @@ type Settings interface {
 	// LoggingEnabled returns true if logging is enabled, false otherwise.
 	LoggingEnabled() bool
 
-	// SetLogger defines which logger to use.
+	// SetLogger defines which logger to use. Loggers that implement QueryLogger
+	// also receive the context the query was executed with.
 	SetLogger(Logger)
 	// Returns the currently configured logger.
 	Logger() Logger
 
+	// SetPreparedStatements enables or disables statement preparation. When
+	// disabled, queries are always sent along with their arguments, the
+	// prepared statement cache is not used and Prepare returns
+	// ErrPreparedStatementsDisabled. This is required by connection poolers
+	// that don't keep prepared statements across transactions, like PgBouncer
+	// in transaction mode, which also requires the binary_parameters=yes option
+	// on PostgreSQL.
+	SetPreparedStatements(bool)
+	// PreparedStatementsEnabled returns true if statement preparation is
+	// enabled, false otherwise.
+	PreparedStatementsEnabled() bool
+
 	// SetPreparedStatementCache enables or disables the prepared statement
 	// cache.
 	SetPreparedStatementCache(bool)
@@ -46,6 +59,14 @@ type Settings interface {
 	// is enabled, false otherwise.
 	PreparedStatementCacheEnabled() bool
 
+	// SetPreparedStatementCacheSize sets the maximum number of prepared
+	// statements the cache can hold, least recently used statements are closed
+	// and evicted when the limit is exceeded. Non-positive values are ignored.
+	SetPreparedStatementCacheSize(int)
+	// PreparedStatementCacheSize returns the maximum number of prepared
+	// statements the cache can hold.
+	PreparedStatementCacheSize() int
+
 	// SetConnMaxLifetime sets the default maximum amount of time a connection
 	// may be reused.
 	SetConnMaxLifetime(time.Duration)
@@ -69,16 +90,290 @@ type Settings interface {
 	// MaxOpenConns returns the default maximum number of open connections to the
 	// database.
 	MaxOpenConns() int
+
+	// SetAutoTimestamps enables or disables automatic timestamps, see
+	// AutoTimestampsEnabled.
+	SetAutoTimestamps(bool)
+	// AutoTimestampsEnabled returns true if fields with the "auto" option (like
+	// `db:"updated_at,auto"`) are set to the current time when items are
+	// inserted or updated, true by default.
+	AutoTimestampsEnabled() bool
+
+	// SetQueryStats enables or disables the collection of query statistics,
+	// see QueryStatsEnabled.
+	SetQueryStats(bool)
+	// QueryStatsEnabled returns true if the statements the session executes
+	// are grouped by fingerprint and timed, see Stats. False by default.
+	QueryStatsEnabled() bool
+
+	// SetStrictIdentifiers enables or disables strict identifiers, see
+	// StrictIdentifiersEnabled.
+	SetStrictIdentifiers(bool)
+	// StrictIdentifiersEnabled returns true if table and column names given as
+	// strings to the query builder, like the keys of db.Cond, are rejected
+	// unless they are plain identifiers like "name" or "schema.table". Names
+	// given with db.Ident are always accepted. False by default.
+	StrictIdentifiersEnabled() bool
+
+	// SetTxRetryPolicy sets the policy that is used to retry transactions that
+	// fail because of serialization failures or deadlocks, a nil policy
+	// disables retries.
+	SetTxRetryPolicy(*TxRetryPolicy)
+
+	// TxRetryPolicy returns the transaction retry policy, if any.
+	TxRetryPolicy() *TxRetryPolicy
+
+	// SetSlowQueryThreshold makes the statements that take longer than the
+	// given duration call the handler with their SQL, arguments, duration and
+	// call stack. A nil handler prints them with the standard logger, a zero
+	// duration disables it.
+	SetSlowQueryThreshold(time.Duration, SlowQueryHandler)
+
+	// SlowQueryThreshold returns the slow query threshold and its handler.
+	SlowQueryThreshold() (time.Duration, SlowQueryHandler)
+
+	// SetNamingStrategy sets the strategy that maps struct fields without a `db`
+	// tag to column names, a nil strategy leaves such fields unmapped.
+	SetNamingStrategy(NamingStrategy)
+
+	// NamingStrategy returns the strategy that maps struct fields without a
+	// `db` tag to column names, nil by default.
+	NamingStrategy() NamingStrategy
+
+	// SetReplicaPolicy sets how reads are balanced among the read replicas of
+	// the session, if any.
+	SetReplicaPolicy(ReplicaPolicy)
+
+	// ReplicaPolicy returns how reads are balanced among the read replicas of
+	// the session, RoundRobin by default.
+	ReplicaPolicy() ReplicaPolicy
+
+	// SetHealthCheckPolicy sets the policy of the background health checker of
+	// the session, a nil policy disables the health checker.
+	SetHealthCheckPolicy(*HealthCheckPolicy)
+
+	// HealthCheckPolicy returns the policy of the background health checker,
+	// if any.
+	HealthCheckPolicy() *HealthCheckPolicy
+
+	// SetRetryPolicy sets the policy that is used to retry SELECT statements
+	// that fail because of transient errors, a nil policy disables retries.
+	SetRetryPolicy(*RetryPolicy)
+
+	// RetryPolicy returns the statement retry policy, if any.
+	RetryPolicy() *RetryPolicy
+
+	// SetQueryCache sets the store where the results of queries marked with
+	// Cache are kept, a nil store disables caching.
+	SetQueryCache(QueryCache)
+
+	// QueryCache returns the query cache store, if any.
+	QueryCache() QueryCache
+
+	// SetCodec registers the codec that fields tagged with codec=name are
+	// encoded and decoded with, a nil codec removes it.
+	SetCodec(name string, codec Codec)
+
+	// Codec returns the codec registered under the given name, if any.
+	Codec(name string) Codec
+
+	// Codecs returns all the registered codecs by name.
+	Codecs() map[string]Codec
+
+	// SetTablePrefix sets the prefix that is added to the names of
+	// collections to get the names of their tables, like "wp_" for the
+	// "posts" collection to be the "wp_posts" table.
+	SetTablePrefix(prefix string)
+
+	// TablePrefix returns the prefix that is added to the names of
+	// collections, if any.
+	TablePrefix() string
+
+	// SetCollectionTablePrefix sets the prefix that is added to the name of
+	// the given collection instead of the one set with SetTablePrefix, an
+	// empty prefix leaves the name of the collection as is. Tables shared by
+	// many applications can be used this way:
+	//
+	//	sess.SetTablePrefix("wp_2_")
+	//	sess.SetCollectionTablePrefix("users", "wp_")
+	//
+	//	sess.Collection("posts") // wp_2_posts
+	//	sess.Collection("users") // wp_users
+	SetCollectionTablePrefix(collection string, prefix string)
+
+	// CollectionTablePrefixes returns the prefixes set with
+	// SetCollectionTablePrefix by collection.
+	CollectionTablePrefixes() map[string]string
+
+	// TableName returns the name of the table of the given collection, with
+	// its prefix.
+	TableName(collection string) string
+}
+
+// ReplicaPolicy defines which read replica a query is sent to when a session
+// has more than one.
+type ReplicaPolicy uint8
+
+// Replica policies.
+const (
+	// RoundRobin sends every query to the next replica in turn.
+	RoundRobin ReplicaPolicy = iota
+	// LeastConns sends every query to the replica with the fewest connections
+	// in use.
+	LeastConns
+)
+
+// TxRetryPolicy defines how transactions run by Tx() are retried when they
+// fail because of a serialization failure, a deadlock or, on MySQL, a lock
+// wait timeout (see ErrSerializationFailure). Retrying means running the whole transaction
+// function again, so it must be safe to do so.
+//
+//   sess.SetTxRetryPolicy(&db.TxRetryPolicy{
+//     MaxAttempts:    5,
+//     InitialBackoff: 10 * time.Millisecond,
+//     MaxBackoff:     time.Second,
+//   })
+type TxRetryPolicy struct {
+	// MaxAttempts is the maximum number of times the transaction function is
+	// run, including the first attempt.
+	MaxAttempts int
+
+	// InitialBackoff is the time to wait before the first retry, it's doubled
+	// on every subsequent retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum time to wait between retries, zero means no
+	// limit.
+	MaxBackoff time.Duration
+}
+
+// Backoff returns the time to wait before the given retry (starting at 1).
+func (p *TxRetryPolicy) Backoff(retry int) time.Duration {
+	return exponentialBackoff(p.InitialBackoff, p.MaxBackoff, retry)
+}
+
+// RetryPolicy defines how SELECT statements that fail because of a transient
+// error, like a connection reset by the server, are retried. Only statements
+// that run outside of transactions are retried, as they're safe to run again.
+//
+//   sess.SetRetryPolicy(&db.RetryPolicy{
+//     MaxAttempts:    3,
+//     InitialBackoff: 50 * time.Millisecond,
+//   })
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a statement is run, including
+	// the first attempt.
+	MaxAttempts int
+
+	// InitialBackoff is the time to wait before the first retry, it's doubled
+	// on every subsequent retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum time to wait between retries, zero means no
+	// limit.
+	MaxBackoff time.Duration
+
+	// Retryable reports whether a statement that failed with the given error
+	// can be retried, IsTransientError is used if nil.
+	Retryable func(err error) bool
+}
+
+// Backoff returns the time to wait before the given retry (starting at 1).
+func (p *RetryPolicy) Backoff(retry int) time.Duration {
+	return exponentialBackoff(p.InitialBackoff, p.MaxBackoff, retry)
+}
+
+// IsRetryable returns true if a statement that failed with err can be
+// retried.
+func (p *RetryPolicy) IsRetryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsTransientError(err)
+}
+
+// HealthCheckPolicy defines how often a session checks that the database
+// server is reachable and how it recovers when it's not. After a failed check
+// the idle connections of the session are dropped, so new ones are opened
+// once the server (or the one that replaced it) is back, and checks are
+// retried with an exponential backoff until one succeeds.
+//
+//   sess.SetHealthCheckPolicy(&db.HealthCheckPolicy{
+//     Interval:       30 * time.Second,
+//     Timeout:        5 * time.Second,
+//     InitialBackoff: time.Second,
+//     MaxBackoff:     30 * time.Second,
+//   })
+type HealthCheckPolicy struct {
+	// Interval is the time between health checks while the server is
+	// reachable.
+	Interval time.Duration
+
+	// Timeout is the maximum time a health check can take, zero means no
+	// limit.
+	Timeout time.Duration
+
+	// InitialBackoff is the time to wait before the first check that follows
+	// a failed one, it's doubled on every subsequent failure.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum time to wait between failed checks, zero means
+	// no limit.
+	MaxBackoff time.Duration
+
+	// OnStateChange, if set, is called with the error of the first check that
+	// fails and with nil when a check succeeds after that.
+	OnStateChange func(err error)
+}
+
+// Backoff returns the time to wait after the given number of consecutive
+// failed checks (starting at 1).
+func (p *HealthCheckPolicy) Backoff(failures int) time.Duration {
+	return exponentialBackoff(p.InitialBackoff, p.MaxBackoff, failures)
+}
+
+func exponentialBackoff(initial time.Duration, max time.Duration, retry int) time.Duration {
+	backoff := initial
+	for i := 1; i < retry; i++ {
+		backoff = backoff * 2
+		if max > 0 && backoff >= max {
+			break
+		}
+	}
+	if max > 0 && backoff > max {
+		return max
+	}
+	return backoff
 }
 
 type settings struct {
 	sync.RWMutex
 
+	preparedStatementsEnabled     uint32
 	preparedStatementCacheEnabled uint32
+	autoTimestampsEnabled         uint32
+	strictIdentifiersEnabled      uint32
+	queryStatsEnabled             uint32
+
+	preparedStatementCacheSize int
 
 	connMaxLifetime time.Duration
 	maxOpenConns    int
 	maxIdleConns    int
+	txRetryPolicy   *TxRetryPolicy
+	namingStrategy  NamingStrategy
+	replicaPolicy   ReplicaPolicy
+
+	slowQueryThreshold time.Duration
+	slowQueryHandler   SlowQueryHandler
+
+	healthCheckPolicy *HealthCheckPolicy
+	retryPolicy       *RetryPolicy
+	queryCache        QueryCache
+	codecs            map[string]Codec
+
+	tablePrefix        string
+	collectionPrefixes map[string]string
 
 	loggingEnabled uint32
 	queryLogger    Logger
@@ -127,6 +422,14 @@ func (c *settings) LoggingEnabled() bool {
 	return c.binaryOption(&c.loggingEnabled)
 }
 
+func (c *settings) SetPreparedStatements(value bool) {
+	c.setBinaryOption(&c.preparedStatementsEnabled, value)
+}
+
+func (c *settings) PreparedStatementsEnabled() bool {
+	return c.binaryOption(&c.preparedStatementsEnabled)
+}
+
 func (c *settings) SetPreparedStatementCache(value bool) {
 	c.setBinaryOption(&c.preparedStatementCacheEnabled, value)
 }
@@ -135,6 +438,45 @@ func (c *settings) PreparedStatementCacheEnabled() bool {
 	return c.binaryOption(&c.preparedStatementCacheEnabled)
 }
 
+func (c *settings) SetPreparedStatementCacheSize(n int) {
+	if n < 1 {
+		return
+	}
+	c.Lock()
+	c.preparedStatementCacheSize = n
+	c.Unlock()
+}
+
+func (c *settings) PreparedStatementCacheSize() int {
+	c.RLock()
+	defer c.RUnlock()
+	return c.preparedStatementCacheSize
+}
+
+func (c *settings) SetAutoTimestamps(value bool) {
+	c.setBinaryOption(&c.autoTimestampsEnabled, value)
+}
+
+func (c *settings) AutoTimestampsEnabled() bool {
+	return c.binaryOption(&c.autoTimestampsEnabled)
+}
+
+func (c *settings) SetStrictIdentifiers(value bool) {
+	c.setBinaryOption(&c.strictIdentifiersEnabled, value)
+}
+
+func (c *settings) StrictIdentifiersEnabled() bool {
+	return c.binaryOption(&c.strictIdentifiersEnabled)
+}
+
+func (c *settings) SetQueryStats(value bool) {
+	c.setBinaryOption(&c.queryStatsEnabled, value)
+}
+
+func (c *settings) QueryStatsEnabled() bool {
+	return c.binaryOption(&c.queryStatsEnabled)
+}
+
 func (c *settings) SetConnMaxLifetime(t time.Duration) {
 	c.Lock()
 	c.connMaxLifetime = t
@@ -171,6 +513,169 @@ func (c *settings) MaxOpenConns() int {
 	return c.maxOpenConns
 }
 
+func (c *settings) SetTxRetryPolicy(policy *TxRetryPolicy) {
+	c.Lock()
+	c.txRetryPolicy = policy
+	c.Unlock()
+}
+
+func (c *settings) TxRetryPolicy() *TxRetryPolicy {
+	c.RLock()
+	defer c.RUnlock()
+	return c.txRetryPolicy
+}
+
+func (c *settings) SetSlowQueryThreshold(threshold time.Duration, handler SlowQueryHandler) {
+	if handler == nil {
+		handler = logSlowQuery
+	}
+	c.Lock()
+	c.slowQueryThreshold = threshold
+	c.slowQueryHandler = handler
+	c.Unlock()
+}
+
+func (c *settings) SlowQueryThreshold() (time.Duration, SlowQueryHandler) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.slowQueryThreshold, c.slowQueryHandler
+}
+
+func (c *settings) SetNamingStrategy(strategy NamingStrategy) {
+	c.Lock()
+	c.namingStrategy = strategy
+	c.Unlock()
+}
+
+func (c *settings) NamingStrategy() NamingStrategy {
+	c.RLock()
+	defer c.RUnlock()
+	return c.namingStrategy
+}
+
+func (c *settings) SetReplicaPolicy(policy ReplicaPolicy) {
+	c.Lock()
+	c.replicaPolicy = policy
+	c.Unlock()
+}
+
+func (c *settings) ReplicaPolicy() ReplicaPolicy {
+	c.RLock()
+	defer c.RUnlock()
+	return c.replicaPolicy
+}
+
+func (c *settings) SetHealthCheckPolicy(policy *HealthCheckPolicy) {
+	c.Lock()
+	c.healthCheckPolicy = policy
+	c.Unlock()
+}
+
+func (c *settings) HealthCheckPolicy() *HealthCheckPolicy {
+	c.RLock()
+	defer c.RUnlock()
+	return c.healthCheckPolicy
+}
+
+func (c *settings) SetRetryPolicy(policy *RetryPolicy) {
+	c.Lock()
+	c.retryPolicy = policy
+	c.Unlock()
+}
+
+func (c *settings) RetryPolicy() *RetryPolicy {
+	c.RLock()
+	defer c.RUnlock()
+	return c.retryPolicy
+}
+
+func (c *settings) SetQueryCache(cache QueryCache) {
+	c.Lock()
+	c.queryCache = cache
+	c.Unlock()
+}
+
+func (c *settings) QueryCache() QueryCache {
+	c.RLock()
+	defer c.RUnlock()
+	return c.queryCache
+}
+
+func (c *settings) SetCodec(name string, codec Codec) {
+	c.Lock()
+	defer c.Unlock()
+	// Codecs are copied on write, since settings are copied by NewSettings.
+	codecs := make(map[string]Codec, len(c.codecs)+1)
+	for k, v := range c.codecs {
+		codecs[k] = v
+	}
+	if codec == nil {
+		delete(codecs, name)
+	} else {
+		codecs[name] = codec
+	}
+	c.codecs = codecs
+}
+
+func (c *settings) Codec(name string) Codec {
+	c.RLock()
+	defer c.RUnlock()
+	return c.codecs[name]
+}
+
+func (c *settings) Codecs() map[string]Codec {
+	c.RLock()
+	defer c.RUnlock()
+	codecs := make(map[string]Codec, len(c.codecs))
+	for k, v := range c.codecs {
+		codecs[k] = v
+	}
+	return codecs
+}
+
+func (c *settings) SetTablePrefix(prefix string) {
+	c.Lock()
+	defer c.Unlock()
+	c.tablePrefix = prefix
+}
+
+func (c *settings) TablePrefix() string {
+	c.RLock()
+	defer c.RUnlock()
+	return c.tablePrefix
+}
+
+func (c *settings) SetCollectionTablePrefix(collection string, prefix string) {
+	c.Lock()
+	defer c.Unlock()
+	// Prefixes are copied on write, since settings are copied by NewSettings.
+	prefixes := make(map[string]string, len(c.collectionPrefixes)+1)
+	for k, v := range c.collectionPrefixes {
+		prefixes[k] = v
+	}
+	prefixes[collection] = prefix
+	c.collectionPrefixes = prefixes
+}
+
+func (c *settings) CollectionTablePrefixes() map[string]string {
+	c.RLock()
+	defer c.RUnlock()
+	prefixes := make(map[string]string, len(c.collectionPrefixes))
+	for k, v := range c.collectionPrefixes {
+		prefixes[k] = v
+	}
+	return prefixes
+}
+
+func (c *settings) TableName(collection string) string {
+	c.RLock()
+	defer c.RUnlock()
+	if prefix, ok := c.collectionPrefixes[collection]; ok {
+		return prefix + collection
+	}
+	return c.tablePrefix + collection
+}
+
 // NewSettings returns a new settings value prefilled with the current default
 // settings.
 func NewSettings() Settings {
@@ -180,7 +685,10 @@ func NewSettings() Settings {
 
 // Settings provides global configuration settings for database sessions.
 var DefaultSettings Settings = &settings{
+	preparedStatementsEnabled:     1,
 	preparedStatementCacheEnabled: 0,
+	preparedStatementCacheSize:    128,
+	autoTimestampsEnabled:         1,
 	connMaxLifetime:               time.Duration(0),
 	maxIdleConns:                  10,
 	maxOpenConns:                  0,
@@ -0,0 +1,254 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestTxRetryPolicyBackoff(t *testing.T) {
+	policy := &TxRetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+	}
+
+	expected := []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		50 * time.Millisecond,
+		50 * time.Millisecond,
+	}
+
+	for i := range expected {
+		if backoff := policy.Backoff(i + 1); backoff != expected[i] {
+			t.Fatalf("Got: %v, Expecting: %v", backoff, expected[i])
+		}
+	}
+
+	policy.MaxBackoff = 0
+	if backoff := policy.Backoff(5); backoff != 160*time.Millisecond {
+		t.Fatalf("Got: %v, Expecting: %v", backoff, 160*time.Millisecond)
+	}
+}
+
+func TestTxRetryPolicySettings(t *testing.T) {
+	s := NewSettings()
+
+	if s.TxRetryPolicy() != nil {
+		t.Fatal("Expecting retries to be disabled by default")
+	}
+
+	policy := &TxRetryPolicy{MaxAttempts: 3}
+	s.SetTxRetryPolicy(policy)
+
+	if s.TxRetryPolicy() != policy {
+		t.Fatal("Expecting retry policy to be set")
+	}
+}
+
+func TestAutoTimestampsSettings(t *testing.T) {
+	s := NewSettings()
+
+	if !s.AutoTimestampsEnabled() {
+		t.Fatal("Expecting automatic timestamps to be enabled by default")
+	}
+
+	s.SetAutoTimestamps(false)
+	if s.AutoTimestampsEnabled() {
+		t.Fatal("Expecting automatic timestamps to be disabled")
+	}
+}
+
+func TestPreparedStatementCacheSizeSettings(t *testing.T) {
+	s := NewSettings()
+
+	if s.PreparedStatementCacheSize() != 128 {
+		t.Fatalf("Got: %v, Expecting: %v", s.PreparedStatementCacheSize(), 128)
+	}
+
+	s.SetPreparedStatementCacheSize(16)
+	if s.PreparedStatementCacheSize() != 16 {
+		t.Fatalf("Got: %v, Expecting: %v", s.PreparedStatementCacheSize(), 16)
+	}
+
+	s.SetPreparedStatementCacheSize(0)
+	if s.PreparedStatementCacheSize() != 16 {
+		t.Fatal("Expecting non-positive sizes to be ignored")
+	}
+}
+
+func TestPreparedStatementsSettings(t *testing.T) {
+	s := NewSettings()
+
+	if !s.PreparedStatementsEnabled() {
+		t.Fatal("Expecting prepared statements to be enabled by default")
+	}
+
+	s.SetPreparedStatements(false)
+	if s.PreparedStatementsEnabled() {
+		t.Fatal("Expecting prepared statements to be disabled")
+	}
+
+	if !NewSettings().PreparedStatementsEnabled() {
+		t.Fatal("Expecting the default settings to be left untouched")
+	}
+}
+
+func TestReplicaPolicySettings(t *testing.T) {
+	s := NewSettings()
+
+	if s.ReplicaPolicy() != RoundRobin {
+		t.Fatal("Expecting round-robin to be the default replica policy")
+	}
+
+	s.SetReplicaPolicy(LeastConns)
+	if s.ReplicaPolicy() != LeastConns {
+		t.Fatalf("Got: %v, Expecting: %v", s.ReplicaPolicy(), LeastConns)
+	}
+}
+
+func TestHealthCheckPolicyBackoff(t *testing.T) {
+	policy := &HealthCheckPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+	}
+
+	expected := []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		5 * time.Second,
+	}
+
+	for i := range expected {
+		if backoff := policy.Backoff(i + 1); backoff != expected[i] {
+			t.Fatalf("Got: %v, Expecting: %v", backoff, expected[i])
+		}
+	}
+
+	s := NewSettings()
+	if s.HealthCheckPolicy() != nil {
+		t.Fatal("Expecting health checks to be disabled by default")
+	}
+
+	s.SetHealthCheckPolicy(policy)
+	if s.HealthCheckPolicy() != policy {
+		t.Fatal("Expecting health check policy to be set")
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     15 * time.Millisecond,
+	}
+
+	if backoff := policy.Backoff(2); backoff != 15*time.Millisecond {
+		t.Fatalf("Got: %v, Expecting: %v", backoff, 15*time.Millisecond)
+	}
+
+	transient := []error{
+		driver.ErrBadConn,
+		io.EOF,
+		errors.New("read tcp 10.0.0.1:5432: read: connection reset by peer"),
+		errors.New("write: broken pipe"),
+	}
+	for _, err := range transient {
+		if !policy.IsRetryable(err) {
+			t.Fatalf("Expecting %q to be retryable", err)
+		}
+	}
+
+	for _, err := range []error{nil, ErrNoMoreRows, errors.New(`syntax error at or near "SELEC"`)} {
+		if policy.IsRetryable(err) {
+			t.Fatalf("Expecting %v not to be retryable", err)
+		}
+	}
+
+	policy.Retryable = func(err error) bool {
+		return err == ErrNoMoreRows
+	}
+	if !policy.IsRetryable(ErrNoMoreRows) || policy.IsRetryable(io.EOF) {
+		t.Fatal("Expecting the custom classifier to be used")
+	}
+
+	s := NewSettings()
+	if s.RetryPolicy() != nil {
+		t.Fatal("Expecting retries to be disabled by default")
+	}
+	s.SetRetryPolicy(policy)
+	if s.RetryPolicy() != policy {
+		t.Fatal("Expecting retry policy to be set")
+	}
+}
+
+type noopCodec struct{}
+
+func (noopCodec) Encode(value []byte) ([]byte, error) { return value, nil }
+
+func (noopCodec) Decode(value []byte) ([]byte, error) { return value, nil }
+
+func TestCodecSettings(t *testing.T) {
+	s := NewSettings()
+	if s.Codec("aes") != nil || len(s.Codecs()) != 0 {
+		t.Fatal("Expecting no codecs by default")
+	}
+
+	s.SetCodec("aes", noopCodec{})
+	if s.Codec("aes") == nil {
+		t.Fatal("Expecting codec to be registered")
+	}
+
+	codecs := s.Codecs()
+	s.SetCodec("aes", nil)
+	if s.Codec("aes") != nil {
+		t.Fatal("Expecting codec to be removed")
+	}
+	if codecs["aes"] == nil {
+		t.Fatal("Expecting codecs returned before to be kept")
+	}
+}
+
+func TestTablePrefixSettings(t *testing.T) {
+	s := NewSettings()
+	if s.TablePrefix() != "" || s.TableName("posts") != "posts" {
+		t.Fatal("Expecting no table prefix by default")
+	}
+
+	s.SetTablePrefix("wp_2_")
+	s.SetCollectionTablePrefix("users", "wp_")
+	s.SetCollectionTablePrefix("options", "")
+
+	for collection, table := range map[string]string{
+		"posts":   "wp_2_posts",
+		"users":   "wp_users",
+		"options": "options",
+	} {
+		if name := s.TableName(collection); name != table {
+			t.Fatalf("Got: %q, Expecting: %q", name, table)
+		}
+	}
+
+	c := NewSettings()
+	if c.TableName("users") != "users" {
+		t.Fatal("Expecting prefixes not to be shared among settings")
+	}
+}
+
+func TestSlowQueryThresholdSettings(t *testing.T) {
+	s := NewSettings()
+	if threshold, _ := s.SlowQueryThreshold(); threshold != 0 {
+		t.Fatal("Expecting the slow query threshold to be disabled by default")
+	}
+
+	s.SetSlowQueryThreshold(time.Second, nil)
+	threshold, handler := s.SlowQueryThreshold()
+	if threshold != time.Second || handler == nil {
+		t.Fatal("Expecting a default slow query handler")
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package spanner
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+
+	sp "cloud.google.com/go/spanner"
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter"
+	"upper.io/db.v3/internal/sqladapter/exql"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// table is the actual implementation of a collection.
+type table struct {
+	sqladapter.BaseCollection // Leveraged by sqladapter
+
+	d    *database
+	name string
+}
+
+var (
+	_ = sqladapter.Collection(&table{})
+	_ = db.Collection(&table{})
+)
+
+// newTable binds *table with sqladapter.
+func newTable(d *database, name string) *table {
+	t := &table{
+		name: name,
+		d:    d,
+	}
+	t.BaseCollection = sqladapter.NewBaseCollection(t)
+	return t
+}
+
+func (t *table) Name() string {
+	return t.name
+}
+
+func (t *table) Database() sqladapter.Database {
+	return t.d
+}
+
+// Insert inserts an item (map or struct) into the collection.
+func (t *table) Insert(item interface{}) (interface{}, error) {
+	return sqladapter.InsertWithHooks(t.d, item, t.insert)
+}
+
+func (t *table) insert(item interface{}) (interface{}, error) {
+	columnNames, columnValues, err := sqlbuilder.Map(item, &sqlbuilder.MapOptions{Mapper: t.d.Mapper(), Codec: t.d.Codec})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range t.commitTimestampColumns(item) {
+		columnNames, columnValues = setColumn(columnNames, columnValues, name, CommitTimestamp)
+	}
+
+	pKey := t.BaseCollection.PrimaryKeys()
+
+	if t.d.writeMode() == writeModeDML {
+		return t.insertDML(pKey, columnNames, columnValues)
+	}
+
+	names := make([]string, 0, len(columnNames))
+	values := make([]interface{}, 0, len(columnValues))
+	for i := range columnNames {
+		value, ok, err := mutationValue(columnValues[i])
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %v", columnNames[i], err)
+		}
+		if !ok {
+			// The column takes its default value.
+			continue
+		}
+		names = append(names, columnNames[i])
+		values = append(values, value)
+	}
+
+	if _, err := apply(t.d, []*sp.Mutation{sp.Insert(t.Name(), names, values)}); err != nil {
+		return nil, err
+	}
+
+	return keyValue(pKey, names, values), nil
+}
+
+// insertDML inserts a row with an INSERT statement, asking for the primary
+// key with THEN RETURN.
+func (t *table) insertDML(pKey []string, columnNames []string, columnValues []interface{}) (interface{}, error) {
+	q := t.d.InsertInto(t.Name()).
+		Columns(columnNames...).
+		Values(columnValues...)
+
+	if len(pKey) == 0 {
+		// There is no primary key.
+		var res sql.Result
+		var err error
+
+		if res, err = q.Exec(); err != nil {
+			return nil, err
+		}
+
+		lastID, _ := res.LastInsertId()
+		return lastID, nil
+	}
+
+	// Asking the database to return the primary key after insertion.
+	q = q.Returning(pKey...)
+
+	var keyMap db.Cond
+	if err := q.Iterator().One(&keyMap); err != nil {
+		return nil, err
+	}
+
+	if len(keyMap) == 1 {
+		return keyMap[pKey[0]], nil
+	}
+
+	// This was a compound key, let's return a map.
+	return keyMap, nil
+}
+
+// commitTimestampColumns returns the columns of the struct fields of item
+// that have the commit_timestamp option.
+func (t *table) commitTimestampColumns(item interface{}) []string {
+	itemT := reflect.TypeOf(item)
+	for itemT != nil && itemT.Kind() == reflect.Ptr {
+		itemT = itemT.Elem()
+	}
+	if itemT == nil || itemT.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var columns []string
+	for name, fi := range t.d.Mapper().TypeMap(itemT).Names {
+		if _, ok := fi.Options["commit_timestamp"]; ok {
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
+
+// setColumn sets the value of the given column, which is appended if it's not
+// part of the list.
+func setColumn(names []string, values []interface{}, name string, value interface{}) ([]string, []interface{}) {
+	for i := range names {
+		if names[i] == name {
+			values[i] = value
+			return names, values
+		}
+	}
+	return append(names, name), append(values, value)
+}
+
+// mutationValue converts a value given by sqlbuilder.Map into one mutations
+// accept, ok is false if the column must be left out to take its default
+// value.
+func mutationValue(v interface{}) (value interface{}, ok bool, err error) {
+	switch w := v.(type) {
+	case *exql.Raw:
+		if w.Value == "DEFAULT" {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("raw value %q can't be written with mutations", w.Value)
+	case db.RawValue:
+		if w.Raw() == CommitTimestamp.Raw() {
+			return sp.CommitTimestamp, true, nil
+		}
+		return nil, false, fmt.Errorf("raw value %q can't be written with mutations", w.Raw())
+	case driver.Valuer:
+		value, err := w.Value()
+		if err != nil {
+			return nil, false, err
+		}
+		return value, true, nil
+	}
+	return v, true, nil
+}
+
+// keyValue returns the value of the primary key of a written row, or a
+// db.Cond with its columns for compound keys.
+func keyValue(pKey []string, names []string, values []interface{}) interface{} {
+	keyMap := db.Cond{}
+	for i := range names {
+		for j := range pKey {
+			if pKey[j] == names[i] {
+				keyMap[pKey[j]] = values[i]
+			}
+		}
+	}
+
+	if len(pKey) == 1 {
+		return keyMap[pKey[0]]
+	}
+	if len(pKey) == 0 {
+		return nil
+	}
+	return keyMap
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package spanner
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const connectionScheme = `spanner`
+
+// ConnectionURL implements a Cloud Spanner connection struct.
+//
+// The following options are recognized:
+//
+//	credentials   path of a service account key file, the application
+//	              default credentials are used if not given. When the
+//	              SPANNER_EMULATOR_HOST environment variable is set the
+//	              client connects to the emulator instead.
+//	endpoint      address of the API, like "localhost:9010".
+//	write_mode    "mutations" (the default) makes Insert on collections
+//	              write with mutations, "dml" makes it use INSERT
+//	              statements. Statements of the builder always use DML.
+type ConnectionURL struct {
+	Project  string
+	Instance string
+	Database string
+	Options  map[string]string
+}
+
+func (c ConnectionURL) String() (s string) {
+	if c.Project == "" || c.Instance == "" || c.Database == "" {
+		return ""
+	}
+
+	vv := url.Values{}
+
+	// Converting options into URL values.
+	for k, v := range c.Options {
+		vv.Set(k, v)
+	}
+
+	// Building URL.
+	u := url.URL{
+		Scheme:   connectionScheme,
+		Host:     c.Project,
+		Path:     "/" + c.Instance + "/" + c.Database,
+		RawQuery: vv.Encode(),
+	}
+
+	return u.String()
+}
+
+// databasePath returns the resource name of the database, as the client
+// expects it.
+func (c ConnectionURL) databasePath() string {
+	return fmt.Sprintf("projects/%s/instances/%s/databases/%s", c.Project, c.Instance, c.Database)
+}
+
+// ParseURL parses s into a ConnectionURL struct.
+func ParseURL(s string) (conn ConnectionURL, err error) {
+	var u *url.URL
+
+	if !strings.HasPrefix(s, connectionScheme+"://") {
+		return conn, fmt.Errorf(`Expecting spanner:// connection scheme.`)
+	}
+
+	if u, err = url.Parse(s); err != nil {
+		return conn, err
+	}
+
+	conn.Project = u.Host
+
+	path := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(path) != 2 {
+		return conn, fmt.Errorf(`Expecting spanner://project/instance/database.`)
+	}
+	conn.Instance, conn.Database = path[0], path[1]
+
+	conn.Options = map[string]string{}
+
+	var vv url.Values
+
+	if vv, err = url.ParseQuery(u.RawQuery); err != nil {
+		return conn, err
+	}
+
+	for k := range vv {
+		conn.Options[k] = vv.Get(k)
+	}
+
+	return conn, err
+}
@@ -0,0 +1,65 @@
+package spanner
+
+import (
+	"testing"
+)
+
+func TestConnectionURL(t *testing.T) {
+	c := ConnectionURL{}
+
+	// Default connection string is empty.
+	if c.String() != "" {
+		t.Fatal(`Expecting default connection string to be empty, got:`, c.String())
+	}
+
+	c.Project = "my-project"
+	c.Instance = "my-instance"
+	c.Database = "music"
+
+	if c.String() != "spanner://my-project/my-instance/music" {
+		t.Fatal(`Test failed, got:`, c.String())
+	}
+
+	if c.databasePath() != "projects/my-project/instances/my-instance/databases/music" {
+		t.Fatal(`Test failed, got:`, c.databasePath())
+	}
+
+	c.Options = map[string]string{
+		"write_mode": "dml",
+	}
+
+	if c.String() != "spanner://my-project/my-instance/music?write_mode=dml" {
+		t.Fatal(`Test failed, got:`, c.String())
+	}
+}
+
+func TestParseConnectionURL(t *testing.T) {
+	u, err := ParseURL("spanner://my-project/my-instance/music?credentials=none&endpoint=localhost:9010")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Project != "my-project" {
+		t.Fatal("Failed to parse project.")
+	}
+
+	if u.Instance != "my-instance" {
+		t.Fatal("Failed to parse instance.")
+	}
+
+	if u.Database != "music" {
+		t.Fatal("Failed to parse database.")
+	}
+
+	if u.Options["credentials"] != "none" || u.Options["endpoint"] != "localhost:9010" {
+		t.Fatal("Expecting options.")
+	}
+
+	if _, err = ParseURL("spanner://my-project/music"); err == nil {
+		t.Fatal("Expecting error.")
+	}
+
+	if _, err = ParseURL("http://example.org"); err == nil {
+		t.Fatal("Expecting error.")
+	}
+}
@@ -0,0 +1,387 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package spanner
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	sp "cloud.google.com/go/spanner"
+	"google.golang.org/grpc/codes"
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter"
+	"upper.io/db.v3/internal/sqladapter/compat"
+	"upper.io/db.v3/internal/sqladapter/exql"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+const (
+	writeModeMutations = `mutations`
+	writeModeDML       = `dml`
+)
+
+// Mutator is implemented by the sessions and transactions of this adapter.
+type Mutator interface {
+	// Apply writes the given mutations and returns the commit timestamp.
+	// Within a transaction mutations are buffered and committed along with
+	// it, the returned timestamp is zero in that case.
+	Apply(ms ...*sp.Mutation) (commitTimestamp time.Time, err error)
+}
+
+// database is the actual implementation of Database
+type database struct {
+	sqladapter.BaseDatabase
+
+	sqlbuilder.SQLBuilder
+
+	connURL db.ConnectionURL
+	mu      sync.Mutex
+}
+
+var (
+	_ = sqlbuilder.Database(&database{})
+	_ = sqladapter.Database(&database{})
+	_ = Mutator(&database{})
+)
+
+// newDatabase creates a new *database session for internal use.
+func newDatabase(settings db.ConnectionURL) *database {
+	return &database{
+		connURL: settings,
+	}
+}
+
+// ConnectionURL returns this database's ConnectionURL.
+func (d *database) ConnectionURL() db.ConnectionURL {
+	return d.connURL
+}
+
+// Open attempts to open a connection to the database server.
+func (d *database) Open(connURL db.ConnectionURL) error {
+	if connURL == nil {
+		return db.ErrMissingConnURL
+	}
+	d.connURL = connURL
+	return d.open()
+}
+
+// NewTx starts a transaction block.
+func (d *database) NewTx(ctx context.Context) (sqlbuilder.Tx, error) {
+	nTx, err := d.NewDatabaseTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tx{DatabaseTx: nTx}, nil
+}
+
+// Collections returns a list of non-system tables from the database.
+func (d *database) Collections() (collections []string, err error) {
+	q := d.Select("table_name").
+		From("information_schema.tables").
+		Where("table_schema = '' AND table_type = 'BASE TABLE'").
+		OrderBy("table_name")
+
+	iter := q.Iterator()
+	defer iter.Close()
+
+	for iter.Next() {
+		var tableName string
+		if err := iter.Scan(&tableName); err != nil {
+			return nil, err
+		}
+		collections = append(collections, tableName)
+	}
+
+	return collections, iter.Err()
+}
+
+func (d *database) open() error {
+	// Binding with sqladapter's logic.
+	d.BaseDatabase = sqladapter.NewBaseDatabase(d)
+
+	// Binding with sqlbuilder.
+	d.SQLBuilder = sqlbuilder.WithSession(d.BaseDatabase, template)
+
+	openFn := func() error {
+		sess, err := sqladapter.OpenDB(driverName, d.connURL.String(), nil, nil)
+		if err == nil {
+			return d.BaseDatabase.BindSession(sess)
+		}
+		return err
+	}
+
+	return d.BaseDatabase.WaitForConnection(openFn)
+}
+
+func (d *database) clone(ctx context.Context, checkConn bool) (*database, error) {
+	clone := newDatabase(d.connURL)
+
+	var err error
+	clone.BaseDatabase, err = d.NewClone(clone, checkConn)
+	if err != nil {
+		return nil, err
+	}
+
+	clone.SetContext(ctx)
+
+	clone.SQLBuilder = sqlbuilder.WithSession(clone.BaseDatabase, template)
+
+	return clone, nil
+}
+
+// CompileStatement allows sqladapter to compile the given statement into the
+// format Spanner expects.
+func (d *database) CompileStatement(stmt *exql.Statement, args []interface{}) (string, []interface{}) {
+	compiled, err := stmt.Compile(template)
+	if err != nil {
+		panic(err.Error())
+	}
+	query, args := sqlbuilder.Preprocess(compiled, args)
+	return replacePlaceholders(query), sqladapter.DecimalsToRats(args)
+}
+
+// Err allows sqladapter to translate some known errors into generic errors,
+// constraint violations are wrapped into a *db.Error.
+func (d *database) Err(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch sp.ErrCode(err) {
+	case codes.AlreadyExists:
+		return &db.Error{Kind: db.ErrUniqueViolation, Err: err}
+	case codes.FailedPrecondition:
+		desc := strings.ToLower(sp.ErrDesc(err))
+		if strings.Contains(desc, "foreign key") {
+			return &db.Error{Kind: db.ErrForeignKeyViolation, Err: err}
+		}
+		if strings.Contains(desc, "check constraint") {
+			return &db.Error{Kind: db.ErrCheckViolation, Err: err}
+		}
+	}
+	return err
+}
+
+// SupportsReturning tells sqladapter INSERT and UPDATE statements can
+// return the rows they write, with THEN RETURN.
+func (d *database) SupportsReturning() bool {
+	return true
+}
+
+// NewCollection allows sqladapter create a local db.Collection.
+func (d *database) NewCollection(name string) db.Collection {
+	return newTable(d, name)
+}
+
+// Tx creates a transaction and passes it to the given function, if if the
+// function returns no error then the transaction is commited.
+func (d *database) Tx(ctx context.Context, fn func(tx sqlbuilder.Tx) error) error {
+	return sqladapter.RunTx(d, ctx, fn)
+}
+
+// NewDatabaseTx allows sqladapter start a transaction block.
+func (d *database) NewDatabaseTx(ctx context.Context) (sqladapter.DatabaseTx, error) {
+	clone, err := d.clone(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	clone.mu.Lock()
+	defer clone.mu.Unlock()
+
+	openFn := func() error {
+		sqlTx, err := compat.BeginTx(clone.BaseDatabase.Session(), ctx, nil)
+		if err == nil {
+			return clone.BindTx(ctx, sqlTx)
+		}
+		return err
+	}
+
+	if err := d.BaseDatabase.WaitForConnection(openFn); err != nil {
+		return nil, err
+	}
+
+	return sqladapter.NewDatabaseTx(clone), nil
+}
+
+// LookupName allows sqladapter look up the database's name.
+func (d *database) LookupName() (string, error) {
+	if d.connURL == nil {
+		return "", nil
+	}
+	connURL, err := ParseURL(d.connURL.String())
+	if err != nil {
+		return "", err
+	}
+	return connURL.Database, nil
+}
+
+// TableExists allows sqladapter check whether a table exists and returns an
+// error in case it doesn't.
+func (d *database) TableExists(name string) error {
+	schema, table := sqladapter.SplitTableName(name)
+
+	q := d.Select("table_name").
+		From("information_schema.tables").
+		Where("table_schema = ? AND table_name = ?", schema, table)
+
+	iter := q.Iterator()
+	defer iter.Close()
+
+	if iter.Next() {
+		var name string
+		if err := iter.Scan(&name); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return db.ErrCollectionDoesNotExist
+}
+
+// PrimaryKeys allows sqladapter find a table's primary keys.
+func (d *database) PrimaryKeys(tableName string) ([]string, error) {
+	schema, table := sqladapter.SplitTableName(tableName)
+
+	q := d.Select("column_name").
+		From("information_schema.index_columns").
+		Where("table_schema = ? AND table_name = ? AND index_name = 'PRIMARY_KEY'", schema, table).
+		OrderBy("ordinal_position")
+
+	iter := q.Iterator()
+	defer iter.Close()
+
+	pk := []string{}
+
+	for iter.Next() {
+		var k string
+		if err := iter.Scan(&k); err != nil {
+			return nil, err
+		}
+		pk = append(pk, k)
+	}
+
+	return pk, iter.Err()
+}
+
+// Indexes returns the names of the indexes of the table.
+func (d *database) Indexes(tableName string) ([]string, error) {
+	schema, table := sqladapter.SplitTableName(tableName)
+
+	q := d.Select("index_name").
+		From("information_schema.indexes").
+		Where("table_schema = ? AND table_name = ? AND index_type = 'INDEX'", schema, table)
+
+	iter := q.Iterator()
+	defer iter.Close()
+
+	indexes := []string{}
+
+	for iter.Next() {
+		var name string
+		if err := iter.Scan(&name); err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, name)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
+
+// Apply writes the given mutations, see Mutator.
+func (d *database) Apply(ms ...*sp.Mutation) (time.Time, error) {
+	return apply(d, ms)
+}
+
+// writeMode returns how collections write items, with mutations or DML.
+func (d *database) writeMode() string {
+	if d.connURL == nil {
+		return writeModeMutations
+	}
+	connURL, err := ParseURL(d.connURL.String())
+	if err != nil || connURL.Options["write_mode"] != writeModeDML {
+		return writeModeMutations
+	}
+	return writeModeDML
+}
+
+// WithContext creates a copy of the session on the given context.
+func (d *database) WithContext(ctx context.Context) sqlbuilder.Database {
+	newDB, _ := d.clone(ctx, false)
+	return newDB
+}
+
+// WithSchema returns a session whose collections belong to the given named
+// schema.
+func (d *database) WithSchema(schema string) (sqlbuilder.Database, error) {
+	sess, err := d.SchemaSession(schema, func() (sqladapter.Database, error) {
+		clone, err := d.clone(d.Context(), false)
+		if err != nil {
+			return nil, err
+		}
+		return clone, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sess.(*database), nil
+}
+
+// WithRecordCache returns a copy of the session whose primary key lookups,
+// like col.Find(id).One(&item), read items from the given store.
+func (d *database) WithRecordCache(store db.QueryCache, ttl time.Duration) (sqlbuilder.Database, error) {
+	clone, err := d.clone(d.Context(), false)
+	if err != nil {
+		return nil, err
+	}
+	clone.SetRecordCache(store, ttl)
+	return clone, nil
+}
+
+// apply writes mutations on the connection or transaction of sess.
+func apply(sess sqladapter.Database, ms []*sp.Mutation) (time.Time, error) {
+	execer, ok := sess.Driver().(compat.Execer)
+	if !ok {
+		return time.Time{}, db.ErrNotConnected
+	}
+
+	args := make([]interface{}, len(ms))
+	for i := range ms {
+		args[i] = ms[i]
+	}
+
+	res, err := compat.ExecContext(execer, sess.Context(), applyMutations, args)
+	if err != nil {
+		return time.Time{}, sess.Err(err)
+	}
+
+	if ns, err := res.LastInsertId(); err == nil {
+		return time.Unix(0, ns).UTC(), nil
+	}
+	return time.Time{}, nil
+}
@@ -0,0 +1,632 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package spanner
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sp "cloud.google.com/go/spanner"
+	adminapi "cloud.google.com/go/spanner/admin/database/apiv1"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/protobuf/types/known/structpb"
+	"upper.io/db.v3"
+)
+
+// driverName is the name the database/sql driver that runs statements on
+// Spanner is registered with.
+const driverName = `upper.io/db.v3/spanner`
+
+// applyMutations is the statement sessions execute to write mutations, its
+// arguments are the *spanner.Mutation values to write.
+const applyMutations = `/* upper:apply mutations */`
+
+// staleReadPrefix starts the comment selector modifiers like ExactStaleness
+// add to queries, see timestampBound.
+const staleReadPrefix = `/*upper:stale_read=`
+
+var (
+	errStaleReadInTx = errors.New(`spanner: stale reads are not supported within read-write transactions`)
+	errDDLInTx       = errors.New(`spanner: DDL statements can't run within transactions`)
+)
+
+// sqlDriver is a database/sql driver on top of the Spanner client, it lets
+// sqladapter run the statements of the builder on Spanner: queries run as
+// single-use read-only transactions (or within the read-write transaction of
+// the session), DML runs within a read-write transaction and DDL statements
+// are sent to the database admin API.
+type sqlDriver struct{}
+
+func (d *sqlDriver) Open(dsn string) (driver.Conn, error) {
+	connURL, err := ParseURL(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(context.Background(), connURL)
+}
+
+type conn struct {
+	client   *sp.Client
+	database string
+	opts     []option.ClientOption
+
+	adminMu sync.Mutex
+	admin   *adminapi.DatabaseAdminClient
+
+	tx *sp.ReadWriteStmtBasedTransaction
+}
+
+var (
+	_ = driver.QueryerContext(&conn{})
+	_ = driver.ExecerContext(&conn{})
+	_ = driver.ConnBeginTx(&conn{})
+	_ = driver.NamedValueChecker(&conn{})
+)
+
+func newConn(ctx context.Context, connURL ConnectionURL) (*conn, error) {
+	c := &conn{
+		database: connURL.databasePath(),
+	}
+
+	if v := connURL.Options["credentials"]; v != "" {
+		c.opts = append(c.opts, option.WithAuthCredentialsFile(option.ServiceAccount, v))
+	}
+	if v := connURL.Options["endpoint"]; v != "" {
+		c.opts = append(c.opts, option.WithEndpoint(v))
+	}
+
+	var err error
+	if c.client, err = sp.NewClient(ctx, c.database, c.opts...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	c.client.Close()
+	if c.admin != nil {
+		return c.admin.Close()
+	}
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.ReadOnly {
+		return nil, db.ErrUnsupported
+	}
+	rwTx, err := sp.NewReadWriteStmtBasedTransaction(ctx, c.client)
+	if err != nil {
+		return nil, err
+	}
+	c.tx = rwTx
+	return &sqlTx{conn: c}, nil
+}
+
+// CheckNamedValue passes arguments to the client as they are, it knows how
+// to encode values like civil.Date, big.Rat or the ones of spanner.Null*
+// types the default converter rejects.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.(driver.Valuer); ok {
+		return driver.ErrSkip
+	}
+	return nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if query == applyMutations {
+		return c.apply(ctx, args)
+	}
+
+	if isDDL(query) {
+		if c.tx != nil {
+			return nil, errDDLInTx
+		}
+		return driver.RowsAffected(0), c.updateDDL(ctx, query)
+	}
+
+	stmt := statement(query, args)
+
+	if c.tx != nil {
+		n, err := c.tx.Update(ctx, stmt)
+		if err != nil {
+			return nil, err
+		}
+		return &result{rowsAffected: n}, nil
+	}
+
+	var n int64
+	ts, err := c.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *sp.ReadWriteTransaction) (err error) {
+		n, err = txn.Update(ctx, stmt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result{rowsAffected: n, commitTimestamp: ts}, nil
+}
+
+// apply writes the given mutations, they're buffered if there's a
+// transaction and are committed along with it.
+func (c *conn) apply(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ms := make([]*sp.Mutation, 0, len(args))
+	for _, arg := range args {
+		m, ok := arg.Value.(*sp.Mutation)
+		if !ok {
+			return nil, fmt.Errorf("spanner: expecting a *spanner.Mutation, got %T", arg.Value)
+		}
+		ms = append(ms, m)
+	}
+
+	if c.tx != nil {
+		if err := c.tx.BufferWrite(ms); err != nil {
+			return nil, err
+		}
+		return &result{rowsAffected: int64(len(ms))}, nil
+	}
+
+	ts, err := c.client.Apply(ctx, ms)
+	if err != nil {
+		return nil, err
+	}
+	return &result{rowsAffected: int64(len(ms)), commitTimestamp: ts}, nil
+}
+
+// updateDDL runs a DDL statement and waits for the schema change to finish.
+func (c *conn) updateDDL(ctx context.Context, query string) error {
+	c.adminMu.Lock()
+	if c.admin == nil {
+		admin, err := adminapi.NewDatabaseAdminClient(ctx, c.opts...)
+		if err != nil {
+			c.adminMu.Unlock()
+			return err
+		}
+		c.admin = admin
+	}
+	c.adminMu.Unlock()
+
+	op, err := c.admin.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{
+		Database:   c.database,
+		Statements: []string{query},
+	})
+	if err != nil {
+		return err
+	}
+	return op.Wait(ctx)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	query, bound, stale, err := timestampBound(query)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := statement(query, args)
+
+	if c.tx != nil {
+		if stale {
+			return nil, errStaleReadInTx
+		}
+		return newRows(c.tx.Query(ctx, stmt))
+	}
+
+	if isDML(query) {
+		// DML with THEN RETURN, it must run within a read-write transaction
+		// so its rows are read before committing it.
+		var r *rows
+		_, err := c.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *sp.ReadWriteTransaction) (err error) {
+			r, err = bufferRows(txn.Query(ctx, stmt))
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+
+	return newRows(c.client.Single().WithTimestampBound(bound).Query(ctx, stmt))
+}
+
+type sqlTx struct {
+	conn *conn
+}
+
+func (t *sqlTx) Commit() error {
+	defer func() {
+		t.conn.tx = nil
+	}()
+	_, err := t.conn.tx.Commit(context.Background())
+	return err
+}
+
+func (t *sqlTx) Rollback() error {
+	defer func() {
+		t.conn.tx = nil
+	}()
+	t.conn.tx.Rollback(context.Background())
+	return nil
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+var (
+	_ = driver.StmtQueryContext(&stmt{})
+	_ = driver.StmtExecContext(&stmt{})
+)
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, namedValues(args))
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: args[i]}
+	}
+	return named
+}
+
+// result is the result of a write, LastInsertId returns the commit
+// timestamp in nanoseconds if the write was committed by itself.
+type result struct {
+	rowsAffected    int64
+	commitTimestamp time.Time
+}
+
+func (r *result) LastInsertId() (int64, error) {
+	if r.commitTimestamp.IsZero() {
+		return 0, db.ErrUnsupported
+	}
+	return r.commitTimestamp.UnixNano(), nil
+}
+
+func (r *result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+// statement turns a query and its arguments into a Spanner statement, the
+// placeholders of CompileStatement (@p1, @p2, ...) take the positional
+// arguments and named arguments keep their names.
+func statement(query string, args []driver.NamedValue) sp.Statement {
+	stmt := sp.NewStatement(query)
+	for _, arg := range args {
+		name := arg.Name
+		if name == "" {
+			name = "p" + strconv.Itoa(arg.Ordinal)
+		}
+		stmt.Params[name] = arg.Value
+	}
+	return stmt
+}
+
+// replacePlaceholders turns the "?" placeholders of a statement into the
+// named parameters Spanner expects, like @p1, @p2, ..., @pn.
+func replacePlaceholders(in string) string {
+	var buf strings.Builder
+	n := 0
+	for i := 0; i < len(in); i++ {
+		if in[i] == '?' {
+			n++
+			buf.WriteString("@p" + strconv.Itoa(n))
+			continue
+		}
+		buf.WriteByte(in[i])
+	}
+	return buf.String()
+}
+
+// firstKeyword returns the first keyword of query in upper case, leading
+// comments are skipped.
+func firstKeyword(query string) string {
+	for {
+		query = strings.TrimSpace(query)
+		switch {
+		case strings.HasPrefix(query, "/*"):
+			i := strings.Index(query, "*/")
+			if i < 0 {
+				return ""
+			}
+			query = query[i+2:]
+		case strings.HasPrefix(query, "--"):
+			i := strings.Index(query, "\n")
+			if i < 0 {
+				return ""
+			}
+			query = query[i+1:]
+		default:
+			end := strings.IndexFunc(query, func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+			})
+			if end < 0 {
+				end = len(query)
+			}
+			return strings.ToUpper(query[:end])
+		}
+	}
+}
+
+func isDML(query string) bool {
+	switch firstKeyword(query) {
+	case "INSERT", "UPDATE", "DELETE":
+		return true
+	}
+	return false
+}
+
+func isDDL(query string) bool {
+	switch firstKeyword(query) {
+	case "CREATE", "ALTER", "DROP", "RENAME", "GRANT", "REVOKE", "ANALYZE":
+		return true
+	}
+	return false
+}
+
+// timestampBound strips the comment added by selector modifiers like
+// ExactStaleness from query and returns the timestamp bound it asks for, or a
+// strong read if there's none.
+func timestampBound(query string) (string, sp.TimestampBound, bool, error) {
+	trimmed := strings.TrimSpace(query)
+	if !strings.HasPrefix(trimmed, staleReadPrefix) {
+		return query, sp.StrongRead(), false, nil
+	}
+
+	end := strings.Index(trimmed, "*/")
+	if end < 0 {
+		return query, sp.StrongRead(), false, nil
+	}
+	spec, query := trimmed[len(staleReadPrefix):end], strings.TrimSpace(trimmed[end+2:])
+
+	var kind, value string
+	if i := strings.Index(spec, ":"); i >= 0 {
+		kind, value = spec[:i], spec[i+1:]
+	}
+
+	switch kind {
+	case "exact_staleness", "max_staleness":
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return "", sp.TimestampBound{}, false, err
+		}
+		if kind == "exact_staleness" {
+			return query, sp.ExactStaleness(d), true, nil
+		}
+		return query, sp.MaxStaleness(d), true, nil
+	case "read_timestamp", "min_read_timestamp":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return "", sp.TimestampBound{}, false, err
+		}
+		if kind == "read_timestamp" {
+			return query, sp.ReadTimestamp(t), true, nil
+		}
+		return query, sp.MinReadTimestamp(t), true, nil
+	}
+
+	return "", sp.TimestampBound{}, false, fmt.Errorf("spanner: unknown stale read %q", spec)
+}
+
+// rows reads the results of a query, the first row is read in advance
+// because the columns are not known before.
+type rows struct {
+	next    func() (*sp.Row, error)
+	stop    func()
+	fields  []*spannerpb.StructType_Field
+	pending *sp.Row
+	done    bool
+}
+
+func newRows(it *sp.RowIterator) (*rows, error) {
+	r := &rows{next: it.Next, stop: it.Stop}
+
+	row, err := it.Next()
+	switch err {
+	case nil:
+		r.pending = row
+	case iterator.Done:
+		r.done = true
+	default:
+		it.Stop()
+		return nil, err
+	}
+
+	if it.Metadata != nil && it.Metadata.RowType != nil {
+		r.fields = it.Metadata.RowType.Fields
+	}
+	return r, nil
+}
+
+// bufferRows reads all the rows of it, so they can be read after the
+// transaction they belong to is over.
+func bufferRows(it *sp.RowIterator) (*rows, error) {
+	defer it.Stop()
+
+	var buffered []*sp.Row
+	err := it.Do(func(row *sp.Row) error {
+		buffered = append(buffered, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r := &rows{
+		next: func() (*sp.Row, error) {
+			if len(buffered) == 0 {
+				return nil, iterator.Done
+			}
+			row := buffered[0]
+			buffered = buffered[1:]
+			return row, nil
+		},
+		stop: func() {},
+	}
+	if it.Metadata != nil && it.Metadata.RowType != nil {
+		r.fields = it.Metadata.RowType.Fields
+	}
+	return r, nil
+}
+
+func (r *rows) Columns() []string {
+	columns := make([]string, len(r.fields))
+	for i := range r.fields {
+		columns[i] = r.fields[i].Name
+	}
+	return columns
+}
+
+func (r *rows) Close() error {
+	r.done = true
+	r.stop()
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+
+	row := r.pending
+	if row != nil {
+		r.pending = nil
+	} else {
+		var err error
+		if row, err = r.next(); err != nil {
+			if err == iterator.Done {
+				r.done = true
+				return io.EOF
+			}
+			return err
+		}
+	}
+
+	for i := range dest {
+		v, err := driverValue(row, i)
+		if err != nil {
+			return err
+		}
+		dest[i] = v
+	}
+	return nil
+}
+
+// driverValue converts the i-th column of row into a value database/sql can
+// scan. Dates become UTC times, NUMERIC values become strings, and JSON,
+// arrays and structs are encoded as JSON.
+func driverValue(row *sp.Row, i int) (driver.Value, error) {
+	var v sp.GenericColumnValue
+	if err := row.Column(i, &v); err != nil {
+		return nil, err
+	}
+	if _, ok := v.Value.GetKind().(*structpb.Value_NullValue); ok {
+		return nil, nil
+	}
+
+	switch v.Type.GetCode() {
+	case spannerpb.TypeCode_INT64:
+		var n int64
+		err := v.Decode(&n)
+		return n, err
+	case spannerpb.TypeCode_FLOAT64:
+		var f float64
+		err := v.Decode(&f)
+		return f, err
+	case spannerpb.TypeCode_FLOAT32:
+		var f float32
+		err := v.Decode(&f)
+		return float64(f), err
+	case spannerpb.TypeCode_BOOL:
+		var b bool
+		err := v.Decode(&b)
+		return b, err
+	case spannerpb.TypeCode_STRING:
+		var s string
+		err := v.Decode(&s)
+		return s, err
+	case spannerpb.TypeCode_BYTES:
+		var b []byte
+		err := v.Decode(&b)
+		return b, err
+	case spannerpb.TypeCode_TIMESTAMP:
+		var t time.Time
+		err := v.Decode(&t)
+		return t, err
+	case spannerpb.TypeCode_DATE:
+		var d sp.NullDate
+		err := v.Decode(&d)
+		return d.Date.In(time.UTC), err
+	case spannerpb.TypeCode_NUMERIC:
+		var n sp.NullNumeric
+		if err := v.Decode(&n); err != nil {
+			return nil, err
+		}
+		return sp.NumericString(&n.Numeric), nil
+	case spannerpb.TypeCode_JSON:
+		var j sp.NullJSON
+		if err := v.Decode(&j); err != nil {
+			return nil, err
+		}
+		return json.Marshal(j.Value)
+	}
+
+	return json.Marshal(v.Value.AsInterface())
+}
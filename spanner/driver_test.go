@@ -0,0 +1,125 @@
+package spanner
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	sp "cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"upper.io/db.v3"
+)
+
+func TestReplacePlaceholders(t *testing.T) {
+	assert.Equal(t,
+		"SELECT * FROM `singers` WHERE `id` = @p1 AND `name` = @p2",
+		replacePlaceholders("SELECT * FROM `singers` WHERE `id` = ? AND `name` = ?"),
+	)
+}
+
+func TestStatementKind(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("SELECT", firstKeyword("/* comment */ -- line\n select 1"))
+
+	assert.True(isDML("INSERT INTO `singers` (`id`) VALUES (@p1)"))
+	assert.True(isDML("update `singers` SET `name` = @p1 WHERE true"))
+	assert.False(isDML("SELECT * FROM `singers`"))
+
+	assert.True(isDDL("CREATE TABLE `singers` (`id` INT64) PRIMARY KEY (`id`)"))
+	assert.False(isDDL("DELETE FROM `singers` WHERE true"))
+}
+
+func TestTimestampBound(t *testing.T) {
+	assert := assert.New(t)
+
+	query, bound, ok, err := timestampBound("SELECT 1")
+	require.NoError(t, err)
+	assert.False(ok)
+	assert.Equal("SELECT 1", query)
+	assert.Equal(sp.StrongRead().String(), bound.String())
+
+	query, bound, ok, err = timestampBound(ExactStaleness(15 * time.Second)("SELECT 1"))
+	require.NoError(t, err)
+	assert.True(ok)
+	assert.Equal("SELECT 1", query)
+	assert.Equal(sp.ExactStaleness(15*time.Second).String(), bound.String())
+
+	query, bound, ok, err = timestampBound(MaxStaleness(time.Minute)("SELECT 1"))
+	require.NoError(t, err)
+	assert.True(ok)
+	assert.Equal("SELECT 1", query)
+	assert.Equal(sp.MaxStaleness(time.Minute).String(), bound.String())
+
+	ts := time.Date(2024, 2, 29, 12, 30, 0, 500, time.UTC)
+
+	_, bound, _, err = timestampBound(ReadTimestamp(ts)("SELECT 1"))
+	require.NoError(t, err)
+	assert.Equal(sp.ReadTimestamp(ts).String(), bound.String())
+
+	_, bound, _, err = timestampBound(MinReadTimestamp(ts)("SELECT 1"))
+	require.NoError(t, err)
+	assert.Equal(sp.MinReadTimestamp(ts).String(), bound.String())
+
+	_, _, _, err = timestampBound(staleRead("unknown", "1s")("SELECT 1"))
+	assert.Error(err)
+}
+
+func TestDriverValue(t *testing.T) {
+	assert := assert.New(t)
+
+	row, err := sp.NewRow(
+		[]string{"id", "name", "born", "rating", "tags", "bio"},
+		[]interface{}{
+			int64(1),
+			"Nina",
+			civil.Date{Year: 2024, Month: 2, Day: 29},
+			3.5,
+			[]string{"jazz", "soul"},
+			sp.NullString{},
+		},
+	)
+	require.NoError(t, err)
+
+	v, err := driverValue(row, 0)
+	require.NoError(t, err)
+	assert.Equal(int64(1), v)
+
+	v, err = driverValue(row, 1)
+	require.NoError(t, err)
+	assert.Equal("Nina", v)
+
+	v, err = driverValue(row, 2)
+	require.NoError(t, err)
+	assert.Equal(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), v)
+
+	v, err = driverValue(row, 3)
+	require.NoError(t, err)
+	assert.Equal(3.5, v)
+
+	v, err = driverValue(row, 4)
+	require.NoError(t, err)
+	assert.Equal([]byte(`["jazz","soul"]`), v)
+
+	v, err = driverValue(row, 5)
+	require.NoError(t, err)
+	assert.Nil(v)
+}
+
+func TestMutationValue(t *testing.T) {
+	assert := assert.New(t)
+
+	v, ok, err := mutationValue(CommitTimestamp)
+	require.NoError(t, err)
+	assert.True(ok)
+	assert.Equal(sp.CommitTimestamp, v)
+
+	v, ok, err = mutationValue("Nina")
+	require.NoError(t, err)
+	assert.True(ok)
+	assert.Equal("Nina", v)
+
+	_, _, err = mutationValue(db.Raw("NOW()"))
+	assert.Error(err)
+}
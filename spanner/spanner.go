@@ -0,0 +1,157 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package spanner is an adapter for Google Cloud Spanner. Queries are
+// rendered by the builder in GoogleSQL and run as single-use read-only
+// transactions, statements that modify data run as DML within read-write
+// transactions, and DDL statements are sent to the database admin API:
+//
+//	sess, err := spanner.Open(spanner.ConnectionURL{
+//		Project:  "my-project",
+//		Instance: "my-instance",
+//		Database: "music",
+//	})
+//
+// Collections write items with mutations by default, which are cheaper than
+// DML, see the write_mode option of ConnectionURL. Mutations can also be
+// written directly with Apply, gspanner being cloud.google.com/go/spanner:
+//
+//	ts, err := sess.(spanner.Mutator).Apply(
+//		gspanner.InsertMap("singers", map[string]interface{}{"id": 1, "name": "Nina"}),
+//	)
+//
+// Struct fields with the commit_timestamp option, like
+// `db:"updated_at,commit_timestamp"`, are set to the commit timestamp of the
+// transaction on insert. Statements of the builder can use CommitTimestamp
+// for the same purpose.
+//
+// Queries read the latest data by default, stale reads are asked for with
+// selector modifiers:
+//
+//	sess.SelectFrom("singers").Amend(spanner.ExactStaleness(15 * time.Second)).All(&singers)
+package spanner // import "upper.io/db.v3/spanner"
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// Adapter is the public name of the adapter.
+const Adapter = `spanner`
+
+func init() {
+	sql.Register(driverName, &sqlDriver{})
+
+	sqlbuilder.RegisterAdapter(Adapter, &sqlbuilder.AdapterFuncMap{
+		New:   New,
+		NewTx: NewTx,
+		Open:  Open,
+	})
+}
+
+// CommitTimestamp is replaced by the commit timestamp of the transaction when
+// used as the value of a column with the allow_commit_timestamp option in
+// statements of the builder:
+//
+//	sess.Update("singers").Set("updated_at", spanner.CommitTimestamp).Where("id", 1).Exec()
+var CommitTimestamp = db.Raw("PENDING_COMMIT_TIMESTAMP()")
+
+// Open stablishes a new connection with Spanner.
+func Open(settings db.ConnectionURL) (sqlbuilder.Database, error) {
+	d := newDatabase(settings)
+	if err := d.Open(settings); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// NewTx returns a transaction session.
+func NewTx(sqlTx *sql.Tx) (sqlbuilder.Tx, error) {
+	d := newDatabase(nil)
+
+	// Binding with sqladapter's logic.
+	d.BaseDatabase = sqladapter.NewBaseDatabase(d)
+
+	// Binding with sqlbuilder.
+	d.SQLBuilder = sqlbuilder.WithSession(d.BaseDatabase, template)
+
+	if err := d.BaseDatabase.BindTx(d.Context(), sqlTx); err != nil {
+		return nil, err
+	}
+
+	newTx := sqladapter.NewDatabaseTx(d)
+	return &tx{DatabaseTx: newTx}, nil
+}
+
+// New wraps the given *sql.DB session, which must have been opened with the
+// driver of this package, and creates a new db session.
+func New(sess *sql.DB) (sqlbuilder.Database, error) {
+	d := newDatabase(nil)
+
+	// Binding with sqladapter's logic.
+	d.BaseDatabase = sqladapter.NewBaseDatabase(d)
+
+	// Binding with sqlbuilder.
+	d.SQLBuilder = sqlbuilder.WithSession(d.BaseDatabase, template)
+
+	if err := d.BaseDatabase.BindSession(sess); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ExactStaleness returns a selector modifier, to be given to Amend, that
+// makes the query read the data as it was the given time ago.
+func ExactStaleness(d time.Duration) func(string) string {
+	return staleRead("exact_staleness", d.String())
+}
+
+// MaxStaleness returns a selector modifier, to be given to Amend, that lets
+// the query read data that is up to the given time old.
+func MaxStaleness(d time.Duration) func(string) string {
+	return staleRead("max_staleness", d.String())
+}
+
+// ReadTimestamp returns a selector modifier, to be given to Amend, that makes
+// the query read the data as it was at the given time.
+func ReadTimestamp(t time.Time) func(string) string {
+	return staleRead("read_timestamp", t.UTC().Format(time.RFC3339Nano))
+}
+
+// MinReadTimestamp returns a selector modifier, to be given to Amend, that
+// lets the query read data as it was at any time after the given one.
+func MinReadTimestamp(t time.Time) func(string) string {
+	return staleRead("min_read_timestamp", t.UTC().Format(time.RFC3339Nano))
+}
+
+// staleRead returns a function that marks queries with a comment the driver
+// takes the timestamp bound of the query from. Stale reads can't be used
+// within transactions.
+func staleRead(kind string, value string) func(string) string {
+	return func(query string) string {
+		return fmt.Sprintf("%s%s:%s*/ %s", staleReadPrefix, kind, value, query)
+	}
+}
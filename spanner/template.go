@@ -0,0 +1,251 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package spanner
+
+import (
+	"upper.io/db.v3/internal/cache"
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+const (
+	adapterColumnSeparator     = `.`
+	adapterIdentifierSeparator = `, `
+	adapterIdentifierQuote     = "`{{.Value}}`"
+	adapterValueSeparator      = `, `
+	adapterValueQuote          = `'{{.}}'`
+	adapterAndKeyword          = `AND`
+	adapterOrKeyword           = `OR`
+	adapterNotKeyword          = `NOT`
+	adapterDescKeyword         = `DESC`
+	adapterAscKeyword          = `ASC`
+	adapterDefaultOperator     = `=`
+	adapterAssignmentOperator  = `=`
+	adapterClauseGroup         = `({{.}})`
+	adapterClauseOperator      = ` {{.}} `
+	adapterColumnValue         = `{{.Column}} {{.Operator}} {{.Value}}`
+	adapterILikeLayout         = `LOWER({{.Column}}) {{.Operator}} LOWER({{.Value}})`
+	adapterMatchLayout         = `SEARCH({{.Column}}, {{.Value}})`
+	adapterTableAliasLayout    = `{{.Name}}{{if .Alias}} AS {{.Alias}}{{end}}`
+	adapterColumnAliasLayout   = `{{.Name}}{{if .Alias}} AS {{.Alias}}{{end}}`
+	adapterSortByColumnLayout  = `{{.Column}} {{.Order}}`
+	adapterWithLayout          = `WITH {{.Clauses}}`
+
+	adapterOrderByLayout = `
+    {{if .SortColumns}}
+      ORDER BY {{.SortColumns}}
+    {{end}}
+  `
+
+	adapterWhereLayout = `
+    {{if .Conds}}
+      WHERE {{.Conds}}
+    {{end}}
+  `
+
+	adapterUsingLayout = `
+    {{if .Columns}}
+      USING ({{.Columns}})
+    {{end}}
+  `
+
+	adapterJoinLayout = `
+    {{if .Table}}
+      {{ if .On }}
+        {{.Type}} JOIN {{.Table}}
+        {{.On}}
+      {{ else if .Using }}
+        {{.Type}} JOIN {{.Table}}
+        {{.Using}}
+      {{else}}
+        {{.Type}} JOIN {{.Table}}
+      {{end}}
+    {{end}}
+  `
+
+	adapterOnLayout = `
+    {{if .Conds}}
+      ON {{.Conds}}
+    {{end}}
+  `
+
+	adapterSelectLayout = `
+    SELECT
+      {{if .Distinct}}
+        DISTINCT
+      {{end}}
+
+      {{if .Columns}}
+        {{.Columns}}
+      {{else}}
+        *
+      {{end}}
+
+      {{if .Table}}
+        FROM {{.Table}}
+      {{end}}
+
+      {{.Joins}}
+
+      {{.Where}}
+
+      {{.GroupBy}}
+
+      {{.OrderBy}}
+
+      {{if .Limit}}
+        LIMIT {{.Limit}}
+      {{end}}
+
+      {{if .Offset}}
+        {{if not .Limit}}
+          LIMIT 9223372036854775807
+        {{end}}
+        OFFSET {{.Offset}}
+      {{end}}
+  `
+
+	// Spanner requires a WHERE clause on UPDATE and DELETE statements.
+	adapterDeleteLayout = `
+    DELETE
+      FROM {{.Table}}
+      {{if .Where}}
+        {{.Where}}
+      {{else}}
+        WHERE true
+      {{end}}
+  `
+	adapterUpdateLayout = `
+    UPDATE
+      {{.Table}}
+    SET {{.ColumnValues}}
+      {{if .Where}}
+        {{.Where}}
+      {{else}}
+        WHERE true
+      {{end}}
+  `
+
+	adapterSelectCountLayout = `
+    SELECT
+      COUNT(1) AS _t
+    FROM {{.Table}}
+      {{.Where}}
+  `
+
+	adapterInsertLayout = `
+    INSERT INTO {{.Table}}
+      {{if .Columns }}({{.Columns}}){{end}}
+    VALUES
+      {{.Values}}
+    {{if .Returning}}
+      THEN RETURN {{.Returning}}
+    {{end}}
+  `
+
+	adapterTruncateLayout = `
+    DELETE FROM {{.Table}} WHERE true
+  `
+
+	adapterDropTableLayout = `
+    DROP TABLE {{.Table}}
+  `
+
+	adapterGroupByLayout = `
+    {{if .GroupColumns}}
+      GROUP BY {{.GroupColumns}}
+    {{end}}
+  `
+)
+
+const (
+	adapterCreateTableLayout      = `CREATE TABLE {{if .IfNotExists}}IF NOT EXISTS {{end}}{{.Table}} ({{.Definitions}}){{if .PrimaryKey}} PRIMARY KEY ({{.PrimaryKey}}){{end}}`
+	adapterCreateIndexLayout      = `CREATE {{if .Unique}}UNIQUE {{end}}INDEX {{.Index}} ON {{.Table}} ({{.Columns}})`
+	adapterAddColumnLayout        = `ALTER TABLE {{.Table}} ADD COLUMN {{.Column}}`
+	adapterDropColumnLayout       = `ALTER TABLE {{.Table}} DROP COLUMN {{.Column}}`
+	adapterColumnDefinitionLayout = `{{.Name}} {{.Type}}{{if .NotNull}} NOT NULL{{end}}{{if .AutoIncrement}} AUTO_INCREMENT{{end}}{{if .Default}} DEFAULT ({{.Default}}){{end}}{{if .PrimaryKey}} PRIMARY KEY{{end}}{{if .Unique}} UNIQUE{{end}}`
+	adapterTrueKeyword            = `TRUE`
+	adapterFalseKeyword           = `FALSE`
+)
+
+// Columns can't be renamed on Spanner, so there's no RenameColumnLayout.
+var adapterColumnTypes = map[string]string{
+	"boolean":   `BOOL`,
+	"smallint":  `INT64`,
+	"integer":   `INT64`,
+	"bigint":    `INT64`,
+	"float":     `FLOAT64`,
+	"decimal":   `NUMERIC`,
+	"string":    `STRING({{.Size}})`,
+	"text":      `STRING(MAX)`,
+	"bytes":     `BYTES(MAX)`,
+	"date":      `DATE`,
+	"timestamp": `TIMESTAMP`,
+	"json":      `JSON`,
+	"uuid":      `STRING(36)`,
+}
+
+var template = &exql.Template{
+	ColumnSeparator:     adapterColumnSeparator,
+	IdentifierSeparator: adapterIdentifierSeparator,
+	IdentifierQuote:     adapterIdentifierQuote,
+	ILikeLayout:         adapterILikeLayout,
+	ValueSeparator:      adapterValueSeparator,
+	ValueQuote:          adapterValueQuote,
+	AndKeyword:          adapterAndKeyword,
+	OrKeyword:           adapterOrKeyword,
+	NotKeyword:          adapterNotKeyword,
+	DescKeyword:         adapterDescKeyword,
+	AscKeyword:          adapterAscKeyword,
+	DefaultOperator:     adapterDefaultOperator,
+	AssignmentOperator:  adapterAssignmentOperator,
+	ClauseGroup:         adapterClauseGroup,
+	ClauseOperator:      adapterClauseOperator,
+	ColumnValue:         adapterColumnValue,
+	TableAliasLayout:    adapterTableAliasLayout,
+	ColumnAliasLayout:   adapterColumnAliasLayout,
+	SortByColumnLayout:  adapterSortByColumnLayout,
+	WhereLayout:         adapterWhereLayout,
+	WithLayout:          adapterWithLayout,
+	JoinLayout:          adapterJoinLayout,
+	OnLayout:            adapterOnLayout,
+	UsingLayout:         adapterUsingLayout,
+	OrderByLayout:       adapterOrderByLayout,
+	MatchLayout:         adapterMatchLayout,
+	InsertLayout:        adapterInsertLayout,
+	SelectLayout:        adapterSelectLayout,
+	UpdateLayout:        adapterUpdateLayout,
+	DeleteLayout:        adapterDeleteLayout,
+	TruncateLayout:      adapterTruncateLayout,
+	DropTableLayout:     adapterDropTableLayout,
+	CountLayout:         adapterSelectCountLayout,
+	GroupByLayout:       adapterGroupByLayout,
+
+	CreateTableLayout:      adapterCreateTableLayout,
+	CreateIndexLayout:      adapterCreateIndexLayout,
+	AddColumnLayout:        adapterAddColumnLayout,
+	DropColumnLayout:       adapterDropColumnLayout,
+	ColumnDefinitionLayout: adapterColumnDefinitionLayout,
+	ColumnTypes:            adapterColumnTypes,
+	TrueKeyword:            adapterTrueKeyword,
+	FalseKeyword:           adapterFalseKeyword,
+	Cache:                  cache.NewCache(),
+}
@@ -0,0 +1,119 @@
+package spanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+func TestTemplateSelect(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		"SELECT * FROM `singers`",
+		b.SelectFrom("singers").String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM `singers` WHERE (`id` = $1)",
+		b.SelectFrom("singers").Where("id", 1).String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM `singers` LIMIT 9223372036854775807 OFFSET 20",
+		b.SelectFrom("singers").Offset(20).String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM `singers` LIMIT 10 OFFSET 20",
+		b.SelectFrom("singers").Limit(10).Offset(20).String(),
+	)
+}
+
+func TestTemplateInsert(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		"INSERT INTO `singers` (`id`, `name`) VALUES ($1, $2)",
+		b.InsertInto("singers").Columns("id", "name").Values(1, "Nina").String(),
+	)
+
+	assert.Equal(
+		"INSERT INTO `singers` (`id`, `name`) VALUES ($1, $2) THEN RETURN `id`",
+		b.InsertInto("singers").Columns("id", "name").Values(1, "Nina").Returning("id").String(),
+	)
+}
+
+func TestTemplateUpdate(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		"UPDATE `singers` SET `name` = $1 WHERE true",
+		b.Update("singers").Set("name", "Nina").String(),
+	)
+
+	assert.Equal(
+		"UPDATE `singers` SET `name` = $1 WHERE (`id` = $2)",
+		b.Update("singers").Set("name", "Nina").Where("id", 1).String(),
+	)
+}
+
+func TestTemplateDelete(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		"DELETE FROM `singers` WHERE true",
+		b.DeleteFrom("singers").String(),
+	)
+
+	assert.Equal(
+		"DELETE FROM `singers` WHERE (`id` IN ($1, $2))",
+		b.DeleteFrom("singers").Where(db.Cond{"id": []int{1, 2}}).String(),
+	)
+}
+
+func TestTemplateDDL(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		"CREATE TABLE `books` (`id` INT64 AUTO_INCREMENT PRIMARY KEY, `title` STRING(255) NOT NULL, `body` STRING(MAX), `public` BOOL NOT NULL DEFAULT (TRUE), `created_at` TIMESTAMP)",
+		b.CreateTable("books").
+			Columns(
+				sqlbuilder.Column{Name: "id", Type: sqlbuilder.BigInt, AutoIncrement: true},
+				sqlbuilder.Column{Name: "title", Type: sqlbuilder.String, NotNull: true},
+				sqlbuilder.Column{Name: "body", Type: sqlbuilder.Text},
+				sqlbuilder.Column{Name: "public", Type: sqlbuilder.Boolean, NotNull: true, Default: true},
+				sqlbuilder.Column{Name: "created_at", Type: sqlbuilder.Timestamp},
+			).
+			PrimaryKey("id").
+			String(),
+	)
+
+	assert.Equal(
+		"CREATE TABLE `tags` (`book_id` INT64 NOT NULL, `tag` STRING(32) NOT NULL) PRIMARY KEY (`book_id`, `tag`)",
+		b.CreateTable("tags").
+			Columns(
+				sqlbuilder.Column{Name: "book_id", Type: sqlbuilder.BigInt, NotNull: true},
+				sqlbuilder.Column{Name: "tag", Type: sqlbuilder.String, Size: 32, NotNull: true},
+			).
+			PrimaryKey("book_id", "tag").
+			String(),
+	)
+
+	assert.Equal(
+		"CREATE UNIQUE INDEX `books_title_idx` ON `books` (`title`)",
+		b.CreateIndex("books_title_idx").Unique().On("books", "title").String(),
+	)
+
+	assert.Equal(
+		"ALTER TABLE `books` ADD COLUMN `isbn` STRING(13); ALTER TABLE `books` DROP COLUMN `body`",
+		b.AlterTable("books").AddColumn(sqlbuilder.Column{Name: "isbn", Type: sqlbuilder.String, Size: 13}).DropColumn("body").String(),
+	)
+}
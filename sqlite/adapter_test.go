@@ -23,9 +23,19 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/compress"
+	"upper.io/db.v3/lib/fixtures"
 	"upper.io/db.v3/lib/sqlbuilder"
 )
 
@@ -133,3 +143,731 @@ func cleanUpCheck(sess sqlbuilder.Database) (err error) {
 	// TODO: Check the number of prepared statements.
 	return nil
 }
+
+func TestJSONExtract(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE IF NOT EXISTS json_docs (id INTEGER PRIMARY KEY, data TEXT)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE json_docs`)
+
+	_, err = sess.InsertInto("json_docs").
+		Columns("id", "data").
+		Values(1, `{"status": "active", "tags": ["go", "sql"]}`).
+		Values(2, `{"status": "inactive", "tags": ["go"]}`).
+		Exec()
+	assert.NoError(t, err)
+
+	var ids []int64
+	err = sess.Select("id").From("json_docs").
+		Where(db.Cond{JSONExtract("data", "$.status"): "active"}).
+		All(&ids)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, ids)
+
+	var statuses []string
+	err = sess.Select(JSONExtract("data", "$.status") + " AS status").From("json_docs").
+		Where(db.Cond{JSONArrayLength("data", "$.tags") + " >=": 1}).
+		OrderBy("id").
+		All(&statuses)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"active", "inactive"}, statuses)
+}
+
+func TestJSONTagOption(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE IF NOT EXISTS json_payloads (id INTEGER PRIMARY KEY, payload TEXT)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE json_payloads`)
+
+	type payload struct {
+		Status string   `json:"status"`
+		Tags   []string `json:"tags"`
+	}
+
+	type item struct {
+		ID      int64   `db:"id,omitempty"`
+		Payload payload `db:"payload,json"`
+	}
+
+	col := sess.Collection("json_payloads")
+
+	in := item{Payload: payload{Status: "active", Tags: []string{"go", "sql"}}}
+	assert.NoError(t, col.InsertReturning(&in))
+
+	var raw string
+	row, err := sess.QueryRow(`SELECT payload FROM json_payloads WHERE id = ?`, in.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, row.Scan(&raw))
+	assert.Equal(t, `{"status":"active","tags":["go","sql"]}`, raw)
+
+	var out item
+	assert.NoError(t, col.Find(in.ID).One(&out))
+	assert.Equal(t, in, out)
+
+	out.Payload.Status = "inactive"
+	assert.NoError(t, col.Find(in.ID).Update(out))
+
+	var items []item
+	assert.NoError(t, col.Find(db.Cond{JSONExtract("payload", "$.status"): "inactive"}).All(&items))
+	assert.Equal(t, []item{out}, items)
+}
+
+// reverseCodec stores values reversed.
+type reverseCodec struct{}
+
+func (reverseCodec) Encode(value []byte) ([]byte, error) {
+	return reverseBytes(value), nil
+}
+
+func (reverseCodec) Decode(value []byte) ([]byte, error) {
+	return reverseBytes(value), nil
+}
+
+func reverseBytes(value []byte) []byte {
+	b := make([]byte, len(value))
+	for i := range value {
+		b[len(value)-1-i] = value[i]
+	}
+	return b
+}
+
+func TestCodec(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE IF NOT EXISTS codec_people (id INTEGER PRIMARY KEY, ssn BLOB, notes BLOB)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE codec_people`)
+
+	type person struct {
+		ID    int64   `db:"id,omitempty"`
+		SSN   string  `db:"ssn,codec=reverse"`
+		Notes *string `db:"notes,codec=reverse"`
+	}
+
+	col := sess.Collection("codec_people")
+
+	_, err = col.Insert(person{SSN: "123-45"})
+	assert.Error(t, err, "the codec is not registered yet")
+
+	sess.SetCodec("reverse", reverseCodec{})
+
+	in := person{SSN: "123-45"}
+	assert.NoError(t, col.InsertReturning(&in))
+
+	var raw []byte
+	row, err := sess.QueryRow(`SELECT ssn FROM codec_people WHERE id = ?`, in.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, row.Scan(&raw))
+	assert.Equal(t, []byte("54-321"), raw)
+
+	var out person
+	assert.NoError(t, col.Find(in.ID).One(&out))
+	assert.Equal(t, in, out)
+
+	err = sess.Tx(nil, func(tx sqlbuilder.Tx) error {
+		notes := "private"
+		out.Notes = &notes
+		return tx.Collection("codec_people").Find(in.ID).Update(out)
+	})
+	assert.NoError(t, err)
+
+	var people []person
+	assert.NoError(t, sess.SelectFrom("codec_people").All(&people))
+	if assert.Equal(t, 1, len(people)) && assert.NotNil(t, people[0].Notes) {
+		assert.Equal(t, "private", *people[0].Notes)
+	}
+}
+
+func TestCompressCodec(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE IF NOT EXISTS compressed_pages (id INTEGER PRIMARY KEY, body BLOB)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE compressed_pages`)
+
+	compress.Register(sess)
+
+	type page struct {
+		ID   int64  `db:"id,omitempty"`
+		Body string `db:"body,codec=zstd"`
+	}
+
+	in := page{Body: strings.Repeat("lorem ipsum ", 1000)}
+	assert.NoError(t, sess.Collection("compressed_pages").InsertReturning(&in))
+
+	var size int
+	row, err := sess.QueryRow(`SELECT LENGTH(body) FROM compressed_pages WHERE id = ?`, in.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, row.Scan(&size))
+	assert.True(t, size < len(in.Body)/10)
+
+	var out page
+	assert.NoError(t, sess.Collection("compressed_pages").Find(in.ID).One(&out))
+	assert.Equal(t, in, out)
+}
+
+func TestUniqueConstraintError(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE IF NOT EXISTS unique_users (id INTEGER PRIMARY KEY, email TEXT UNIQUE)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE unique_users`)
+
+	type user struct {
+		_     struct{} `db:"-" unique:"email->Email"`
+		ID    int64    `db:"id,omitempty"`
+		Email string   `db:"email"`
+	}
+
+	col := sess.Collection("unique_users")
+
+	_, err = col.Insert(user{Email: "joe@example.com"})
+	assert.NoError(t, err)
+
+	other := user{Email: "ann@example.com"}
+	assert.NoError(t, col.InsertReturning(&other))
+
+	_, err = col.Insert(user{Email: "joe@example.com"})
+	if assert.IsType(t, &db.ConstraintError{}, err) {
+		assert.Equal(t, "Email", err.(*db.ConstraintError).Field)
+		assert.Equal(t, db.ErrUniqueViolation, db.ErrorKind(err))
+	}
+
+	other.Email = "joe@example.com"
+	err = col.Find(other.ID).Update(other)
+	if assert.IsType(t, &db.ConstraintError{}, err) {
+		assert.Equal(t, "Email", err.(*db.ConstraintError).Field)
+	}
+}
+
+func TestContextWithTx(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE IF NOT EXISTS ambient_items (id INTEGER PRIMARY KEY, name TEXT)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE ambient_items`)
+
+	insertItem := func(ctx context.Context, name string) error {
+		_, err := sess.WithContext(ctx).Collection("ambient_items").Insert(map[string]interface{}{"name": name})
+		return err
+	}
+
+	countItems := func(ctx context.Context) uint64 {
+		count, err := sess.WithContext(ctx).Collection("ambient_items").Find().Count()
+		assert.NoError(t, err)
+		return count
+	}
+
+	err = sess.Tx(nil, func(tx sqlbuilder.Tx) error {
+		ctx := db.ContextWithTx(context.Background(), tx)
+		if err := insertItem(ctx, "first"); err != nil {
+			return err
+		}
+		assert.Equal(t, uint64(1), countItems(ctx))
+		return errors.New("rolled back")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, uint64(0), countItems(context.Background()))
+
+	err = sess.Tx(nil, func(tx sqlbuilder.Tx) error {
+		ctx := db.ContextWithTx(context.Background(), tx)
+		if err := insertItem(ctx, "first"); err != nil {
+			return err
+		}
+		var name string
+		row, err := sess.QueryRowContext(ctx, `SELECT name FROM ambient_items`)
+		if err != nil {
+			return err
+		}
+		if err := row.Scan(&name); err != nil {
+			return err
+		}
+		assert.Equal(t, "first", name)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), countItems(context.Background()))
+}
+
+func TestTxHooks(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE IF NOT EXISTS hooked_items (id INTEGER PRIMARY KEY, name TEXT)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE hooked_items`)
+
+	var committed []uint64
+	var rolledBack []error
+
+	countItems := func() uint64 {
+		count, err := sess.Collection("hooked_items").Find().Count()
+		assert.NoError(t, err)
+		return count
+	}
+
+	err = sess.Tx(nil, func(tx sqlbuilder.Tx) error {
+		tx.OnCommit(func() {
+			committed = append(committed, countItems())
+		})
+		tx.OnRollback(func(err error) {
+			rolledBack = append(rolledBack, err)
+		})
+		_, err := tx.Collection("hooked_items").Insert(map[string]interface{}{"name": "first"})
+		assert.NoError(t, err)
+		assert.Empty(t, committed)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1}, committed)
+	assert.Empty(t, rolledBack)
+
+	errRollback := errors.New("rolled back")
+	err = sess.Tx(nil, func(tx sqlbuilder.Tx) error {
+		tx.OnCommit(func() {
+			committed = append(committed, countItems())
+		})
+		tx.OnRollback(func(err error) {
+			rolledBack = append(rolledBack, err)
+		})
+		return errRollback
+	})
+	assert.Equal(t, errRollback, err)
+	assert.Equal(t, []uint64{1}, committed)
+	assert.Equal(t, []error{errRollback}, rolledBack)
+
+	tx, err := sess.NewTx(nil)
+	assert.NoError(t, err)
+	tx.OnRollback(func(err error) {
+		rolledBack = append(rolledBack, err)
+	})
+	assert.NoError(t, tx.Rollback())
+	assert.Error(t, tx.Rollback())
+	assert.Equal(t, []error{errRollback, nil}, rolledBack)
+}
+
+func TestSavepoint(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE IF NOT EXISTS savepoint_items (id INTEGER PRIMARY KEY, name TEXT UNIQUE)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE savepoint_items`)
+
+	tx, err := sess.NewTx(nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, sqlbuilder.ErrInvalidSavepointName, tx.Savepoint("it's"))
+
+	loaded := 0
+	for _, name := range []string{"a", "b", "a", "c"} {
+		assert.NoError(t, tx.Savepoint("item"))
+		if _, err := tx.Collection("savepoint_items").Insert(map[string]interface{}{"name": name}); err != nil {
+			assert.NoError(t, tx.RollbackTo("item"))
+			continue
+		}
+		assert.NoError(t, tx.ReleaseSavepoint("item"))
+		loaded++
+	}
+	assert.Equal(t, 3, loaded)
+
+	assert.Error(t, tx.RollbackTo("unknown"))
+	assert.NoError(t, tx.Commit())
+
+	count, err := sess.Collection("savepoint_items").Find().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), count)
+}
+
+func TestTablePrefix(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	for _, table := range []string{"app_2_notes", "app_accounts"} {
+		_, err := sess.Exec(`CREATE TABLE IF NOT EXISTS ` + table + ` (id INTEGER PRIMARY KEY, name TEXT)`)
+		assert.NoError(t, err)
+		defer sess.Exec(`DROP TABLE ` + table)
+	}
+
+	sess.SetTablePrefix("app_2_")
+	sess.SetCollectionTablePrefix("accounts", "app_")
+
+	notes := sess.Collection("notes")
+	assert.Equal(t, "app_2_notes", notes.Name())
+	_, err := notes.Insert(map[string]interface{}{"name": "first"})
+	assert.NoError(t, err)
+
+	err = sess.Tx(nil, func(tx sqlbuilder.Tx) error {
+		_, err := tx.Collection("accounts").Insert(map[string]interface{}{"name": "joe"})
+		return err
+	})
+	assert.NoError(t, err)
+
+	count, err := sess.Collection("accounts").Find().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	sess.SetTablePrefix("")
+	assert.Equal(t, "notes", sess.Collection("notes").Name())
+}
+
+func TestStrictIdentifiers(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE IF NOT EXISTS strict_notes (id INTEGER PRIMARY KEY, "first name" TEXT)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE strict_notes`)
+
+	sess.SetStrictIdentifiers(true)
+
+	notes := sess.Collection("strict_notes")
+	_, err = notes.Insert(map[string]interface{}{"first name": "joe"})
+	assert.Equal(t, sqlbuilder.ErrInvalidIdentifier, err)
+
+	_, err = sess.InsertInto("strict_notes").Columns(sess.Quote("first name")).Values("joe").Exec()
+	assert.Equal(t, sqlbuilder.ErrInvalidIdentifier, err)
+
+	_, err = sess.Exec(`INSERT INTO strict_notes (`+sess.Quote("first name")+`) VALUES (?)`, "joe")
+	assert.NoError(t, err)
+
+	count, err := notes.Find(db.Cond{db.Ident("first name"): "joe"}).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	_, err = notes.Find(db.Cond{"id = 1 OR 1 =": 1}).Count()
+	assert.Equal(t, sqlbuilder.ErrInvalidIdentifier, err)
+}
+
+func TestQueryStats(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE IF NOT EXISTS stats_notes (id INTEGER PRIMARY KEY, name TEXT)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE stats_notes`)
+
+	sess.SetQueryStats(true)
+
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := sess.InsertInto("stats_notes").Values(map[string]interface{}{"name": name}).Exec()
+		assert.NoError(t, err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		var notes []map[string]interface{}
+		err := sess.SelectFrom("stats_notes").Where("id >=", i).All(&notes)
+		assert.NoError(t, err)
+	}
+
+	stats := sess.Stats()
+
+	top := stats.TopByCount(2)
+	if assert.Len(t, top, 2) {
+		for _, q := range top {
+			assert.Equal(t, uint64(3), q.Count)
+			assert.True(t, q.MaxDuration >= q.MinDuration)
+		}
+	}
+
+	rows := stats.TopByRows(1)
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, `SELECT * FROM "stats_notes" WHERE ("id" >= ?)`, rows[0].Fingerprint)
+		assert.Equal(t, uint64(6), rows[0].Rows)
+	}
+
+	sess.ResetStats()
+	assert.Empty(t, sess.Stats().Queries)
+}
+
+func TestSlowQuery(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	var slow []*db.SlowQuery
+	sess.SetSlowQueryThreshold(time.Nanosecond, func(ctx context.Context, q *db.SlowQuery) {
+		slow = append(slow, q)
+	})
+
+	_, err := sess.Exec(`SELECT ?`, 42)
+	assert.NoError(t, err)
+
+	if assert.Len(t, slow, 1) {
+		assert.Equal(t, `SELECT ?`, slow[0].Query)
+		assert.Equal(t, []interface{}{42}, slow[0].Args)
+		assert.Equal(t, time.Nanosecond, slow[0].Threshold)
+		assert.True(t, slow[0].Duration() >= time.Nanosecond)
+		assert.True(t, strings.HasPrefix(slow[0].Stack, "upper.io/db.v3/sqlite.TestSlowQuery"), slow[0].Stack)
+	}
+
+	sess.SetSlowQueryThreshold(time.Hour, func(ctx context.Context, q *db.SlowQuery) {
+		slow = append(slow, q)
+	})
+
+	_, err = sess.Exec(`SELECT ?`, 42)
+	assert.NoError(t, err)
+	assert.Len(t, slow, 1)
+}
+
+type autoMigrateUser struct {
+	ID    int64  `db:"id,pk,omitempty"`
+	Email string `db:"email,unique"`
+}
+
+func (autoMigrateUser) TableName() string {
+	return "auto_migrate_users"
+}
+
+type autoMigrateUserV2 struct {
+	ID       int64   `db:"id,pk,omitempty"`
+	Email    string  `db:"email,unique"`
+	Nickname *string `db:"nickname,index"`
+	Visits   *int64  `db:"visits"`
+}
+
+type autoMigrateUserV3 struct {
+	ID    int64  `db:"id,pk,omitempty"`
+	Email string `db:"email,unique"`
+	Age   int    `db:"age"`
+}
+
+func (autoMigrateUserV3) TableName() string {
+	return "auto_migrate_users"
+}
+
+func (autoMigrateUserV2) TableName() string {
+	return "auto_migrate_users"
+}
+
+func TestAutoMigrate(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	defer sess.Exec(`DROP TABLE IF EXISTS auto_migrate_users`)
+
+	queries, err := sess.AutoMigrateDryRun(&autoMigrateUser{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`CREATE TABLE "auto_migrate_users" ("id" INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL, "email" VARCHAR(255) NOT NULL)`,
+		`CREATE UNIQUE INDEX "auto_migrate_users_email_key" ON "auto_migrate_users" ("email")`,
+	}, queries)
+	assert.False(t, sess.Collection("auto_migrate_users").Exists())
+
+	assert.NoError(t, sess.AutoMigrate(&autoMigrateUser{}))
+	assert.True(t, sess.Collection("auto_migrate_users").Exists())
+
+	queries, err = sess.AutoMigrateDryRun(&autoMigrateUser{})
+	assert.NoError(t, err)
+	assert.Empty(t, queries)
+
+	_, err = sess.Collection("auto_migrate_users").Insert(autoMigrateUser{Email: "ana@example.com"})
+	assert.NoError(t, err)
+
+	queries, err = sess.AutoMigrateDryRun(&autoMigrateUserV2{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`ALTER TABLE "auto_migrate_users" ADD COLUMN "nickname" VARCHAR(255); ALTER TABLE "auto_migrate_users" ADD COLUMN "visits" INTEGER`,
+		`CREATE INDEX "auto_migrate_users_nickname_idx" ON "auto_migrate_users" ("nickname")`,
+	}, queries)
+
+	assert.NoError(t, sess.AutoMigrate(&autoMigrateUserV2{}))
+
+	var users []autoMigrateUserV2
+	assert.NoError(t, sess.Collection("auto_migrate_users").Find().All(&users))
+	if assert.Len(t, users, 1) {
+		assert.Equal(t, "ana@example.com", users[0].Email)
+		assert.Nil(t, users[0].Nickname)
+	}
+
+	_, err = sess.AutoMigrateDryRun(&autoMigrateUserV3{})
+	assert.Error(t, err)
+}
+
+func TestFixtures(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	_, err := sess.Exec(`CREATE TABLE fixture_users (id INTEGER PRIMARY KEY, email VARCHAR(255))`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE fixture_users`)
+
+	_, err = sess.Exec(`CREATE TABLE fixture_orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES fixture_users(id), items TEXT)`)
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE fixture_orders`)
+
+	refs, err := sess.(*database).ReferencedTables("fixture_orders")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fixture_users"}, refs)
+
+	f := fixtures.New()
+	err = f.Parse("fixtures.yml", []byte(`
+fixture_orders:
+  - id: 1
+    user_id: 1
+    items: [{sku: "A-1", qty: 2}]
+fixture_users:
+  - id: 1
+    email: ana@example.com
+`))
+	assert.NoError(t, err)
+
+	t.Run("Setup", func(t *testing.T) {
+		f.Setup(t, sess)
+
+		count, err := sess.Collection("fixture_orders").Find().Count()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), count)
+
+		var order struct {
+			Items string `db:"items"`
+		}
+		assert.NoError(t, sess.Collection("fixture_orders").Find(1).One(&order))
+		assert.Equal(t, `[{"qty":2,"sku":"A-1"}]`, order.Items)
+	})
+
+	count, err := sess.Collection("fixture_users").Find().Count()
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	t.Run("Tx", func(t *testing.T) {
+		tx := f.Tx(t, sess)
+
+		count, err := tx.Collection("fixture_users").Find().Count()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), count)
+	})
+
+	count, err = sess.Collection("fixture_users").Find().Count()
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestFTS5(t *testing.T) {
+	sess := mustOpen()
+	defer sess.Close()
+
+	err := CreateFTS5Table(sess, "fts_docs", []string{"title", "body"}, &FTS5Options{Tokenize: "porter"})
+	if err != nil && strings.Contains(err.Error(), "no such module") {
+		t.Skip("FTS5 is not available, build with the sqlite_fts5 tag.")
+	}
+	assert.NoError(t, err)
+	defer sess.Exec(`DROP TABLE fts_docs`)
+
+	_, err = sess.InsertInto("fts_docs").
+		Columns("title", "body").
+		Values("Going fast", "SQLite runs everywhere").
+		Values("SQLite tips", "Full-text search with SQLite and FTS5").
+		Values("Unrelated", "Nothing to see here").
+		Exec()
+	assert.NoError(t, err)
+
+	var titles []struct {
+		Title string `db:"title"`
+	}
+	err = sess.Select(Highlight("fts_docs", 0, "[", "]") + " AS title").From("fts_docs").
+		Where(Match("fts_docs", "sqlite")).
+		OrderBy(Rank).
+		All(&titles)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(titles)) {
+		assert.Equal(t, "[SQLite] tips", titles[0].Title)
+	}
+}
+
+func TestOnConnect(t *testing.T) {
+	var calls int32
+
+	connURL := settings
+	connURL.OnConnect = func(conn db.Conn) error {
+		atomic.AddInt32(&calls, 1)
+		return conn.Exec("PRAGMA foreign_keys = ON")
+	}
+
+	sess, err := Open(connURL)
+	assert.NoError(t, err)
+	defer sess.Close()
+
+	row, err := sess.QueryRow("PRAGMA foreign_keys")
+	assert.NoError(t, err)
+
+	var enabled int
+	assert.NoError(t, row.Scan(&enabled))
+	assert.Equal(t, 1, enabled)
+	assert.True(t, atomic.LoadInt32(&calls) > 0)
+
+	connURL.OnConnect = func(conn db.Conn) error {
+		return errors.New("refused")
+	}
+
+	_, err = Open(connURL)
+	assert.Error(t, err)
+}
+
+func TestWithSchema(t *testing.T) {
+	tenantDB := settings.Database + ".tenant_1"
+	os.Remove(tenantDB)
+	defer os.Remove(tenantDB)
+
+	connURL := settings
+	connURL.OnConnect = func(conn db.Conn) error {
+		return conn.Exec("ATTACH DATABASE ? AS tenant_1", tenantDB)
+	}
+
+	sess, err := Open(connURL)
+	assert.NoError(t, err)
+	defer sess.Close()
+
+	_, err = sess.Exec(`CREATE TABLE tenant_1.artist (
+		id integer primary key,
+		name varchar(60)
+	)`)
+	assert.NoError(t, err)
+
+	tenant, err := sess.WithSchema("tenant_1")
+	assert.NoError(t, err)
+
+	same, err := sess.WithSchema("tenant_1")
+	assert.NoError(t, err)
+	assert.True(t, tenant == same)
+
+	artist := tenant.Collection("artist")
+	assert.Equal(t, "tenant_1.artist", artist.Name())
+	assert.True(t, artist.Exists())
+
+	id, err := artist.Insert(map[string]string{"name": "Ozzie"})
+	assert.NoError(t, err)
+	assert.NotNil(t, id)
+
+	count, err := artist.Find().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	var item struct {
+		ID   int64  `db:"id,omitempty"`
+		Name string `db:"name"`
+	}
+	assert.NoError(t, artist.Find(id).One(&item))
+	assert.Equal(t, "Ozzie", item.Name)
+
+	collections, err := tenant.Collections()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"artist"}, collections)
+
+	// The main database is not affected.
+	count, err = sess.Collection("artist").Find(db.Cond{"name": "Ozzie"}).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), count)
+
+	for _, name := range []string{"", "tenant.1", `tenant"1`, "tenant 1", "tenant;1"} {
+		_, err = sess.WithSchema(name)
+		assert.Equal(t, db.ErrInvalidSchemaName, err, name)
+	}
+}
@@ -22,19 +22,43 @@
 package sqlite
 
 import (
+	"database/sql"
 	"fmt"
 	"net/url"
 	"path/filepath"
 	"runtime"
 	"strings"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/internal/sqladapter"
 )
 
 const connectionScheme = `file`
 
 // ConnectionURL implements a SQLite connection struct.
+//
+// OnConnect is called on every new connection of the pool before it's used,
+// like to set pragmas that only apply to the connection they run on:
+//
+//	settings.OnConnect = func(conn db.Conn) error {
+//		return conn.Exec("PRAGMA foreign_keys = ON")
+//	}
 type ConnectionURL struct {
 	Database string
 	Options  map[string]string
+
+	OnConnect func(db.Conn) error
+}
+
+// openDB opens a pool of connections to the database of connURL.
+func openDB(connURL db.ConnectionURL) (*sql.DB, error) {
+	switch c := connURL.(type) {
+	case ConnectionURL:
+		return sqladapter.OpenDB("sqlite3", c.String(), nil, c.OnConnect)
+	case *ConnectionURL:
+		return sqladapter.OpenDB("sqlite3", c.String(), nil, c.OnConnect)
+	}
+	return sql.Open("sqlite3", connURL.String())
 }
 
 func (c ConnectionURL) String() (s string) {
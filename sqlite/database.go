@@ -29,10 +29,12 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
-	_ "github.com/mattn/go-sqlite3" // SQLite3 driver.
+	"github.com/mattn/go-sqlite3" // SQLite3 driver.
 	"upper.io/db.v3"
 	"upper.io/db.v3/internal/sqladapter"
 	"upper.io/db.v3/internal/sqladapter/compat"
@@ -102,7 +104,7 @@ func (d *database) NewTx(ctx context.Context) (sqlbuilder.Tx, error) {
 // Collections returns a list of non-system tables from the database.
 func (d *database) Collections() (collections []string, err error) {
 	q := d.Select("tbl_name").
-		From("sqlite_master").
+		From(masterTable(d.Schema())).
 		Where("type = ?", "table")
 
 	iter := q.Iterator()
@@ -129,7 +131,7 @@ func (d *database) open() error {
 	openFn := func() error {
 		openFiles := atomic.LoadInt32(&fileOpenCount)
 		if openFiles < maxOpenFiles {
-			sess, err := sql.Open("sqlite3", d.ConnectionURL().String())
+			sess, err := openDB(d.ConnectionURL())
 			if err == nil {
 				if err := d.BaseDatabase.BindSession(sess); err != nil {
 					return err
@@ -175,16 +177,53 @@ func (d *database) CompileStatement(stmt *exql.Statement, args []interface{}) (s
 	return sqlbuilder.Preprocess(compiled, args)
 }
 
-// Err allows sqladapter to translate some known errors into generic errors.
+// Err allows sqladapter to translate some known errors into generic errors,
+// constraint violations are wrapped into a *db.Error.
 func (d *database) Err(err error) error {
 	if err != nil {
 		if err == errTooManyOpenFiles {
 			return db.ErrTooManyClients
 		}
+		if e, ok := err.(sqlite3.Error); ok && e.Code == sqlite3.ErrConstraint {
+			if dbErr := constraintErr(e.ExtendedCode, e.Error()); dbErr != nil {
+				dbErr.Err = err
+				return dbErr
+			}
+		}
 	}
 	return err
 }
 
+// constraintErr returns a *db.Error for the given extended result code, the
+// names of the table and columns involved are taken from messages like
+// "UNIQUE constraint failed: artist.name".
+func constraintErr(code sqlite3.ErrNoExtended, msg string) *db.Error {
+	var dbErr *db.Error
+
+	switch code {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		dbErr = &db.Error{Kind: db.ErrUniqueViolation}
+		if i := strings.Index(msg, "failed: "); i >= 0 {
+			for _, column := range strings.Split(msg[i+len("failed: "):], ",") {
+				column = strings.TrimSpace(column)
+				if j := strings.Index(column, "."); j >= 0 {
+					dbErr.Table, column = column[:j], column[j+1:]
+				}
+				dbErr.Columns = append(dbErr.Columns, column)
+			}
+		}
+	case sqlite3.ErrConstraintForeignKey:
+		dbErr = &db.Error{Kind: db.ErrForeignKeyViolation}
+	case sqlite3.ErrConstraintCheck:
+		dbErr = &db.Error{Kind: db.ErrCheckViolation}
+		if i := strings.Index(msg, "failed: "); i >= 0 {
+			dbErr.Constraint = strings.TrimSpace(msg[i+len("failed: "):])
+		}
+	}
+
+	return dbErr
+}
+
 // StatementExec wraps the statement to execute around a transaction.
 func (d *database) StatementExec(ctx context.Context, query string, args ...interface{}) (res sql.Result, err error) {
 	d.mu.Lock()
@@ -200,6 +239,7 @@ func (d *database) StatementExec(ctx context.Context, query string, args ...inte
 	}
 
 	if res, err = compat.ExecContext(sqlTx, ctx, query, args); err != nil {
+		_ = sqlTx.Rollback()
 		return nil, err
 	}
 
@@ -258,9 +298,11 @@ func (d *database) LookupName() (string, error) {
 // TableExists allows sqladapter check whether a table exists and returns an
 // error in case it doesn't.
 func (d *database) TableExists(name string) error {
+	schema, table := sqladapter.SplitTableName(name)
+
 	q := d.Select("tbl_name").
-		From("sqlite_master").
-		Where("type = 'table' AND tbl_name = ?", name)
+		From(masterTable(schema)).
+		Where("type = 'table' AND tbl_name = ?", table)
 
 	iter := q.Iterator()
 	defer iter.Close()
@@ -275,11 +317,23 @@ func (d *database) TableExists(name string) error {
 	return db.ErrCollectionDoesNotExist
 }
 
+// masterTable returns the name of the table that describes the schema of the
+// given attached database, or the one of the main database if it's empty.
+func masterTable(schema string) string {
+	if schema == "" {
+		return "sqlite_master"
+	}
+	return schema + ".sqlite_master"
+}
+
 // PrimaryKeys allows sqladapter find a table's primary keys.
 func (d *database) PrimaryKeys(tableName string) ([]string, error) {
 	pk := make([]string, 0, 1)
 
 	stmt := exql.RawSQL(fmt.Sprintf("PRAGMA TABLE_INFO('%s')", tableName))
+	if schema, table := sqladapter.SplitTableName(tableName); schema != "" {
+		stmt = exql.RawSQL(fmt.Sprintf(`PRAGMA "%s".TABLE_INFO('%s')`, schema, table))
+	}
 
 	rows, err := d.Query(stmt)
 	if err != nil {
@@ -314,8 +368,96 @@ func (d *database) PrimaryKeys(tableName string) ([]string, error) {
 	return pk, nil
 }
 
+// Indexes returns the names of the indexes of the table.
+func (d *database) Indexes(tableName string) ([]string, error) {
+	schema, table := sqladapter.SplitTableName(tableName)
+
+	q := d.Select("name").
+		From(masterTable(schema)).
+		Where("type = 'index' AND tbl_name = ?", table)
+
+	iter := q.Iterator()
+	defer iter.Close()
+
+	indexes := []string{}
+
+	for iter.Next() {
+		var name string
+		if err := iter.Scan(&name); err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, name)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
+
+// ReferencedTables returns the names of the tables the foreign keys of the
+// table point to.
+func (d *database) ReferencedTables(tableName string) ([]string, error) {
+	stmt := exql.RawSQL(fmt.Sprintf("PRAGMA FOREIGN_KEY_LIST('%s')", tableName))
+	if schema, table := sqladapter.SplitTableName(tableName); schema != "" {
+		stmt = exql.RawSQL(fmt.Sprintf(`PRAGMA "%s".FOREIGN_KEY_LIST('%s')`, schema, table))
+	}
+
+	rows, err := d.Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []struct {
+		Table string `db:"table"`
+	}{}
+
+	if err := sqlbuilder.NewIterator(rows).All(&keys); err != nil {
+		return nil, err
+	}
+
+	tables := []string{}
+	seen := map[string]bool{}
+
+	for _, key := range keys {
+		if !seen[key.Table] {
+			seen[key.Table] = true
+			tables = append(tables, key.Table)
+		}
+	}
+
+	return tables, nil
+}
+
 // WithContext creates a copy of the session on the given context.
 func (d *database) WithContext(ctx context.Context) sqlbuilder.Database {
 	newDB, _ := d.clone(ctx, false)
 	return newDB
 }
+
+// WithSchema returns a session whose collections belong to the given attached
+// database.
+func (d *database) WithSchema(schema string) (sqlbuilder.Database, error) {
+	sess, err := d.SchemaSession(schema, func() (sqladapter.Database, error) {
+		clone, err := d.clone(d.Context(), false)
+		if err != nil {
+			return nil, err
+		}
+		return clone, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sess.(*database), nil
+}
+
+// WithRecordCache returns a copy of the session whose primary key lookups,
+// like col.Find(id).One(&item), read items from the given store.
+func (d *database) WithRecordCache(store db.QueryCache, ttl time.Duration) (sqlbuilder.Database, error) {
+	clone, err := d.clone(d.Context(), false)
+	if err != nil {
+		return nil, err
+	}
+	clone.SetRecordCache(store, ttl)
+	return clone, nil
+}
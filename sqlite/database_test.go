@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+)
+
+func TestErr(t *testing.T) {
+	d := &database{}
+
+	{
+		dbErr := constraintErr(sqlite3.ErrConstraintUnique, "UNIQUE constraint failed: artist.name, artist.id")
+		if assert.NotNil(t, dbErr) {
+			assert.Equal(t, db.ErrUniqueViolation, dbErr.Kind)
+			assert.Equal(t, "artist", dbErr.Table)
+			assert.Equal(t, []string{"name", "id"}, dbErr.Columns)
+		}
+	}
+
+	{
+		dbErr := constraintErr(sqlite3.ErrConstraintForeignKey, "FOREIGN KEY constraint failed")
+		if assert.NotNil(t, dbErr) {
+			assert.Equal(t, db.ErrForeignKeyViolation, dbErr.Kind)
+			assert.Nil(t, dbErr.Columns)
+		}
+	}
+
+	{
+		dbErr := constraintErr(sqlite3.ErrConstraintCheck, "CHECK constraint failed: artist_name_check")
+		if assert.NotNil(t, dbErr) {
+			assert.Equal(t, db.ErrCheckViolation, dbErr.Kind)
+			assert.Equal(t, "artist_name_check", dbErr.Constraint)
+		}
+	}
+
+	assert.Nil(t, constraintErr(sqlite3.ErrConstraintNotNull, "NOT NULL constraint failed: artist.name"))
+
+	err := d.Err(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintPrimaryKey})
+	assert.Equal(t, db.ErrUniqueViolation, db.ErrorKind(err))
+
+	assert.Equal(t, db.ErrTooManyClients, d.Err(errTooManyOpenFiles))
+	assert.Nil(t, d.Err(nil))
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sqlite
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// planRow is a row returned by EXPLAIN QUERY PLAN.
+type planRow struct {
+	id     int
+	parent int
+	detail string
+}
+
+// Explain wraps a query into an EXPLAIN QUERY PLAN statement, SQLite can't
+// analyze queries.
+func (d *database) Explain(query string, analyze bool) (string, error) {
+	if analyze {
+		return "", db.ErrUnsupported
+	}
+	return "EXPLAIN QUERY PLAN " + query, nil
+}
+
+// ScanQueryPlan reads the rows returned by an EXPLAIN QUERY PLAN statement.
+func (d *database) ScanQueryPlan(rows *sql.Rows) (*sqlbuilder.QueryPlan, error) {
+	var planRows []planRow
+	for rows.Next() {
+		var r planRow
+		var notUsed interface{}
+		if err := rows.Scan(&r.id, &r.parent, &notUsed, &r.detail); err != nil {
+			return nil, err
+		}
+		planRows = append(planRows, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return buildQueryPlan(planRows), nil
+}
+
+// buildQueryPlan arranges the rows of EXPLAIN QUERY PLAN into a tree, rows
+// refer to their parent by id and parents come before their children.
+func buildQueryPlan(planRows []planRow) *sqlbuilder.QueryPlan {
+	plan := &sqlbuilder.QueryPlan{}
+
+	var raw bytes.Buffer
+	nodes := map[int]*sqlbuilder.PlanNode{}
+	for _, r := range planRows {
+		raw.WriteString(r.detail)
+		raw.WriteString("\n")
+
+		node := &sqlbuilder.PlanNode{
+			Operation:  r.detail,
+			Attributes: map[string]interface{}{},
+		}
+		// Details look like "SCAN artist" or "SEARCH TABLE artist USING ...".
+		fields := strings.Fields(r.detail)
+		if len(fields) > 1 && (fields[0] == "SCAN" || fields[0] == "SEARCH") {
+			node.Table = fields[1]
+			if node.Table == "TABLE" && len(fields) > 2 {
+				node.Table = fields[2]
+			}
+		}
+		nodes[r.id] = node
+
+		if parent, ok := nodes[r.parent]; ok && r.parent != r.id {
+			parent.Children = append(parent.Children, node)
+		} else {
+			plan.Nodes = append(plan.Nodes, node)
+		}
+	}
+
+	plan.Raw = raw.String()
+	return plan
+}
@@ -0,0 +1,31 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"upper.io/db.v3"
+)
+
+func TestQueryPlan(t *testing.T) {
+	plan := buildQueryPlan([]planRow{
+		{id: 2, parent: 0, detail: "MATERIALIZE v"},
+		{id: 5, parent: 2, detail: "SCAN publication"},
+		{id: 11, parent: 0, detail: "SCAN v"},
+		{id: 13, parent: 0, detail: "SEARCH TABLE artist USING INTEGER PRIMARY KEY (rowid=?)"},
+	})
+
+	assert.Equal(t, "MATERIALIZE v\nSCAN publication\nSCAN v\nSEARCH TABLE artist USING INTEGER PRIMARY KEY (rowid=?)\n", plan.Raw)
+
+	if assert.Equal(t, 3, len(plan.Nodes)) {
+		assert.Equal(t, "", plan.Nodes[0].Table)
+		if assert.Equal(t, 1, len(plan.Nodes[0].Children)) {
+			assert.Equal(t, "publication", plan.Nodes[0].Children[0].Table)
+		}
+		assert.Equal(t, "v", plan.Nodes[1].Table)
+		assert.Equal(t, "artist", plan.Nodes[2].Table)
+	}
+
+	_, err := (&database{}).Explain("SELECT 1", true)
+	assert.Equal(t, db.ErrUnsupported, err)
+}
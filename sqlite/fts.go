@@ -0,0 +1,118 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sqlite
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	"upper.io/db.v3"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+// Rank is the hidden column of FTS5 tables that scores how well a row
+// matches a query, lower values are better matches:
+//
+//	sess.SelectFrom("docs").Where(sqlite.Match("docs", "sqlite")).OrderBy(sqlite.Rank)
+const Rank = "rank"
+
+// FTS5Options are the options of a FTS5 table, see CreateFTS5Table.
+type FTS5Options struct {
+	// Content is the name of the table that holds the indexed data for an
+	// external content table.
+	Content string
+
+	// ContentRowID is the integer primary key of the Content table.
+	ContentRowID string
+
+	// Tokenize defines the tokenizer, like "porter unicode61".
+	Tokenize string
+
+	// Prefix lists the prefix lengths to index, like "2 3".
+	Prefix string
+}
+
+// CreateFTS5Table creates a FTS5 virtual table for full-text search, unless
+// it already exists. FTS5 must be enabled in the SQLite library, go-sqlite3
+// requires the sqlite_fts5 build tag.
+//
+//	err = sqlite.CreateFTS5Table(sess, "docs", []string{"title", "body"}, nil)
+func CreateFTS5Table(sess sqlbuilder.Database, table string, columns []string, options *FTS5Options) error {
+	if len(columns) == 0 {
+		return errors.New("upper: FTS5 tables require at least one column")
+	}
+
+	var b bytes.Buffer
+	b.WriteString("CREATE VIRTUAL TABLE IF NOT EXISTS ")
+	b.WriteString(quoteIdentifier(table))
+	b.WriteString(" USING fts5(")
+	for i := range columns {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(quoteIdentifier(columns[i]))
+	}
+	if options != nil {
+		for _, opt := range []struct{ name, value string }{
+			{"content", options.Content},
+			{"content_rowid", options.ContentRowID},
+			{"tokenize", options.Tokenize},
+			{"prefix", options.Prefix},
+		} {
+			if opt.value != "" {
+				b.WriteString(", " + opt.name + " = " + quoteString(opt.value))
+			}
+		}
+	}
+	b.WriteString(")")
+
+	_, err := sess.Exec(b.String())
+	return err
+}
+
+// Match returns a condition that matches the rows of a FTS5 table against a
+// full-text query, see https://www.sqlite.org/fts5.html for the query syntax.
+//
+//	res := sess.Collection("docs").Find(sqlite.Match("docs", "title: sqlite")).OrderBy(sqlite.Rank)
+func Match(table, query string) db.RawValue {
+	return db.Raw(quoteIdentifier(table)+" MATCH ?", query)
+}
+
+// Highlight returns an expression that returns the text of the given column
+// of a FTS5 table with the matched terms enclosed by open and close, see
+// JSONExtract for how to use expressions.
+//
+//	sess.Select("rowid", sqlite.Highlight("docs", 0, "<b>", "</b>")+" AS title").
+//		From("docs").
+//		Where(sqlite.Match("docs", "sqlite"))
+func Highlight(table string, column int, open, close string) string {
+	return "highlight(" + quoteIdentifier(table) + ", " + strconv.Itoa(column) + ", " + quoteString(open) + ", " + quoteString(close) + ")"
+}
+
+// Snippet returns an expression that returns a fragment of up to the given
+// number of tokens of the given column of a FTS5 table, with the matched
+// terms enclosed by open and close, and ellipsis added where the text was
+// trimmed. A column of -1 picks the column that matches best.
+func Snippet(table string, column int, open, close, ellipsis string, tokens int) string {
+	return "snippet(" + quoteIdentifier(table) + ", " + strconv.Itoa(column) + ", " + quoteString(open) + ", " + quoteString(close) + ", " + quoteString(ellipsis) + ", " + strconv.Itoa(tokens) + ")"
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sqlite
+
+import (
+	"strings"
+
+	"upper.io/db.v3/internal/sqladapter/exql"
+)
+
+// JSONExtract returns an expression that reads the value at the given path
+// of a JSON column with the json_extract() function of SQLite's JSON1
+// extension. The expression can be used as a column or as a condition key,
+// followed by an operator if needed:
+//
+//	sess.Select("id", sqlite.JSONExtract("data", "$.name")+" AS name").From("users")
+//
+//	db.Cond{sqlite.JSONExtract("data", "$.status"): "active"}
+//	db.Cond{sqlite.JSONExtract("data", "$.tags[0]") + " IN": []string{"go", "sql"}}
+func JSONExtract(column, path string) string {
+	return "json_extract(" + quoteIdentifier(column) + ", " + quoteString(path) + ")"
+}
+
+// JSONArrayLength returns an expression that counts the elements of the JSON
+// array at the given path of a JSON column, see JSONExtract.
+//
+//	db.Cond{sqlite.JSONArrayLength("data", "$.tags") + " >": 2}
+func JSONArrayLength(column, path string) string {
+	return "json_array_length(" + quoteIdentifier(column) + ", " + quoteString(path) + ")"
+}
+
+func quoteIdentifier(name string) string {
+	s, err := exql.ColumnWithName(name).Compile(template)
+	if err != nil {
+		panic(err.Error())
+	}
+	return s
+}
+
+func quoteString(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
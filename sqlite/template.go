@@ -42,9 +42,16 @@ const (
 	adapterClauseGroup         = `({{.}})`
 	adapterClauseOperator      = ` {{.}} `
 	adapterColumnValue         = `{{.Column}} {{.Operator}} {{.Value}}`
+	adapterILikeLayout         = `LOWER({{.Column}}) {{.Operator}} LOWER({{.Value}})`
+	adapterRankLayout          = `-rank`
 	adapterTableAliasLayout    = `{{.Name}}{{if .Alias}} AS {{.Alias}}{{end}}`
 	adapterColumnAliasLayout   = `{{.Name}}{{if .Alias}} AS {{.Alias}}{{end}}`
 	adapterSortByColumnLayout  = `{{.Column}} {{.Order}}`
+	adapterWithLayout          = `WITH {{if .Recursive}}RECURSIVE {{end}}{{.Clauses}}`
+	adapterOnConflictLayout    = `ON CONFLICT {{if .Columns}}({{.Columns}}) {{end}}{{if .ColumnValues}}DO UPDATE SET {{.ColumnValues}} {{.Where}}{{else}}DO NOTHING{{end}}`
+	adapterExcludedLayout      = `EXCLUDED.{{.}}`
+	adapterTupleInLayout       = `({{.Columns}}) {{if .Negate}}NOT {{end}}IN ({{.Values}})`
+	adapterValuesListLayout    = `{{if .Columns}}(SELECT {{range $i, $c := .Columns}}{{if $i}}, {{end}}column{{$c.Position}} AS {{$c.Name}}{{end}} FROM (VALUES {{range $i, $r := .Rows}}{{if $i}}, {{end}}({{$r}}){{end}})){{else}}(VALUES {{range $i, $r := .Rows}}{{if $i}}, {{end}}({{$r}}){{end}}){{end}}{{if .Name}} AS {{.Name}}{{end}}`
 
 	adapterOrderByLayout = `
     {{if .SortColumns}}
@@ -125,12 +132,28 @@ const (
     DELETE
       FROM {{.Table}}
       {{.Where}}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
   `
 	adapterUpdateLayout = `
     UPDATE
       {{.Table}}
     SET {{.ColumnValues}}
       {{ .Where }}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
+  `
+	adapterUpdateFromLayout = `
+    UPDATE
+      {{.Table}}
+    SET {{.ColumnValues}}
+    FROM {{.Sources}}
+      {{ .Where }}
+    {{if .Returning}}
+      RETURNING {{.Returning}}
+    {{end}}
   `
 
 	adapterSelectCountLayout = `
@@ -149,6 +172,7 @@ const (
     {{else}}
       DEFAULT VALUES
     {{end}}
+    {{.OnConflict}}
     {{if .Returning}}
       RETURNING {{.Returning}}
     {{end}}
@@ -173,10 +197,42 @@ const (
   `
 )
 
+const (
+	adapterCreateTableLayout      = `CREATE TABLE {{if .IfNotExists}}IF NOT EXISTS {{end}}{{.Table}} ({{.Definitions}}{{if .PrimaryKey}}, PRIMARY KEY ({{.PrimaryKey}}){{end}})`
+	adapterCreateIndexLayout      = `CREATE {{if .Unique}}UNIQUE {{end}}INDEX {{.Index}} ON {{.Table}} ({{.Columns}})`
+	adapterAddColumnLayout        = `ALTER TABLE {{.Table}} ADD COLUMN {{.Column}}`
+	adapterDropColumnLayout       = `ALTER TABLE {{.Table}} DROP COLUMN {{.Column}}`
+	adapterRenameColumnLayout     = `ALTER TABLE {{.Table}} RENAME COLUMN {{.Column}} TO {{.NewColumn}}`
+	adapterColumnDefinitionLayout = `{{.Name}} {{.Type}}{{if .PrimaryKey}} PRIMARY KEY{{if .AutoIncrement}} AUTOINCREMENT{{end}}{{end}}{{if .NotNull}} NOT NULL{{end}}{{if .Default}} DEFAULT {{.Default}}{{end}}{{if .Unique}} UNIQUE{{end}}`
+	adapterTrueKeyword            = `TRUE`
+	adapterFalseKeyword           = `FALSE`
+)
+
+// Auto incremented keys must be INTEGER PRIMARY KEY on SQLite, so all
+// integer types are INTEGER.
+var adapterColumnTypes = map[string]string{
+	"boolean":   `BOOLEAN`,
+	"smallint":  `INTEGER`,
+	"integer":   `INTEGER`,
+	"bigint":    `INTEGER`,
+	"float":     `REAL`,
+	"decimal":   `NUMERIC{{if .Size}}({{.Size}}, {{.Scale}}){{end}}`,
+	"string":    `VARCHAR({{.Size}})`,
+	"text":      `TEXT`,
+	"bytes":     `BLOB`,
+	"date":      `DATE`,
+	"timestamp": `DATETIME`,
+	"interval":  `TEXT`,
+	"json":      `TEXT`,
+	"uuid":      `CHAR(36)`,
+	"enum":      `TEXT CHECK ({{.Name}} IN ({{.Values}}))`,
+}
+
 var template = &exql.Template{
 	ColumnSeparator:     adapterColumnSeparator,
 	IdentifierSeparator: adapterIdentifierSeparator,
 	IdentifierQuote:     adapterIdentifierQuote,
+	ILikeLayout:         adapterILikeLayout,
 	ValueSeparator:      adapterValueSeparator,
 	ValueQuote:          adapterValueQuote,
 	AndKeyword:          adapterAndKeyword,
@@ -193,18 +249,35 @@ var template = &exql.Template{
 	ColumnAliasLayout:   adapterColumnAliasLayout,
 	SortByColumnLayout:  adapterSortByColumnLayout,
 	WhereLayout:         adapterWhereLayout,
+	WithLayout:          adapterWithLayout,
+	OnConflictLayout:    adapterOnConflictLayout,
+	ExcludedLayout:      adapterExcludedLayout,
 	JoinLayout:          adapterJoinLayout,
 	OnLayout:            adapterOnLayout,
 	UsingLayout:         adapterUsingLayout,
+	ValuesListLayout:    adapterValuesListLayout,
 	OrderByLayout:       adapterOrderByLayout,
+	RankLayout:          adapterRankLayout,
 	InsertLayout:        adapterInsertLayout,
 	SelectLayout:        adapterSelectLayout,
 	UpdateLayout:        adapterUpdateLayout,
+	UpdateFromLayout:    adapterUpdateFromLayout,
 	DeleteLayout:        adapterDeleteLayout,
 	TruncateLayout:      adapterTruncateLayout,
+	TupleInLayout:       adapterTupleInLayout,
 	DropDatabaseLayout:  adapterDropDatabaseLayout,
 	DropTableLayout:     adapterDropTableLayout,
 	CountLayout:         adapterSelectCountLayout,
 	GroupByLayout:       adapterGroupByLayout,
-	Cache:               cache.NewCache(),
+
+	CreateTableLayout:      adapterCreateTableLayout,
+	CreateIndexLayout:      adapterCreateIndexLayout,
+	AddColumnLayout:        adapterAddColumnLayout,
+	DropColumnLayout:       adapterDropColumnLayout,
+	RenameColumnLayout:     adapterRenameColumnLayout,
+	ColumnDefinitionLayout: adapterColumnDefinitionLayout,
+	ColumnTypes:            adapterColumnTypes,
+	TrueKeyword:            adapterTrueKeyword,
+	FalseKeyword:           adapterFalseKeyword,
+	Cache:                  cache.NewCache(),
 }
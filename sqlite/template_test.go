@@ -144,6 +144,19 @@ func TestTemplateSelect(t *testing.T) {
 		`SELECT DATE()`,
 		b.Select(db.Raw("DATE()")).String(),
 	)
+
+	assert.Equal(
+		`SELECT * FROM (SELECT column1 AS "id", column2 AS "title" FROM (VALUES ($1, $2), ($3, $4))) AS "v"`,
+		b.SelectFrom(db.Values([][]interface{}{{1, "Hamlet"}, {2, "Ulysses"}}).As("v(id, title)")).String(),
+	)
+
+	{
+		sel := b.SelectFrom("jobs").ForUpdate()
+		_, err := sel.(interface {
+			Compile() (string, error)
+		}).Compile()
+		assert.Error(err)
+	}
 }
 
 func TestTemplateInsert(t *testing.T) {
@@ -186,6 +199,18 @@ func TestTemplateInsert(t *testing.T) {
 		`INSERT INTO "artist" ("name", "id") VALUES ($1, $2)`,
 		b.InsertInto("artist").Columns("name", "id").Values("Chavela Vargas", 12).String(),
 	)
+
+	assert.Equal(
+		`INSERT INTO "artist" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO NOTHING`,
+		b.InsertInto("artist").Columns("id", "name").Values(12, "Chavela Vargas").OnConflict("id").DoNothing().String(),
+	)
+
+	assert.Equal(
+		`INSERT INTO "artist" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name" WHERE ("artist"."name" != $3)`,
+		b.InsertInto("artist").Columns("id", "name").Values(12, "Chavela Vargas").
+			OnConflict("id").DoUpdate(map[string]interface{}{"name": sqlbuilder.Excluded("name")}).
+			DoUpdateWhere(db.Cond{"artist.name !=": "Alondra de la Parra"}).String(),
+	)
 }
 
 func TestTemplateUpdate(t *testing.T) {
@@ -228,6 +253,16 @@ func TestTemplateUpdate(t *testing.T) {
 			"id = id + ?", 10,
 		).Where("id > ?", 0).String(),
 	)
+
+	assert.Equal(
+		`UPDATE "products" SET "price" = prices.amount FROM "prices" WHERE (products.id = prices.product_id)`,
+		b.Update("products").Set("price = prices.amount").From("prices").Where("products.id = prices.product_id").String(),
+	)
+
+	assert.Equal(
+		`UPDATE "artist" SET "name" = $1 WHERE ("id" = $2) RETURNING "id", "name"`,
+		b.Update("artist").Set("name", "Artist").Where(db.Cond{"id": 1}).Returning("id", "name").String(),
+	)
 }
 
 func TestTemplateDelete(t *testing.T) {
@@ -243,4 +278,116 @@ func TestTemplateDelete(t *testing.T) {
 		`DELETE FROM "artist" WHERE (id > 5)`,
 		b.DeleteFrom("artist").Where("id > 5").String(),
 	)
+
+	assert.Equal(
+		`DELETE FROM "artist" WHERE (id > 5) RETURNING "id"`,
+		b.DeleteFrom("artist").Where("id > 5").Returning("id").String(),
+	)
+
+	{
+		_, err := b.DeleteFrom("orders").Using("customers").Where("orders.customer_id = customers.id").(interface {
+			Compile() (string, error)
+		}).Compile()
+		assert.Error(err)
+	}
+}
+
+func TestTemplateJSON(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		`SELECT "id", json_extract("data", '$.name') AS "name" FROM "users"`,
+		b.Select("id", JSONExtract("data", "$.name")+" AS name").From("users").String(),
+	)
+
+	{
+		q := b.SelectFrom("users").Where(db.Cond{
+			JSONExtract("users.data", "$.tags[0]") + " IN": []string{"go", "sql"},
+		})
+		assert.Equal(
+			`SELECT * FROM "users" WHERE (json_extract("users"."data", '$.tags[0]') IN ($1, $2))`,
+			q.String(),
+		)
+		assert.Equal([]interface{}{"go", "sql"}, q.Arguments())
+	}
+
+	assert.Equal(
+		`SELECT * FROM "users" WHERE (json_array_length("data", '$.tags') > $1)`,
+		b.SelectFrom("users").Where(db.Cond{JSONArrayLength("data", "$.tags") + " >": 2}).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "users" WHERE (json_extract("data", '$.it''s') = $1)`,
+		b.SelectFrom("users").Where(db.Cond{JSONExtract("data", "$.it's"): 1}).String(),
+	)
+}
+
+func TestTemplateFTS5(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	q := b.Select("rowid", Highlight("docs", 0, "<b>", "</b>")+" AS title").
+		From("docs").
+		Where(Match("docs", "title: sqlite")).
+		OrderBy(Rank)
+	assert.Equal(
+		`SELECT "rowid", highlight("docs", 0, '<b>', '</b>') AS "title" FROM "docs" WHERE ("docs" MATCH $1) ORDER BY "rank" ASC`,
+		q.String(),
+	)
+	assert.Equal([]interface{}{"title: sqlite"}, q.Arguments())
+
+	assert.Equal(
+		`SELECT snippet("docs", -1, '[', ']', '...', 8) FROM "docs"`,
+		b.Select(Snippet("docs", -1, "[", "]", "...", 8)).From("docs").String(),
+	)
+
+	q = b.SelectFrom("docs").
+		Where(db.Match("body", "sqlite")).
+		OrderBy(db.Rank("body", "sqlite"))
+	assert.Equal(
+		`SELECT * FROM "docs" WHERE ("body" MATCH $1) ORDER BY -rank DESC`,
+		q.String(),
+	)
+	assert.Equal([]interface{}{"sqlite"}, q.Arguments())
+}
+
+func TestTemplateDDL(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		`CREATE TABLE "books" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "title" VARCHAR(255) NOT NULL, "body" TEXT, "public" BOOLEAN NOT NULL DEFAULT TRUE, "created_at" DATETIME)`,
+		b.CreateTable("books").
+			Columns(
+				sqlbuilder.Column{Name: "id", Type: sqlbuilder.BigInt, AutoIncrement: true},
+				sqlbuilder.Column{Name: "title", Type: sqlbuilder.String, NotNull: true},
+				sqlbuilder.Column{Name: "body", Type: sqlbuilder.Text},
+				sqlbuilder.Column{Name: "public", Type: sqlbuilder.Boolean, NotNull: true, Default: true},
+				sqlbuilder.Column{Name: "created_at", Type: sqlbuilder.Timestamp},
+			).
+			PrimaryKey("id").
+			String(),
+	)
+
+	assert.Equal(
+		`CREATE TABLE "tags" ("book_id" INTEGER NOT NULL, "tag" VARCHAR(32) NOT NULL, PRIMARY KEY ("book_id", "tag"))`,
+		b.CreateTable("tags").
+			Columns(
+				sqlbuilder.Column{Name: "book_id", Type: sqlbuilder.BigInt, NotNull: true},
+				sqlbuilder.Column{Name: "tag", Type: sqlbuilder.String, Size: 32, NotNull: true},
+			).
+			PrimaryKey("book_id", "tag").
+			String(),
+	)
+
+	assert.Equal(
+		`CREATE UNIQUE INDEX "books_title_idx" ON "books" ("title")`,
+		b.CreateIndex("books_title_idx").Unique().On("books", "title").String(),
+	)
+
+	assert.Equal(
+		`ALTER TABLE "books" ADD COLUMN "isbn" VARCHAR(13); ALTER TABLE "books" DROP COLUMN "body"`,
+		b.AlterTable("books").AddColumn(sqlbuilder.Column{Name: "isbn", Type: sqlbuilder.String, Size: 13}).DropColumn("body").String(),
+	)
 }
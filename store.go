@@ -0,0 +1,173 @@
+//go:build go1.18
+// +build go1.18
+
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+// Store provides typed access to the items of a Collection, so results are
+// read into values of type T instead of interface{} destinations:
+//
+//	type Book struct {
+//		ID    uint   `db:"id,omitempty"`
+//		Title string `db:"title"`
+//	}
+//
+//	books := db.NewStore[Book](sess.Collection("books"))
+//	book, err := books.Get(db.Cond{"id": 1})
+//	all, err := books.Find(db.Cond{"title LIKE": "%Go%"}).OrderBy("title").All()
+//
+// Store is a thin layer on top of Collection and Result, both remain
+// available for anything Store does not cover.
+type Store[T any] struct {
+	col Collection
+}
+
+// NewStore returns a Store of items of type T kept in the given collection.
+func NewStore[T any](col Collection) *Store[T] {
+	return &Store[T]{col: col}
+}
+
+// Collection returns the underlying collection.
+func (s *Store[T]) Collection() Collection {
+	return s.col
+}
+
+// Name returns the name of the underlying collection.
+func (s *Store[T]) Name() string {
+	return s.col.Name()
+}
+
+// Insert inserts the given item and returns its primary key.
+func (s *Store[T]) Insert(item T) (interface{}, error) {
+	return s.col.Insert(item)
+}
+
+// InsertReturning inserts the given item and updates it with the values
+// stored in the collection, see Collection.InsertReturning.
+func (s *Store[T]) InsertReturning(item *T) error {
+	return s.col.InsertReturning(item)
+}
+
+// UpdateReturning updates the given item and refreshes it with the values
+// stored in the collection, see Collection.UpdateReturning.
+func (s *Store[T]) UpdateReturning(item *T) error {
+	return s.col.UpdateReturning(item)
+}
+
+// Find returns the items that match the given conditions, see
+// Collection.Find.
+func (s *Store[T]) Find(conds ...interface{}) *StoreResult[T] {
+	return &StoreResult[T]{res: s.col.Find(conds...)}
+}
+
+// Get returns the first item that matches the given conditions, or
+// ErrNoMoreRows if there is none.
+func (s *Store[T]) Get(conds ...interface{}) (T, error) {
+	return s.Find(conds...).One()
+}
+
+// StoreResult is a typed Result, see Store.
+type StoreResult[T any] struct {
+	res Result
+}
+
+// Result returns the underlying result set.
+func (r *StoreResult[T]) Result() Result {
+	return r.res
+}
+
+// Where discards all the previously set conditions and sets new ones, see
+// Result.Where.
+func (r *StoreResult[T]) Where(conds ...interface{}) *StoreResult[T] {
+	return &StoreResult[T]{res: r.res.Where(conds...)}
+}
+
+// And adds more conditions to the result set, see Result.And.
+func (r *StoreResult[T]) And(conds ...interface{}) *StoreResult[T] {
+	return &StoreResult[T]{res: r.res.And(conds...)}
+}
+
+// OrderBy sets the order of the results, see Result.OrderBy.
+func (r *StoreResult[T]) OrderBy(fields ...interface{}) *StoreResult[T] {
+	return &StoreResult[T]{res: r.res.OrderBy(fields...)}
+}
+
+// Limit sets the maximum number of results, see Result.Limit.
+func (r *StoreResult[T]) Limit(n int) *StoreResult[T] {
+	return &StoreResult[T]{res: r.res.Limit(n)}
+}
+
+// Offset sets how many results are skipped, see Result.Offset.
+func (r *StoreResult[T]) Offset(n int) *StoreResult[T] {
+	return &StoreResult[T]{res: r.res.Offset(n)}
+}
+
+// One returns the first item of the result set, or ErrNoMoreRows if it's
+// empty.
+func (r *StoreResult[T]) One() (T, error) {
+	var item T
+	if err := r.res.One(&item); err != nil {
+		var zero T
+		return zero, err
+	}
+	return item, nil
+}
+
+// All returns all the items of the result set.
+func (r *StoreResult[T]) All() ([]T, error) {
+	items := []T{}
+	if err := r.res.All(&items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Each calls fn with every item of the result set, one at a time, and stops
+// at the first error fn returns.
+func (r *StoreResult[T]) Each(fn func(T) error) error {
+	defer r.res.Close()
+	for {
+		var item T
+		if !r.res.Next(&item) {
+			return r.res.Err()
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+}
+
+// Count returns the number of items in the result set.
+func (r *StoreResult[T]) Count() (uint64, error) {
+	return r.res.Count()
+}
+
+// Update updates all the items in the result set, see Result.Update.
+func (r *StoreResult[T]) Update(values interface{}) error {
+	return r.res.Update(values)
+}
+
+// Delete deletes all the items in the result set.
+func (r *StoreResult[T]) Delete() error {
+	return r.res.Delete()
+}
@@ -0,0 +1,101 @@
+//go:build go1.18
+// +build go1.18
+
+package db
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type storeTestBook struct {
+	ID    uint   `db:"id"`
+	Title string `db:"title"`
+}
+
+// storeTestResult implements the parts of Result StoreResult relies on.
+type storeTestResult struct {
+	Result
+	books []storeTestBook
+	conds []interface{}
+	i     int
+}
+
+func (r *storeTestResult) Where(conds ...interface{}) Result {
+	return &storeTestResult{books: r.books, conds: conds}
+}
+
+func (r *storeTestResult) One(dst interface{}) error {
+	if len(r.books) == 0 {
+		return ErrNoMoreRows
+	}
+	*dst.(*storeTestBook) = r.books[0]
+	return nil
+}
+
+func (r *storeTestResult) All(dst interface{}) error {
+	*dst.(*[]storeTestBook) = append([]storeTestBook(nil), r.books...)
+	return nil
+}
+
+func (r *storeTestResult) Next(dst interface{}) bool {
+	if r.i >= len(r.books) {
+		return false
+	}
+	*dst.(*storeTestBook) = r.books[r.i]
+	r.i++
+	return true
+}
+
+func (r *storeTestResult) Err() error {
+	return nil
+}
+
+func (r *storeTestResult) Close() error {
+	return nil
+}
+
+func TestStoreResult(t *testing.T) {
+	books := []storeTestBook{{1, "Hamlet"}, {2, "Ulysses"}}
+	res := &StoreResult[storeTestBook]{res: &storeTestResult{books: books}}
+
+	book, err := res.One()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book != books[0] {
+		t.Fatalf("Got: %v, Expecting: %v", book, books[0])
+	}
+
+	all, err := res.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(all, books) {
+		t.Fatalf("Got: %v, Expecting: %v", all, books)
+	}
+
+	filtered := res.Where(Cond{"id": 2})
+	if conds := filtered.Result().(*storeTestResult).conds; len(conds) != 1 {
+		t.Fatal("Expecting conditions to be passed to the underlying result")
+	}
+
+	titles := []string{}
+	errStop := errors.New("stop")
+	err = res.Each(func(b storeTestBook) error {
+		titles = append(titles, b.Title)
+		if len(titles) == 1 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || len(titles) != 1 {
+		t.Fatalf("Expecting Each to stop at the first error, got %v", err)
+	}
+
+	empty := &StoreResult[storeTestBook]{res: &storeTestResult{}}
+	if _, err := empty.One(); err != ErrNoMoreRows {
+		t.Fatalf("Got: %v, Expecting: %v", err, ErrNoMoreRows)
+	}
+}